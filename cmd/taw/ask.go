@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/donghojung/taw/internal/icon"
+	"github.com/donghojung/taw/internal/task"
+)
+
+var askCmd = &cobra.Command{
+	Use:   "ask <question>",
+	Short: "Ask an agent a question about the codebase",
+	Long: `Queue a read-only task that asks an agent a question about the codebase. The
+agent works in the project directory without a branch or worktree and commits
+nothing. When it's done, its answer is kept in .taw/answers/<task>.md and the
+task is cleaned up; read the answer with 'taw show <task>'.
+
+Any task tagged #ask is an ask task, e.g. taw run-one "... #ask" answers
+one in the terminal.`,
+	Example: `  taw ask "How does the queue decide when to start a task?"
+  taw ask "Which endpoints don't check the session?"`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runAsk,
+}
+
+func runAsk(cmd *cobra.Command, args []string) error {
+	question := strings.TrimSpace(strings.Join(args, " "))
+	if question == "" {
+		return errors.New("the question is empty")
+	}
+
+	application, _, err := loadProject(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	running, err := queueTasks(application, task.AskContent(question))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Question queued; 'taw status' shows its task, 'taw show <task>' the answer\n", icon.Success)
+	if !running {
+		fmt.Println("Run 'taw' to start it")
+	}
+	return nil
+}
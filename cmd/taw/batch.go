@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/donghojung/taw/internal/config"
+	"github.com/donghojung/taw/internal/icon"
+	"github.com/donghojung/taw/internal/logging"
+	"github.com/donghojung/taw/internal/task"
+	"github.com/donghojung/taw/internal/tmux"
+)
+
+var (
+	batchFile         string
+	batchOnComplete   string
+	batchReportFormat string
+)
+
+var batchCmd = &cobra.Command{
+	Use:   "batch --file <tasks.md>",
+	Short: "Queue a file of tasks to run unattended, e.g. overnight",
+	Long: `Queue one task per "## " section of a markdown file (the heading is the
+first line of the task) and start the project's session detached if it isn't
+running. The tasks complete with --on-complete (auto-pr by default) instead
+of on_complete, so nothing waits for a confirmation.
+
+The tasks start as the queue allows: max_parallel_tasks, rate limits, the
+daily budget and queue.drain (e.g. drain: hours to only run them at night).
+Once none is queued or running, the daemon saves the batch report to
+.taw/batches/<id>.md and tells you; 'taw batch report' shows it, or the
+progress so far.`,
+	Example: `  taw batch --file tasks.md
+  taw batch --file tasks.md --on-complete auto-commit
+  taw batch report`,
+	Args: cobra.NoArgs,
+	RunE: runBatch,
+}
+
+var batchReportCmd = &cobra.Command{
+	Use:   "report [id]",
+	Short: "Show what succeeded, failed or needs review in a batch",
+	Long: `Show the report of a batch (the latest by default): the tasks that succeeded,
+failed (verification or push failures), need review (waiting for input), are
+still running, or haven't started.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runBatchReport,
+}
+
+func init() {
+	batchCmd.Flags().StringVarP(&batchFile, "file", "f", "", "Markdown file with one task per '## ' section")
+	batchCmd.Flags().StringVar(&batchOnComplete, "on-complete", string(config.OnCompleteAutoPR), "How the tasks complete: auto-commit or auto-pr")
+	batchReportCmd.Flags().StringVar(&batchReportFormat, "format", "md", "Output format: md or json")
+	batchCmd.AddCommand(batchReportCmd)
+}
+
+func runBatch(cmd *cobra.Command, args []string) error {
+	if batchFile == "" {
+		return fmt.Errorf("--file is required")
+	}
+	onComplete := config.OnComplete(batchOnComplete)
+	if onComplete != config.OnCompleteAutoCommit && onComplete != config.OnCompleteAutoPR {
+		return fmt.Errorf("invalid --on-complete %q: must be auto-commit or auto-pr", batchOnComplete)
+	}
+	policy, err := config.LoadPolicy()
+	if err != nil {
+		return err
+	}
+	if err := policy.Check("on_complete", batchOnComplete); err != nil {
+		return fmt.Errorf("--on-complete: %w", err)
+	}
+
+	data, err := os.ReadFile(batchFile)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", batchFile, err)
+	}
+	sections := task.ParseBatchFile(string(data))
+	if len(sections) == 0 {
+		return fmt.Errorf("no tasks in %s: start each task with a '## ' heading", batchFile)
+	}
+
+	application, mgr, err := loadProject(cmd.Context())
+	if err != nil {
+		return err
+	}
+	if !application.HasConfig() {
+		return fmt.Errorf("the project isn't set up yet: run 'taw' once first")
+	}
+
+	batch, contents := task.NewBatch(filepath.Base(batchFile), sections, onComplete)
+	if err := mgr.SaveBatch(batch); err != nil {
+		return err
+	}
+	queueMgr := task.NewQueueManager(application.QueueDir)
+	for _, content := range contents {
+		if err := queueMgr.Add(content); err != nil {
+			return fmt.Errorf("failed to queue task: %w", err)
+		}
+	}
+	fmt.Printf("%s Queued %d task(s) as batch %s (on complete: %s)\n", icon.Success, len(contents), batch.ID, onComplete)
+
+	// The session starts the queue itself
+	tm := tmux.New(application.SessionName)
+	if tm.HasSession(application.SessionName) {
+		if err := spawnInternal(application.SessionName, "process-queue"); err != nil {
+			return err
+		}
+	} else {
+		logger, err := logging.New(application.GetLogPath(), application.Debug)
+		if err != nil {
+			return fmt.Errorf("failed to setup logging: %w", err)
+		}
+		defer logger.Close()
+		logger.SetScript("taw batch")
+		logging.SetGlobal(logger)
+
+		assetsDir, err := bootstrapAssets(application)
+		if err != nil {
+			return err
+		}
+		application.SetAssetsDir(assetsDir)
+
+		logging.Log("=== Session start (batch %s) ===", batch.ID)
+		if err := createSession(application, tm); err != nil {
+			return err
+		}
+		fmt.Println("Started the session detached; run 'taw' to attach")
+	}
+
+	if hold, err := queueHoldReason(cmd.Context(), mgr, application.SessionName); err == nil && hold != "" {
+		fmt.Printf("%s Held: %s\n", icon.Warning, hold)
+	}
+	fmt.Println("Run 'taw batch report' for the results")
+	return nil
+}
+
+func runBatchReport(cmd *cobra.Command, args []string) error {
+	application, mgr, err := loadProject(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	var batch *task.Batch
+	if len(args) == 1 {
+		if batch, err = mgr.LoadBatch(args[0]); err != nil {
+			return err
+		}
+	} else {
+		batches, err := mgr.ListBatches()
+		if err != nil {
+			return err
+		}
+		if len(batches) == 0 {
+			return fmt.Errorf("no batches yet: queue one with 'taw batch --file <tasks.md>'")
+		}
+		batch = batches[len(batches)-1]
+	}
+
+	queue, err := task.NewQueueManager(application.QueueDir).List()
+	if err != nil {
+		return err
+	}
+	report, err := mgr.BatchReport(batch, queue)
+	if err != nil {
+		return err
+	}
+
+	switch batchReportFormat {
+	case "md":
+		fmt.Print(report.Markdown())
+	case "json":
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode report: %w", err)
+		}
+		fmt.Println(string(data))
+	default:
+		return fmt.Errorf("unknown format: %s (use md or json)", batchReportFormat)
+	}
+	return nil
+}
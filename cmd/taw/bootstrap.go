@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/donghojung/taw/internal/app"
+	"github.com/donghojung/taw/internal/constants"
+	"github.com/donghojung/taw/internal/embed"
+	"github.com/donghojung/taw/internal/icon"
+	"github.com/donghojung/taw/internal/logging"
+)
+
+// bootstrapAssets returns the directory holding the prompts and .claude
+// assets, installing the embedded ones on first run. Lookup order:
+//  1. .taw/assets - installed for this project only
+//  2. $TAW_HOME/_taw - legacy install tree
+//  3. $XDG_DATA_HOME/taw (~/.local/share/taw) - shared by all projects
+//
+// Installed copies made by an older binary are refreshed.
+func bootstrapAssets(app *app.App) (string, error) {
+	projectDir := filepath.Join(app.TawDir, constants.AssetsDirName)
+	if dirExists(projectDir) {
+		return projectDir, refreshAssets(projectDir)
+	}
+
+	legacyDir := filepath.Join(app.TawHome, "_taw")
+	if dirExists(filepath.Join(legacyDir, "claude")) {
+		return legacyDir, nil
+	}
+
+	dataDir, err := getDataDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get data directory: %w", err)
+	}
+	if dirExists(dataDir) {
+		return dataDir, refreshAssets(dataDir)
+	}
+
+	// First run: ask where to install
+	fmt.Println("\n" + icon.Decoration("📦") + "TAW needs to install its prompts and Claude commands.")
+	fmt.Println("Install Location:")
+	fmt.Printf("  1. %s (Recommended) - Shared by all projects\n", dataDir)
+	fmt.Printf("  2. %s - This project only\n", filepath.Join(constants.TawDirName, constants.AssetsDirName))
+	fmt.Print("\nSelect [1-2, default: 1]: ")
+
+	var choice string
+	fmt.Scanln(&choice)
+
+	dir := dataDir
+	if choice == "2" {
+		dir = projectDir
+	}
+
+	if err := embed.Install(dir); err != nil {
+		return "", fmt.Errorf("failed to install assets: %w", err)
+	}
+	fmt.Printf("\n%s Assets installed to %s\n", icon.Success, dir)
+	logging.Log("Installed assets to %s", dir)
+
+	return dir, nil
+}
+
+// refreshAssets reinstalls the embedded assets if dir holds an older version.
+func refreshAssets(dir string) error {
+	if embed.Installed(dir) {
+		return nil
+	}
+
+	if err := embed.Install(dir); err != nil {
+		return fmt.Errorf("failed to update assets: %w", err)
+	}
+	logging.Log("Updated assets in %s", dir)
+	return nil
+}
+
+// getDataDir returns the shared TAW data directory.
+func getDataDir() (string, error) {
+	if dataHome := os.Getenv("XDG_DATA_HOME"); dataHome != "" {
+		return filepath.Join(dataHome, "taw"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "taw"), nil
+}
+
+// dirExists returns true if path is an existing directory.
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/donghojung/taw/internal/icon"
+	"github.com/donghojung/taw/internal/task"
+)
+
+var budgetCmd = &cobra.Command{
+	Use:   "budget",
+	Short: "Show the estimated cost of tasks against their budgets",
+	Long: `Show today's estimated Claude cost and the cost of each task against the
+budgets set with budget.per_task_usd and budget.daily_usd. Costs are estimated
+from the Claude Code transcripts in ~/.claude/projects, at API prices. Tasks
+working in the project directory (main work mode, non-git projects without
+snapshots) share its transcripts, so they have no budget of their own and
+count towards today's spend together.
+
+With budget.on_exceed: pause, new tasks are queued while today is over budget;
+with stop, the agents over budget are stopped as well.`,
+	Example: `  taw budget
+  taw budget task fix-login-test 10
+  taw budget today 50`,
+	Args: cobra.NoArgs,
+	RunE: runBudget,
+}
+
+var budgetTaskCmd = &cobra.Command{
+	Use:   "task <task> <usd>",
+	Short: "Override the budget of a task (0 for no limit)",
+	Long: `Override budget.per_task_usd for one task. A stopped agent stays stopped;
+restart it from the dashboard once its budget is raised.`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeTaskName(0),
+	RunE:              runBudgetTask,
+}
+
+var budgetTodayCmd = &cobra.Command{
+	Use:   "today <usd>",
+	Short: "Override today's budget",
+	Long: `Override budget.daily_usd until midnight. Queued tasks start once today's
+spend is under the new budget.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBudgetToday,
+}
+
+func init() {
+	budgetCmd.AddCommand(budgetTaskCmd)
+	budgetCmd.AddCommand(budgetTodayCmd)
+}
+
+func runBudget(cmd *cobra.Command, args []string) error {
+	_, mgr, err := loadProject(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	tasks, err := mgr.ListTasks()
+	if err != nil {
+		return err
+	}
+
+	// The daemon records costs every minute; show the current ones
+	spend := mgr.LoadSpend(time.Now())
+	var lines []string
+	for _, t := range tasks {
+		total, today, err := mgr.TaskCost(t)
+		var line string
+		switch {
+		case errors.Is(err, task.ErrSharedCost):
+			line = fmt.Sprintf("  %-30s %s", t.Name, "shared with the project directory")
+		case err != nil:
+			return err
+		default:
+			spend.Tasks[t.Name] = today
+			line = fmt.Sprintf("  %-30s %s", t.Name, formatBudget(total, mgr.TaskBudget(t)))
+		}
+		if status := t.LoadStatus(); status != "" {
+			line += "  " + string(status)
+		}
+		lines = append(lines, line)
+	}
+
+	fmt.Printf("Today: %s (on exceed: %s)\n", formatBudget(spend.Total(), mgr.DailyBudget()), mgr.BudgetPolicy())
+	if mgr.BudgetPaused() {
+		fmt.Printf("%s New tasks are queued until today's spend is under budget\n", icon.Warning)
+	}
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+func runBudgetTask(cmd *cobra.Command, args []string) error {
+	_, mgr, err := loadProject(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	t, err := mgr.GetTask(args[0])
+	if err != nil {
+		return err
+	}
+	usd, err := parseAmount(args[1])
+	if err != nil {
+		return err
+	}
+	if err := mgr.SetTaskBudget(t, usd); err != nil {
+		return fmt.Errorf("failed to save budget: %w", err)
+	}
+	if usd == 0 {
+		fmt.Printf("%s %s: no budget\n", icon.Success, t.Name)
+		return nil
+	}
+	fmt.Printf("%s %s: budget $%.2f\n", icon.Success, t.Name, usd)
+	return nil
+}
+
+func runBudgetToday(cmd *cobra.Command, args []string) error {
+	_, mgr, err := loadProject(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	usd, err := parseAmount(args[0])
+	if err != nil {
+		return err
+	}
+	if usd == 0 {
+		return fmt.Errorf("today's budget must be greater than 0; set budget.daily_usd to \"\" for no limit")
+	}
+	if err := mgr.SetDailyBudget(usd); err != nil {
+		return fmt.Errorf("failed to save budget: %w", err)
+	}
+	fmt.Printf("%s Today's budget: $%.2f\n", icon.Success, usd)
+	return nil
+}
+
+// parseAmount parses an amount in USD such as 5, 2.50 or $2.50.
+func parseAmount(value string) (float64, error) {
+	f, err := strconv.ParseFloat(strings.TrimPrefix(value, "$"), 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("invalid amount %q: must be in USD such as 5 or 2.50", value)
+	}
+	return f, nil
+}
+
+// formatBudget formats a cost with its budget, e.g. "$1.20 of $5.00", or the
+// cost alone when there's no budget.
+func formatBudget(cost, budget float64) string {
+	if budget <= 0 {
+		return fmt.Sprintf("$%.2f", cost)
+	}
+	return fmt.Sprintf("$%.2f of $%.2f", cost, budget)
+}
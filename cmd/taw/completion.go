@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/donghojung/taw/internal/app"
+	"github.com/donghojung/taw/internal/task"
+)
+
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish]",
+	Short: "Generate the shell completion script",
+	Long: `Generate the completion script for taw. Task names of the project in the
+current directory are completed too.
+
+Bash (needs the bash-completion package):
+  source <(taw completion bash)
+  # or permanently:
+  taw completion bash > ~/.local/share/bash-completion/completions/taw
+
+Zsh (needs compinit):
+  taw completion zsh > "${fpath[1]}/_taw"
+
+Fish:
+  taw completion fish > ~/.config/fish/completions/taw.fish
+
+Start a new shell for the completion to take effect.`,
+	Example:               "  taw completion zsh > \"${fpath[1]}/_taw\"",
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs:             []string{"bash", "zsh", "fish"},
+	DisableFlagsInUseLine: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		switch args[0] {
+		case "bash":
+			return rootCmd.GenBashCompletionV2(out, true)
+		case "zsh":
+			return rootCmd.GenZshCompletion(out)
+		case "fish":
+			return rootCmd.GenFishCompletion(out, true)
+		}
+		return fmt.Errorf("unsupported shell: %s", args[0])
+	},
+}
+
+// completeTaskName returns a completion function offering the task names of
+// the project in the current directory for the argument at position pos, or
+// for every argument if pos is negative.
+func completeTaskName(pos int) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if pos >= 0 && len(args) != pos {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		application, err := app.New(cwd)
+		if err != nil || !application.IsInitialized() {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		// Listing tasks only reads .taw/agents, so no config or git is needed
+		mgr := task.NewManager(application.AgentsDir, application.ProjectDir, application.TawDir, false, nil)
+		tasks, err := mgr.ListTasks()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		var names []string
+		for _, t := range tasks {
+			if strings.HasPrefix(t.Name, toComplete) {
+				names = append(names, fmt.Sprintf("%s\t%s", t.Name, t.Status))
+			}
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	}
+}
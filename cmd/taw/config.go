@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/donghojung/taw/internal/app"
+	"github.com/donghojung/taw/internal/config"
+	"github.com/donghojung/taw/internal/constants"
+	"github.com/donghojung/taw/internal/icon"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show and change project settings",
+	Long: `Show and change the settings in .taw/config without running the setup
+wizard again. Nested settings are named with dots, e.g. limits.memory,
+timeouts.git or verify.steps.lint.command.
+
+Running tasks pick up most changes when their next operation starts; restart
+the session for session-wide settings (windows, source_tmux_conf, ascii).`,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get [key]",
+	Short: "Print a setting, or all settings",
+	Example: `  taw config get
+  taw config get on_complete`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeConfigKey,
+	RunE:              runConfigGet,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Change a setting",
+	Long: `Validate and change a setting. An empty value ("") clears lists and optional
+settings such as sandbox_image or limits.memory.
+
+The config file is rewritten from its template, so comments of your own are
+not kept; use 'taw config edit' to keep them.`,
+	Example: `  taw config set on_complete auto-pr
+  taw config set verify.command "go test ./..."
+  taw config set limits.memory ""`,
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: completeConfigKey,
+	RunE:              runConfigSet,
+}
+
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Edit the config file in $EDITOR",
+	Long: `Open .taw/config in $EDITOR (vim by default). When the editor exits, the
+file is checked for unknown settings and invalid values; it's only saved
+once it's valid, or kept unchanged if you give up.`,
+	Args: cobra.NoArgs,
+	RunE: runConfigEdit,
+}
+
+func init() {
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configEditCmd)
+}
+
+// loadConfigProject returns the project of the current directory with its
+// config. Unlike loadProject, an unreadable config is an error.
+func loadConfigProject(cmd *cobra.Command) (*app.App, *config.Config, error) {
+	application, _, err := loadProject(cmd.Context())
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg, err := config.Load(application.TawDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	return application, cfg, nil
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	_, cfg, err := loadConfigProject(cmd)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 1 {
+		value, err := cfg.Get(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Println(value)
+		return nil
+	}
+
+	// Load already refused an invalid policy
+	policy, _ := config.LoadPolicy()
+	for _, s := range cfg.Settings() {
+		if policy.IsLocked(s.Key) {
+			fmt.Printf("%s: %s (locked by %s)\n", s.Key, s.Value, policy.Path)
+			continue
+		}
+		fmt.Printf("%s: %s\n", s.Key, s.Value)
+	}
+	return nil
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	application, cfg, err := loadConfigProject(cmd)
+	if err != nil {
+		return err
+	}
+
+	key, value := args[0], strings.Join(args[1:], " ")
+	if err := cfg.Set(key, value); err != nil {
+		return err
+	}
+	if err := cfg.Save(application.TawDir); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	refreshWindowNames(application)
+
+	value, _ = cfg.Get(key)
+	fmt.Printf("%s %s: %s\n", icon.Success, key, value)
+	return nil
+}
+
+func runConfigEdit(cmd *cobra.Command, args []string) error {
+	application, cfg, err := loadConfigProject(cmd)
+	if err != nil {
+		return err
+	}
+
+	configPath := filepath.Join(application.TawDir, constants.ConfigFileName)
+	original, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		// Start from the defaults, with their comments
+		if err := cfg.Save(application.TawDir); err != nil {
+			return fmt.Errorf("failed to create config: %w", err)
+		}
+		original, err = os.ReadFile(configPath)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	// A config written before the organization policy is saved with the
+	// enforced values first, so that only the user's changes are checked
+	if policy, _ := config.LoadPolicy(); policy != nil && config.Check(bytes.NewReader(original)) != nil {
+		if err := cfg.Save(application.TawDir); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+		if original, err = os.ReadFile(configPath); err != nil {
+			return fmt.Errorf("failed to read config: %w", err)
+		}
+		fmt.Printf("%s Config updated with the settings enforced by %s\n", icon.Warning, policy.Path)
+	}
+
+	// Edit a copy, so an invalid config never reaches running tasks
+	tmpFile, err := os.CreateTemp("", "taw-config-*.yaml")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+	_, err = tmpFile.Write(original)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		if err := runEditor(tmpPath); err != nil {
+			return fmt.Errorf("editor failed: %w", err)
+		}
+		edited, err := os.ReadFile(tmpPath)
+		if err != nil {
+			return err
+		}
+
+		checkErr := config.Check(bytes.NewReader(edited))
+		if checkErr == nil {
+			if bytes.Equal(edited, original) {
+				fmt.Println("No changes")
+				return nil
+			}
+			if err := os.WriteFile(configPath, edited, 0644); err != nil {
+				return fmt.Errorf("failed to save config: %w", err)
+			}
+			refreshWindowNames(application)
+			fmt.Printf("%s Config saved\n", icon.Success)
+			return nil
+		}
+
+		fmt.Printf("%s Invalid config:\n", icon.Failure)
+		for _, line := range strings.Split(checkErr.Error(), "\n") {
+			fmt.Printf("  %s\n", line)
+		}
+		fmt.Print("Edit again? [Y/n]: ")
+		answer, err := reader.ReadString('\n')
+		if err != nil || strings.HasPrefix(strings.ToLower(strings.TrimSpace(answer)), "n") {
+			return fmt.Errorf("config left unchanged")
+		}
+	}
+}
+
+// runEditor opens path in $EDITOR, or vim.
+func runEditor(path string) error {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vim"
+	}
+
+	// $EDITOR may carry arguments, e.g. "code --wait"
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// completeConfigKey completes the keys of the fixed settings.
+func completeConfigKey(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) != 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var keys []string
+	for _, key := range config.Keys() {
+		if strings.HasPrefix(key, toComplete) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, cobra.ShellCompDirectiveNoFileComp
+}
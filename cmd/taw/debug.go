@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/donghojung/taw/internal/app"
+	"github.com/donghojung/taw/internal/icon"
+	"github.com/donghojung/taw/internal/task"
+	"github.com/donghojung/taw/internal/tmux"
+)
+
+const (
+	// debugLogTail is how much of the end of the log a debug bundle keeps.
+	debugLogTail = 1 << 20
+	// debugTranscriptLines is how many lines of each agent pane are captured.
+	debugTranscriptLines = 2000
+	// debugCommandTimeout bounds each diagnostic command.
+	debugCommandTimeout = 10 * time.Second
+)
+
+var debugBundleCmd = &cobra.Command{
+	Use:   "debug-bundle [file]",
+	Short: "Collect diagnostics into an archive for bug reports",
+	Long: `Collect what's needed to investigate a bug into a tar.gz archive to attach
+to a GitHub issue: the project state of 'taw export' (config, task metadata,
+the end of the log), recent agent output, git worktrees, tmux windows and
+panes, and the versions of taw, tmux, git and claude.
+
+Secrets (the env file's values and redact patterns) are masked in every
+file. Review the archive before sharing it; agent output may contain code.`,
+	Example: `  taw debug-bundle                  # taw-debug-<time>.tar.gz
+  taw debug-bundle bug-123.tar.gz`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runDebugBundle,
+}
+
+func runDebugBundle(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	application, mgr, err := loadProject(ctx)
+	if err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("taw-debug-%s.tar.gz", time.Now().Format("20060102-150405"))
+	if len(args) > 0 {
+		path = args[0]
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	files, err := application.Export(f, app.ExportOptions{
+		TawVersion: Version,
+		Redact:     true,
+		LogTail:    debugLogTail,
+		Extra:      collectDiagnostics(ctx, application, mgr),
+	})
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return err
+	}
+
+	fmt.Printf("%s Wrote %d files to %s\n", icon.Success, files, path)
+	fmt.Println("Secrets are masked; review the archive before attaching it to an issue")
+	return nil
+}
+
+// collectDiagnostics returns the diagnostic files of a debug bundle. What
+// can't be collected is noted in the files instead of failing the bundle.
+func collectDiagnostics(ctx context.Context, application *app.App, mgr *task.Manager) map[string][]byte {
+	extra := make(map[string][]byte)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "taw: %s\n", Version)
+	fmt.Fprintf(&sb, "go: %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&sb, "tmux: %s\n", tmux.Version())
+	fmt.Fprintf(&sb, "git: %s\n", debugCommand(ctx, "", "git", "--version"))
+	fmt.Fprintf(&sb, "claude: %s\n", debugCommand(ctx, "", "claude", "--version"))
+	fmt.Fprintf(&sb, "shell: %s\n", os.Getenv("SHELL"))
+	fmt.Fprintf(&sb, "term: %s\n", os.Getenv("TERM"))
+	fmt.Fprintf(&sb, "git repo: %t\n", application.IsGitRepo)
+	extra["version.txt"] = []byte(sb.String())
+
+	if application.IsGitRepo {
+		out := debugCommand(ctx, application.ProjectDir, "git", "worktree", "list", "--porcelain")
+		out += "\n\n" + debugCommand(ctx, application.ProjectDir, "git", "status", "--short", "--branch")
+		extra["git-worktrees.txt"] = []byte(out + "\n")
+	}
+
+	tm := tmux.New(application.SessionName)
+	if !tm.HasSession(application.SessionName) {
+		extra["tmux.txt"] = []byte("No taw session is running\n")
+		return extra
+	}
+	sb.Reset()
+	for _, query := range [][]string{
+		{"list-windows", "-a", "-F", "#{window_id} #{window_index} #{window_name} active=#{window_active} panes=#{window_panes}"},
+		{"list-panes", "-a", "-F", "#{pane_id} #{window_id}.#{pane_index} #{pane_current_command} dead=#{pane_dead} #{pane_width}x#{pane_height}"},
+	} {
+		out, err := tm.RunWithOutput(query...)
+		if err != nil {
+			out = err.Error()
+		}
+		fmt.Fprintf(&sb, "$ tmux %s\n%s\n\n", query[0], out)
+	}
+	extra["tmux.txt"] = []byte(sb.String())
+
+	// Recent output of each open agent
+	tasks, err := mgr.ListTasks()
+	if err != nil {
+		return extra
+	}
+	for _, t := range tasks {
+		windowID, err := t.LoadWindowID()
+		if err != nil || windowID == "" {
+			continue
+		}
+		out, err := tm.CapturePane(windowID+".0", debugTranscriptLines)
+		if err != nil {
+			continue
+		}
+		extra["transcripts/"+t.Name+".txt"] = []byte(out + "\n")
+	}
+	return extra
+}
+
+// debugCommand runs a diagnostic command and returns its output, or the
+// error if it failed.
+func debugCommand(ctx context.Context, dir, name string, args ...string) string {
+	ctx, cancel := context.WithTimeout(ctx, debugCommandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return strings.TrimSpace(fmt.Sprintf("%v: %s", err, out))
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/spf13/cobra"
+
+	"github.com/donghojung/taw/internal/app"
+	"github.com/donghojung/taw/internal/claude"
+	"github.com/donghojung/taw/internal/config"
+	"github.com/donghojung/taw/internal/github"
+	"github.com/donghojung/taw/internal/icon"
+	"github.com/donghojung/taw/internal/tmux"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the tools TAW runs with",
+	Long: `Check that tmux, git, claude and gh are installed and recent enough, and
+print how to fix what isn't. In a TAW project, its config applies: agents in
+a sandbox or with agent.login_shell or agent.shell_env may find a claude
+that isn't on this PATH.
+
+Exits with an error if tasks can't run.`,
+	Args: cobra.NoArgs,
+	RunE: runDoctor,
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	cfg := config.DefaultConfig()
+	if cwd, err := os.Getwd(); err == nil {
+		if application, err := app.New(cwd); err == nil && application.IsInitialized() && application.HasConfig() {
+			if err := application.LoadConfig(); err == nil {
+				cfg = application.Config
+			}
+		}
+	}
+
+	problems := 0
+	check := func(name, version string, err error, hint string) {
+		if err != nil {
+			fmt.Printf("%s %-7s %v\n", icon.Warning, name, err)
+			if hint != "" {
+				fmt.Printf("          %s\n", hint)
+			}
+			return
+		}
+		fmt.Printf("%s %-7s %s\n", icon.Success, name, version)
+	}
+
+	// tmux
+	if version := tmux.Version(); version == "" {
+		problems++
+		check("tmux", "", fmt.Errorf("not installed"), "Install tmux (e.g. brew install tmux or apt install tmux)")
+	} else if warning := tmux.PopupWarning(); warning != "" {
+		check("tmux", "", fmt.Errorf("%s", warning), "")
+	} else {
+		check("tmux", version, nil, "")
+	}
+
+	// git is only needed in git projects
+	if _, err := exec.LookPath("git"); err != nil {
+		check("git", "", fmt.Errorf("not installed, projects run without git"), "Install git for worktrees, branches and pull requests")
+	} else {
+		check("git", debugCommand(ctx, "", "git", "--version"), nil, "")
+	}
+
+	// claude
+	pathFromShell := cfg.Agent.LoginShell || cfg.Agent.ShellEnv != ""
+	err := claude.CheckVersion(cfg.ClaudeMin)
+	switch {
+	case err != nil && (cfg.Sandbox == config.SandboxDocker || pathFromShell):
+		check("claude", "", err, "Agents use the claude of their sandbox or shell, checked when they start")
+	case err != nil:
+		problems++
+		check("claude", "", err, claude.ErrorHint(err))
+	default:
+		text, _, _ := claude.InstalledVersion()
+		check("claude", fmt.Sprintf("%s (%s+ required)", text, claude.MinVersion(cfg.ClaudeMin)), nil, "")
+	}
+
+	// gh is only needed for pull requests, and a token does without it
+	if _, err := exec.LookPath("gh"); err != nil {
+		if github.New().IsInstalled() {
+			check("gh", "not installed, using the GitHub API with a token", nil, "")
+		} else {
+			check("gh", "", fmt.Errorf("not installed"), "Install the GitHub CLI (https://cli.github.com), or set GH_TOKEN, for on_complete: auto-pr")
+		}
+	} else {
+		check("gh", firstLine(debugCommand(ctx, "", "gh", "--version")), nil, "")
+	}
+
+	if problems > 0 {
+		return fmt.Errorf("%d problem(s) keep tasks from running", problems)
+	}
+	return nil
+}
+
+// claudeWarning returns a warning if agents can't start with the installed
+// claude, or "" if they can or use another one: in a sandbox, or on the PATH
+// of agent.login_shell or agent.shell_env.
+func claudeWarning(cfg *config.Config) string {
+	if cfg.Sandbox == config.SandboxDocker || cfg.Agent.LoginShell || cfg.Agent.ShellEnv != "" {
+		return ""
+	}
+	if err := claude.CheckVersion(cfg.ClaudeMin); err != nil {
+		return fmt.Sprintf("%v: %s", err, claude.ErrorHint(err))
+	}
+	return ""
+}
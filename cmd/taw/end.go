@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/donghojung/taw/internal/app"
+	"github.com/donghojung/taw/internal/icon"
+	"github.com/donghojung/taw/internal/logging"
+	"github.com/donghojung/taw/internal/task"
+	"github.com/donghojung/taw/internal/tmux"
+)
+
+var (
+	endSelector     taskSelector
+	mergeSelector   taskSelector
+	retrySelector   taskSelector
+	cleanupSelector taskSelector
+
+	// cleanupForce allows cleaning up tasks whose branch isn't merged.
+	cleanupForce bool
+)
+
+var endCmd = &cobra.Command{
+	Use:   "end [task...]",
+	Short: "End tasks: verify, commit, push, merge per on_complete, clean up",
+	Long: `End tasks like ⌥e does: run the verification gate, commit, push, merge if
+on_complete is auto-merge, clean up and close their windows. A task that needs
+attention (failed verification, push or merge) is kept open.
+
+Name the tasks, or select them with --all-done, --status or --tag. Works
+inside and outside the tmux session, including from a task's own shell pane.`,
+	Example: `  taw end fix-login-test
+  taw end --all-done          # Every task whose agent reported done
+  taw end --all-done --tag ui # ...tagged #ui`,
+	ValidArgsFunction: completeTaskName(-1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runEnd(cmd, args, &endSelector, false)
+	},
+}
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge [task...]",
+	Short: "Merge tasks into the main branch and end them",
+	Long: `Merge tasks into the main branch with the configured merge_strategy,
+whatever on_complete says, then end them like 'taw end'.`,
+	Example: `  taw merge fix-login-test
+  taw merge --all-done`,
+	ValidArgsFunction: completeTaskName(-1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runEnd(cmd, args, &mergeSelector, true)
+	},
+}
+
+var retryCmd = &cobra.Command{
+	Use:   "retry [task...]",
+	Short: "Retry ending tasks whose push or verification failed",
+	Long: `Run the end pipeline again for tasks that were kept open because their push
+or verification failed, e.g. after fixing credentials or the tests.`,
+	Example: `  taw retry --failed
+  taw retry fix-login-test`,
+	ValidArgsFunction: completeTaskName(-1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runEnd(cmd, args, &retrySelector, false)
+	},
+}
+
+var cleanupTasksCmd = &cobra.Command{
+	Use:   "cleanup [task...]",
+	Short: "Remove tasks without ending them",
+	Long: `Remove tasks: close their windows and delete their worktrees, branches and
+agent directories, without committing, pushing or merging. Tasks whose branch
+isn't merged are skipped unless --force is given, as their work would be lost.`,
+	Example: `  taw cleanup --merged
+  taw cleanup --force abandoned-idea`,
+	ValidArgsFunction: completeTaskName(-1),
+	RunE:              runCleanup,
+}
+
+func init() {
+	endSelector.addFlags(endCmd)
+	mergeSelector.addFlags(mergeCmd)
+	retrySelector.addFlags(retryCmd)
+	cleanupSelector.addFlags(cleanupTasksCmd)
+	cleanupTasksCmd.Flags().BoolVar(&cleanupForce, "force", false, "Also remove tasks whose branch isn't merged")
+}
+
+// loadProject returns the project of the current directory and its task
+// manager.
+func loadProject(ctx context.Context) (*app.App, *task.Manager, error) {
+	application, err := getAppFromSession(ctx, "")
+	if err != nil {
+		return nil, nil, fmt.Errorf("no TAW project found in the current directory or its parents")
+	}
+	mgr := task.NewManager(application.AgentsDir, application.ProjectDir, application.TawDir, application.IsGitRepo, application.Config)
+	return application, mgr, nil
+}
+
+// runEnd ends the selected tasks one by one, merging them if merge is set.
+func runEnd(cmd *cobra.Command, names []string, selector *taskSelector, merge bool) error {
+	ctx := cmd.Context()
+
+	application, mgr, err := loadProject(ctx)
+	if err != nil {
+		return err
+	}
+	if merge && !application.IsGitRepo {
+		return fmt.Errorf("merge only works in git repositories")
+	}
+
+	tasks, err := selector.selectTasks(ctx, mgr, names)
+	if err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		fmt.Println("No tasks selected")
+		return nil
+	}
+
+	var failed int
+	for _, t := range tasks {
+		if err := endOne(ctx, application, mgr, t, merge); err != nil {
+			fmt.Printf("%s %s: %v\n", icon.Failure, t.Name, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d tasks failed", failed, len(tasks))
+	}
+	return nil
+}
+
+// endOne ends a task and reports whether it ended or was kept open.
+func endOne(ctx context.Context, application *app.App, mgr *task.Manager, t *task.Task, merge bool) error {
+	windowID, _ := t.LoadWindowID()
+
+	// Ending closes the task window, which would take this process with it
+	if windowID != "" && os.Getenv("TMUX") != "" && os.Getenv("WINDOW_ID") == windowID {
+		taskID, err := t.EnsureID()
+		if err != nil {
+			return err
+		}
+		jobArgs := []string{taskID}
+		if merge {
+			jobArgs = append([]string{"--merge"}, jobArgs...)
+		}
+		if err := spawnInternal(application.SessionName, "end-task", jobArgs...); err != nil {
+			return err
+		}
+		fmt.Printf("Ending %s in the background; the window closes when done\n", t.Name)
+		return nil
+	}
+
+	if err := endTask(ctx, application, mgr, application.SessionName, windowID, t, merge); err != nil {
+		return err
+	}
+
+	reason, kept := keptOpen(mgr, t)
+	if !kept {
+		fmt.Printf("%s %s ended\n", icon.Success, t.Name)
+		return nil
+	}
+	fmt.Printf("%s %s kept open (%s); see .taw/log for details\n", icon.Warning, t.Name, reason)
+	return nil
+}
+
+// keptOpen returns whether endTask kept a task open for the user, as a task
+// still on disk is, and why: its status and, if known, what failed.
+func keptOpen(mgr *task.Manager, t *task.Task) (string, bool) {
+	kept, err := mgr.GetTask(t.Name)
+	if err != nil {
+		return "", false
+	}
+	reason := string(kept.Status)
+	if failure := kept.LoadPushFailure(); failure != "" {
+		reason += ": " + failure
+	} else if result := kept.LoadVerifyResult(); result != nil && !result.Passed() {
+		reason += ": verification " + result.Summary()
+	}
+	return reason, true
+}
+
+// runCleanup removes the selected tasks without ending them.
+func runCleanup(cmd *cobra.Command, names []string) error {
+	ctx := cmd.Context()
+
+	application, mgr, err := loadProject(ctx)
+	if err != nil {
+		return err
+	}
+
+	tasks, err := cleanupSelector.selectTasks(ctx, mgr, names)
+	if err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		fmt.Println("No tasks selected")
+		return nil
+	}
+
+	merged := make(map[string]bool)
+	if application.IsGitRepo {
+		mergedTasks, err := mgr.FindMergedTasks(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to find merged tasks: %w", err)
+		}
+		for _, t := range mergedTasks {
+			merged[t.Name] = true
+		}
+	}
+
+	tm := tmux.New(application.SessionName)
+	inSession := tm.HasSession(application.SessionName)
+	var ownWindow string
+	var errs []error
+	for _, t := range tasks {
+		if application.IsGitRepo && !cleanupForce && !merged[t.Name] {
+			fmt.Printf("%s %s: not merged, skipped (use --force to discard its work)\n", icon.Warning, t.Name)
+			continue
+		}
+
+		if !merged[t.Name] {
+			mgr.RecordCompletion(t, task.OutcomeDiscarded)
+		}
+		windowID, _ := t.LoadWindowID()
+		if err := mgr.CleanupTask(ctx, t); err != nil {
+			fmt.Printf("%s %s: %v\n", icon.Failure, t.Name, err)
+			errs = append(errs, err)
+			continue
+		}
+		fmt.Printf("%s %s removed\n", icon.Success, t.Name)
+
+		// Closing the window this runs in goes last, as it ends this process
+		if windowID != "" && inSession {
+			if windowID == os.Getenv("WINDOW_ID") {
+				ownWindow = windowID
+			} else if err := tm.KillWindow(windowID); err != nil {
+				logging.Debug("Failed to kill window: %v", err)
+			}
+		}
+	}
+	if ownWindow != "" {
+		defer tm.KillWindow(ownWindow)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%d of %d tasks failed: %w", len(errs), len(tasks), errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/donghojung/taw/internal/app"
+	"github.com/donghojung/taw/internal/icon"
+)
+
+var (
+	// exportNoRedact keeps secrets in exported files.
+	exportNoRedact bool
+	// importForce overwrites files that already exist in .taw.
+	importForce bool
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export <file>",
+	Short: "Export the project state to a tar.gz archive",
+	Long: `Export the project state in .taw to a tar.gz archive: config, prompts, task
+metadata, queue, outbox, archive and log. Worktrees, branches, the env file,
+locks and caches are not included.
+
+Secrets (the env file's values and redact patterns) are masked in every file
+unless --no-redact is given, so the archive can be attached to a bug report.
+Use --no-redact to move a project to another machine with 'taw import'.`,
+	Example: `  taw export taw-state.tar.gz               # Support bundle
+  taw export --no-redact taw-state.tar.gz   # Migration`,
+	Args: cobra.ExactArgs(1),
+	RunE: runExport,
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import the project state from an archive made by taw export",
+	Long: `Restore the project state of an archive made by 'taw export' into .taw of
+the current directory. Files already in .taw are kept unless --force is given.
+
+Worktrees and branches are not part of the archive: push task branches
+before exporting, and tasks whose worktree is missing are offered for
+recovery when the session starts.`,
+	Example: `  taw import taw-state.tar.gz
+  taw import --force taw-state.tar.gz`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImport,
+}
+
+func init() {
+	exportCmd.Flags().BoolVar(&exportNoRedact, "no-redact", false, "Keep secrets in exported files")
+	importCmd.Flags().BoolVar(&importForce, "force", false, "Overwrite files that already exist")
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	application, _, err := loadProject(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", args[0], err)
+	}
+	files, err := application.Export(f, app.ExportOptions{
+		TawVersion: Version,
+		Redact:     !exportNoRedact,
+	})
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(args[0])
+		return err
+	}
+
+	fmt.Printf("%s Exported %d files to %s\n", icon.Success, files, args[0])
+	if !exportNoRedact {
+		fmt.Println("Secrets are masked; use --no-redact to export for 'taw import'")
+	}
+	return nil
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+	// .taw may not exist yet on a new machine
+	application, err := app.New(cwd)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", args[0], err)
+	}
+	defer f.Close()
+
+	result, err := application.Import(f, importForce)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s Imported %d files from %s (exported %s)\n", icon.Success, result.Files,
+		result.Manifest.Project, result.Manifest.CreatedAt.Format("2006-01-02 15:04"))
+	if len(result.Skipped) > 0 {
+		fmt.Printf("%s %d existing files kept; use --force to overwrite them\n", icon.Warning, len(result.Skipped))
+	}
+	if result.Manifest.Redacted {
+		fmt.Printf("%s Secrets were masked in this export; check .taw/config\n", icon.Warning)
+	}
+	if result.Manifest.GitRepo && !application.IsGitRepo {
+		fmt.Printf("%s The project was a git repository; clone it here before running taw\n", icon.Warning)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/donghojung/taw/internal/icon"
+	"github.com/donghojung/taw/internal/task"
+	"github.com/donghojung/taw/internal/tmux"
+)
+
+// gcDryRun lists the tasks gc would hibernate without hibernating them.
+var gcDryRun bool
+
+var gcCmd = &cobra.Command{
+	Use:   "gc [task...]",
+	Short: "Reclaim disk space by hibernating done tasks",
+	Long: `Hibernate the tasks that are done but kept open: close their windows and
+remove their worktrees, keeping their branches and agent directories. Opening
+a hibernated task again recreates its worktree from the branch, e.g. with h in
+the dashboard, 'taw end' or 'taw run-one --task'.
+
+Name the tasks, or leave them out to hibernate every done task. Tasks with
+uncommitted changes in their worktree are skipped.`,
+	Example: `  taw gc
+  taw gc --dry-run
+  taw gc fix-login-test`,
+	ValidArgsFunction: completeTaskName(-1),
+	RunE:              runGC,
+}
+
+func init() {
+	gcCmd.Flags().BoolVarP(&gcDryRun, "dry-run", "n", false, "Show what would be hibernated")
+}
+
+func runGC(cmd *cobra.Command, names []string) error {
+	ctx := cmd.Context()
+
+	application, mgr, err := loadProject(ctx)
+	if err != nil {
+		return err
+	}
+	if tm := tmux.New(application.SessionName); tm.HasSession(application.SessionName) {
+		mgr.SetTmuxClient(tm)
+	}
+
+	var tasks []*task.Task
+	if len(names) == 0 {
+		if tasks, err = mgr.HibernateCandidates(); err != nil {
+			return err
+		}
+	}
+	for _, name := range names {
+		t, err := mgr.GetTask(name)
+		if err != nil {
+			return err
+		}
+		tasks = append(tasks, t)
+	}
+	if len(tasks) == 0 {
+		fmt.Println("No done tasks to hibernate")
+		return nil
+	}
+
+	usage, err := mgr.DiskUsage()
+	if err != nil {
+		return err
+	}
+
+	var reclaimed int64
+	for _, t := range tasks {
+		if gcDryRun {
+			fmt.Printf("%s Would hibernate %s (%s)\n", icon.Pending, t.Name, task.FormatSize(usage.Size(t.Name)))
+			continue
+		}
+		size, err := mgr.HibernateTask(ctx, t)
+		if err != nil {
+			fmt.Printf("%s %s: %v\n", icon.Warning, t.Name, err)
+			continue
+		}
+		reclaimed += size
+		fmt.Printf("%s %s hibernated (%s)\n", icon.Success, t.Name, task.FormatSize(size))
+	}
+	if reclaimed > 0 {
+		fmt.Printf("Reclaimed %s\n", task.FormatSize(reclaimed))
+	}
+	return nil
+}
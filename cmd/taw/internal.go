@@ -1,11 +1,17 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -14,10 +20,14 @@ import (
 	"github.com/donghojung/taw/internal/claude"
 	"github.com/donghojung/taw/internal/config"
 	"github.com/donghojung/taw/internal/constants"
+	"github.com/donghojung/taw/internal/daemon"
 	"github.com/donghojung/taw/internal/embed"
 	"github.com/donghojung/taw/internal/git"
+	"github.com/donghojung/taw/internal/icon"
 	"github.com/donghojung/taw/internal/logging"
+	"github.com/donghojung/taw/internal/notify"
 	"github.com/donghojung/taw/internal/task"
+	"github.com/donghojung/taw/internal/telemetry"
 	"github.com/donghojung/taw/internal/tmux"
 	"github.com/donghojung/taw/internal/tui"
 
@@ -40,13 +50,37 @@ func init() {
 	internalCmd.AddCommand(attachCmd)
 	internalCmd.AddCommand(cleanupCmd)
 	internalCmd.AddCommand(processQueueCmd)
+	internalCmd.AddCommand(processOutboxCmd)
 	internalCmd.AddCommand(quickTaskCmd)
+	internalCmd.AddCommand(queueStatusCmd)
 	internalCmd.AddCommand(mergeCompletedCmd)
+	internalCmd.AddCommand(mergeCompletedUICmd)
 	internalCmd.AddCommand(popupShellCmd)
 	internalCmd.AddCommand(toggleLogCmd)
 	internalCmd.AddCommand(logViewerCmd)
+	internalCmd.AddCommand(showDiffCmd)
+	internalCmd.AddCommand(openTaskCmd)
+	internalCmd.AddCommand(diffViewerCmd)
+	internalCmd.AddCommand(dashboardCmd)
+	internalCmd.AddCommand(showWindowCmd)
 	internalCmd.AddCommand(toggleHelpCmd)
 	internalCmd.AddCommand(recoverTaskCmd)
+	internalCmd.AddCommand(pickDraftCmd)
+	internalCmd.AddCommand(verifyTaskCmd)
+	internalCmd.AddCommand(daemonCmd)
+	internalCmd.AddCommand(sendCmd)
+	internalCmd.AddCommand(setStatusCmd)
+	internalCmd.AddCommand(runAgentCmd)
+	internalCmd.AddCommand(agentExitedCmd)
+	internalCmd.AddCommand(paneInfoCmd)
+	internalCmd.AddCommand(replayTaskCmd)
+	internalCmd.AddCommand(quitCmd)
+	internalCmd.AddCommand(quitUICmd)
+	internalCmd.AddCommand(parkTasksCmd)
+
+	endTaskCmd.Flags().BoolVar(&endTaskSkipVerify, "skip-verify", false, "Skip the verify steps")
+	endTaskCmd.Flags().BoolVar(&endTaskMerge, "merge", false, "Merge the task regardless of on_complete")
+	runAgentCmd.Flags().BoolVar(&runAgentContinue, "continue", false, "Continue the agent's conversation")
 }
 
 var toggleNewCmd = &cobra.Command{
@@ -54,17 +88,19 @@ var toggleNewCmd = &cobra.Command{
 	Short: "Toggle the new task window",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
 		sessionName := args[0]
 		tm := tmux.New(sessionName)
 
-		// Check if _ window exists
+		// Check if the new task window exists (found by ID, so renaming it doesn't matter)
 		windows, err := tm.ListWindows()
 		if err != nil {
 			return err
 		}
 
+		newWindowID, _ := tm.GetOption(constants.NewWindowOption)
 		for _, w := range windows {
-			if strings.HasPrefix(w.Name, constants.EmojiNew) {
+			if newWindowID != "" && w.ID == newWindowID {
 				// Window exists, select it and run new-task
 				if err := tm.SelectWindow(w.ID); err != nil {
 					return err
@@ -83,18 +119,21 @@ var toggleNewCmd = &cobra.Command{
 		}
 
 		// Create new window without command (keeps shell open)
-		app, err := getAppFromSession(sessionName)
+		app, err := getAppFromSession(ctx, sessionName)
 		if err != nil {
 			return err
 		}
 
 		windowID, err := tm.NewWindow(tmux.WindowOpts{
-			Name:     constants.NewWindowName,
+			Name:     icon.New.Label(constants.NewWindowName),
 			StartDir: app.ProjectDir,
 		})
 		if err != nil {
 			return err
 		}
+		if err := tm.SetOption(constants.NewWindowOption, windowID, true); err != nil {
+			logging.Debug("Failed to save new window ID: %v", err)
+		}
 
 		// Send new-task command to the new window
 		tawBin, _ := os.Executable()
@@ -115,9 +154,10 @@ var newTaskCmd = &cobra.Command{
 	Short: "Create a new task",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
 		sessionName := args[0]
 
-		app, err := getAppFromSession(sessionName)
+		app, err := getAppFromSession(ctx, sessionName)
 		if err != nil {
 			return err
 		}
@@ -130,8 +170,12 @@ var newTaskCmd = &cobra.Command{
 			logging.SetGlobal(logger)
 		}
 
-		// Open editor for task content
-		content, err := openEditor(app.ProjectDir)
+		// Open editor for task content, with the queue in its header
+		queue, err := task.NewQueueManager(app.QueueDir).List()
+		if err != nil {
+			logging.Debug("Failed to read queue: %v", err)
+		}
+		content, err := openEditor(app.ProjectDir, isolationHeader(app)+queueHeader(queue))
 		if err != nil {
 			return fmt.Errorf("failed to open editor: %w", err)
 		}
@@ -141,22 +185,73 @@ var newTaskCmd = &cobra.Command{
 			return nil
 		}
 
-		// Create task with spinner
 		mgr := task.NewManager(app.AgentsDir, app.ProjectDir, app.TawDir, app.IsGitRepo, app.Config)
 
-		var newTask *task.Task
+		// Queue the task while another task works in the shared working
+		// tree, or the tree has changes of its own; it starts once it's free
+		if busy, err := mgr.SharedTreeBusy(ctx, nil); err != nil {
+			logging.Debug("Failed to check the working tree: %v", err)
+		} else if busy != "" {
+			if err := task.NewQueueManager(app.QueueDir).Add(content); err != nil {
+				return fmt.Errorf("failed to queue task: %w", err)
+			}
+			logging.Log("Task queued: %s", busy)
+			tmux.New(sessionName).DisplayMessage(fmt.Sprintf("%s; the task is queued", busy))
+			updateQueueStatus(tmux.New(sessionName), app)
+			return nil
+		}
+
+		// Queue the task while max_parallel_tasks are running; it starts
+		// when one of them ends
+		if atLimit, err := mgr.AtTaskLimit(); err != nil {
+			logging.Debug("Failed to count running tasks: %v", err)
+		} else if atLimit {
+			if err := task.NewQueueManager(app.QueueDir).Add(content); err != nil {
+				return fmt.Errorf("failed to queue task: %w", err)
+			}
+			logging.Log("Task queued: %d tasks are running", app.Config.MaxParallel)
+			tmux.New(sessionName).DisplayMessage(fmt.Sprintf("%d tasks are running; the task is queued", app.Config.MaxParallel))
+			updateQueueStatus(tmux.New(sessionName), app)
+			return nil
+		}
+
+		// Queue the task while agents back off from a rate limit
+		if limit := mgr.RateLimited(); limit != nil {
+			if err := task.NewQueueManager(app.QueueDir).Add(content); err != nil {
+				return fmt.Errorf("failed to queue task: %w", err)
+			}
+			logging.Log("Task queued: %s until %s", limit.Reason, limit.Until.Format("15:04:05"))
+			tmux.New(sessionName).DisplayMessage(fmt.Sprintf("%s; the task is queued until %s", limit.Reason, limit.Until.Format("15:04")))
+			updateQueueStatus(tmux.New(sessionName), app)
+			return nil
+		}
+
+		// Queue the task while today is over budget
+		if mgr.BudgetPaused() {
+			if err := task.NewQueueManager(app.QueueDir).Add(content); err != nil {
+				return fmt.Errorf("failed to queue task: %w", err)
+			}
+			logging.Log("Task queued: today's spend is over the $%.2f budget", mgr.DailyBudget())
+			tmux.New(sessionName).DisplayMessage(fmt.Sprintf("Today's spend is over the $%.2f budget; the task is queued ('taw budget' to raise it)", mgr.DailyBudget()))
+			updateQueueStatus(tmux.New(sessionName), app)
+			return nil
+		}
+
+		// Create task with spinner
+
+		var newTasks []*task.Task
 		spinner := tui.NewSpinner("태스크 이름 생성 중...")
 		p := tea.NewProgram(spinner)
 
 		// Run task creation in background
 		go func() {
-			t, err := mgr.CreateTask(content)
+			tasks, err := createTasks(ctx, mgr, content, "")
 			if err != nil {
 				p.Send(tui.SpinnerDoneMsg{Err: err})
 				return
 			}
-			newTask = t
-			p.Send(tui.SpinnerDoneMsg{Result: t.Name})
+			newTasks = tasks
+			p.Send(tui.SpinnerDoneMsg{Result: tasks[0].Name})
 		}()
 
 		finalModel, err := p.Run()
@@ -169,20 +264,20 @@ var newTaskCmd = &cobra.Command{
 			return fmt.Errorf("failed to create task: %w", spinnerResult.GetError())
 		}
 
-		logging.Log("Task created: %s", newTask.Name)
-
-		// Handle task in background
-		tawBin, _ := os.Executable()
-		handleCmd := exec.Command(tawBin, "internal", "handle-task", sessionName, newTask.AgentDir)
-		handleCmd.Start()
-
-		// Wait for window to be created
-		windowIDFile := filepath.Join(newTask.AgentDir, ".tab-lock", "window_id")
-		for i := 0; i < 60; i++ { // 30 seconds max (60 * 500ms)
-			if _, err := os.Stat(windowIDFile); err == nil {
-				break
+		// Handle tasks in background
+		for _, t := range newTasks {
+			logging.Log("Task created: %s", t.Name)
+			emitEvent(ctx, app, mgr, sessionName, config.EventTaskCreated, t, "")
+			if err := spawnInternal(sessionName, "handle-task", t.AgentDir); err != nil {
+				logging.Warn("Failed to start handle-task: %v", err)
 			}
-			time.Sleep(500 * time.Millisecond)
+		}
+
+		// Wait for handle-task to signal that the window exists
+		tm := tmux.New(sessionName)
+		channel := constants.WindowReadyChannelPrefix + newTasks[0].Name
+		if err := tm.WaitFor(channel, app.Config.Timeouts.Window); err != nil {
+			logging.Debug("Window for %s not signaled: %v", newTasks[0].Name, err)
 		}
 
 		return nil
@@ -194,12 +289,13 @@ var handleTaskCmd = &cobra.Command{
 	Short: "Handle a task (create window, start Claude)",
 	Args:  cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
 		sessionName := args[0]
 		agentDir := args[1]
 
 		taskName := filepath.Base(agentDir)
 
-		app, err := getAppFromSession(sessionName)
+		app, err := getAppFromSession(ctx, sessionName)
 		if err != nil {
 			return err
 		}
@@ -222,23 +318,93 @@ var handleTaskCmd = &cobra.Command{
 			return err
 		}
 
-		// Create tab-lock atomically
+		// Only one handle-task sets up a task at a time
+		unlock, err := mgr.LockTask(t, "handle-task")
+		if err != nil {
+			if errors.Is(err, task.ErrTaskLocked) {
+				logging.Log("Task already being handled")
+				return nil
+			}
+			return err
+		}
+		defer unlock()
+
+		// Create tab-lock atomically; an existing one is either a task that
+		// is set up, or the setup of a handle-task that died halfway
 		created, err := t.CreateTabLock()
 		if err != nil {
 			return err
 		}
-		if !created {
+		if created {
+			if err := t.BeginSetup(); err != nil {
+				logging.Warn("Failed to record setup: %v", err)
+			}
+		} else if !t.SetupInterrupted() {
 			logging.Log("Task already being handled")
 			return nil
+		} else {
+			logging.Log("Resuming interrupted setup")
 		}
 
-		// Setup worktree if git mode
-		if app.IsGitRepo && app.Config.WorkMode == config.WorkModeWorktree {
-			logging.Log("Creating worktree")
-			if err := mgr.SetupWorktree(t); err != nil {
+		// A hibernated task gets its worktree back
+		if t.IsHibernated() {
+			logging.Log("Recreating worktree of hibernated task")
+			if err := mgr.WakeTask(ctx, t); err != nil {
 				t.RemoveTabLock()
-				return fmt.Errorf("failed to setup worktree: %w", err)
+				return fmt.Errorf("failed to wake task: %w", err)
+			}
+		}
+
+		// Wake up new-task once the window exists, or right away on failure
+		tm := tmux.New(sessionName)
+		windowSignaled := false
+		signalWindow := func() {
+			if windowSignaled {
+				return
+			}
+			windowSignaled = true
+			if err := tm.Signal(constants.WindowReadyChannelPrefix + taskName); err != nil {
+				logging.Debug("Failed to signal window: %v", err)
+			}
+		}
+		defer signalWindow()
+
+		// Tasks sharing the working tree run one at a time: a new task that
+		// would clobber another's work goes back to the queue
+		if created && !t.SetupDone(task.SetupWorkspace) {
+			if busy, err := mgr.SharedTreeBusy(ctx, t); err != nil {
+				logging.Debug("Failed to check the working tree: %v", err)
+			} else if busy != "" {
+				return requeueTask(app, sessionName, t, busy)
+			}
+		}
+
+		// Setup worktree if git mode
+		if !t.SetupDone(task.SetupWorkspace) {
+			if mgr.UsesWorktree(t) {
+				logging.Log("Creating worktree")
+				if err := mgr.SetupWorktree(ctx, t); err != nil {
+					t.RemoveTabLock()
+					if hint := task.Hint(err); hint != "" {
+						tm.DisplayMessage(fmt.Sprintf(icon.Warning.String()+" %s: %s", taskName, hint))
+					}
+					return fmt.Errorf("failed to setup worktree: %w", err)
+				}
+			}
+
+			// Record the project's files, and copy them in snapshot mode, if not git
+			if !app.IsGitRepo {
+				if err := mgr.SetupNoGit(t); err != nil {
+					t.RemoveTabLock()
+					return fmt.Errorf("failed to setup task: %w", err)
+				}
 			}
+			markSetupDone(t, task.SetupWorkspace)
+		}
+
+		// Sync slash commands into the worktree's .claude (error is non-fatal)
+		if err := mgr.SyncClaudeAssets(ctx, t); err != nil {
+			logging.Warn("Failed to sync claude assets: %v", err)
 		}
 
 		// Setup symlinks (error is non-fatal)
@@ -247,87 +413,174 @@ var handleTaskCmd = &cobra.Command{
 			logging.Warn("Failed to setup symlinks: %v", err)
 		}
 
-		// Create tmux window
-		tm := tmux.New(sessionName)
+		// Create tmux window, unless an interrupted setup already did
 		workDir := mgr.GetWorkingDirectory(t)
 
-		windowID, err := tm.NewWindow(tmux.WindowOpts{
-			Name:     t.GetWindowName(),
-			StartDir: workDir,
-			Detached: true,
-		})
+		windowID := ""
+		if t.SetupDone(task.SetupWindow) {
+			if id := taskWindow(tm, t); id != "" {
+				windowID = id
+			} else {
+				// Everything inside the window went with it
+				t.ForgetSetup(task.SetupWindow, task.SetupShell, task.SetupAgent)
+			}
+		}
+		if windowID == "" {
+			windowID, err = tm.NewWindow(tmux.WindowOpts{
+				Name:     t.Name,
+				StartDir: workDir,
+				Command:  paneShell(app.Config),
+				Detached: true,
+			})
+			if err != nil {
+				t.RemoveTabLock()
+				return fmt.Errorf("failed to create window: %w", err)
+			}
+
+			// Save window ID, and tag the window with the task
+			if err := t.SaveWindowID(windowID); err != nil {
+				logging.Warn("Failed to save window ID: %v", err)
+			}
+			if _, err := t.EnsureID(); err != nil {
+				logging.Warn("Failed to get task ID: %v", err)
+			}
+			if err := t.SyncWindow(tm, windowID); err != nil {
+				logging.Warn("Failed to tag window: %v", err)
+			}
+			markSetupDone(t, task.SetupWindow)
+		}
+		signalWindow()
+
+		// Move the window to its place in the configured order (error is non-fatal)
+		mgr.SetTmuxClient(tm)
+		if err := mgr.ArrangeWindows(); err != nil {
+			logging.Debug("Failed to arrange windows: %v", err)
+		}
+
+		// Write the env script both panes source, with the task context and
+		// the agent's variables (error is non-fatal)
+		taskContext := mgr.TaskContext(ctx, t, sessionName)
+		agentEnv, err := app.Config.AgentEnv(app.TawDir)
 		if err != nil {
-			t.RemoveTabLock()
-			return fmt.Errorf("failed to create window: %w", err)
+			logging.Warn("Failed to load env: %v", err)
+			if errors.Is(err, config.ErrEnvFileExposed) {
+				tm.DisplayMessage(fmt.Sprintf(icon.Warning.String()+" %s: .taw/env is readable by others and was skipped (chmod 600 .taw/env)", taskName))
+			}
+		}
+		if err := t.SaveEnv(append(taskContext.EnvVars(), agentEnv...)); err != nil {
+			logging.Warn("Failed to save env: %v", err)
 		}
 
-		// Save window ID
-		if err := t.SaveWindowID(windowID); err != nil {
-			logging.Warn("Failed to save window ID: %v", err)
+		// Get taw binary path for end-task
+		tawBin, _ := os.Executable()
+
+		// Split window for user pane, with the agent's variables and a cheat
+		// sheet of the task (error is non-fatal)
+		if !t.SetupDone(task.SetupShell) {
+			shellCmd := fmt.Sprintf("%s; cd '%s'; '%s' internal pane-info '%s' '%s'; %s",
+				t.SourceEnvCommand(), workDir, tawBin, sessionName, taskName, paneShell(app.Config))
+			if err := tm.SplitWindow(windowID, true, shellCmd); err != nil {
+				logging.Warn("Failed to split window: %v", err)
+			}
+			markSetupDone(t, task.SetupShell)
 		}
 
-		// Split window for user pane (error is non-fatal)
-		if err := tm.SplitWindow(windowID, true, ""); err != nil {
-			logging.Warn("Failed to split window: %v", err)
+		// Check remote access up front so auth problems don't surface deep inside end-task
+		if err := mgr.Preflight(ctx); err != nil {
+			logging.Warn("%v", err)
+			var preflightErr *task.PreflightError
+			if errors.As(err, &preflightErr) {
+				tm.DisplayMessage(fmt.Sprintf(icon.Warning.String()+" %s: %s", taskName, preflightErr.Hint))
+			}
 		}
 
-		// Build system prompt
-		globalPrompt, _ := os.ReadFile(app.GetGlobalPromptPath())
-		projectPrompt, _ := os.ReadFile(app.GetPromptPath())
-		systemPrompt := claude.BuildSystemPrompt(string(globalPrompt), string(projectPrompt))
+		// Build and save the system and user prompts
+		saveTaskPrompts(app, mgr, t, workDir, taskContext)
 
-		// Build user prompt with context
-		var userPrompt strings.Builder
-		userPrompt.WriteString(fmt.Sprintf("# Task: %s\n\n", taskName))
-		if app.IsGitRepo && app.Config.WorkMode == config.WorkModeWorktree {
-			userPrompt.WriteString(fmt.Sprintf("**Worktree**: %s\n", workDir))
+		// Leave the window open without a usable Claude rather than waiting for
+		// a prompt that never comes. With a login shell or shell env, the pane's PATH
+		// isn't ours, so the agent script checks instead.
+		claudeClient := claude.NewWithTimeouts(app.Config.Timeouts.ClaudeReady, app.Config.Timeouts.ClaudeName)
+		pathFromShell := app.Config.Agent.LoginShell || app.Config.Agent.ShellEnv != ""
+		if !mgr.Sandboxed() && !pathFromShell {
+			if err := claude.CheckVersion(app.Config.ClaudeMin); err != nil {
+				logging.Warn("Cannot start task: %v", err)
+				tm.DisplayMessage(fmt.Sprintf(icon.Warning.String()+" %s: %s", taskName, claude.ErrorHint(err)))
+				return nil
+			}
 		}
-		userPrompt.WriteString(fmt.Sprintf("**Project**: %s\n\n", app.ProjectDir))
-		userPrompt.WriteString(t.Content)
 
-		// Save prompts (errors are non-fatal but should be logged)
-		if err := os.WriteFile(t.GetSystemPromptPath(), []byte(systemPrompt), 0644); err != nil {
-			logging.Warn("Failed to save system prompt: %v", err)
+		claudeBin := "claude --dangerously-skip-permissions"
+		if app.Config.Model != "" {
+			claudeBin += fmt.Sprintf(" --model '%s'", app.Config.Model)
 		}
-		if err := os.WriteFile(t.GetUserPromptPath(), []byte(userPrompt.String()), 0644); err != nil {
-			logging.Warn("Failed to save user prompt: %v", err)
+		agentCmd := fmt.Sprintf("%s --system-prompt \"$(cat '%s')\"", claudeBin, t.GetSystemPromptPath())
+		if mgr.Sandboxed() {
+			// Pass the task variables into the container by name
+			envNames := []string{"TASK_NAME", "TAW_DIR", "PROJECT_DIR", "WINDOW_ID", "ON_COMPLETE", "PUSH_REMOTE", "SESSION_NAME"}
+			if mgr.UsesWorktree(t) {
+				envNames = append(envNames, "WORKTREE_DIR", "TASK_BRANCH")
+			}
+			if !app.IsGitRepo {
+				envNames = append(envNames, "WORK_DIR")
+			}
+			for _, v := range agentEnv {
+				envNames = append(envNames, v.Name)
+			}
+
+			sandboxCmd, err := mgr.SandboxCommand(t, agentCmd, envNames)
+			if err != nil {
+				logging.Warn("Cannot start task: %v", err)
+				tm.DisplayMessage(fmt.Sprintf(icon.Warning.String()+" %s: %s", taskName, task.Hint(err)))
+				return nil
+			}
+			agentCmd = sandboxCmd
+		} else {
+			// Limits that can't be applied here are skipped (error is non-fatal)
+			limitedCmd, err := mgr.LimitCommand(agentCmd)
+			if err != nil {
+				logging.Warn("Resource limits partially applied: %v", err)
+			}
+			agentCmd = limitedCmd
 		}
 
-		// Get taw binary path for end-task
-		tawBin, _ := os.Executable()
+		// The agent runs from a script, started with run-agent, which tells the
+		// watchdog when it exits
+		agentScript := mgr.AgentScript(t, agentCmd)
+		if err := t.SaveAgentScript(false, agentScript); err != nil {
+			return fmt.Errorf("failed to save agent script: %w", err)
+		}
 
-		// Build environment variables and Claude command
-		// These are used by PROMPT.md for auto-merge, auto-pr, etc.
-		var envVars strings.Builder
-		envVars.WriteString(fmt.Sprintf("export TASK_NAME='%s' ", taskName))
-		envVars.WriteString(fmt.Sprintf("TAW_DIR='%s' ", app.TawDir))
-		envVars.WriteString(fmt.Sprintf("PROJECT_DIR='%s' ", app.ProjectDir))
-		if app.IsGitRepo && app.Config.WorkMode == config.WorkModeWorktree {
-			envVars.WriteString(fmt.Sprintf("WORKTREE_DIR='%s' ", workDir))
-		}
-		envVars.WriteString(fmt.Sprintf("WINDOW_ID='%s' ", windowID))
-		envVars.WriteString(fmt.Sprintf("ON_COMPLETE='%s' ", app.Config.OnComplete))
-		envVars.WriteString(fmt.Sprintf("TAW_HOME='%s' ", filepath.Dir(filepath.Dir(tawBin))))
-		envVars.WriteString(fmt.Sprintf("TAW_BIN='%s' ", tawBin))
-		envVars.WriteString(fmt.Sprintf("SESSION_NAME='%s'", sessionName))
-
-		claudeCmd := fmt.Sprintf("%s && claude --dangerously-skip-permissions --system-prompt \"$(cat '%s')\"",
-			envVars.String(), t.GetSystemPromptPath())
-		if err := tm.SendKeysLiteral(windowID+".0", claudeCmd); err != nil {
-			return fmt.Errorf("failed to send Claude command: %w", err)
-		}
-		if err := tm.SendKeys(windowID+".0", "Enter"); err != nil {
-			return fmt.Errorf("failed to send Enter: %w", err)
+		// Recovery restarts a dead agent with this, continuing its conversation (error is non-fatal)
+		resumeScript := strings.Replace(agentScript, claudeBin, claudeBin+" --continue", 1)
+		if err := t.SaveAgentScript(true, resumeScript); err != nil {
+			logging.Debug("Failed to save resume script: %v", err)
+		} else if err := t.SaveResumeCommand(runAgentCommand(tawBin, sessionName, taskName, true)); err != nil {
+			logging.Debug("Failed to save resume command: %v", err)
+		}
+		if !t.SetupDone(task.SetupAgent) {
+			if err := tm.SendKeysLiteral(windowID+".0", runAgentCommand(tawBin, sessionName, taskName, false)); err != nil {
+				return fmt.Errorf("failed to send Claude command: %w", err)
+			}
+			if err := tm.SendKeys(windowID+".0", "Enter"); err != nil {
+				return fmt.Errorf("failed to send Enter: %w", err)
+			}
+			if err := t.SaveAgentStart(); err != nil {
+				logging.Debug("Failed to record agent start: %v", err)
+			}
+			markSetupDone(t, task.SetupAgent)
 		}
 
 		// Wait for Claude to be ready
-		claudeClient := claude.New()
-		if err := claudeClient.WaitForReady(tm, windowID+".0"); err != nil {
+		if err := claudeClient.WaitForReady(ctx, tm, windowID+".0"); err != nil {
 			logging.Warn("Timeout waiting for Claude: %v", err)
+			if hint := task.Hint(err); hint != "" {
+				tm.DisplayMessage(fmt.Sprintf(icon.Warning.String()+" %s: %s", taskName, hint))
+			}
 		}
 
 		// Send trust response if needed (error is non-fatal)
-		if err := claudeClient.SendTrustResponse(tm, windowID+".0"); err != nil {
+		if err := claudeClient.SendTrustResponse(ctx, tm, windowID+".0"); err != nil {
 			logging.Debug("Failed to send trust response: %v", err)
 		}
 
@@ -336,153 +589,201 @@ var handleTaskCmd = &cobra.Command{
 
 		// Send task instruction - tell Claude to read from file
 		taskInstruction := fmt.Sprintf("ultrathink Read and execute the task from '%s'", t.GetUserPromptPath())
-		if err := claudeClient.SendInput(tm, windowID+".0", taskInstruction); err != nil {
+		if err := mgr.SendInstruction(ctx, tm, t, windowID+".0", task.InstructionTask, taskInstruction); err != nil {
 			logging.Warn("Failed to send task instruction: %v", err)
 		}
+		markSetupDone(t, task.SetupStarted)
+		if err := t.RecordStarted(); err != nil {
+			logging.Debug("Failed to record start: %v", err)
+		}
+
+		// A replay sends the rest of its instructions as the agent finishes each
+		if len(t.LoadReplay()) > 0 {
+			if err := spawnInternal(sessionName, "replay-task", taskName); err != nil {
+				logging.Warn("Failed to start replay: %v", err)
+			}
+		}
 
 		logging.Log("Task started")
+		emitEvent(ctx, app, mgr, sessionName, config.EventTaskStarted, t, "")
 		return nil
 	},
 }
 
+// paneShell returns the command that ends a task pane's setup with the user's
+// shell, a login shell if agent.login_shell is set so it reads the profile
+// that sets PATH.
+func paneShell(cfg *config.Config) string {
+	if cfg != nil && cfg.Agent.LoginShell {
+		return `exec "${SHELL:-/bin/sh}" -l`
+	}
+	return `exec "${SHELL:-/bin/sh}"`
+}
+
+// runAgentCommand returns the command that runs a task's agent script in its
+// pane. It's typed into the user's shell, which may be fish or nushell, so it
+// is only a bare command with single-quoted arguments.
+func runAgentCommand(tawBin, sessionName, taskName string, resume bool) string {
+	command := fmt.Sprintf("%s internal run-agent '%s' '%s'", tawBin, sessionName, taskName)
+	if resume {
+		command += " --continue"
+	}
+	return command
+}
+
+// markSetupDone records a step of handle-task (error is non-fatal: the step
+// runs again if the setup is resumed).
+func markSetupDone(t *task.Task, step task.SetupStep) {
+	if err := t.MarkSetupDone(step); err != nil {
+		logging.Debug("Failed to record setup step %s: %v", step, err)
+	}
+}
+
+// endTaskSkipVerify skips the verify steps of the end pipeline.
+var endTaskSkipVerify bool
+
+// endTaskMerge merges the task regardless of on_complete (taw merge).
+var endTaskMerge bool
+
 var endTaskCmd = &cobra.Command{
-	Use:   "end-task [session] [window-id]",
+	Use:   "end-task [session] [task-id]",
 	Short: "End a task (commit, merge, cleanup)",
 	Args:  cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
 		sessionName := args[0]
-		windowID := args[1]
+		taskID := args[1]
 
-		app, err := getAppFromSession(sessionName)
+		app, err := getAppFromSession(ctx, sessionName)
 		if err != nil {
 			return err
 		}
 
-		// Find task by window ID
 		mgr := task.NewManager(app.AgentsDir, app.ProjectDir, app.TawDir, app.IsGitRepo, app.Config)
-		tasks, err := mgr.ListTasks()
+		targetTask, windowID, err := findTaskByID(tmux.New(sessionName), mgr, taskID)
 		if err != nil {
-			return fmt.Errorf("failed to list tasks: %w", err)
-		}
-
-		var targetTask *task.Task
-		for _, t := range tasks {
-			if id, _ := t.LoadWindowID(); id == windowID {
-				targetTask = t
-				break
-			}
-		}
-
-		if targetTask == nil {
-			return fmt.Errorf("task not found for window %s", windowID)
-		}
-
-		// Setup logging
-		logger, _ := logging.New(app.GetLogPath(), app.Debug)
-		if logger != nil {
-			defer logger.Close()
-			logger.SetScript("end-task")
-			logger.SetTask(targetTask.Name)
-			logging.SetGlobal(logger)
+			return err
 		}
 
-		logging.Log("=== End task ===")
-		logging.Log("ON_COMPLETE=%s", app.Config.OnComplete)
+		return endTask(ctx, app, mgr, sessionName, windowID, targetTask, endTaskMerge)
+	},
+}
 
-		tm := tmux.New(sessionName)
-		gitClient := git.New()
-		workDir := mgr.GetWorkingDirectory(targetTask)
+// endTask runs the task's end pipeline (see endPipeline): its steps, per
+// pipeline.steps or on_complete (with merge if merge is set), cleanup, and
+// closing the task window. A task that needs the user's attention is kept
+// open instead.
+func endTask(ctx context.Context, app *app.App, mgr *task.Manager, sessionName, windowID string, t *task.Task, merge bool) error {
+	// Setup logging
+	logger, _ := logging.New(app.GetLogPath(), app.Debug)
+	if logger != nil {
+		defer logger.Close()
+		logger.SetScript("end-task")
+		logger.SetTask(t.Name)
+		logging.SetGlobal(logger)
+	}
 
-		// Commit changes if git mode
-		if app.IsGitRepo {
-			if gitClient.HasChanges(workDir) {
-				logging.Log("Committing changes")
-				if err := gitClient.AddAll(workDir); err != nil {
-					logging.Warn("Failed to add changes: %v", err)
-				}
-				diffStat, _ := gitClient.GetDiffStat(workDir)
-				message := fmt.Sprintf("chore: auto-commit on task end\n\n%s", diffStat)
-				if err := gitClient.Commit(workDir, message); err != nil {
-					logging.Warn("Failed to commit: %v", err)
-				}
-			}
+	tm := tmux.New(sessionName)
 
-			// Push changes
-			logging.Log("Pushing changes")
-			if err := gitClient.Push(workDir, "origin", targetTask.Name, true); err != nil {
-				logging.Warn("Failed to push: %v", err)
-			}
+	// A second client pressing M-e on the same window is turned away
+	unlock, err := lockTask(tm, mgr, t, "end-task")
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
-			// Handle auto-merge mode
-			if app.Config != nil && app.Config.OnComplete == config.OnCompleteAutoMerge {
-				logging.Log("auto-merge: merging to main...")
+	// The steps work in the worktree of a hibernated task
+	if err := mgr.WakeTask(ctx, t); err != nil {
+		return fmt.Errorf("failed to wake task: %w", err)
+	}
 
-				// Get main branch name
-				mainBranch := gitClient.GetMainBranch(app.ProjectDir)
+	return newEndPipeline(app, mgr, tm, sessionName, windowID, t, merge).run(ctx, endTaskSkipVerify)
+}
 
-				// Fetch and checkout main in PROJECT_DIR
-				if err := gitClient.Fetch(app.ProjectDir, "origin"); err != nil {
-					logging.Warn("Failed to fetch: %v", err)
-				}
-				if err := gitClient.Checkout(app.ProjectDir, mainBranch); err != nil {
-					logging.Warn("Failed to checkout %s: %v", mainBranch, err)
-				} else {
-					// Pull latest
-					if err := gitClient.Pull(app.ProjectDir); err != nil {
-						logging.Warn("Failed to pull: %v", err)
-					}
+var endTaskUICmd = &cobra.Command{
+	Use:   "end-task-ui [session] [task-id]",
+	Short: "End task with UI feedback",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		sessionName := args[0]
+		taskID := args[1]
 
-					// Merge task branch (--no-ff)
-					mergeMsg := fmt.Sprintf("Merge branch '%s'", targetTask.Name)
-					if err := gitClient.Merge(app.ProjectDir, targetTask.Name, true, mergeMsg); err != nil {
-						logging.Warn("Merge failed: %v - may need manual resolution", err)
-						// Abort merge on conflict
-						if abortErr := gitClient.MergeAbort(app.ProjectDir); abortErr != nil {
-							logging.Warn("Failed to abort merge: %v", abortErr)
-						}
-					} else {
-						// Push merged main
-						if err := gitClient.Push(app.ProjectDir, "origin", mainBranch, false); err != nil {
-							logging.Warn("Failed to push merged main: %v", err)
-						} else {
-							logging.Log("Merged to %s", mainBranch)
-						}
-					}
-				}
-			}
+		app, err := getAppFromSession(ctx, sessionName)
+		if err != nil {
+			return err
 		}
 
-		// Cleanup task
-		logging.Log("Cleanup started")
-		if err := mgr.CleanupTask(targetTask); err != nil {
-			logging.Warn("Cleanup failed: %v", err)
-		} else {
-			logging.Log("Cleanup completed")
+		mgr := task.NewManager(app.AgentsDir, app.ProjectDir, app.TawDir, app.IsGitRepo, app.Config)
+		tm := tmux.New(sessionName)
+		targetTask, _, err := findTaskByID(tm, mgr, taskID)
+		if err != nil {
+			return err
 		}
 
-		// Kill window
-		if err := tm.KillWindow(windowID); err != nil {
-			logging.Warn("Failed to kill window: %v", err)
+		// Without a verification pipeline there is nothing to show
+		pipeline := mgr.VerifyPipeline(ctx, targetTask)
+		if len(pipeline) == 0 {
+			return spawnInternal(sessionName, "end-task", taskID)
 		}
 
-		// Process queue
+		// A row per verification step and per other step
 		tawBin, _ := os.Executable()
-		if err := exec.Command(tawBin, "internal", "process-queue", sessionName).Start(); err != nil {
-			logging.Debug("Failed to start process-queue: %v", err)
-		}
-
-		return nil
+		return tm.DisplayPopup(tmux.PopupOpts{
+			Width:  "80",
+			Height: fmt.Sprintf("%d", len(pipeline)+len(mgr.EndSteps(targetTask, false))+8),
+			Title:  fmt.Sprintf(" End: %s ", targetTask.Name),
+			Close:  true,
+		}, fmt.Sprintf("%s internal verify-task '%s' '%s'", tawBin, sessionName, taskID))
 	},
 }
 
-var endTaskUICmd = &cobra.Command{
-	Use:   "end-task-ui [session] [window-id]",
-	Short: "End task with UI feedback",
+var verifyTaskCmd = &cobra.Command{
+	Use:   "verify-task [session] [task-id]",
+	Short: "End a task, showing the steps of its end pipeline live",
 	Args:  cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// For now, just call end-task
-		// TODO: Implement proper TUI with progress display
-		return endTaskCmd.RunE(cmd, args)
+		ctx := cmd.Context()
+		sessionName := args[0]
+		taskID := args[1]
+
+		app, err := getAppFromSession(ctx, sessionName)
+		if err != nil {
+			return err
+		}
+
+		tm := tmux.New(sessionName)
+		mgr := task.NewManager(app.AgentsDir, app.ProjectDir, app.TawDir, app.IsGitRepo, app.Config)
+		targetTask, windowID, err := findTaskByID(tm, mgr, taskID)
+		if err != nil {
+			return err
+		}
+
+		// Setup logging
+		logger, _ := logging.New(app.GetLogPath(), app.Debug)
+		if logger != nil {
+			defer logger.Close()
+			logger.SetScript("verify-task")
+			logger.SetTask(targetTask.Name)
+			logging.SetGlobal(logger)
+		}
+
+		unlock, err := lockTask(tm, mgr, targetTask, "verify-task")
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		p := newEndPipeline(app, mgr, tm, sessionName, windowID, targetTask, false)
+		passed, err := tui.RunEndTaskUI(ctx, targetTask.Name, p.uiSteps(ctx, false))
+		if err != nil {
+			return err
+		}
+		if !passed && p.keep == nil {
+			// Cancelled before a step failed
+			return nil
+		}
+		return p.end(ctx)
 	},
 }
 
@@ -499,9 +800,10 @@ var attachCmd = &cobra.Command{
 }
 
 var cleanupCmd = &cobra.Command{
-	Use:   "cleanup [task-name]",
-	Short: "Cleanup a specific task",
-	Args:  cobra.ExactArgs(1),
+	Use:               "cleanup [task-name]",
+	Short:             "Cleanup a specific task",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTaskName(0),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		taskName := args[0]
 		// TODO: Implement cleanup logic
@@ -512,114 +814,495 @@ var cleanupCmd = &cobra.Command{
 
 var processQueueCmd = &cobra.Command{
 	Use:   "process-queue [session]",
-	Short: "Process the next task in queue",
+	Short: "Start queued tasks while slots are free",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
 		sessionName := args[0]
 
-		app, err := getAppFromSession(sessionName)
-		if err != nil {
-			return err
-		}
-
-		queueMgr := task.NewQueueManager(app.QueueDir)
-		queuedTask, err := queueMgr.Pop()
+		app, err := getAppFromSession(ctx, sessionName)
 		if err != nil {
 			return err
 		}
 
-		if queuedTask == nil {
-			return nil // Queue is empty
-		}
-
-		// Create task from queue
 		mgr := task.NewManager(app.AgentsDir, app.ProjectDir, app.TawDir, app.IsGitRepo, app.Config)
-		newTask, err := mgr.CreateTask(queuedTask.Content)
-		if err != nil {
-			return err
+		for {
+			// The queue is processed again as running tasks end, and by the
+			// daemon once a rate limit, the budget or the drain policy allows
+			queued, _, err := processQueue(ctx, app, mgr, sessionName, false)
+			if errors.Is(err, task.ErrQueueHeld) {
+				logging.Log("%v", err)
+				return nil
+			}
+			if err != nil || queued == nil {
+				return err
+			}
 		}
-
-		// Handle task
-		tawBin, _ := os.Executable()
-		handleCmd := exec.Command(tawBin, "internal", "handle-task", sessionName, newTask.AgentDir)
-		return handleCmd.Start()
 	},
 }
 
-var quickTaskCmd = &cobra.Command{
-	Use:   "quick-task [session]",
-	Short: "Add a quick task to the queue",
-	Args:  cobra.ExactArgs(1),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		sessionName := args[0]
+// processQueue starts the next queued task and returns it with the tasks
+// created from it, or nil if the queue is empty. Unless manual, it only
+// starts it as the drain policy allows. It fails with task.ErrQueueHeld,
+// saying why, if the task can't start yet.
+func processQueue(ctx context.Context, app *app.App, mgr *task.Manager, sessionName string, manual bool) (*task.QueuedTask, []*task.Task, error) {
+	queueMgr := task.NewQueueManager(app.QueueDir)
+	queue, err := queueMgr.List()
+	if err != nil || len(queue) == 0 {
+		return nil, nil, err
+	}
 
-		app, err := getAppFromSession(sessionName)
-		if err != nil {
-			return err
+	blocked, err := mgr.QueueBlocked(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if blocked == "" && !manual {
+		blocked = mgr.QueueHold(time.Now(), sessionAttached(sessionName))
+	}
+	if blocked != "" {
+		return nil, nil, fmt.Errorf("%w: %d task(s) waiting: %s", task.ErrQueueHeld, len(queue), blocked)
+	}
+
+	queuedTask, err := queueMgr.Pop()
+	if err != nil || queuedTask == nil {
+		return nil, nil, err
+	}
+	updateQueueStatus(tmux.New(sessionName), app)
+
+	// Create task from queue
+	newTasks, err := createTasks(ctx, mgr, queuedTask.Content, queuedTask.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Handle tasks
+	for _, t := range newTasks {
+		if queuedTask.Slack != nil {
+			if err := t.SaveSlackOrigin(queuedTask.Slack); err != nil {
+				logging.Warn("Failed to save Slack origin of %s: %v", t.Name, err)
+			}
 		}
+		emitEvent(ctx, app, mgr, sessionName, config.EventTaskCreated, t, "")
+		if err := spawnInternal(sessionName, "handle-task", t.AgentDir); err != nil {
+			return nil, nil, err
+		}
+	}
+	return queuedTask, newTasks, nil
+}
 
-		tm := tmux.New(sessionName)
+// requeueTask puts a task that can't start yet back in the queue under its
+// name, and removes it.
+func requeueTask(app *app.App, sessionName string, t *task.Task, reason string) error {
+	if err := task.NewQueueManager(app.QueueDir).AddWithOrigin(t.Content, t.Name, t.LoadSlackOrigin()); err != nil {
+		t.RemoveTabLock()
+		return fmt.Errorf("failed to queue task: %w", err)
+	}
+	if err := t.Remove(); err != nil {
+		logging.Warn("Failed to remove requeued task: %v", err)
+	}
+	logging.Log("Task queued: %s", reason)
 
-		// Use tmux display-popup to get input
-		popupCmd := fmt.Sprintf("read -p 'Quick task: ' task && echo \"$task\" >> %s/.queue/$(date +%%s).task", app.TawDir)
+	tm := tmux.New(sessionName)
+	tm.DisplayMessage(fmt.Sprintf("%s; %s is queued", reason, t.Name))
+	updateQueueStatus(tm, app)
+	return nil
+}
 
-		return tm.DisplayPopup(tmux.PopupOpts{
-			Width:  "60",
-			Height: "3",
-			Title:  "Quick Task",
-			Close:  true,
-		}, fmt.Sprintf("bash -c '%s'", popupCmd))
-	},
+// sessionAttached returns true if a client is attached to the session.
+func sessionAttached(sessionName string) bool {
+	out, err := tmux.New(sessionName).RunWithOutput("display-message", "-p", "-t", sessionName, "#{session_attached}")
+	return err == nil && strings.TrimSpace(out) != "" && strings.TrimSpace(out) != "0"
 }
 
-var mergeCompletedCmd = &cobra.Command{
-	Use:   "merge-completed [session]",
-	Short: "Merge all completed tasks",
+var processOutboxCmd = &cobra.Command{
+	Use:   "process-outbox [session]",
+	Short: "Retry pending remote actions until the outbox drains",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
 		sessionName := args[0]
 
-		app, err := getAppFromSession(sessionName)
+		app, err := getAppFromSession(ctx, sessionName)
 		if err != nil {
 			return err
 		}
 
-		if !app.IsGitRepo {
-			return fmt.Errorf("merge-completed only works in git repositories")
-		}
-
-		tm := tmux.New(sessionName)
-		gitClient := git.New()
+		// Setup logging
+		logger, _ := logging.New(app.GetLogPath(), app.Debug)
+		if logger != nil {
+			defer logger.Close()
+			logger.SetScript("process-outbox")
+			logging.SetGlobal(logger)
+		}
 
-		// Find windows with ✅ emoji
-		windows, err := tm.ListWindows()
+		// Only one processor at a time
+		outbox := task.NewOutbox(app.OutboxDir)
+		locked, err := outbox.Lock()
 		if err != nil {
 			return err
 		}
+		if !locked {
+			return nil
+		}
+		defer outbox.Unlock()
 
-		for _, w := range windows {
-			if !strings.HasPrefix(w.Name, constants.EmojiDone) {
-				continue
+		mgr := task.NewManager(app.AgentsDir, app.ProjectDir, app.TawDir, app.IsGitRepo, app.Config)
+		for {
+			pending, wait, err := mgr.ProcessOutbox(ctx, outbox)
+			if err != nil {
+				return err
+			}
+			if pending == 0 {
+				return nil
+			}
+
+			logging.Log("%d outbox action(s) pending, retrying in %s", pending, wait.Round(time.Second))
+			// The actions stay in the outbox for the next processor
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(wait):
 			}
+		}
+	},
+}
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon [session]",
+	Short: "Run the session daemon that supervises background jobs",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		sessionName := args[0]
+
+		app, err := getAppFromSession(ctx, sessionName)
+		if err != nil {
+			return err
+		}
 
-			// Extract task name
-			taskName := strings.TrimPrefix(w.Name, constants.EmojiDone)
+		// Setup logging
+		logger, _ := logging.New(app.GetLogPath(), app.Debug)
+		if logger != nil {
+			defer logger.Close()
+			logger.SetScript("daemon")
+			logging.SetGlobal(logger)
+		}
 
-			fmt.Printf("Merging task: %s\n", taskName)
+		tawBin, _ := os.Executable()
+		runner := daemon.NewRunner(ctx, tawBin, sessionName)
+		server := daemon.NewServer(daemon.SocketPath(sessionName), runner)
+		if err := server.Listen(); err != nil {
+			logging.Debug("Daemon not started: %v", err)
+			return nil
+		}
+		go server.Serve()
+		logging.Log("Daemon started")
 
-			// Merge branch
-			err := gitClient.Merge(app.ProjectDir, taskName, true, fmt.Sprintf("Merge branch '%s'", taskName))
+		tm := tmux.New(sessionName)
+		mgr := task.NewManager(app.AgentsDir, app.ProjectDir, app.TawDir, app.IsGitRepo, app.Config)
+		mgr.SetTmuxClient(tm)
+		outbox := task.NewOutbox(app.OutboxDir)
+		ticker := time.NewTicker(constants.DaemonPollInterval)
+		defer ticker.Stop()
+		var lastDiskCheck time.Time
+		overQuota := false
+		banner, bannerSet := "", false
+		var lastBudgetCheck time.Time
+		var lastBatchCheck time.Time
+		var lastPRCommentsCheck time.Time
+		var lastStaleCheck time.Time
+		budgetPaused := false
+		queueHold := ""
+		treeBusy := ""
+
+		// Queue the files dropped into the inbox as they arrive
+		if mgr.InboxDir() != "" {
+			go watchInbox(ctx, app, mgr, runner)
+		}
+
+	loop:
+		for {
+			// Resume or roll back operations left half-finished by a killed process
+			recovered, err := mgr.RecoverJournal(ctx, outbox)
 			if err != nil {
-				fmt.Printf("Failed to merge %s: %v\n", taskName, err)
-				gitClient.MergeAbort(app.ProjectDir)
-				continue
+				logging.Warn("Failed to recover interrupted operations: %v", err)
+			}
+			for _, entry := range recovered {
+				logging.Log("Recovered interrupted %s of %s", entry.Op, entry.TaskName)
+			}
+
+			// Move the tickets of tasks that got a pull request to in review
+			if queued, err := mgr.QueueTicketReviews(); err != nil {
+				logging.Debug("Failed to check ticket reviews: %v", err)
+			} else if queued > 0 {
+				logging.Log("Queued %d ticket(s) for review", queued)
+			}
+
+			// Resume remote actions left over from earlier runs
+			if actions, _ := outbox.List(); len(actions) > 0 {
+				runner.Submit("process-outbox")
+			}
+
+			// Show the queue, in case tasks were queued from outside the session
+			updateQueueStatus(tm, app)
+
+			// Report opted-in usage counts once a day
+			sendTelemetry(ctx)
+
+			// Flag working agents whose pane stopped changing
+			checkAgentHealth(app, mgr, tm)
+
+			// Pause new tasks while agents hit rate limits, and resume the
+			// queue once the backoff ends
+			checkRateLimits(app, mgr, tm)
+			// The banner is set again while shown, in case an attach reset it
+			if text := rateLimitBanner(mgr); text != banner || text != "" || !bannerSet {
+				if err := tm.SetOption("status-left", text, true); err != nil {
+					logging.Debug("Failed to set rate limit banner: %v", err)
+				}
+				if banner != "" && text == "" {
+					logging.Log("Rate limit backoff ended")
+					runner.Submit("process-queue")
+				}
+				banner, bannerSet = text, true
+			}
+
+			// Check task costs against their budgets, and resume the queue
+			// once today's budget allows new tasks again
+			if mgr.HasBudget() && time.Since(lastBudgetCheck) >= constants.BudgetCheckInterval {
+				lastBudgetCheck = time.Now()
+				checkBudgets(app, mgr, tm)
+			}
+			if paused := mgr.BudgetPaused(); paused != budgetPaused {
+				if !paused {
+					logging.Log("Daily budget allows new tasks again")
+					runner.Submit("process-queue")
+				}
+				budgetPaused = paused
+			}
+
+			// Start queued tasks once the drain policy allows, e.g. when
+			// queue.hours begin or a client attaches
+			if hold := mgr.QueueHold(time.Now(), sessionAttached(sessionName)); hold != queueHold {
+				if hold == "" {
+					logging.Log("Queue drain policy (%s) allows queued tasks to start", mgr.QueueDrain())
+					runner.Submit("process-queue")
+				} else {
+					logging.Log("Queued tasks held: %s", hold)
+				}
+				queueHold = hold
+			}
+
+			// Start queued tasks once the shared working tree is free again,
+			// e.g. when its uncommitted changes are committed (work_mode: main)
+			if mgr.SharesWorkingTree() {
+				if n, _ := task.NewQueueManager(app.QueueDir).Count(); n > 0 {
+					busy, err := mgr.SharedTreeBusy(ctx, nil)
+					if err == nil && busy != treeBusy {
+						if busy == "" {
+							logging.Log("The shared working tree is free; starting queued tasks")
+							runner.Submit("process-queue")
+						}
+						treeBusy = busy
+					}
+				}
+			}
+
+			// Write the morning report of batches that finished
+			if time.Since(lastBatchCheck) >= constants.BatchCheckInterval {
+				lastBatchCheck = time.Now()
+				checkBatches(app, mgr, tm)
+			}
+
+			// Tell the user about new comments on the pull requests of open tasks
+			if interval := mgr.PRCommentsInterval(); interval > 0 && time.Since(lastPRCommentsCheck) >= interval {
+				lastPRCommentsCheck = time.Now()
+				checkPRComments(ctx, app, mgr, tm)
+			}
+
+			// Flag tasks nobody touched for stale.days, and park them after
+			// the grace period
+			if mgr.StaleDays() > 0 && time.Since(lastStaleCheck) >= constants.StaleCheckInterval {
+				lastStaleCheck = time.Now()
+				checkStaleTasks(ctx, app, mgr, tm)
+			}
+
+			// Keep windows in order as statuses change outside set-status
+			if err := mgr.ArrangeWindows(); err != nil {
+				logging.Debug("Failed to arrange windows: %v", err)
+			}
+
+			// Warn once each time .taw grows past the disk quota
+			if quota := app.Config.DiskQuotaBytes(); quota > 0 && time.Since(lastDiskCheck) >= constants.DiskCheckInterval {
+				lastDiskCheck = time.Now()
+				if usage, err := mgr.DiskUsage(); err == nil {
+					if usage.OverQuota(quota) && !overQuota {
+						logging.Warn(".taw uses %s, over the %s disk quota", task.FormatSize(usage.Total), task.FormatSize(quota))
+						tm.DisplayMessage(fmt.Sprintf(icon.Warning.String()+" .taw uses %s (quota %s): run 'taw status' for cleanup candidates, or 'taw gc'", task.FormatSize(usage.Total), task.FormatSize(quota)))
+					}
+					overQuota = usage.OverQuota(quota)
+				}
 			}
 
-			// End task
-			tawBin, _ := os.Executable()
-			exec.Command(tawBin, "internal", "end-task", sessionName, w.ID).Run()
+			select {
+			case <-ctx.Done():
+				break loop
+			case <-ticker.C:
+			}
+
+			if !tm.HasSession(sessionName) {
+				logging.Log("Session ended")
+				break
+			}
+		}
+
+		server.Close()
+		runner.Wait()
+		logging.Log("Daemon stopped")
+		return nil
+	},
+}
+
+var sendCmd = &cobra.Command{
+	Use:   "send [session] [job] [args...]",
+	Short: "Ask the session daemon to run an internal command",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return spawnInternal(args[0], args[1], args[2:]...)
+	},
+}
+
+var quickTaskCmd = &cobra.Command{
+	Use:   "quick-task [session]",
+	Short: "Add a quick task to the queue",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		sessionName := args[0]
+
+		app, err := getAppFromSession(ctx, sessionName)
+		if err != nil {
+			return err
+		}
+
+		tm := tmux.New(sessionName)
+
+		// Use tmux display-popup to get input
+		tawBin, _ := os.Executable()
+		popupCmd := fmt.Sprintf("read -p 'Quick task: ' task && echo \"$task\" >> %s/.queue/$(date +%%s).task && \"%s\" internal queue-status %s",
+			app.TawDir, tawBin, sessionName)
+
+		return tm.DisplayPopup(tmux.PopupOpts{
+			Width:  "60",
+			Height: "3",
+			Title:  "Quick Task",
+			Close:  true,
+		}, fmt.Sprintf("bash -c '%s'", popupCmd))
+	},
+}
+
+var queueStatusCmd = &cobra.Command{
+	Use:   "queue-status [session]",
+	Short: "Show the queue in the status bar",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		app, err := getAppFromSession(cmd.Context(), args[0])
+		if err != nil {
+			return err
+		}
+		updateQueueStatus(tmux.New(args[0]), app)
+		return nil
+	},
+}
+
+var mergeCompletedCmd = &cobra.Command{
+	Use:   "merge-completed [session]",
+	Short: "Open the merge popup for completed tasks",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		sessionName := args[0]
+		tm := tmux.New(sessionName)
+
+		app, err := getAppFromSession(ctx, sessionName)
+		if err != nil {
+			return err
+		}
+		if !app.IsGitRepo {
+			tm.DisplayMessage(icon.Warning.String() + " Merging only works in git repositories")
+			return nil
+		}
+
+		mgr := task.NewManager(app.AgentsDir, app.ProjectDir, app.TawDir, app.IsGitRepo, app.Config)
+		tasks, err := mgr.MergeableTasks()
+		if err != nil {
+			return err
+		}
+		if len(tasks) == 0 {
+			tm.DisplayMessage("No completed tasks to merge")
+			return nil
+		}
+
+		tawBin, _ := os.Executable()
+		return tm.DisplayPopup(tmux.PopupOpts{
+			Width:  "80%",
+			Height: "60%",
+			Title:  " Merge completed tasks ",
+			Close:  true,
+		}, fmt.Sprintf("%s internal merge-completed-ui '%s'", tawBin, sessionName))
+	},
+}
+
+var mergeCompletedUICmd = &cobra.Command{
+	Use:   "merge-completed-ui [session]",
+	Short: "Select completed tasks and merge them",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		sessionName := args[0]
+
+		app, err := getAppFromSession(ctx, sessionName)
+		if err != nil {
+			return err
+		}
+
+		// Setup logging
+		logger, _ := logging.New(app.GetLogPath(), app.Debug)
+		if logger != nil {
+			defer logger.Close()
+			logger.SetScript("merge-completed")
+			logging.SetGlobal(logger)
+		}
+
+		mgr := task.NewManager(app.AgentsDir, app.ProjectDir, app.TawDir, app.IsGitRepo, app.Config)
+		tasks, err := mgr.MergeableTasks()
+		if err != nil {
+			return err
+		}
+		if len(tasks) == 0 {
+			fmt.Println("No completed tasks to merge")
+			return nil
 		}
 
+		fmt.Println("Checking completed tasks...")
+		candidates := mgr.MergeCandidates(ctx, tasks)
+
+		failed, err := tui.RunMergeUI(ctx, candidates, func(ctx context.Context, t *task.Task) error {
+			windowID, _ := t.LoadWindowID()
+			if err := endTask(ctx, app, mgr, sessionName, windowID, t, true); err != nil {
+				return err
+			}
+			if reason, kept := keptOpen(mgr, t); kept {
+				return fmt.Errorf("kept open, %s", reason)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if failed > 0 {
+			logging.Warn("%d task(s) failed to merge", failed)
+		}
 		return nil
 	},
 }
@@ -629,6 +1312,7 @@ var popupShellCmd = &cobra.Command{
 	Short: "Toggle popup shell",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
 		sessionName := args[0]
 		tm := tmux.New(sessionName)
 
@@ -637,7 +1321,7 @@ var popupShellCmd = &cobra.Command{
 		if isOpen == "1" {
 			// Close popup using display-popup -C
 			tm.SetOption("@taw_popup_open", "", true)
-			tm.Run("display-popup", "-C")
+			tm.ClosePopup()
 			return nil
 		}
 
@@ -647,7 +1331,7 @@ var popupShellCmd = &cobra.Command{
 		panePath, err := tm.Display("#{pane_current_path}")
 		if err != nil || panePath == "" {
 			// Fallback to project dir
-			app, err := getAppFromSession(sessionName)
+			app, err := getAppFromSession(ctx, sessionName)
 			if err != nil {
 				return err
 			}
@@ -678,6 +1362,7 @@ var toggleLogCmd = &cobra.Command{
 	Short: "Toggle log viewer",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
 		sessionName := args[0]
 		tm := tmux.New(sessionName)
 
@@ -686,11 +1371,11 @@ var toggleLogCmd = &cobra.Command{
 		if isOpen == "1" {
 			// Close popup using display-popup -C
 			tm.SetOption("@taw_log_open", "", true)
-			tm.Run("display-popup", "-C")
+			tm.ClosePopup()
 			return nil
 		}
 
-		app, err := getAppFromSession(sessionName)
+		app, err := getAppFromSession(ctx, sessionName)
 		if err != nil {
 			return err
 		}
@@ -729,95 +1414,1241 @@ var logViewerCmd = &cobra.Command{
 	},
 }
 
-var toggleHelpCmd = &cobra.Command{
-	Use:   "toggle-help [session]",
-	Short: "Toggle help popup",
-	Args:  cobra.ExactArgs(1),
+var showDiffCmd = &cobra.Command{
+	Use:   "show-diff [session] [task-id]",
+	Short: "Toggle the diff viewer of a task",
+	Args:  cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
 		sessionName := args[0]
+		taskID := args[1]
 		tm := tmux.New(sessionName)
 
-		// Check if help popup is open
-		isOpen, _ := tm.GetOption("@taw_help_open")
+		// Check if diff popup is open
+		isOpen, _ := tm.GetOption("@taw_diff_open")
 		if isOpen == "1" {
-			// Close popup using display-popup -C
-			tm.SetOption("@taw_help_open", "", true)
-			tm.Run("display-popup", "-C")
+			tm.SetOption("@taw_diff_open", "", true)
+			tm.ClosePopup()
 			return nil
 		}
 
-		tm.SetOption("@taw_help_open", "1", true)
-
-		// Get help content from embedded assets
-		helpContent, err := embed.GetHelp()
+		app, err := getAppFromSession(ctx, sessionName)
 		if err != nil {
-			return fmt.Errorf("failed to get help content: %w", err)
+			return err
+		}
+		if !app.IsGitRepo {
+			tm.DisplayMessage("Diffs need a git repository")
+			return nil
 		}
 
-		// Write to temp file
-		tmpFile, err := os.CreateTemp("", "taw-help-*.md")
+		mgr := task.NewManager(app.AgentsDir, app.ProjectDir, app.TawDir, app.IsGitRepo, app.Config)
+		targetTask, _, err := findTaskByID(tm, mgr, taskID)
 		if err != nil {
-			return fmt.Errorf("failed to create temp file: %w", err)
+			tm.DisplayMessage(fmt.Sprintf("No diff: %v", err))
+			return nil
 		}
-		tmpPath := tmpFile.Name()
-		if _, err := tmpFile.WriteString(helpContent); err != nil {
-			tmpFile.Close()
-			return fmt.Errorf("failed to write help content: %w", err)
+
+		tm.SetOption("@taw_diff_open", "1", true)
+
+		tawBin, err := os.Executable()
+		if err != nil {
+			tawBin = "taw"
 		}
-		tmpFile.Close()
 
-		// Build command with lesskey bindings for Alt+H and Alt+/ to quit
-		// Creates a temp keyfile, uses LESSKEYIN to load it, then cleans up
-		popupCmd := fmt.Sprintf(
-			"KEYFILE=$(mktemp) && printf '#command\\n\\\\eh quit\\n\\\\e/ quit\\n' > \"$KEYFILE\" && "+
-				"LESSKEYIN=\"$KEYFILE\" less '%s'; "+
-				"rm -f '%s' \"$KEYFILE\" 2>/dev/null || true; "+
-				"tmux -L 'taw-%s' set-option -g @taw_help_open '' 2>/dev/null || true",
-			tmpPath, tmpPath, sessionName)
+		// Build command that clears state on exit
+		diffCmd := fmt.Sprintf("%s internal diff-viewer '%s' '%s'; tmux -L 'taw-%s' set-option -g @taw_diff_open '' 2>/dev/null || true",
+			tawBin, sessionName, targetTask.Name, sessionName)
 
 		return tm.DisplayPopup(tmux.PopupOpts{
-			Width:  "80%",
-			Height: "80%",
-			Title:  " Help (⌥h or q to close) ",
+			Width:  "95%",
+			Height: "90%",
+			Title:  fmt.Sprintf(" Diff: %s (↑↓:scroll  n/N:file  s:split  r:refresh  q:quit) ", targetTask.Name),
 			Close:  true,
-		}, popupCmd)
+		}, diffCmd)
 	},
 }
 
-var recoverTaskCmd = &cobra.Command{
-	Use:   "recover-task [session] [task-name]",
-	Short: "Recover a corrupted task",
-	Args:  cobra.ExactArgs(2),
+var diffViewerCmd = &cobra.Command{
+	Use:    "diff-viewer [session] [task-name]",
+	Short:  "Run the diff viewer",
+	Args:   cobra.ExactArgs(2),
+	Hidden: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		sessionName := args[0]
-		taskName := args[1]
+		ctx := cmd.Context()
 
-		app, err := getAppFromSession(sessionName)
+		app, err := getAppFromSession(ctx, args[0])
 		if err != nil {
 			return err
 		}
-
 		mgr := task.NewManager(app.AgentsDir, app.ProjectDir, app.TawDir, app.IsGitRepo, app.Config)
-		t, err := mgr.GetTask(taskName)
+		t, err := mgr.GetTask(args[1])
 		if err != nil {
 			return err
 		}
+		return tui.RunDiffViewer(func() (string, error) {
+			return mgr.Diff(ctx, t)
+		})
+	},
+}
 
-		recoveryMgr := task.NewRecoveryManager(app.ProjectDir)
-		if err := recoveryMgr.RecoverTask(t); err != nil {
-			return fmt.Errorf("failed to recover task: %w", err)
+var dashboardCmd = &cobra.Command{
+	Use:    "dashboard [session]",
+	Short:  "Run the dashboard",
+	Args:   cobra.ExactArgs(1),
+	Hidden: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		app, err := getAppFromSession(cmd.Context(), args[0])
+		if err != nil {
+			return err
 		}
 
-		fmt.Printf("Task %s recovered successfully\n", taskName)
-		return nil
+		// Nudging and restarting agents type into their panes
+		mgr := task.NewManager(app.AgentsDir, app.ProjectDir, app.TawDir, app.IsGitRepo, app.Config)
+		mgr.SetTmuxClient(tmux.New(args[0]))
+		// handle-task recreates the worktree of a hibernated task
+		reopen := func(t *task.Task) error {
+			return spawnInternal(args[0], "handle-task", t.AgentDir)
+		}
+		return tui.RunDashboard(mgr, task.NewQueueManager(app.QueueDir), task.NewOutbox(app.OutboxDir), reopen)
 	},
 }
 
-// getAppFromSession creates an App from session name
-func getAppFromSession(sessionName string) (*app.App, error) {
-	// Session name is the project directory name
-	// We need to find the project directory
-
+var showWindowCmd = &cobra.Command{
+	Use:       "show-window [session] [dashboard|logs]",
+	Short:     "Jump to a session window, opening it if needed",
+	Args:      cobra.ExactArgs(2),
+	ValidArgs: []string{constants.DashboardWindow, constants.LogsWindow},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sessionName := args[0]
+		tm := tmux.New(sessionName)
+
+		app, err := getAppFromSession(cmd.Context(), sessionName)
+		if err != nil {
+			return err
+		}
+
+		windowID, err := openSessionWindow(app, tm, args[1], false)
+		if err != nil {
+			return err
+		}
+		return tm.SelectWindow(windowID)
+	},
+}
+
+// openSessionWindow returns the ID of the dashboard or logs window, creating
+// it if it isn't open. The window is found by the ID saved in a tmux option,
+// so renaming it doesn't matter.
+func openSessionWindow(app *app.App, tm tmux.Client, name string, detached bool) (string, error) {
+	tawBin, err := os.Executable()
+	if err != nil {
+		tawBin = "taw"
+	}
+
+	var command string
+	var windowIcon icon.Icon
+	switch name {
+	case constants.DashboardWindow:
+		command = fmt.Sprintf("%s internal dashboard %s", tawBin, app.SessionName)
+		windowIcon = icon.Dashboard
+	case constants.LogsWindow:
+		command = fmt.Sprintf("%s internal log-viewer %s", tawBin, app.GetLogPath())
+		windowIcon = icon.Logs
+	default:
+		return "", fmt.Errorf("unknown window: %s (expected %s or %s)", name, constants.DashboardWindow, constants.LogsWindow)
+	}
+
+	option := fmt.Sprintf("@taw_%s_window", name)
+	if windowID, _ := tm.GetOption(option); windowID != "" {
+		windows, err := tm.ListWindows()
+		if err != nil {
+			return "", err
+		}
+		for _, w := range windows {
+			if w.ID == windowID {
+				return windowID, nil
+			}
+		}
+	}
+
+	windowID, err := tm.NewWindow(tmux.WindowOpts{
+		Name:       windowIcon.Label(name),
+		StartDir:   app.ProjectDir,
+		Command:    command,
+		Detached:   detached,
+		AfterIndex: -1,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s window: %w", name, err)
+	}
+	if err := tm.SetOption(option, windowID, true); err != nil {
+		logging.Debug("Failed to save %s window ID: %v", name, err)
+	}
+	return windowID, nil
+}
+
+var toggleHelpCmd = &cobra.Command{
+	Use:   "toggle-help [session]",
+	Short: "Toggle help popup",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sessionName := args[0]
+		tm := tmux.New(sessionName)
+
+		// Check if help popup is open
+		isOpen, _ := tm.GetOption("@taw_help_open")
+		if isOpen == "1" {
+			// Close popup using display-popup -C
+			tm.SetOption("@taw_help_open", "", true)
+			tm.ClosePopup()
+			return nil
+		}
+
+		tm.SetOption("@taw_help_open", "1", true)
+
+		// Loading the config sets the icon mode (error is non-fatal - emoji are shown)
+		if _, err := getAppFromSession(cmd.Context(), sessionName); err != nil {
+			logging.Debug("Failed to load config for help: %v", err)
+		}
+
+		// Get help content from embedded assets
+		helpContent, err := embed.GetHelp()
+		if err != nil {
+			return fmt.Errorf("failed to get help content: %w", err)
+		}
+		helpContent = icon.Localize(helpContent)
+
+		// Write to temp file
+		tmpFile, err := os.CreateTemp("", "taw-help-*.md")
+		if err != nil {
+			return fmt.Errorf("failed to create temp file: %w", err)
+		}
+		tmpPath := tmpFile.Name()
+		if _, err := tmpFile.WriteString(helpContent); err != nil {
+			tmpFile.Close()
+			return fmt.Errorf("failed to write help content: %w", err)
+		}
+		tmpFile.Close()
+
+		// Build command with lesskey bindings for Alt+H and Alt+/ to quit
+		// Creates a temp keyfile, uses LESSKEYIN to load it, then cleans up
+		popupCmd := fmt.Sprintf(
+			"KEYFILE=$(mktemp) && printf '#command\\n\\\\eh quit\\n\\\\e/ quit\\n' > \"$KEYFILE\" && "+
+				"LESSKEYIN=\"$KEYFILE\" less '%s'; "+
+				"rm -f '%s' \"$KEYFILE\" 2>/dev/null || true; "+
+				"tmux -L 'taw-%s' set-option -g @taw_help_open '' 2>/dev/null || true",
+			tmpPath, tmpPath, sessionName)
+
+		return tm.DisplayPopup(tmux.PopupOpts{
+			Width:  "80%",
+			Height: "80%",
+			Title:  fmt.Sprintf(" Help (%s or q to close) ", icon.Key("h")),
+			Close:  true,
+		}, popupCmd)
+	},
+}
+
+var recoverTaskCmd = &cobra.Command{
+	Use:               "recover-task [session] [task-name]",
+	Short:             "Recover a corrupted task",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeTaskName(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		sessionName := args[0]
+		taskName := args[1]
+
+		app, err := getAppFromSession(ctx, sessionName)
+		if err != nil {
+			return err
+		}
+
+		mgr := task.NewManager(app.AgentsDir, app.ProjectDir, app.TawDir, app.IsGitRepo, app.Config)
+		if _, err := mgr.GetTask(taskName); err != nil {
+			return err
+		}
+
+		// The scan tells what's wrong with the task
+		tm := tmux.New(sessionName)
+		if tm.HasSession(sessionName) {
+			mgr.SetTmuxClient(tm)
+		}
+		corrupted, err := mgr.FindCorruptedTasks(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to check task: %w", err)
+		}
+		var t *task.Task
+		for _, c := range corrupted {
+			if c.Name == taskName {
+				t = c
+			}
+		}
+		if t == nil {
+			fmt.Printf("Task %s is not corrupted\n", taskName)
+			return nil
+		}
+
+		recoveryMgr := task.NewRecoveryManager(app.ProjectDir)
+		recoveryMgr.SetTmuxClient(tm)
+		if err := recoveryMgr.RecoverTask(ctx, t); err != nil {
+			return fmt.Errorf("failed to recover task: %w", err)
+		}
+		if t.CorruptedReason == task.CorruptStaleTabLock && tm.HasSession(sessionName) {
+			if err := spawnInternal(sessionName, "handle-task", t.AgentDir); err != nil {
+				return fmt.Errorf("failed to reopen window: %w", err)
+			}
+		}
+
+		fmt.Printf("Task %s recovered successfully\n", taskName)
+		return nil
+	},
+}
+
+var setStatusCmd = &cobra.Command{
+	Use:               "set-status [session] [task-name] [status]",
+	Short:             "Record a task's status (working, waiting or done)",
+	Args:              cobra.ExactArgs(3),
+	ValidArgsFunction: completeTaskName(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		sessionName := args[0]
+		taskName := args[1]
+
+		status := task.Status(args[2])
+		switch status {
+		case task.StatusWorking, task.StatusWaiting, task.StatusDone:
+		default:
+			return fmt.Errorf("invalid status: %s (use working, waiting or done)", args[2])
+		}
+
+		app, err := getAppFromSession(ctx, sessionName)
+		if err != nil {
+			return err
+		}
+
+		mgr := task.NewManager(app.AgentsDir, app.ProjectDir, app.TawDir, app.IsGitRepo, app.Config)
+		t, err := mgr.GetTask(taskName)
+		if err != nil {
+			return err
+		}
+
+		if err := t.SaveStatus(status); err != nil {
+			return fmt.Errorf("failed to save status: %w", err)
+		}
+		logging.Log("Status of %s: %s", taskName, status)
+
+		// The window's options and position only display the status
+		tm := tmux.New(sessionName)
+		if windowID := taskWindow(tm, t); windowID != "" {
+			if err := t.SyncWindow(tm, windowID); err != nil {
+				logging.Debug("Failed to update window: %v", err)
+			}
+		}
+		mgr.SetTmuxClient(tm)
+		if err := mgr.ArrangeWindows(); err != nil {
+			logging.Debug("Failed to arrange windows: %v", err)
+		}
+
+		if status == task.StatusWaiting || status == task.StatusDone {
+			notifyStatus(app, tm, taskName, status)
+		}
+		return nil
+	},
+}
+
+// notifyStatus tells the user that a task needs them or is done, as set by
+// the notifications setting (errors are non-fatal).
+func notifyStatus(app *app.App, tm tmux.Client, taskName string, status task.Status) {
+	if app.Config.Notifications == config.NotificationsNone {
+		return
+	}
+
+	message := fmt.Sprintf("%s is done", taskName)
+	if status == task.StatusWaiting {
+		message = fmt.Sprintf("%s needs your input", taskName)
+	}
+	notifyUser(app, tm, message)
+}
+
+// notifyUser shows a message in tmux, and on the desktop too with
+// notifications: desktop (errors are non-fatal).
+func notifyUser(app *app.App, tm tmux.Client, message string) {
+	if err := tm.DisplayMessage(message); err != nil {
+		logging.Debug("Failed to display notification: %v", err)
+	}
+	if app.Config.Notifications == config.NotificationsDesktop {
+		if err := notify.Send("taw: "+filepath.Base(app.ProjectDir), message); err != nil {
+			logging.Debug("Failed to send desktop notification: %v", err)
+		}
+	}
+}
+
+// watchInbox queues the files dropped into the inbox until ctx is done, and
+// starts the queue when new tasks arrive.
+func watchInbox(ctx context.Context, app *app.App, mgr *task.Manager, runner *daemon.Runner) {
+	queue := task.NewQueueManager(app.QueueDir)
+	ticker := time.NewTicker(constants.InboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		queued, err := mgr.IngestInbox(queue)
+		if err != nil {
+			logging.Warn("Failed to read the inbox: %v", err)
+		}
+		for _, name := range queued {
+			logging.Log("Queued %s from the inbox", name)
+		}
+		if len(queued) > 0 {
+			runner.Submit("process-queue")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkBatches saves the report of each batch whose tasks all finished, and
+// tells the user (errors are non-fatal).
+func checkBatches(app *app.App, mgr *task.Manager, tm tmux.Client) {
+	queue, err := task.NewQueueManager(app.QueueDir).List()
+	if err != nil {
+		logging.Debug("Failed to read queue: %v", err)
+		return
+	}
+	reports, err := mgr.FinishBatches(queue)
+	if err != nil {
+		logging.Warn("Failed to check batches: %v", err)
+	}
+	for _, r := range reports {
+		logging.Log("Batch %s finished: %s (report: %s)", r.Batch.ID, r.Summary(), mgr.GetBatchReportPath(r.Batch))
+		notifyUser(app, tm, fmt.Sprintf(icon.Done.String()+" Batch %s finished: %s ('taw batch report')", r.Batch.ID, r.Summary()))
+	}
+}
+
+// checkPRComments tells the user about new comments on the pull requests of
+// tasks with a window, and sets the tasks to waiting or, with
+// pr_comments.forward, types the comments into their agent's pane (errors
+// are non-fatal).
+func checkPRComments(ctx context.Context, app *app.App, mgr *task.Manager, tm tmux.Client) {
+	tasks, err := mgr.ListTasks()
+	if err != nil {
+		logging.Debug("Failed to list tasks: %v", err)
+		return
+	}
+	for _, t := range tasks {
+		if t.PRNumber <= 0 {
+			continue
+		}
+		windowID := taskWindow(tm, t)
+		if windowID == "" {
+			continue
+		}
+		comments, err := mgr.NewPRComments(ctx, t)
+		if err != nil {
+			logging.Debug("Failed to check comments of %s: %v", t.Name, err)
+			continue
+		}
+		if len(comments) == 0 {
+			continue
+		}
+
+		last := comments[len(comments)-1]
+		logging.Log("%d new comment(s) on PR #%d of %s, the last by %s", len(comments), t.PRNumber, t.Name, last.Author)
+		message := fmt.Sprintf(icon.Waiting.String()+" %s: %d new comment(s) on PR #%d, the last by %s", t.Name, len(comments), t.PRNumber, last.Author)
+
+		if mgr.ForwardPRComments() {
+			instruction := task.PRCommentsInstruction(t.PRNumber, comments)
+			if err := mgr.SendInstruction(ctx, tm, t, windowID+".0", task.InstructionComment, instruction); err != nil {
+				logging.Warn("Failed to forward comments to %s: %v", t.Name, err)
+			} else {
+				notifyUser(app, tm, message+"; forwarded to the agent")
+				continue
+			}
+		}
+
+		if t.Status != task.StatusWaiting {
+			if err := t.SaveStatus(task.StatusWaiting); err != nil {
+				logging.Warn("Failed to set %s to waiting: %v", t.Name, err)
+			} else if err := t.SyncWindow(tm, windowID); err != nil {
+				logging.Debug("Failed to update window: %v", err)
+			}
+		}
+		notifyUser(app, tm, message)
+	}
+}
+
+// checkStaleTasks tells the user about tasks in progress that went untouched
+// for stale.days, and parks those still untouched after stale.grace_days
+// (errors are non-fatal).
+func checkStaleTasks(ctx context.Context, app *app.App, mgr *task.Manager, tm tmux.Client) {
+	tasks, err := mgr.ParkableTasks()
+	if err != nil {
+		logging.Debug("Failed to list tasks: %v", err)
+		return
+	}
+	for _, t := range tasks {
+		flagged, due, err := mgr.CheckStale(ctx, t)
+		if err != nil {
+			logging.Debug("Failed to check whether %s is stale: %v", t.Name, err)
+			continue
+		}
+		if flagged {
+			logging.Warn("%s is stale: untouched for %d day(s)", t.Name, mgr.StaleDays())
+			notifyUser(app, tm, fmt.Sprintf(icon.Warning.String()+" %s is untouched for %d day(s) and will be parked unless it's worked on", t.Name, mgr.StaleDays()))
+		}
+		if !due {
+			continue
+		}
+
+		unlock, err := lockTask(tm, mgr, t, "park-stale")
+		if err != nil {
+			continue
+		}
+		saved, err := mgr.ParkStaleTask(ctx, t)
+		unlock()
+		if err != nil {
+			logging.Warn("Failed to park stale %s: %v", t.Name, err)
+			continue
+		}
+		logging.Log("Parked stale %s: work saved to %s, worktree removed", t.Name, saved)
+		notifyUser(app, tm, fmt.Sprintf(icon.Done.String()+" Parked stale %s (%s); open it again from the dashboard", t.Name, saved))
+	}
+}
+
+// checkAgentHealth flags working agents whose pane stopped changing, and
+// renames the windows of tasks that became or stopped being stuck.
+func checkAgentHealth(app *app.App, mgr *task.Manager, tm tmux.Client) {
+	tasks, err := mgr.ListTasks()
+	if err != nil {
+		logging.Debug("Failed to list tasks: %v", err)
+		return
+	}
+	for _, t := range tasks {
+		stuck, changed, err := mgr.CheckHealth(t)
+		if err != nil {
+			logging.Debug("Failed to check health of %s: %v", t.Name, err)
+		}
+		if !changed {
+			continue
+		}
+		if windowID := taskWindow(tm, t); windowID != "" {
+			if err := t.SyncWindow(tm, windowID); err != nil {
+				logging.Debug("Failed to update window: %v", err)
+			}
+		}
+		if stuck {
+			logging.Warn("%s may be stuck: its pane hasn't changed for %s", t.Name, app.Config.Agent.StuckAfter)
+			notifyUser(app, tm, fmt.Sprintf(icon.Stuck.String()+" %s may be stuck (no output for %s); nudge or restart it from the dashboard", t.Name, app.Config.Agent.StuckAfter))
+		}
+	}
+}
+
+// checkRateLimits starts or extends the session's rate limit backoff for
+// agents showing a new rate limit or overload error.
+func checkRateLimits(app *app.App, mgr *task.Manager, tm tmux.Client) {
+	tasks, err := mgr.ListTasks()
+	if err != nil {
+		logging.Debug("Failed to list tasks: %v", err)
+		return
+	}
+	for _, t := range tasks {
+		reason, hit, err := mgr.CheckRateLimit(t)
+		if err != nil {
+			logging.Debug("Failed to check %s for rate limits: %v", t.Name, err)
+		}
+		if !hit {
+			continue
+		}
+		limit, err := mgr.RecordRateLimit(t, reason)
+		if err != nil {
+			logging.Warn("Failed to record rate limit: %v", err)
+			continue
+		}
+		logging.Warn("%s in %s: pausing new tasks until %s", reason, t.Name, limit.Until.Format("15:04:05"))
+		notifyUser(app, tm, fmt.Sprintf(icon.Warning.String()+" %s in %s: new tasks wait until %s", reason, t.Name, limit.Until.Format("15:04")))
+	}
+}
+
+// checkBudgets records the cost of unfinished tasks and tells the user once
+// when a task or the day goes over budget. With budget.on_exceed: stop, the
+// agents over budget are stopped.
+func checkBudgets(app *app.App, mgr *task.Manager, tm tmux.Client) {
+	tasks, err := mgr.ListTasks()
+	if err != nil {
+		logging.Debug("Failed to list tasks: %v", err)
+		return
+	}
+	policy := mgr.BudgetPolicy()
+	for _, t := range tasks {
+		if t.Status == task.StatusDone {
+			continue
+		}
+		status, err := mgr.CheckBudget(t)
+		if err != nil {
+			logging.Debug("Failed to check budget of %s: %v", t.Name, err)
+			if status == nil {
+				continue
+			}
+		}
+
+		if status.TaskExceeded {
+			logging.Warn("%s is over its budget: $%.2f of $%.2f", t.Name, status.TaskCost, status.TaskBudget)
+			notifyUser(app, tm, fmt.Sprintf(icon.Warning.String()+" %s is over its $%.2f budget ($%.2f spent)", t.Name, status.TaskBudget, status.TaskCost))
+		}
+		if status.DayExceeded {
+			logging.Warn("Today's spend is over budget: $%.2f of $%.2f", status.DayCost, status.DayBudget)
+			message := fmt.Sprintf(icon.Warning.String()+" Today's spend is over the $%.2f budget ($%.2f spent)", status.DayBudget, status.DayCost)
+			if policy != config.BudgetWarn {
+				message += "; new tasks are queued"
+			}
+			notifyUser(app, tm, message)
+		}
+
+		if policy == config.BudgetStop && t.Status == task.StatusWorking && (status.TaskOver() || status.DayOver()) {
+			if err := mgr.StopAgent(t); err != nil {
+				logging.Warn("Failed to stop agent of %s: %v", t.Name, err)
+				continue
+			}
+			logging.Log("Stopped agent of %s: over budget", t.Name)
+			notifyUser(app, tm, fmt.Sprintf(icon.Warning.String()+" Stopped %s: over budget ('taw budget' to raise it, then restart it from the dashboard)", t.Name))
+		}
+	}
+}
+
+// rateLimitBanner returns the status bar text shown during a rate limit
+// backoff, or "" when there's none.
+func rateLimitBanner(mgr *task.Manager) string {
+	limit := mgr.RateLimited()
+	if limit == nil {
+		return ""
+	}
+	return fmt.Sprintf(" %s %s: new tasks paused until %s ", icon.Warning, limit.Reason, limit.Until.Format("15:04"))
+}
+
+// queueSegment returns the status bar segment naming the next queued tasks,
+// or "" when the queue is empty.
+func queueSegment(queue []task.QueuedTask) string {
+	if len(queue) == 0 {
+		return ""
+	}
+	var titles []string
+	for i := 0; i < len(queue) && i < constants.QueuePreviewCount; i++ {
+		titles = append(titles, queue[i].Title())
+	}
+	if len(queue) > constants.QueuePreviewCount {
+		titles = append(titles, "…")
+	}
+	return fmt.Sprintf(" %s %d queued: %s |", icon.Queue, len(queue), strings.Join(titles, ", "))
+}
+
+// updateQueueStatus shows the queue in the status bar (error is non-fatal).
+func updateQueueStatus(tm tmux.Client, app *app.App) {
+	queue, err := task.NewQueueManager(app.QueueDir).List()
+	if err != nil {
+		logging.Debug("Failed to read queue: %v", err)
+		return
+	}
+	if err := tm.SetOption(constants.QueueOption, queueSegment(queue), true); err != nil {
+		logging.Debug("Failed to show queue: %v", err)
+	}
+}
+
+// queueHeader returns comment lines listing the queue for the new-task
+// editor, or "" when the queue is empty.
+func queueHeader(queue []task.QueuedTask) string {
+	if len(queue) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "#\n# Queue: %d task(s) waiting to start\n", len(queue))
+	for i := 0; i < len(queue) && i < constants.QueuePreviewCount; i++ {
+		fmt.Fprintf(&sb, "#   %d. %s\n", i+1, queue[i].Title())
+	}
+	if len(queue) > constants.QueuePreviewCount {
+		fmt.Fprintf(&sb, "#   ... and %d more ('taw status' lists them)\n", len(queue)-constants.QueuePreviewCount)
+	}
+	return sb.String()
+}
+
+// isolationHeader returns the comment lines of the editor template telling
+// how to make a read-only task skip its worktree, or "" outside worktree mode.
+func isolationHeader(app *app.App) string {
+	if !app.IsGitRepo || app.Config.WorkMode != config.WorkModeWorktree {
+		return ""
+	}
+	var tags []string
+	for _, tag := range app.Config.ReadOnlyTags {
+		tags = append(tags, "#"+strings.TrimPrefix(tag, "#"))
+	}
+	tags = append(tags, "#isolation-none")
+	return fmt.Sprintf("#\n# Read-only task (research, a question)? Tag it %s\n# to skip the worktree and work in the project directory.\n", strings.Join(tags, ", "))
+}
+
+// runAgentContinue runs the resume script of the agent.
+var runAgentContinue bool
+
+var runAgentCmd = &cobra.Command{
+	Use:   "run-agent [session] [task-name]",
+	Short: "Run a task's agent script in its pane and report its exit",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		sessionName := args[0]
+		taskName := args[1]
+
+		app, err := getAppFromSession(ctx, sessionName)
+		if err != nil {
+			return err
+		}
+		mgr := task.NewManager(app.AgentsDir, app.ProjectDir, app.TawDir, app.IsGitRepo, app.Config)
+		t, err := mgr.GetTask(taskName)
+		if err != nil {
+			return err
+		}
+
+		// Ctrl-C in the pane is for the agent; run-agent waits for it to exit
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGQUIT)
+		defer signal.Stop(signals)
+
+		agent := exec.Command("sh", t.GetAgentScriptPath(runAgentContinue))
+		agent.Stdin = os.Stdin
+		agent.Stdout = os.Stdout
+		agent.Stderr = os.Stderr
+		code := 0
+		if err := agent.Run(); err != nil {
+			var exitErr *exec.ExitError
+			if !errors.As(err, &exitErr) {
+				return fmt.Errorf("failed to run agent: %w", err)
+			}
+			// Like $? in sh: 128 plus the signal for a killed agent
+			code = exitErr.ExitCode()
+			if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+				code = 128 + int(status.Signal())
+			}
+		}
+
+		return agentExitedCmd.RunE(cmd, []string{sessionName, taskName, strconv.Itoa(code)})
+	},
+}
+
+var agentExitedCmd = &cobra.Command{
+	Use:   "agent-exited [session] [task-name] [exit-code]",
+	Short: "Handle the agent of a task window exiting (restart it if it crashed)",
+	Args:  cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		sessionName := args[0]
+		taskName := args[1]
+
+		code, err := strconv.Atoi(args[2])
+		if err != nil {
+			return fmt.Errorf("invalid exit code: %s", args[2])
+		}
+
+		app, err := getAppFromSession(ctx, sessionName)
+		if err != nil {
+			return err
+		}
+
+		// Setup logging
+		logger, _ := logging.New(app.GetLogPath(), app.Debug)
+		if logger != nil {
+			defer logger.Close()
+			logger.SetScript("agent-exited")
+			logger.SetTask(taskName)
+			logging.SetGlobal(logger)
+		}
+
+		// The task is gone when its window was killed by end-task
+		mgr := task.NewManager(app.AgentsDir, app.ProjectDir, app.TawDir, app.IsGitRepo, app.Config)
+		t, err := mgr.GetTask(taskName)
+		if err != nil {
+			return nil
+		}
+		tm := tmux.New(sessionName)
+		mgr.SetTmuxClient(tm)
+
+		action, err := mgr.AgentExited(t, code)
+		switch action {
+		case task.WatchdogNone:
+			logging.Log("Agent exited (code %d)", code)
+			return nil
+		case task.WatchdogRestarted:
+			logging.Warn("Agent crashed (code %d), restarted (%d/%d)", code, t.LoadRestarts(), constants.MaxAgentRestarts)
+			notifyUser(app, tm, fmt.Sprintf(icon.Warning.String()+" %s: agent crashed (exit %d), restarting", taskName, code))
+			return nil
+		}
+
+		if err != nil {
+			logging.Warn("Agent crashed (code %d): %v", code, err)
+		} else {
+			logging.Warn("Agent crashed (code %d)", code)
+		}
+		emitEvent(ctx, app, mgr, sessionName, config.EventTaskFailed, t, fmt.Sprintf("agent crashed (exit %d)", code))
+		if windowID := taskWindow(tm, t); windowID != "" {
+			if err := t.SyncWindow(tm, windowID); err != nil {
+				logging.Debug("Failed to update window: %v", err)
+			}
+		}
+		if err := mgr.ArrangeWindows(); err != nil {
+			logging.Debug("Failed to arrange windows: %v", err)
+		}
+		if errors.Is(err, claude.ErrNotInstalled) {
+			notifyUser(app, tm, fmt.Sprintf(icon.Warning.String()+" %s: %s", taskName, claude.ErrorHint(err)))
+			return nil
+		}
+		notifyUser(app, tm, fmt.Sprintf(icon.Warning.String()+" %s: agent crashed (exit %d); run 'taw' to restart it", taskName, code))
+		return nil
+	},
+}
+
+var paneInfoCmd = &cobra.Command{
+	Use:               "pane-info [session] [task-name]",
+	Short:             "Print the cheat sheet of a task's user pane",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeTaskName(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		taskName := args[1]
+
+		app, err := getAppFromSession(ctx, args[0])
+		if err != nil {
+			return err
+		}
+
+		mgr := task.NewManager(app.AgentsDir, app.ProjectDir, app.TawDir, app.IsGitRepo, app.Config)
+		t, err := mgr.GetTask(taskName)
+		if err != nil {
+			return err
+		}
+		workDir := mgr.GetWorkingDirectory(t)
+
+		fmt.Printf("Task:    %s (%s)\n", t.Name, t.Status)
+		if app.IsGitRepo {
+			gitClient := git.NewWithBackend(git.Backend(app.Config.GitBackend), app.Config.Timeouts.Git, app.Config.Timeouts.Network, nil)
+			if branch, err := gitClient.GetCurrentBranch(ctx, workDir); err == nil {
+				fmt.Printf("Branch:  %s\n", branch)
+			}
+		}
+		fmt.Printf("Dir:     %s\n", workDir)
+		fmt.Printf("Keys:    %s end  %s merge done  %s popup shell  %s log  %s help\n",
+			icon.Key("e"), icon.Key("m"), icon.Key("p"), icon.Key("l"), icon.Key("h"))
+		fmt.Printf("End:     taw end %[1]s  (or taw merge %[1]s)\n", t.Name)
+		fmt.Printf("Prompt:  taw prompt show %s\n", t.Name)
+		fmt.Println("Status:  taw status")
+		vars := "$TASK_NAME $PROJECT_DIR $TAW_BIN"
+		if mgr.UsesWorktree(t) {
+			vars = "$TASK_NAME $WORKTREE_DIR $PROJECT_DIR $TAW_BIN"
+		}
+		fmt.Printf("Env:     %s (as the agent sees them)\n", vars)
+		fmt.Println()
+		return nil
+	},
+}
+
+var pickDraftCmd = &cobra.Command{
+	Use:   "pick-draft [session] [group]",
+	Short: "Compare competing drafts and keep the winner",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		sessionName := args[0]
+		group := args[1]
+
+		app, err := getAppFromSession(ctx, sessionName)
+		if err != nil {
+			return err
+		}
+
+		// Setup logging
+		logger, _ := logging.New(app.GetLogPath(), app.Debug)
+		if logger != nil {
+			defer logger.Close()
+			logger.SetScript("pick-draft")
+			logger.SetTask(group)
+			logging.SetGlobal(logger)
+		}
+
+		mgr := task.NewManager(app.AgentsDir, app.ProjectDir, app.TawDir, app.IsGitRepo, app.Config)
+		summaries, err := mgr.SummarizeDrafts(ctx, group)
+		if err != nil {
+			return err
+		}
+		if len(summaries) == 0 {
+			return fmt.Errorf("no drafts found for %s", group)
+		}
+
+		winner, err := tui.RunDraftPicker(group, summaries)
+		if err != nil {
+			return err
+		}
+		if winner == nil {
+			return nil
+		}
+
+		logging.Log("Picked draft %s", winner.Name)
+		tm := tmux.New(sessionName)
+
+		// Discard the other drafts
+		for _, s := range summaries {
+			if s.Task.Name == winner.Name {
+				continue
+			}
+			if id := taskWindow(tm, s.Task); id != "" {
+				if err := tm.KillWindow(id); err != nil {
+					logging.Debug("Failed to kill window: %v", err)
+				}
+			}
+			mgr.RecordDiff(ctx, s.Task)
+			mgr.RecordCompletion(s.Task, task.OutcomeDiscarded)
+			if err := mgr.CleanupTask(ctx, s.Task); err != nil {
+				logging.Warn("Failed to discard draft %s: %v", s.Task.Name, err)
+			} else {
+				logging.Log("Discarded draft %s", s.Task.Name)
+			}
+		}
+
+		// The winner continues as a regular task through end-task
+		if err := winner.ClearDraftGroup(); err != nil {
+			return fmt.Errorf("failed to clear draft group: %w", err)
+		}
+
+		winnerID, err := winner.EnsureID()
+		if err != nil {
+			return err
+		}
+		return spawnInternal(sessionName, "end-task", winnerID)
+	},
+}
+
+// rejectVerification keeps a task open after a failed verification and asks
+// the agent to fix the failures.
+func rejectVerification(ctx context.Context, tm tmux.Client, mgr *task.Manager, windowID string, t *task.Task, result *task.VerifyResult) error {
+	mgr.RecordVerifyFailure(t)
+
+	if err := t.SaveStatus(task.StatusWaiting); err != nil {
+		logging.Warn("Failed to save status: %v", err)
+	}
+	if err := t.SyncWindow(tm, windowID); err != nil {
+		logging.Debug("Failed to update window: %v", err)
+	}
+	tm.DisplayMessage(fmt.Sprintf(icon.Warning.String()+" %s: verification %s", t.Name, result.Summary()))
+
+	failed := result.FirstFailure()
+	instruction := fmt.Sprintf(
+		"Verification step %s failed: `%s` exited with an error. The full output is in %s. "+
+			"Fix the failures, commit, and finish the task again.",
+		failed.Name, failed.Command, t.GetVerifyLogPath(failed.Name))
+	if err := mgr.SendInstruction(ctx, tm, t, windowID+".0", task.InstructionVerify, instruction); err != nil {
+		logging.Warn("Failed to send verification output to agent: %v", err)
+	}
+	return nil
+}
+
+// rejectCommits keeps a task open because commit messages don't follow the
+// convention, and asks its agent to reword them if on_invalid is agent.
+func rejectCommits(ctx context.Context, tm tmux.Client, mgr *task.Manager, windowID string, t *task.Task, issues []task.CommitIssue) error {
+	if err := t.SaveStatus(task.StatusWaiting); err != nil {
+		logging.Warn("Failed to save status: %v", err)
+	}
+	if err := t.SyncWindow(tm, windowID); err != nil {
+		logging.Debug("Failed to update window: %v", err)
+	}
+	tm.DisplayMessage(fmt.Sprintf(icon.Warning.String()+" %s: %d commit messages don't follow the convention", t.Name, len(issues)))
+
+	if mgr.CommitPolicy() != config.CommitPolicyAgent {
+		return nil
+	}
+	lines := make([]string, len(issues))
+	for i, issue := range issues {
+		lines[i] = issue.String()
+	}
+	instruction := fmt.Sprintf(
+		"These commit messages don't follow Conventional Commits (type(scope): description): %s. "+
+			"Reword them without changing the commits' content, and finish the task again.",
+		strings.Join(lines, "; "))
+	if err := mgr.SendInstruction(ctx, tm, t, windowID+".0", task.InstructionCommits, instruction); err != nil {
+		logging.Warn("Failed to send commit issues to agent: %v", err)
+	}
+	return nil
+}
+
+var replayTaskCmd = &cobra.Command{
+	Use:   "replay-task [session] [task-name]",
+	Short: "Send a replay task's recorded instructions, one each time its agent is done",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		sessionName := args[0]
+		taskName := args[1]
+
+		app, err := getAppFromSession(ctx, sessionName)
+		if err != nil {
+			return err
+		}
+
+		logger, _ := logging.New(app.GetLogPath(), app.Debug)
+		if logger != nil {
+			defer logger.Close()
+			logger.SetScript("replay-task")
+			logger.SetTask(taskName)
+			logging.SetGlobal(logger)
+		}
+
+		mgr := task.NewManager(app.AgentsDir, app.ProjectDir, app.TawDir, app.IsGitRepo, app.Config)
+		t, err := mgr.GetTask(taskName)
+		if err != nil {
+			return err
+		}
+
+		tm := tmux.New(sessionName)
+		instructions := t.LoadReplay()
+		for i, in := range instructions {
+			// Wait for the agent to be done with the previous instruction
+			for {
+				if _, err := os.Stat(t.AgentDir); err != nil {
+					logging.Log("Replay stopped: task removed after %d of %d instructions", i, len(instructions))
+					return nil
+				}
+				if status := t.LoadStatus(); status == task.StatusWaiting || status == task.StatusDone {
+					break
+				}
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(constants.ReplayPollInterval):
+				}
+			}
+
+			windowID := taskWindow(tm, t)
+			if windowID == "" {
+				logging.Log("Replay stopped: no window after %d of %d instructions", i, len(instructions))
+				return nil
+			}
+			if err := t.SaveStatus(task.StatusWorking); err != nil {
+				logging.Debug("Failed to save status: %v", err)
+			}
+			if err := mgr.SendInstruction(ctx, tm, t, windowID+".0", in.Kind, in.Text); err != nil {
+				return fmt.Errorf("failed to send instruction: %w", err)
+			}
+			logging.Log("Replayed %s instruction (%d/%d)", in.Kind, i+1, len(instructions))
+		}
+
+		// Resuming the task must not send them again
+		if err := os.Remove(t.GetReplayPath()); err != nil && !os.IsNotExist(err) {
+			logging.Debug("Failed to remove replay: %v", err)
+		}
+		logging.Log("Replay finished")
+		return nil
+	},
+}
+
+// findTaskByID finds the task with the given ID and its window, or "" if it
+// has none. tmux reuses the IDs of closed windows, so key bindings pass task
+// IDs instead.
+func findTaskByID(tm tmux.Client, mgr *task.Manager, taskID string) (*task.Task, string, error) {
+	if taskID == "" {
+		return nil, "", fmt.Errorf("not a task window")
+	}
+	tasks, err := mgr.ListTasks()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list tasks: %w", err)
+	}
+
+	for _, t := range tasks {
+		if t.ID == taskID {
+			return t, taskWindow(tm, t), nil
+		}
+	}
+	return nil, "", fmt.Errorf("task not found: %s", taskID)
+}
+
+// taskWindow returns the ID of the window tagged with a task, or "" if it
+// has none.
+func taskWindow(tm tmux.Client, t *task.Task) string {
+	windows, err := tm.ListWindows()
+	if err != nil {
+		return ""
+	}
+	return t.WindowIn(windows)
+}
+
+// createTasks creates a task, or competing drafts when drafts > 1, named
+// name if it makes a valid task name.
+func createTasks(ctx context.Context, mgr *task.Manager, content, name string) ([]*task.Task, error) {
+	// Read-only tasks have no changes to compare
+	if n := mgr.DraftCount(); n > 1 && mgr.InferIsolation(content) != config.IsolationNone {
+		countUsage(telemetry.EventTaskCreated)
+		return mgr.CreateDrafts(ctx, content, name, n)
+	}
+
+	t, err := mgr.CreateTask(ctx, content, name)
+	if err != nil {
+		return nil, err
+	}
+	countUsage(telemetry.EventTaskCreated)
+	return []*task.Task{t}, nil
+}
+
+// saveTaskPrompts saves what the agent reads to the task's agent directory:
+// the task context, the system prompt built from the prompt layers (plus
+// extra layers) with the context's placeholders expanded, and the user prompt
+// with the task and the prompts of its routes. Errors are non-fatal but logged.
+func saveTaskPrompts(app *app.App, mgr *task.Manager, t *task.Task, workDir string, taskContext task.Context, extra ...claude.PromptLayer) {
+	if err := t.SaveContext(taskContext); err != nil {
+		logging.Warn("Failed to save task context: %v", err)
+	}
+
+	promptLayers := app.GetPromptLayers(t.GetPromptPath())
+	promptLayers = append(promptLayers, mgr.KnowledgeLayers(t)...)
+	if mgr.Sandboxed() {
+		sandboxPrompt, _ := embed.GetSandboxPrompt()
+		promptLayers = append(promptLayers, claude.PromptLayer{Name: "sandbox", Source: "sandbox: docker", Content: sandboxPrompt})
+	}
+	if t.IsAsk() {
+		askPrompt, _ := embed.GetAskPrompt()
+		promptLayers = append(promptLayers, claude.PromptLayer{Name: "ask", Source: "#ask", Content: askPrompt})
+	}
+	promptLayers = append(promptLayers, extra...)
+	systemPrompt := icon.Localize(claude.BuildSystemPrompt(taskContext.Vars(), promptLayers...))
+
+	var userPrompt strings.Builder
+	userPrompt.WriteString(fmt.Sprintf("# Task: %s\n\n", t.Name))
+	if mgr.UsesWorktree(t) {
+		userPrompt.WriteString(fmt.Sprintf("**Worktree**: %s\n", workDir))
+	}
+	userPrompt.WriteString(fmt.Sprintf("**Project**: %s\n", app.ProjectDir))
+	userPrompt.WriteString(fmt.Sprintf("**Context**: %s\n\n", t.GetContextPath()))
+	userPrompt.WriteString(t.Content)
+	userPrompt.WriteString(mgr.RoutePrompt(t))
+
+	if err := os.WriteFile(t.GetSystemPromptPath(), []byte(systemPrompt), 0644); err != nil {
+		logging.Warn("Failed to save system prompt: %v", err)
+	}
+	if err := os.WriteFile(t.GetUserPromptPath(), []byte(userPrompt.String()), 0644); err != nil {
+		logging.Warn("Failed to save user prompt: %v", err)
+	}
+}
+
+// finishDraft marks a competing draft as done and opens the draft picker
+// once every draft in its group has finished.
+func finishDraft(tm tmux.Client, mgr *task.Manager, sessionName, windowID string, t *task.Task) error {
+	if err := t.MarkDraftDone(); err != nil {
+		return fmt.Errorf("failed to mark draft done: %w", err)
+	}
+	logging.Log("Draft finished (group: %s)", t.DraftGroup)
+
+	if err := t.SyncWindow(tm, windowID); err != nil {
+		logging.Debug("Failed to update window: %v", err)
+	}
+
+	if !mgr.AllDraftsDone(t.DraftGroup) {
+		return nil
+	}
+
+	logging.Log("All drafts finished, opening draft picker")
+	tawBin, _ := os.Executable()
+	return tm.DisplayPopup(tmux.PopupOpts{
+		Width:  "80%",
+		Height: "60%",
+		Title:  " Pick a draft ",
+		Close:  true,
+	}, fmt.Sprintf("%s internal pick-draft '%s' '%s'", tawBin, sessionName, t.DraftGroup))
+}
+
+// enqueueOutbox records a remote action for retry and starts the outbox processor.
+func enqueueOutbox(app *app.App, sessionName string, kind task.OutboxActionKind, taskName string) {
+	if err := task.NewOutbox(app.OutboxDir).Add(kind, taskName); err != nil {
+		logging.Warn("Failed to enqueue %s: %v", kind, err)
+		return
+	}
+	logging.Log("Queued %s for retry", kind)
+	startOutboxProcessor(sessionName)
+}
+
+// emitEvent queues a task event for the webhooks that want it, and the
+// matching change of the task's ticket, and starts the outbox processor to
+// deliver them. Without a session (terminal mode) the outbox is processed
+// once in the foreground; what fails is retried by the next session.
+func emitEvent(ctx context.Context, app *app.App, mgr *task.Manager, sessionName string, kind config.WebhookEvent, t *task.Task, reason string) {
+	queued, err := mgr.EmitEvent(kind, t, reason)
+	if err != nil {
+		logging.Warn("Failed to queue %s event: %v", kind, err)
+	}
+	if queued == 0 {
+		return
+	}
+	logging.Log("Queued %d outbox action(s) for %s", queued, kind)
+
+	if sessionName != "" {
+		startOutboxProcessor(sessionName)
+		return
+	}
+	if _, _, err := mgr.ProcessOutbox(ctx, task.NewOutbox(app.OutboxDir)); err != nil {
+		logging.Warn("Failed to process outbox: %v", err)
+	}
+}
+
+// startOutboxProcessor starts the outbox processor in the background.
+func startOutboxProcessor(sessionName string) {
+	if err := spawnInternal(sessionName, "process-outbox"); err != nil {
+		logging.Debug("Failed to start process-outbox: %v", err)
+	}
+}
+
+// lockTask takes the lock of a task for an operation, telling the user in
+// tmux if another client is already at it. Call the returned function to
+// release it; it may be called more than once.
+func lockTask(tm tmux.Client, mgr *task.Manager, t *task.Task, op string) (func(), error) {
+	unlock, err := mgr.LockTask(t, op)
+	if err != nil {
+		logging.Warn("Not running %s: %v", op, err)
+		if errors.Is(err, task.ErrTaskLocked) {
+			tm.DisplayMessage(fmt.Sprintf(icon.Postponed.String()+" %s: %v", t.Name, err))
+		}
+		return nil, err
+	}
+	var once sync.Once
+	return func() { once.Do(unlock) }, nil
+}
+
+// spawnInternal runs an internal command in the background. The session daemon
+// supervises it when running; otherwise it falls back to a detached process.
+func spawnInternal(sessionName, job string, args ...string) error {
+	err := daemon.Send(daemon.SocketPath(sessionName), job, args...)
+	if err == nil {
+		return nil
+	}
+	logging.Debug("Daemon unavailable, starting %s directly: %v", job, err)
+
+	tawBin, _ := os.Executable()
+	cmdArgs := append([]string{"internal", job, sessionName}, args...)
+	return exec.Command(tawBin, cmdArgs...).Start()
+}
+
+// startDaemon starts the session daemon unless one is already running.
+func startDaemon(sessionName string) {
+	if daemon.Ping(daemon.SocketPath(sessionName)) == nil {
+		return
+	}
+
+	tawBin, _ := os.Executable()
+	cmd := exec.Command(tawBin, "internal", "daemon", sessionName)
+	// Detach from the terminal so the daemon outlives the attaching client
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		logging.Warn("Failed to start daemon: %v", err)
+	}
+}
+
+// getAppFromSession creates an App from session name
+func getAppFromSession(ctx context.Context, sessionName string) (*app.App, error) {
+	// Session name is the project directory name
+	// We need to find the project directory
+
 	// First, try to get it from environment
 	if tawDir := os.Getenv("TAW_DIR"); tawDir != "" {
 		projectDir := filepath.Dir(tawDir)
@@ -825,7 +2656,7 @@ func getAppFromSession(sessionName string) (*app.App, error) {
 		if err != nil {
 			return nil, err
 		}
-		return loadAppConfig(application)
+		return loadAppConfig(ctx, application)
 	}
 
 	// Try current directory
@@ -843,7 +2674,7 @@ func getAppFromSession(sessionName string) (*app.App, error) {
 			if err != nil {
 				return nil, err
 			}
-			return loadAppConfig(application)
+			return loadAppConfig(ctx, application)
 		}
 
 		parent := filepath.Dir(dir)
@@ -856,12 +2687,17 @@ func getAppFromSession(sessionName string) (*app.App, error) {
 	return nil, fmt.Errorf("could not find project directory for session %s", sessionName)
 }
 
-func loadAppConfig(application *app.App) (*app.App, error) {
+func loadAppConfig(ctx context.Context, application *app.App) (*app.App, error) {
 	tawHome, _ := getTawHome()
 	application.SetTawHome(tawHome)
 
-	gitClient := git.New()
-	application.SetGitRepo(gitClient.IsGitRepo(application.ProjectDir))
+	// Internal commands run on every keybinding; the marker written at session
+	// start saves spawning git on this hot path
+	if _, err := os.Stat(filepath.Join(application.TawDir, constants.GitRepoMarker)); err == nil {
+		application.SetGitRepo(true)
+	} else {
+		application.SetGitRepo(git.New().IsGitRepo(ctx, application.ProjectDir))
+	}
 
 	if err := application.LoadConfig(); err != nil {
 		application.Config = config.DefaultConfig()
@@ -871,7 +2707,7 @@ func loadAppConfig(application *app.App) (*app.App, error) {
 }
 
 // openEditor opens an editor for task input
-func openEditor(workDir string) (string, error) {
+func openEditor(workDir, header string) (string, error) {
 	editor := os.Getenv("EDITOR")
 	if editor == "" {
 		editor = "vim"
@@ -889,7 +2725,7 @@ func openEditor(workDir string) (string, error) {
 	template := `# Task Description
 # Lines starting with # will be ignored
 # Describe your task below:
-
+` + header + `
 `
 	if _, err := tmpFile.WriteString(template); err != nil {
 		tmpFile.Close()
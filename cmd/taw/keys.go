@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/donghojung/taw/internal/app"
+	"github.com/donghojung/taw/internal/icon"
+	"github.com/donghojung/taw/internal/tmux"
+)
+
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Check TAW key bindings against your tmux config",
+	Long: `List the key bindings of your tmux config that TAW sessions replace, with
+free Alt keys to move them to.
+
+TAW sessions run on their own tmux server. Set source_tmux_conf: true in
+.taw/config to load your tmux config into them.`,
+	Args: cobra.NoArgs,
+	RunE: runKeys,
+}
+
+func runKeys(cmd *cobra.Command, args []string) error {
+	userConf := tmux.UserConfigPath()
+	if userConf == "" {
+		fmt.Println("No tmux config found (~/.tmux.conf or ~/.config/tmux/tmux.conf): nothing to check")
+		return nil
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	application, err := app.New(cwd)
+	if err != nil {
+		return err
+	}
+
+	user, err := tmux.UserRootBindings(userConf)
+	if err != nil {
+		return err
+	}
+
+	// Shown as they'd be typed, not with the full binary path
+	bindings := sessionBindings(application, "taw")
+	conflicts := tmux.FindConflicts(bindings, user)
+
+	fmt.Printf("Checked %s\n\n", userConf)
+	if len(conflicts) == 0 {
+		fmt.Println("No conflicts: TAW replaces none of your key bindings")
+		return nil
+	}
+
+	fmt.Printf("%s %d of your key bindings are replaced in TAW sessions:\n", icon.Warning, len(conflicts))
+	for _, c := range conflicts {
+		fmt.Printf("  %-8s yours: %s\n", c.Key, c.UserCommand)
+		fmt.Printf("  %-8s TAW:   %s\n", "", c.TawCommand)
+	}
+
+	free := tmux.FreeAltKeys(bindings, user)
+	if len(free) == 0 {
+		return nil
+	}
+	fmt.Printf("\nFree Alt keys to move yours to: %s\n", strings.Join(free, " "))
+	fmt.Println("For example, in your tmux config:")
+	for i, c := range conflicts {
+		if i >= len(free) {
+			break
+		}
+		fmt.Printf("  bind -n %s %s\n", free[i], c.UserCommand)
+	}
+	return nil
+}
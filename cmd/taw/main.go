@@ -2,30 +2,51 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/donghojung/taw/internal/app"
+	"github.com/donghojung/taw/internal/claude"
 	"github.com/donghojung/taw/internal/config"
 	"github.com/donghojung/taw/internal/constants"
 	"github.com/donghojung/taw/internal/git"
+	"github.com/donghojung/taw/internal/icon"
 	"github.com/donghojung/taw/internal/logging"
 	"github.com/donghojung/taw/internal/task"
 	"github.com/donghojung/taw/internal/tmux"
+	"github.com/donghojung/taw/internal/tui"
 )
 
 var (
 	// Version is set at build time
 	Version = "dev"
+
+	// TelemetryURL is where opted-in usage counts are sent, set at build
+	// time; $TAW_TELEMETRY_URL overrides it
+	TelemetryURL = ""
 )
 
 func main() {
-	if err := rootCmd.Execute(); err != nil {
+	// Cancel long operations on Ctrl-C or when the daemon stops a job
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+	stopProfiling()
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
+		if hint := task.Hint(err); hint != "" {
+			fmt.Fprintf(os.Stderr, "Hint: %s\n", hint)
+		}
 		os.Exit(1)
 	}
 }
@@ -34,15 +55,80 @@ var rootCmd = &cobra.Command{
 	Use:   "taw",
 	Short: "TAW - Tmux + Agent + Worktree",
 	Long: `TAW is a Claude Code-based autonomous task execution system.
-It manages tasks in tmux sessions with optional git worktree isolation.`,
+It manages tasks in tmux sessions with optional git worktree isolation.
+
+Run taw in a project directory to start (or attach to) its tmux session.`,
+	Example: `  taw               # Start or attach to the session of this project
+  taw --refresh     # Attach, revalidating cached PR statuses
+  taw status        # List tasks, disk usage, queue and outbox`,
 	RunE:         runMain,
 	SilenceUsage: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return startProfiling()
+	},
 }
 
 func init() {
 	rootCmd.AddCommand(cleanCmd)
 	rootCmd.AddCommand(setupCmd)
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(showCmd)
+	rootCmd.AddCommand(reportCmd)
+	rootCmd.AddCommand(promptCmd)
 	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(completionCmd)
+	rootCmd.AddCommand(keysCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(runOneCmd)
+	rootCmd.AddCommand(endCmd)
+	rootCmd.AddCommand(mergeCmd)
+	rootCmd.AddCommand(retryCmd)
+	rootCmd.AddCommand(cleanupTasksCmd)
+	rootCmd.AddCommand(gcCmd)
+	rootCmd.AddCommand(askCmd)
+	rootCmd.AddCommand(openCmd)
+	rootCmd.AddCommand(undoCmd)
+	rootCmd.AddCommand(replayCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(debugBundleCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(budgetCmd)
+	rootCmd.AddCommand(telemetryCmd)
+	rootCmd.AddCommand(ticketCmd)
+	rootCmd.AddCommand(addressReviewCmd)
+	rootCmd.AddCommand(queueCmd)
+	rootCmd.AddCommand(syncTasksCmd)
+	rootCmd.AddCommand(batchCmd)
+	rootCmd.AddCommand(serveCmd)
+
+	// Replaced by completionCmd, which documents the install per shell
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	promptCmd.AddCommand(promptShowCmd)
+	promptShowCmd.ValidArgsFunction = completeTaskName(0)
+	promptCmd.AddCommand(promptKnowledgeCmd)
+	promptKnowledgeCmd.ValidArgsFunction = completeTaskName(0)
+
+	// Profiling flags (hidden, for startup benchmarking)
+	rootCmd.PersistentFlags().StringVar(&cpuProfilePath, "cpuprofile", "", "Write a CPU profile to file")
+	rootCmd.PersistentFlags().StringVar(&memProfilePath, "memprofile", "", "Write a memory profile to file")
+	rootCmd.PersistentFlags().MarkHidden("cpuprofile")
+	rootCmd.PersistentFlags().MarkHidden("memprofile")
+
+	rootCmd.Flags().BoolVar(&refreshPRStatus, "refresh", false, "Revalidate cached PR statuses with GitHub")
+
+	reportCmd.Flags().StringVar(&reportSince, "since", "7d", "Look-back period (e.g. 1d, 7d, 2w, 36h)")
+	runOneCmd.Flags().StringVar(&runOneTask, "task", "", "Resume an existing task instead of creating one")
+	runOneCmd.RegisterFlagCompletionFunc("task", completeTaskName(0))
+	setupCmd.Flags().StringVar(&setupPreset, "preset", "", "Apply a preset without the wizard: "+strings.Join(config.PresetNames(), ", "))
+	setupCmd.RegisterFlagCompletionFunc("preset", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return config.PresetNames(), cobra.ShellCompDirectiveNoFileComp
+	})
+
+	reportCmd.Flags().StringVar(&reportFormat, "format", "md", "Output format: md or json")
+	reportCmd.Flags().StringVar(&reportOwner, "owner", "", "Only tasks created by this user")
+	statusCmd.Flags().StringVar(&statusOwner, "owner", "", "Only tasks created by this user")
 
 	// Internal commands (hidden, called by tmux keybindings)
 	rootCmd.AddCommand(internalCmd)
@@ -66,12 +152,93 @@ var cleanCmd = &cobra.Command{
 var setupCmd = &cobra.Command{
 	Use:   "setup",
 	Short: "Run the setup wizard",
-	Long:  "Configure TAW settings for the current project",
-	RunE:  runSetup,
+	Long: `Configure TAW settings for the current project.
+
+A preset sets several settings at once for a common way of working:
+  solo      auto-merge finished tasks into main
+  team      open a PR per task, squash merges, {user}/{task} branches and
+            the project's tests as a gate before committing
+  ci        open PRs without asking, with plain output and no notifications
+  cautious  ask before each action, at most 2 agents at once
+
+With --preset, the preset is saved without running the wizard; the wizard
+also offers the presets as its first step.`,
+	Example: `  taw setup
+  taw setup --preset team`,
+	Args: cobra.NoArgs,
+	RunE: runSetup,
+}
+
+// setupPreset is the preset of 'taw setup --preset'.
+var setupPreset string
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show tasks, queue, and pending remote actions",
+	Example: `  taw status
+  taw status --owner alice   # Only tasks alice created`,
+	Args: cobra.NoArgs,
+	RunE: runStatus,
+}
+
+// statusOwner limits taw status to the tasks of a user.
+var statusOwner string
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Summarize completed tasks, merged PRs and failures",
+	Example: `  taw report                        # Last 7 days as markdown
+  taw report --since 2w --format json
+  taw report --owner alice          # Only tasks alice created`,
+	Args: cobra.NoArgs,
+	RunE: runReport,
+}
+
+var promptCmd = &cobra.Command{
+	Use:   "prompt",
+	Short: "Inspect the agent system prompt",
+}
+
+var promptShowCmd = &cobra.Command{
+	Use:   "show [task-name]",
+	Short: "Preview the composed system prompt",
+	Long: `Print the system prompt agents receive, composed from (most general first):
+  1. the default prompt (.taw/.global-prompt, or the embedded one)
+  2. the user prompt (~/.config/taw/PROMPT.md)
+  3. the project prompt (.taw/PROMPT.md)
+  4. the task's extra prompt (.taw/agents/<task-name>/PROMPT.md)
+  5. the notes of the knowledge base (.taw/knowledge/*.md) the task gets`,
+	Example: `  taw prompt show            # Prompt for new tasks
+  taw prompt show fix-login  # Prompt including the task's extra prompt and knowledge`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runPromptShow,
 }
 
+var promptKnowledgeCmd = &cobra.Command{
+	Use:   "knowledge [task-name]",
+	Short: "List the knowledge base notes and what selects them",
+	Long: `List the notes of the knowledge base (.taw/knowledge/*.md) with the tags and
+keywords that add them to a task's prompt. With a task, list only the notes
+it gets.`,
+	Example: `  taw prompt knowledge
+  taw prompt knowledge fix-login`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runPromptKnowledge,
+}
+
+var (
+	reportSince  string
+	reportFormat string
+	reportOwner  string
+)
+
+// refreshPRStatus makes the attach-time merged check ignore the PR status cache TTL.
+var refreshPRStatus bool
+
 // runMain is the main entry point - starts or attaches to a tmux session
 func runMain(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
 	// Get current directory
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -93,7 +260,7 @@ func runMain(cmd *cobra.Command, args []string) error {
 
 	// Detect git repo
 	gitClient := git.New()
-	application.SetGitRepo(gitClient.IsGitRepo(cwd))
+	application.SetGitRepo(gitClient.IsGitRepo(ctx, cwd))
 
 	// Initialize .taw directory
 	if err := application.Initialize(); err != nil {
@@ -109,10 +276,17 @@ func runMain(cmd *cobra.Command, args []string) error {
 	logger.SetScript("taw")
 	logging.SetGlobal(logger)
 
+	// Install prompts and .claude assets on first run
+	assetsDir, err := bootstrapAssets(application)
+	if err != nil {
+		return err
+	}
+	application.SetAssetsDir(assetsDir)
+
 	// Check if config exists, run setup if not
 	if !application.HasConfig() {
 		fmt.Println("No configuration found. Running setup...")
-		if err := runSetupWizard(application); err != nil {
+		if err := runSetupWizard(application, ""); err != nil {
 			return err
 		}
 	}
@@ -125,10 +299,22 @@ func runMain(cmd *cobra.Command, args []string) error {
 	// Create tmux client
 	tm := tmux.New(application.SessionName)
 
+	// Popups need tmux 3.2+; older versions get panes and windows instead
+	if warning := tmux.PopupWarning(); warning != "" {
+		logging.Warn("%s", warning)
+		fmt.Println(icon.Warning.String() + " " + warning)
+	}
+
+	// Tasks won't start without a recent enough claude; say so up front
+	if warning := claudeWarning(application.Config); warning != "" {
+		logging.Warn("%s", warning)
+		fmt.Println(icon.Warning.String() + " " + warning)
+	}
+
 	// Check if session already exists
 	if tm.HasSession(application.SessionName) {
 		logging.Log("Attaching to existing session")
-		return attachToSession(application, tm)
+		return attachToSession(ctx, application, tm)
 	}
 
 	// Start new session
@@ -136,8 +322,95 @@ func runMain(cmd *cobra.Command, args []string) error {
 	return startNewSession(application, tm)
 }
 
-// startNewSession creates a new tmux session
+// startNewSession creates a new tmux session and attaches to it
 func startNewSession(app *app.App, tm tmux.Client) error {
+	if err := createSession(app, tm); err != nil {
+		return err
+	}
+
+	// Attach to session
+	return tm.AttachSession(app.SessionName)
+}
+
+// windowNameField matches a field of the window name template, e.g. {name:.16}.
+var windowNameField = regexp.MustCompile(`\{(\w+)(?::\.([0-9]+))?\}`)
+
+// windowStatusFormat returns the status bar format of a window: the window
+// name template filled in from the options of the task a window is tagged
+// with, or the window name. tmux renders it again whenever they change.
+func windowStatusFormat(template string) string {
+	status := "#{" + constants.TaskStatusOption + "}"
+	statusIcon := icon.Working.String()
+	for _, s := range []struct {
+		status string
+		icon   icon.Icon
+	}{
+		{"waiting", icon.Waiting}, {"push-failed", icon.Waiting},
+		{"done", icon.Done}, {"corrupted", icon.Warning}, {"stuck", icon.Stuck},
+	} {
+		statusIcon = fmt.Sprintf("#{?#{==:%s,%s},%s,%s}", status, s.status, s.icon, statusIcon)
+	}
+	fields := map[string]string{
+		"emoji":  statusIcon,
+		"index":  "#I",
+		"name":   constants.TaskOption,
+		"status": constants.TaskStatusOption,
+		"branch": constants.TaskBranchOption,
+	}
+
+	// Text is escaped, as the name is a branch of a conditional
+	escape := strings.NewReplacer("#", "##", ",", "#,").Replace
+	var sb strings.Builder
+	last := 0
+	for _, m := range windowNameField.FindAllStringSubmatchIndex(template, -1) {
+		sb.WriteString(escape(template[last:m[0]]))
+		last = m[1]
+		// Config only accepts known fields
+		field := fields[template[m[2]:m[3]]]
+		switch {
+		case !strings.HasPrefix(field, "@"):
+			sb.WriteString(field)
+		case m[4] >= 0:
+			fmt.Fprintf(&sb, "#{=%s:%s}", template[m[4]:m[5]], field)
+		default:
+			fmt.Fprintf(&sb, "#{%s}", field)
+		}
+	}
+	sb.WriteString(escape(template[last:]))
+
+	return fmt.Sprintf("#{?#{%s},%s,#I:#W}#{?window_flags,#{window_flags}, }", constants.TaskOption, sb.String())
+}
+
+// setWindowStatusFormat names the windows in the status bar after the window
+// name template.
+func setWindowStatusFormat(batch *tmux.Batch, template string) {
+	format := windowStatusFormat(template)
+	batch.SetOption("window-status-format", format, true)
+	batch.SetOption("window-status-current-format", format, true)
+}
+
+// refreshWindowNames applies a changed config to the window names of a
+// running session.
+func refreshWindowNames(application *app.App) {
+	tm := tmux.New(application.SessionName)
+	if !tm.HasSession(application.SessionName) {
+		return
+	}
+	cfg, err := config.Load(application.TawDir)
+	if err != nil {
+		return
+	}
+	icon.SetASCII(cfg.ASCII)
+
+	batch := tmux.NewBatch()
+	setWindowStatusFormat(batch, cfg.WindowName)
+	if err := tm.RunBatch(batch); err != nil {
+		logging.Debug("Failed to update window names: %v", err)
+	}
+}
+
+// createSession creates the tmux session of the project, detached
+func createSession(app *app.App, tm tmux.Client) error {
 	// Get taw binary path for initial command
 	tawBin, err := os.Executable()
 	if err != nil {
@@ -149,76 +422,153 @@ func startNewSession(app *app.App, tm tmux.Client) error {
 	if err := tm.NewSession(tmux.SessionOpts{
 		Name:       app.SessionName,
 		StartDir:   app.ProjectDir,
-		WindowName: constants.NewWindowName,
+		WindowName: icon.New.Label(constants.NewWindowName),
 		Detached:   true,
 	}); err != nil {
 		return fmt.Errorf("failed to create session: %w", err)
 	}
 
+	// Remember the new task window by ID, so renaming it doesn't lose it
+	if windows, err := tm.ListWindows(); err == nil && len(windows) > 0 {
+		tm.SetOption(constants.NewWindowOption, windows[0].ID, true)
+	}
+
 	// Setup tmux configuration
 	if err := setupTmuxConfig(app, tm); err != nil {
 		logging.Warn("Failed to setup tmux config: %v", err)
 	}
 
-	// Setup git repo marker if applicable
-	if app.IsGitRepo {
-		markerPath := filepath.Join(app.TawDir, constants.GitRepoMarker)
-		os.WriteFile(markerPath, []byte{}, 0644)
+	// Setup the git repo marker, prompt and .claude symlinks, and .gitignore
+	if err := app.Bootstrap(); err != nil {
+		logging.Warn("Failed to bootstrap project: %v", err)
 	}
 
-	// Setup global prompt symlink
-	if err := setupPromptSymlink(app); err != nil {
-		logging.Warn("Failed to setup prompt symlink: %v", err)
-	}
+	// Start the daemon that supervises background jobs
+	startDaemon(app.SessionName)
 
-	// Setup .claude symlink
-	if err := setupClaudeSymlink(app); err != nil {
-		logging.Warn("Failed to setup claude symlink: %v", err)
+	// Start tasks queued while no session was running (e.g. by 'taw ticket')
+	if err := spawnInternal(app.SessionName, "process-queue"); err != nil {
+		logging.Debug("Failed to start process-queue: %v", err)
 	}
 
-	// Update .gitignore
-	if app.IsGitRepo {
-		updateGitignore(app.ProjectDir)
+	// Open the persistent windows, leaving the new task window selected
+	for _, name := range app.Config.Windows {
+		if _, err := openSessionWindow(app, tm, name, true); err != nil {
+			logging.Warn("Failed to open %s window: %v", name, err)
+		}
 	}
 
 	// Send new-task command to the _ window
 	// Use SendKeysLiteral for the command and SendKeys for Enter
 	newTaskCmd := fmt.Sprintf("%s internal new-task %s", tawBin, app.SessionName)
-	tm.SendKeysLiteral(app.SessionName+":"+constants.NewWindowName, newTaskCmd)
-	tm.SendKeys(app.SessionName+":"+constants.NewWindowName, "Enter")
-
-	// Attach to session
-	return tm.AttachSession(app.SessionName)
+	newWindow := app.SessionName + ":" + icon.New.Label(constants.NewWindowName)
+	tm.SendKeysLiteral(newWindow, newTaskCmd)
+	tm.SendKeys(newWindow, "Enter")
+	return nil
 }
 
 // attachToSession attaches to an existing session
-func attachToSession(app *app.App, tm tmux.Client) error {
+func attachToSession(ctx context.Context, app *app.App, tm tmux.Client) error {
 	// Run cleanup and recovery before attaching
 	mgr := task.NewManager(app.AgentsDir, app.ProjectDir, app.TawDir, app.IsGitRepo, app.Config)
 	mgr.SetTmuxClient(tm)
+	if refreshPRStatus {
+		mgr.SetPRRefresh(true)
+	}
 
 	// Auto cleanup merged tasks
-	merged, err := mgr.FindMergedTasks()
+	merged, err := mgr.FindMergedTasks(ctx)
 	if err == nil {
 		for _, t := range merged {
 			logging.Log("Auto-cleaning merged task: %s", t.Name)
-			mgr.CleanupTask(t)
+			emitEvent(ctx, app, mgr, app.SessionName, config.EventTaskMerged, t, "")
+			emitEvent(ctx, app, mgr, app.SessionName, config.EventTaskCompleted, t, "")
+			mgr.CleanupTask(ctx, t)
 		}
 	}
 
+	// Offer to fix tasks whose worktree or branch is broken
+	if err := recoverCorruptedTasks(ctx, app, mgr, tm); err != nil {
+		logging.Warn("Failed to recover corrupted tasks: %v", err)
+	}
+
 	// Reopen incomplete tasks
 	incomplete, err := mgr.FindIncompleteTasks(app.SessionName)
 	if err == nil {
 		for _, t := range incomplete {
 			logging.Log("Reopening incomplete task: %s", t.Name)
+			// handle-task completes a setup that died halfway
+			if t.SetupInterrupted() {
+				if err := spawnInternal(app.SessionName, "handle-task", t.AgentDir); err != nil {
+					logging.Warn("Failed to resume setup of %s: %v", t.Name, err)
+				}
+				continue
+			}
 			// TODO: Implement reopen logic
 		}
 	}
 
+	// Restart the daemon if it died with a previous client
+	startDaemon(app.SessionName)
+
 	// Attach to session
 	return tm.AttachSession(app.SessionName)
 }
 
+// recoverCorruptedTasks scans for corrupted tasks and, on a terminal, lets the
+// user recover, clean up or skip each of them before attaching.
+func recoverCorruptedTasks(ctx context.Context, app *app.App, mgr *task.Manager, tm tmux.Client) error {
+	corrupted, err := mgr.FindCorruptedTasks(ctx)
+	if err != nil || len(corrupted) == 0 {
+		return err
+	}
+
+	// Without a terminal there's no one to ask; the next attach asks again
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		for _, t := range corrupted {
+			logging.Warn("Corrupted task %s: %s", t.Name, task.GetRecoveryDescription(t.CorruptedReason))
+		}
+		return nil
+	}
+
+	recoveryMgr := task.NewRecoveryManager(app.ProjectDir)
+	recoveryMgr.SetTmuxClient(tm)
+	failed, err := tui.RunRecoverUI(ctx, corrupted, func(ctx context.Context, t *task.Task, action task.RecoveryAction) error {
+		switch action {
+		case task.RecoveryRecover:
+			logging.Log("Recovering corrupted task: %s", t.Name)
+			if err := recoveryMgr.RecoverTask(ctx, t); err != nil {
+				return err
+			}
+			// Reopen the task whose window was gone
+			if t.CorruptedReason == task.CorruptStaleTabLock {
+				return spawnInternal(app.SessionName, "handle-task", t.AgentDir)
+			}
+
+		case task.RecoveryCleanup:
+			logging.Log("Cleaning up corrupted task: %s", t.Name)
+			windowID := taskWindow(tm, t)
+			mgr.RecordCompletion(t, task.OutcomeDiscarded)
+			if err := mgr.CleanupTask(ctx, t); err != nil {
+				return err
+			}
+			if windowID != "" {
+				if err := tm.KillWindow(windowID); err != nil {
+					logging.Debug("Failed to kill window: %v", err)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d corrupted tasks could not be fixed", failed, len(corrupted))
+	}
+	return nil
+}
+
 // setupTmuxConfig configures tmux keybindings and options
 func setupTmuxConfig(app *app.App, tm tmux.Client) error {
 	// Get path to taw binary
@@ -227,99 +577,91 @@ func setupTmuxConfig(app *app.App, tm tmux.Client) error {
 		tawBin = "taw"
 	}
 
+	// All options and bindings go to tmux as one command list
+	batch := tmux.NewBatch()
+
+	// The user's config goes first, so TAW's options and bindings win
+	userConf := ""
+	if app.Config.SourceTmuxConf {
+		userConf = tmux.UserConfigPath()
+	}
+	if userConf != "" {
+		batch.SourceFile(userConf)
+	}
+
 	// Setup status bar
-	tm.SetOption("status", "on", true)
-	tm.SetOption("status-position", "bottom", true)
-	tm.SetOption("status-left", "", true)
-	tm.SetOption("status-right", " ⌥n:new ⌥e:end ⌥m:merge ⌥p:shell ⌥l:log ⌥u:queue ⌥h:help ⌥q:quit ", true)
-	tm.SetOption("status-right-length", "100", true)
+	batch.SetOption("status", "on", true)
+	batch.SetOption("status-position", "bottom", true)
+	// The daemon shows a rate limit banner on the left
+	batch.SetOption("status-left", "", true)
+	batch.SetOption("status-left-length", "80", true)
+	var keys []string
+	for _, binding := range []struct{ key, action string }{
+		{"n", "new"}, {"e", "end"}, {"m", "merge"}, {"p", "shell"},
+		{"l", "log"}, {"u", "queue"}, {"h", "help"}, {"q", "quit"},
+	} {
+		keys = append(keys, icon.Key(binding.key)+":"+binding.action)
+	}
+	// The queue segment is kept up to date as tasks are queued and started,
+	// after the branch of the current task window
+	branch := "#{?#{" + constants.TaskBranchOption + "},#{" + constants.TaskBranchOption + "} ,}"
+	batch.SetOption("status-right", branch+"#{"+constants.QueueOption+"} "+strings.Join(keys, " ")+" ", true)
+	batch.SetOption("status-right-length", "200", true)
+	// Task windows show their task's status and name from their window options
+	setWindowStatusFormat(batch, app.Config.WindowName)
 
 	// Enable mouse mode
-	tm.SetOption("mouse", "on", true)
+	batch.SetOption("mouse", "on", true)
 
 	// Setup keybindings
-	bindings := []tmux.BindOpts{
-		{Key: "M-Tab", Command: "select-pane -t :.+", NoPrefix: true},
-		{Key: "M-Left", Command: "previous-window", NoPrefix: true},
-		{Key: "M-Right", Command: "next-window", NoPrefix: true},
-		{Key: "M-n", Command: fmt.Sprintf("run-shell '%s internal toggle-new %s'", tawBin, app.SessionName), NoPrefix: true},
-		{Key: "M-e", Command: fmt.Sprintf("run-shell '%s internal end-task-ui %s #{window_id}'", tawBin, app.SessionName), NoPrefix: true},
-		{Key: "M-m", Command: fmt.Sprintf("run-shell '%s internal merge-completed %s'", tawBin, app.SessionName), NoPrefix: true},
-		{Key: "M-p", Command: fmt.Sprintf("run-shell '%s internal popup-shell %s'", tawBin, app.SessionName), NoPrefix: true},
-		{Key: "M-u", Command: fmt.Sprintf("run-shell '%s internal quick-task %s'", tawBin, app.SessionName), NoPrefix: true},
-		{Key: "M-l", Command: fmt.Sprintf("run-shell '%s internal toggle-log %s'", tawBin, app.SessionName), NoPrefix: true},
-		{Key: "M-/", Command: fmt.Sprintf("run-shell '%s internal toggle-help %s'", tawBin, app.SessionName), NoPrefix: true},
-		{Key: "M-h", Command: fmt.Sprintf("run-shell '%s internal toggle-help %s'", tawBin, app.SessionName), NoPrefix: true},
-		{Key: "M-q", Command: "detach", NoPrefix: true},
-	}
-
+	bindings := sessionBindings(app, tawBin)
 	for _, b := range bindings {
-		if err := tm.Bind(b); err != nil {
-			logging.Debug("Failed to bind %s: %v", b.Key, err)
-		}
+		batch.Bind(b)
 	}
 
-	return nil
-}
-
-// setupPromptSymlink creates the global prompt symlink
-func setupPromptSymlink(app *app.App) error {
-	linkPath := app.GetGlobalPromptPath()
-
-	// Remove existing symlink
-	os.Remove(linkPath)
-
-	// Determine target based on git mode
-	var target string
-	if app.IsGitRepo {
-		target = filepath.Join(app.TawHome, "_taw", "PROMPT.md")
-	} else {
-		target = filepath.Join(app.TawHome, "_taw", "PROMPT-nogit.md")
+	if err := tm.RunBatch(batch); err != nil {
+		// tmux stops at the first failing command - apply the rest one by one
+		logging.Debug("Batched tmux config failed, applying individually: %v", err)
+		for _, command := range batch.Commands() {
+			if err := tm.Run(command...); err != nil {
+				logging.Debug("Failed to run tmux %s: %v", strings.Join(command, " "), err)
+			}
+		}
 	}
 
-	return os.Symlink(target, linkPath)
-}
-
-// setupClaudeSymlink creates the .claude symlink
-func setupClaudeSymlink(app *app.App) error {
-	linkPath := filepath.Join(app.TawDir, constants.ClaudeLink)
-
-	// Remove existing symlink
-	os.Remove(linkPath)
-
-	target := filepath.Join(app.TawHome, "_taw", "claude")
-	return os.Symlink(target, linkPath)
-}
-
-// updateGitignore adds .taw to .gitignore if not already present
-func updateGitignore(projectDir string) {
-	gitignorePath := filepath.Join(projectDir, ".gitignore")
-
-	// Read existing content
-	content, _ := os.ReadFile(gitignorePath)
-	contentStr := string(content)
-
-	// Check if .taw is already in .gitignore
-	// Look for ".taw" or ".taw/" on its own line
-	lines := strings.Split(contentStr, "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == ".taw" || line == ".taw/" {
-			return
+	// Tell the user which of their bindings TAW replaced
+	if userConf != "" {
+		if user, err := tmux.UserRootBindings(userConf); err != nil {
+			logging.Debug("Failed to check key conflicts: %v", err)
+		} else if conflicts := tmux.FindConflicts(bindings, user); len(conflicts) > 0 {
+			logging.Warn("%d TAW key bindings shadow bindings in %s", len(conflicts), userConf)
+			tm.DisplayMessage(fmt.Sprintf("%s %d of your tmux keys are used by TAW: run 'taw keys' for alternatives", icon.Warning, len(conflicts)))
 		}
 	}
 
-	// Append .taw
-	f, err := os.OpenFile(gitignorePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return
-	}
-	defer f.Close()
+	return nil
+}
 
-	if len(content) > 0 && content[len(content)-1] != '\n' {
-		f.WriteString("\n")
+// sessionBindings returns the key bindings of TAW sessions
+func sessionBindings(app *app.App, tawBin string) []tmux.BindOpts {
+	return []tmux.BindOpts{
+		{Key: "M-Tab", Command: "select-pane -t :.+", NoPrefix: true},
+		{Key: "M-Left", Command: "previous-window", NoPrefix: true},
+		{Key: "M-Right", Command: "next-window", NoPrefix: true},
+		{Key: "M-n", Command: fmt.Sprintf("run-shell '%s internal toggle-new %s'", tawBin, app.SessionName), NoPrefix: true},
+		{Key: "M-e", Command: fmt.Sprintf("run-shell '%s internal end-task-ui %s \"#{"+constants.TaskIDOption+"}\"'", tawBin, app.SessionName), NoPrefix: true},
+		{Key: "M-m", Command: fmt.Sprintf("run-shell '%s internal send %s merge-completed'", tawBin, app.SessionName), NoPrefix: true},
+		{Key: "M-p", Command: fmt.Sprintf("run-shell '%s internal popup-shell %s'", tawBin, app.SessionName), NoPrefix: true},
+		{Key: "M-u", Command: fmt.Sprintf("run-shell '%s internal quick-task %s'", tawBin, app.SessionName), NoPrefix: true},
+		{Key: "M-l", Command: fmt.Sprintf("run-shell '%s internal toggle-log %s'", tawBin, app.SessionName), NoPrefix: true},
+		{Key: "M-o", Command: fmt.Sprintf("run-shell '%s internal open-task %s \"#{"+constants.TaskIDOption+"}\"'", tawBin, app.SessionName), NoPrefix: true},
+		{Key: "M-g", Command: fmt.Sprintf("run-shell '%s internal show-diff %s \"#{"+constants.TaskIDOption+"}\"'", tawBin, app.SessionName), NoPrefix: true},
+		{Key: "M-d", Command: fmt.Sprintf("run-shell '%s internal show-window %s %s'", tawBin, app.SessionName, constants.DashboardWindow), NoPrefix: true},
+		{Key: "M-L", Command: fmt.Sprintf("run-shell '%s internal show-window %s %s'", tawBin, app.SessionName, constants.LogsWindow), NoPrefix: true},
+		{Key: "M-/", Command: fmt.Sprintf("run-shell '%s internal toggle-help %s'", tawBin, app.SessionName), NoPrefix: true},
+		{Key: "M-h", Command: fmt.Sprintf("run-shell '%s internal toggle-help %s'", tawBin, app.SessionName), NoPrefix: true},
+		{Key: "M-q", Command: fmt.Sprintf("run-shell '%s internal quit %s \"#{client_name}\"'", tawBin, app.SessionName), NoPrefix: true},
 	}
-	f.WriteString(".taw/\n")
 }
 
 // getTawHome returns the TAW installation directory
@@ -347,6 +689,8 @@ func getTawHome() (string, error) {
 
 // runClean removes all TAW resources
 func runClean(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
 	cwd, err := os.Getwd()
 	if err != nil {
 		return err
@@ -358,7 +702,7 @@ func runClean(cmd *cobra.Command, args []string) error {
 	}
 
 	gitClient := git.New()
-	application.SetGitRepo(gitClient.IsGitRepo(cwd))
+	application.SetGitRepo(gitClient.IsGitRepo(ctx, cwd))
 
 	if err := application.LoadConfig(); err != nil {
 		// Config might not exist, continue anyway
@@ -381,8 +725,11 @@ func runClean(cmd *cobra.Command, args []string) error {
 		tasks, _ := mgr.ListTasks()
 		for _, t := range tasks {
 			fmt.Printf("Cleaning up task: %s\n", t.Name)
-			mgr.CleanupTask(t)
+			mgr.CleanupTask(ctx, t)
 		}
+
+		// Drop the branches kept for 'taw undo' with the trash
+		mgr.EmptyTrash(ctx)
 	}
 
 	// Remove .taw directory
@@ -393,8 +740,10 @@ func runClean(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// runSetup runs the setup wizard
-func runSetup(cmd *cobra.Command, args []string) error {
+// runStatus prints tasks, queued tasks, and pending outbox actions
+func runStatus(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
 	cwd, err := os.Getwd()
 	if err != nil {
 		return err
@@ -405,61 +754,299 @@ func runSetup(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if !application.IsInitialized() {
+		return fmt.Errorf("no .taw directory found in %s", application.ProjectDir)
+	}
+
 	gitClient := git.New()
-	application.SetGitRepo(gitClient.IsGitRepo(cwd))
+	application.SetGitRepo(gitClient.IsGitRepo(ctx, cwd))
 
-	// Initialize .taw directory
-	if err := application.Initialize(); err != nil {
+	if err := application.LoadConfig(); err != nil {
+		application.Config = config.DefaultConfig()
+	}
+
+	if warning := tmux.PopupWarning(); warning != "" {
+		fmt.Printf("%s %s\n\n", icon.Warning, warning)
+	}
+
+	// Tasks
+	mgr := task.NewManager(application.AgentsDir, application.ProjectDir, application.TawDir, application.IsGitRepo, application.Config)
+	tasks, err := mgr.ListTasks()
+	if err != nil {
+		return err
+	}
+	tasks = task.FilterOwner(tasks, statusOwner)
+
+	usage, err := mgr.DiskUsage()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Tasks:")
+	if len(tasks) == 0 {
+		fmt.Println("  (none)")
+	}
+	for _, t := range tasks {
+		line := fmt.Sprintf("  %-32s %-11s %-16s %6s %6s", t.Name, t.Status, ownerOrDash(t.Owner),
+			task.FormatSize(usage.Size(t.Name)), task.FormatDuration(time.Since(t.CreatedAt)))
+		if waiting := t.LoadTimeline().TimeWaiting(time.Now()); waiting >= time.Minute {
+			line += "  waited " + task.FormatDuration(waiting)
+		}
+		if t.PRNumber > 0 {
+			line += fmt.Sprintf("  PR #%d", t.PRNumber)
+		}
+		if result := t.LoadVerifyResult(); result != nil {
+			line += "  verify: " + result.Summary()
+		}
+		if reason := t.LoadPushFailure(); reason != "" {
+			line += "  (" + reason + ")"
+		}
+		if t.IsHibernated() {
+			line += "  hibernated"
+		}
+		fmt.Println(line)
+	}
+
+	// Disk usage
+	quota := application.Config.DiskQuotaBytes()
+	fmt.Printf("\nDisk: %s", task.FormatSize(usage.Total))
+	if quota > 0 {
+		fmt.Printf(" of %s quota", task.FormatSize(quota))
+	}
+	fmt.Println()
+	if usage.OverQuota(quota) {
+		fmt.Println("  " + icon.Warning.String() + " Over quota. Largest tasks (finish or clean them up to free space):")
+		for _, t := range usage.Largest(constants.DiskQuotaSuggestions) {
+			fmt.Printf("    %-32s %6s  %s\n", t.Task.Name, task.FormatSize(t.Bytes), t.Task.Status)
+		}
+		fmt.Println("  Run 'taw gc' to hibernate done tasks and remove their worktrees.")
+	}
+
+	// Queue
+	queue, _ := task.NewQueueManager(application.QueueDir).List()
+	fmt.Printf("\nQueue: %d task(s) waiting\n", len(queue))
+	for i := range queue {
+		fmt.Printf("  %d. %s\n", i+1, queue[i].Title())
+	}
+	if len(queue) > 0 {
+		if hold, err := queueHoldReason(ctx, mgr, application.SessionName); err == nil && hold != "" {
+			fmt.Printf("  %s Held: %s\n", icon.Warning, hold)
+		}
+	}
+
+	// Outbox
+	actions, err := task.NewOutbox(application.OutboxDir).List()
+	if err != nil {
 		return err
 	}
 
-	return runSetupWizard(application)
+	fmt.Println("\nOutbox:")
+	if len(actions) == 0 {
+		fmt.Println("  (empty)")
+	}
+	for _, a := range actions {
+		state := "due now"
+		if a.GaveUp() {
+			state = "gave up"
+		} else if wait := time.Until(a.NextAttempt); wait > 0 {
+			state = fmt.Sprintf("retry in %s", wait.Round(time.Second))
+		}
+		fmt.Printf("  %-10s %-32s attempts: %d, %s\n", a.Kind, a.TaskName, a.Attempts, state)
+		if a.LastError != "" {
+			fmt.Printf("             last error: %s\n", strings.TrimSpace(a.LastError))
+		}
+	}
+
+	return nil
 }
 
-// runSetupWizard runs the interactive setup wizard
-func runSetupWizard(app *app.App) error {
-	cfg := config.DefaultConfig()
+// ownerOrDash returns the owner of a task, or "-" if it isn't known.
+func ownerOrDash(owner string) string {
+	if owner == "" {
+		return "-"
+	}
+	return owner
+}
 
-	fmt.Println("\n🚀 TAW Setup Wizard")
+// runPromptShow prints the composed system prompt
+func runPromptShow(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
 
-	// Work mode (only for git repos)
-	if app.IsGitRepo {
-		fmt.Println("Work Mode:")
-		fmt.Println("  1. worktree (Recommended) - Each task gets its own git worktree")
-		fmt.Println("  2. main - All tasks work on current branch")
-		fmt.Print("\nSelect [1-2, default: 1]: ")
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
 
-		var choice string
-		fmt.Scanln(&choice)
+	application, err := app.New(cwd)
+	if err != nil {
+		return err
+	}
 
-		switch choice {
-		case "2":
-			cfg.WorkMode = config.WorkModeMain
-		default:
-			cfg.WorkMode = config.WorkModeWorktree
+	gitClient := git.New()
+	application.SetGitRepo(gitClient.IsGitRepo(ctx, cwd))
+
+	if len(args) == 0 {
+		fmt.Println(icon.Localize(claude.BuildSystemPrompt(nil, application.GetPromptLayers("")...)))
+		return nil
+	}
+
+	// The task's placeholders depend on the configuration
+	if err := application.LoadConfig(); err != nil {
+		return err
+	}
+	mgr := task.NewManager(application.AgentsDir, application.ProjectDir, application.TawDir, application.IsGitRepo, application.Config)
+	t, err := mgr.GetTask(args[0])
+	if err != nil {
+		return err
+	}
+	layers := append(application.GetPromptLayers(t.GetPromptPath()), mgr.KnowledgeLayers(t)...)
+	vars := mgr.TaskContext(ctx, t, application.SessionName).Vars()
+	fmt.Println(icon.Localize(claude.BuildSystemPrompt(vars, layers...)))
+	return nil
+}
+
+// runPromptKnowledge lists the knowledge base, or the notes a task gets
+func runPromptKnowledge(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	application, err := app.New(cwd)
+	if err != nil {
+		return err
+	}
+	mgr := task.NewManager(application.AgentsDir, application.ProjectDir, application.TawDir, false, application.Config)
+
+	docs, err := mgr.Knowledge()
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		t, err := mgr.GetTask(args[0])
+		if err != nil {
+			return err
 		}
+		docs = mgr.MatchKnowledge(t)
+	}
+	if len(docs) == 0 {
+		fmt.Printf("No knowledge notes (add markdown files to %s)\n", mgr.KnowledgeDir())
+		return nil
 	}
 
-	// On complete
-	fmt.Println("\nOn Complete Action:")
-	fmt.Println("  1. confirm (Recommended) - Ask before each action")
-	fmt.Println("  2. auto-commit - Automatically commit changes")
-	fmt.Println("  3. auto-merge - Auto commit + merge + cleanup")
-	fmt.Println("  4. auto-pr - Auto commit + create pull request")
-	fmt.Print("\nSelect [1-4, default: 1]: ")
-
-	var choice string
-	fmt.Scanln(&choice)
-
-	switch choice {
-	case "2":
-		cfg.OnComplete = config.OnCompleteAutoCommit
-	case "3":
-		cfg.OnComplete = config.OnCompleteAutoMerge
-	case "4":
-		cfg.OnComplete = config.OnCompleteAutoPR
+	for _, doc := range docs {
+		var match []string
+		if doc.Always {
+			match = append(match, "always")
+		}
+		for _, tag := range doc.Tags {
+			match = append(match, "#"+tag)
+		}
+		match = append(match, doc.Keywords...)
+		fmt.Printf("%-24s %5d bytes  %s\n", doc.Name, len(doc.Content), strings.Join(match, ", "))
+	}
+	return nil
+}
+
+// runReport prints a summary of archived tasks
+func runReport(cmd *cobra.Command, args []string) error {
+	period, err := task.ParseSince(reportSince)
+	if err != nil {
+		return err
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	application, err := app.New(cwd)
+	if err != nil {
+		return err
+	}
+
+	if !application.IsInitialized() {
+		return fmt.Errorf("no .taw directory found in %s", application.ProjectDir)
+	}
+
+	entries, err := task.NewArchive(application.ArchiveDir).List()
+	if err != nil {
+		return err
+	}
+
+	since := time.Now().Add(-period)
+	report := task.BuildReport(task.FilterArchiveOwner(entries, reportOwner), since)
+	report.Owner = reportOwner
+	mgr := task.NewManager(application.AgentsDir, application.ProjectDir, application.TawDir, application.IsGitRepo, nil)
+	report.AddCost(mgr.SpendSince(since))
+
+	switch reportFormat {
+	case "md":
+		fmt.Print(report.Markdown())
+	case "json":
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode report: %w", err)
+		}
+		fmt.Println(string(data))
 	default:
-		cfg.OnComplete = config.OnCompleteConfirm
+		return fmt.Errorf("unknown format: %s (use md or json)", reportFormat)
+	}
+
+	return nil
+}
+
+// runSetup runs the setup wizard
+func runSetup(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	application, err := app.New(cwd)
+	if err != nil {
+		return err
+	}
+
+	gitClient := git.New()
+	application.SetGitRepo(gitClient.IsGitRepo(ctx, cwd))
+
+	// Initialize .taw directory
+	if err := application.Initialize(); err != nil {
+		return err
+	}
+
+	return runSetupWizard(application, setupPreset)
+}
+
+// runSetupWizard runs the interactive setup wizard, starting from the current
+// config, or applies a preset without it. Without a terminal, the current
+// config (or the defaults) is saved.
+func runSetupWizard(app *app.App, preset string) error {
+	cfg, err := config.Load(app.TawDir)
+	if err != nil {
+		return err
+	}
+
+	if preset != "" {
+		if err := cfg.ApplyPreset(preset, app.ProjectDir); err != nil {
+			return err
+		}
+	} else if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		result, err := tui.RunSetupWizard(cfg, app.ProjectDir, app.IsGitRepo)
+		if err != nil {
+			return fmt.Errorf("setup wizard failed: %w", err)
+		}
+		if result.Cancelled {
+			if app.HasConfig() {
+				fmt.Println("Setup cancelled; config left unchanged")
+				return nil
+			}
+			return fmt.Errorf("setup cancelled")
+		}
+		cfg, preset = result.Config, result.Preset
 	}
 
 	// Save configuration
@@ -467,9 +1054,34 @@ func runSetupWizard(app *app.App) error {
 		return fmt.Errorf("failed to save configuration: %w", err)
 	}
 
-	fmt.Println("\n✅ Configuration saved!")
-	fmt.Printf("   Work mode: %s\n", cfg.WorkMode)
+	fmt.Println("\n" + icon.Success.String() + " Configuration saved!")
+	if preset != "" {
+		fmt.Printf("   Preset: %s\n", preset)
+	}
+	if app.IsGitRepo {
+		fmt.Printf("   Work mode: %s\n", cfg.WorkMode)
+	}
 	fmt.Printf("   On complete: %s\n", cfg.OnComplete)
+	if app.IsGitRepo {
+		fmt.Printf("   Merge strategy: %s\n", cfg.MergeStrategy)
+	}
+	if cfg.MaxParallel > 0 {
+		fmt.Printf("   Max parallel tasks: %d\n", cfg.MaxParallel)
+	}
+	if cfg.Model != "" {
+		fmt.Printf("   Model: %s\n", cfg.Model)
+	}
+	if app.IsGitRepo && cfg.WorkMode == config.WorkModeWorktree {
+		fmt.Printf("   Branch template: %s\n", cfg.BranchTemplate)
+		if cfg.WorktreeRoot != "" {
+			fmt.Printf("   Worktree root: %s\n", cfg.WorktreeRoot)
+		}
+	}
+	fmt.Printf("   Notifications: %s\n", cfg.Notifications)
+	if cfg.Verify.Command != "" {
+		fmt.Printf("   Verify command: %s\n", cfg.Verify.Command)
+	}
+	fmt.Println("   Change these later with 'taw setup' or 'taw config set'")
 
 	return nil
 }
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/spf13/cobra"
+
+	"github.com/donghojung/taw/internal/icon"
+	"github.com/donghojung/taw/internal/logging"
+	"github.com/donghojung/taw/internal/task"
+	"github.com/donghojung/taw/internal/tmux"
+)
+
+var openCmd = &cobra.Command{
+	Use:   "open [task]",
+	Short: "Open a task's worktree in your editor or IDE",
+	Long: `Open a task's worktree (its working directory without one) in the editor or
+IDE set with ide: code (the default), idea, cursor, or any command, where {dir}
+is the directory and {task} the task name. ⌥o does the same for the current
+task window.
+
+Without a task name, the task of the shell pane it runs in is opened. In a
+GitHub Codespace or a VS Code Remote terminal, code opens the worktree in the
+connected VS Code window.`,
+	Example: `  taw open fix-login-test
+  taw config set ide "idea {dir}"`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeTaskName(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, mgr, err := loadProject(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		name := os.Getenv("TASK_NAME")
+		if len(args) > 0 {
+			name = args[0]
+		}
+		if name == "" {
+			return errors.New("name the task to open")
+		}
+		t, err := mgr.GetTask(name)
+		if err != nil {
+			return err
+		}
+
+		command, err := openInIDE(mgr, t)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s Opened %s: %s\n", icon.Success, t.Name, command)
+		return nil
+	},
+}
+
+var openTaskCmd = &cobra.Command{
+	Use:   "open-task [session] [task-id]",
+	Short: "Open the worktree of a task window in the configured IDE",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		sessionName := args[0]
+		tm := tmux.New(sessionName)
+
+		app, err := getAppFromSession(ctx, sessionName)
+		if err != nil {
+			return err
+		}
+
+		mgr := task.NewManager(app.AgentsDir, app.ProjectDir, app.TawDir, app.IsGitRepo, app.Config)
+		t, _, err := findTaskByID(tm, mgr, args[1])
+		if err != nil {
+			tm.DisplayMessage(fmt.Sprintf("Nothing to open: %v", err))
+			return nil
+		}
+
+		command, err := openInIDE(mgr, t)
+		if err != nil {
+			tm.DisplayMessage(fmt.Sprintf("%s %s: %v", icon.Warning, t.Name, err))
+			return nil
+		}
+		logging.Log("Opened %s: %s", t.Name, command)
+		tm.DisplayMessage(fmt.Sprintf("Opening %s in %s", t.Name, app.Config.IDE))
+		return nil
+	},
+}
+
+// openInIDE starts the ide command for a task without waiting for it, as
+// IDEs may keep running, and returns the command.
+func openInIDE(mgr *task.Manager, t *task.Task) (string, error) {
+	command, err := mgr.IDECommand(t)
+	if err != nil {
+		return "", err
+	}
+
+	c := exec.Command("sh", "-c", command)
+	c.Dir = mgr.GetWorkingDirectory(t)
+	if err := c.Start(); err != nil {
+		return command, fmt.Errorf("failed to run %s: %w", command, err)
+	}
+	go c.Wait()
+	return command, nil
+}
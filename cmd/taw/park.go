@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/donghojung/taw/internal/config"
+	"github.com/donghojung/taw/internal/icon"
+	"github.com/donghojung/taw/internal/logging"
+	"github.com/donghojung/taw/internal/task"
+	"github.com/donghojung/taw/internal/tmux"
+)
+
+var quitCmd = &cobra.Command{
+	Use:   "quit [session] [client]",
+	Short: "Detach the client, parking the tasks in progress per park_on_quit",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		sessionName := args[0]
+		client := args[1]
+		tm := tmux.New(sessionName)
+
+		app, err := getAppFromSession(ctx, sessionName)
+		if err != nil {
+			return detachClient(tm, client)
+		}
+
+		mgr := task.NewManager(app.AgentsDir, app.ProjectDir, app.TawDir, app.IsGitRepo, app.Config)
+		tasks, err := mgr.ParkableTasks()
+		if err != nil {
+			logging.Debug("Failed to list tasks to park: %v", err)
+		}
+		if len(tasks) == 0 {
+			return detachClient(tm, client)
+		}
+
+		switch mgr.ParkOnQuit() {
+		case config.ParkOnQuitAuto:
+			if err := spawnInternal(sessionName, "park-tasks"); err != nil {
+				logging.Warn("Failed to start park-tasks: %v", err)
+			}
+			return detachClient(tm, client)
+		case config.ParkOnQuitAsk:
+			tawBin, _ := os.Executable()
+			return tm.DisplayPopup(tmux.PopupOpts{
+				Width:  "70",
+				Height: fmt.Sprintf("%d", len(tasks)+7),
+				Title:  " Quit ",
+				Close:  true,
+			}, fmt.Sprintf("%s internal quit-ui '%s' '%s'", tawBin, sessionName, client))
+		}
+		return detachClient(tm, client)
+	},
+}
+
+var quitUICmd = &cobra.Command{
+	Use:   "quit-ui [session] [client]",
+	Short: "Ask whether to park the tasks in progress, then detach the client",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		sessionName := args[0]
+		client := args[1]
+		tm := tmux.New(sessionName)
+
+		app, err := getAppFromSession(ctx, sessionName)
+		if err != nil {
+			return err
+		}
+
+		mgr := task.NewManager(app.AgentsDir, app.ProjectDir, app.TawDir, app.IsGitRepo, app.Config)
+		tasks, err := mgr.ParkableTasks()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%d task(s) in progress:\n", len(tasks))
+		for _, t := range tasks {
+			fmt.Printf("  %s %s\n", t.StatusIcon(), t.Name)
+		}
+		fmt.Println()
+		if confirm("Push them as draft pull requests before quitting?") {
+			if err := spawnInternal(sessionName, "park-tasks"); err != nil {
+				return err
+			}
+		}
+		return detachClient(tm, client)
+	},
+}
+
+var parkTasksCmd = &cobra.Command{
+	Use:   "park-tasks [session]",
+	Short: "Push the tasks in progress with draft pull requests and mark them parked",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		sessionName := args[0]
+
+		app, err := getAppFromSession(ctx, sessionName)
+		if err != nil {
+			return err
+		}
+
+		// Setup logging
+		logger, _ := logging.New(app.GetLogPath(), app.Debug)
+		if logger != nil {
+			defer logger.Close()
+			logger.SetScript("park-tasks")
+			logging.SetGlobal(logger)
+		}
+
+		tm := tmux.New(sessionName)
+		mgr := task.NewManager(app.AgentsDir, app.ProjectDir, app.TawDir, app.IsGitRepo, app.Config)
+		tasks, err := mgr.ParkableTasks()
+		if err != nil {
+			return err
+		}
+
+		parked := 0
+		for _, t := range tasks {
+			if parkTask(ctx, tm, mgr, t) {
+				parked++
+			}
+		}
+		if parked > 0 {
+			notifyUser(app, tm, fmt.Sprintf("%s Parked %d task(s) as draft pull requests", icon.Done, parked))
+		}
+		return nil
+	},
+}
+
+// parkTask parks a task under its lock and refreshes its window. A task that
+// fails to park is left as it was.
+func parkTask(ctx context.Context, tm tmux.Client, mgr *task.Manager, t *task.Task) bool {
+	unlock, err := lockTask(tm, mgr, t, "park-task")
+	if err != nil {
+		return false
+	}
+	defer unlock()
+
+	prNumber, err := mgr.ParkTask(ctx, t)
+	if err != nil {
+		logging.Warn("Failed to park %s: %v", t.Name, err)
+		return false
+	}
+	logging.Log("Parked %s with pull request #%d", t.Name, prNumber)
+
+	if windowID, err := t.LoadWindowID(); err == nil && windowID != "" {
+		if err := t.SyncWindow(tm, windowID); err != nil {
+			logging.Debug("Failed to update window: %v", err)
+		}
+	}
+	return true
+}
+
+// detachClient detaches the client that quit.
+func detachClient(tm tmux.Client, client string) error {
+	return tm.Run("detach-client", "-t", client)
+}
@@ -0,0 +1,499 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/donghojung/taw/internal/app"
+	"github.com/donghojung/taw/internal/config"
+	"github.com/donghojung/taw/internal/constants"
+	"github.com/donghojung/taw/internal/git"
+	"github.com/donghojung/taw/internal/github"
+	"github.com/donghojung/taw/internal/icon"
+	"github.com/donghojung/taw/internal/logging"
+	"github.com/donghojung/taw/internal/task"
+	"github.com/donghojung/taw/internal/telemetry"
+	"github.com/donghojung/taw/internal/tmux"
+	"github.com/donghojung/taw/internal/tui"
+)
+
+// endPipeline ends a task: it runs the steps of the task's pipeline in order
+// (see task.Manager.EndSteps), then records the task, cleans it up and closes
+// its window. end-task runs it in the background and verify-task in the end
+// task UI. A step that fails, or a draft waiting for the others, keeps the
+// task open instead.
+type endPipeline struct {
+	app         *app.App
+	mgr         *task.Manager
+	tm          tmux.Client
+	sessionName string
+	windowID    string
+	t           *task.Task
+	actions     []config.PipelineAction
+
+	// keep tells the user and the agent why the task stays open, once the
+	// pipeline stops. Set by the failing step.
+	keep func(ctx context.Context) error
+
+	outcome      task.ArchiveOutcome
+	diffRecorded bool
+}
+
+// endStep is a step of an end pipeline, as shown in the end task UI. The
+// verify action runs as a step per verification step.
+type endStep struct {
+	name         string
+	allowFailure bool
+	run          func(ctx context.Context) (tui.StepStatus, string)
+}
+
+// newEndPipeline returns the pipeline that ends a task, merging it whatever
+// on_complete says if merge is set.
+func newEndPipeline(app *app.App, mgr *task.Manager, tm tmux.Client, sessionName, windowID string, t *task.Task, merge bool) *endPipeline {
+	// Tasks of a batch complete the way the batch was queued
+	onComplete := mgr.OnComplete(t)
+	actions := mgr.EndSteps(t, merge)
+	logging.Log("=== End task ===")
+	logging.Log("ON_COMPLETE=%s STEPS=%v", onComplete, actions)
+	countUsage(telemetry.EventComplete + string(onComplete))
+
+	return &endPipeline{
+		app:         app,
+		mgr:         mgr,
+		tm:          tm,
+		sessionName: sessionName,
+		windowID:    windowID,
+		t:           t,
+		actions:     actions,
+		outcome:     task.OutcomeCompleted,
+	}
+}
+
+// steps returns the steps to run. Without git, only verify, commit (which
+// copies a snapshot's changes back, so it always runs) and notify apply.
+// Competing drafts stop after commit to wait for the user to pick one.
+func (p *endPipeline) steps(ctx context.Context, skipVerify bool) []endStep {
+	actions := p.actions
+	if !p.app.IsGitRepo && !config.HasAction(actions, config.ActionCommit) {
+		actions = append([]config.PipelineAction{config.ActionCommit}, actions...)
+	}
+
+	var steps []endStep
+	for _, action := range actions {
+		switch action {
+		case config.ActionVerify:
+			if !skipVerify {
+				steps = append(steps, p.verifySteps(ctx)...)
+			}
+		case config.ActionCommit:
+			if p.app.IsGitRepo {
+				steps = append(steps, endStep{name: "commit", run: p.commit})
+			} else {
+				steps = append(steps, endStep{name: "apply changes", run: p.applyChanges})
+			}
+			if p.t.DraftGroup != "" {
+				return append(steps, endStep{name: "draft", run: p.finishDraft})
+			}
+		case config.ActionPush:
+			if p.app.IsGitRepo {
+				steps = append(steps, endStep{name: "push", run: p.push})
+			}
+		case config.ActionPR:
+			if p.app.IsGitRepo {
+				steps = append(steps, endStep{name: "pull request", run: p.openPR})
+			}
+		case config.ActionMerge:
+			if p.app.IsGitRepo {
+				steps = append(steps, endStep{name: fmt.Sprintf("merge (%s)", p.mgr.MergeStrategy()), run: p.merge})
+			}
+		case config.ActionNotify:
+			steps = append(steps, endStep{name: "notify", run: p.notify})
+		}
+	}
+	return steps
+}
+
+// run runs the steps in the background, then ends the task or keeps it open.
+func (p *endPipeline) run(ctx context.Context, skipVerify bool) error {
+	for _, step := range p.steps(ctx, skipVerify) {
+		status, _ := p.runStep(ctx, step)
+		if ctx.Err() != nil {
+			// Cancelled - leave the task as it is
+			return ctx.Err()
+		}
+		if status == tui.StepFail && !step.allowFailure {
+			break
+		}
+	}
+	return p.end(ctx)
+}
+
+// runStep runs a step, forgetting why a previous run of it kept the task.
+func (p *endPipeline) runStep(ctx context.Context, step endStep) (tui.StepStatus, string) {
+	p.keep = nil
+	status, message := step.run(ctx)
+	if message != "" {
+		logging.Log("Step %s: %s (%s)", step.name, status, message)
+	} else {
+		logging.Log("Step %s: %s", step.name, status)
+	}
+	return status, message
+}
+
+// uiSteps returns the steps for the end task UI.
+func (p *endPipeline) uiSteps(ctx context.Context, skipVerify bool) []tui.Step {
+	var steps []tui.Step
+	for _, step := range p.steps(ctx, skipVerify) {
+		step := step
+		steps = append(steps, tui.Step{
+			Name:         step.name,
+			AllowFailure: step.allowFailure,
+			Run: func(ctx context.Context) (tui.StepStatus, string) {
+				return p.runStep(ctx, step)
+			},
+		})
+	}
+	return steps
+}
+
+// end keeps the task open if a step asked to, else finishes it: records it,
+// cleans it up, closes its window and starts the next queued task.
+func (p *endPipeline) end(ctx context.Context) error {
+	if p.keep != nil {
+		return p.keep(ctx)
+	}
+
+	// Archive the diff if the branch still differs from main
+	p.recordDiff(ctx)
+
+	// Keep the answer of an ask task before its agent directory goes
+	if p.t.IsAsk() {
+		p.saveAnswer()
+	}
+
+	p.mgr.RecordCompletion(p.t, p.outcome)
+	if p.outcome == task.OutcomeMerged {
+		emitEvent(ctx, p.app, p.mgr, p.sessionName, config.EventTaskMerged, p.t, "")
+	}
+	emitEvent(ctx, p.app, p.mgr, p.sessionName, config.EventTaskCompleted, p.t, "")
+
+	logging.Log("Cleanup started")
+	if err := p.mgr.CleanupTask(ctx, p.t); err != nil {
+		logging.Warn("Cleanup failed: %v", err)
+	} else {
+		logging.Log("Cleanup completed")
+	}
+
+	if p.windowID != "" {
+		if err := p.tm.KillWindow(p.windowID); err != nil {
+			logging.Warn("Failed to kill window: %v", err)
+		}
+	}
+
+	if err := spawnInternal(p.sessionName, "process-queue"); err != nil {
+		logging.Debug("Failed to start process-queue: %v", err)
+	}
+	return nil
+}
+
+// keepOpen keeps the task open with its window showing its status, after a
+// message to the user unless it's empty.
+func (p *endPipeline) keepOpen(message string) {
+	p.keep = func(context.Context) error {
+		p.showKept(message)
+		return nil
+	}
+}
+
+// showKept shows the user why the task was kept open.
+func (p *endPipeline) showKept(message string) {
+	if message != "" {
+		p.tm.DisplayMessage(message)
+	}
+	if p.windowID == "" {
+		return
+	}
+	if err := p.t.SyncWindow(p.tm, p.windowID); err != nil {
+		logging.Debug("Failed to update window: %v", err)
+	}
+}
+
+// fail emits the task-failed event for reason.
+func (p *endPipeline) fail(ctx context.Context, reason string) {
+	emitEvent(ctx, p.app, p.mgr, p.sessionName, config.EventTaskFailed, p.t, reason)
+}
+
+// verifySteps returns a step per verification step. A blocking failure asks
+// the agent to fix it.
+func (p *endPipeline) verifySteps(ctx context.Context) []endStep {
+	pipeline := p.mgr.VerifyPipeline(ctx, p.t)
+	steps := make([]endStep, len(pipeline))
+	for i, step := range pipeline {
+		step := step
+		first := i == 0
+		steps[i] = endStep{
+			name:         step.Name,
+			allowFailure: step.AllowFailure,
+			run: func(ctx context.Context) (tui.StepStatus, string) {
+				if first {
+					p.t.ClearVerifyResult()
+				}
+				result, err := p.mgr.VerifyStep(ctx, p.t, step)
+				if result == nil {
+					// Cancelled
+					return tui.StepFail, err.Error()
+				}
+				if err != nil {
+					logging.Warn("Failed to record %s result: %v", step.Name, err)
+				}
+				if result.Passed {
+					return tui.StepOK, result.Summary()
+				}
+				if !step.AllowFailure {
+					p.keep = func(ctx context.Context) error {
+						verifyResult := p.t.LoadVerifyResult()
+						if verifyResult == nil {
+							return nil
+						}
+						logging.Warn("Verification %s - keeping task open", verifyResult.Summary())
+						p.fail(ctx, "verification "+verifyResult.Summary())
+						return rejectVerification(ctx, p.tm, p.mgr, p.windowID, p.t, verifyResult)
+					}
+				}
+				return tui.StepFail, result.Summary()
+			},
+		}
+	}
+	return steps
+}
+
+// commit commits what the agent left, checks the commit messages and
+// updates the changelog.
+func (p *endPipeline) commit(ctx context.Context) (tui.StepStatus, string) {
+	message := "nothing to commit"
+	if committed, err := p.mgr.CommitChanges(ctx, p.t); err != nil {
+		logging.Warn("%v", err)
+	} else if committed {
+		message = "committed changes"
+	}
+
+	// Competing drafts are checked once the user picks one
+	if p.t.DraftGroup != "" {
+		return tui.StepOK, message
+	}
+
+	// Commit messages are checked before anything is pushed
+	issues, err := p.mgr.CheckCommits(ctx, p.t)
+	if err != nil {
+		logging.Warn("Failed to check commits: %v", err)
+	}
+	if len(issues) > 0 {
+		for _, issue := range issues {
+			logging.Warn("Commit %s", issue)
+		}
+		if p.mgr.CommitPolicy() != config.CommitPolicyWarn {
+			reason := fmt.Sprintf("%d commit messages don't follow the convention", len(issues))
+			p.keep = func(ctx context.Context) error {
+				p.fail(ctx, reason)
+				return rejectCommits(ctx, p.tm, p.mgr, p.windowID, p.t, issues)
+			}
+			return tui.StepFail, reason
+		}
+	}
+
+	if err := p.mgr.UpdateChangelog(ctx, p.t); err != nil {
+		logging.Warn("Failed to update changelog: %v", err)
+	}
+	return tui.StepOK, message
+}
+
+// applyChanges copies the changes of a task without git back to the project,
+// unless the project changed under them.
+func (p *endPipeline) applyChanges(ctx context.Context) (tui.StepStatus, string) {
+	changes, err := p.mgr.NoGitChanges(p.t)
+	if err != nil {
+		logging.Warn("Failed to summarize changes: %v", err)
+		return tui.StepSkip, ""
+	}
+	logging.Log("Changed files: %s", changes.Summary())
+	for _, line := range changes.Lines() {
+		logging.Log("  %s", line)
+	}
+
+	if err := p.mgr.ApplySnapshot(p.t, changes); err != nil {
+		logging.Warn("Failed to apply snapshot: %v", err)
+		p.keep = func(ctx context.Context) error {
+			p.fail(ctx, err.Error())
+			p.showKept(fmt.Sprintf(icon.Warning.String()+" %s: %v - merge them into %s and end the task again", p.t.Name, err, p.t.GetSnapshotDir()))
+			return nil
+		}
+		return tui.StepFail, err.Error()
+	}
+
+	p.mgr.RecordChanges(p.t, changes)
+	p.tm.DisplayMessage(fmt.Sprintf(icon.Done.String()+" %s: %s", p.t.Name, changes.Summary()))
+	return tui.StepOK, changes.Summary()
+}
+
+// finishDraft marks a competing draft done; the task stays open until the
+// user picks a draft.
+func (p *endPipeline) finishDraft(ctx context.Context) (tui.StepStatus, string) {
+	p.keep = func(context.Context) error {
+		return finishDraft(p.tm, p.mgr, p.sessionName, p.windowID, p.t)
+	}
+	return tui.StepOK, "waiting for the other drafts"
+}
+
+// push pushes the task branch; a non-fast-forward push is retried after a
+// rebase, and a network failure in the background.
+func (p *endPipeline) push(ctx context.Context) (tui.StepStatus, string) {
+	pushErr := p.mgr.Preflight(ctx)
+	if pushErr == nil {
+		pushErr = p.mgr.PushTask(ctx, p.t)
+	}
+	if pushErr == nil {
+		return tui.StepOK, ""
+	}
+	logging.Warn("Failed to push: %v", pushErr)
+
+	// Keep the task open so the unpushed work isn't cleaned up
+	var preflightErr *task.PreflightError
+	var taskPushErr *task.PushError
+	message := pushErr.Error()
+	p.keep = func(ctx context.Context) error {
+		switch {
+		case errors.As(pushErr, &preflightErr):
+			p.t.SavePushFailure(preflightErr.Hint)
+			p.tm.DisplayMessage(fmt.Sprintf(icon.Warning.String()+" %s: %s", p.t.Name, preflightErr.Hint))
+		case errors.As(pushErr, &taskPushErr):
+			p.tm.DisplayMessage(fmt.Sprintf(icon.Warning.String()+" %s: %s", p.t.Name, taskPushErr.Hint()))
+			// Transient failures are retried in the background
+			if taskPushErr.Kind == git.PushErrorNetwork {
+				enqueueOutbox(p.app, p.sessionName, task.OutboxPush, p.t.Name)
+			}
+		}
+		p.fail(ctx, fmt.Sprintf("push failed: %v", pushErr))
+		p.showKept("")
+		return nil
+	}
+	switch {
+	case errors.As(pushErr, &preflightErr):
+		message = preflightErr.Hint
+	case errors.As(pushErr, &taskPushErr):
+		message = taskPushErr.Hint()
+	}
+	return tui.StepFail, message
+}
+
+// openPR opens a pull request for the task unless it has one; one GitHub
+// couldn't be reached for is retried in the background.
+func (p *endPipeline) openPR(ctx context.Context) (tui.StepStatus, string) {
+	prNumber, err := p.mgr.OpenPR(ctx, p.t)
+	if err != nil {
+		logging.Warn("Failed to open pull request: %v", err)
+		hint := github.ErrorHint(err)
+		if hint == "" {
+			hint = err.Error()
+		}
+		p.keep = func(ctx context.Context) error {
+			if github.IsTransient(err) {
+				enqueueOutbox(p.app, p.sessionName, task.OutboxCreatePR, p.t.Name)
+			}
+			p.fail(ctx, fmt.Sprintf("pull request failed: %v", err))
+			p.showKept(fmt.Sprintf(icon.Warning.String()+" %s: %s", p.t.Name, hint))
+			return nil
+		}
+		return tui.StepFail, hint
+	}
+	logging.Log("Pull request #%d", prNumber)
+	return tui.StepOK, fmt.Sprintf("#%d", prNumber)
+}
+
+// merge merges the task into the main branch without touching the project
+// directory's checkout. A conflict keeps the task open to be resolved in its
+// worktree, and a network failure is retried in the background.
+func (p *endPipeline) merge(ctx context.Context) (tui.StepStatus, string) {
+	// Archive the diff while the branch still differs from main
+	p.recordDiff(ctx)
+
+	strategy := p.mgr.MergeStrategy()
+	logging.Log("Merging to main (strategy: %s)...", strategy)
+	err := p.mgr.MergeToMain(ctx, p.t)
+	if err == nil {
+		p.outcome = task.OutcomeMerged
+		if strategy == config.MergeStrategyMergeQueue {
+			return tui.StepOK, fmt.Sprintf("enqueued for %s", p.mgr.MainBranch(ctx))
+		}
+		return tui.StepOK, fmt.Sprintf("merged into %s", p.mgr.MainBranch(ctx))
+	}
+
+	// Keep the task open while another operation holds the project
+	if errors.Is(err, task.ErrProjectLocked) {
+		logging.Warn("Merge postponed: %v", err)
+		p.keepOpen(fmt.Sprintf(icon.Postponed.String()+" %s: %v, try again later", p.t.Name, err))
+		return tui.StepFail, err.Error()
+	}
+
+	logging.Warn("Merge failed: %v - may need manual resolution", err)
+	p.fail(ctx, fmt.Sprintf("merge failed: %v", err))
+	message := err.Error()
+	if hint := task.Hint(err); hint != "" {
+		p.tm.DisplayMessage(fmt.Sprintf(icon.Warning.String()+" %s: %s", p.t.Name, hint))
+		message = hint
+	}
+
+	// Keep the task open so the conflict can be resolved in its worktree, or
+	// main checked out elsewhere so it can move
+	if errors.Is(err, git.ErrMergeConflict) || errors.Is(err, task.ErrMainCheckedOut) {
+		p.keepOpen("")
+		return tui.StepFail, message
+	}
+	// Keep the task open and retry in the background if the remote was unreachable
+	if git.ClassifyPushError(err) == git.PushErrorNetwork {
+		p.keep = func(context.Context) error {
+			enqueueOutbox(p.app, p.sessionName, task.OutboxMerge, p.t.Name)
+			return nil
+		}
+		return tui.StepFail, message
+	}
+	// The branch is pushed, so the task ends without the merge
+	return tui.StepSkip, message
+}
+
+// notify tells the user the task ended, with the notify.message option.
+func (p *endPipeline) notify(ctx context.Context) (tui.StepStatus, string) {
+	message := p.mgr.PipelineOption(p.t, config.ActionNotify, "message")
+	if message == "" {
+		message = fmt.Sprintf("%s ended", p.t.Name)
+	}
+	notifyUser(p.app, p.tm, icon.Done.String()+" "+message)
+	return tui.StepOK, ""
+}
+
+// saveAnswer keeps the answer of an ask task, falling back to the end of its
+// agent's pane, and tells the user where to read it (errors are non-fatal).
+func (p *endPipeline) saveAnswer() {
+	transcript := ""
+	if p.windowID != "" {
+		if out, err := p.tm.CapturePane(p.windowID+".0", constants.AnswerPaneLines); err == nil {
+			transcript = out
+		}
+	}
+	path, err := p.mgr.SaveAnswer(p.t, transcript)
+	if err != nil {
+		logging.Warn("Failed to keep answer: %v", err)
+		return
+	}
+	logging.Log("Answer kept in %s", path)
+	notifyUser(p.app, p.tm, fmt.Sprintf("%s %s answered: taw show %s", icon.Done, p.t.Name, p.t.Name))
+}
+
+// recordDiff archives the task branch's diff against main, once.
+func (p *endPipeline) recordDiff(ctx context.Context) {
+	if p.diffRecorded || !p.app.IsGitRepo {
+		return
+	}
+	p.mgr.RecordDiff(ctx, p.t)
+	p.diffRecorded = true
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+// Profiling output paths (hidden --cpuprofile/--memprofile flags)
+var (
+	cpuProfilePath string
+	memProfilePath string
+	cpuProfileFile *os.File
+)
+
+// startProfiling starts CPU profiling if requested.
+func startProfiling() error {
+	if cpuProfilePath == "" {
+		return nil
+	}
+
+	f, err := os.Create(cpuProfilePath)
+	if err != nil {
+		return fmt.Errorf("failed to create CPU profile: %w", err)
+	}
+	if err := pprof.StartCPUProfile(f); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to start CPU profile: %w", err)
+	}
+	cpuProfileFile = f
+	return nil
+}
+
+// stopProfiling stops CPU profiling and writes the heap profile if requested.
+func stopProfiling() {
+	if cpuProfileFile != nil {
+		pprof.StopCPUProfile()
+		cpuProfileFile.Close()
+		cpuProfileFile = nil
+	}
+
+	if memProfilePath == "" {
+		return
+	}
+
+	f, err := os.Create(memProfilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create memory profile: %v\n", err)
+		return
+	}
+	defer f.Close()
+
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write memory profile: %v\n", err)
+	}
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/donghojung/taw/internal/app"
+	"github.com/donghojung/taw/internal/icon"
+	"github.com/donghojung/taw/internal/slack"
+	"github.com/donghojung/taw/internal/task"
+	"github.com/donghojung/taw/internal/tmux"
+)
+
+var queueCmd = &cobra.Command{
+	Use:   "queue",
+	Short: "Show the task queue and why it is held",
+	Long: `Show the queued tasks and whether they start on their own. queue.drain sets
+when they do: manual (only with 'taw queue start'), slot (as running tasks
+end), hours (only during queue.hours, e.g. overnight) or attached (only while
+a client is attached to the session). max_parallel_tasks, rate limits and the
+daily budget hold the queue as well.`,
+	Example: `  taw queue
+  taw queue add "Fix the flaky login test"
+  taw queue promote 3
+  taw queue list --json`,
+	Args: cobra.NoArgs,
+	RunE: runQueue,
+}
+
+var queueListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the queued tasks",
+	Args:  cobra.NoArgs,
+	RunE:  runQueue,
+}
+
+var queueAddCmd = &cobra.Command{
+	Use:   "add [content]",
+	Short: "Add a task to the end of the queue",
+	Long: `Add a task to the end of the queue, with the arguments as its content, or
+standard input without arguments. The session starts it when the queue allows.`,
+	Example: `  taw queue add "Fix the flaky login test"
+  taw queue add < task.md`,
+	RunE: runQueueAdd,
+}
+
+var queueRmCmd = &cobra.Command{
+	Use:     "rm <n>",
+	Short:   "Remove the task at position n from the queue",
+	Example: `  taw queue rm 2`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runQueueRm,
+}
+
+var queueClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove every task from the queue",
+	Args:  cobra.NoArgs,
+	RunE:  runQueueClear,
+}
+
+var queuePromoteCmd = &cobra.Command{
+	Use:     "promote <n>",
+	Short:   "Move the task at position n to the front of the queue",
+	Example: `  taw queue promote 3`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runQueuePromote,
+}
+
+var queueJSON bool
+
+var queueStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start the next queued task now",
+	Long: `Start the next queued task regardless of queue.drain. It still waits while
+max_parallel_tasks are running, agents back off from a rate limit or today
+is over budget.`,
+	Args: cobra.NoArgs,
+	RunE: runQueueStart,
+}
+
+func init() {
+	queueCmd.PersistentFlags().BoolVar(&queueJSON, "json", false, "Print the result as JSON")
+	queueCmd.AddCommand(queueStartCmd)
+	queueCmd.AddCommand(queueListCmd)
+	queueCmd.AddCommand(queueAddCmd)
+	queueCmd.AddCommand(queueRmCmd)
+	queueCmd.AddCommand(queueClearCmd)
+	queueCmd.AddCommand(queuePromoteCmd)
+}
+
+// queueItem is a queued task as the queue commands print it with --json.
+type queueItem struct {
+	Position int    `json:"position"`
+	Title    string `json:"title"`
+	Content  string `json:"content"`
+	File     string `json:"file"`
+}
+
+// queueList is the queue as 'taw queue list --json' prints it.
+type queueList struct {
+	Drain string      `json:"drain"`
+	Held  string      `json:"held,omitempty"`
+	Tasks []queueItem `json:"tasks"`
+}
+
+// newQueueItem returns the queued task at a position for --json.
+func newQueueItem(position int, t *task.QueuedTask) queueItem {
+	return queueItem{Position: position, Title: t.Title(), Content: t.Content, File: t.Path}
+}
+
+// printJSON prints v as indented JSON.
+func printJSON(v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// parseQueuePosition parses a queue position argument, 1 being the next task.
+func parseQueuePosition(arg string) (int, error) {
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid position %q: must be a number from 'taw queue list'", arg)
+	}
+	return n, nil
+}
+
+// queueChanged shows the changed queue in the status bar of a running
+// session.
+func queueChanged(application *app.App) {
+	if tm := tmux.New(application.SessionName); tm.HasSession(application.SessionName) {
+		updateQueueStatus(tm, application)
+	}
+}
+
+func runQueue(cmd *cobra.Command, args []string) error {
+	application, mgr, err := loadProject(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	queue, err := task.NewQueueManager(application.QueueDir).List()
+	if err != nil {
+		return err
+	}
+	if queueJSON {
+		list := queueList{Drain: string(mgr.QueueDrain()), Tasks: []queueItem{}}
+		for i := range queue {
+			list.Tasks = append(list.Tasks, newQueueItem(i+1, &queue[i]))
+		}
+		if len(queue) > 0 {
+			if list.Held, err = queueHoldReason(cmd.Context(), mgr, application.SessionName); err != nil {
+				return err
+			}
+		}
+		return printJSON(list)
+	}
+
+	fmt.Printf("Queue: %d task(s) waiting (drain: %s)\n", len(queue), mgr.QueueDrain())
+	for i := range queue {
+		fmt.Printf("  %d. %s\n", i+1, queue[i].Title())
+	}
+	if len(queue) == 0 {
+		return nil
+	}
+
+	hold, err := queueHoldReason(cmd.Context(), mgr, application.SessionName)
+	if err != nil {
+		return err
+	}
+	if hold != "" {
+		fmt.Printf("%s Held: %s\n", icon.Warning, hold)
+	}
+	return nil
+}
+
+func runQueueStart(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	application, mgr, err := loadProject(ctx)
+	if err != nil {
+		return err
+	}
+	if !tmux.New(application.SessionName).HasSession(application.SessionName) {
+		return fmt.Errorf("no taw session is running; run 'taw' first")
+	}
+
+	queued, tasks, err := processQueue(ctx, application, mgr, application.SessionName, true)
+	if err != nil {
+		return err
+	}
+	if queued == nil {
+		if queueJSON {
+			return printJSON(map[string][]string{"started": {}})
+		}
+		fmt.Println("The queue is empty")
+		return nil
+	}
+
+	names := make([]string, len(tasks))
+	for i, t := range tasks {
+		names[i] = t.Name
+	}
+	if queueJSON {
+		return printJSON(map[string][]string{"started": names})
+	}
+	fmt.Printf("%s Started %s: %s\n", icon.Success, strings.Join(names, ", "), queued.Title())
+	return nil
+}
+
+func runQueueAdd(cmd *cobra.Command, args []string) error {
+	application, _, err := loadProject(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	content := strings.Join(args, " ")
+	if len(args) == 0 {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read the task from standard input: %w", err)
+		}
+		content = string(data)
+	}
+	if strings.TrimSpace(content) == "" {
+		return fmt.Errorf("the task is empty")
+	}
+
+	queueMgr := task.NewQueueManager(application.QueueDir)
+	if err := queueMgr.Add(content); err != nil {
+		return fmt.Errorf("failed to queue task: %w", err)
+	}
+	queue, err := queueMgr.List()
+	if err != nil {
+		return err
+	}
+	added := &queue[len(queue)-1]
+
+	// The session starts the queue itself
+	if tm := tmux.New(application.SessionName); tm.HasSession(application.SessionName) {
+		updateQueueStatus(tm, application)
+		if err := spawnInternal(application.SessionName, "process-queue"); err != nil {
+			return err
+		}
+	}
+
+	if queueJSON {
+		return printJSON(newQueueItem(len(queue), added))
+	}
+	fmt.Printf("%s Queued at %d: %s\n", icon.Success, len(queue), added.Title())
+	return nil
+}
+
+func runQueueRm(cmd *cobra.Command, args []string) error {
+	n, err := parseQueuePosition(args[0])
+	if err != nil {
+		return err
+	}
+	application, _, err := loadProject(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	removed, err := task.NewQueueManager(application.QueueDir).Remove(n)
+	if err != nil {
+		return err
+	}
+	queueChanged(application)
+
+	if queueJSON {
+		return printJSON(newQueueItem(n, removed))
+	}
+	fmt.Printf("%s Removed %d: %s\n", icon.Success, n, removed.Title())
+	return nil
+}
+
+func runQueueClear(cmd *cobra.Command, args []string) error {
+	application, _, err := loadProject(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	queueMgr := task.NewQueueManager(application.QueueDir)
+	count, err := queueMgr.Count()
+	if err != nil {
+		return err
+	}
+	if err := queueMgr.Clear(); err != nil {
+		return err
+	}
+	queueChanged(application)
+
+	if queueJSON {
+		return printJSON(map[string]int{"removed": count})
+	}
+	fmt.Printf("%s Removed %d queued task(s)\n", icon.Success, count)
+	return nil
+}
+
+func runQueuePromote(cmd *cobra.Command, args []string) error {
+	n, err := parseQueuePosition(args[0])
+	if err != nil {
+		return err
+	}
+	application, _, err := loadProject(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	promoted, err := task.NewQueueManager(application.QueueDir).Promote(n)
+	if err != nil {
+		return err
+	}
+	queueChanged(application)
+
+	if queueJSON {
+		return printJSON(newQueueItem(1, promoted))
+	}
+	fmt.Printf("%s Next up: %s\n", icon.Success, promoted.Title())
+	return nil
+}
+
+// queueHoldReason returns why queued tasks don't start on their own now, or
+// "" if they do.
+func queueHoldReason(ctx context.Context, mgr *task.Manager, sessionName string) (string, error) {
+	blocked, err := mgr.QueueBlocked(ctx)
+	if err != nil || blocked != "" {
+		return blocked, err
+	}
+	return mgr.QueueHold(time.Now(), sessionAttached(sessionName)), nil
+}
+
+// queueTasks adds tasks to the queue, which applies max_parallel_tasks, rate
+// limits and budgets, and starts processing it. It returns false if the
+// session isn't running; the tasks then start with the next session.
+func queueTasks(app *app.App, contents ...string) (bool, error) {
+	return queueTasksFrom(app, nil, contents...)
+}
+
+// queueTasksFrom queues tasks like queueTasks, recording where in Slack they
+// were requested unless origin is nil. An error starting the queue comes
+// with true: the tasks are queued.
+func queueTasksFrom(app *app.App, origin *slack.Origin, contents ...string) (bool, error) {
+	queueMgr := task.NewQueueManager(app.QueueDir)
+	for _, content := range contents {
+		if err := queueMgr.AddWithOrigin(content, "", origin); err != nil {
+			return false, fmt.Errorf("failed to queue task: %w", err)
+		}
+	}
+
+	tm := tmux.New(app.SessionName)
+	if !tm.HasSession(app.SessionName) {
+		return false, nil
+	}
+	updateQueueStatus(tm, app)
+	return true, spawnInternal(app.SessionName, "process-queue")
+}
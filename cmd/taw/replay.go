@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/donghojung/taw/internal/icon"
+	"github.com/donghojung/taw/internal/tmux"
+)
+
+var replayCmd = &cobra.Command{
+	Use:   "replay <task>",
+	Short: "Run a task's instructions again on a fresh worktree",
+	Long: `Create a new task, <task>-replay, with the same content and extra prompt as
+the task, and send its agent the instructions the task's agent got, in order:
+the task instruction when it starts, then each nudge, failed verification and
+commit message fix once the agent is done with the previous one.
+
+Instructions are recorded in .taw/agents/<task>/.instructions. The system and
+user prompts are built again from the current prompt files, so a replay shows
+what a prompt change does to the same task.`,
+	Example:           `  taw replay fix-login-test`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTaskName(0),
+	RunE:              runReplay,
+}
+
+func runReplay(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	application, mgr, err := loadProject(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The replay's agent runs in a window of the session
+	tm := tmux.New(application.SessionName)
+	if !tm.HasSession(application.SessionName) {
+		return fmt.Errorf("no taw session is running; start taw first")
+	}
+
+	source, err := mgr.GetTask(args[0])
+	if err != nil {
+		return err
+	}
+	t, err := mgr.CreateReplay(ctx, source)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s %s created from %s\n", icon.Success, t.Name, source.Name)
+
+	if err := spawnInternal(application.SessionName, "handle-task", t.AgentDir); err != nil {
+		return fmt.Errorf("failed to start task: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/donghojung/taw/internal/github"
+	"github.com/donghojung/taw/internal/icon"
+	"github.com/donghojung/taw/internal/task"
+)
+
+var (
+	reviewCombined bool
+	reviewPrint    bool
+)
+
+var addressReviewCmd = &cobra.Command{
+	Use:   "address-review <pr#>",
+	Short: "Create tasks from the unresolved review comments of a pull request",
+	Long: `Fetch the unresolved review threads of a pull request with gh and queue a
+task per thread, or one task for all of them with --combined. Each task
+starts from the pull request's branch and pushes its commits to it when it
+ends, instead of opening a pull request of its own.
+
+Once a task ended with follow-up commits on the pull request, its threads are
+resolved with a reply naming the commit. Tasks whose content has a
+"Review: #<n> on <branch> at <commit>" line and "Review-Thread: <id>" lines
+are handled the same way.
+
+The tasks need work_mode: worktree, and a pull request whose branch is on the
+push remote.`,
+	Example: `  taw address-review 42
+  taw address-review 42 --combined`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAddressReview,
+}
+
+func init() {
+	addressReviewCmd.Flags().BoolVar(&reviewCombined, "combined", false, "Create one task for all threads")
+	addressReviewCmd.Flags().BoolVar(&reviewPrint, "print", false, "Print the task contents instead of queueing them")
+}
+
+func runAddressReview(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	prNumber, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+	if err != nil || prNumber <= 0 {
+		return fmt.Errorf("invalid PR number %q", args[0])
+	}
+
+	application, mgr, err := loadProject(ctx)
+	if err != nil {
+		return err
+	}
+
+	pr, threads, err := mgr.ReviewPR(ctx, prNumber)
+	if err != nil {
+		if hint := github.ErrorHint(err); hint != "" {
+			return fmt.Errorf("%w\n%s", err, hint)
+		}
+		return err
+	}
+	if len(threads) == 0 {
+		fmt.Printf("PR #%d has no unresolved review threads\n", prNumber)
+		return nil
+	}
+
+	contents := task.ReviewTaskContents(pr, threads, reviewCombined)
+	if reviewPrint {
+		fmt.Print(strings.Join(contents, "\n---\n\n"))
+		return nil
+	}
+
+	running, err := queueTasks(application, contents...)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Queued %d task(s) for %d review thread(s) of PR #%d on %s\n",
+		icon.Success, len(contents), len(threads), prNumber, pr.HeadRefName)
+	if !running {
+		fmt.Println("Run 'taw' to start them")
+	}
+	return nil
+}
@@ -0,0 +1,486 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/donghojung/taw/internal/app"
+	"github.com/donghojung/taw/internal/claude"
+	"github.com/donghojung/taw/internal/config"
+	"github.com/donghojung/taw/internal/embed"
+	"github.com/donghojung/taw/internal/git"
+	"github.com/donghojung/taw/internal/icon"
+	"github.com/donghojung/taw/internal/logging"
+	"github.com/donghojung/taw/internal/notify"
+	"github.com/donghojung/taw/internal/task"
+	"github.com/donghojung/taw/internal/telemetry"
+)
+
+// runOneTask is the name of an existing task to resume (--task).
+var runOneTask string
+
+var runOneCmd = &cobra.Command{
+	Use:   "run-one [task]",
+	Short: "Run a single task in this terminal, without tmux",
+	Long: `Run one task with the agent in the foreground of the current terminal, for
+systems without tmux or for debugging. The task gets its worktree, prompts,
+env and limits like in a tmux session. When the agent exits, TAW asks whether
+to finish the task: verify, commit, push, merge per on_complete, and clean up.
+
+The agent gets the terminal itself, so TAW doesn't see its output: there is
+no rate limit or stuck detection, and ask tasks only answer through
+answer.md, without falling back to the end of the agent's output.
+
+The task is read from the argument, or from stdin if none is given.`,
+	Example: `  taw run-one "Fix the flaky login test"
+  taw run-one < task.md
+  taw run-one --task fix-login-test   # Resume a task kept for later`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRunOne,
+}
+
+func runRunOne(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+
+	application, err := app.New(cwd)
+	if err != nil {
+		return fmt.Errorf("failed to create app: %w", err)
+	}
+
+	tawHome, err := getTawHome()
+	if err != nil {
+		return fmt.Errorf("failed to get TAW home: %w", err)
+	}
+	application.SetTawHome(tawHome)
+
+	gitClient := git.New()
+	application.SetGitRepo(gitClient.IsGitRepo(ctx, cwd))
+
+	if err := application.Initialize(); err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+
+	logger, err := logging.New(application.GetLogPath(), application.Debug)
+	if err != nil {
+		return fmt.Errorf("failed to setup logging: %w", err)
+	}
+	defer logger.Close()
+	logger.SetScript("run-one")
+	logging.SetGlobal(logger)
+
+	assetsDir, err := bootstrapAssets(application)
+	if err != nil {
+		return err
+	}
+	application.SetAssetsDir(assetsDir)
+
+	if !application.HasConfig() {
+		fmt.Println("No configuration found. Running setup...")
+		if err := runSetupWizard(application, ""); err != nil {
+			return err
+		}
+	}
+
+	if err := application.LoadConfig(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if err := application.Bootstrap(); err != nil {
+		logging.Warn("Failed to bootstrap project: %v", err)
+	}
+
+	mgr := task.NewManager(application.AgentsDir, application.ProjectDir, application.TawDir, application.IsGitRepo, application.Config)
+
+	// Create the task, or resume a kept one
+	var t *task.Task
+	if runOneTask != "" {
+		if t, err = mgr.GetTask(runOneTask); err != nil {
+			return err
+		}
+	} else {
+		content, err := readTaskContent(args)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Creating task...")
+		if t, err = mgr.CreateTask(ctx, content, ""); err != nil {
+			return err
+		}
+		emitEvent(ctx, application, mgr, "", config.EventTaskCreated, t, "")
+	}
+	logger.SetTask(t.Name)
+	logging.Log("Running task in terminal mode")
+
+	// The tab lock keeps a tmux session from opening the task at the same time
+	created, err := t.CreateTabLock()
+	if err != nil {
+		return err
+	}
+	if !created {
+		return fmt.Errorf("task %s is already open in a tmux session", t.Name)
+	}
+
+	if err := prepareNativeTask(ctx, application, mgr, t); err != nil {
+		t.RemoveTabLock()
+		return err
+	}
+
+	fmt.Printf("Starting agent for %s (exit the agent to finish the task)\n", t.Name)
+	if err := t.RecordStarted(); err != nil {
+		logging.Debug("Failed to record start: %v", err)
+	}
+	emitEvent(ctx, application, mgr, "", config.EventTaskStarted, t, "")
+	if err := runNativeAgent(ctx, application, mgr, t); err != nil {
+		logging.Warn("Agent exited: %v", err)
+		fmt.Printf("%s Agent exited: %v\n", icon.Warning, err)
+	}
+
+	// A new context: interrupting the agent may have cancelled the command's
+	finishCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if !confirm(fmt.Sprintf("Finish %s (verify, commit, push, merge per on_complete, clean up)?", t.Name)) {
+		if err := t.SaveStatus(task.StatusWaiting); err != nil {
+			logging.Debug("Failed to save status: %v", err)
+		}
+		t.RemoveTabLock()
+		fmt.Printf("Task kept. Resume it with: taw run-one --task %s\n", t.Name)
+		return nil
+	}
+
+	return finishNativeTask(finishCtx, application, mgr, t)
+}
+
+// readTaskContent returns the task from the argument, or from stdin.
+func readTaskContent(args []string) (string, error) {
+	if len(args) > 0 {
+		return strings.TrimSpace(args[0]), nil
+	}
+
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Println("Describe the task, then press Ctrl-D:")
+	}
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("failed to read task: %w", err)
+	}
+	content := strings.TrimSpace(string(data))
+	if content == "" {
+		return "", errors.New("empty task")
+	}
+	return content, nil
+}
+
+// prepareNativeTask sets up the task like handle-task, minus the tmux window:
+// worktree, .claude assets, symlinks, env and prompts.
+func prepareNativeTask(ctx context.Context, app *app.App, mgr *task.Manager, t *task.Task) error {
+	// Tasks sharing the working tree run one at a time
+	if busy, err := mgr.SharedTreeBusy(ctx, t); err != nil {
+		return err
+	} else if busy != "" {
+		return errors.New(busy)
+	}
+
+	if mgr.UsesWorktree(t) {
+		if t.IsHibernated() {
+			fmt.Println("Recreating worktree...")
+			if err := mgr.WakeTask(ctx, t); err != nil {
+				return fmt.Errorf("failed to wake task: %w", err)
+			}
+		}
+		if _, err := os.Stat(t.GetWorktreeDir()); os.IsNotExist(err) {
+			fmt.Println("Creating worktree...")
+			if err := mgr.SetupWorktree(ctx, t); err != nil {
+				return fmt.Errorf("failed to setup worktree: %w", err)
+			}
+		}
+	}
+	if !app.IsGitRepo {
+		if err := mgr.SetupNoGit(t); err != nil {
+			return fmt.Errorf("failed to setup task: %w", err)
+		}
+	}
+
+	// Sync slash commands into the worktree's .claude (error is non-fatal)
+	if err := mgr.SyncClaudeAssets(ctx, t); err != nil {
+		logging.Warn("Failed to sync claude assets: %v", err)
+	}
+
+	// Setup symlinks (error is non-fatal)
+	if err := t.SetupSymlinks(app.TawHome, app.ProjectDir); err != nil {
+		logging.Warn("Failed to setup symlinks: %v", err)
+	}
+
+	// Write the env script the agent sources, with the task context and the
+	// agent's variables (error is non-fatal)
+	taskContext := mgr.TaskContext(ctx, t, "")
+	agentEnv, err := app.Config.AgentEnv(app.TawDir)
+	if err != nil {
+		logging.Warn("Failed to load env: %v", err)
+		if errors.Is(err, config.ErrEnvFileExposed) {
+			fmt.Printf("%s .taw/env is readable by others and was skipped (chmod 600 .taw/env)\n", icon.Warning)
+		}
+	}
+	if err := t.SaveEnv(append(taskContext.EnvVars(), agentEnv...)); err != nil {
+		logging.Warn("Failed to save env: %v", err)
+	}
+
+	// Check remote access up front so auth problems don't surface when finishing
+	if err := mgr.Preflight(ctx); err != nil {
+		logging.Warn("%v", err)
+		var preflightErr *task.PreflightError
+		if errors.As(err, &preflightErr) {
+			fmt.Printf("%s %s\n", icon.Warning, preflightErr.Hint)
+		}
+	}
+
+	nativePrompt, _ := embed.GetNativePrompt()
+	saveTaskPrompts(app, mgr, t, mgr.GetWorkingDirectory(t), taskContext,
+		claude.PromptLayer{Name: "terminal mode", Source: "taw run-one", Content: nativePrompt})
+
+	if err := t.SaveStatus(task.StatusWorking); err != nil {
+		logging.Debug("Failed to save status: %v", err)
+	}
+	return nil
+}
+
+// runNativeAgent runs the agent in the foreground on the current terminal
+// and waits for it to exit. The agent's output goes straight to the terminal,
+// not through TAW, so nothing watches it for rate limits or a stuck agent.
+func runNativeAgent(ctx context.Context, app *app.App, mgr *task.Manager, t *task.Task) error {
+	// A shell env may put claude on the PATH, which the agent script checks
+	if !mgr.Sandboxed() && app.Config.Agent.ShellEnv == "" {
+		if err := claude.CheckVersion(app.Config.ClaudeMin); err != nil {
+			return fmt.Errorf("%w: %s", err, claude.ErrorHint(err))
+		}
+	}
+
+	workDir := mgr.GetWorkingDirectory(t)
+
+	// The task context, without the tmux variables
+	env := mgr.TaskContext(ctx, t, "").Vars()
+
+	instruction := fmt.Sprintf("ultrathink Read and execute the task from '%s'", t.GetUserPromptPath())
+	if err := t.RecordInstruction(task.InstructionTask, instruction); err != nil {
+		logging.Debug("Failed to record instruction: %v", err)
+	}
+	agentCmd := fmt.Sprintf("claude --dangerously-skip-permissions --system-prompt \"$(cat '%s')\" \"%s\"", t.GetSystemPromptPath(), instruction)
+	if mgr.Sandboxed() {
+		envNames := make([]string, 0, len(env))
+		for name := range env {
+			envNames = append(envNames, name)
+		}
+		sandboxCmd, err := mgr.SandboxCommand(t, agentCmd, envNames)
+		if err != nil {
+			return err
+		}
+		agentCmd = sandboxCmd
+	} else {
+		// Limits that can't be applied here are skipped (error is non-fatal)
+		limitedCmd, err := mgr.LimitCommand(agentCmd)
+		if err != nil {
+			logging.Warn("Resource limits partially applied: %v", err)
+		}
+		agentCmd = limitedCmd
+	}
+
+	shell := exec.Command("sh", "-c", mgr.AgentScript(t, agentCmd))
+	shell.Dir = workDir
+	shell.Env = os.Environ()
+	for name, value := range env {
+		shell.Env = append(shell.Env, name+"="+value)
+	}
+	shell.Stdin = os.Stdin
+	shell.Stdout = os.Stdout
+	shell.Stderr = os.Stderr
+
+	// The agent owns the terminal: Ctrl-C is for it, not for TAW
+	signal.Ignore(os.Interrupt)
+	defer signal.Reset(os.Interrupt)
+
+	logging.Log("Task started")
+	return shell.Run()
+}
+
+// finishNativeTask ends the task like end-task: verify, commit, push, merge
+// per on_complete, record and clean up. Anything that needs the user keeps
+// the task for a later run.
+func finishNativeTask(ctx context.Context, app *app.App, mgr *task.Manager, t *task.Task) error {
+	logging.Log("=== End task ===")
+	countUsage(telemetry.EventComplete + string(app.Config.OnComplete))
+	steps := mgr.EndSteps(t, false)
+	logging.Log("STEPS=%v", steps)
+
+	keep := func(reason string) error {
+		emitEvent(ctx, app, mgr, "", config.EventTaskFailed, t, reason)
+		if err := t.SaveStatus(task.StatusWaiting); err != nil {
+			logging.Debug("Failed to save status: %v", err)
+		}
+		t.RemoveTabLock()
+		fmt.Printf("%s %s\nTask kept. Resume it with: taw run-one --task %s\n", icon.Warning, reason, t.Name)
+		return nil
+	}
+
+	if pipeline := mgr.VerifyPipeline(ctx, t); len(pipeline) > 0 && config.HasAction(steps, config.ActionVerify) {
+		fmt.Printf("Verifying (%d steps)...\n", len(pipeline))
+		result, err := mgr.Verify(ctx, t)
+		if ctx.Err() != nil {
+			return keep("Verification cancelled")
+		}
+		if err != nil {
+			logging.Warn("Failed to record verification result: %v", err)
+		}
+		if result != nil && !result.Passed() {
+			logging.Warn("Verification %s - keeping task open", result.Summary())
+			return keep(fmt.Sprintf("Verification %s", result.Summary()))
+		}
+		logging.Log("Verification %s", result.Summary())
+	}
+
+	outcome := task.OutcomeCompleted
+
+	if app.IsGitRepo {
+		if config.HasAction(steps, config.ActionCommit) {
+			fmt.Println("Committing changes...")
+			if _, err := mgr.CommitChanges(ctx, t); err != nil {
+				logging.Warn("%v", err)
+			}
+
+			issues, err := mgr.CheckCommits(ctx, t)
+			if err != nil {
+				logging.Warn("Failed to check commits: %v", err)
+			}
+			if len(issues) > 0 {
+				for _, issue := range issues {
+					logging.Warn("Commit %s", issue)
+				}
+				// Without a running agent, rewording is left to the user
+				if mgr.CommitPolicy() != config.CommitPolicyWarn {
+					return keep(fmt.Sprintf("%d commit messages don't follow the convention", len(issues)))
+				}
+			}
+			if err := mgr.UpdateChangelog(ctx, t); err != nil {
+				logging.Warn("Failed to update changelog: %v", err)
+			}
+		}
+
+		var pushErr error
+		if config.HasAction(steps, config.ActionPush) {
+			fmt.Println("Pushing changes...")
+			pushErr = mgr.Preflight(ctx)
+			if pushErr == nil {
+				pushErr = mgr.PushTask(ctx, t)
+			}
+		}
+		if pushErr != nil {
+			logging.Warn("Failed to push: %v", pushErr)
+			var preflightErr *task.PreflightError
+			var taskPushErr *task.PushError
+			switch {
+			case errors.As(pushErr, &preflightErr):
+				t.SavePushFailure(preflightErr.Hint)
+				return keep(preflightErr.Hint)
+			case errors.As(pushErr, &taskPushErr):
+				return keep(taskPushErr.Hint())
+			}
+			return keep(fmt.Sprintf("Failed to push: %v", pushErr))
+		}
+
+		if config.HasAction(steps, config.ActionPR) {
+			fmt.Println("Opening pull request...")
+			prNumber, err := mgr.OpenPR(ctx, t)
+			if err != nil {
+				logging.Warn("Failed to open pull request: %v", err)
+				return keep(fmt.Sprintf("Failed to open pull request: %v", err))
+			}
+			fmt.Printf("Pull request #%d\n", prNumber)
+		}
+
+		// Archive the diff while the branch still differs from main
+		mgr.RecordDiff(ctx, t)
+
+		if config.HasAction(steps, config.ActionMerge) {
+			fmt.Printf("Merging (strategy: %s)...\n", mgr.MergeStrategy())
+			if err := mgr.MergeToMain(ctx, t); err != nil {
+				logging.Warn("Merge failed: %v", err)
+				if hint := task.Hint(err); hint != "" {
+					return keep(hint)
+				}
+				return keep(fmt.Sprintf("Merge failed: %v", err))
+			}
+			outcome = task.OutcomeMerged
+		}
+	} else if changes, err := mgr.NoGitChanges(t); err != nil {
+		logging.Warn("Failed to summarize changes: %v", err)
+	} else {
+		fmt.Printf("Changed files: %s\n", changes.Summary())
+		for _, line := range changes.Lines() {
+			fmt.Printf("  %s\n", line)
+		}
+		if err := mgr.ApplySnapshot(t, changes); err != nil {
+			return keep(fmt.Sprintf("%v - merge them into %s", err, t.GetSnapshotDir()))
+		}
+		mgr.RecordChanges(t, changes)
+	}
+
+	mgr.RecordCompletion(t, outcome)
+	if outcome == task.OutcomeMerged {
+		emitEvent(ctx, app, mgr, "", config.EventTaskMerged, t, "")
+	}
+	emitEvent(ctx, app, mgr, "", config.EventTaskCompleted, t, "")
+
+	// Keep the answer of an ask task before its agent directory goes
+	if t.IsAsk() {
+		if _, err := mgr.SaveAnswer(t, ""); err != nil {
+			logging.Warn("Failed to keep answer: %v", err)
+		} else {
+			fmt.Printf("\n%s\n\n", mgr.LoadAnswer(t.Name))
+		}
+	}
+
+	fmt.Println("Cleaning up...")
+	if err := mgr.CleanupTask(ctx, t); err != nil {
+		logging.Warn("Cleanup failed: %v", err)
+		return fmt.Errorf("cleanup failed: %w", err)
+	}
+
+	if config.HasAction(steps, config.ActionNotify) {
+		message := mgr.PipelineOption(t, config.ActionNotify, "message")
+		if message == "" {
+			message = fmt.Sprintf("%s ended", t.Name)
+		}
+		fmt.Printf("%s %s\n", icon.Done, message)
+		if app.Config.Notifications == config.NotificationsDesktop {
+			if err := notify.Send("taw: "+filepath.Base(app.ProjectDir), message); err != nil {
+				logging.Debug("Failed to send desktop notification: %v", err)
+			}
+		}
+	}
+
+	fmt.Printf("%s %s finished\n", icon.Success, t.Name)
+	return nil
+}
+
+// confirm asks a yes/no question on the terminal, defaulting to no.
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/donghojung/taw/internal/task"
+)
+
+// taskSelector picks the tasks of a bulk command: by name, and by status,
+// #tag or state. A task must match every selector given.
+type taskSelector struct {
+	statuses []string
+	tags     []string
+	allDone  bool
+	merged   bool
+	failed   bool
+}
+
+// addFlags registers the selector flags on cmd.
+func (s *taskSelector) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&s.statuses, "status", nil, "Select tasks with these statuses (e.g. done,waiting)")
+	cmd.Flags().StringSliceVar(&s.tags, "tag", nil, "Select tasks with these #tags in their content (without #)")
+	cmd.Flags().BoolVar(&s.allDone, "all-done", false, "Select tasks whose agent reported done")
+	cmd.Flags().BoolVar(&s.merged, "merged", false, "Select tasks whose branch is merged")
+	cmd.Flags().BoolVar(&s.failed, "failed", false, "Select tasks whose push or verification failed")
+}
+
+// empty returns true if no selector flag is set.
+func (s *taskSelector) empty() bool {
+	return len(s.statuses) == 0 && len(s.tags) == 0 && !s.allDone && !s.merged && !s.failed
+}
+
+// selectTasks returns the named tasks, or all tasks if names is empty,
+// filtered by the selectors. Without names or selectors nothing is selected,
+// so a bare bulk command never acts on every task.
+func (s *taskSelector) selectTasks(ctx context.Context, mgr *task.Manager, names []string) ([]*task.Task, error) {
+	if len(names) == 0 && s.empty() {
+		return nil, fmt.Errorf("name tasks or select them with --all-done, --merged, --failed, --status or --tag")
+	}
+
+	var tasks []*task.Task
+	if len(names) > 0 {
+		for _, name := range names {
+			t, err := mgr.GetTask(name)
+			if err != nil {
+				return nil, err
+			}
+			tasks = append(tasks, t)
+		}
+	} else {
+		all, err := mgr.ListTasks()
+		if err != nil {
+			return nil, err
+		}
+		tasks = all
+	}
+
+	var merged map[string]bool
+	if s.merged {
+		mergedTasks, err := mgr.FindMergedTasks(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find merged tasks: %w", err)
+		}
+		merged = make(map[string]bool)
+		for _, t := range mergedTasks {
+			merged[t.Name] = true
+		}
+	}
+
+	var selected []*task.Task
+	for _, t := range tasks {
+		if s.matches(t) && (!s.merged || merged[t.Name]) {
+			selected = append(selected, t)
+		}
+	}
+	return selected, nil
+}
+
+// matches checks the selectors that need only the task itself.
+func (s *taskSelector) matches(t *task.Task) bool {
+	if s.allDone && t.Status != task.StatusDone {
+		return false
+	}
+	if s.failed && !taskFailed(t) {
+		return false
+	}
+	if len(s.statuses) > 0 && !containsFold(s.statuses, string(t.Status)) {
+		return false
+	}
+	for _, tag := range s.tags {
+		if !containsFold(t.Tags(), strings.TrimPrefix(tag, "#")) {
+			return false
+		}
+	}
+	return true
+}
+
+// taskFailed returns true if ending the task failed: its push was rejected
+// or its last verification didn't pass.
+func taskFailed(t *task.Task) bool {
+	if t.Status == task.StatusPushFailed || t.LoadPushFailure() != "" {
+		return true
+	}
+	result := t.LoadVerifyResult()
+	return result != nil && !result.Passed()
+}
+
+// containsFold returns true if list contains s, ignoring case.
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
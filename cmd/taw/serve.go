@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/donghojung/taw/internal/app"
+	"github.com/donghojung/taw/internal/constants"
+	"github.com/donghojung/taw/internal/icon"
+	"github.com/donghojung/taw/internal/logging"
+	"github.com/donghojung/taw/internal/slack"
+	"github.com/donghojung/taw/internal/task"
+)
+
+// serveAddr is the listen address of taw serve.
+var serveAddr string
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the Slack slash command of this project",
+	Long: `Run an HTTP server for the project's integrations until interrupted.
+
+POST /slack/command is the request URL of a Slack slash command (e.g. /taw):
+  /taw add <task>   Queue a task; the channel is told when it finishes (with
+                    its PR) or needs attention
+  /taw help         Show the commands
+Requests must be signed with the Slack app's signing secret, read from
+.taw/env (SLACK_SIGNING_SECRET, or slack.signing_secret_env). Only the users
+in slack.allowed_users may queue tasks, from the channels in
+slack.allowed_channels if it is set. Results are posted with the bot token
+(SLACK_BOT_TOKEN, or slack.bot_token_env).
+
+Slack needs to reach the server: put it behind a reverse proxy or a tunnel.`,
+	Example: `  taw serve
+  taw serve --listen :8742`,
+	Args: cobra.NoArgs,
+	RunE: runServe,
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveAddr, "listen", constants.DefaultServeAddr, "Address to listen on")
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	application, mgr, err := loadProject(ctx)
+	if err != nil {
+		return err
+	}
+	logger, _ := logging.New(application.GetLogPath(), application.Debug)
+	if logger != nil {
+		defer logger.Close()
+		logger.SetScript("serve")
+		logging.SetGlobal(logger)
+	}
+
+	if name := application.Config.Slack.SigningSecretEnvName(); mgr.Secret(name) == "" {
+		fmt.Printf("%s %s is not set in .taw/env; Slack requests are refused\n", icon.Warning, name)
+	}
+	if len(application.Config.Slack.AllowedUsers) == 0 {
+		fmt.Printf("%s slack.allowed_users is empty; Slack tasks are refused\n", icon.Warning)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /slack/command", &slackHandler{app: application, mgr: mgr})
+	server := &http.Server{
+		Addr:              serveAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: constants.ServeReadTimeout,
+		ReadTimeout:       constants.ServeReadTimeout,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.ListenAndServe()
+	}()
+	logging.Log("Serving on %s", serveAddr)
+	fmt.Printf("Serving %s on http://%s (Ctrl-C to stop)\n", application.SessionName, serveAddr)
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), constants.ServeReadTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// slackHandler handles Slack slash commands.
+type slackHandler struct {
+	app *app.App
+	mgr *task.Manager
+}
+
+func (h *slackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, constants.ServeMaxBodyBytes))
+	if err != nil {
+		http.Error(w, "failed to read request", http.StatusBadRequest)
+		return
+	}
+
+	// The secret is read on every request, so it can be rotated in .taw/env
+	secret := h.mgr.Secret(h.app.Config.Slack.SigningSecretEnvName())
+	if secret == "" {
+		http.Error(w, "Slack is not configured", http.StatusServiceUnavailable)
+		return
+	}
+	if err := slack.Verify(secret, r.Header, body, time.Now()); err != nil {
+		logging.Warn("Refused Slack request: %v", err)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.command(form)); err != nil {
+		logging.Debug("Failed to write Slack response: %v", err)
+	}
+}
+
+// command runs a slash command and returns its reply.
+func (h *slackHandler) command(form url.Values) slack.Response {
+	command := form.Get("command")
+	sub, rest, _ := strings.Cut(strings.TrimSpace(form.Get("text")), " ")
+	switch sub {
+	case "add":
+		content := strings.TrimSpace(rest)
+		if content == "" {
+			return slack.Response{ResponseType: "ephemeral", Text: fmt.Sprintf("Usage: %s add <task>", command)}
+		}
+
+		channel, user := form.Get("channel_id"), form.Get("user_id")
+		if !h.app.Config.Slack.Allows(user, form.Get("user_name"), channel, form.Get("channel_name")) {
+			logging.Warn("Refused Slack task from %s (%s) in %s", form.Get("user_name"), user, channel)
+			return slack.Response{ResponseType: "ephemeral", Text: fmt.Sprintf(
+				"You can't queue tasks in %s from here; ask to be added to slack.allowed_users (and the channel to slack.allowed_channels)",
+				h.app.SessionName)}
+		}
+
+		running, err := queueTasksFrom(h.app, &slack.Origin{Channel: channel, User: user}, content)
+		if err != nil && !running {
+			logging.Warn("Failed to queue Slack task: %v", err)
+			return slack.Response{ResponseType: "ephemeral", Text: fmt.Sprintf("Failed to queue the task: %v", err)}
+		}
+		if err != nil {
+			logging.Debug("Failed to start process-queue: %v", err)
+		}
+		logging.Log("Task queued from Slack by %s in %s", form.Get("user_name"), channel)
+
+		text := fmt.Sprintf("<@%s> queued a task: %s", user, firstLine(rest))
+		if !running {
+			text += "\nIt starts once the taw session of " + h.app.SessionName + " runs."
+		}
+		return slack.Response{ResponseType: "in_channel", Text: text}
+
+	default:
+		return slack.Response{ResponseType: "ephemeral", Text: fmt.Sprintf(
+			"%[1]s add <task>: Queue a task in %[2]s; this channel is told when it finishes\n%[1]s help: Show this help",
+			command, h.app.SessionName)}
+	}
+}
+
+// firstLine returns the first line of text.
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	return line
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/donghojung/taw/internal/icon"
+	"github.com/donghojung/taw/internal/task"
+	"github.com/donghojung/taw/internal/tmux"
+)
+
+// showLogTail is how much of the end of the log is searched for a task's lines.
+const showLogTail = 1 << 20
+
+var (
+	showJSON  bool
+	showLines int
+)
+
+var showCmd = &cobra.Command{
+	Use:   "show <task>",
+	Short: "Show everything about a task",
+	Long: `Show a task's content and prompts, status, branch and changes, pull request,
+timestamps and estimated cost, with its recent log lines and the end of its
+agent's output while its window is open.
+
+The answer of an ask task ('taw ask') is shown too, and still after the task
+is cleaned up.`,
+	Example: `  taw show fix-login
+  taw show fix-login --lines 50
+  taw show fix-login --json`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTaskName(0),
+	RunE:              runShow,
+}
+
+func init() {
+	showCmd.Flags().BoolVar(&showJSON, "json", false, "Print the details as JSON")
+	showCmd.Flags().IntVar(&showLines, "lines", 20, "Log lines and agent output lines to show")
+}
+
+func runShow(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	application, mgr, err := loadProject(ctx)
+	if err != nil {
+		return err
+	}
+	t, err := mgr.GetTask(args[0])
+	if err != nil {
+		// Ask tasks are cleaned up once answered
+		if answer := mgr.LoadAnswer(args[0]); answer != "" {
+			return printAnswer(args[0], answer)
+		}
+		return err
+	}
+
+	d := mgr.Details(ctx, t)
+	d.Log = taskLogLines(application.GetLogPath(), t.Name, showLines)
+	if tm := tmux.New(application.SessionName); tm.HasSession(application.SessionName) {
+		if windowID := taskWindow(tm, t); windowID != "" {
+			if out, err := tm.CapturePane(windowID+".0", showLines); err == nil {
+				d.Transcript = strings.TrimRight(out, "\n ")
+			}
+		}
+	}
+
+	if showJSON {
+		data, err := json.MarshalIndent(d, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	printDetails(d)
+	return nil
+}
+
+// printDetails prints the details of a task for people.
+func printDetails(d *task.Details) {
+	status := string(d.Status)
+	if d.Stuck {
+		status += ", " + icon.Stuck.String() + " may be stuck"
+	}
+	if d.Hibernated {
+		status += ", hibernated"
+	}
+	if d.Stale {
+		status += ", stale"
+	}
+	if d.ReadOnly {
+		status += ", read-only"
+	}
+	fmt.Printf("%s (%s)\n", d.Name, status)
+
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Printf("  %-10s %s\n", name+":", value)
+		}
+	}
+	field("ID", d.ID)
+	field("Owner", d.Owner)
+	field("Branch", d.Branch)
+	field("Work dir", d.WorkDir)
+	field("Tags", strings.Join(d.Tags, " "))
+	field("Ticket", d.Ticket)
+	field("Batch", d.Batch)
+	field("Drafts", d.DraftGroup)
+
+	changes := fmt.Sprintf("%d file(s)", d.Files)
+	if d.Insertions > 0 || d.Deletions > 0 {
+		changes += fmt.Sprintf(", +%d -%d", d.Insertions, d.Deletions)
+	}
+	field("Changes", changes)
+	if d.PRNumber > 0 {
+		field("PR", strings.TrimSpace(fmt.Sprintf("#%d %s %s", d.PRNumber, strings.ToLower(d.PRState), d.PRURL)))
+	}
+	if d.CostShared {
+		field("Cost", "shared with the project directory")
+	} else {
+		field("Cost", fmt.Sprintf("%s ($%.2f today)", formatBudget(d.CostUSD, d.BudgetUSD), d.CostTodayUSD))
+	}
+
+	tl := d.Timeline
+	field("Created", formatTime(tl.CreatedAt))
+	field("Started", formatTime(tl.StartedAt))
+	field("Completed", formatTime(tl.CompletedAt))
+	if waiting := tl.TimeWaiting(time.Now()); waiting >= time.Minute {
+		field("Waited", task.FormatDuration(waiting))
+	}
+	if d.Restarts > 0 {
+		field("Restarts", fmt.Sprintf("%d", d.Restarts))
+	}
+	if d.Verify != nil {
+		field("Verify", d.Verify.Summary())
+	}
+	field("Push", d.PushFailure)
+
+	section := func(title, text string) {
+		if text == "" {
+			return
+		}
+		fmt.Printf("\n%s:\n", title)
+		for _, line := range strings.Split(text, "\n") {
+			fmt.Println(strings.TrimRight("  "+line, " "))
+		}
+	}
+	section("Content", strings.TrimSpace(d.Content))
+	section("Prompt", d.Prompt)
+	section("Answer", d.Answer)
+	section("Recent log", strings.Join(d.Log, "\n"))
+	section("Agent output", d.Transcript)
+}
+
+// printAnswer prints the kept answer of a cleaned-up ask task.
+func printAnswer(name, answer string) error {
+	if showJSON {
+		return printJSON(struct {
+			Name   string `json:"name"`
+			Answer string `json:"answer"`
+		}{name, answer})
+	}
+	fmt.Println(answer)
+	return nil
+}
+
+// formatTime returns a time with how long ago it was, or "" if it's zero.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%s (%s ago)", t.Format("2006-01-02 15:04"), task.FormatDuration(time.Since(t)))
+}
+
+// taskLogLines returns the last n lines of the log that name a task.
+func taskLogLines(logPath, taskName string, n int) []string {
+	f, err := os.Open(logPath)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	if info, err := f.Stat(); err == nil && info.Size() > showLogTail {
+		if _, err := f.Seek(-showLogTail, io.SeekEnd); err != nil {
+			return nil
+		}
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.Contains(line, taskName) {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/donghojung/taw/internal/constants"
+)
+
+// BenchmarkStartup measures a whole run of the binary, from exec to exit, for
+// commands that must stay fast: version, and the internal commands tmux key
+// bindings run on every press.
+func BenchmarkStartup(b *testing.B) {
+	bin := filepath.Join(b.TempDir(), "taw")
+	if out, err := exec.Command("go", "build", "-o", bin, ".").CombinedOutput(); err != nil {
+		b.Fatalf("go build: %v\n%s", err, out)
+	}
+
+	// A project as a session leaves it: .taw set up and marked as a git repo
+	projectDir := b.TempDir()
+	tawDir := filepath.Join(projectDir, constants.TawDirName)
+	if err := os.MkdirAll(tawDir, 0755); err != nil {
+		b.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tawDir, constants.GitRepoMarker), nil, 0644); err != nil {
+		b.Fatal(err)
+	}
+	env := append(os.Environ(), "TAW_DIR="+tawDir, "TAW_HOME="+b.TempDir())
+
+	for _, bm := range []struct {
+		name string
+		args []string
+	}{
+		{"version", []string{"version"}},
+		{"internal", []string{"internal", "queue-status", "bench"}},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				cmd := exec.Command(bin, bm.args...)
+				cmd.Dir = projectDir
+				cmd.Env = env
+				if out, err := cmd.CombinedOutput(); err != nil {
+					b.Fatalf("taw %v: %v\n%s", bm.args, err, out)
+				}
+			}
+		})
+	}
+}
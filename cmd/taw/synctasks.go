@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/donghojung/taw/internal/icon"
+	"github.com/donghojung/taw/internal/task"
+	"github.com/donghojung/taw/internal/tmux"
+)
+
+// syncTasksDryRun shows what sync-tasks would do without doing it.
+var syncTasksDryRun bool
+
+var syncTasksCmd = &cobra.Command{
+	Use:   "sync-tasks",
+	Short: "Sync tasks with the checklist in TASKS.md",
+	Long: `Keep the backlog in a tracked markdown file (tasks_file, TASKS.md by
+default), so that it lives in the repository and follows it across machines.
+
+Each unchecked "- [ ] ..." item is queued as a task, with the lines indented
+under it as the rest of the task. Items whose task was merged are checked off
+with a link to the pull request; commit the file to share them. Items whose
+task is queued or running are left as they are, and so are items whose task
+ended without merging: edit or check them off yourself.`,
+	Example: `  taw sync-tasks
+  taw sync-tasks --dry-run`,
+	Args: cobra.NoArgs,
+	RunE: runSyncTasks,
+}
+
+func init() {
+	syncTasksCmd.Flags().BoolVarP(&syncTasksDryRun, "dry-run", "n", false, "Show what would be queued and checked off")
+}
+
+func runSyncTasks(cmd *cobra.Command, args []string) error {
+	application, mgr, err := loadProject(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	sync, err := mgr.SyncTasksFile(cmd.Context(), task.NewQueueManager(application.QueueDir), syncTasksDryRun)
+	if err != nil {
+		return err
+	}
+
+	queued, checked := "Queued", "Checked off"
+	if syncTasksDryRun {
+		queued, checked = "Would queue", "Would check off"
+	}
+	for _, title := range sync.Checked {
+		fmt.Printf("%s %s: %s\n", icon.Success, checked, title)
+	}
+	for _, title := range sync.Queued {
+		fmt.Printf("%s %s: %s\n", icon.Queue, queued, title)
+	}
+	for _, title := range sync.Running {
+		fmt.Printf("%s In progress: %s\n", icon.Running, title)
+	}
+	for _, title := range sync.Ended {
+		fmt.Printf("%s Ended without merging: %s\n", icon.Warning, title)
+	}
+	if !sync.Changed() {
+		fmt.Printf("%s is up to date\n", filepath.Base(mgr.TasksFilePath()))
+	}
+	if syncTasksDryRun || len(sync.Queued) == 0 {
+		return nil
+	}
+
+	if !tmux.New(application.SessionName).HasSession(application.SessionName) {
+		fmt.Println("Run 'taw' to start them")
+		return nil
+	}
+	return spawnInternal(application.SessionName, "process-queue")
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/donghojung/taw/internal/app"
+	"github.com/donghojung/taw/internal/icon"
+	"github.com/donghojung/taw/internal/logging"
+	"github.com/donghojung/taw/internal/telemetry"
+	"github.com/donghojung/taw/internal/tmux"
+)
+
+var telemetryCmd = &cobra.Command{
+	Use:   "telemetry",
+	Short: "Show or change anonymous usage reporting (off by default)",
+	Long: `TAW can report anonymous usage counts to help decide what to work on next:
+how many tasks are created, which completion modes (on_complete) end them,
+and the TAW version, OS and tmux version. Task names, content, paths and
+project names are never included.
+
+Nothing is recorded or sent until you run 'taw telemetry on'. Counts are kept
+in ~/.config/taw/telemetry.json and sent at most once a day by the session
+daemon. DO_NOT_TRACK=1 turns it off whatever you chose.`,
+	Args: cobra.NoArgs,
+	RunE: runTelemetryStatus,
+}
+
+var telemetryOnCmd = &cobra.Command{
+	Use:   "on",
+	Short: "Opt in to anonymous usage reporting",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		state, err := telemetry.Enable(app.GetUserConfigDir())
+		if err != nil {
+			return fmt.Errorf("failed to enable telemetry: %w", err)
+		}
+		fmt.Printf("%s Telemetry on, thank you. 'taw telemetry status' shows what is sent.\n", icon.Success)
+		if telemetryURL() == "" {
+			fmt.Println("This build has no telemetry endpoint; counts stay on this machine.")
+		}
+		if telemetry.Disabled() {
+			fmt.Println("DO_NOT_TRACK=1 is set, so nothing is recorded while it is.")
+		}
+		logging.Debug("Telemetry enabled with ID %s", state.ID)
+		return nil
+	},
+}
+
+var telemetryOffCmd = &cobra.Command{
+	Use:   "off",
+	Short: "Opt out and forget the counts not sent yet",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := telemetry.Disable(app.GetUserConfigDir()); err != nil {
+			return fmt.Errorf("failed to disable telemetry: %w", err)
+		}
+		fmt.Printf("%s Telemetry off\n", icon.Success)
+		return nil
+	},
+}
+
+var telemetryStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether usage is reported, and the next report",
+	Args:  cobra.NoArgs,
+	RunE:  runTelemetryStatus,
+}
+
+func init() {
+	telemetryCmd.AddCommand(telemetryOnCmd)
+	telemetryCmd.AddCommand(telemetryOffCmd)
+	telemetryCmd.AddCommand(telemetryStatusCmd)
+}
+
+func runTelemetryStatus(cmd *cobra.Command, args []string) error {
+	state, err := telemetry.Load(app.GetUserConfigDir())
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case !state.Enabled:
+		fmt.Println("Telemetry: off (nothing is recorded or sent; 'taw telemetry on' to opt in)")
+		return nil
+	case telemetry.Disabled():
+		fmt.Println("Telemetry: on, but paused by DO_NOT_TRACK=1")
+	default:
+		fmt.Println("Telemetry: on ('taw telemetry off' to opt out)")
+	}
+
+	url := telemetryURL()
+	if url == "" {
+		url = "none in this build (counts stay on this machine)"
+	}
+	fmt.Printf("Endpoint: %s\n", url)
+	if !state.LastSent.IsZero() {
+		fmt.Printf("Last sent: %s\n", state.LastSent.Format("2006-01-02 15:04"))
+	}
+
+	data, err := json.MarshalIndent(state.Report(Version, tmux.Version()), "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Next report:\n%s\n", data)
+	return nil
+}
+
+// telemetryURL returns where usage counts are sent, or "" if nowhere.
+func telemetryURL() string {
+	if url := os.Getenv("TAW_TELEMETRY_URL"); url != "" {
+		return url
+	}
+	return TelemetryURL
+}
+
+// countUsage counts an event if the user opted in to telemetry (error is
+// non-fatal).
+func countUsage(event string) {
+	if err := telemetry.Count(app.GetUserConfigDir(), event); err != nil {
+		logging.Debug("Failed to count %s: %v", event, err)
+	}
+}
+
+// sendTelemetry sends the usage counts if the user opted in and they're due
+// (error is non-fatal).
+func sendTelemetry(ctx context.Context) {
+	url := telemetryURL()
+	if url == "" {
+		return
+	}
+	dir := app.GetUserConfigDir()
+	state, err := telemetry.Load(dir)
+	if err != nil || !state.Due() {
+		return
+	}
+	if err := state.Send(ctx, dir, url, Version, tmux.Version()); err != nil {
+		logging.Debug("%v", err)
+	}
+}
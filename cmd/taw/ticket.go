@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/donghojung/taw/internal/icon"
+	"github.com/donghojung/taw/internal/ticket"
+)
+
+// ticketPrint prints the task of a ticket instead of queueing it.
+var ticketPrint bool
+
+var ticketCmd = &cobra.Command{
+	Use:   "ticket <key>",
+	Short: "Create a task from a Jira or Linear ticket",
+	Long: `Read a ticket from the issue tracker set with ticket.provider and queue a task
+with its title and description. The task starts in the project's taw session,
+or when the session starts.
+
+The ticket then follows the task: it moves to ticket.in_progress when the
+agent starts, to ticket.in_review once the task has a pull request (linked
+from the ticket), and to ticket.done when the task ends. Any task whose
+content has a "Ticket: <key>" line is linked the same way.
+
+The API token is read from .taw/env, named by ticket.token_env
+(JIRA_API_TOKEN or LINEAR_API_KEY by default).`,
+	Example: `  taw ticket PROJ-123
+  taw ticket ENG-42 --print`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTicket,
+}
+
+func init() {
+	ticketCmd.Flags().BoolVar(&ticketPrint, "print", false, "Print the task content instead of queueing it")
+}
+
+func runTicket(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	key := strings.ToUpper(args[0])
+	if !ticket.IsKey(key) {
+		return fmt.Errorf("invalid ticket key %q (e.g. PROJ-123)", args[0])
+	}
+
+	application, mgr, err := loadProject(ctx)
+	if err != nil {
+		return err
+	}
+	provider, err := mgr.TicketProvider()
+	if err != nil {
+		if hint := ticket.ErrorHint(err); hint != "" {
+			return fmt.Errorf("%w\n%s", err, hint)
+		}
+		return err
+	}
+
+	t, err := provider.Get(ctx, key)
+	if err != nil {
+		if hint := ticket.ErrorHint(err); hint != "" {
+			return fmt.Errorf("%w\n%s", err, hint)
+		}
+		return err
+	}
+	content := t.Content()
+	if ticketPrint {
+		fmt.Print(content)
+		return nil
+	}
+
+	running, err := queueTasks(application, content)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s %s queued: %s\n", icon.Success, t.Key, t.Title)
+	if !running {
+		fmt.Println("Run 'taw' to start it")
+	}
+	return nil
+}
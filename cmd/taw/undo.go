@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/donghojung/taw/internal/app"
+	"github.com/donghojung/taw/internal/icon"
+	"github.com/donghojung/taw/internal/task"
+	"github.com/donghojung/taw/internal/tmux"
+)
+
+var undoCmd = &cobra.Command{
+	Use:   "undo [task]",
+	Short: "Restore a task that was cleaned up or merged",
+	Long: `Restore a task removed by ending, merging or cleaning it up: its agent
+directory, its branch and its worktree come back, and its window reopens if
+the session is running. Without a task, list the tasks that can be restored.
+
+Removed tasks stay in .taw/trash for trash_days days (7 by default). Commits
+already merged into the main branch are not reverted; revert them on the main
+branch if needed. Uncommitted changes in a removed worktree can't be restored.`,
+	Example: `  taw undo                   # List restorable tasks
+  taw undo fix-login-test`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeTrashedTask,
+	RunE:              runUndo,
+}
+
+func runUndo(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	application, mgr, err := loadProject(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		entries, err := mgr.ListTrash(ctx)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			fmt.Println("Nothing to restore")
+			return nil
+		}
+		for _, e := range entries {
+			branch := ""
+			if e.HasBranch {
+				branch = ", branch kept"
+			}
+			fmt.Printf("  %-30s removed %s ago, expires in %s%s\n", e.Name,
+				formatAge(time.Since(e.TrashedAt)), formatAge(time.Until(e.ExpiresAt(mgr.TrashDays()))), branch)
+		}
+		return nil
+	}
+
+	t, err := mgr.RestoreTask(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s %s restored\n", icon.Success, t.Name)
+
+	// handle-task opens the window, reusing the restored worktree
+	tm := tmux.New(application.SessionName)
+	if !tm.HasSession(application.SessionName) {
+		fmt.Printf("No taw session is running; it works in %s\n", mgr.GetWorkingDirectory(t))
+		return nil
+	}
+	if err := spawnInternal(application.SessionName, "handle-task", t.AgentDir); err != nil {
+		return fmt.Errorf("failed to reopen window: %w", err)
+	}
+	return nil
+}
+
+// formatAge formats a duration in days, hours or minutes.
+func formatAge(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return "0m"
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+}
+
+// completeTrashedTask completes the names of the tasks in the trash.
+func completeTrashedTask(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) != 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	application, err := app.New(cwd)
+	if err != nil || !application.IsInitialized() {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	// Listing the trash only reads .taw/trash, so no config or git is needed
+	mgr := task.NewManager(application.AgentsDir, application.ProjectDir, application.TawDir, false, nil)
+	entries, err := mgr.ListTrash(cmd.Context())
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var names []string
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name, toComplete) {
+			names = append(names, e.Name)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
@@ -6,18 +6,25 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/donghojung/taw/internal/claude"
 	"github.com/donghojung/taw/internal/config"
 	"github.com/donghojung/taw/internal/constants"
+	"github.com/donghojung/taw/internal/embed"
+	"github.com/donghojung/taw/internal/icon"
+	"github.com/donghojung/taw/internal/logging"
 )
 
 // App represents the main application context with all dependencies.
 type App struct {
 	// Paths
-	ProjectDir  string // Root directory of the user's project
-	TawDir      string // .taw directory path
-	AgentsDir   string // agents directory path
-	QueueDir    string // .queue directory path
-	TawHome     string // TAW installation directory
+	ProjectDir string // Root directory of the user's project
+	TawDir     string // .taw directory path
+	AgentsDir  string // agents directory path
+	QueueDir   string // .queue directory path
+	OutboxDir  string // outbox directory path (pending remote actions)
+	ArchiveDir string // archive directory path (records of finished tasks)
+	TawHome    string // TAW installation directory
+	AssetsDir  string // Installed prompts and .claude assets
 
 	// Session
 	SessionName string // tmux session name
@@ -40,6 +47,8 @@ func New(projectDir string) (*App, error) {
 	tawDir := filepath.Join(absPath, constants.TawDirName)
 	agentsDir := filepath.Join(tawDir, constants.AgentsDirName)
 	queueDir := filepath.Join(tawDir, constants.QueueDirName)
+	outboxDir := filepath.Join(tawDir, constants.OutboxDirName)
+	archiveDir := filepath.Join(tawDir, constants.ArchiveDirName)
 
 	// Determine session name from project directory name
 	sessionName := filepath.Base(absPath)
@@ -52,6 +61,8 @@ func New(projectDir string) (*App, error) {
 		TawDir:      tawDir,
 		AgentsDir:   agentsDir,
 		QueueDir:    queueDir,
+		OutboxDir:   outboxDir,
+		ArchiveDir:  archiveDir,
 		SessionName: sessionName,
 		Debug:       debug,
 	}
@@ -77,13 +88,17 @@ func (a *App) Initialize() error {
 	return nil
 }
 
-// LoadConfig loads the project configuration.
+// LoadConfig loads the project configuration and keeps its secrets out of logs.
 func (a *App) LoadConfig() error {
 	cfg, err := config.Load(a.TawDir)
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 	a.Config = cfg
+
+	logging.SetRedactions(cfg.Secrets(a.TawDir))
+	logging.SetRedactPatterns(cfg.RedactRegexps())
+	icon.SetASCII(cfg.ASCII)
 	return nil
 }
 
@@ -113,6 +128,61 @@ func (a *App) GetGlobalPromptPath() string {
 	return filepath.Join(a.TawDir, constants.GlobalPromptLink)
 }
 
+// GetUserConfigDir returns the directory of the user's settings shared by all
+// projects ($XDG_CONFIG_HOME/taw, or ~/.config/taw).
+func GetUserConfigDir() string {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		configHome = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configHome, "taw")
+}
+
+// GetUserPromptPath returns the path to the user's prompt shared by all
+// projects ($XDG_CONFIG_HOME/taw/PROMPT.md, or ~/.config/taw/PROMPT.md).
+func GetUserPromptPath() string {
+	dir := GetUserConfigDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, constants.PromptFileName)
+}
+
+// GetPromptLayers returns the system prompt hierarchy: the default prompt
+// (installed copy, or the embedded one), the user prompt, the project prompt
+// and the task's extra prompt. Missing files are empty layers.
+func (a *App) GetPromptLayers(taskPromptPath string) []claude.PromptLayer {
+	var layers []claude.PromptLayer
+
+	defaultSource := a.GetGlobalPromptPath()
+	defaultPrompt, err := os.ReadFile(defaultSource)
+	if err != nil {
+		defaultSource = "embedded"
+		prompt, _ := embed.GetPrompt(a.IsGitRepo)
+		defaultPrompt = []byte(prompt)
+	}
+	layers = append(layers, claude.PromptLayer{Name: "default", Source: defaultSource, Content: string(defaultPrompt)})
+
+	if userPath := GetUserPromptPath(); userPath != "" {
+		userPrompt, _ := os.ReadFile(userPath)
+		layers = append(layers, claude.PromptLayer{Name: "user", Source: userPath, Content: string(userPrompt)})
+	}
+
+	projectPrompt, _ := os.ReadFile(a.GetPromptPath())
+	layers = append(layers, claude.PromptLayer{Name: "project", Source: a.GetPromptPath(), Content: string(projectPrompt)})
+
+	if taskPromptPath != "" {
+		taskPrompt, _ := os.ReadFile(taskPromptPath)
+		layers = append(layers, claude.PromptLayer{Name: "task", Source: taskPromptPath, Content: string(taskPrompt)})
+	}
+
+	return layers
+}
+
 // GetAgentDir returns the path to a specific agent's directory.
 func (a *App) GetAgentDir(taskName string) string {
 	return filepath.Join(a.AgentsDir, taskName)
@@ -123,6 +193,11 @@ func (a *App) SetTawHome(path string) {
 	a.TawHome = path
 }
 
+// SetAssetsDir sets the directory holding the installed prompts and .claude assets.
+func (a *App) SetAssetsDir(path string) {
+	a.AssetsDir = path
+}
+
 // SetGitRepo sets whether the project is a git repository.
 func (a *App) SetGitRepo(isGit bool) {
 	a.IsGitRepo = isGit
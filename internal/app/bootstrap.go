@@ -0,0 +1,203 @@
+// Package app provides the main application context and dependency injection.
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/donghojung/taw/internal/constants"
+)
+
+// undoFunc reverts one bootstrap change.
+type undoFunc func() error
+
+// bootstrapStep is one change to the project made at session start. apply is
+// idempotent: it changes nothing if the project is already set up, and then
+// returns a nil undo.
+type bootstrapStep struct {
+	name  string
+	apply func() (undoFunc, error)
+}
+
+// Bootstrap prepares the project for a session: the git repo marker, the
+// global prompt and .claude symlinks, and the .taw entry in .gitignore.
+// Files are replaced atomically, so concurrent sessions never see a partial
+// file. If a step fails, the steps already applied are rolled back.
+func (a *App) Bootstrap() error {
+	markerPath := filepath.Join(a.TawDir, constants.GitRepoMarker)
+
+	promptTarget := filepath.Join(a.AssetsDir, "PROMPT-nogit.md")
+	if a.IsGitRepo {
+		promptTarget = filepath.Join(a.AssetsDir, "PROMPT.md")
+	}
+
+	steps := []bootstrapStep{
+		{"git repo marker", func() (undoFunc, error) {
+			// Read by internal commands instead of running git
+			if a.IsGitRepo {
+				return ensureFile(markerPath)
+			}
+			return removeFile(markerPath)
+		}},
+		{"prompt symlink", func() (undoFunc, error) {
+			return ensureSymlink(a.GetGlobalPromptPath(), promptTarget)
+		}},
+		{"claude symlink", func() (undoFunc, error) {
+			return ensureSymlink(filepath.Join(a.TawDir, constants.ClaudeLink), filepath.Join(a.AssetsDir, "claude"))
+		}},
+		{".gitignore", func() (undoFunc, error) {
+			if !a.IsGitRepo {
+				return nil, nil
+			}
+			return ensureIgnored(filepath.Join(a.ProjectDir, ".gitignore"), constants.TawDirName)
+		}},
+	}
+
+	var undos []undoFunc
+	for _, step := range steps {
+		undo, err := step.apply()
+		if err != nil {
+			err = fmt.Errorf("failed to set up %s: %w", step.name, err)
+			for i := len(undos) - 1; i >= 0; i-- {
+				if undoErr := undos[i](); undoErr != nil {
+					err = errors.Join(err, fmt.Errorf("rollback failed: %w", undoErr))
+				}
+			}
+			return err
+		}
+		if undo != nil {
+			undos = append(undos, undo)
+		}
+	}
+	return nil
+}
+
+// ensureFile creates an empty file at path if none exists.
+func ensureFile(path string) (undoFunc, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+	return func() error { return os.Remove(path) }, nil
+}
+
+// removeFile removes the file at path if it exists.
+func removeFile(path string) (undoFunc, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := os.Remove(path); err != nil {
+		return nil, err
+	}
+	return func() error { return writeFileAtomic(path, data) }, nil
+}
+
+// ensureSymlink points the symlink at linkPath to target, replacing an
+// existing link in one rename. Anything but a symlink at linkPath is an error.
+func ensureSymlink(linkPath, target string) (undoFunc, error) {
+	previous, err := os.Readlink(linkPath)
+	switch {
+	case err == nil && previous == target:
+		return nil, nil
+	case err != nil && !os.IsNotExist(err):
+		return nil, fmt.Errorf("%s exists and is not a symlink", linkPath)
+	}
+
+	if err := symlinkAtomic(target, linkPath); err != nil {
+		return nil, err
+	}
+
+	if previous == "" {
+		return func() error { return os.Remove(linkPath) }, nil
+	}
+	return func() error { return symlinkAtomic(previous, linkPath) }, nil
+}
+
+// ensureIgnored adds entry to the .gitignore at path unless a line already
+// ignores it (with or without leading and trailing slashes).
+func ensureIgnored(path, entry string) (undoFunc, error) {
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	existed := err == nil
+
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.Trim(line, "/") == entry {
+			return nil, nil
+		}
+	}
+
+	updated := append([]byte(nil), content...)
+	if len(updated) > 0 && updated[len(updated)-1] != '\n' {
+		updated = append(updated, '\n')
+	}
+	updated = append(updated, entry+"/\n"...)
+
+	if err := writeFileAtomic(path, updated); err != nil {
+		return nil, err
+	}
+
+	if !existed {
+		return func() error { return os.Remove(path) }, nil
+	}
+	return func() error { return writeFileAtomic(path, content) }, nil
+}
+
+// writeFileAtomic replaces the file at path with data through a temporary
+// file, keeping the mode of an existing file.
+func writeFileAtomic(path string, data []byte) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// symlinkAtomic creates or replaces the symlink at linkPath.
+func symlinkAtomic(target, linkPath string) error {
+	tmp := fmt.Sprintf("%s.tmp-%d", linkPath, os.Getpid())
+	os.Remove(tmp)
+	if err := os.Symlink(target, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, linkPath); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
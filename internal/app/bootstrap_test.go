@@ -0,0 +1,173 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/donghojung/taw/internal/constants"
+)
+
+func TestEnsureIgnored(t *testing.T) {
+	tests := []struct {
+		name    string
+		content *string // nil if there is no .gitignore
+		want    string
+	}{
+		{"no file", nil, ".taw/\n"},
+		{"empty", ptr(""), ".taw/\n"},
+		{"ignored", ptr("node_modules\n.taw\n"), "node_modules\n.taw\n"},
+		{"ignored with slashes", ptr("/.taw/\nbin/\n"), "/.taw/\nbin/\n"},
+		{"ignored with spaces", ptr("  .taw/  \n"), "  .taw/  \n"},
+		{"commented", ptr("# .taw\n"), "# .taw\n.taw/\n"},
+		{"no trailing newline", ptr("node_modules"), "node_modules\n.taw/\n"},
+		{"other entry", ptr(".tawrc\n"), ".tawrc\n.taw/\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".gitignore")
+			if tt.content != nil {
+				writeFile(t, path, *tt.content)
+			}
+
+			undo, err := ensureIgnored(path, constants.TawDirName)
+			if err != nil {
+				t.Fatalf("ensureIgnored: %v", err)
+			}
+			if got := readFile(t, path); got != tt.want {
+				t.Errorf("content = %q, want %q", got, tt.want)
+			}
+
+			changed := tt.content == nil || *tt.content != tt.want
+			if (undo != nil) != changed {
+				t.Fatalf("undo = %v, want one only if the file changed", undo != nil)
+			}
+			if undo == nil {
+				return
+			}
+
+			// A second run finds the entry
+			if again, err := ensureIgnored(path, constants.TawDirName); err != nil || again != nil {
+				t.Errorf("second run: undo = %v, err = %v, want no change", again != nil, err)
+			}
+
+			if err := undo(); err != nil {
+				t.Fatalf("undo: %v", err)
+			}
+			if tt.content == nil {
+				if _, err := os.Stat(path); !os.IsNotExist(err) {
+					t.Errorf("undo left %s behind", path)
+				}
+			} else if got := readFile(t, path); got != *tt.content {
+				t.Errorf("content after undo = %q, want %q", got, *tt.content)
+			}
+		})
+	}
+}
+
+func TestBootstrapRepeated(t *testing.T) {
+	a := newTestApp(t)
+	writeFile(t, filepath.Join(a.ProjectDir, ".gitignore"), "node_modules\n")
+
+	if err := a.Bootstrap(); err != nil {
+		t.Fatalf("Bootstrap: %v", err)
+	}
+	first := snapshot(t, a)
+	if err := a.Bootstrap(); err != nil {
+		t.Fatalf("second Bootstrap: %v", err)
+	}
+	second := snapshot(t, a)
+
+	for path, want := range first {
+		if got := second[path]; got != want {
+			t.Errorf("%s = %q after the second run, want %q", path, got, want)
+		}
+	}
+	if got := first[".gitignore"]; got != "node_modules\n.taw/\n" {
+		t.Errorf(".gitignore = %q, want the .taw entry added once", got)
+	}
+}
+
+func TestBootstrapRollback(t *testing.T) {
+	a := newTestApp(t)
+	// An earlier session linked the prompt elsewhere
+	previous := filepath.Join(a.AssetsDir, "OLD-PROMPT.md")
+	if err := os.Symlink(previous, a.GetGlobalPromptPath()); err != nil {
+		t.Fatal(err)
+	}
+	// The .gitignore step, the last one, fails on a directory
+	if err := os.Mkdir(filepath.Join(a.ProjectDir, ".gitignore"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.Bootstrap(); err == nil {
+		t.Fatal("Bootstrap succeeded, want the .gitignore step to fail")
+	}
+
+	if _, err := os.Lstat(filepath.Join(a.TawDir, constants.GitRepoMarker)); !os.IsNotExist(err) {
+		t.Errorf("git repo marker not removed: %v", err)
+	}
+	if target, err := os.Readlink(a.GetGlobalPromptPath()); err != nil || target != previous {
+		t.Errorf("prompt link = %q (%v), want it restored to %q", target, err, previous)
+	}
+	if _, err := os.Lstat(filepath.Join(a.TawDir, constants.ClaudeLink)); !os.IsNotExist(err) {
+		t.Errorf("claude link not removed: %v", err)
+	}
+}
+
+// newTestApp returns an app for a git project in a temporary directory.
+func newTestApp(t *testing.T) *App {
+	t.Helper()
+	projectDir := t.TempDir()
+	a := &App{
+		ProjectDir: projectDir,
+		TawDir:     filepath.Join(projectDir, constants.TawDirName),
+		AssetsDir:  t.TempDir(),
+		IsGitRepo:  true,
+	}
+	if err := os.MkdirAll(a.TawDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+// snapshot returns what Bootstrap sets up: file contents and link targets.
+func snapshot(t *testing.T, a *App) map[string]string {
+	t.Helper()
+	state := map[string]string{
+		".gitignore": readFile(t, filepath.Join(a.ProjectDir, ".gitignore")),
+		"marker":     readFile(t, filepath.Join(a.TawDir, constants.GitRepoMarker)),
+	}
+	for name, path := range map[string]string{
+		"prompt": a.GetGlobalPromptPath(),
+		"claude": filepath.Join(a.TawDir, constants.ClaudeLink),
+	} {
+		target, err := os.Readlink(path)
+		if err != nil {
+			t.Fatalf("%s link: %v", name, err)
+		}
+		state[name] = target
+	}
+	return state
+}
+
+func ptr(s string) *string {
+	return &s
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
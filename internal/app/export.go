@@ -0,0 +1,281 @@
+// Package app provides the main application context and dependency injection.
+package app
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/donghojung/taw/internal/constants"
+	"github.com/donghojung/taw/internal/logging"
+)
+
+// ExportManifest describes an export. It's the first entry of the archive.
+type ExportManifest struct {
+	Format     int       `json:"format"`
+	TawVersion string    `json:"taw_version,omitempty"`
+	Project    string    `json:"project"`
+	GitRepo    bool      `json:"git_repo"`
+	Redacted   bool      `json:"redacted"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ExportOptions controls what an export contains.
+type ExportOptions struct {
+	TawVersion string
+	Redact     bool // Mask secrets in every file, for sharing the export
+
+	// LogTail keeps only the last LogTail bytes of the log; 0 keeps it all.
+	LogTail int64
+	// Extra are additional files (e.g. diagnostics) written under
+	// debug/, redacted like the rest. Import ignores them.
+	Extra map[string][]byte
+}
+
+// ImportResult summarizes an import.
+type ImportResult struct {
+	Manifest ExportManifest
+	Files    int
+	Skipped  []string // Files that already existed and were kept
+}
+
+// Export writes the project state in .taw (config, prompts, tasks, queue,
+// outbox, archive and log) to w as a gzipped tar, and returns the number of
+// files written. Worktrees, secrets, locks, caches and symlinks into this
+// machine are left out.
+func (a *App) Export(w io.Writer, opts ExportOptions) (int, error) {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	manifest, err := json.MarshalIndent(ExportManifest{
+		Format:     constants.ExportFormatVersion,
+		TawVersion: opts.TawVersion,
+		Project:    filepath.Base(a.ProjectDir),
+		GitRepo:    a.IsGitRepo,
+		Redacted:   opts.Redact,
+		CreatedAt:  time.Now(),
+	}, "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	if err := writeTarFile(tw, constants.ExportManifestName, 0644, manifest); err != nil {
+		return 0, err
+	}
+
+	var files int
+	err = filepath.WalkDir(a.TawDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(a.TawDir, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		if exportSkip(rel, d) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			return tw.WriteHeader(&tar.Header{
+				Typeflag: tar.TypeDir,
+				Name:     name + "/",
+				Mode:     int64(info.Mode().Perm()),
+				ModTime:  info.ModTime(),
+			})
+		}
+		// Sockets, pipes and the like
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if rel == constants.LogFileName && opts.LogTail > 0 && int64(len(data)) > opts.LogTail {
+			data = data[int64(len(data))-opts.LogTail:]
+			// Start at a whole line
+			if i := bytes.IndexByte(data, '\n'); i >= 0 {
+				data = data[i+1:]
+			}
+		}
+		if opts.Redact {
+			data = []byte(logging.Redact(string(data)))
+		}
+		files++
+		return writeTarFile(tw, name, info.Mode().Perm(), data)
+	})
+	if err != nil {
+		return files, fmt.Errorf("failed to export %s: %w", a.TawDir, err)
+	}
+
+	names := make([]string, 0, len(opts.Extra))
+	for name := range opts.Extra {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		data := opts.Extra[name]
+		if opts.Redact {
+			data = []byte(logging.Redact(string(data)))
+		}
+		if err := writeTarFile(tw, path.Join(constants.DebugDirName, name), 0644, data); err != nil {
+			return files, err
+		}
+		files++
+	}
+
+	if err := tw.Close(); err != nil {
+		return files, err
+	}
+	return files, gz.Close()
+}
+
+// exportSkip returns true for the paths of .taw left out of exports: secrets,
+// locks, caches and everything tied to this machine.
+func exportSkip(rel string, d fs.DirEntry) bool {
+	// Symlinks point into this machine's TAW install; they're recreated on start
+	if d.Type()&fs.ModeSymlink != 0 {
+		return true
+	}
+
+	parts := strings.Split(rel, string(filepath.Separator))
+	name := parts[len(parts)-1]
+
+	// The project lock, the outbox lock and tab-locks of open windows
+	if name == constants.ProjectLockFileName || name == constants.TabLockDirName {
+		return true
+	}
+	if len(parts) == 1 {
+		switch name {
+		case constants.EnvFileName, constants.CacheDirName, constants.JournalDirName, constants.TrashDirName, constants.RateLimitFileName:
+			return true
+		}
+	}
+	if len(parts) == 3 && parts[0] == constants.AgentsDirName {
+		switch name {
+		case constants.WorktreeDirName, constants.SnapshotDirName, constants.AgentEnvFileName:
+			return true
+		}
+	}
+	return false
+}
+
+// writeTarFile writes a regular file entry.
+func writeTarFile(tw *tar.Writer, name string, mode fs.FileMode, data []byte) error {
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     int64(mode),
+		Size:     int64(len(data)),
+		ModTime:  time.Now(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// Import restores an export written by Export into .taw. Files that already
+// exist are kept unless overwrite is set.
+func (a *App) Import(r io.Reader, overwrite bool) (*ImportResult, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a TAW export: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	// The manifest comes first
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != constants.ExportManifestName {
+		return nil, fmt.Errorf("not a TAW export: %s is missing", constants.ExportManifestName)
+	}
+	result := &ImportResult{}
+	if err := json.NewDecoder(tr).Decode(&result.Manifest); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", constants.ExportManifestName, err)
+	}
+	if result.Manifest.Format > constants.ExportFormatVersion {
+		return nil, fmt.Errorf("export format %d is newer than this TAW supports (%d); update TAW", result.Manifest.Format, constants.ExportFormatVersion)
+	}
+
+	if err := os.MkdirAll(a.TawDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", a.TawDir, err)
+	}
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return result, fmt.Errorf("failed to read export: %w", err)
+		}
+
+		// Never write outside .taw
+		name := path.Clean(hdr.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return result, fmt.Errorf("invalid path in export: %s", hdr.Name)
+		}
+		// Diagnostics of a debug bundle
+		if name == constants.DebugDirName || strings.HasPrefix(name, constants.DebugDirName+"/") {
+			continue
+		}
+		target := filepath.Join(a.TawDir, filepath.FromSlash(name))
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return result, err
+			}
+
+		case tar.TypeReg:
+			if _, err := os.Lstat(target); err == nil && !overwrite {
+				result.Skipped = append(result.Skipped, name)
+				continue
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return result, err
+			}
+			if err := writeImportedFile(target, fs.FileMode(hdr.Mode).Perm(), tr); err != nil {
+				return result, fmt.Errorf("failed to write %s: %w", name, err)
+			}
+			result.Files++
+		}
+	}
+
+	return result, nil
+}
+
+// writeImportedFile writes the content of r to path.
+func writeImportedFile(path string, mode fs.FileMode, r io.Reader) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -4,6 +4,7 @@ package claude
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os/exec"
 	"regexp"
@@ -16,66 +17,95 @@ import (
 
 // Client defines the interface for Claude CLI operations.
 type Client interface {
+	// IsInstalled checks if the claude CLI is available.
+	IsInstalled() bool
+
 	// GenerateTaskName generates a task name from the given content.
-	GenerateTaskName(content string) (string, error)
+	GenerateTaskName(ctx context.Context, content string) (string, error)
 
 	// WaitForReady waits for Claude to be ready in a tmux pane.
-	WaitForReady(tm tmux.Client, target string) error
+	WaitForReady(ctx context.Context, tm tmux.Client, target string) error
 
 	// SendInput sends input to Claude in a tmux pane.
-	SendInput(tm tmux.Client, target, input string) error
+	SendInput(ctx context.Context, tm tmux.Client, target, input string) error
 
 	// SendTrustResponse sends 'y' if trust prompt is detected.
-	SendTrustResponse(tm tmux.Client, target string) error
+	SendTrustResponse(ctx context.Context, tm tmux.Client, target string) error
 }
 
 // claudeClient implements the Client interface.
 type claudeClient struct {
 	maxAttempts  int
 	pollInterval time.Duration
+	nameTimeout  time.Duration
 }
 
-// New creates a new Claude client.
+// New creates a new Claude client with the default timeouts.
 func New() Client {
+	return NewWithTimeouts(0, 0)
+}
+
+// NewWithTimeouts creates a new Claude client that waits up to readyTimeout
+// for Claude to start and nameTimeout for task name generation.
+// Non-positive timeouts use the defaults.
+func NewWithTimeouts(readyTimeout, nameTimeout time.Duration) Client {
+	if readyTimeout <= 0 {
+		readyTimeout = constants.DefaultClaudeReadyTimeout
+	}
+	if nameTimeout <= 0 {
+		nameTimeout = constants.DefaultClaudeNameTimeout
+	}
 	return &claudeClient{
-		maxAttempts:  constants.ClaudeReadyMaxAttempts,
+		maxAttempts:  int(readyTimeout / constants.ClaudeReadyPollInterval),
 		pollInterval: constants.ClaudeReadyPollInterval,
+		nameTimeout:  nameTimeout,
 	}
 }
 
 // TaskNamePattern validates task name format.
 var TaskNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{6,30}[a-z0-9]$`)
 
-// ReadyPatterns matches Claude ready prompts.
-var ReadyPatterns = regexp.MustCompile(`(?i)(Trust|trust|bypass permissions|╭─|^> $)`)
-
 // TrustPattern matches trust confirmation prompt.
 var TrustPattern = regexp.MustCompile(`(?i)trust`)
 
+// IsInstalled checks if the claude CLI is available.
+func (c *claudeClient) IsInstalled() bool {
+	_, err := exec.LookPath("claude")
+	return err == nil
+}
+
 // GenerateTaskName generates a task name using Claude CLI (Haiku model).
-func (c *claudeClient) GenerateTaskName(content string) (string, error) {
+func (c *claudeClient) GenerateTaskName(ctx context.Context, content string) (string, error) {
 	prompt := fmt.Sprintf(`Create a short task name for this task (8-32 lowercase chars, hyphens only, verb-noun format like "add-login-feature"):
 %s
 
 Respond with ONLY the task name, nothing else.`, content)
 
-	// Try with increasing timeouts
+	// Try with increasing timeouts, up to the configured one
 	timeouts := []time.Duration{
-		constants.ClaudeNameGenTimeout1,
-		constants.ClaudeNameGenTimeout2,
-		constants.ClaudeNameGenTimeout3,
+		c.nameTimeout * 3 / 10,
+		c.nameTimeout / 2,
+		c.nameTimeout,
 	}
 
 	var lastErr error
 	for _, timeout := range timeouts {
-		name, err := c.runClaude(prompt, timeout)
+		if ctx.Err() != nil {
+			break
+		}
+
+		name, err := c.runClaude(ctx, prompt, timeout)
 		if err != nil {
 			lastErr = err
+			if errors.Is(err, ErrNotInstalled) {
+				// Retrying won't help
+				break
+			}
 			continue
 		}
 
 		// Validate the name
-		name = sanitizeTaskName(name)
+		name = SanitizeTaskName(name)
 		if TaskNamePattern.MatchString(name) {
 			return name, nil
 		}
@@ -88,8 +118,8 @@ Respond with ONLY the task name, nothing else.`, content)
 	return fallback, lastErr
 }
 
-func (c *claudeClient) runClaude(prompt string, timeout time.Duration) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+func (c *claudeClient) runClaude(ctx context.Context, prompt string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, "claude", "-p", "--model", "haiku")
@@ -100,14 +130,17 @@ func (c *claudeClient) runClaude(prompt string, timeout time.Duration) (string,
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return "", fmt.Errorf("%w: %w", ErrNotInstalled, err)
+		}
 		return "", fmt.Errorf("claude command failed: %w: %s", err, stderr.String())
 	}
 
 	return strings.TrimSpace(stdout.String()), nil
 }
 
-// sanitizeTaskName cleans up a task name to match the required format.
-func sanitizeTaskName(name string) string {
+// SanitizeTaskName cleans up a task name to match the required format.
+func SanitizeTaskName(name string) string {
 	// Convert to lowercase
 	name = strings.ToLower(name)
 
@@ -146,26 +179,52 @@ func sanitizeTaskName(name string) string {
 }
 
 // WaitForReady waits for Claude to be ready in the specified tmux pane.
-func (c *claudeClient) WaitForReady(tm tmux.Client, target string) error {
+// It sleeps on the pane's output stream and only polls as a fallback.
+func (c *claudeClient) WaitForReady(ctx context.Context, tm tmux.Client, target string) error {
+	ready, readyOutput := readyPatterns()
+
+	// Watch before looking at the pane so output in between isn't missed
+	watch, err := tm.WatchOutput(target, readyOutput)
+	if err == nil {
+		defer watch.Stop()
+	}
+	if content, err := tm.CapturePane(target, 50); err == nil && ready.MatchString(content) {
+		return nil
+	}
+
+	if watch != nil {
+		timeout := time.Duration(c.maxAttempts) * c.pollInterval
+		if err := watch.Wait(ctx, timeout); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			// Output may have been missed (e.g. redrawn screen) - fall back to polling
+		}
+	}
+
 	for i := 0; i < c.maxAttempts; i++ {
 		content, err := tm.CapturePane(target, 50)
 		if err != nil {
 			return fmt.Errorf("failed to capture pane: %w", err)
 		}
 
-		if ReadyPatterns.MatchString(content) {
+		if ready.MatchString(content) {
 			return nil
 		}
 
-		time.Sleep(c.pollInterval)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(c.pollInterval):
+		}
 	}
 
-	return fmt.Errorf("timeout waiting for Claude to be ready after %d attempts", c.maxAttempts)
+	return fmt.Errorf("%w after %d attempts", ErrNotReady, c.maxAttempts)
 }
 
 // SendInput sends input to Claude in the specified tmux pane.
 // Uses Escape followed by CR to properly submit multi-line input.
-func (c *claudeClient) SendInput(tm tmux.Client, target, input string) error {
+func (c *claudeClient) SendInput(ctx context.Context, tm tmux.Client, target, input string) error {
 	// First send the text literally
 	if err := tm.SendKeysLiteral(target, input); err != nil {
 		return fmt.Errorf("failed to send input: %w", err)
@@ -189,7 +248,7 @@ func (c *claudeClient) SendInput(tm tmux.Client, target, input string) error {
 }
 
 // SendTrustResponse sends 'y' if a trust prompt is detected.
-func (c *claudeClient) SendTrustResponse(tm tmux.Client, target string) error {
+func (c *claudeClient) SendTrustResponse(ctx context.Context, tm tmux.Client, target string) error {
 	content, err := tm.CapturePane(target, 20)
 	if err != nil {
 		return fmt.Errorf("failed to capture pane: %w", err)
@@ -204,19 +263,52 @@ func (c *claudeClient) SendTrustResponse(tm tmux.Client, target string) error {
 	return nil
 }
 
-// BuildSystemPrompt builds the system prompt from global and project prompts.
-func BuildSystemPrompt(globalPrompt, projectPrompt string) string {
-	var sb strings.Builder
+// PromptLayer is one level of the system prompt hierarchy.
+type PromptLayer struct {
+	Name    string // Layer name: default, user, project or task
+	Source  string // Where the content was read from
+	Content string
+}
+
+// PromptVars holds the values of the {{NAME}} placeholders of prompts, e.g.
+// TASK_NAME or WORKTREE_DIR.
+type PromptVars map[string]string
+
+// promptPlaceholder matches a placeholder such as {{TASK_NAME}}.
+var promptPlaceholder = regexp.MustCompile(`\{\{([A-Z][A-Z0-9_]*)\}\}`)
 
-	if globalPrompt != "" {
-		sb.WriteString(globalPrompt)
+// Expand replaces the placeholders of content that have a value. Unknown
+// placeholders are left as they are, so a typo shows in the prompt.
+func (v PromptVars) Expand(content string) string {
+	if len(v) == 0 {
+		return content
 	}
+	return promptPlaceholder.ReplaceAllStringFunc(content, func(placeholder string) string {
+		if value, ok := v[placeholder[2:len(placeholder)-2]]; ok {
+			return value
+		}
+		return placeholder
+	})
+}
+
+// BuildSystemPrompt composes the system prompt from layers, most general first.
+// Each non-empty layer is introduced by a comment naming its source, so later
+// layers can refine or override earlier ones and the result can be traced back.
+// Placeholders are expanded with vars, which may be nil outside of a task.
+func BuildSystemPrompt(vars PromptVars, layers ...PromptLayer) string {
+	var sb strings.Builder
+
+	for _, layer := range layers {
+		content := strings.TrimSpace(vars.Expand(layer.Content))
+		if content == "" {
+			continue
+		}
 
-	if projectPrompt != "" {
 		if sb.Len() > 0 {
 			sb.WriteString("\n\n---\n\n")
 		}
-		sb.WriteString(projectPrompt)
+		sb.WriteString(fmt.Sprintf("<!-- taw: %s prompt (%s) -->\n\n", layer.Name, layer.Source))
+		sb.WriteString(content)
 	}
 
 	return sb.String()
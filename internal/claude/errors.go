@@ -0,0 +1,30 @@
+// Package claude provides an interface for interacting with Claude CLI.
+package claude
+
+import (
+	"errors"
+)
+
+// Recognized Claude CLI failures, matched with errors.Is.
+var (
+	ErrNotInstalled = errors.New("claude CLI not installed")
+	ErrNotReady     = errors.New("claude did not become ready")
+	ErrTooOld       = errors.New("claude CLI is too old")
+)
+
+// ErrorHint returns a user-facing remediation hint for a recognized Claude
+// failure, or "" if the error isn't one.
+func ErrorHint(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrNotInstalled):
+		return "Install Claude Code (npm install -g @anthropic-ai/claude-code) and make sure 'claude' is on your PATH"
+	case errors.Is(err, ErrTooOld):
+		return "Update Claude Code with 'claude update' (or npm install -g @anthropic-ai/claude-code@latest)"
+	case errors.Is(err, ErrNotReady):
+		return "Claude didn't start in time - check the task window, or raise timeouts.claude_ready in .taw/config"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,144 @@
+// Package claude provides an interface for interacting with Claude CLI.
+package claude
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Usage is the token usage of Claude sessions and its estimated cost.
+type Usage struct {
+	InputTokens      int64
+	OutputTokens     int64
+	CacheWriteTokens int64
+	CacheReadTokens  int64
+	CostUSD          float64
+}
+
+// modelPrices are the API prices in USD per million tokens, by the first
+// model name fragment that matches. Unknown models are priced as Sonnet.
+var modelPrices = []struct {
+	model                                string
+	input, output, cacheWrite, cacheRead float64
+}{
+	{"opus-4-5", 5, 25, 6.25, 0.5},
+	{"opus", 15, 75, 18.75, 1.5},
+	{"haiku", 0.8, 4, 1, 0.08},
+	{"sonnet", 3, 15, 3.75, 0.3},
+}
+
+// transcriptEntry is the part of a Claude Code transcript line that carries
+// token usage.
+type transcriptEntry struct {
+	Timestamp time.Time `json:"timestamp"`
+	RequestID string    `json:"requestId"`
+	CostUSD   float64   `json:"costUSD"`
+	Message   struct {
+		ID    string `json:"id"`
+		Model string `json:"model"`
+		Usage *struct {
+			InputTokens      int64 `json:"input_tokens"`
+			OutputTokens     int64 `json:"output_tokens"`
+			CacheWriteTokens int64 `json:"cache_creation_input_tokens"`
+			CacheReadTokens  int64 `json:"cache_read_input_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+}
+
+// nonAlphanumeric matches the characters Claude Code replaces in the names
+// of its project directories.
+var nonAlphanumeric = regexp.MustCompile(`[^a-zA-Z0-9]`)
+
+// TranscriptDir returns where Claude Code keeps the transcripts of sessions
+// started in dir.
+func TranscriptDir(dir string) string {
+	configDir := os.Getenv("CLAUDE_CONFIG_DIR")
+	if configDir == "" {
+		home, _ := os.UserHomeDir()
+		configDir = filepath.Join(home, ".claude")
+	}
+	return filepath.Join(configDir, "projects", nonAlphanumeric.ReplaceAllString(dir, "-"))
+}
+
+// LoadUsage sums the usage of the sessions started in dir, counting the
+// messages sent from from until to (zero times are unbounded). Messages
+// recorded more than once are counted once.
+func LoadUsage(dir string, from, to time.Time) (*Usage, error) {
+	usage := &Usage{}
+	files, err := filepath.Glob(filepath.Join(TranscriptDir(dir), "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	for _, path := range files {
+		// Older transcripts can't have messages in the range
+		if info, err := os.Stat(path); err != nil || !from.IsZero() && info.ModTime().Before(from) {
+			continue
+		}
+		if err := addTranscriptUsage(usage, path, from, to, seen); err != nil {
+			return nil, err
+		}
+	}
+	return usage, nil
+}
+
+// addTranscriptUsage adds the usage of a transcript file.
+func addTranscriptUsage(usage *Usage, path string, from, to time.Time, seen map[string]bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if !bytes.Contains(line, []byte(`"usage"`)) {
+			continue
+		}
+		var entry transcriptEntry
+		if err := json.Unmarshal(line, &entry); err != nil || entry.Message.Usage == nil {
+			continue
+		}
+		if !from.IsZero() && entry.Timestamp.Before(from) || !to.IsZero() && !entry.Timestamp.Before(to) {
+			continue
+		}
+		if key := entry.Message.ID + entry.RequestID; key != "" {
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+
+		u := entry.Message.Usage
+		usage.InputTokens += u.InputTokens
+		usage.OutputTokens += u.OutputTokens
+		usage.CacheWriteTokens += u.CacheWriteTokens
+		usage.CacheReadTokens += u.CacheReadTokens
+		if entry.CostUSD > 0 {
+			usage.CostUSD += entry.CostUSD
+			continue
+		}
+
+		price := modelPrices[len(modelPrices)-1]
+		for _, p := range modelPrices {
+			if strings.Contains(entry.Message.Model, p.model) {
+				price = p
+				break
+			}
+		}
+		usage.CostUSD += (float64(u.InputTokens)*price.input +
+			float64(u.OutputTokens)*price.output +
+			float64(u.CacheWriteTokens)*price.cacheWrite +
+			float64(u.CacheReadTokens)*price.cacheRead) / 1e6
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,125 @@
+// Package claude provides an interface for interacting with Claude CLI.
+package claude
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/donghojung/taw/internal/constants"
+)
+
+// Version is a claude CLI version: major, minor and patch.
+type Version [3]int
+
+// versionPattern matches the version in "1.0.33 (Claude Code)".
+var versionPattern = regexp.MustCompile(`(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// ParseVersion returns the first version in s, e.g. 1.0.33 in
+// "1.0.33 (Claude Code)". A missing patch number is 0.
+func ParseVersion(s string) (Version, bool) {
+	m := versionPattern.FindStringSubmatch(s)
+	if m == nil {
+		return Version{}, false
+	}
+	var v Version
+	for i := range v {
+		v[i], _ = strconv.Atoi(m[i+1])
+	}
+	return v, true
+}
+
+// String returns the version as major.minor.patch.
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2])
+}
+
+// Less returns true if v is older than o.
+func (v Version) Less(o Version) bool {
+	for i := range v {
+		if v[i] != o[i] {
+			return v[i] < o[i]
+		}
+	}
+	return false
+}
+
+var (
+	versionOnce  sync.Once
+	versionText  string
+	version      Version
+	versionKnown bool
+)
+
+// InstalledVersion returns the installed claude version as reported by
+// claude --version, e.g. "1.0.33 (Claude Code)", and the version parsed from
+// it. text is empty if claude couldn't be run, and known is false if the
+// version wasn't recognized.
+func InstalledVersion() (text string, v Version, known bool) {
+	versionOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), constants.ClaudeVersionTimeout)
+		defer cancel()
+		out, err := exec.CommandContext(ctx, "claude", "--version").Output()
+		if err != nil {
+			return
+		}
+		versionText = strings.TrimSpace(string(out))
+		version, versionKnown = ParseVersion(versionText)
+	})
+	return versionText, version, versionKnown
+}
+
+// MinVersion returns the oldest claude version TAW runs with: min if it's a
+// version, else constants.MinClaudeVersion.
+func MinVersion(min string) Version {
+	if v, ok := ParseVersion(min); ok {
+		return v
+	}
+	v, _ := ParseVersion(constants.MinClaudeVersion)
+	return v
+}
+
+// CheckVersion returns ErrNotInstalled if claude isn't on the PATH, or
+// ErrTooOld if it's older than min (see MinVersion). A version that can't be
+// read is assumed to be recent enough.
+func CheckVersion(min string) error {
+	if _, err := exec.LookPath("claude"); err != nil {
+		return ErrNotInstalled
+	}
+	_, v, known := InstalledVersion()
+	if minVersion := MinVersion(min); known && v.Less(minVersion) {
+		return fmt.Errorf("%w: %s is older than %s", ErrTooOld, v, minVersion)
+	}
+	return nil
+}
+
+// readyScreens are what claude shows when it's ready for input, by the first
+// version showing it: a trust or permissions prompt, then the box around the
+// input until 2.0 and the rules above and below it since.
+var readyScreens = []struct {
+	since   Version
+	pattern *regexp.Regexp
+	output  string // pattern as a grep -E pattern for the pane's output stream
+}{
+	{Version{0, 0, 0}, regexp.MustCompile(`(?i)(trust|bypass permissions|╭─|^> $)`), `[Tt]rust|bypass permissions|╭─`},
+	{Version{2, 0, 0}, regexp.MustCompile(`(?i)(trust|bypass permissions|╭─|─{20,})`), `[Tt]rust|bypass permissions|╭─|─{20,}`},
+}
+
+// readyPatterns returns the ready screen patterns of the installed claude.
+// An unknown version, e.g. of a claude that only runs in a sandbox, gets the
+// newest ones.
+func readyPatterns() (*regexp.Regexp, string) {
+	screen := readyScreens[len(readyScreens)-1]
+	if _, v, known := InstalledVersion(); known {
+		for _, s := range readyScreens {
+			if !v.Less(s.since) {
+				screen = s
+			}
+		}
+	}
+	return screen.pattern, screen.output
+}
@@ -4,9 +4,14 @@ package config
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/donghojung/taw/internal/constants"
 )
@@ -19,6 +24,14 @@ const (
 	WorkModeMain     WorkMode = "main"     // All tasks work on current branch
 )
 
+// Isolation defines whether a task gets its own worktree in worktree mode.
+type Isolation string
+
+const (
+	IsolationWorktree Isolation = "worktree" // The task gets its own worktree and branch
+	IsolationNone     Isolation = "none"     // The task reads the project directory, e.g. research
+)
+
 // OnComplete defines what happens when a task completes.
 type OnComplete string
 
@@ -29,26 +42,861 @@ const (
 	OnCompleteAutoPR     OnComplete = "auto-pr"     // Auto commit + create PR
 )
 
+// MergeStrategy defines how auto-merge integrates a task branch into main.
+type MergeStrategy string
+
+const (
+	MergeStrategyMerge      MergeStrategy = "merge"          // Local --no-ff merge and push
+	MergeStrategySquash     MergeStrategy = "squash"         // gh pr merge --squash
+	MergeStrategyRebase     MergeStrategy = "rebase"         // gh pr merge --rebase
+	MergeStrategyMergeQueue MergeStrategy = "gh-merge-queue" // gh pr merge --auto (merge queue)
+)
+
+// GitBackend selects how TAW reads repository state.
+type GitBackend string
+
+const (
+	GitBackendAuto  GitBackend = "auto"   // git binary, or go-git if git isn't installed
+	GitBackendExec  GitBackend = "exec"   // Always run the git binary
+	GitBackendGoGit GitBackend = "go-git" // Read status, branches and worktrees in-process
+)
+
+// Sandbox selects where agents run.
+type Sandbox string
+
+const (
+	SandboxNone   Sandbox = "none"   // Agents run directly on the host
+	SandboxDocker Sandbox = "docker" // Each agent runs in a container with its worktree mounted
+)
+
+// NoGitMode defines where agents work in a project that isn't a git repository.
+type NoGitMode string
+
+const (
+	NoGitModeDirect   NoGitMode = "direct"   // Agents edit the project files
+	NoGitModeSnapshot NoGitMode = "snapshot" // Agents edit a copy, applied to the project when the task ends
+)
+
+// BudgetPolicy defines what happens when a task or the day goes over budget.
+type BudgetPolicy string
+
+const (
+	BudgetWarn  BudgetPolicy = "warn"  // Notify only
+	BudgetPause BudgetPolicy = "pause" // Notify and queue new tasks while over the daily budget
+	BudgetStop  BudgetPolicy = "stop"  // Pause, and stop the agents over budget
+)
+
+// QueueDrain defines when queued tasks start on their own.
+type QueueDrain string
+
+const (
+	QueueDrainManual   QueueDrain = "manual"   // Only with 'taw queue start'
+	QueueDrainSlot     QueueDrain = "slot"     // As soon as a running task ends
+	QueueDrainHours    QueueDrain = "hours"    // Only during queue.hours, e.g. an overnight batch
+	QueueDrainAttached QueueDrain = "attached" // Only while a client is attached to the session
+)
+
+// TicketProvider selects the issue tracker of 'taw ticket'.
+type TicketProvider string
+
+const (
+	TicketNone   TicketProvider = "none"   // No issue tracker
+	TicketJira   TicketProvider = "jira"   // Jira Cloud or Data Center
+	TicketLinear TicketProvider = "linear" // Linear
+)
+
+// WebhookEvent is a task event sent to webhooks.
+type WebhookEvent string
+
+const (
+	EventTaskCreated   WebhookEvent = "task.created"   // A task was created from its content
+	EventTaskStarted   WebhookEvent = "task.started"   // Its agent started working
+	EventTaskCompleted WebhookEvent = "task.completed" // It ended and was cleaned up
+	EventTaskMerged    WebhookEvent = "task.merged"    // Its branch was merged into main
+	EventTaskFailed    WebhookEvent = "task.failed"    // It needs the user: verification, push or merge failed, or its agent crashed
+)
+
+// WindowOrder selects how task windows are ordered.
+type WindowOrder string
+
+const (
+	WindowOrderCreated  WindowOrder = "created"  // Oldest first, as they were opened
+	WindowOrderNewest   WindowOrder = "newest"   // Newest first
+	WindowOrderStatus   WindowOrder = "status"   // Working, then waiting, then done
+	WindowOrderPriority WindowOrder = "priority" // By #p1 (highest) to #p9 tag, untagged last
+)
+
+// CommitConvention is the rule the commit messages of tasks follow.
+type CommitConvention string
+
+const (
+	CommitConventionNone         CommitConvention = "none"         // Any message
+	CommitConventionConventional CommitConvention = "conventional" // Conventional Commits: type(scope)!: description
+)
+
+// CommitPolicy selects what end-task does with commit messages that don't
+// follow the convention.
+type CommitPolicy string
+
+const (
+	CommitPolicyWarn   CommitPolicy = "warn"   // Log them and end the task
+	CommitPolicyReject CommitPolicy = "reject" // Keep the task open for the user to reword them
+	CommitPolicyAgent  CommitPolicy = "agent"  // Keep the task open and ask its agent to reword them
+)
+
+// Notifications selects how the user is told that a task needs attention.
+type Notifications string
+
+const (
+	NotificationsNone    Notifications = "none"    // No notifications
+	NotificationsTmux    Notifications = "tmux"    // Message in the tmux status line
+	NotificationsDesktop Notifications = "desktop" // Desktop notification (notify-send or osascript), and the tmux message
+)
+
+// ParkOnQuit selects what quitting the session (⌥ q) does with the tasks
+// still in progress.
+type ParkOnQuit string
+
+const (
+	ParkOnQuitOff  ParkOnQuit = "off"  // Only detach
+	ParkOnQuitAsk  ParkOnQuit = "ask"  // Ask whether to park them
+	ParkOnQuitAuto ParkOnQuit = "auto" // Park them without asking
+)
+
 // Config represents the TAW project configuration.
 type Config struct {
-	WorkMode   WorkMode   `yaml:"work_mode"`
-	OnComplete OnComplete `yaml:"on_complete"`
+	WorkMode       WorkMode         `yaml:"work_mode"`
+	ReadOnlyTags   []string         `yaml:"read_only_tags"` // Tags of tasks that get isolation: none
+	OnComplete     OnComplete       `yaml:"on_complete"`
+	MergeStrategy  MergeStrategy    `yaml:"merge_strategy"`
+	Pipeline       PipelineConfig   `yaml:"pipeline"`
+	ParkOnQuit     ParkOnQuit       `yaml:"park_on_quit"` // Push tasks in progress as draft PRs on quit
+	PushRemote     string           `yaml:"push_remote"`
+	UpstreamRemote string           `yaml:"upstream_remote"`
+	SignCommits    bool             `yaml:"sign_commits"` // Sign the commits of TAW and the agents (commit.gpgsign)
+	GitBackend     GitBackend       `yaml:"git_backend"`
+	PRCacheTTL     time.Duration    `yaml:"pr_cache_ttl"`
+	Sandbox        Sandbox          `yaml:"sandbox"`
+	SandboxImage   string           `yaml:"sandbox_image"`  // Empty uses the devcontainer.json image
+	SandboxMounts  []string         `yaml:"sandbox_mounts"` // Extra docker -v specs, e.g. ~/.claude:/root/.claude
+	SandboxArgs    string           `yaml:"sandbox_args"`   // Extra docker run arguments
+	Drafts         int              `yaml:"drafts"`
+	MaxParallel    int              `yaml:"max_parallel_tasks"` // Tasks running at once; more are queued. 0 is unlimited
+	Model          string           `yaml:"model"`              // Model of the agents, e.g. opus; empty uses Claude's default
+	ClaudeMin      string           `yaml:"claude_min_version"` // Oldest claude to run, e.g. 1.0.50; empty is constants.MinClaudeVersion
+	BranchTemplate string           `yaml:"branch_template"`    // Task branch name; {task} is the task name, {user} $USER
+	WorktreeRoot   string           `yaml:"worktree_root"`      // Where worktrees are created; empty is .taw/agents/<task>/worktree
+	TasksFile      string           `yaml:"tasks_file"`         // Checklist synced with tasks by taw sync-tasks
+	Notifications  Notifications    `yaml:"notifications"`
+	ASCII          bool             `yaml:"ascii"`                // Plain ASCII status indicators instead of emoji
+	WindowName     string           `yaml:"window_name_template"` // Status bar name of task windows, e.g. {emoji}{name:.16}
+	WindowOrder    WindowOrder      `yaml:"window_order"`
+	GroupDone      bool             `yaml:"window_group_done"` // Waiting and done task windows go last
+	Windows        []string         `yaml:"windows"`           // Persistent session windows: dashboard, logs
+	SourceTmuxConf bool             `yaml:"source_tmux_conf"`  // Load ~/.tmux.conf before TAW's bindings
+	IDE            string           `yaml:"ide"`               // Opens task worktrees: code, idea, or a command with {dir}
+	Verify         VerifyConfig     `yaml:"verify"`
+	Routes         []Route          `yaml:"routes"`
+	Commits        CommitsConfig    `yaml:"commits"`
+	PRSummary      PRSummaryConfig  `yaml:"pr_summary"`
+	PRComments     PRCommentsConfig `yaml:"pr_comments"`
+	NoGit          NoGitConfig      `yaml:"nogit"`
+	Agent          AgentConfig      `yaml:"agent"`
+	Stale          StaleConfig      `yaml:"stale"`
+	Budget         BudgetConfig     `yaml:"budget"`
+	Queue          QueueConfig      `yaml:"queue"`
+	Ticket         TicketConfig     `yaml:"ticket"`
+	Slack          SlackConfig      `yaml:"slack"`
+	Tools          ToolsConfig      `yaml:"tools"`
+	Webhooks       []Webhook        `yaml:"webhooks"`
+	Env            []EnvVar         `yaml:"env"`
+	EnvRedact      []string         `yaml:"env_redact"` // Names of env variables to redact
+	Redact         []RedactPattern  `yaml:"redact"`     // Extra secret patterns to mask
+	Limits         LimitsConfig     `yaml:"limits"`
+	DiskQuota      string           `yaml:"disk_quota"` // Warn when .taw grows past this, e.g. 20g; empty is unlimited
+	TrashDays      int              `yaml:"trash_days"` // Days cleaned-up tasks stay restorable with 'taw undo'; 0 disables
+	Timeouts       TimeoutsConfig   `yaml:"timeouts"`
+}
+
+// LimitsConfig caps the resources of each agent and everything it runs, so
+// one runaway build doesn't starve the other agents and the user's editor.
+type LimitsConfig struct {
+	Nice   int     `yaml:"nice"`   // CPU niceness 1-19; 0 leaves it unchanged
+	IONice string  `yaml:"ionice"` // Best-effort I/O level 0-7, or "idle"; empty leaves it unchanged
+	CPUs   float64 `yaml:"cpus"`   // CPU cores, e.g. 1.5; 0 is unlimited
+	Memory string  `yaml:"memory"` // Memory cap, e.g. 4g; empty is unlimited
+}
+
+// lazyRegexp returns a getter compiling expr on first use, so commands that
+// never validate a config, such as version, don't pay for it at startup.
+func lazyRegexp(expr string) func() *regexp.Regexp {
+	return sync.OnceValue(func() *regexp.Regexp {
+		return regexp.MustCompile(expr)
+	})
+}
+
+// branchTemplatePattern matches a branch template: it names each task's branch
+// after the task, without characters git rejects in branch names.
+var branchTemplatePattern = lazyRegexp(`^[A-Za-z0-9._/{}-]*\{task\}[A-Za-z0-9._/{}-]*$`)
+
+// windowNamePattern matches a window name template: text with {emoji},
+// {index}, {name}, {status} and {branch} fields, which {name:.N} and
+// {branch:.N} cut to N characters.
+var windowNamePattern = lazyRegexp(`^([^{}]|\{(emoji|index|status)\}|\{(name|branch)(:\.[0-9]+)?\})+$`)
+
+// versionPattern matches a version such as "1.0" or "1.0.50".
+var versionPattern = lazyRegexp(`^[0-9]+\.[0-9]+(\.[0-9]+)?$`)
+
+// sizePattern matches a size such as "512m" or "4g".
+var sizePattern = lazyRegexp(`^[0-9]+[kmgKMG]?$`)
+
+// set sets a limit by its config key. Invalid values are ignored.
+func (l *LimitsConfig) set(key, value string) {
+	switch key {
+	case "nice":
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 && n <= 19 {
+			l.Nice = n
+		}
+	case "ionice":
+		if n, err := strconv.Atoi(value); value == "" || value == "idle" || err == nil && n >= 0 && n <= 7 {
+			l.IONice = value
+		}
+	case "cpus":
+		if f, err := strconv.ParseFloat(value, 64); err == nil && f >= 0 {
+			l.CPUs = f
+		}
+	case "memory":
+		if value == "" || sizePattern().MatchString(value) {
+			l.Memory = value
+		}
+	}
+}
+
+// BranchName returns the branch of a new task from the branch template.
+func (c *Config) BranchName(task string) string {
+	if !branchTemplatePattern().MatchString(c.BranchTemplate) {
+		return task
+	}
+	user := userNamePattern().ReplaceAllString(os.Getenv("USER"), "")
+	if user == "" {
+		user = "user"
+	}
+	return strings.NewReplacer("{task}", task, "{user}", user).Replace(c.BranchTemplate)
+}
+
+// userNamePattern matches characters of $USER that can't be in a branch name.
+var userNamePattern = lazyRegexp(`[^A-Za-z0-9._-]`)
+
+// WorktreePath returns where the worktree of a new task is created under the
+// worktree root, or an empty string to keep it in the task's agent directory.
+func (c *Config) WorktreePath(projectDir, task string) string {
+	root := c.WorktreeRoot
+	if root == "" {
+		return ""
+	}
+	if rest, ok := strings.CutPrefix(root, "~"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			root = home + rest
+		}
+	}
+	if !filepath.IsAbs(root) {
+		root = filepath.Join(projectDir, root)
+	}
+	return filepath.Join(root, filepath.Base(projectDir), task)
+}
+
+// DiskQuotaBytes returns the disk quota in bytes, or 0 if there is none.
+func (c *Config) DiskQuotaBytes() int64 {
+	if !sizePattern().MatchString(c.DiskQuota) {
+		return 0
+	}
+
+	value := strings.ToLower(c.DiskQuota)
+	unit := int64(1)
+	switch value[len(value)-1] {
+	case 'k':
+		unit = 1 << 10
+	case 'm':
+		unit = 1 << 20
+	case 'g':
+		unit = 1 << 30
+	}
+	n, _ := strconv.ParseInt(strings.TrimRight(value, "kmg"), 10, 64)
+	return n * unit
+}
+
+// TimeoutsConfig configures how long TAW waits for slow operations.
+type TimeoutsConfig struct {
+	Git         time.Duration `yaml:"git"`          // Local git commands (worktree, branch, merge)
+	Network     time.Duration `yaml:"network"`      // git push, fetch and pull
+	GitHub      time.Duration `yaml:"github"`       // gh commands
+	ClaudeReady time.Duration `yaml:"claude_ready"` // Claude starting up in a task window
+	ClaudeName  time.Duration `yaml:"claude_name"`  // Task name generation
+	Window      time.Duration `yaml:"window"`       // Task window creation
+	Lock        time.Duration `yaml:"lock"`         // Waiting for another operation's project lock
+}
+
+// set sets a timeout by its config key. Invalid durations are ignored.
+func (t *TimeoutsConfig) set(key, value string) {
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return
+	}
+
+	switch key {
+	case "git":
+		t.Git = d
+	case "network":
+		t.Network = d
+	case "github":
+		t.GitHub = d
+	case "claude_ready":
+		t.ClaudeReady = d
+	case "claude_name":
+		t.ClaudeName = d
+	case "window":
+		t.Window = d
+	case "lock":
+		t.Lock = d
+	}
+}
+
+// CommitsConfig configures the checks end-task runs on the commits of a task
+// before it is pushed.
+type CommitsConfig struct {
+	Convention CommitConvention `yaml:"convention"`
+	OnInvalid  CommitPolicy     `yaml:"on_invalid"`
+	Types      []string         `yaml:"types"`     // Allowed conventional commit types
+	Changelog  string           `yaml:"changelog"` // File getting an entry per feat and fix commit, e.g. CHANGELOG.md; empty disables
+}
+
+// PRSummaryConfig configures the summary table added to PRs created by TAW.
+type PRSummaryConfig struct {
+	Enabled         bool   `yaml:"enabled"`
+	CoverageCommand string `yaml:"coverage_command"` // Prints a coverage percentage; empty skips coverage
+}
+
+// PRCommentsConfig configures how new comments and reviews on the pull
+// requests of open tasks reach them.
+type PRCommentsConfig struct {
+	Interval time.Duration `yaml:"interval"` // How often the pull requests are checked; 0 disables
+	Forward  bool          `yaml:"forward"`  // Type new comments into the agent's pane as a follow-up instruction
+}
+
+// StaleConfig configures what happens to tasks in progress nobody touches:
+// no commits on their branch and no output in their window.
+type StaleConfig struct {
+	Days      int `yaml:"days"`       // Untouched days before a task is flagged stale; 0 disables
+	GraceDays int `yaml:"grace_days"` // Days a stale task stays flagged before it is parked and its worktree removed
+}
+
+// NoGitConfig configures tasks in projects that aren't git repositories.
+type NoGitConfig struct {
+	Mode   NoGitMode `yaml:"mode"`
+	Ignore []string  `yaml:"ignore"` // Names skipped by snapshots and change summaries, e.g. node_modules
+}
+
+// AgentConfig configures how TAW looks after the agents in task windows.
+type AgentConfig struct {
+	AutoRestart bool          `yaml:"auto_restart"` // Restart an agent that crashed, continuing its conversation
+	StuckAfter  time.Duration `yaml:"stuck_after"`  // Working agents whose pane doesn't change this long are flagged; 0 disables
+	LoginShell  bool          `yaml:"login_shell"`  // Start task panes with a login shell, so profile PATH entries apply
+	ShellEnv    string        `yaml:"shell_env"`    // sh file sourced before the agent starts, e.g. ~/.nvm/nvm.sh
+}
+
+// BudgetConfig caps the estimated Claude cost of each task and of each day.
+type BudgetConfig struct {
+	PerTaskUSD float64      `yaml:"per_task_usd"` // 0 is unlimited
+	DailyUSD   float64      `yaml:"daily_usd"`    // 0 is unlimited
+	OnExceed   BudgetPolicy `yaml:"on_exceed"`
+}
+
+// QueueConfig configures when queued tasks start.
+type QueueConfig struct {
+	Drain QueueDrain `yaml:"drain"`
+	Hours string     `yaml:"hours"` // Daily window of the hours drain, e.g. 22:00-07:00
+	Inbox string     `yaml:"inbox"` // Directory whose markdown files are queued as tasks; empty disables
+}
+
+// hoursPattern matches a daily window such as 22:00-07:00.
+var hoursPattern = lazyRegexp(`^([01][0-9]|2[0-3]):[0-5][0-9]-([01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+// InHours returns true if now is within the queue hours, which may span
+// midnight.
+func (q QueueConfig) InHours(now time.Time) bool {
+	if !hoursPattern().MatchString(q.Hours) {
+		return false
+	}
+	from, to, _ := strings.Cut(q.Hours, "-")
+	start, end := clockMinutes(from), clockMinutes(to)
+	minute := now.Hour()*60 + now.Minute()
+	if start <= end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// clockMinutes returns the minutes since midnight of a time such as 07:30.
+func clockMinutes(clock string) int {
+	hours, _ := strconv.Atoi(clock[:2])
+	minutes, _ := strconv.Atoi(clock[3:])
+	return hours*60 + minutes
+}
+
+// TicketConfig configures the issue tracker that tasks are created from and
+// whose tickets follow their tasks.
+type TicketConfig struct {
+	Provider   TicketProvider `yaml:"provider"`
+	URL        string         `yaml:"url"`         // Jira site; for Linear, the API endpoint (empty is the default)
+	Email      string         `yaml:"email"`       // Jira account of an API token; empty uses a personal access token
+	TokenEnv   string         `yaml:"token_env"`   // Variable in .taw/env with the token; empty uses the provider's default
+	InProgress string         `yaml:"in_progress"` // Status of tickets whose task started; empty leaves them
+	InReview   string         `yaml:"in_review"`   // Status of tickets whose task has a pull request
+	Done       string         `yaml:"done"`        // Status of tickets whose task finished
+}
+
+// TokenEnvName returns the variable that holds the tracker's API token.
+func (t TicketConfig) TokenEnvName() string {
+	if t.TokenEnv != "" {
+		return t.TokenEnv
+	}
+	if t.Provider == TicketLinear {
+		return "LINEAR_API_KEY"
+	}
+	return "JIRA_API_TOKEN"
+}
+
+// SlackConfig configures the Slack slash command bridge of 'taw serve'.
+type SlackConfig struct {
+	SigningSecretEnv string   `yaml:"signing_secret_env"` // Variable in .taw/env with the app's signing secret
+	BotTokenEnv      string   `yaml:"bot_token_env"`      // Variable in .taw/env with the bot token that posts results
+	AllowedUsers     []string `yaml:"allowed_users"`      // IDs or names of the users who may queue tasks
+	AllowedChannels  []string `yaml:"allowed_channels"`   // IDs or names of the channels tasks may be queued from; empty means any
+}
+
+// SigningSecretEnvName returns the variable that holds the signing secret.
+func (s SlackConfig) SigningSecretEnvName() string {
+	if s.SigningSecretEnv != "" {
+		return s.SigningSecretEnv
+	}
+	return "SLACK_SIGNING_SECRET"
+}
+
+// Allows returns true if a user may queue tasks from a channel: the user must
+// be in allowed_users, and the channel in allowed_channels unless it is empty.
+// Users and channels match by ID or name.
+func (s SlackConfig) Allows(userID, userName, channelID, channelName string) bool {
+	if !hasAny(s.AllowedUsers, userID, userName) {
+		return false
+	}
+	return len(s.AllowedChannels) == 0 || hasAny(s.AllowedChannels, channelID, channelName)
+}
+
+// BotTokenEnvName returns the variable that holds the bot token.
+func (s SlackConfig) BotTokenEnvName() string {
+	if s.BotTokenEnv != "" {
+		return s.BotTokenEnv
+	}
+	return "SLACK_BOT_TOKEN"
+}
+
+// Webhook is an HTTP endpoint that receives task events as JSON.
+type Webhook struct {
+	Name      string         `yaml:"name"`
+	URL       string         `yaml:"url"`
+	Events    []WebhookEvent `yaml:"events"`     // Events sent; empty sends all
+	SecretEnv string         `yaml:"secret_env"` // Variable in .taw/env with the HMAC signing key
+}
+
+// Wants returns true if the webhook receives an event.
+func (w Webhook) Wants(event WebhookEvent) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// webhook returns the named webhook, adding it if needed.
+func (c *Config) webhook(name string) *Webhook {
+	for i := range c.Webhooks {
+		if c.Webhooks[i].Name == name {
+			return &c.Webhooks[i]
+		}
+	}
+	c.Webhooks = append(c.Webhooks, Webhook{Name: name})
+	return &c.Webhooks[len(c.Webhooks)-1]
+}
+
+// setWebhook sets a webhook key relative to "webhooks.".
+func (c *Config) setWebhook(key, value string) {
+	idx := strings.LastIndex(key, ".")
+	if idx <= 0 {
+		return
+	}
+	name, field := key[:idx], key[idx+1:]
+
+	hook := c.webhook(name)
+	switch field {
+	case "url":
+		hook.URL = value
+	case "events":
+		hook.Events = nil
+		for _, e := range splitList(value) {
+			hook.Events = append(hook.Events, WebhookEvent(e))
+		}
+	case "secret_env":
+		hook.SecretEnv = value
+	}
+}
+
+// VerifyConfig configures the verification gate run by end-task.
+type VerifyConfig struct {
+	Command string        `yaml:"command"` // Single-step shorthand, e.g. "go test ./..."
+	Timeout time.Duration `yaml:"timeout"` // Default per-step timeout
+	Steps   []VerifyStep  `yaml:"steps"`   // Named pipeline; takes precedence over Command
+}
+
+// VerifyStep is a named step of the verification pipeline.
+type VerifyStep struct {
+	Name         string        `yaml:"name"`
+	Command      string        `yaml:"command"`
+	Timeout      time.Duration `yaml:"timeout"`
+	AllowFailure bool          `yaml:"allow_failure"` // Failure is reported but doesn't block completion
+}
+
+// Pipeline returns the steps to run, in order. A lone command is treated as
+// a single step named "verify". Steps without a timeout use the default.
+func (v VerifyConfig) Pipeline() []VerifyStep {
+	steps := v.Steps
+	if len(steps) == 0 && v.Command != "" {
+		steps = []VerifyStep{{Name: "verify", Command: v.Command}}
+	}
+
+	var pipeline []VerifyStep
+	for _, step := range steps {
+		if step.Command == "" {
+			continue
+		}
+		if step.Timeout <= 0 {
+			step.Timeout = v.Timeout
+		}
+		pipeline = append(pipeline, step)
+	}
+	return pipeline
+}
+
+// setStepField sets a field of the named step, adding the step if needed.
+func (v *VerifyConfig) setStepField(name, field, value string) {
+	idx := -1
+	for i := range v.Steps {
+		if v.Steps[i].Name == name {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		v.Steps = append(v.Steps, VerifyStep{Name: name})
+		idx = len(v.Steps) - 1
+	}
+
+	step := &v.Steps[idx]
+	switch field {
+	case "command":
+		step.Command = value
+	case "timeout":
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			step.Timeout = d
+		}
+	case "allow_failure":
+		step.AllowFailure = value == "true"
+	}
+}
+
+// ToolsConfig configures the tools and MCP servers agents get. Rules under
+// Tags only apply to tasks tagged with #<tag> in their content.
+type ToolsConfig struct {
+	Allow []string    `yaml:"allow"` // Permission rules, e.g. "Bash(go test:*)"
+	Deny  []string    `yaml:"deny"`
+	MCP   []MCPServer `yaml:"mcp"`
+	Tags  []ToolRules `yaml:"tags"`
+}
+
+// MCPServer is an MCP server made available to agents.
+type MCPServer struct {
+	Name    string   `yaml:"name"`
+	Command string   `yaml:"command"` // Stdio server command line
+	URL     string   `yaml:"url"`     // HTTP server URL (instead of Command)
+	Tags    []string `yaml:"tags"`    // Only for tasks with one of these tags; empty means all
+}
+
+// ToolRules are extra permission rules for tasks with a tag.
+type ToolRules struct {
+	Tag   string   `yaml:"tag"`
+	Allow []string `yaml:"allow"`
+	Deny  []string `yaml:"deny"`
+}
+
+// ToolSet is the tooling resolved for one task.
+type ToolSet struct {
+	Allow []string
+	Deny  []string
+	MCP   []MCPServer
+}
+
+// Empty returns true if the set configures nothing.
+func (s ToolSet) Empty() bool {
+	return len(s.Allow) == 0 && len(s.Deny) == 0 && len(s.MCP) == 0
+}
+
+// Resolve returns the tools for a task with the given tags.
+func (t ToolsConfig) Resolve(tags []string) ToolSet {
+	set := ToolSet{
+		Allow: append([]string(nil), t.Allow...),
+		Deny:  append([]string(nil), t.Deny...),
+	}
+
+	for _, rules := range t.Tags {
+		if hasAny(tags, rules.Tag) {
+			set.Allow = append(set.Allow, rules.Allow...)
+			set.Deny = append(set.Deny, rules.Deny...)
+		}
+	}
+
+	for _, server := range t.MCP {
+		if len(server.Tags) == 0 || hasAny(tags, server.Tags...) {
+			set.MCP = append(set.MCP, server)
+		}
+	}
+
+	return set
+}
+
+// hasAny returns true if list contains any of values.
+func hasAny(list []string, values ...string) bool {
+	for _, v := range values {
+		for _, item := range list {
+			if item == v {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// set sets a tools key relative to "tools.".
+func (t *ToolsConfig) set(key, value string) {
+	switch key {
+	case "allow":
+		t.Allow = splitList(value)
+		return
+	case "deny":
+		t.Deny = splitList(value)
+		return
+	}
+
+	kind, rest, _ := strings.Cut(key, ".")
+	idx := strings.LastIndex(rest, ".")
+	if idx <= 0 {
+		return
+	}
+	name, field := rest[:idx], rest[idx+1:]
+
+	switch kind {
+	case "mcp":
+		server := t.server(name)
+		switch field {
+		case "command":
+			server.Command = value
+		case "url":
+			server.URL = value
+		case "tags":
+			server.Tags = splitList(value)
+		}
+	case "tags":
+		rules := t.rules(name)
+		switch field {
+		case "allow":
+			rules.Allow = splitList(value)
+		case "deny":
+			rules.Deny = splitList(value)
+		}
+	}
+}
+
+// server returns the named MCP server, adding it if needed.
+func (t *ToolsConfig) server(name string) *MCPServer {
+	for i := range t.MCP {
+		if t.MCP[i].Name == name {
+			return &t.MCP[i]
+		}
+	}
+	t.MCP = append(t.MCP, MCPServer{Name: name})
+	return &t.MCP[len(t.MCP)-1]
+}
+
+// rules returns the rules of a tag, adding them if needed.
+func (t *ToolsConfig) rules(tag string) *ToolRules {
+	for i := range t.Tags {
+		if t.Tags[i].Tag == tag {
+			return &t.Tags[i]
+		}
+	}
+	t.Tags = append(t.Tags, ToolRules{Tag: tag})
+	return &t.Tags[len(t.Tags)-1]
+}
+
+// splitList splits a comma-separated value, dropping empty items.
+// Commas inside parentheses (e.g. "Bash(a, b)") don't split.
+func splitList(value string) []string {
+	var items []string
+	depth, start := 0, 0
+	for i, r := range value {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				if item := strings.TrimSpace(value[start:i]); item != "" {
+					items = append(items, item)
+				}
+				start = i + 1
+			}
+		}
+	}
+	if item := strings.TrimSpace(value[start:]); item != "" {
+		items = append(items, item)
+	}
+	return items
+}
+
+// RedactPattern is a named regular expression matching secrets.
+type RedactPattern struct {
+	Name    string `yaml:"name"`
+	Pattern string `yaml:"pattern"`
+}
+
+// RedactRegexps returns the compiled redaction patterns.
+func (c *Config) RedactRegexps() []*regexp.Regexp {
+	var patterns []*regexp.Regexp
+	for _, p := range c.Redact {
+		if re, err := regexp.Compile(p.Pattern); err == nil {
+			patterns = append(patterns, re)
+		}
+	}
+	return patterns
+}
+
+// configSection is an enclosing "key:" line of nested config keys.
+type configSection struct {
+	indent int
+	name   string
+}
+
+// parseStepKey splits "verify.steps.<name>.<field>" into name and field.
+func parseStepKey(key string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(key, "verify.steps.")
+	if !ok {
+		return "", "", false
+	}
+	idx := strings.LastIndex(rest, ".")
+	if idx <= 0 {
+		return "", "", false
+	}
+	return rest[:idx], rest[idx+1:], true
 }
 
 // DefaultConfig returns the default configuration.
 func DefaultConfig() *Config {
 	return &Config{
-		WorkMode:   WorkModeWorktree,
-		OnComplete: OnCompleteConfirm,
+		WorkMode:       WorkModeWorktree,
+		ReadOnlyTags:   splitList(constants.DefaultReadOnlyTags),
+		OnComplete:     OnCompleteConfirm,
+		MergeStrategy:  MergeStrategyMerge,
+		ParkOnQuit:     ParkOnQuitAsk,
+		PushRemote:     constants.DefaultRemote,
+		UpstreamRemote: constants.DefaultRemote,
+		GitBackend:     GitBackendAuto,
+		PRCacheTTL:     constants.DefaultPRCacheTTL,
+		Sandbox:        SandboxNone,
+		Drafts:         1,
+		BranchTemplate: constants.DefaultBranchTemplate,
+		TasksFile:      constants.DefaultTasksFile,
+		IDE:            constants.DefaultIDE,
+		Notifications:  NotificationsNone,
+		WindowName:     constants.DefaultWindowNameTemplate,
+		WindowOrder:    WindowOrderCreated,
+		TrashDays:      constants.DefaultTrashDays,
+		Verify: VerifyConfig{
+			Timeout: constants.DefaultVerifyTimeout,
+		},
+		Commits: CommitsConfig{
+			Convention: CommitConventionNone,
+			OnInvalid:  CommitPolicyReject,
+			Types:      splitList(constants.DefaultCommitTypes),
+		},
+		PRSummary: PRSummaryConfig{
+			Enabled: true,
+		},
+		PRComments: PRCommentsConfig{
+			Interval: constants.DefaultPRCommentsInterval,
+		},
+		Agent: AgentConfig{
+			StuckAfter: constants.DefaultStuckAfter,
+		},
+		Stale: StaleConfig{
+			GraceDays: constants.DefaultStaleGraceDays,
+		},
+		Budget: BudgetConfig{
+			OnExceed: BudgetWarn,
+		},
+		Queue: QueueConfig{
+			Drain: QueueDrainSlot,
+			Hours: constants.DefaultQueueHours,
+			Inbox: constants.DefaultInboxDir,
+		},
+		Ticket: TicketConfig{
+			Provider:   TicketNone,
+			InProgress: "In Progress",
+			InReview:   "In Review",
+			Done:       "Done",
+		},
+		NoGit: NoGitConfig{
+			Mode:   NoGitModeDirect,
+			Ignore: splitList(constants.DefaultNoGitIgnore),
+		},
+		Timeouts: TimeoutsConfig{
+			Git:         constants.DefaultGitTimeout,
+			Network:     constants.DefaultNetworkTimeout,
+			GitHub:      constants.DefaultGitHubTimeout,
+			ClaudeReady: constants.DefaultClaudeReadyTimeout,
+			ClaudeName:  constants.DefaultClaudeNameTimeout,
+			Window:      constants.DefaultWindowTimeout,
+			Lock:        constants.DefaultLockTimeout,
+		},
 	}
 }
 
-// Load reads the configuration from the given taw directory.
+// Load reads the configuration from the given taw directory, with the
+// organization policy enforced over it.
 func Load(tawDir string) (*Config, error) {
+	policy, err := LoadPolicy()
+	if err != nil {
+		return nil, err
+	}
+
 	configPath := filepath.Join(tawDir, constants.ConfigFileName)
 
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return DefaultConfig(), nil
+		cfg := DefaultConfig()
+		policy.enforce(cfg)
+		return cfg, nil
 	}
 
 	file, err := os.Open(configPath)
@@ -58,10 +906,29 @@ func Load(tawDir string) (*Config, error) {
 	defer file.Close()
 
 	cfg := DefaultConfig()
-	scanner := bufio.NewScanner(file)
+	if err := scanConfig(file, func(_ int, key, value string) {
+		cfg.apply(key, value)
+	}); err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	policy.enforce(cfg)
+	return cfg, nil
+}
+
+// scanConfig calls fn with the line number, the full key (e.g.
+// "verify.steps.lint.command") and the value of each setting in r.
+func scanConfig(r io.Reader, fn func(line int, key, value string)) error {
+	scanner := bufio.NewScanner(r)
+
+	// Indented keys belong to the enclosing sections (e.g. "verify.steps.lint.command")
+	var sections []configSection
 
+	lineNo := 0
 	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
+		lineNo++
+		raw := scanner.Text()
+		line := strings.TrimSpace(raw)
 		if line == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
@@ -74,26 +941,251 @@ func Load(tawDir string) (*Config, error) {
 		key := strings.TrimSpace(parts[0])
 		value := strings.TrimSpace(parts[1])
 
-		switch key {
-		case "work_mode":
-			cfg.WorkMode = WorkMode(value)
-		case "on_complete":
-			cfg.OnComplete = OnComplete(value)
+		indent := len(raw) - len(strings.TrimLeft(raw, " \t"))
+		for len(sections) > 0 && sections[len(sections)-1].indent >= indent {
+			sections = sections[:len(sections)-1]
 		}
+		if value == "" {
+			sections = append(sections, configSection{indent: indent, name: key})
+			continue
+		}
+		for i := len(sections) - 1; i >= 0; i-- {
+			key = sections[i].name + "." + key
+		}
+
+		fn(lineNo, key, value)
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("failed to read config: %w", err)
+	return scanner.Err()
+}
+
+// apply sets a setting by its full key. Invalid values are ignored.
+func (c *Config) apply(key, value string) {
+	if name, field, ok := parseStepKey(key); ok {
+		c.Verify.setStepField(name, field, value)
+		return
+	}
+	if name, ok := strings.CutPrefix(key, "pipeline."); ok {
+		c.Pipeline.set(name, value)
+		return
+	}
+	if name, ok := strings.CutPrefix(key, "limits."); ok {
+		c.Limits.set(name, value)
+		return
+	}
+	if name, ok := strings.CutPrefix(key, "timeouts."); ok {
+		c.Timeouts.set(name, value)
+		return
+	}
+	if name, ok := strings.CutPrefix(key, "tools."); ok {
+		c.Tools.set(name, value)
+		return
+	}
+	if name, ok := strings.CutPrefix(key, "webhooks."); ok {
+		c.setWebhook(name, value)
+		return
+	}
+	if name, ok := strings.CutPrefix(key, "routes."); ok {
+		c.setRoute(name, value)
+		return
+	}
+	if name, ok := strings.CutPrefix(key, "redact."); ok {
+		// Invalid patterns are ignored
+		if _, err := regexp.Compile(value); err == nil {
+			c.Redact = append(c.Redact, RedactPattern{Name: name, Pattern: value})
+		}
+		return
+	}
+	if name, ok := strings.CutPrefix(key, "env."); ok {
+		c.Env = setEnvVar(c.Env, EnvVar{Name: name, Value: unquote(value)})
+		return
 	}
 
-	return cfg, nil
+	switch key {
+	case "work_mode":
+		c.WorkMode = WorkMode(value)
+	case "read_only_tags":
+		c.ReadOnlyTags = splitList(value)
+	case "on_complete":
+		c.OnComplete = OnComplete(value)
+	case "merge_strategy":
+		c.MergeStrategy = MergeStrategy(value)
+	case "park_on_quit":
+		c.ParkOnQuit = ParkOnQuit(value)
+	case "push_remote":
+		c.PushRemote = value
+	case "upstream_remote":
+		c.UpstreamRemote = value
+	case "sign_commits":
+		c.SignCommits = value == "true"
+	case "git_backend":
+		c.GitBackend = GitBackend(value)
+	case "pr_cache_ttl":
+		// 0 revalidates on every check
+		if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+			c.PRCacheTTL = d
+		}
+	case "sandbox":
+		c.Sandbox = Sandbox(value)
+	case "sandbox_image":
+		c.SandboxImage = value
+	case "sandbox_mounts":
+		c.SandboxMounts = splitList(value)
+	case "sandbox_args":
+		c.SandboxArgs = value
+	case "disk_quota":
+		if value == "" || sizePattern().MatchString(value) {
+			c.DiskQuota = value
+		}
+	case "trash_days":
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			c.TrashDays = n
+		}
+	case "ascii":
+		c.ASCII = value == "true"
+	case "window_name_template":
+		if windowNamePattern().MatchString(value) {
+			c.WindowName = value
+		}
+	case "window_order":
+		c.WindowOrder = WindowOrder(value)
+	case "window_group_done":
+		c.GroupDone = value == "true"
+	case "windows":
+		c.Windows = splitList(value)
+	case "source_tmux_conf":
+		c.SourceTmuxConf = value == "true"
+	case "ide":
+		c.IDE = value
+	case "drafts":
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			c.Drafts = n
+		}
+	case "max_parallel_tasks":
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			c.MaxParallel = n
+		}
+	case "model":
+		c.Model = value
+	case "claude_min_version":
+		if value == "" || versionPattern().MatchString(value) {
+			c.ClaudeMin = value
+		}
+	case "branch_template":
+		if branchTemplatePattern().MatchString(value) {
+			c.BranchTemplate = value
+		}
+	case "worktree_root":
+		c.WorktreeRoot = value
+	case "tasks_file":
+		c.TasksFile = value
+	case "notifications":
+		c.Notifications = Notifications(value)
+	case "verify.command":
+		c.Verify.Command = value
+	case "verify.timeout":
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			c.Verify.Timeout = d
+		}
+	case "commits.convention":
+		c.Commits.Convention = CommitConvention(value)
+	case "commits.on_invalid":
+		c.Commits.OnInvalid = CommitPolicy(value)
+	case "commits.types":
+		c.Commits.Types = splitList(value)
+	case "commits.changelog":
+		c.Commits.Changelog = value
+	case "env_redact":
+		c.EnvRedact = splitList(value)
+	case "pr_summary.enabled":
+		c.PRSummary.Enabled = value == "true"
+	case "pr_summary.coverage_command":
+		c.PRSummary.CoverageCommand = value
+	case "pr_comments.interval":
+		// 0 disables the check
+		if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+			c.PRComments.Interval = d
+		}
+	case "pr_comments.forward":
+		c.PRComments.Forward = value == "true"
+	case "nogit.mode":
+		c.NoGit.Mode = NoGitMode(value)
+	case "nogit.ignore":
+		c.NoGit.Ignore = splitList(value)
+	case "agent.auto_restart":
+		c.Agent.AutoRestart = value == "true"
+	case "agent.login_shell":
+		c.Agent.LoginShell = value == "true"
+	case "stale.days":
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			c.Stale.Days = n
+		}
+	case "stale.grace_days":
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			c.Stale.GraceDays = n
+		}
+	case "agent.shell_env":
+		c.Agent.ShellEnv = value
+	case "budget.per_task_usd":
+		c.Budget.PerTaskUSD = parseUSD(value, c.Budget.PerTaskUSD)
+	case "budget.daily_usd":
+		c.Budget.DailyUSD = parseUSD(value, c.Budget.DailyUSD)
+	case "budget.on_exceed":
+		c.Budget.OnExceed = BudgetPolicy(value)
+	case "queue.drain":
+		c.Queue.Drain = QueueDrain(value)
+	case "queue.hours":
+		if hoursPattern().MatchString(value) {
+			c.Queue.Hours = value
+		}
+	case "queue.inbox":
+		c.Queue.Inbox = value
+	case "ticket.provider":
+		c.Ticket.Provider = TicketProvider(value)
+	case "ticket.url":
+		c.Ticket.URL = value
+	case "ticket.email":
+		c.Ticket.Email = value
+	case "ticket.token_env":
+		c.Ticket.TokenEnv = value
+	case "ticket.in_progress":
+		c.Ticket.InProgress = value
+	case "ticket.in_review":
+		c.Ticket.InReview = value
+	case "ticket.done":
+		c.Ticket.Done = value
+	case "slack.signing_secret_env":
+		c.Slack.SigningSecretEnv = value
+	case "slack.bot_token_env":
+		c.Slack.BotTokenEnv = value
+	case "slack.allowed_users":
+		c.Slack.AllowedUsers = splitList(value)
+	case "slack.allowed_channels":
+		c.Slack.AllowedChannels = splitList(value)
+	case "agent.stuck_after":
+		// 0 disables the check
+		if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+			c.Agent.StuckAfter = d
+		}
+	}
 }
 
-// Save writes the configuration to the given taw directory.
+// Save writes the configuration to the given taw directory, with the
+// organization policy enforced over it.
 func (c *Config) Save(tawDir string) error {
+	policy, err := LoadPolicy()
+	if err != nil {
+		return err
+	}
+	policy.enforce(c)
+
 	configPath := filepath.Join(tawDir, constants.ConfigFileName)
+	return os.WriteFile(configPath, []byte(c.render()), 0644)
+}
 
-	content := fmt.Sprintf(`# TAW Configuration
+// render returns the configuration file content.
+func (c *Config) render() string {
+	return fmt.Sprintf(`# TAW Configuration
 # Generated by taw setup
 
 # Work mode: worktree or main
@@ -101,15 +1193,557 @@ func (c *Config) Save(tawDir string) error {
 # - main: All tasks work on the current branch
 work_mode: %s
 
+# Tags of read-only tasks (research, questions) that skip the worktree in
+# worktree mode and read the project directory instead (isolation: none).
+# #isolation-none and #isolation-worktree in a task choose for that task.
+read_only_tags: %s
+
 # On complete action: confirm, auto-commit, auto-merge, or auto-pr
 # - confirm: Ask before each action (recommended)
 # - auto-commit: Automatically commit changes
 # - auto-merge: Auto commit + merge + cleanup + close window
 # - auto-pr: Auto commit + create pull request
 on_complete: %s
-`, c.WorkMode, c.OnComplete)
 
-	return os.WriteFile(configPath, []byte(content), 0644)
+# Merge strategy for auto-merge: merge, squash, rebase, or gh-merge-queue
+# - merge: Merge locally with --no-ff and push (default)
+# - squash: Squash-merge the pull request (gh pr merge --squash)
+# - rebase: Rebase-merge the pull request (gh pr merge --rebase)
+# - gh-merge-queue: Enqueue the pull request (gh pr merge --auto), for protected branches
+merge_strategy: %s
+
+# Steps that end a task, in order; empty uses those of on_complete: verify,
+# commit, push, and merge with auto-merge. A failing step keeps the task open.
+# - verify: Run the verification gate (verify)
+# - commit: Commit what the agent left, check commit messages (commits) and
+#   update the changelog; without git, copy a snapshot's changes back
+# - push: Push the task branch
+# - pr: Open a pull request unless the task has one
+# - merge: Merge into the main branch (merge_strategy)
+# - notify: Tell the user the task ended (notifications)
+# Options go in a section per step ({task} is the task name):
+#   pipeline:
+#     steps: verify, commit, push, pr, notify
+#     commit:
+#       message: chore: finish {task}
+#     merge:
+#       strategy: squash
+#     notify:
+#       message: {task} is ready for review
+pipeline:
+  steps: %s
+%s
+# Park the tasks still in progress when quitting the session (⌥ q): commit
+# and push their work, open a draft pull request so it's visible and backed
+# up, and mark them parked. Ending a parked task marks its pull request ready.
+# - off: Only detach
+# - ask: Ask whether to park them (default)
+# - auto: Park them without asking
+park_on_quit: %s
+
+# Git remotes (set push_remote to your fork for fork-based workflows)
+# - push_remote: Where task branches are pushed
+# - upstream_remote: Where the main branch lives and merges land
+push_remote: %s
+upstream_remote: %s
+
+# Sign the commits made by TAW and by agents (git commit.gpgsign, with your
+# git signing setup)
+sign_commits: %t
+
+# Git backend for status, branch, merged and worktree queries: auto, exec, or go-git
+# - auto: Run the git binary, or go-git if git isn't installed (default)
+# - exec: Always run the git binary
+# - go-git: Read the repository in-process (faster with many tasks)
+git_backend: %s
+
+# How long a PR's status is trusted before asking GitHub again (0 = every time).
+# Merged PRs are never asked again; run 'taw --refresh' to revalidate now.
+pr_cache_ttl: %s
+
+# Sandbox for agents: none or docker
+# - none: Agents run directly on the host (default)
+# - docker: Each agent runs in a container with only its worktree and the
+#   repository's .git mounted. The image needs claude and git installed;
+#   sandbox_image defaults to the image in .devcontainer/devcontainer.json.
+#   Mount credentials with sandbox_mounts (comma-separated docker -v specs).
+sandbox: %s
+sandbox_image: %s
+sandbox_mounts: %s
+sandbox_args: %s
+
+# Competing drafts: number of agents working on each task in parallel (worktree mode)
+# - 1: Normal mode (default)
+# - N > 1: Each task runs in N worktrees; pick the best draft when all are done
+drafts: %d
+
+# Tasks whose agents run at once (0 = unlimited). New tasks past the limit
+# wait in the queue and start as running tasks end.
+max_parallel_tasks: %d
+
+# Model of the agents, passed to claude --model (e.g. opus, sonnet, or a full
+# model name). Empty uses Claude's default.
+model: %s
+
+# Oldest claude version to run agents with, e.g. 1.0.50. Older ones are
+# refused at task start with update instructions. Empty uses TAW's minimum.
+claude_min_version: %s
+
+# Task branch name (worktree mode): {task} is the task name, {user} is $USER,
+# e.g. feature/{task} or {user}/{task}. Applies to tasks created afterwards.
+branch_template: %s
+
+# Where task worktrees are created, e.g. ~/worktrees (each project gets a
+# directory in it). Empty keeps them in .taw/agents/<task>/worktree.
+worktree_root: %s
+
+# Tracked checklist of the backlog, relative to the project. 'taw sync-tasks'
+# queues its unchecked "- [ ] ..." items as tasks and checks them off with a
+# link to the pull request once merged. Empty is TASKS.md.
+tasks_file: %s
+
+# Notify when a task waits for input or is done: none, tmux, or desktop
+# - tmux: Message in the tmux status line
+# - desktop: Desktop notification (notify-send or osascript), and the tmux message
+notifications: %s
+
+# Accessibility: show plain ASCII status indicators ([W] working, [?] waiting,
+# [OK] done, [!] warning) instead of emoji in window names, the status bar and
+# dialogs, for terminals or fonts that render emoji poorly
+ascii: %t
+
+# Name of task windows in the status bar, re-rendered as their status changes:
+# {emoji} is the status icon, {index} the window number, {name} the task name,
+# {status} the status and {branch} the task branch. {name:.N} and {branch:.N}
+# cut them to N characters, e.g. {emoji}{index}:{name:.40} on wide terminals.
+window_name_template: %s
+
+# Task window order, kept as statuses change: created, newest, status, or priority
+# - created: Oldest first, as they were opened (default)
+# - newest: Newest first
+# - status: Working, then waiting, then done
+# - priority: By #p1 (highest) to #p9 tag in the task, untagged last
+# window_group_done moves waiting and done tasks after the ones still working,
+# so active work stays next to the new task window.
+window_order: %s
+window_group_done: %t
+
+# Persistent windows opened at session start (comma-separated), instead of
+# the popups: dashboard (tasks, queue and outbox, refreshed live) and logs
+# (the log viewer following the log). Jump to them with ⌥d and ⌥L.
+windows: %s
+
+# Load your ~/.tmux.conf into TAW sessions (they run on their own tmux server).
+# TAW's ⌥ bindings still win where they clash; 'taw keys' lists those clashes
+# and free keys to move your bindings to.
+source_tmux_conf: %t
+
+# Editor or IDE that 'taw open' and ⌥o open a task's worktree in: code, idea,
+# cursor, or a command where {dir} is the worktree and {task} the task name
+# (without {dir}, the directory is appended). In a Codespace or a VS Code
+# Remote terminal, code opens it in the connected VS Code window.
+ide: %s
+
+# Verification gate: end-task runs this in the worktree before commit/merge.
+# On failure the output is sent back to the agent and the task stays open.
+# Leave command empty to disable, or define named steps instead:
+#   steps:
+#     build:
+#       command: go build ./...
+#     lint:
+#       command: golangci-lint run
+#       timeout: 5m
+#       allow_failure: true
+verify:
+  command: %s
+  timeout: %s
+%s
+# Routes for the parts of a monorepo: a task tagged #<tag> with one of the
+# tags, or whose content mentions one of the paths, gets the prompt file
+# (relative to the project) added to its prompt; the verify command runs as a
+# verification step named after the route for those tasks and the ones whose
+# changes touch one of the paths.
+#   routes:
+#     frontend:
+#       paths: frontend/, packages/ui/
+#       tags: frontend
+#       prompt: docs/frontend-style.md
+#       verify: cd frontend && npm test
+%s
+# Commit messages of tasks, checked by end-task before the task is pushed
+# - convention: none or conventional (Conventional Commits, e.g.
+#   "feat(api): add pagination"; types limits the allowed types)
+# - on_invalid: warn (log them), reject (keep the task open) or agent (keep
+#   the task open and ask its agent to reword them)
+# - changelog: File getting an entry for each feat and fix commit (and
+#   breaking change) under its Unreleased section before the task is pushed,
+#   e.g. CHANGELOG.md (empty = none)
+commits:
+  convention: %s
+  on_invalid: %s
+  types: %s
+  changelog: %s
+
+# Summary table (diff stat, changed packages, coverage delta) added to PRs
+# created by TAW. coverage_command must print a total percentage (the last
+# one in its output is used), e.g.
+#   go test -coverprofile=/tmp/cover-$$.out ./... >/dev/null && go tool cover -func=/tmp/cover-$$.out | tail -1
+pr_summary:
+  enabled: %t
+  coverage_command: %s
+
+# New comments and reviews of others on the pull requests of open tasks
+# notify you and set the task to waiting
+# - interval: How often they are checked; 0 disables
+# - forward: Type new comments into the agent's pane as a follow-up
+#   instruction instead of setting the task to waiting
+pr_comments:
+  interval: %s
+  forward: %t
+
+# Projects that aren't git repositories
+# - mode: direct (agents edit the project files) or snapshot (agents edit a
+#   copy in .taw/agents/<task>/snapshot, applied to the project when the task
+#   ends unless the same files changed meanwhile)
+# - ignore: comma-separated file or directory names left out of snapshots
+#   and of the summary of changed files
+nogit:
+  mode: %s
+  ignore: %s
+
+# Agents that exit unexpectedly (non-zero exit code, or right after starting)
+# are reported with a notification and their task is set to waiting
+# - auto_restart: Restart them instead, continuing their conversation (up to
+#   3 times in a row)
+# - stuck_after: Flag working agents whose pane hasn't changed this long as
+#   possibly stuck; the dashboard can nudge or restart them (0 = never)
+# - login_shell: Start task panes with your shell as a login shell, for PATH
+#   entries set in your profile (e.g. when the pane says claude is not found)
+# - shell_env: sh file sourced before the agent starts (e.g. ~/.nvm/nvm.sh)
+agent:
+  auto_restart: %t
+  stuck_after: %s
+  login_shell: %t
+  shell_env: %s
+
+# Tasks in progress untouched (no commits, no agent output) for days are
+# flagged stale and you are notified. Still untouched grace_days later, they
+# are parked: work committed, branch pushed with a draft PR (or saved as
+# parked.patch in the task directory without a remote), worktree removed and
+# window closed. Open them again from the dashboard. days: 0 disables.
+stale:
+  days: %d
+  grace_days: %d
+
+# Budgets for the estimated Claude cost, in USD (empty = unlimited)
+# - per_task_usd: Cost of a single task
+# - daily_usd: Cost of all tasks today
+# - on_exceed: warn (notify), pause (also queue new tasks while over the
+#   daily budget) or stop (also stop the agents over budget)
+# 'taw budget' shows the spending and raises a limit for a task or today.
+budget:
+  per_task_usd: %s
+  daily_usd: %s
+  on_exceed: %s
+
+# When queued tasks start on their own: manual, slot, hours, or attached
+# - manual: Only with 'taw queue start'
+# - slot: As soon as fewer than max_parallel_tasks are running (default)
+# - hours: Only during hours (HH:MM-HH:MM, may span midnight), e.g. to run an
+#   overnight batch
+# - attached: Only while a client is attached to the session
+# The log says why queued tasks are held; 'taw queue' shows the queue.
+# inbox: Directory watched for markdown files to queue as tasks, e.g. a synced
+# ~/Dropbox/taw-inbox (relative to the project; empty = off). The file's
+# content is the task and its name the task's name; queued files are removed.
+queue:
+  drain: %s
+  hours: %s
+  inbox: %s
+
+# Issue tracker of 'taw ticket <key>', which creates a task from a ticket;
+# the ticket then follows the task and gets a link to its pull request
+# - provider: jira, linear or none
+# - url: Jira site, e.g. https://acme.atlassian.net (Linear: empty)
+# - email: Jira account of the API token (empty = personal access token)
+# - token_env: Variable in .taw/env with the API token (empty =
+#   JIRA_API_TOKEN or LINEAR_API_KEY)
+# - in_progress, in_review, done: Statuses for a started task, a task with a
+#   pull request and a finished task (empty = leave the ticket)
+ticket:
+  provider: %s
+  url: %s
+  email: %s
+  token_env: %s
+  in_progress: %s
+  in_review: %s
+  done: %s
+
+# Slack slash command of 'taw serve': '/taw add <task>' in a channel queues a
+# task, and the channel is told when it finishes or fails (with its PR). The
+# request URL of the command is http(s)://<host>/slack/command.
+# - signing_secret_env: Variable in .taw/env with the app's signing secret
+#   (empty = SLACK_SIGNING_SECRET)
+# - bot_token_env: Variable in .taw/env with the bot token (chat:write) that
+#   posts results (empty = SLACK_BOT_TOKEN)
+# - allowed_users: Comma-separated IDs (U...) or names of the users who may
+#   queue tasks; everyone else is refused (empty = nobody)
+# - allowed_channels: Comma-separated IDs (C...) or names of the channels
+#   tasks may be queued from (empty = any)
+slack:
+  signing_secret_env: %s
+  bot_token_env: %s
+  allowed_users: %s
+  allowed_channels: %s
+
+# Tools and MCP servers for agents, written into each worktree's
+# .claude/settings.local.json and .mcp.json before the agent starts.
+# allow/deny take comma-separated permission rules. Tag a task by writing
+# #<tag> in its content to add the rules under tags.<tag>; an MCP server with
+# tags is only started for tasks with one of them.
+#   tools:
+#     allow: Bash(go test:*), WebFetch
+#     mcp:
+#       github:
+#         command: npx -y @modelcontextprotocol/server-github
+#       playwright:
+#         command: npx @playwright/mcp@latest
+#         tags: frontend
+#     tags:
+#       frontend:
+#         allow: Bash(npm run:*)
+%s
+# Webhooks receiving task events as JSON POSTs, retried from .taw/outbox:
+# task.created, task.started, task.completed, task.merged and task.failed.
+# events limits what a webhook gets (empty = all). With secret_env, the body
+# is signed with HMAC-SHA256 using that variable of .taw/env as the key, in
+# the X-TAW-Signature header (sha256=<hex>).
+#   webhooks:
+#     chatops:
+#       url: https://hooks.example.com/taw
+#       events: task.merged, task.failed
+#       secret_env: TAW_WEBHOOK_SECRET
+%s
+# Environment for agent shells (the agent pane, the shell pane and
+# verification steps). Secrets belong in .taw/env (KEY=VALUE lines, chmod 600),
+# whose values are always redacted from logs and saved output. env_redact
+# names variables below to redact too.
+#   env:
+#     DATABASE_URL: postgres://localhost/dev
+#     SENTRY_TOKEN: xxxx
+#   env_redact: SENTRY_TOKEN
+%s
+# Extra secret patterns (regular expressions) masked in logs and saved output,
+# on top of the built-in ones for GitHub, Anthropic, OpenAI, AWS and Slack
+# keys, bearer tokens and private keys.
+#   redact:
+#     internal_token: itk_[A-Za-z0-9]{32}
+%s
+# Resource limits for each agent and everything it runs (empty/0 = unlimited)
+# - nice: CPU niceness (1-19)
+# - ionice: Best-effort I/O level (0-7) or idle (Linux)
+# - cpus: CPU cores, e.g. 1.5 (systemd-run on the host, --cpus in the sandbox)
+# - memory: Memory cap, e.g. 4g (systemd-run on the host, --memory in the sandbox)
+limits:
+  nice: %d
+  ionice: %s
+  cpus: %s
+  memory: %s
+
+# Warn when .taw (task worktrees and agent files) grows past this size,
+# e.g. 20g (empty = no quota). 'taw status' lists the largest tasks.
+disk_quota: %s
+
+# Days a cleaned-up or merged task stays in .taw/trash, restorable with
+# 'taw undo <task>' (0 = delete right away)
+trash_days: %d
+
+# Timeouts for slow operations (raise them for big repos or slow networks)
+# - git: Local git commands (worktree, branch, merge)
+# - network: git push, fetch and pull
+# - github: gh commands (PR creation, merge)
+# - claude_ready: Claude starting up in a task window
+# - claude_name: Task name generation
+# - window: Task window creation
+# - lock: Waiting for another operation on the project to finish
+timeouts:
+  git: %s
+  network: %s
+  github: %s
+  claude_ready: %s
+  claude_name: %s
+  window: %s
+  lock: %s
+`, c.WorkMode, strings.Join(c.ReadOnlyTags, ", "), c.OnComplete, c.MergeStrategy, c.pipelineSteps(), c.Pipeline.yaml(), c.ParkOnQuit, c.PushRemote, c.UpstreamRemote, c.SignCommits, c.GitBackend, c.PRCacheTTL,
+		c.Sandbox, c.SandboxImage, strings.Join(c.SandboxMounts, ", "), c.SandboxArgs, c.Drafts,
+		c.MaxParallel, c.Model, c.ClaudeMin, c.BranchTemplate, c.WorktreeRoot, c.TasksFile, c.Notifications, c.ASCII, c.WindowName, c.WindowOrder, c.GroupDone, strings.Join(c.Windows, ", "), c.SourceTmuxConf, c.IDE,
+		c.Verify.Command, c.Verify.Timeout, c.Verify.stepsYAML(), c.routesYAML(),
+		c.Commits.Convention, c.Commits.OnInvalid, strings.Join(c.Commits.Types, ", "), c.Commits.Changelog,
+		c.PRSummary.Enabled, c.PRSummary.CoverageCommand,
+		c.PRComments.Interval, c.PRComments.Forward,
+		c.NoGit.Mode, strings.Join(c.NoGit.Ignore, ", "),
+		c.Agent.AutoRestart, c.Agent.StuckAfter, c.Agent.LoginShell, c.Agent.ShellEnv,
+		c.Stale.Days, c.Stale.GraceDays,
+		usdString(c.Budget.PerTaskUSD), usdString(c.Budget.DailyUSD), c.Budget.OnExceed,
+		c.Queue.Drain, c.Queue.Hours, c.Queue.Inbox,
+		c.Ticket.Provider, c.Ticket.URL, c.Ticket.Email, c.Ticket.TokenEnv, c.Ticket.InProgress, c.Ticket.InReview, c.Ticket.Done,
+		c.Slack.SigningSecretEnv, c.Slack.BotTokenEnv, strings.Join(c.Slack.AllowedUsers, ", "), strings.Join(c.Slack.AllowedChannels, ", "),
+		c.Tools.yaml(), c.webhooksYAML(), c.envYAML(), c.redactYAML(),
+		c.Limits.Nice, c.Limits.IONice, c.Limits.cpusString(), c.Limits.Memory, c.DiskQuota, c.TrashDays,
+		c.Timeouts.Git, c.Timeouts.Network, c.Timeouts.GitHub,
+		c.Timeouts.ClaudeReady, c.Timeouts.ClaudeName, c.Timeouts.Window, c.Timeouts.Lock)
+}
+
+// stepsYAML renders the verification steps for the config file.
+func (v VerifyConfig) stepsYAML() string {
+	if len(v.Steps) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("  steps:\n")
+	for _, step := range v.Steps {
+		fmt.Fprintf(&sb, "    %s:\n", step.Name)
+		fmt.Fprintf(&sb, "      command: %s\n", step.Command)
+		if step.Timeout > 0 {
+			fmt.Fprintf(&sb, "      timeout: %s\n", step.Timeout)
+		}
+		if step.AllowFailure {
+			sb.WriteString("      allow_failure: true\n")
+		}
+	}
+	return sb.String()
+}
+
+// yaml renders the tools section for the config file.
+func (t ToolsConfig) yaml() string {
+	if len(t.Allow) == 0 && len(t.Deny) == 0 && len(t.MCP) == 0 && len(t.Tags) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("tools:\n")
+	if len(t.Allow) > 0 {
+		fmt.Fprintf(&sb, "  allow: %s\n", strings.Join(t.Allow, ", "))
+	}
+	if len(t.Deny) > 0 {
+		fmt.Fprintf(&sb, "  deny: %s\n", strings.Join(t.Deny, ", "))
+	}
+	if len(t.MCP) > 0 {
+		sb.WriteString("  mcp:\n")
+		for _, server := range t.MCP {
+			fmt.Fprintf(&sb, "    %s:\n", server.Name)
+			if server.Command != "" {
+				fmt.Fprintf(&sb, "      command: %s\n", server.Command)
+			}
+			if server.URL != "" {
+				fmt.Fprintf(&sb, "      url: %s\n", server.URL)
+			}
+			if len(server.Tags) > 0 {
+				fmt.Fprintf(&sb, "      tags: %s\n", strings.Join(server.Tags, ", "))
+			}
+		}
+	}
+	if len(t.Tags) > 0 {
+		sb.WriteString("  tags:\n")
+		for _, rules := range t.Tags {
+			fmt.Fprintf(&sb, "    %s:\n", rules.Tag)
+			if len(rules.Allow) > 0 {
+				fmt.Fprintf(&sb, "      allow: %s\n", strings.Join(rules.Allow, ", "))
+			}
+			if len(rules.Deny) > 0 {
+				fmt.Fprintf(&sb, "      deny: %s\n", strings.Join(rules.Deny, ", "))
+			}
+		}
+	}
+	return sb.String()
+}
+
+// parseUSD returns the amount in value, 0 if empty, or old if invalid.
+func parseUSD(value string, old float64) float64 {
+	if value == "" {
+		return 0
+	}
+	if f, err := strconv.ParseFloat(strings.TrimPrefix(value, "$"), 64); err == nil && f >= 0 {
+		return f
+	}
+	return old
+}
+
+// usdString renders a budget, empty if unlimited.
+func usdString(f float64) string {
+	if f <= 0 {
+		return ""
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// cpusString renders the CPU limit, empty if unlimited.
+func (l LimitsConfig) cpusString() string {
+	if l.CPUs <= 0 {
+		return ""
+	}
+	return strconv.FormatFloat(l.CPUs, 'f', -1, 64)
+}
+
+// envYAML renders the env section for the config file.
+func (c *Config) envYAML() string {
+	if len(c.Env) == 0 && len(c.EnvRedact) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	if len(c.Env) > 0 {
+		sb.WriteString("env:\n")
+		for _, v := range c.Env {
+			fmt.Fprintf(&sb, "  %s: %s\n", v.Name, v.Value)
+		}
+	}
+	if len(c.EnvRedact) > 0 {
+		fmt.Fprintf(&sb, "env_redact: %s\n", strings.Join(c.EnvRedact, ", "))
+	}
+	return sb.String()
+}
+
+// webhooksYAML renders the webhooks section for the config file.
+func (c *Config) webhooksYAML() string {
+	if len(c.Webhooks) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("webhooks:\n")
+	for _, hook := range c.Webhooks {
+		fmt.Fprintf(&sb, "  %s:\n", hook.Name)
+		if hook.URL != "" {
+			fmt.Fprintf(&sb, "    url: %s\n", hook.URL)
+		}
+		if len(hook.Events) > 0 {
+			events := make([]string, len(hook.Events))
+			for i, e := range hook.Events {
+				events[i] = string(e)
+			}
+			fmt.Fprintf(&sb, "    events: %s\n", strings.Join(events, ", "))
+		}
+		if hook.SecretEnv != "" {
+			fmt.Fprintf(&sb, "    secret_env: %s\n", hook.SecretEnv)
+		}
+	}
+	return sb.String()
+}
+
+// redactYAML renders the redact section for the config file.
+func (c *Config) redactYAML() string {
+	if len(c.Redact) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("redact:\n")
+	for _, p := range c.Redact {
+		fmt.Fprintf(&sb, "  %s: %s\n", p.Name, p.Pattern)
+	}
+	return sb.String()
 }
 
 // Exists checks if a configuration file exists in the given taw directory.
@@ -124,6 +1758,11 @@ func ValidWorkModes() []WorkMode {
 	return []WorkMode{WorkModeWorktree, WorkModeMain}
 }
 
+// ValidIsolations returns all valid task isolations.
+func ValidIsolations() []Isolation {
+	return []Isolation{IsolationWorktree, IsolationNone}
+}
+
 // ValidOnCompletes returns all valid on_complete values.
 func ValidOnCompletes() []OnComplete {
 	return []OnComplete{
@@ -133,3 +1772,94 @@ func ValidOnCompletes() []OnComplete {
 		OnCompleteAutoPR,
 	}
 }
+
+// ValidMergeStrategies returns all valid merge_strategy values.
+func ValidMergeStrategies() []MergeStrategy {
+	return []MergeStrategy{
+		MergeStrategyMerge,
+		MergeStrategySquash,
+		MergeStrategyRebase,
+		MergeStrategyMergeQueue,
+	}
+}
+
+// ValidParkOnQuits returns all valid park_on_quit values.
+func ValidParkOnQuits() []ParkOnQuit {
+	return []ParkOnQuit{ParkOnQuitOff, ParkOnQuitAsk, ParkOnQuitAuto}
+}
+
+// ValidGitBackends returns all valid git_backend values.
+func ValidGitBackends() []GitBackend {
+	return []GitBackend{
+		GitBackendAuto,
+		GitBackendExec,
+		GitBackendGoGit,
+	}
+}
+
+// ValidSandboxes returns all valid sandbox values.
+func ValidSandboxes() []Sandbox {
+	return []Sandbox{SandboxNone, SandboxDocker}
+}
+
+// ValidNotifications returns all valid notifications values.
+func ValidNotifications() []Notifications {
+	return []Notifications{NotificationsNone, NotificationsTmux, NotificationsDesktop}
+}
+
+// ValidNoGitModes returns all valid non-git mode values.
+func ValidNoGitModes() []NoGitMode {
+	return []NoGitMode{NoGitModeDirect, NoGitModeSnapshot}
+}
+
+// ValidQueueDrains returns all valid queue drain values.
+func ValidQueueDrains() []QueueDrain {
+	return []QueueDrain{QueueDrainManual, QueueDrainSlot, QueueDrainHours, QueueDrainAttached}
+}
+
+// ValidTicketProviders returns all valid ticket provider values.
+func ValidTicketProviders() []TicketProvider {
+	return []TicketProvider{TicketNone, TicketJira, TicketLinear}
+}
+
+// ValidWebhookEvents returns all valid webhook events.
+func ValidWebhookEvents() []string {
+	return []string{string(EventTaskCreated), string(EventTaskStarted), string(EventTaskCompleted), string(EventTaskMerged), string(EventTaskFailed)}
+}
+
+// ValidPipelineActions returns all valid pipeline actions.
+func ValidPipelineActions() []string {
+	return []string{
+		string(ActionVerify),
+		string(ActionCommit),
+		string(ActionPush),
+		string(ActionPR),
+		string(ActionMerge),
+		string(ActionNotify),
+	}
+}
+
+// ValidBudgetPolicies returns all valid budget policy values.
+func ValidBudgetPolicies() []BudgetPolicy {
+	return []BudgetPolicy{BudgetWarn, BudgetPause, BudgetStop}
+}
+
+// ValidCommitConventions returns all valid commit convention values.
+func ValidCommitConventions() []CommitConvention {
+	return []CommitConvention{CommitConventionNone, CommitConventionConventional}
+}
+
+// ValidCommitPolicies returns all valid commit policy values.
+func ValidCommitPolicies() []CommitPolicy {
+	return []CommitPolicy{CommitPolicyWarn, CommitPolicyReject, CommitPolicyAgent}
+}
+
+// ValidWindowOrders returns all valid window order values.
+func ValidWindowOrders() []WindowOrder {
+	return []WindowOrder{WindowOrderCreated, WindowOrderNewest, WindowOrderStatus, WindowOrderPriority}
+}
+
+// UsesPullRequest returns true if the strategy merges through a GitHub pull request.
+func (s MergeStrategy) UsesPullRequest() bool {
+	return s == MergeStrategySquash || s == MergeStrategyRebase || s == MergeStrategyMergeQueue
+}
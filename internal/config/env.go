@@ -0,0 +1,186 @@
+// Package config handles TAW configuration parsing and management.
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/donghojung/taw/internal/constants"
+)
+
+// ErrEnvFileExposed is returned when .taw/env can be read by other users.
+// Its variables are not injected until the permissions are fixed.
+var ErrEnvFileExposed = errors.New("env file is readable by other users")
+
+// EnvVar is a variable injected into agent shells.
+type EnvVar struct {
+	Name   string
+	Value  string
+	Secret bool // Redacted from logs and saved output
+}
+
+// AgentEnv returns the variables for agent shells: the env section of the
+// config, then .taw/env, whose variables override the config's and are
+// always secret, then the git settings. Variables named in env_redact are
+// secret too. If .taw/env is exposed, only the config's variables are
+// returned, with ErrEnvFileExposed.
+func (c *Config) AgentEnv(tawDir string) ([]EnvVar, error) {
+	vars := append([]EnvVar(nil), c.Env...)
+
+	fileVars, err := LoadEnvFile(tawDir)
+	if err == nil {
+		for _, v := range fileVars {
+			vars = setEnvVar(vars, v)
+		}
+	}
+
+	// The git settings go after any the variables already pass
+	count := os.Getenv("GIT_CONFIG_COUNT")
+	for _, v := range vars {
+		if v.Name == "GIT_CONFIG_COUNT" {
+			count = v.Value
+		}
+	}
+	for _, v := range c.gitEnv(count) {
+		vars = setEnvVar(vars, v)
+	}
+
+	for i := range vars {
+		if hasAny(c.EnvRedact, vars[i].Name) {
+			vars[i].Secret = true
+		}
+	}
+	return vars, err
+}
+
+// GitEnv returns the variables that configure git for TAW and the agents:
+// commit signing with sign_commits. Settings already passed to git in
+// GIT_CONFIG_COUNT and its keys are kept.
+func (c *Config) GitEnv() []EnvVar {
+	return c.gitEnv(os.Getenv("GIT_CONFIG_COUNT"))
+}
+
+// gitEnv returns the git settings as variables following the count settings
+// already passed in GIT_CONFIG_* variables.
+func (c *Config) gitEnv(count string) []EnvVar {
+	if !c.SignCommits {
+		return nil
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || n < 0 {
+		n = 0
+	}
+	return []EnvVar{
+		{Name: "GIT_CONFIG_COUNT", Value: strconv.Itoa(n + 1)},
+		{Name: fmt.Sprintf("GIT_CONFIG_KEY_%d", n), Value: "commit.gpgsign"},
+		{Name: fmt.Sprintf("GIT_CONFIG_VALUE_%d", n), Value: "true"},
+	}
+}
+
+// Environ returns variables as NAME=value entries, e.g. for exec.Cmd.Env.
+func Environ(vars []EnvVar) []string {
+	env := make([]string, 0, len(vars))
+	for _, v := range vars {
+		env = append(env, v.Name+"="+v.Value)
+	}
+	return env
+}
+
+// Secrets returns the values to redact from logs and saved output. Unlike
+// AgentEnv it includes the values of an exposed .taw/env.
+func (c *Config) Secrets(tawDir string) []string {
+	vars, err := c.AgentEnv(tawDir)
+	if errors.Is(err, ErrEnvFileExposed) {
+		fileVars, _ := readEnvFile(filepath.Join(tawDir, constants.EnvFileName))
+		vars = append(vars, fileVars...)
+	}
+
+	var secrets []string
+	for _, v := range vars {
+		if v.Secret && v.Value != "" {
+			secrets = append(secrets, v.Value)
+		}
+	}
+	return secrets
+}
+
+// LoadEnvFile reads KEY=VALUE lines from .taw/env. A missing file has no
+// variables. The file must not be readable by group or others.
+func LoadEnvFile(tawDir string) ([]EnvVar, error) {
+	path := filepath.Join(tawDir, constants.EnvFileName)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return nil, fmt.Errorf("%w: %s has mode %04o, run 'chmod 600 %s'", ErrEnvFileExposed, path, info.Mode().Perm(), path)
+	}
+
+	return readEnvFile(path)
+}
+
+// readEnvFile parses a dotenv file. Lines may start with "export"; values may
+// be quoted.
+func readEnvFile(path string) ([]EnvVar, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open env file: %w", err)
+	}
+	defer file.Close()
+
+	var vars []EnvVar
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		vars = setEnvVar(vars, EnvVar{Name: name, Value: unquote(strings.TrimSpace(value)), Secret: true})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+	return vars, nil
+}
+
+// setEnvVar sets a variable, replacing an earlier one of the same name.
+func setEnvVar(vars []EnvVar, v EnvVar) []EnvVar {
+	for i := range vars {
+		if vars[i].Name == v.Name {
+			vars[i] = v
+			return vars
+		}
+	}
+	return append(vars, v)
+}
+
+// unquote strips matching single or double quotes around a value.
+func unquote(value string) string {
+	if len(value) >= 2 {
+		first, last := value[0], value[len(value)-1]
+		if (first == '"' || first == '\'') && first == last {
+			return value[1 : len(value)-1]
+		}
+	}
+	return value
+}
@@ -0,0 +1,137 @@
+// Package config handles TAW configuration parsing and management.
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PipelineAction is a step of the pipeline that ends a task.
+type PipelineAction string
+
+const (
+	ActionVerify PipelineAction = "verify" // Run the verification gate
+	ActionCommit PipelineAction = "commit" // Commit what the agent left, check commit messages, update the changelog
+	ActionPush   PipelineAction = "push"   // Push the task branch
+	ActionPR     PipelineAction = "pr"     // Open a pull request unless the task has one
+	ActionMerge  PipelineAction = "merge"  // Merge into the main branch
+	ActionNotify PipelineAction = "notify" // Tell the user the task ended
+)
+
+// pipelineOptions are the options of each action.
+var pipelineOptions = map[PipelineAction][]string{
+	ActionCommit: {"message"},  // Message of the commit of leftover changes; {task} is the task name
+	ActionMerge:  {"strategy"}, // Overrides merge_strategy
+	ActionNotify: {"message"},  // {task} is the task name
+}
+
+// PipelineConfig sets the steps that end a task, each an action with
+// options, e.g. commit.message.
+type PipelineConfig struct {
+	Steps   []PipelineAction                     `yaml:"steps"` // Empty uses the steps of on_complete
+	Options map[PipelineAction]map[string]string `yaml:"-"`
+}
+
+// OnCompleteSteps returns the steps of an on_complete mode: tasks are
+// verified, committed and pushed, and merged with auto-merge. With auto-pr,
+// the agent opens the pull request.
+func OnCompleteSteps(mode OnComplete) []PipelineAction {
+	steps := []PipelineAction{ActionVerify, ActionCommit, ActionPush}
+	if mode == OnCompleteAutoMerge {
+		steps = append(steps, ActionMerge)
+	}
+	return steps
+}
+
+// EndSteps returns the steps that end a task completing with mode: the
+// configured ones for on_complete's mode, else the mode's own. Modes set
+// for some tasks only, e.g. by a batch, keep their steps.
+func (c *Config) EndSteps(mode OnComplete) []PipelineAction {
+	if len(c.Pipeline.Steps) > 0 && mode == c.OnComplete {
+		return c.Pipeline.Steps
+	}
+	return OnCompleteSteps(mode)
+}
+
+// Option returns an option of an action, or "" if it isn't set.
+func (p PipelineConfig) Option(action PipelineAction, name string) string {
+	return p.Options[action][name]
+}
+
+// HasAction returns true if steps include action.
+func HasAction(steps []PipelineAction, action PipelineAction) bool {
+	for _, step := range steps {
+		if step == action {
+			return true
+		}
+	}
+	return false
+}
+
+// set sets a pipeline key relative to "pipeline.".
+func (p *PipelineConfig) set(key, value string) {
+	if key == "steps" {
+		p.Steps = nil
+		for _, step := range splitList(value) {
+			if hasAny(ValidPipelineActions(), step) {
+				p.Steps = append(p.Steps, PipelineAction(step))
+			}
+		}
+		return
+	}
+	action, name, _ := strings.Cut(key, ".")
+	if known, err := checkPipelineOption(key, value); !known || err != nil {
+		return
+	}
+	if p.Options == nil {
+		p.Options = make(map[PipelineAction]map[string]string)
+	}
+	if p.Options[PipelineAction(action)] == nil {
+		p.Options[PipelineAction(action)] = make(map[string]string)
+	}
+	if value == "" {
+		delete(p.Options[PipelineAction(action)], name)
+		return
+	}
+	p.Options[PipelineAction(action)][name] = value
+}
+
+// checkPipelineOption validates an option key relative to "pipeline.".
+// known is false if the action has no such option.
+func checkPipelineOption(key, value string) (known bool, err error) {
+	action, name, _ := strings.Cut(key, ".")
+	if !hasAny(pipelineOptions[PipelineAction(action)], name) {
+		return false, errUnknownSetting
+	}
+	if PipelineAction(action) == ActionMerge && name == "strategy" && value != "" {
+		return true, oneOf(ValidMergeStrategies())(value)
+	}
+	return true, nil
+}
+
+// yaml renders the options of the steps for the config file.
+func (p PipelineConfig) yaml() string {
+	var sb strings.Builder
+	for _, action := range ValidPipelineActions() {
+		options := p.Options[PipelineAction(action)]
+		if len(options) == 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, "  %s:\n", action)
+		for _, name := range pipelineOptions[PipelineAction(action)] {
+			if value := options[name]; value != "" {
+				fmt.Fprintf(&sb, "    %s: %s\n", name, value)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// pipelineSteps renders the steps for the config file.
+func (c *Config) pipelineSteps() string {
+	steps := make([]string, len(c.Pipeline.Steps))
+	for i, step := range c.Pipeline.Steps {
+		steps[i] = string(step)
+	}
+	return strings.Join(steps, ", ")
+}
@@ -0,0 +1,177 @@
+// Package config handles TAW configuration parsing and management.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/donghojung/taw/internal/constants"
+)
+
+// Policy is the settings an organization enforces on every project. It's
+// read from /etc/taw/policy.yaml, or the file named by $TAW_POLICY, in the
+// config file format:
+//
+//	locked:
+//	  sandbox: docker
+//	  sign_commits: true
+//	disallowed:
+//	  on_complete: auto-merge
+type Policy struct {
+	Path       string
+	Locked     map[string]string   // Settings with a fixed value
+	Disallowed map[string][]string // Values a setting can't take
+}
+
+// PolicyPath returns the path of the policy file.
+func PolicyPath() string {
+	if path := os.Getenv("TAW_POLICY"); path != "" {
+		return path
+	}
+	return constants.DefaultPolicyPath
+}
+
+// LoadPolicy reads the policy file. It returns nil if there is none.
+func LoadPolicy() (*Policy, error) {
+	path := PolicyPath()
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open policy: %w", err)
+	}
+	defer file.Close()
+
+	p := &Policy{Path: path, Locked: make(map[string]string), Disallowed: make(map[string][]string)}
+	var errs []error
+	err = scanConfig(file, func(line int, key, value string) {
+		if name, ok := strings.CutPrefix(key, "locked."); ok {
+			if _, err := checkSetting(name, value); err != nil {
+				errs = append(errs, fmt.Errorf("line %d: %s: %w", line, name, err))
+				return
+			}
+			p.Locked[name] = normalize(name, value)
+			return
+		}
+		if name, ok := strings.CutPrefix(key, "disallowed."); ok {
+			check, fixed := fixedCheck(name)
+			if !fixed {
+				errs = append(errs, fmt.Errorf("line %d: %s: %w", line, name, errUnknownSetting))
+				return
+			}
+			var values []string
+			for _, v := range splitList(value) {
+				if err := check(v); err != nil {
+					errs = append(errs, fmt.Errorf("line %d: %s: %w", line, name, err))
+					return
+				}
+				values = append(values, normalize(name, v))
+			}
+			p.Disallowed[name] = values
+			return
+		}
+		errs = append(errs, fmt.Errorf("line %d: %s: must be under locked or disallowed", line, key))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy: %w", err)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid policy %s:\n%w", path, err)
+	}
+	return p, nil
+}
+
+// IsLocked returns true if the policy fixes the value of a setting.
+func (p *Policy) IsLocked(key string) bool {
+	if p == nil {
+		return false
+	}
+	_, ok := p.Locked[key]
+	return ok
+}
+
+// Check returns an error if the policy doesn't allow a value for a setting.
+// The pipeline can't get around it: a merge step merges like on_complete
+// auto-merge, and merge.strategy overrides merge_strategy.
+func (p *Policy) Check(key, value string) error {
+	if p == nil {
+		return nil
+	}
+	switch key {
+	case "pipeline.steps":
+		return p.checkSteps(value)
+	case "pipeline.merge.strategy":
+		if value == "" {
+			return nil
+		}
+		if err := p.Check("merge_strategy", value); err != nil {
+			return fmt.Errorf("overrides merge_strategy: %w", err)
+		}
+		return nil
+	}
+	value = normalize(key, value)
+	if locked, ok := p.Locked[key]; ok && locked != value {
+		return fmt.Errorf("locked to %q by %s", locked, p.Path)
+	}
+	if hasAny(p.Disallowed[key], value) {
+		return fmt.Errorf("%q is not allowed by %s", value, p.Path)
+	}
+	return nil
+}
+
+// checkSteps returns an error if the policy doesn't allow the pipeline steps:
+// a merge step when on_complete can't be auto-merge, or no merge step when
+// on_complete is locked to it. No steps use those of on_complete.
+func (p *Policy) checkSteps(value string) error {
+	steps := splitList(value)
+	if len(steps) == 0 {
+		return nil
+	}
+	if !hasAny(steps, string(ActionMerge)) {
+		if locked := p.Locked["on_complete"]; locked == string(OnCompleteAutoMerge) {
+			return fmt.Errorf("a merge step is required: on_complete is locked to %q by %s", locked, p.Path)
+		}
+		return nil
+	}
+	if err := p.Check("on_complete", string(OnCompleteAutoMerge)); err != nil {
+		return fmt.Errorf("a merge step merges like on_complete auto-merge: %w", err)
+	}
+	return nil
+}
+
+// enforce sets the locked settings, and resets settings with a disallowed
+// value, and pipeline settings getting around the policy, to their default.
+func (p *Policy) enforce(c *Config) {
+	if p == nil {
+		return
+	}
+	for key, value := range p.Locked {
+		c.set(key, value)
+	}
+	for key, values := range p.Disallowed {
+		if value, _ := c.Get(key); hasAny(values, value) {
+			def, _ := DefaultConfig().Get(key)
+			c.set(key, def)
+		}
+	}
+	for _, key := range []string{"pipeline.steps", "pipeline.merge.strategy"} {
+		if value, _ := c.Get(key); p.Check(key, value) != nil {
+			c.set(key, "")
+		}
+	}
+}
+
+// normalize returns a value of a setting as the config file writes it, e.g.
+// 10m0s for 10m.
+func normalize(key, value string) string {
+	if _, fixed := fixedCheck(key); !fixed {
+		return value
+	}
+	c := DefaultConfig()
+	c.apply(key, value)
+	normalized, _ := c.Get(key)
+	return normalized
+}
@@ -0,0 +1,137 @@
+// Package config handles TAW configuration parsing and management.
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Preset is a named set of settings for a common way of working.
+type Preset struct {
+	Name        string
+	Description string
+	Settings    []Setting
+	// VerifyGate sets verify.command to the project's test command, if it
+	// has none and one is found.
+	VerifyGate bool
+}
+
+// presets are the presets in the order they're offered.
+var presets = []Preset{
+	{
+		Name:        "solo",
+		Description: "Working alone: finished tasks are merged into main right away",
+		Settings: []Setting{
+			{"work_mode", string(WorkModeWorktree)},
+			{"on_complete", string(OnCompleteAutoMerge)},
+			{"merge_strategy", string(MergeStrategyMerge)},
+			{"branch_template", "{task}"},
+			{"park_on_quit", string(ParkOnQuitOff)},
+			{"notifications", string(NotificationsTmux)},
+		},
+	},
+	{
+		Name:        "team",
+		Description: "Shared repository: each task opens a PR, squash-merged after the tests pass",
+		Settings: []Setting{
+			{"work_mode", string(WorkModeWorktree)},
+			{"on_complete", string(OnCompleteAutoPR)},
+			{"merge_strategy", string(MergeStrategySquash)},
+			{"branch_template", "{user}/{task}"},
+			{"park_on_quit", string(ParkOnQuitAuto)},
+			{"pr_summary.enabled", "true"},
+			{"notifications", string(NotificationsDesktop)},
+		},
+		VerifyGate: true,
+	},
+	{
+		Name:        "ci",
+		Description: "Unattended runs: tasks open PRs without asking, with plain output",
+		Settings: []Setting{
+			{"work_mode", string(WorkModeWorktree)},
+			{"on_complete", string(OnCompleteAutoPR)},
+			{"merge_strategy", string(MergeStrategySquash)},
+			{"pr_summary.enabled", "true"},
+			{"notifications", string(NotificationsNone)},
+			{"ascii", "true"},
+		},
+		VerifyGate: true,
+	},
+	{
+		Name:        "cautious",
+		Description: "Review everything: ask before each action and run few agents at once",
+		Settings: []Setting{
+			{"work_mode", string(WorkModeWorktree)},
+			{"on_complete", string(OnCompleteConfirm)},
+			{"merge_strategy", string(MergeStrategyMerge)},
+			{"max_parallel_tasks", "2"},
+			{"notifications", string(NotificationsTmux)},
+			{"trash_days", "30"},
+		},
+		VerifyGate: true,
+	},
+}
+
+// Presets returns the presets in the order they're offered.
+func Presets() []Preset {
+	return presets
+}
+
+// PresetNames returns the names of the presets.
+func PresetNames() []string {
+	names := make([]string, len(presets))
+	for i, p := range presets {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// ApplyPreset sets the settings of a preset. Settings it doesn't name are
+// kept; its verify gate uses the test command found in projectDir.
+func (c *Config) ApplyPreset(name, projectDir string) error {
+	for _, p := range presets {
+		if p.Name != name {
+			continue
+		}
+		for _, s := range p.Settings {
+			if err := c.Set(s.Key, s.Value); err != nil {
+				return fmt.Errorf("preset %s: %w", name, err)
+			}
+		}
+		if p.VerifyGate && c.Verify.Command == "" && len(c.Verify.Steps) == 0 {
+			c.Verify.Command = DetectTestCommand(projectDir)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown preset: %s (use %s)", name, strings.Join(PresetNames(), ", "))
+}
+
+// testCommands are the test commands of common project files, in order of
+// preference. A file only counts if it contains marker, when set.
+var testCommands = []struct {
+	file    string
+	marker  string
+	command string
+}{
+	{"go.mod", "", "go test ./..."},
+	{"Cargo.toml", "", "cargo test"},
+	{"package.json", `"test":`, "npm test"},
+	{"Makefile", "\ntest:", "make test"},
+}
+
+// DetectTestCommand returns the test command of the project in dir, or an
+// empty string if there's no project file it knows.
+func DetectTestCommand(dir string) string {
+	for _, tc := range testCommands {
+		data, err := os.ReadFile(filepath.Join(dir, tc.file))
+		if err != nil {
+			continue
+		}
+		if tc.marker == "" || strings.Contains("\n"+string(data), tc.marker) {
+			return tc.command
+		}
+	}
+	return ""
+}
@@ -0,0 +1,111 @@
+// Package config handles TAW configuration parsing and management.
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Route gives the tasks of one part of a monorepo an extra prompt and
+// verification step: tasks tagged with one of its tags, or whose content
+// or changes mention one of its paths.
+type Route struct {
+	Name   string   `yaml:"name"`
+	Tags   []string `yaml:"tags"`
+	Paths  []string `yaml:"paths"`  // Directories of the project, e.g. frontend/
+	Prompt string   `yaml:"prompt"` // File added to the task's prompt, relative to the project
+	Verify string   `yaml:"verify"` // Verification step named after the route
+}
+
+// Match returns true if a task with the tags, content and changed files
+// belongs to the route.
+func (r Route) Match(tags []string, content string, files []string) bool {
+	for _, tag := range r.Tags {
+		if hasAny(tags, tag) {
+			return true
+		}
+	}
+	for _, path := range r.Paths {
+		dir := strings.TrimSuffix(filepath.ToSlash(path), "/") + "/"
+		if strings.Contains(content, dir) {
+			return true
+		}
+		for _, file := range files {
+			if strings.HasPrefix(file, dir) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// MatchRoutes returns the routes a task with the tags, content and changed
+// files belongs to.
+func (c *Config) MatchRoutes(tags []string, content string, files []string) []Route {
+	var routes []Route
+	for _, r := range c.Routes {
+		if r.Match(tags, content, files) {
+			routes = append(routes, r)
+		}
+	}
+	return routes
+}
+
+// route returns the named route, adding it if needed.
+func (c *Config) route(name string) *Route {
+	for i := range c.Routes {
+		if c.Routes[i].Name == name {
+			return &c.Routes[i]
+		}
+	}
+	c.Routes = append(c.Routes, Route{Name: name})
+	return &c.Routes[len(c.Routes)-1]
+}
+
+// setRoute sets a route key relative to "routes.".
+func (c *Config) setRoute(key, value string) {
+	idx := strings.LastIndex(key, ".")
+	if idx <= 0 {
+		return
+	}
+	name, field := key[:idx], key[idx+1:]
+
+	r := c.route(name)
+	switch field {
+	case "tags":
+		r.Tags = splitList(value)
+	case "paths":
+		r.Paths = splitList(value)
+	case "prompt":
+		r.Prompt = value
+	case "verify":
+		r.Verify = value
+	}
+}
+
+// routesYAML renders the routes section for the config file.
+func (c *Config) routesYAML() string {
+	if len(c.Routes) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("routes:\n")
+	for _, r := range c.Routes {
+		fmt.Fprintf(&sb, "  %s:\n", r.Name)
+		if len(r.Tags) > 0 {
+			fmt.Fprintf(&sb, "    tags: %s\n", strings.Join(r.Tags, ", "))
+		}
+		if len(r.Paths) > 0 {
+			fmt.Fprintf(&sb, "    paths: %s\n", strings.Join(r.Paths, ", "))
+		}
+		if r.Prompt != "" {
+			fmt.Fprintf(&sb, "    prompt: %s\n", r.Prompt)
+		}
+		if r.Verify != "" {
+			fmt.Fprintf(&sb, "    verify: %s\n", r.Verify)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,458 @@
+// Package config handles TAW configuration parsing and management.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/donghojung/taw/internal/constants"
+)
+
+// Setting is a config key with its value as written in the config file.
+type Setting struct {
+	Key   string
+	Value string
+}
+
+// settingCheck returns an error if a value is invalid for a setting.
+type settingCheck func(value string) error
+
+// settings are the fixed settings in config file order. Settings named by
+// the user (verify steps, pipeline options, routes, tools, webhooks, env and redact entries) are
+// checked by checkSetting.
+var settings = []struct {
+	key   string
+	check settingCheck
+}{
+	{"work_mode", oneOf(ValidWorkModes())},
+	{"read_only_tags", anyValue},
+	{"on_complete", oneOf(ValidOnCompletes())},
+	{"merge_strategy", oneOf(ValidMergeStrategies())},
+	{"pipeline.steps", isList(ValidPipelineActions())},
+	{"park_on_quit", oneOf(ValidParkOnQuits())},
+	{"push_remote", anyValue},
+	{"upstream_remote", anyValue},
+	{"sign_commits", isBool},
+	{"git_backend", oneOf(ValidGitBackends())},
+	{"pr_cache_ttl", isDuration(0)},
+	{"sandbox", oneOf(ValidSandboxes())},
+	{"sandbox_image", anyValue},
+	{"sandbox_mounts", anyValue},
+	{"sandbox_args", anyValue},
+	{"drafts", isInt(1, 0)},
+	{"max_parallel_tasks", isInt(0, 0)},
+	{"model", anyValue},
+	{"claude_min_version", isVersion},
+	{"branch_template", isBranchTemplate},
+	{"worktree_root", anyValue},
+	{"tasks_file", anyValue},
+	{"notifications", oneOf(ValidNotifications())},
+	{"ascii", isBool},
+	{"window_name_template", isWindowNameTemplate},
+	{"window_order", oneOf(ValidWindowOrders())},
+	{"window_group_done", isBool},
+	{"windows", isList(ValidWindows())},
+	{"source_tmux_conf", isBool},
+	{"ide", anyValue},
+	{"verify.command", anyValue},
+	{"verify.timeout", isDuration(time.Nanosecond)},
+	{"commits.convention", oneOf(ValidCommitConventions())},
+	{"commits.on_invalid", oneOf(ValidCommitPolicies())},
+	{"commits.types", anyValue},
+	{"commits.changelog", anyValue},
+	{"pr_summary.enabled", isBool},
+	{"pr_summary.coverage_command", anyValue},
+	{"pr_comments.interval", isDuration(0)},
+	{"pr_comments.forward", isBool},
+	{"nogit.mode", oneOf(ValidNoGitModes())},
+	{"nogit.ignore", anyValue},
+	{"agent.auto_restart", isBool},
+	{"agent.stuck_after", isDuration(0)},
+	{"agent.login_shell", isBool},
+	{"agent.shell_env", anyValue},
+	{"stale.days", isInt(0, 0)},
+	{"stale.grace_days", isInt(0, 0)},
+	{"budget.per_task_usd", isUSD},
+	{"budget.daily_usd", isUSD},
+	{"budget.on_exceed", oneOf(ValidBudgetPolicies())},
+	{"queue.drain", oneOf(ValidQueueDrains())},
+	{"queue.hours", isHours},
+	{"queue.inbox", anyValue},
+	{"ticket.provider", oneOf(ValidTicketProviders())},
+	{"ticket.url", isOptionalURL},
+	{"ticket.email", anyValue},
+	{"ticket.token_env", anyValue},
+	{"ticket.in_progress", anyValue},
+	{"ticket.in_review", anyValue},
+	{"ticket.done", anyValue},
+	{"slack.signing_secret_env", anyValue},
+	{"slack.bot_token_env", anyValue},
+	{"slack.allowed_users", anyValue},
+	{"slack.allowed_channels", anyValue},
+	{"env_redact", anyValue},
+	{"limits.nice", isInt(0, 19)},
+	{"limits.ionice", isIONice},
+	{"limits.cpus", isCPUs},
+	{"limits.memory", isSize},
+	{"disk_quota", isSize},
+	{"trash_days", isInt(0, 0)},
+	{"timeouts.git", isDuration(time.Nanosecond)},
+	{"timeouts.network", isDuration(time.Nanosecond)},
+	{"timeouts.github", isDuration(time.Nanosecond)},
+	{"timeouts.claude_ready", isDuration(time.Nanosecond)},
+	{"timeouts.claude_name", isDuration(time.Nanosecond)},
+	{"timeouts.window", isDuration(time.Nanosecond)},
+	{"timeouts.lock", isDuration(time.Nanosecond)},
+}
+
+// Keys returns the keys of the fixed settings, in config file order.
+func Keys() []string {
+	keys := make([]string, len(settings))
+	for i, s := range settings {
+		keys[i] = s.key
+	}
+	return keys
+}
+
+// ValidWindows returns all valid persistent window names.
+func ValidWindows() []string {
+	return []string{constants.DashboardWindow, constants.LogsWindow}
+}
+
+// Settings returns every setting with its value: the fixed ones, then the
+// ones named by the user.
+func (c *Config) Settings() []Setting {
+	values := make(map[string]string)
+	var named []Setting
+	scanConfig(strings.NewReader(c.render()), func(_ int, key, value string) {
+		values[key] = value
+		if _, fixed := fixedCheck(key); !fixed {
+			named = append(named, Setting{Key: key, Value: value})
+		}
+	})
+
+	result := make([]Setting, 0, len(settings)+len(named))
+	for _, s := range settings {
+		result = append(result, Setting{Key: s.key, Value: values[s.key]})
+	}
+	return append(result, named...)
+}
+
+// Get returns the value of a setting as written in the config file.
+func (c *Config) Get(key string) (string, error) {
+	if known, _ := checkSetting(key, ""); !known {
+		return "", fmt.Errorf("%w: %s", errUnknownSetting, key)
+	}
+	for _, s := range c.Settings() {
+		if s.Key == key {
+			return s.Value, nil
+		}
+	}
+	// A named setting that isn't set
+	return "", nil
+}
+
+// Set validates a value and sets the setting. An empty value resets lists and
+// optional values. Settings locked by the organization policy can't change.
+func (c *Config) Set(key, value string) error {
+	if known, err := checkSetting(key, value); err != nil {
+		if !known {
+			return fmt.Errorf("%w: %s", err, key)
+		}
+		return fmt.Errorf("invalid %s: %w", key, err)
+	}
+	policy, err := LoadPolicy()
+	if err != nil {
+		return err
+	}
+	if err := policy.Check(key, value); err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+
+	c.set(key, value)
+	return nil
+}
+
+// set sets a setting without validating it.
+func (c *Config) set(key, value string) {
+	// A redact pattern replaces the one of the same name
+	if name, ok := strings.CutPrefix(key, "redact."); ok {
+		var patterns []RedactPattern
+		for _, p := range c.Redact {
+			if p.Name != name {
+				patterns = append(patterns, p)
+			}
+		}
+		c.Redact = patterns
+	}
+
+	c.apply(key, value)
+}
+
+// Check validates a config file and returns an error listing the unknown
+// settings, invalid values and values the organization policy doesn't allow
+// with their line numbers.
+func Check(r io.Reader) error {
+	policy, err := LoadPolicy()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	err = scanConfig(r, func(line int, key, value string) {
+		if _, err := checkSetting(key, value); err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %s: %w", line, key, err))
+		} else if err := policy.Check(key, value); err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %s: %w", line, key, err))
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
+
+// errUnknownSetting is returned for keys that aren't settings.
+var errUnknownSetting = errors.New("unknown setting")
+
+// fixedCheck returns the check of a fixed setting.
+func fixedCheck(key string) (settingCheck, bool) {
+	for _, s := range settings {
+		if s.key == key {
+			return s.check, true
+		}
+	}
+	return nil, false
+}
+
+// checkSetting validates a value for a setting. known is false if the key
+// isn't a setting.
+func checkSetting(key, value string) (known bool, err error) {
+	if check, ok := fixedCheck(key); ok {
+		return true, check(value)
+	}
+
+	if _, field, ok := parseStepKey(key); ok {
+		switch field {
+		case "command":
+			return true, nil
+		case "timeout":
+			return true, isDuration(time.Nanosecond)(value)
+		case "allow_failure":
+			return true, isBool(value)
+		}
+		return false, errUnknownSetting
+	}
+	if name, ok := strings.CutPrefix(key, "pipeline."); ok {
+		return checkPipelineOption(name, value)
+	}
+	if name, ok := strings.CutPrefix(key, "tools."); ok {
+		if name == "allow" || name == "deny" {
+			return true, nil
+		}
+		kind, rest, _ := strings.Cut(name, ".")
+		idx := strings.LastIndex(rest, ".")
+		if idx > 0 {
+			field := rest[idx+1:]
+			if kind == "mcp" && (field == "command" || field == "url" || field == "tags") ||
+				kind == "tags" && (field == "allow" || field == "deny") {
+				return true, nil
+			}
+		}
+		return false, errUnknownSetting
+	}
+	if name, ok := strings.CutPrefix(key, "webhooks."); ok {
+		idx := strings.LastIndex(name, ".")
+		if idx > 0 {
+			switch name[idx+1:] {
+			case "url":
+				return true, isURL(value)
+			case "events":
+				return true, isList(ValidWebhookEvents())(value)
+			case "secret_env":
+				return true, nil
+			}
+		}
+		return false, errUnknownSetting
+	}
+	if name, ok := strings.CutPrefix(key, "routes."); ok {
+		idx := strings.LastIndex(name, ".")
+		if idx > 0 {
+			switch name[idx+1:] {
+			case "tags", "paths", "prompt", "verify":
+				return true, nil
+			}
+		}
+		return false, errUnknownSetting
+	}
+	if name, ok := strings.CutPrefix(key, "env."); ok && name != "" {
+		return true, nil
+	}
+	if name, ok := strings.CutPrefix(key, "redact."); ok && name != "" {
+		if _, err := regexp.Compile(value); err != nil {
+			return true, fmt.Errorf("invalid pattern: %w", err)
+		}
+		return true, nil
+	}
+	return false, errUnknownSetting
+}
+
+// anyValue accepts any value.
+func anyValue(string) error {
+	return nil
+}
+
+// oneOf accepts one of the given values.
+func oneOf[T ~string](valid []T) settingCheck {
+	return func(value string) error {
+		names := make([]string, len(valid))
+		for i, v := range valid {
+			if string(v) == value {
+				return nil
+			}
+			names[i] = string(v)
+		}
+		return fmt.Errorf("must be one of %s", strings.Join(names, ", "))
+	}
+}
+
+// isList accepts a comma-separated list of the given values.
+func isList(valid []string) settingCheck {
+	return func(value string) error {
+		for _, item := range splitList(value) {
+			if !hasAny(valid, item) {
+				return fmt.Errorf("%q is not one of %s", item, strings.Join(valid, ", "))
+			}
+		}
+		return nil
+	}
+}
+
+// isBool accepts true or false.
+func isBool(value string) error {
+	if value != "true" && value != "false" {
+		return fmt.Errorf("must be true or false")
+	}
+	return nil
+}
+
+// isInt accepts an integer from min, up to max unless max is 0.
+func isInt(min, max int) settingCheck {
+	return func(value string) error {
+		n, err := strconv.Atoi(value)
+		switch {
+		case err != nil:
+			return fmt.Errorf("must be a number")
+		case n < min:
+			return fmt.Errorf("must be at least %d", min)
+		case max > 0 && n > max:
+			return fmt.Errorf("must be at most %d", max)
+		}
+		return nil
+	}
+}
+
+// isDuration accepts a duration of at least min, e.g. 30s or 5m.
+func isDuration(min time.Duration) settingCheck {
+	return func(value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("must be a duration such as 30s or 5m")
+		}
+		if d < min {
+			if min == 0 {
+				return fmt.Errorf("must not be negative")
+			}
+			return fmt.Errorf("must be greater than 0")
+		}
+		return nil
+	}
+}
+
+// isSize accepts a size such as 512m or 4g, or nothing.
+func isSize(value string) error {
+	if value != "" && !sizePattern().MatchString(value) {
+		return fmt.Errorf("must be a size such as 512m or 4g")
+	}
+	return nil
+}
+
+// isVersion accepts a version such as 1.0.50, or nothing.
+func isVersion(value string) error {
+	if value != "" && !versionPattern().MatchString(value) {
+		return fmt.Errorf("must be a version such as 1.0.50")
+	}
+	return nil
+}
+
+// isBranchTemplate accepts a branch name containing {task}.
+func isBranchTemplate(value string) error {
+	if !branchTemplatePattern().MatchString(value) {
+		return fmt.Errorf("must contain {task} and only letters, digits and . _ / - { }")
+	}
+	return nil
+}
+
+// isWindowNameTemplate accepts text with window name fields.
+func isWindowNameTemplate(value string) error {
+	if !windowNamePattern().MatchString(value) {
+		return fmt.Errorf("must be text with {emoji}, {index}, {name}, {status} or {branch}, e.g. {emoji}{name:.16}")
+	}
+	return nil
+}
+
+// isIONice accepts an I/O level from 0 to 7, idle, or nothing.
+func isIONice(value string) error {
+	if n, err := strconv.Atoi(value); value == "" || value == "idle" || err == nil && n >= 0 && n <= 7 {
+		return nil
+	}
+	return fmt.Errorf("must be 0-7 or idle")
+}
+
+// isUSD accepts an amount in USD such as 2.50, or nothing.
+func isUSD(value string) error {
+	if value == "" {
+		return nil
+	}
+	if f, err := strconv.ParseFloat(strings.TrimPrefix(value, "$"), 64); err != nil || f < 0 {
+		return fmt.Errorf("must be an amount in USD such as 5 or 2.50")
+	}
+	return nil
+}
+
+// isHours accepts a daily window such as 22:00-07:00.
+func isHours(value string) error {
+	if !hoursPattern().MatchString(value) {
+		return fmt.Errorf("must be a window such as 22:00-07:00")
+	}
+	return nil
+}
+
+// isURL accepts an http or https URL.
+func isURL(value string) error {
+	if !strings.HasPrefix(value, "http://") && !strings.HasPrefix(value, "https://") {
+		return fmt.Errorf("must be an http:// or https:// URL")
+	}
+	return nil
+}
+
+// isOptionalURL accepts an http:// or https:// URL, or nothing.
+func isOptionalURL(value string) error {
+	if value == "" {
+		return nil
+	}
+	return isURL(value)
+}
+
+// isCPUs accepts a number of CPU cores such as 1.5.
+func isCPUs(value string) error {
+	if f, err := strconv.ParseFloat(value, 64); err != nil || f < 0 {
+		return fmt.Errorf("must be a number of cores such as 1.5")
+	}
+	return nil
+}
@@ -10,6 +10,17 @@ const (
 	EmojiDone    = "✅"
 	EmojiWarning = "⚠️"
 	EmojiNew     = "⭐️"
+	EmojiStuck   = "💤"
+)
+
+// Window status prefixes in ASCII mode
+const (
+	ASCIIWorking = "[W]"
+	ASCIIWaiting = "[?]"
+	ASCIIDone    = "[OK]"
+	ASCIIWarning = "[!]"
+	ASCIINew     = "[+]"
+	ASCIIStuck   = "[Z]"
 )
 
 // Display limits
@@ -19,19 +30,187 @@ const (
 	MinTaskNameLen    = 8
 )
 
-// Claude interaction timeouts
+// Claude interaction settings
 const (
-	ClaudeReadyMaxAttempts  = 60
 	ClaudeReadyPollInterval = 500 * time.Millisecond
-	ClaudeNameGenTimeout1   = 3 * time.Second
-	ClaudeNameGenTimeout2   = 5 * time.Second
-	ClaudeNameGenTimeout3   = 10 * time.Second
+	ClaudeVersionTimeout    = 10 * time.Second
+
+	// MinClaudeVersion is the oldest claude TAW supports: older ones lack
+	// flags agents are started with. claude_min_version in the config may
+	// raise it.
+	MinClaudeVersion = "1.0.0"
+)
+
+// Default timeouts (overridable in the timeouts section of the config)
+const (
+	DefaultGitTimeout         = 2 * time.Minute  // Local git commands
+	DefaultNetworkTimeout     = 5 * time.Minute  // git push/fetch/pull
+	DefaultGitHubTimeout      = 1 * time.Minute  // gh commands
+	DefaultClaudeReadyTimeout = 1 * time.Minute  // Claude starting up in a window
+	DefaultClaudeNameTimeout  = 10 * time.Second // Task name generation
+	DefaultWindowTimeout      = 30 * time.Second // Task window creation
+	DefaultLockTimeout        = 2 * time.Minute  // Waiting for the project lock
+)
+
+// Verification settings
+const (
+	DefaultVerifyTimeout = 10 * time.Minute
+	VerifyOutputMaxBytes = 8 * 1024
+)
+
+// Knowledge base settings
+const (
+	KnowledgeMaxBytes = 32 * 1024 // Knowledge injected into one task's prompt
+)
+
+// Outbox retry settings
+const (
+	OutboxBaseBackoff = 30 * time.Second
+	OutboxMaxBackoff  = 30 * time.Minute
+	OutboxMaxAttempts = 8
 )
 
-// Git/Worktree timeouts
+// Daemon settings
 const (
-	WorktreeTimeout       = 30 * time.Second
-	WindowCreationTimeout = 30 * time.Second
+	DaemonDialTimeout    = 1 * time.Second
+	DaemonRequestTimeout = 5 * time.Second
+	DaemonPollInterval   = 30 * time.Second
+	DaemonJobStopTimeout = 10 * time.Second
+)
+
+// Agent watchdog settings
+const (
+	// AgentMinUptime is how long an agent must run for an exit with code 0 not
+	// to count as a crash, and for an earlier crash not to count toward
+	// MaxAgentRestarts
+	AgentMinUptime   = 1 * time.Minute
+	MaxAgentRestarts = 3 // Crashes in a row restarted before giving up
+
+	// AgentNotFoundCode is the exit code of an agent script that didn't find
+	// claude, like sh's for a missing command. Restarting won't help.
+	AgentNotFoundCode = 127
+
+	DefaultStuckAfter = 10 * time.Minute // Unchanged pane time before a working agent is flagged
+	NudgeMessage      = "You haven't made visible progress for a while. Continue the task, or set your status to waiting if you need help."
+)
+
+// Rate limit backoff settings
+const (
+	RateLimitBaseBackoff = 1 * time.Minute  // Backoff after the first rate limit hit
+	RateLimitMaxBackoff  = 30 * time.Minute // Backoff doubles with each hit up to this
+	RateLimitScanLines   = 15               // Last lines of an agent pane searched for rate limit errors
+)
+
+// Ask task settings
+const (
+	AnswerPaneLines = 200 // Last lines of an agent pane kept as the answer when it wrote none
+)
+
+// Cost budget settings
+const (
+	BudgetCheckInterval = 1 * time.Minute // How often the daemon reads transcripts for task costs
+)
+
+// Project lock settings
+const (
+	ProjectLockPollInterval = 200 * time.Millisecond
+)
+
+// PR status cache settings
+const (
+	DefaultPRCacheTTL = 5 * time.Minute // How long a cached PR status is trusted
+)
+
+// Stale task settings
+const (
+	DefaultStaleGraceDays = 2             // Days a stale task stays flagged before it is parked
+	StaleCheckInterval    = 1 * time.Hour // How often the daemon looks for stale tasks
+)
+
+// PR comment settings
+const (
+	DefaultPRCommentsInterval = 2 * time.Minute // How often the PRs of open tasks are checked for new comments
+)
+
+// Trash settings
+const (
+	DefaultTrashDays = 7                 // How long cleaned-up tasks can be restored with 'taw undo'
+	TrashRefPrefix   = "refs/taw/trash/" // Git refs keeping the branches of trashed tasks
+)
+
+// Organization policy settings
+const (
+	DefaultPolicyPath = "/etc/taw/policy.yaml" // Overridden by $TAW_POLICY
+)
+
+// Telemetry settings
+const (
+	TelemetryFileName = "telemetry.json" // In the user config directory
+	TelemetryInterval = 24 * time.Hour   // How often counts are reported once opted in
+	TelemetryTimeout  = 10 * time.Second // Time allowed to send a report
+)
+
+// Server settings (taw serve)
+const (
+	DefaultServeAddr  = "localhost:8742" // Listen address of taw serve
+	ServeReadTimeout  = 10 * time.Second // Time allowed to read a request
+	ServeMaxBodyBytes = 1 << 20          // Largest request body accepted
+)
+
+// Queue display settings
+const (
+	QueuePreviewCount = 3  // Next queued tasks named in the status bar and the new-task editor
+	QueueTitleMaxLen  = 30 // Longer queued task titles are cut
+)
+
+// Batch settings (taw batch)
+const (
+	BatchIDFormat      = "20060102-150405" // Batch IDs are their creation time
+	BatchCheckInterval = 1 * time.Minute   // How often the daemon checks whether batches finished
+)
+
+// Queue drain settings
+const (
+	DefaultQueueHours = "22:00-07:00" // When queued tasks start with queue.drain: hours
+)
+
+// Export settings
+const (
+	ExportFormatVersion = 1 // Bumped when taw import can't read older exports as is
+)
+
+// Task scan settings
+const (
+	ScanConcurrency = 8 // Tasks checked at once by merged/corrupted scans
+
+	// StaleGitLockAge is how old a git lock file in a worktree must be to be
+	// considered left behind by a killed git process
+	StaleGitLockAge = 10 * time.Minute
+)
+
+// Session windows (the windows setting)
+const (
+	DashboardWindow          = "dashboard"
+	LogsWindow               = "logs"
+	DashboardRefreshInterval = 2 * time.Second
+)
+
+// Disk usage settings
+const (
+	DiskCheckInterval    = 10 * time.Minute // How often the daemon checks the disk quota
+	DiskQuotaSuggestions = 5                // Largest tasks listed when over quota
+)
+
+// Inbox settings (queue.inbox)
+const (
+	DefaultInboxDir     = ".taw/inbox"
+	InboxPollInterval   = 5 * time.Second // How often the daemon looks for new files in the inbox
+	InboxSettleDuration = 3 * time.Second // Files changed more recently may still be written or synced
+)
+
+// Replay settings
+const (
+	ReplayPollInterval = 2 * time.Second // How often a replay checks whether its agent is done with an instruction
 )
 
 // Tmux command timeout
@@ -41,30 +220,118 @@ const (
 
 // Default configuration values
 const (
-	DefaultMainBranch  = "main"
-	DefaultWorkMode    = "worktree"
-	DefaultOnComplete  = "confirm"
+	DefaultMainBranch     = "main"
+	DefaultRemote         = "origin"
+	DefaultWorkMode       = "worktree"
+	DefaultOnComplete     = "confirm"
+	DefaultMergeStrategy  = "merge"
+	DefaultBranchTemplate = "{task}" // Task branches are named after their task
+	DefaultTasksFile      = "TASKS.md"
+
+	DefaultWindowNameTemplate = "{index}:{emoji}{name:.12}" // Status bar name of task windows
+	DefaultNoGitIgnore        = "node_modules, .venv, __pycache__, .DS_Store"
+	DefaultCommitTypes        = "feat, fix, docs, style, refactor, perf, test, build, ci, chore, revert"
+	DefaultReadOnlyTags       = "research, question"
+	DefaultIDE                = "code"
 )
 
 // Directory and file names
 const (
-	TawDirName       = ".taw"
-	AgentsDirName    = "agents"
-	QueueDirName     = ".queue"
-	ConfigFileName   = "config"
-	LogFileName      = "log"
-	PromptFileName   = "PROMPT.md"
-	TaskFileName     = "task"
-	TabLockDirName   = ".tab-lock"
-	WindowIDFileName = "window_id"
-	PRFileName       = ".pr"
-	GitRepoMarker    = ".is-git-repo"
-	GlobalPromptLink = ".global-prompt"
-	ClaudeLink       = ".claude"
+	TawDirName          = ".taw"
+	AgentsDirName       = "agents"
+	QueueDirName        = ".queue"
+	QueuePromoteFile    = ".promote"
+	OutboxDirName       = "outbox"
+	ArchiveDirName      = "archive"
+	AnswersDirName      = "answers"
+	CacheDirName        = "cache"
+	AssetsDirName       = "assets"
+	CommandsDirName     = "commands"
+	AssetSyncFileName   = ".taw-sync"
+	JournalDirName      = "journal"
+	TrashDirName        = "trash"
+	TrashedAtFileName   = ".trashed-at"
+	SpendDirName        = "spend"
+	BatchDirName        = "batches"
+	KnowledgeDirName    = "knowledge"
+	WorktreeDirName     = "worktree"
+	SnapshotDirName     = "snapshot"
+	ChecksumsFileName   = ".checksums"
+	ExportManifestName  = "taw-export.json"
+	DebugDirName        = "debug"
+	ProjectLockFileName = ".lock"
+	OutboxLockFileName  = "processor.lock"
+	ConfigFileName      = "config"
+	EnvFileName         = "env"
+	AgentEnvFileName    = ".env"
+	ContextFileName     = "context.json"
+	LogFileName         = "log"
+	PromptFileName      = "PROMPT.md"
+	TaskFileName        = "task"
+	TaskIDFileName      = ".task-id"
+	TabLockDirName      = ".tab-lock"
+	TaskLockFileName    = ".op-lock"
+	WindowIDFileName    = "window_id"
+	SetupFileName       = "setup"
+	PRFileName          = ".pr"
+	PushFailedFileName  = ".push-failed"
+	StatusFileName      = ".status"
+	DraftGroupFileName  = ".draft-group"
+	OwnerFileName       = ".owner"
+	TimelineFileName    = ".timeline"
+	ResumeCmdFileName   = ".resume-cmd"
+	AgentScriptFile     = ".agent.sh"
+	ResumeScriptFile    = ".resume.sh"
+	AgentStartFileName  = ".agent-started"
+	RestartsFileName    = ".restarts"
+	PaneHashFileName    = ".pane-hash"
+	StuckFileName       = ".stuck"
+	RateLimitSeenFile   = ".rate-limit-seen"
+	RateLimitFileName   = ".rate-limit"
+	BudgetFileName      = ".budget"
+	BudgetWarnedFile    = ".budget-warned"
+	TicketStateFile     = ".ticket-state"
+	SlackOriginFileName = ".slack"
+	BranchFileName      = ".branch"
+	WorktreePathFile    = ".worktree-path"
+	DraftDoneFileName   = ".draft-done"
+	ParkedFileName      = ".parked"
+	HibernatedFileName  = ".hibernated"
+	IsolationFileName   = ".isolation"
+	AnswerFileName      = "answer.md"
+	VerifyFileName      = ".verify"
+	InstructionFileName = ".instructions"
+	ReplayFileName      = ".replay"
+	PRCommentsFileName  = ".pr-comments"
+	StaleFileName       = ".stale"
+	ParkedPatchFileName = "parked.patch"
+	VerifyLogPrefix     = "verify-"
+	GitRepoMarker       = ".is-git-repo"
+	GlobalPromptLink    = ".global-prompt"
+	ClaudeLink          = ".claude"
+	SettingsFileName    = "settings.local.json"
+	DevcontainerDir     = ".devcontainer"
+	DevcontainerJSON    = "devcontainer.json"
+	MCPConfigFileName   = ".mcp.json"
 )
 
 // Tmux related constants
 const (
 	TmuxSocketPrefix = "taw-"
-	NewWindowName    = EmojiNew + "new"
+	NewWindowName    = "new"             // Shown with the icon.New prefix
+	NewWindowOption  = "@taw_new_window" // tmux option holding the new task window's ID
+	PopupPaneOption  = "@taw_popup_pane" // tmux option holding the pane standing in for a popup
+	QueueOption      = "@taw_queue"      // tmux option holding the queue segment of the status bar
+	TaskIDOption     = "@taw_task_id"    // tmux window option holding the ID of the window's task
+	TaskOption       = "@taw_task"       // tmux window option holding the name of the window's task
+	TaskStatusOption = "@taw_status"     // tmux window option holding the status shown for the window's task
+	TaskBranchOption = "@taw_branch"     // tmux window option holding the branch of the window's task
+
+	// PopupWindowHeight is the height (percent) from which a popup falls back
+	// to a temporary window instead of a split pane on tmux without popups
+	PopupWindowHeight = 80
+
+	// WindowReadyChannelPrefix is the tmux wait-for channel handle-task signals
+	// once a task's window exists
+	WindowReadyChannelPrefix = "taw-window-"
 )
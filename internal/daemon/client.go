@@ -0,0 +1,70 @@
+// Package daemon provides the per-session TAW daemon that owns background work.
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/donghojung/taw/internal/constants"
+)
+
+// pingJob is a no-op request used to check that the daemon is alive.
+const pingJob = "ping"
+
+// Send asks the daemon listening on socketPath to run a job.
+// It returns an error if no daemon is running, so callers can fall back.
+func Send(socketPath, job string, args ...string) error {
+	return SendContext(context.Background(), socketPath, job, args...)
+}
+
+// SendContext is Send, giving up when ctx is done or its deadline passes,
+// if that comes before the request timeout.
+func SendContext(ctx context.Context, socketPath, job string, args ...string) error {
+	dialer := net.Dialer{Timeout: constants.DaemonDialTimeout}
+	conn, err := dialer.DialContext(ctx, "unix", socketPath)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("daemon not running: %w", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(constants.DaemonRequestTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+	// Unblock the request when ctx is cancelled
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	if err := json.NewEncoder(conn).Encode(Request{Job: job, Args: args}); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if !resp.OK {
+		return fmt.Errorf("daemon rejected %s: %s", job, resp.Error)
+	}
+	return nil
+}
+
+// Ping returns nil if a daemon is serving socketPath.
+func Ping(socketPath string) error {
+	return Send(socketPath, pingJob)
+}
@@ -0,0 +1,108 @@
+// Package daemon provides the per-session TAW daemon that owns background work.
+package daemon
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/donghojung/taw/internal/constants"
+	"github.com/donghojung/taw/internal/logging"
+)
+
+// Request asks the daemon to run an internal command as a supervised job.
+type Request struct {
+	Job  string   `json:"job"`
+	Args []string `json:"args,omitempty"`
+}
+
+// Response reports whether the daemon accepted a request.
+type Response struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// SocketPath returns the daemon's unix socket path for a session.
+// It lives in the temp dir because project paths can exceed the socket path limit.
+func SocketPath(sessionName string) string {
+	return filepath.Join(os.TempDir(), fmt.Sprintf("%s%d-%s.sock", constants.TmuxSocketPrefix, os.Getuid(), sessionName))
+}
+
+// Server accepts job requests over a unix socket and runs them through a Runner.
+type Server struct {
+	socketPath string
+	runner     *Runner
+	listener   net.Listener
+}
+
+// NewServer creates a new daemon server.
+func NewServer(socketPath string, runner *Runner) *Server {
+	return &Server{
+		socketPath: socketPath,
+		runner:     runner,
+	}
+}
+
+// Listen binds the socket. It fails if another daemon is already serving it.
+func (s *Server) Listen() error {
+	if Ping(s.socketPath) == nil {
+		return fmt.Errorf("daemon already running on %s", s.socketPath)
+	}
+
+	// Remove a stale socket left by a crashed daemon
+	os.Remove(s.socketPath)
+
+	listener, err := net.Listen("unix", s.socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.socketPath, err)
+	}
+	s.listener = listener
+	return nil
+}
+
+// Serve handles connections until Close is called.
+func (s *Server) Serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+// Close stops accepting requests and removes the socket.
+func (s *Server) Close() error {
+	if s.listener == nil {
+		return nil
+	}
+	err := s.listener.Close()
+	os.Remove(s.socketPath)
+	return err
+}
+
+// handle serves a single request.
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(constants.DaemonRequestTimeout))
+
+	var req Request
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&req); err != nil {
+		json.NewEncoder(conn).Encode(Response{Error: fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+
+	resp := Response{OK: true}
+	if req.Job != pingJob {
+		if err := s.runner.Submit(req.Job, req.Args...); err != nil {
+			logging.Warn("Rejected job %s: %v", req.Job, err)
+			resp = Response{Error: err.Error()}
+		}
+	}
+
+	json.NewEncoder(conn).Encode(resp)
+}
@@ -0,0 +1,131 @@
+// Package daemon provides the per-session TAW daemon that owns background work.
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/donghojung/taw/internal/constants"
+	"github.com/donghojung/taw/internal/logging"
+)
+
+// singletonJobs only ever run once at a time. A request while one is running
+// schedules a single rerun so no work is lost.
+var singletonJobs = map[string]bool{
+	"process-queue":  true,
+	"process-outbox": true,
+}
+
+// Runner runs internal commands as supervised child processes: every job is
+// waited on, and failures are logged with their output instead of vanishing.
+// Cancelling the runner's context stops running jobs with SIGTERM.
+type Runner struct {
+	ctx     context.Context
+	bin     string
+	session string
+
+	mu      sync.Mutex
+	running map[string]int  // job name -> running count
+	rerun   map[string]bool // singleton jobs requested while running
+	wg      sync.WaitGroup
+}
+
+// NewRunner creates a runner that invokes "<bin> internal <job> <session> ...".
+func NewRunner(ctx context.Context, bin, session string) *Runner {
+	return &Runner{
+		ctx:     ctx,
+		bin:     bin,
+		session: session,
+		running: make(map[string]int),
+		rerun:   make(map[string]bool),
+	}
+}
+
+// Submit starts a job in the background.
+func (r *Runner) Submit(job string, args ...string) error {
+	if job == "" {
+		return fmt.Errorf("empty job name")
+	}
+
+	r.mu.Lock()
+	if singletonJobs[job] && r.running[job] > 0 {
+		r.rerun[job] = true
+		r.mu.Unlock()
+		logging.Debug("Job %s already running, scheduled rerun", job)
+		return nil
+	}
+	r.running[job]++
+	r.mu.Unlock()
+
+	r.wg.Add(1)
+	go r.run(job, args)
+	return nil
+}
+
+// Running returns the number of jobs currently running.
+func (r *Runner) Running() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	total := 0
+	for _, n := range r.running {
+		total += n
+	}
+	return total
+}
+
+// Wait blocks until all running jobs finish.
+func (r *Runner) Wait() {
+	r.wg.Wait()
+}
+
+// run executes a job and reruns singleton jobs requested in the meantime.
+func (r *Runner) run(job string, args []string) {
+	defer r.wg.Done()
+
+	for {
+		r.exec(job, args)
+
+		r.mu.Lock()
+		if r.rerun[job] && r.ctx.Err() == nil {
+			delete(r.rerun, job)
+			r.mu.Unlock()
+			continue
+		}
+		r.running[job]--
+		r.mu.Unlock()
+		return
+	}
+}
+
+// exec runs a single job to completion and logs its outcome.
+func (r *Runner) exec(job string, args []string) {
+	cmdArgs := append([]string{"internal", job, r.session}, args...)
+	cmd := exec.CommandContext(r.ctx, r.bin, cmdArgs...)
+	// Let the job cancel its own operations before it is killed
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(syscall.SIGTERM)
+	}
+	cmd.WaitDelay = constants.DaemonJobStopTimeout
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	start := time.Now()
+	logging.Debug("Job started: %s %s", job, strings.Join(args, " "))
+
+	if err := cmd.Run(); err != nil {
+		logging.Warn("Job %s failed after %s: %v: %s",
+			job, time.Since(start).Round(time.Millisecond), err, strings.TrimSpace(output.String()))
+		return
+	}
+
+	logging.Debug("Job finished: %s (%s)", job, time.Since(start).Round(time.Millisecond))
+}
@@ -2,8 +2,14 @@
 package embed
 
 import (
+	"crypto/sha256"
 	"embed"
+	"encoding/hex"
+	"fmt"
 	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
 )
 
 //go:embed assets/*
@@ -25,6 +31,35 @@ func GetPrompt(isGitRepo bool) (string, error) {
 	return string(data), nil
 }
 
+// GetSandboxPrompt returns the prompt added for agents running in a sandbox.
+func GetSandboxPrompt() (string, error) {
+	data, err := Assets.ReadFile("assets/PROMPT-sandbox.md")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// GetNativePrompt returns the prompt added for agents run by taw run-one,
+// without tmux.
+func GetNativePrompt() (string, error) {
+	data, err := Assets.ReadFile("assets/PROMPT-native.md")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// GetAskPrompt returns the prompt added for ask tasks, which only answer a
+// question about the codebase.
+func GetAskPrompt() (string, error) {
+	data, err := Assets.ReadFile("assets/PROMPT-ask.md")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
 // GetHelp returns the help content.
 func GetHelp() (string, error) {
 	data, err := Assets.ReadFile("assets/HELP.md")
@@ -36,7 +71,7 @@ func GetHelp() (string, error) {
 
 // GetCommand returns the content of a slash command.
 func GetCommand(name string) (string, error) {
-	data, err := Assets.ReadFile("assets/commands/" + name + ".md")
+	data, err := Assets.ReadFile("assets/claude/commands/" + name + ".md")
 	if err != nil {
 		return "", err
 	}
@@ -45,7 +80,7 @@ func GetCommand(name string) (string, error) {
 
 // ListCommands returns all available slash commands.
 func ListCommands() ([]string, error) {
-	entries, err := Assets.ReadDir("assets/commands")
+	entries, err := Assets.ReadDir("assets/claude/commands")
 	if err != nil {
 		return nil, err
 	}
@@ -73,3 +108,76 @@ func GetAsset(path string) ([]byte, error) {
 func WalkAssets(fn func(path string, d fs.DirEntry, err error) error) error {
 	return fs.WalkDir(Assets, "assets", fn)
 }
+
+// versionFileName records which assets an installation holds.
+const versionFileName = ".version"
+
+// Version identifies the embedded assets. It changes whenever any asset does,
+// so an installation made by an older binary can be detected and refreshed.
+func Version() (string, error) {
+	h := sha256.New()
+	err := WalkAssets(func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := Assets.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", path, len(data))
+		h.Write(data)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12], nil
+}
+
+// Installed returns true if dir holds the assets embedded in this binary.
+func Installed(dir string) bool {
+	version, err := Version()
+	if err != nil {
+		return false
+	}
+	data, err := os.ReadFile(filepath.Join(dir, versionFileName))
+	return err == nil && strings.TrimSpace(string(data)) == version
+}
+
+// Install writes the embedded assets (prompts, help and the claude/ directory
+// with slash commands and settings) into dir, replacing older copies.
+func Install(dir string) error {
+	err := WalkAssets(func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel("assets", path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, rel)
+
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+
+		data, err := Assets.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", target, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	version, err := Version()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, versionFileName), []byte(version+"\n"), 0644)
+}
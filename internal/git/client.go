@@ -4,10 +4,12 @@ package git
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,51 +19,89 @@ import (
 // Client defines the interface for git operations.
 type Client interface {
 	// Repository
-	IsGitRepo(dir string) bool
-	GetRepoRoot(dir string) (string, error)
-	GetMainBranch(dir string) string
+	IsGitRepo(ctx context.Context, dir string) bool
+	GetRepoRoot(ctx context.Context, dir string) (string, error)
+	GetMainBranch(ctx context.Context, dir string) string
+	GetDefaultBranch(ctx context.Context, dir, remote string) string
+	GetUserName(ctx context.Context, dir string) (string, error)
 
 	// Worktree
-	WorktreeAdd(projectDir, worktreeDir, branch string, createBranch bool) error
-	WorktreeRemove(projectDir, worktreeDir string, force bool) error
-	WorktreePrune(projectDir string) error
-	WorktreeList(projectDir string) ([]Worktree, error)
+	WorktreeAdd(ctx context.Context, projectDir, worktreeDir, branch string, createBranch bool) error
+	WorktreeAddDetached(ctx context.Context, projectDir, worktreeDir, commitish string) error
+	WorktreeRemove(ctx context.Context, projectDir, worktreeDir string, force bool) error
+	WorktreePrune(ctx context.Context, projectDir string) error
+	WorktreeList(ctx context.Context, projectDir string) ([]Worktree, error)
 
 	// Branch
-	BranchExists(dir, branch string) bool
-	BranchDelete(dir, branch string, force bool) error
-	BranchMerged(dir, branch, into string) bool
-	BranchCreate(dir, branch, startPoint string) error
-	GetCurrentBranch(dir string) (string, error)
+	BranchExists(ctx context.Context, dir, branch string) bool
+	BranchDelete(ctx context.Context, dir, branch string, force bool) error
+	BranchMerged(ctx context.Context, dir, branch, into string) bool
+	ListBranches(ctx context.Context, dir string) ([]string, error)
+	MergedBranches(ctx context.Context, dir, into string) ([]string, error)
+	BranchCreate(ctx context.Context, dir, branch, startPoint string) error
+	GetCurrentBranch(ctx context.Context, dir string) (string, error)
+
+	// Refs
+	UpdateRef(ctx context.Context, dir, ref, target string) error
+	// UpdateRefFrom points ref at target only if it still points at old.
+	UpdateRefFrom(ctx context.Context, dir, ref, target, old string) error
+	DeleteRef(ctx context.Context, dir, ref string) error
+	RefExists(ctx context.Context, dir, ref string) bool
+	RevParse(ctx context.Context, dir, rev string) (string, error)
+	IsAncestor(ctx context.Context, dir, ancestor, rev string) bool
 
 	// Changes
-	HasChanges(dir string) bool
-	HasUntrackedFiles(dir string) bool
-	GetUntrackedFiles(dir string) ([]string, error)
-	StashCreate(dir string) (string, error)
-	StashApply(dir, stashHash string) error
+	HasChanges(ctx context.Context, dir string) bool
+	HasUntrackedFiles(ctx context.Context, dir string) bool
+	GetUntrackedFiles(ctx context.Context, dir string) ([]string, error)
+	// TrackedFiles returns those of paths (relative to dir) that are tracked.
+	TrackedFiles(ctx context.Context, dir string, paths []string) ([]string, error)
+	// Exclude adds patterns missing from the repository's info/exclude,
+	// which all its worktrees share.
+	Exclude(ctx context.Context, dir string, patterns []string) error
+	StashCreate(ctx context.Context, dir string) (string, error)
+	StashApply(ctx context.Context, dir, stashHash string) error
 
 	// Commit
-	Add(dir, path string) error
-	AddAll(dir string) error
-	Commit(dir, message string) error
-	GetDiffStat(dir string) (string, error)
+	Add(ctx context.Context, dir, path string) error
+	AddAll(ctx context.Context, dir string) error
+	Commit(ctx context.Context, dir, message string) error
+	GetDiffStat(ctx context.Context, dir string) (string, error)
+	GetBranchDiffStat(ctx context.Context, dir, base, branch string) (string, error)
+	GetChangedFiles(ctx context.Context, dir, base, branch string) ([]string, error)
+	// GetDiff returns the diff of the working tree against its merge base with base.
+	GetDiff(ctx context.Context, dir, base string) (string, error)
+	// GetWorkingChangedFiles returns the files of the working tree that differ
+	// from its merge base with base, committed or not, and untracked files.
+	GetWorkingChangedFiles(ctx context.Context, dir, base string) ([]string, error)
+	// GetCommits returns the commits of HEAD that aren't on base, oldest first.
+	GetCommits(ctx context.Context, dir, base string) ([]Commit, error)
+	// LastCommitTime returns when the commit rev points to was committed.
+	LastCommitTime(ctx context.Context, dir, rev string) (time.Time, error)
+	// FormatPatch returns the commits of branch that aren't on base as a
+	// patch series for git am.
+	FormatPatch(ctx context.Context, dir, base, branch string) (string, error)
 
 	// Remote
-	Push(dir, remote, branch string, setUpstream bool) error
-	Fetch(dir, remote string) error
-	Pull(dir string) error
+	Push(ctx context.Context, dir, remote, branch string, setUpstream bool) error
+	GetRemoteURL(ctx context.Context, dir, remote string) (string, error)
+	CanPush(ctx context.Context, dir, remote string) error
+	Fetch(ctx context.Context, dir, remote string) error
+	FetchBranch(ctx context.Context, dir, remote, branch string) error
+	Pull(ctx context.Context, dir string) error
+	PullRebase(ctx context.Context, dir, remote, branch string) error
 
 	// Merge
-	Merge(dir, branch string, noFF bool, message string) error
-	MergeAbort(dir string) error
-	HasConflicts(dir string) (bool, []string, error)
-	CheckoutOurs(dir, path string) error
-	CheckoutTheirs(dir, path string) error
+	Merge(ctx context.Context, dir, branch string, noFF bool, message string) error
+	MergeAbort(ctx context.Context, dir string) error
+	RebaseAbort(ctx context.Context, dir string) error
+	HasConflicts(ctx context.Context, dir string) (bool, []string, error)
+	CheckoutOurs(ctx context.Context, dir, path string) error
+	CheckoutTheirs(ctx context.Context, dir, path string) error
 
 	// Status
-	Status(dir string) (string, error)
-	Checkout(dir, target string) error
+	Status(ctx context.Context, dir string) (string, error)
+	Checkout(ctx context.Context, dir, target string) error
 }
 
 // Worktree represents a git worktree.
@@ -71,16 +111,51 @@ type Worktree struct {
 	Head   string
 }
 
+// Commit is a commit with its message.
+type Commit struct {
+	Hash    string
+	Subject string
+	Body    string
+}
+
 // gitClient implements the Client interface.
 type gitClient struct {
-	timeout time.Duration
+	timeout        time.Duration // Local commands
+	networkTimeout time.Duration // Commands talking to a remote
+	env            []string      // Added to the environment of every command
 }
 
-// New creates a new git client.
+// New creates a new git client with the default timeouts.
 func New() Client {
+	return NewWithTimeouts(0, 0)
+}
+
+// NewWithTimeouts creates a new git client that runs the git binary, or
+// go-git for queries if git isn't installed. Non-positive timeouts use the defaults.
+func NewWithTimeouts(timeout, networkTimeout time.Duration) Client {
+	return NewWithBackend(BackendAuto, timeout, networkTimeout, nil)
+}
+
+// newExecClient creates a client that runs the git binary for everything.
+func newExecClient(timeout, networkTimeout time.Duration) *gitClient {
+	if timeout <= 0 {
+		timeout = constants.DefaultGitTimeout
+	}
+	if networkTimeout <= 0 {
+		networkTimeout = constants.DefaultNetworkTimeout
+	}
 	return &gitClient{
-		timeout: constants.WorktreeTimeout,
+		timeout:        timeout,
+		networkTimeout: networkTimeout,
+	}
+}
+
+// withTimeout applies the given timeout unless the caller already set a deadline.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
 	}
+	return context.WithTimeout(ctx, timeout)
 }
 
 func (c *gitClient) cmd(ctx context.Context, dir string, args ...string) *exec.Cmd {
@@ -88,25 +163,41 @@ func (c *gitClient) cmd(ctx context.Context, dir string, args ...string) *exec.C
 	if dir != "" {
 		cmd.Dir = dir
 	}
+	if len(c.env) > 0 {
+		cmd.Env = append(os.Environ(), c.env...)
+	}
 	return cmd
 }
 
-func (c *gitClient) run(dir string, args ...string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+func (c *gitClient) run(ctx context.Context, dir string, args ...string) error {
+	ctx, cancel := withTimeout(ctx, c.timeout)
 	defer cancel()
 
+	return c.runCmd(ctx, dir, args...)
+}
+
+// runNetwork runs a command that talks to a remote, with the network timeout.
+func (c *gitClient) runNetwork(ctx context.Context, dir string, args ...string) error {
+	ctx, cancel := withTimeout(ctx, c.networkTimeout)
+	defer cancel()
+
+	return c.runCmd(ctx, dir, args...)
+}
+
+// runCmd runs a command under an already bounded context.
+func (c *gitClient) runCmd(ctx context.Context, dir string, args ...string) error {
 	cmd := c.cmd(ctx, dir, args...)
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("%w: %s", err, stderr.String())
+		return wrapError(ctx, err, stderr.String())
 	}
 	return nil
 }
 
-func (c *gitClient) runOutput(dir string, args ...string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+func (c *gitClient) runOutput(ctx context.Context, dir string, args ...string) (string, error) {
+	ctx, cancel := withTimeout(ctx, c.timeout)
 	defer cancel()
 
 	cmd := c.cmd(ctx, dir, args...)
@@ -115,25 +206,33 @@ func (c *gitClient) runOutput(dir string, args ...string) (string, error) {
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("%w: %s", err, stderr.String())
+		return "", wrapError(ctx, err, stderr.String())
 	}
 	return strings.TrimSpace(stdout.String()), nil
 }
 
 // Repository
 
-func (c *gitClient) IsGitRepo(dir string) bool {
-	_, err := c.runOutput(dir, "rev-parse", "--git-dir")
+func (c *gitClient) IsGitRepo(ctx context.Context, dir string) bool {
+	_, err := c.runOutput(ctx, dir, "rev-parse", "--git-dir")
 	return err == nil
 }
 
-func (c *gitClient) GetRepoRoot(dir string) (string, error) {
-	return c.runOutput(dir, "rev-parse", "--show-toplevel")
+func (c *gitClient) GetRepoRoot(ctx context.Context, dir string) (string, error) {
+	return c.runOutput(ctx, dir, "rev-parse", "--show-toplevel")
 }
 
-func (c *gitClient) GetMainBranch(dir string) string {
-	// Try to get from origin/HEAD
-	output, err := c.runOutput(dir, "symbolic-ref", "refs/remotes/origin/HEAD", "--short")
+func (c *gitClient) GetUserName(ctx context.Context, dir string) (string, error) {
+	return c.runOutput(ctx, dir, "config", "user.name")
+}
+
+func (c *gitClient) GetMainBranch(ctx context.Context, dir string) string {
+	return c.GetDefaultBranch(ctx, dir, constants.DefaultRemote)
+}
+
+func (c *gitClient) GetDefaultBranch(ctx context.Context, dir, remote string) string {
+	// Try to get from <remote>/HEAD
+	output, err := c.runOutput(ctx, dir, "symbolic-ref", fmt.Sprintf("refs/remotes/%s/HEAD", remote), "--short")
 	if err == nil {
 		parts := strings.Split(output, "/")
 		if len(parts) > 0 {
@@ -142,12 +241,12 @@ func (c *gitClient) GetMainBranch(dir string) string {
 	}
 
 	// Check if main exists
-	if c.BranchExists(dir, "main") {
+	if c.BranchExists(ctx, dir, "main") {
 		return "main"
 	}
 
 	// Check if master exists
-	if c.BranchExists(dir, "master") {
+	if c.BranchExists(ctx, dir, "master") {
 		return "master"
 	}
 
@@ -156,7 +255,7 @@ func (c *gitClient) GetMainBranch(dir string) string {
 
 // Worktree
 
-func (c *gitClient) WorktreeAdd(projectDir, worktreeDir, branch string, createBranch bool) error {
+func (c *gitClient) WorktreeAdd(ctx context.Context, projectDir, worktreeDir, branch string, createBranch bool) error {
 	args := []string{"worktree", "add"}
 	if createBranch {
 		args = append(args, "-b", branch)
@@ -165,24 +264,28 @@ func (c *gitClient) WorktreeAdd(projectDir, worktreeDir, branch string, createBr
 	if !createBranch {
 		args = append(args, branch)
 	}
-	return c.run(projectDir, args...)
+	return c.run(ctx, projectDir, args...)
 }
 
-func (c *gitClient) WorktreeRemove(projectDir, worktreeDir string, force bool) error {
+func (c *gitClient) WorktreeAddDetached(ctx context.Context, projectDir, worktreeDir, commitish string) error {
+	return c.run(ctx, projectDir, "worktree", "add", "--detach", worktreeDir, commitish)
+}
+
+func (c *gitClient) WorktreeRemove(ctx context.Context, projectDir, worktreeDir string, force bool) error {
 	args := []string{"worktree", "remove"}
 	if force {
 		args = append(args, "--force")
 	}
 	args = append(args, worktreeDir)
-	return c.run(projectDir, args...)
+	return c.run(ctx, projectDir, args...)
 }
 
-func (c *gitClient) WorktreePrune(projectDir string) error {
-	return c.run(projectDir, "worktree", "prune")
+func (c *gitClient) WorktreePrune(ctx context.Context, projectDir string) error {
+	return c.run(ctx, projectDir, "worktree", "prune")
 }
 
-func (c *gitClient) WorktreeList(projectDir string) ([]Worktree, error) {
-	output, err := c.runOutput(projectDir, "worktree", "list", "--porcelain")
+func (c *gitClient) WorktreeList(ctx context.Context, projectDir string) ([]Worktree, error) {
+	output, err := c.runOutput(ctx, projectDir, "worktree", "list", "--porcelain")
 	if err != nil {
 		return nil, err
 	}
@@ -219,21 +322,21 @@ func (c *gitClient) WorktreeList(projectDir string) ([]Worktree, error) {
 
 // Branch
 
-func (c *gitClient) BranchExists(dir, branch string) bool {
-	err := c.run(dir, "rev-parse", "--verify", fmt.Sprintf("refs/heads/%s", branch))
+func (c *gitClient) BranchExists(ctx context.Context, dir, branch string) bool {
+	err := c.run(ctx, dir, "rev-parse", "--verify", fmt.Sprintf("refs/heads/%s", branch))
 	return err == nil
 }
 
-func (c *gitClient) BranchDelete(dir, branch string, force bool) error {
+func (c *gitClient) BranchDelete(ctx context.Context, dir, branch string, force bool) error {
 	flag := "-d"
 	if force {
 		flag = "-D"
 	}
-	return c.run(dir, "branch", flag, branch)
+	return c.run(ctx, dir, "branch", flag, branch)
 }
 
-func (c *gitClient) BranchMerged(dir, branch, into string) bool {
-	output, err := c.runOutput(dir, "branch", "--merged", into)
+func (c *gitClient) BranchMerged(ctx context.Context, dir, branch, into string) bool {
+	output, err := c.runOutput(ctx, dir, "branch", "--merged", into)
 	if err != nil {
 		return false
 	}
@@ -247,38 +350,101 @@ func (c *gitClient) BranchMerged(dir, branch, into string) bool {
 	return false
 }
 
-func (c *gitClient) BranchCreate(dir, branch, startPoint string) error {
+func (c *gitClient) ListBranches(ctx context.Context, dir string) ([]string, error) {
+	output, err := c.runOutput(ctx, dir, "for-each-ref", "--format=%(refname:short)", "refs/heads")
+	if err != nil {
+		return nil, err
+	}
+	return splitLines(output), nil
+}
+
+func (c *gitClient) MergedBranches(ctx context.Context, dir, into string) ([]string, error) {
+	output, err := c.runOutput(ctx, dir, "for-each-ref", "--format=%(refname:short)", "--merged", into, "refs/heads")
+	if err != nil {
+		return nil, err
+	}
+	return splitLines(output), nil
+}
+
+func (c *gitClient) BranchCreate(ctx context.Context, dir, branch, startPoint string) error {
 	args := []string{"branch", branch}
 	if startPoint != "" {
 		args = append(args, startPoint)
 	}
-	return c.run(dir, args...)
+	return c.run(ctx, dir, args...)
+}
+
+func (c *gitClient) GetCurrentBranch(ctx context.Context, dir string) (string, error) {
+	return c.runOutput(ctx, dir, "rev-parse", "--abbrev-ref", "HEAD")
 }
 
-func (c *gitClient) GetCurrentBranch(dir string) (string, error) {
-	return c.runOutput(dir, "rev-parse", "--abbrev-ref", "HEAD")
+// Refs
+
+// UpdateRef points ref (e.g. refs/taw/trash/<task>) at target, which may be
+// a branch, another ref or a commit.
+func (c *gitClient) UpdateRef(ctx context.Context, dir, ref, target string) error {
+	return c.run(ctx, dir, "update-ref", ref, target)
+}
+
+func (c *gitClient) UpdateRefFrom(ctx context.Context, dir, ref, target, old string) error {
+	return c.run(ctx, dir, "update-ref", ref, target, old)
+}
+
+func (c *gitClient) DeleteRef(ctx context.Context, dir, ref string) error {
+	return c.run(ctx, dir, "update-ref", "-d", ref)
+}
+
+func (c *gitClient) RefExists(ctx context.Context, dir, ref string) bool {
+	return c.run(ctx, dir, "rev-parse", "--verify", "--quiet", ref) == nil
+}
+
+// RevParse returns the commit hash rev (e.g. HEAD or a branch) resolves to in
+// dir, following packed refs and linked worktrees like git does.
+func (c *gitClient) RevParse(ctx context.Context, dir, rev string) (string, error) {
+	return c.runOutput(ctx, dir, "rev-parse", "--verify", rev+"^{commit}")
+}
+
+// IsAncestor returns true if ancestor is reachable from rev.
+func (c *gitClient) IsAncestor(ctx context.Context, dir, ancestor, rev string) bool {
+	return c.run(ctx, dir, "merge-base", "--is-ancestor", ancestor, rev) == nil
 }
 
 // Changes
 
-func (c *gitClient) HasChanges(dir string) bool {
-	output, err := c.runOutput(dir, "status", "--porcelain")
+func (c *gitClient) HasChanges(ctx context.Context, dir string) bool {
+	output, err := c.runOutput(ctx, dir, "status", "--porcelain")
 	if err != nil {
 		return false
 	}
 	return strings.TrimSpace(output) != ""
 }
 
-func (c *gitClient) HasUntrackedFiles(dir string) bool {
-	output, err := c.runOutput(dir, "ls-files", "--others", "--exclude-standard")
+func (c *gitClient) HasUntrackedFiles(ctx context.Context, dir string) bool {
+	output, err := c.runOutput(ctx, dir, "ls-files", "--others", "--exclude-standard")
 	if err != nil {
 		return false
 	}
 	return strings.TrimSpace(output) != ""
 }
 
-func (c *gitClient) GetUntrackedFiles(dir string) ([]string, error) {
-	output, err := c.runOutput(dir, "ls-files", "--others", "--exclude-standard")
+func (c *gitClient) GetUntrackedFiles(ctx context.Context, dir string) ([]string, error) {
+	output, err := c.runOutput(ctx, dir, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+
+	if output == "" {
+		return nil, nil
+	}
+
+	return strings.Split(output, "\n"), nil
+}
+
+func (c *gitClient) TrackedFiles(ctx context.Context, dir string, paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	output, err := c.runOutput(ctx, dir, append([]string{"ls-files", "--"}, paths...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -290,54 +456,185 @@ func (c *gitClient) GetUntrackedFiles(dir string) ([]string, error) {
 	return strings.Split(output, "\n"), nil
 }
 
-func (c *gitClient) StashCreate(dir string) (string, error) {
-	return c.runOutput(dir, "stash", "create")
+func (c *gitClient) Exclude(ctx context.Context, dir string, patterns []string) error {
+	path, err := c.runOutput(ctx, dir, "rev-parse", "--git-path", "info/exclude")
+	if err != nil {
+		return err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	existing := make(map[string]bool)
+	for _, line := range strings.Split(string(data), "\n") {
+		existing[strings.TrimSpace(line)] = true
+	}
+
+	var missing []string
+	for _, pattern := range patterns {
+		if !existing[pattern] {
+			existing[pattern] = true
+			missing = append(missing, pattern)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	content := string(data)
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	content += strings.Join(missing, "\n") + "\n"
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *gitClient) StashCreate(ctx context.Context, dir string) (string, error) {
+	return c.runOutput(ctx, dir, "stash", "create")
 }
 
-func (c *gitClient) StashApply(dir, stashHash string) error {
-	return c.run(dir, "stash", "apply", stashHash)
+func (c *gitClient) StashApply(ctx context.Context, dir, stashHash string) error {
+	return c.run(ctx, dir, "stash", "apply", stashHash)
 }
 
 // Commit
 
-func (c *gitClient) Add(dir, path string) error {
-	return c.run(dir, "add", path)
+func (c *gitClient) Add(ctx context.Context, dir, path string) error {
+	return c.run(ctx, dir, "add", path)
+}
+
+func (c *gitClient) AddAll(ctx context.Context, dir string) error {
+	return c.run(ctx, dir, "add", "-A")
+}
+
+func (c *gitClient) Commit(ctx context.Context, dir, message string) error {
+	return c.run(ctx, dir, "commit", "-m", message)
+}
+
+func (c *gitClient) GetDiffStat(ctx context.Context, dir string) (string, error) {
+	return c.runOutput(ctx, dir, "diff", "--cached", "--stat")
+}
+
+func (c *gitClient) GetBranchDiffStat(ctx context.Context, dir, base, branch string) (string, error) {
+	return c.runOutput(ctx, dir, "diff", "--shortstat", base+"..."+branch)
+}
+
+func (c *gitClient) GetChangedFiles(ctx context.Context, dir, base, branch string) ([]string, error) {
+	output, err := c.runOutput(ctx, dir, "diff", "--name-only", base+"..."+branch)
+	if err != nil {
+		return nil, err
+	}
+	if output == "" {
+		return nil, nil
+	}
+	return strings.Split(output, "\n"), nil
+}
+
+func (c *gitClient) GetDiff(ctx context.Context, dir, base string) (string, error) {
+	return c.runOutput(ctx, dir, "diff", "--no-color", "--no-ext-diff", "--merge-base", base)
+}
+
+func (c *gitClient) GetWorkingChangedFiles(ctx context.Context, dir, base string) ([]string, error) {
+	output, err := c.runOutput(ctx, dir, "diff", "--name-only", "--merge-base", base)
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := c.GetUntrackedFiles(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	return append(splitLines(output), untracked...), nil
 }
 
-func (c *gitClient) AddAll(dir string) error {
-	return c.run(dir, "add", "-A")
+func (c *gitClient) GetCommits(ctx context.Context, dir, base string) ([]Commit, error) {
+	// Fields are separated by NUL and commits by RS, which messages don't contain
+	output, err := c.runOutput(ctx, dir, "log", "--reverse", "--no-merges", "--format=%H%x00%s%x00%b%x1e", base+"..HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, record := range strings.Split(output, "\x1e") {
+		fields := strings.SplitN(strings.TrimSpace(record), "\x00", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		commits = append(commits, Commit{Hash: fields[0], Subject: fields[1], Body: strings.TrimSpace(fields[2])})
+	}
+	return commits, nil
 }
 
-func (c *gitClient) Commit(dir, message string) error {
-	return c.run(dir, "commit", "-m", message)
+func (c *gitClient) LastCommitTime(ctx context.Context, dir, rev string) (time.Time, error) {
+	output, err := c.runOutput(ctx, dir, "log", "-1", "--format=%ct", rev)
+	if err != nil {
+		return time.Time{}, err
+	}
+	seconds, err := strconv.ParseInt(strings.TrimSpace(output), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unexpected commit time %q: %w", output, err)
+	}
+	return time.Unix(seconds, 0), nil
 }
 
-func (c *gitClient) GetDiffStat(dir string) (string, error) {
-	return c.runOutput(dir, "diff", "--cached", "--stat")
+func (c *gitClient) FormatPatch(ctx context.Context, dir, base, branch string) (string, error) {
+	output, err := c.runOutput(ctx, dir, "format-patch", "--stdout", base+".."+branch)
+	if err != nil {
+		return "", err
+	}
+	return output + "\n", nil
 }
 
 // Remote
 
-func (c *gitClient) Push(dir, remote, branch string, setUpstream bool) error {
+func (c *gitClient) Push(ctx context.Context, dir, remote, branch string, setUpstream bool) error {
 	args := []string{"push"}
 	if setUpstream {
 		args = append(args, "-u")
 	}
 	args = append(args, remote, branch)
-	return c.run(dir, args...)
+	return c.runNetwork(ctx, dir, args...)
+}
+
+func (c *gitClient) GetRemoteURL(ctx context.Context, dir, remote string) (string, error) {
+	return c.runOutput(ctx, dir, "remote", "get-url", remote)
+}
+
+func (c *gitClient) CanPush(ctx context.Context, dir, remote string) error {
+	// Dry-run to a scratch ref: exercises auth and connectivity without touching the remote
+	return c.runNetwork(ctx, dir, "push", "--dry-run", "--no-verify", remote, "HEAD:refs/heads/taw-preflight")
+}
+
+func (c *gitClient) Fetch(ctx context.Context, dir, remote string) error {
+	return c.runNetwork(ctx, dir, "fetch", remote)
+}
+
+func (c *gitClient) FetchBranch(ctx context.Context, dir, remote, branch string) error {
+	// Fast-forward only; git refuses to update a branch checked out in any worktree
+	return c.runNetwork(ctx, dir, "fetch", remote, fmt.Sprintf("%s:%s", branch, branch))
 }
 
-func (c *gitClient) Fetch(dir, remote string) error {
-	return c.run(dir, "fetch", remote)
+func (c *gitClient) Pull(ctx context.Context, dir string) error {
+	return c.runNetwork(ctx, dir, "pull")
 }
 
-func (c *gitClient) Pull(dir string) error {
-	return c.run(dir, "pull")
+func (c *gitClient) PullRebase(ctx context.Context, dir, remote, branch string) error {
+	return c.runNetwork(ctx, dir, "pull", "--rebase", remote, branch)
 }
 
 // Merge
 
-func (c *gitClient) Merge(dir, branch string, noFF bool, message string) error {
+func (c *gitClient) Merge(ctx context.Context, dir, branch string, noFF bool, message string) error {
 	args := []string{"merge"}
 	if noFF {
 		args = append(args, "--no-ff")
@@ -346,15 +643,26 @@ func (c *gitClient) Merge(dir, branch string, noFF bool, message string) error {
 		args = append(args, "-m", message)
 	}
 	args = append(args, branch)
-	return c.run(dir, args...)
+	if err := c.run(ctx, dir, args...); err != nil {
+		// git reports conflicts on stdout, so check the index instead
+		if conflicted, _, _ := c.HasConflicts(ctx, dir); conflicted && !errors.Is(err, ErrMergeConflict) {
+			return fmt.Errorf("%w: %w", ErrMergeConflict, err)
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *gitClient) MergeAbort(ctx context.Context, dir string) error {
+	return c.run(ctx, dir, "merge", "--abort")
 }
 
-func (c *gitClient) MergeAbort(dir string) error {
-	return c.run(dir, "merge", "--abort")
+func (c *gitClient) RebaseAbort(ctx context.Context, dir string) error {
+	return c.run(ctx, dir, "rebase", "--abort")
 }
 
-func (c *gitClient) HasConflicts(dir string) (bool, []string, error) {
-	output, err := c.runOutput(dir, "diff", "--name-only", "--diff-filter=U")
+func (c *gitClient) HasConflicts(ctx context.Context, dir string) (bool, []string, error) {
+	output, err := c.runOutput(ctx, dir, "diff", "--name-only", "--diff-filter=U")
 	if err != nil {
 		return false, nil, err
 	}
@@ -367,22 +675,30 @@ func (c *gitClient) HasConflicts(dir string) (bool, []string, error) {
 	return true, files, nil
 }
 
-func (c *gitClient) CheckoutOurs(dir, path string) error {
-	return c.run(dir, "checkout", "--ours", path)
+func (c *gitClient) CheckoutOurs(ctx context.Context, dir, path string) error {
+	return c.run(ctx, dir, "checkout", "--ours", path)
 }
 
-func (c *gitClient) CheckoutTheirs(dir, path string) error {
-	return c.run(dir, "checkout", "--theirs", path)
+func (c *gitClient) CheckoutTheirs(ctx context.Context, dir, path string) error {
+	return c.run(ctx, dir, "checkout", "--theirs", path)
 }
 
 // Status
 
-func (c *gitClient) Status(dir string) (string, error) {
-	return c.runOutput(dir, "status", "-s")
+func (c *gitClient) Status(ctx context.Context, dir string) (string, error) {
+	return c.runOutput(ctx, dir, "status", "-s")
 }
 
-func (c *gitClient) Checkout(dir, target string) error {
-	return c.run(dir, "checkout", target)
+func (c *gitClient) Checkout(ctx context.Context, dir, target string) error {
+	return c.run(ctx, dir, "checkout", target)
+}
+
+// splitLines splits command output into lines, returning nil for empty output.
+func splitLines(output string) []string {
+	if output == "" {
+		return nil
+	}
+	return strings.Split(output, "\n")
 }
 
 // CopyUntrackedFiles copies untracked files from source to destination.
@@ -415,3 +731,26 @@ func CopyUntrackedFiles(files []string, srcDir, dstDir string) error {
 	}
 	return nil
 }
+
+// ParseShortStat parses "git diff --shortstat" output into its counts.
+func ParseShortStat(stat string) (files, insertions, deletions int) {
+	for _, part := range strings.Split(stat, ",") {
+		fields := strings.Fields(part)
+		if len(fields) < 2 {
+			continue
+		}
+		n, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(fields[1], "file"):
+			files = n
+		case strings.HasPrefix(fields[1], "insertion"):
+			insertions = n
+		case strings.HasPrefix(fields[1], "deletion"):
+			deletions = n
+		}
+	}
+	return files, insertions, deletions
+}
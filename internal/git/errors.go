@@ -0,0 +1,143 @@
+// Package git provides an interface for git operations.
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/donghojung/taw/internal/icon"
+)
+
+// Recognized git failures. Commands wrap them so callers can match with
+// errors.Is and show ErrorHint instead of git's raw stderr.
+var (
+	ErrNotRepository  = errors.New("not a git repository")
+	ErrNoRemote       = errors.New("remote not configured")
+	ErrMergeConflict  = errors.New("merge conflict")
+	ErrWorktreeLocked = errors.New("worktree is locked")
+	ErrBranchInUse    = errors.New("branch is checked out in another worktree")
+	ErrIndexLocked    = errors.New("index is locked by another git process")
+)
+
+// classifyError returns the recognized failure for git's stderr, or nil.
+func classifyError(stderr string) error {
+	msg := strings.ToLower(stderr)
+	switch {
+	case strings.Contains(msg, "index.lock"):
+		return ErrIndexLocked
+	case strings.Contains(msg, "not a git repository (or any"):
+		return ErrNotRepository
+	case containsAny(msg, "no such remote", "no configured push destination",
+		"does not appear to be a git repository"):
+		return ErrNoRemote
+	case containsAny(msg, "conflict", "automatic merge failed", "could not apply"):
+		return ErrMergeConflict
+	case containsAny(msg, "is locked", "locked working tree"):
+		return ErrWorktreeLocked
+	case containsAny(msg, "already checked out at", "is already used by worktree"):
+		return ErrBranchInUse
+	default:
+		return nil
+	}
+}
+
+// wrapError wraps a failed command's error with its stderr and, when
+// recognized, the matching sentinel or the context error that stopped it.
+func wrapError(ctx context.Context, err error, stderr string) error {
+	kind := classifyError(stderr)
+	if stderr = strings.TrimSpace(stderr); stderr != "" {
+		err = fmt.Errorf("%w: %s", err, stderr)
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return fmt.Errorf("%w: %w", ctxErr, err)
+	}
+	if kind != nil {
+		return fmt.Errorf("%w: %w", kind, err)
+	}
+	return err
+}
+
+// ErrorHint returns a user-facing remediation hint for a recognized git
+// failure, or "" if the error isn't one.
+func ErrorHint(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrNotRepository):
+		return "Not inside a git repository - run taw from your project or 'git init' it first"
+	case errors.Is(err, ErrNoRemote):
+		return "Remote not found - add it with 'git remote add' or fix push_remote/upstream_remote in .taw/config"
+	case errors.Is(err, ErrMergeConflict):
+		return "Task conflicts with main - rebase the task branch onto main, resolve the conflicts and end the task again"
+	case errors.Is(err, ErrWorktreeLocked):
+		return "Worktree is locked - run 'git worktree unlock <path>' and retry"
+	case errors.Is(err, ErrBranchInUse):
+		return "Branch is checked out in another worktree - switch that checkout to another branch and retry"
+	case errors.Is(err, ErrIndexLocked):
+		return "Another git process is running - wait for it, or remove a stale .git/index.lock"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "Git timed out - retry, or raise timeouts.git/timeouts.network in .taw/config"
+	default:
+		return ""
+	}
+}
+
+// PushErrorKind classifies why a push failed.
+type PushErrorKind string
+
+const (
+	PushErrorNonFastForward PushErrorKind = "non-fast-forward" // Remote has commits we don't have
+	PushErrorAuth           PushErrorKind = "auth"             // Credentials missing or rejected
+	PushErrorNetwork        PushErrorKind = "network"          // Remote unreachable
+	PushErrorUnknown        PushErrorKind = "unknown"          // Anything else
+)
+
+// ClassifyPushError inspects a push error (including git's stderr) and returns its kind.
+func ClassifyPushError(err error) PushErrorKind {
+	if err == nil {
+		return ""
+	}
+
+	msg := strings.ToLower(err.Error())
+	switch {
+	case containsAny(msg, "non-fast-forward", "fetch first", "[rejected]", "updates were rejected"):
+		return PushErrorNonFastForward
+	case containsAny(msg, "authentication failed", "permission denied", "could not read username",
+		"invalid username or password", "publickey",
+		// A 403 as git reports it, e.g. "The requested URL returned error: 403",
+		// not any 403 in a branch name or hash
+		"error: 403", "http 403", "403 forbidden"):
+		return PushErrorAuth
+	case containsAny(msg, "could not resolve host", "connection refused", "connection timed out",
+		"network is unreachable", "could not read from remote repository", "operation timed out"):
+		return PushErrorNetwork
+	default:
+		return PushErrorUnknown
+	}
+}
+
+// PushErrorHint returns a user-facing remediation hint for a push error kind.
+func PushErrorHint(kind PushErrorKind) string {
+	switch kind {
+	case PushErrorNonFastForward:
+		return "Remote branch has diverged - rebase onto the remote branch and push again"
+	case PushErrorAuth:
+		return "Git credentials were rejected - check 'gh auth status' or your SSH keys"
+	case PushErrorNetwork:
+		return "Remote is unreachable - check your network connection and retry"
+	default:
+		return "Push failed - see the log (" + icon.Key("l") + ") for details"
+	}
+}
+
+// containsAny returns true if s contains any of the substrings.
+func containsAny(s string, substrs ...string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
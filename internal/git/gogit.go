@@ -0,0 +1,412 @@
+// Package git provides an interface for git operations.
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-billy/v5/osfs"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+
+	"github.com/donghojung/taw/internal/constants"
+)
+
+// Backend selects how read-heavy operations are performed.
+type Backend string
+
+const (
+	BackendAuto  Backend = "auto"   // go-git only when the git binary is missing
+	BackendExec  Backend = "exec"   // Always run the git binary
+	BackendGoGit Backend = "go-git" // Read the repository in-process with go-git
+)
+
+// NewWithBackend creates a new git client using the given backend for status,
+// branch, merged and worktree queries. Everything else (worktree creation,
+// merges, pushes) always runs the git binary, with env (NAME=value entries)
+// added to its environment. Non-positive timeouts use the defaults.
+func NewWithBackend(backend Backend, timeout, networkTimeout time.Duration, env []string) Client {
+	c := newExecClient(timeout, networkTimeout)
+	c.env = env
+
+	switch backend {
+	case BackendExec:
+		return c
+	case BackendGoGit:
+		return &goGitClient{Client: c}
+	default:
+		if _, err := exec.LookPath("git"); err != nil {
+			return &goGitClient{Client: c}
+		}
+		return c
+	}
+}
+
+// goGitClient answers read-heavy queries in-process with go-git, without
+// forking a git process per call. Other operations fall through to the
+// embedded exec client.
+type goGitClient struct {
+	Client
+}
+
+// open opens the repository containing dir, including linked worktrees.
+func (c *goGitClient) open(dir string) (*gogit.Repository, error) {
+	return gogit.PlainOpenWithOptions(dir, &gogit.PlainOpenOptions{
+		DetectDotGit:          true,
+		EnableDotGitCommonDir: true,
+	})
+}
+
+// Repository
+
+func (c *goGitClient) IsGitRepo(ctx context.Context, dir string) bool {
+	_, err := c.open(dir)
+	return err == nil
+}
+
+func (c *goGitClient) GetRepoRoot(ctx context.Context, dir string) (string, error) {
+	repo, err := c.open(dir)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrNotRepository, err)
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		return "", err
+	}
+	return wt.Filesystem.Root(), nil
+}
+
+func (c *goGitClient) GetMainBranch(ctx context.Context, dir string) string {
+	return c.GetDefaultBranch(ctx, dir, constants.DefaultRemote)
+}
+
+func (c *goGitClient) GetDefaultBranch(ctx context.Context, dir, remote string) string {
+	repo, err := c.open(dir)
+	if err != nil {
+		return c.Client.GetDefaultBranch(ctx, dir, remote)
+	}
+
+	// Try to get from <remote>/HEAD
+	ref, err := repo.Reference(plumbing.NewRemoteHEADReferenceName(remote), false)
+	if err == nil && ref.Type() == plumbing.SymbolicReference {
+		parts := strings.Split(ref.Target().Short(), "/")
+		return parts[len(parts)-1]
+	}
+
+	for _, branch := range []string{"main", "master"} {
+		if _, err := repo.Reference(plumbing.NewBranchReferenceName(branch), false); err == nil {
+			return branch
+		}
+	}
+	return constants.DefaultMainBranch
+}
+
+// Worktree
+
+// WorktreeList reads the worktrees from the common git directory, the same
+// records `git worktree list` reports. The main worktree comes first.
+func (c *goGitClient) WorktreeList(ctx context.Context, projectDir string) ([]Worktree, error) {
+	repo, err := c.open(projectDir)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotRepository, err)
+	}
+
+	commonDir, err := commonGitDir(projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	main := Worktree{Path: filepath.Dir(commonDir)}
+	if head, err := os.ReadFile(filepath.Join(commonDir, "HEAD")); err == nil {
+		main.Branch, main.Head = resolveHead(repo, string(head))
+	}
+	worktrees := []Worktree{main}
+
+	entries, err := os.ReadDir(filepath.Join(commonDir, "worktrees"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return worktrees, nil
+		}
+		return nil, err
+	}
+
+	for _, e := range entries {
+		adminDir := filepath.Join(commonDir, "worktrees", e.Name())
+		gitdir, err := os.ReadFile(filepath.Join(adminDir, "gitdir"))
+		if err != nil {
+			continue
+		}
+
+		wt := Worktree{Path: filepath.Dir(strings.TrimSpace(string(gitdir)))}
+		if head, err := os.ReadFile(filepath.Join(adminDir, "HEAD")); err == nil {
+			wt.Branch, wt.Head = resolveHead(repo, string(head))
+		}
+		worktrees = append(worktrees, wt)
+	}
+
+	return worktrees, nil
+}
+
+// commonGitDir returns the git directory shared by all worktrees of dir's repository.
+func commonGitDir(dir string) (string, error) {
+	for d := dir; ; d = filepath.Dir(d) {
+		dotGit := filepath.Join(d, ".git")
+		info, err := os.Stat(dotGit)
+		if err == nil {
+			if info.IsDir() {
+				return filepath.Abs(dotGit)
+			}
+			return linkedCommonDir(dotGit)
+		}
+
+		if parent := filepath.Dir(d); parent == d {
+			return "", ErrNotRepository
+		}
+	}
+}
+
+// linkedCommonDir follows a linked worktree's .git file to the common git directory.
+func linkedCommonDir(dotGitFile string) (string, error) {
+	data, err := os.ReadFile(dotGitFile)
+	if err != nil {
+		return "", err
+	}
+
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+	if !ok {
+		return "", fmt.Errorf("%w: malformed %s", ErrNotRepository, dotGitFile)
+	}
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(dotGitFile), gitDir)
+	}
+
+	common, err := os.ReadFile(filepath.Join(gitDir, "commondir"))
+	if err != nil {
+		return filepath.Abs(gitDir)
+	}
+	commonDir := strings.TrimSpace(string(common))
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(gitDir, commonDir)
+	}
+	return filepath.Abs(commonDir)
+}
+
+// resolveHead turns the contents of a HEAD file into a branch name (empty if
+// detached) and the commit it points at.
+func resolveHead(repo *gogit.Repository, head string) (string, string) {
+	head = strings.TrimSpace(head)
+	target, ok := strings.CutPrefix(head, "ref: ")
+	if !ok {
+		return "", head
+	}
+
+	name := plumbing.ReferenceName(target)
+	ref, err := repo.Reference(name, true)
+	if err != nil {
+		// Unborn branch
+		return name.Short(), ""
+	}
+	return name.Short(), ref.Hash().String()
+}
+
+// Branch
+
+func (c *goGitClient) BranchExists(ctx context.Context, dir, branch string) bool {
+	repo, err := c.open(dir)
+	if err != nil {
+		return false
+	}
+	_, err = repo.Reference(plumbing.NewBranchReferenceName(branch), false)
+	return err == nil
+}
+
+func (c *goGitClient) BranchMerged(ctx context.Context, dir, branch, into string) bool {
+	repo, err := c.open(dir)
+	if err != nil {
+		return false
+	}
+
+	branchHash, err := repo.ResolveRevision(plumbing.Revision(branch))
+	if err != nil {
+		return false
+	}
+	intoHash, err := repo.ResolveRevision(plumbing.Revision(into))
+	if err != nil {
+		return false
+	}
+	if *branchHash == *intoHash {
+		return true
+	}
+
+	branchCommit, err := repo.CommitObject(*branchHash)
+	if err != nil {
+		return false
+	}
+	intoCommit, err := repo.CommitObject(*intoHash)
+	if err != nil {
+		return false
+	}
+
+	merged, err := branchCommit.IsAncestor(intoCommit)
+	return err == nil && merged
+}
+
+func (c *goGitClient) ListBranches(ctx context.Context, dir string) ([]string, error) {
+	repo, err := c.open(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotRepository, err)
+	}
+
+	refs, err := repo.Branches()
+	if err != nil {
+		return nil, err
+	}
+	defer refs.Close()
+
+	var branches []string
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		branches = append(branches, ref.Name().Short())
+		return nil
+	})
+	return branches, err
+}
+
+// MergedBranches walks the history of into once, stopping as soon as every
+// branch tip has been seen.
+func (c *goGitClient) MergedBranches(ctx context.Context, dir, into string) ([]string, error) {
+	repo, err := c.open(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotRepository, err)
+	}
+
+	intoHash, err := repo.ResolveRevision(plumbing.Revision(into))
+	if err != nil {
+		return nil, err
+	}
+	intoCommit, err := repo.CommitObject(*intoHash)
+	if err != nil {
+		return nil, err
+	}
+
+	refs, err := repo.Branches()
+	if err != nil {
+		return nil, err
+	}
+	defer refs.Close()
+
+	tips := make(map[plumbing.Hash][]string)
+	if err := refs.ForEach(func(ref *plumbing.Reference) error {
+		tips[ref.Hash()] = append(tips[ref.Hash()], ref.Name().Short())
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	var merged []string
+	err = object.NewCommitPreorderIter(intoCommit, nil, nil).ForEach(func(commit *object.Commit) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if names, ok := tips[commit.Hash]; ok {
+			merged = append(merged, names...)
+			delete(tips, commit.Hash)
+		}
+		if len(tips) == 0 {
+			return storer.ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(merged)
+	return merged, nil
+}
+
+func (c *goGitClient) GetCurrentBranch(ctx context.Context, dir string) (string, error) {
+	repo, err := c.open(dir)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrNotRepository, err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return "", err
+	}
+	if !head.Name().IsBranch() {
+		// Detached, like `git rev-parse --abbrev-ref HEAD`
+		return "HEAD", nil
+	}
+	return head.Name().Short(), nil
+}
+
+// Changes
+
+// status returns the worktree status, honoring the user's global excludes
+// like the git binary does.
+func (c *goGitClient) status(dir string) (gogit.Status, error) {
+	repo, err := c.open(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotRepository, err)
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	if patterns, err := gitignore.LoadGlobalPatterns(osfs.New("/")); err == nil {
+		wt.Excludes = append(wt.Excludes, patterns...)
+	}
+
+	return wt.Status()
+}
+
+func (c *goGitClient) HasChanges(ctx context.Context, dir string) bool {
+	status, err := c.status(dir)
+	if err != nil {
+		return false
+	}
+	return !status.IsClean()
+}
+
+func (c *goGitClient) HasUntrackedFiles(ctx context.Context, dir string) bool {
+	files, err := c.GetUntrackedFiles(ctx, dir)
+	return err == nil && len(files) > 0
+}
+
+func (c *goGitClient) GetUntrackedFiles(ctx context.Context, dir string) ([]string, error) {
+	status, err := c.status(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for path, s := range status {
+		if s.Worktree == gogit.Untracked {
+			files = append(files, path)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Status
+
+func (c *goGitClient) Status(ctx context.Context, dir string) (string, error) {
+	status, err := c.status(dir)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(status.String()), nil
+}
@@ -0,0 +1,193 @@
+// Package github provides an interface for GitHub CLI (gh) operations.
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PRCache configures the on-disk cache of PR statuses.
+type PRCache struct {
+	Dir     string        // Cache directory; empty disables caching
+	TTL     time.Duration // How long a cached status is trusted without asking GitHub
+	Refresh bool          // Revalidate every cached status regardless of TTL
+}
+
+// prCacheEntry is a cached PR status with the ETag GitHub sent for it.
+type prCacheEntry struct {
+	Status    PRStatus  `json:"status"`
+	ETag      string    `json:"etag,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// path returns the cache file of a pull request.
+func (p PRCache) path(prNumber int) string {
+	return filepath.Join(p.Dir, fmt.Sprintf("pr-%d.json", prNumber))
+}
+
+// load returns the cached entry of a pull request, or nil.
+func (p PRCache) load(prNumber int) *prCacheEntry {
+	if p.Dir == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(p.path(prNumber))
+	if err != nil {
+		return nil
+	}
+
+	var entry prCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil
+	}
+	return &entry
+}
+
+// save stores an entry (error is non-fatal - the next check asks GitHub again).
+func (p PRCache) save(prNumber int, entry *prCacheEntry) {
+	if p.Dir == "" {
+		return
+	}
+	if err := os.MkdirAll(p.Dir, 0755); err != nil {
+		return
+	}
+
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.WriteFile(p.path(prNumber), data, 0644); err != nil {
+		// Stale or missing entries are revalidated next time
+	}
+}
+
+// fresh returns true if the entry can be used without asking GitHub.
+// Merged PRs never change again, so they stay fresh forever.
+func (p PRCache) fresh(entry *prCacheEntry) bool {
+	if entry.Status.Merged {
+		return true
+	}
+	return !p.Refresh && time.Since(entry.CheckedAt) < p.TTL
+}
+
+// status returns the status of a pull request from the cache while it is
+// fresh, or else from fetch, which is given the cached ETag and returns a
+// nil status if the pull request is unchanged since.
+func (p PRCache) status(prNumber int, fetch func(etag string) (*PRStatus, string, error)) (*PRStatus, error) {
+	entry := p.load(prNumber)
+	if entry != nil && p.fresh(entry) {
+		return &entry.Status, nil
+	}
+
+	var etag string
+	if entry != nil {
+		etag = entry.ETag
+	}
+
+	status, etag, err := fetch(etag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PR status: %w", err)
+	}
+	if status == nil {
+		// Not modified since the cached status
+		status = &entry.Status
+	}
+
+	p.save(prNumber, &prCacheEntry{
+		Status:    *status,
+		ETag:      etag,
+		CheckedAt: time.Now(),
+	})
+	return status, nil
+}
+
+// fetchPR gets a pull request from the REST API. With an etag GitHub answers
+// 304 Not Modified, which doesn't count against the rate limit, if the PR is
+// unchanged; that is reported as a nil status. Returns the response's ETag.
+func (c *ghClient) fetchPR(ctx context.Context, dir string, prNumber int, etag string) (*PRStatus, string, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	args := []string{"api", "--include", fmt.Sprintf("repos/{owner}/{repo}/pulls/%d", prNumber)}
+	if etag != "" {
+		args = append(args, "-H", "If-None-Match: "+etag)
+	}
+
+	cmd := c.cmd(ctx, dir, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	// gh exits non-zero on 304, so look at the status line before the error
+	runErr := cmd.Run()
+	code, headers, body := parseHTTPResponse(stdout.String())
+	if code == http.StatusNotModified {
+		return nil, etag, nil
+	}
+	if runErr != nil {
+		return nil, "", wrapError(ctx, runErr, stderr.String())
+	}
+
+	status, err := parsePRStatus([]byte(body))
+	if err != nil {
+		return nil, "", err
+	}
+	return status, headers.Get("ETag"), nil
+}
+
+// parsePRStatus parses a pull request of the REST API into its status.
+func parsePRStatus(body []byte) (*PRStatus, error) {
+	var pr struct {
+		Number  int    `json:"number"`
+		State   string `json:"state"`
+		Merged  bool   `json:"merged"`
+		HTMLURL string `json:"html_url"`
+	}
+	if err := json.Unmarshal(body, &pr); err != nil {
+		return nil, fmt.Errorf("failed to parse PR status: %w", err)
+	}
+
+	status := &PRStatus{
+		Number: pr.Number,
+		State:  pr.State,
+		Merged: pr.Merged,
+		URL:    pr.HTMLURL,
+	}
+	if status.Merged {
+		status.State = "merged"
+	}
+	return status, nil
+}
+
+// parseHTTPResponse splits `gh api --include` output into the status code,
+// headers and body. Returns a zero code if the output has no status line.
+func parseHTTPResponse(output string) (int, http.Header, string) {
+	output = strings.ReplaceAll(output, "\r\n", "\n")
+	head, body, _ := strings.Cut(output, "\n\n")
+
+	lines := strings.Split(head, "\n")
+	fields := strings.Fields(lines[0])
+	if len(fields) < 2 || !strings.HasPrefix(fields[0], "HTTP/") {
+		return 0, nil, output
+	}
+	code, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, nil, output
+	}
+
+	headers := make(http.Header)
+	for _, line := range lines[1:] {
+		if key, value, ok := strings.Cut(line, ":"); ok {
+			headers.Add(strings.TrimSpace(key), strings.TrimSpace(value))
+		}
+	}
+	return code, headers, body
+}
@@ -0,0 +1,60 @@
+// Package github provides an interface for GitHub CLI (gh) operations.
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// States of a pull request's checks, summarized over all of them.
+const (
+	ChecksNone    = ""        // No checks ran
+	ChecksPassing = "passing" // Every check succeeded or was skipped
+	ChecksPending = "pending" // Some checks are still running, none failed
+	ChecksFailing = "failing" // A check failed
+)
+
+// checkRollup is an entry of a pull request's statusCheckRollup: a check run,
+// with a status and conclusion, or a commit status, with a state.
+type checkRollup struct {
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+	State      string `json:"state"`
+}
+
+// GetPRChecks returns the state of a pull request's checks: ChecksPassing,
+// ChecksPending, ChecksFailing, or ChecksNone.
+func (c *ghClient) GetPRChecks(ctx context.Context, dir string, prNumber int) (string, error) {
+	output, err := c.runOutput(ctx, dir, "pr", "view", fmt.Sprintf("%d", prNumber), "--json", "statusCheckRollup")
+	if err != nil {
+		return ChecksNone, fmt.Errorf("failed to get checks of PR #%d: %w", prNumber, err)
+	}
+
+	var pr struct {
+		Checks []checkRollup `json:"statusCheckRollup"`
+	}
+	if err := json.Unmarshal([]byte(output), &pr); err != nil {
+		return ChecksNone, fmt.Errorf("failed to parse checks of PR #%d: %w", prNumber, err)
+	}
+	return summarizeChecks(pr.Checks), nil
+}
+
+// summarizeChecks returns the state of a set of checks: failing if any
+// failed, else pending if any is still running.
+func summarizeChecks(checks []checkRollup) string {
+	state := ChecksNone
+	for _, check := range checks {
+		switch {
+		case check.Conclusion == "FAILURE" || check.Conclusion == "TIMED_OUT" || check.Conclusion == "CANCELLED" ||
+			check.Conclusion == "ACTION_REQUIRED" || check.Conclusion == "STARTUP_FAILURE" ||
+			check.State == "FAILURE" || check.State == "ERROR":
+			return ChecksFailing
+		case check.Status != "" && check.Status != "COMPLETED", check.State == "PENDING" || check.State == "EXPECTED":
+			state = ChecksPending
+		case state == ChecksNone:
+			state = ChecksPassing
+		}
+	}
+	return state
+}
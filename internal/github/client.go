@@ -4,11 +4,13 @@ package github
 import (
 	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"os/exec"
 	"strings"
 	"time"
+
+	"github.com/donghojung/taw/internal/constants"
 )
 
 // Client defines the interface for GitHub CLI operations.
@@ -16,23 +18,57 @@ type Client interface {
 	// IsInstalled checks if gh CLI is available.
 	IsInstalled() bool
 
+	// AuthStatus returns an error if gh is not authenticated.
+	AuthStatus(ctx context.Context) error
+
 	// CreatePR creates a pull request and returns the PR number.
-	CreatePR(dir, title, body, base string) (int, error)
+	// head may be "owner:branch" for a pull request from a fork, or empty for the current branch.
+	CreatePR(ctx context.Context, dir, title, body, base, head string) (int, error)
+
+	// CreateDraftPR creates a draft pull request and returns the PR number.
+	CreateDraftPR(ctx context.Context, dir, title, body, base, head string) (int, error)
+
+	// MarkPRReady marks a draft pull request ready for review.
+	MarkPRReady(ctx context.Context, dir string, prNumber int) error
 
 	// GetPRStatus gets the status of a pull request.
-	GetPRStatus(dir string, prNumber int) (*PRStatus, error)
+	GetPRStatus(ctx context.Context, dir string, prNumber int) (*PRStatus, error)
+
+	// GetPRChecks gets the state of the checks of a pull request.
+	GetPRChecks(ctx context.Context, dir string, prNumber int) (string, error)
 
 	// IsPRMerged checks if a pull request has been merged.
-	IsPRMerged(dir string, prNumber int) (bool, error)
+	IsPRMerged(ctx context.Context, dir string, prNumber int) (bool, error)
 
 	// ViewPRWeb opens the pull request in a web browser.
-	ViewPRWeb(dir string, prNumber int) error
+	ViewPRWeb(ctx context.Context, dir string, prNumber int) error
+
+	// MergePR merges a pull request on GitHub.
+	MergePR(ctx context.Context, dir string, prNumber int, opts MergeOpts) error
+
+	// GetPR gets a pull request with its branches.
+	GetPR(ctx context.Context, dir string, prNumber int) (*PullRequest, error)
+
+	// ListReviewThreads lists the unresolved review threads of a pull request.
+	ListReviewThreads(ctx context.Context, dir string, prNumber int) ([]ReviewThread, error)
+
+	// ResolveReviewThread resolves a review thread, replying to it first if reply isn't empty.
+	ResolveReviewThread(ctx context.Context, dir, threadID, reply string) error
+
+	// ListPRComments lists the latest comments and reviews of others on a pull request, oldest first.
+	ListPRComments(ctx context.Context, dir string, prNumber int) ([]PRComment, error)
+}
+
+// MergeOpts contains options for merging a pull request.
+type MergeOpts struct {
+	Method string // "merge", "squash", "rebase", or empty to let GitHub decide
+	Auto   bool   // --auto: merge when requirements are met (or enqueue in merge queue)
 }
 
 // PRStatus represents the status of a pull request.
 type PRStatus struct {
 	Number int    `json:"number"`
-	State  string `json:"state"`  // "open", "closed", "merged"
+	State  string `json:"state"` // "open", "closed", "merged"
 	Merged bool   `json:"merged"`
 	URL    string `json:"url"`
 }
@@ -40,13 +76,43 @@ type PRStatus struct {
 // ghClient implements the Client interface.
 type ghClient struct {
 	timeout time.Duration
+	cache   PRCache
 }
 
-// New creates a new GitHub CLI client.
+// New creates a new GitHub CLI client with the default timeout.
 func New() Client {
+	return NewWithTimeout(0)
+}
+
+// NewWithTimeout creates a new GitHub CLI client. A non-positive timeout uses the default.
+func NewWithTimeout(timeout time.Duration) Client {
+	return NewWithCache(timeout, PRCache{})
+}
+
+// NewWithCache creates a new GitHub CLI client that caches PR statuses on disk
+// and revalidates them with conditional requests once their TTL has passed.
+// Without gh on the PATH, the client talks to the GitHub API directly (see
+// restClient). A non-positive timeout uses the default.
+func NewWithCache(timeout time.Duration, cache PRCache) Client {
+	if timeout <= 0 {
+		timeout = constants.DefaultGitHubTimeout
+	}
+	if _, err := exec.LookPath("gh"); err != nil {
+		return newRESTClient(timeout, cache)
+	}
 	return &ghClient{
-		timeout: 30 * time.Second,
+		timeout: timeout,
+		cache:   cache,
+	}
+}
+
+// withTimeout applies the client's default timeout unless the caller
+// already set a deadline.
+func (c *ghClient) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
 	}
+	return context.WithTimeout(ctx, c.timeout)
 }
 
 func (c *ghClient) cmd(ctx context.Context, dir string, args ...string) *exec.Cmd {
@@ -57,8 +123,8 @@ func (c *ghClient) cmd(ctx context.Context, dir string, args ...string) *exec.Cm
 	return cmd
 }
 
-func (c *ghClient) run(dir string, args ...string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+func (c *ghClient) run(ctx context.Context, dir string, args ...string) error {
+	ctx, cancel := c.withTimeout(ctx)
 	defer cancel()
 
 	cmd := c.cmd(ctx, dir, args...)
@@ -66,13 +132,13 @@ func (c *ghClient) run(dir string, args ...string) error {
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("%w: %s", err, stderr.String())
+		return wrapError(ctx, err, stderr.String())
 	}
 	return nil
 }
 
-func (c *ghClient) runOutput(dir string, args ...string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+func (c *ghClient) runOutput(ctx context.Context, dir string, args ...string) (string, error) {
+	ctx, cancel := c.withTimeout(ctx)
 	defer cancel()
 
 	cmd := c.cmd(ctx, dir, args...)
@@ -81,7 +147,7 @@ func (c *ghClient) runOutput(dir string, args ...string) (string, error) {
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("%w: %s", err, stderr.String())
+		return "", wrapError(ctx, err, stderr.String())
 	}
 	return strings.TrimSpace(stdout.String()), nil
 }
@@ -92,14 +158,41 @@ func (c *ghClient) IsInstalled() bool {
 	return err == nil
 }
 
+// AuthStatus returns an error if gh is not authenticated.
+func (c *ghClient) AuthStatus(ctx context.Context) error {
+	if err := c.run(ctx, "", "auth", "status"); err != nil {
+		if errors.Is(err, ErrNotInstalled) || errors.Is(err, ErrNotAuthenticated) {
+			return err
+		}
+		return fmt.Errorf("%w: %w", ErrNotAuthenticated, err)
+	}
+	return nil
+}
+
 // CreatePR creates a pull request and returns the PR number.
-func (c *ghClient) CreatePR(dir, title, body, base string) (int, error) {
+func (c *ghClient) CreatePR(ctx context.Context, dir, title, body, base, head string) (int, error) {
+	return c.createPR(ctx, dir, title, body, base, head, false)
+}
+
+// CreateDraftPR creates a draft pull request and returns the PR number.
+func (c *ghClient) CreateDraftPR(ctx context.Context, dir, title, body, base, head string) (int, error) {
+	return c.createPR(ctx, dir, title, body, base, head, true)
+}
+
+// createPR runs gh pr create and returns the PR number.
+func (c *ghClient) createPR(ctx context.Context, dir, title, body, base, head string, draft bool) (int, error) {
 	args := []string{"pr", "create", "--title", title, "--body", body}
+	if draft {
+		args = append(args, "--draft")
+	}
 	if base != "" {
 		args = append(args, "--base", base)
 	}
+	if head != "" {
+		args = append(args, "--head", head)
+	}
 
-	output, err := c.runOutput(dir, args...)
+	output, err := c.runOutput(ctx, dir, args...)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create PR: %w", err)
 	}
@@ -119,31 +212,70 @@ func (c *ghClient) CreatePR(dir, title, body, base string) (int, error) {
 	return prNumber, nil
 }
 
-// GetPRStatus gets the status of a pull request.
-func (c *ghClient) GetPRStatus(dir string, prNumber int) (*PRStatus, error) {
-	output, err := c.runOutput(dir, "pr", "view", fmt.Sprintf("%d", prNumber), "--json", "number,state,merged,url")
-	if err != nil {
-		return nil, fmt.Errorf("failed to get PR status: %w", err)
-	}
-
-	var status PRStatus
-	if err := json.Unmarshal([]byte(output), &status); err != nil {
-		return nil, fmt.Errorf("failed to parse PR status: %w", err)
-	}
-
-	return &status, nil
+// GetPRStatus gets the status of a pull request, from the cache while it is fresh.
+func (c *ghClient) GetPRStatus(ctx context.Context, dir string, prNumber int) (*PRStatus, error) {
+	return c.cache.status(prNumber, func(etag string) (*PRStatus, string, error) {
+		return c.fetchPR(ctx, dir, prNumber, etag)
+	})
 }
 
 // IsPRMerged checks if a pull request has been merged.
-func (c *ghClient) IsPRMerged(dir string, prNumber int) (bool, error) {
-	status, err := c.GetPRStatus(dir, prNumber)
+func (c *ghClient) IsPRMerged(ctx context.Context, dir string, prNumber int) (bool, error) {
+	status, err := c.GetPRStatus(ctx, dir, prNumber)
 	if err != nil {
 		return false, err
 	}
 	return status.Merged, nil
 }
 
+// MarkPRReady marks a draft pull request ready for review.
+func (c *ghClient) MarkPRReady(ctx context.Context, dir string, prNumber int) error {
+	if err := c.run(ctx, dir, "pr", "ready", fmt.Sprintf("%d", prNumber)); err != nil {
+		return fmt.Errorf("failed to mark PR #%d ready: %w", prNumber, err)
+	}
+	return nil
+}
+
 // ViewPRWeb opens the pull request in a web browser.
-func (c *ghClient) ViewPRWeb(dir string, prNumber int) error {
-	return c.run(dir, "pr", "view", fmt.Sprintf("%d", prNumber), "--web")
+func (c *ghClient) ViewPRWeb(ctx context.Context, dir string, prNumber int) error {
+	return c.run(ctx, dir, "pr", "view", fmt.Sprintf("%d", prNumber), "--web")
+}
+
+// MergePR merges a pull request on GitHub.
+func (c *ghClient) MergePR(ctx context.Context, dir string, prNumber int, opts MergeOpts) error {
+	args := []string{"pr", "merge", fmt.Sprintf("%d", prNumber)}
+	if opts.Method != "" {
+		args = append(args, "--"+opts.Method)
+	}
+	if opts.Auto {
+		args = append(args, "--auto")
+	}
+
+	if err := c.run(ctx, dir, args...); err != nil {
+		return fmt.Errorf("failed to merge PR #%d: %w", prNumber, err)
+	}
+	return nil
+}
+
+// OwnerFromURL extracts the repository owner from a GitHub remote URL.
+// Supports https://github.com/owner/repo(.git) and git@github.com:owner/repo(.git).
+func OwnerFromURL(url string) string {
+	owner, _ := repoFromURL(url)
+	return owner
+}
+
+// repoFromURL extracts the repository owner and name from a GitHub remote URL.
+func repoFromURL(url string) (string, string) {
+	url = strings.TrimSuffix(strings.TrimSpace(url), ".git")
+	if i := strings.Index(url, "://"); i >= 0 {
+		url = url[i+3:]
+	} else if i := strings.Index(url, ":"); i >= 0 {
+		url = strings.Replace(url, ":", "/", 1)
+	}
+
+	parts := strings.Split(url, "/")
+	if len(parts) < 3 {
+		return "", ""
+	}
+	return parts[len(parts)-2], parts[len(parts)-1]
 }
@@ -0,0 +1,142 @@
+// Package github provides an interface for GitHub CLI (gh) operations.
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PRComment is a comment on a pull request: on its conversation, a review,
+// or a review's comment on a line.
+type PRComment struct {
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	URL       string    `json:"url"`
+	Path      string    `json:"path,omitempty"`   // The file of a line comment
+	Line      int       `json:"line,omitempty"`   // 0 if the comment is on the whole file
+	Review    string    `json:"review,omitempty"` // The state of a review, e.g. CHANGES_REQUESTED
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Location returns where a line comment is, e.g. "main.go:42", or "".
+func (c PRComment) Location() string {
+	if c.Line > 0 {
+		return fmt.Sprintf("%s:%d", c.Path, c.Line)
+	}
+	return c.Path
+}
+
+// prCommentsQuery fetches the latest comments and reviews of a pull request,
+// with the user asking, whose own comments are left out.
+const prCommentsQuery = `query($owner: String!, $name: String!, $number: Int!) {
+  viewer { login }
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) {
+      comments(last: 50) {
+        nodes { author { login } body url createdAt }
+      }
+      reviews(last: 50) {
+        nodes {
+          author { login } body url state submittedAt
+          comments(first: 50) {
+            nodes { author { login } body url path line createdAt }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// ListPRComments lists the latest comments of others on a pull request.
+func (c *ghClient) ListPRComments(ctx context.Context, dir string, prNumber int) ([]PRComment, error) {
+	// gh fills in {owner} and {repo} from the repository of dir
+	output, err := c.runOutput(ctx, dir, "api", "graphql",
+		"-f", "query="+prCommentsQuery,
+		"-F", "owner={owner}",
+		"-F", "name={repo}",
+		"-F", fmt.Sprintf("number=%d", prNumber))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list comments of PR #%d: %w", prNumber, err)
+	}
+	return parsePRComments([]byte(output), prNumber)
+}
+
+// parsePRComments parses the response to prCommentsQuery into the comments
+// of others, oldest first. Reviews count when they have a body, or approve
+// or request changes.
+func parsePRComments(output []byte, prNumber int) ([]PRComment, error) {
+	type author struct {
+		Login string `json:"login"`
+	}
+	var resp struct {
+		Data struct {
+			Viewer     author `json:"viewer"`
+			Repository struct {
+				PullRequest *struct {
+					Comments struct {
+						Nodes []struct {
+							Author    author    `json:"author"`
+							Body      string    `json:"body"`
+							URL       string    `json:"url"`
+							CreatedAt time.Time `json:"createdAt"`
+						} `json:"nodes"`
+					} `json:"comments"`
+					Reviews struct {
+						Nodes []struct {
+							Author      author    `json:"author"`
+							Body        string    `json:"body"`
+							URL         string    `json:"url"`
+							State       string    `json:"state"`
+							SubmittedAt time.Time `json:"submittedAt"`
+							Comments    struct {
+								Nodes []struct {
+									Author    author    `json:"author"`
+									Body      string    `json:"body"`
+									URL       string    `json:"url"`
+									Path      string    `json:"path"`
+									Line      int       `json:"line"`
+									CreatedAt time.Time `json:"createdAt"`
+								} `json:"nodes"`
+							} `json:"comments"`
+						} `json:"nodes"`
+					} `json:"reviews"`
+				} `json:"pullRequest"`
+			} `json:"repository"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(output, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse comments of PR #%d: %w", prNumber, err)
+	}
+	pr := resp.Data.Repository.PullRequest
+	if pr == nil {
+		return nil, fmt.Errorf("PR #%d not found", prNumber)
+	}
+
+	viewer := resp.Data.Viewer.Login
+	var comments []PRComment
+	add := func(c PRComment) {
+		if c.Author != viewer {
+			comments = append(comments, c)
+		}
+	}
+	for _, n := range pr.Comments.Nodes {
+		add(PRComment{Author: n.Author.Login, Body: n.Body, URL: n.URL, CreatedAt: n.CreatedAt})
+	}
+	for _, r := range pr.Reviews.Nodes {
+		if strings.TrimSpace(r.Body) != "" || r.State == "CHANGES_REQUESTED" || r.State == "APPROVED" {
+			add(PRComment{Author: r.Author.Login, Body: r.Body, URL: r.URL, Review: r.State, CreatedAt: r.SubmittedAt})
+		}
+		for _, n := range r.Comments.Nodes {
+			add(PRComment{Author: n.Author.Login, Body: n.Body, URL: n.URL, Path: n.Path, Line: n.Line, CreatedAt: n.CreatedAt})
+		}
+	}
+
+	sort.SliceStable(comments, func(i, j int) bool {
+		return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+	})
+	return comments, nil
+}
@@ -0,0 +1,74 @@
+// Package github provides an interface for GitHub CLI (gh) operations.
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Recognized gh failures, matched with errors.Is.
+var (
+	ErrNotInstalled     = errors.New("gh CLI not installed")
+	ErrNotAuthenticated = errors.New("gh is not authenticated")
+)
+
+// wrapError wraps a failed gh command's error with its stderr and, when
+// recognized, the matching sentinel or the context error that stopped it.
+func wrapError(ctx context.Context, err error, stderr string) error {
+	msg := strings.ToLower(stderr)
+	if stderr = strings.TrimSpace(stderr); stderr != "" {
+		err = fmt.Errorf("%w: %s", err, stderr)
+	}
+
+	switch {
+	case errors.Is(err, exec.ErrNotFound):
+		return fmt.Errorf("%w: %w", ErrNotInstalled, err)
+	case ctx.Err() != nil:
+		return fmt.Errorf("%w: %w", ctx.Err(), err)
+	case strings.Contains(msg, "gh auth login") || strings.Contains(msg, "not logged in"):
+		return fmt.Errorf("%w: %w", ErrNotAuthenticated, err)
+	default:
+		return err
+	}
+}
+
+// ErrorHint returns a user-facing remediation hint for a recognized gh
+// failure, or "" if the error isn't one.
+func ErrorHint(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrNotInstalled):
+		return "Install the GitHub CLI (brew install gh), or set GH_TOKEN, to create and merge pull requests"
+	case errors.Is(err, ErrNotAuthenticated):
+		return "Run 'gh auth login' to authenticate the GitHub CLI, or set GH_TOKEN to a valid token without gh"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "GitHub timed out - retry, or raise timeouts.github in .taw/config"
+	case IsTransient(err):
+		return "GitHub couldn't be reached - check the network connection and retry"
+	default:
+		return ""
+	}
+}
+
+// IsTransient returns true if GitHub couldn't be reached or timed out, so
+// retrying later may succeed.
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{"error connecting to", "could not resolve host", "no such host",
+		"connection refused", "connection reset", "network is unreachable", "i/o timeout", "tls handshake timeout"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
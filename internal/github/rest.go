@@ -0,0 +1,610 @@
+// Package github provides an interface for GitHub CLI (gh) operations.
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultAPIURL is the GitHub REST API, unless GITHUB_API_URL names another
+// (GitHub Actions sets it to the server's API, GitHub Enterprise included).
+const defaultAPIURL = "https://api.github.com"
+
+const markPRReadyMutation = `mutation($id: ID!) {
+  markPullRequestReadyForReview(input: {pullRequestId: $id}) { pullRequest { id } }
+}`
+
+const enableAutoMergeMutation = `mutation($id: ID!, $method: PullRequestMergeMethod) {
+  enablePullRequestAutoMerge(input: {pullRequestId: $id, mergeMethod: $method}) { pullRequest { id } }
+}`
+
+// restClient implements the Client interface with the GitHub REST and
+// GraphQL APIs, for where gh isn't installed (containers, CI). It
+// authenticates with GH_TOKEN or GITHUB_TOKEN, or else the credential git
+// keeps for GitHub (e.g. in the macOS keychain), and works on the repository
+// of the upstream or origin remote of dir, as gh does.
+type restClient struct {
+	timeout time.Duration
+	cache   PRCache
+	apiURL  string
+	http    *http.Client
+
+	tokenOnce sync.Once
+	token     string
+}
+
+// restPR is a pull request as the REST API returns it.
+type restPR struct {
+	NodeID  string    `json:"node_id"`
+	Number  int       `json:"number"`
+	Title   string    `json:"title"`
+	State   string    `json:"state"`
+	Merged  bool      `json:"merged"`
+	HTMLURL string    `json:"html_url"`
+	Head    restPRRef `json:"head"`
+	Base    restPRRef `json:"base"`
+}
+
+// restPRRef is the head or base branch of a pull request.
+type restPRRef struct {
+	Ref  string `json:"ref"`
+	SHA  string `json:"sha"`
+	Repo *struct {
+		FullName string `json:"full_name"`
+		Owner    struct {
+			Login string `json:"login"`
+		} `json:"owner"`
+	} `json:"repo"` // nil if the fork was deleted
+}
+
+func newRESTClient(timeout time.Duration, cache PRCache) *restClient {
+	apiURL := strings.TrimSuffix(os.Getenv("GITHUB_API_URL"), "/")
+	if apiURL == "" {
+		apiURL = defaultAPIURL
+	}
+	return &restClient{
+		timeout: timeout,
+		cache:   cache,
+		apiURL:  apiURL,
+		http:    &http.Client{},
+	}
+}
+
+// withTimeout applies the client's default timeout unless the caller
+// already set a deadline.
+func (c *restClient) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+// host returns the GitHub host the API belongs to, for git's credentials.
+func (c *restClient) host() string {
+	u, err := url.Parse(c.apiURL)
+	if err != nil || u.Host == "api.github.com" {
+		return "github.com"
+	}
+	return u.Host
+}
+
+// graphqlURL returns the GraphQL endpoint next to the REST API:
+// api.github.com/graphql, or <host>/api/graphql on GitHub Enterprise.
+func (c *restClient) graphqlURL() string {
+	if base, ok := strings.CutSuffix(c.apiURL, "/api/v3"); ok {
+		return base + "/api/graphql"
+	}
+	return c.apiURL + "/graphql"
+}
+
+// authToken returns the token to authenticate with, or "" if there is none.
+// It is looked up once.
+func (c *restClient) authToken() string {
+	c.tokenOnce.Do(func() {
+		for _, env := range []string{"GH_TOKEN", "GITHUB_TOKEN"} {
+			if token := strings.TrimSpace(os.Getenv(env)); token != "" {
+				c.token = token
+				return
+			}
+		}
+		c.token = c.credentialToken()
+	})
+	return c.token
+}
+
+// credentialToken asks git's credential helpers for the password of the
+// GitHub host, never prompting for one. Returns "" if none is stored.
+func (c *restClient) credentialToken() string {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "credential", "fill")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("protocol=https\nhost=%s\n\n", c.host()))
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=", "SSH_ASKPASS=")
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		if password, ok := strings.CutPrefix(line, "password="); ok {
+			return strings.TrimSpace(password)
+		}
+	}
+	return ""
+}
+
+// send sends a request to the API, with in encoded as its JSON body unless
+// it's nil. Returns the status, headers and body of the response; a status
+// of 400 or more is returned as an error.
+func (c *restClient) send(ctx context.Context, method, endpoint string, in any, header http.Header) (int, http.Header, []byte, error) {
+	token := c.authToken()
+	if token == "" {
+		return 0, nil, nil, fmt.Errorf("%w: no GH_TOKEN or GITHUB_TOKEN, and git's credential helper has none for %s", ErrNotAuthenticated, c.host())
+	}
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return 0, nil, nil, err
+		}
+		body = bytes.NewReader(data)
+	}
+	if !strings.HasPrefix(endpoint, "https://") && !strings.HasPrefix(endpoint, "http://") {
+		endpoint = c.apiURL + "/" + endpoint
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0, nil, nil, fmt.Errorf("%w: %w", ctx.Err(), err)
+		}
+		return 0, nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return resp.StatusCode, resp.Header, data, apiError(resp.StatusCode, data)
+	}
+	return resp.StatusCode, resp.Header, data, nil
+}
+
+// call sends a request to the API and decodes its JSON response into out
+// unless it's nil.
+func (c *restClient) call(ctx context.Context, method, endpoint string, in, out any) error {
+	_, _, data, err := c.send(ctx, method, endpoint, in, nil)
+	if err != nil {
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to parse response of %s: %w", endpoint, err)
+	}
+	return nil
+}
+
+// graphql runs a GraphQL query and returns the whole response, as
+// 'gh api graphql' prints it.
+func (c *restClient) graphql(ctx context.Context, query string, variables map[string]any) ([]byte, error) {
+	_, _, data, err := c.send(ctx, http.MethodPost, c.graphqlURL(), map[string]any{
+		"query":     query,
+		"variables": variables,
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse GraphQL response: %w", err)
+	}
+	if len(resp.Errors) > 0 {
+		var messages []string
+		for _, e := range resp.Errors {
+			messages = append(messages, e.Message)
+		}
+		return nil, errors.New(strings.Join(messages, "; "))
+	}
+	return data, nil
+}
+
+// apiError returns the error of a failed API request, with GitHub's message.
+// 401 means the token is missing, wrong or expired.
+func apiError(code int, body []byte) error {
+	var resp struct {
+		Message string `json:"message"`
+		Errors  []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	msg := http.StatusText(code)
+	if json.Unmarshal(body, &resp) == nil && resp.Message != "" {
+		msg = resp.Message
+		for _, e := range resp.Errors {
+			if e.Message != "" {
+				msg += ": " + e.Message
+			}
+		}
+	}
+
+	err := fmt.Errorf("HTTP %d: %s", code, msg)
+	if code == http.StatusUnauthorized {
+		return fmt.Errorf("%w: %w", ErrNotAuthenticated, err)
+	}
+	return err
+}
+
+// repo returns the owner and name of the GitHub repository of dir: that of
+// its upstream remote, or else its origin.
+func (c *restClient) repo(ctx context.Context, dir string) (string, string, error) {
+	for _, remote := range []string{"upstream", "origin"} {
+		cmd := exec.CommandContext(ctx, "git", "remote", "get-url", remote)
+		cmd.Dir = dir
+		output, err := cmd.Output()
+		if err != nil {
+			continue
+		}
+		if owner, name := repoFromURL(string(output)); owner != "" && name != "" {
+			return owner, name, nil
+		}
+	}
+	return "", "", fmt.Errorf("no GitHub remote in %s", dir)
+}
+
+// repoEndpoint returns the REST endpoint of the GitHub repository of dir.
+func (c *restClient) repoEndpoint(ctx context.Context, dir string) (string, error) {
+	owner, name, err := c.repo(ctx, dir)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("repos/%s/%s", owner, name), nil
+}
+
+// getPR gets a pull request from the REST API.
+func (c *restClient) getPR(ctx context.Context, dir string, prNumber int) (*restPR, error) {
+	repo, err := c.repoEndpoint(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	var pr restPR
+	if err := c.call(ctx, http.MethodGet, fmt.Sprintf("%s/pulls/%d", repo, prNumber), nil, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// IsInstalled reports whether there is a token to reach the GitHub API with.
+func (c *restClient) IsInstalled() bool {
+	return c.authToken() != ""
+}
+
+// AuthStatus returns an error if the token isn't accepted by GitHub. Only a
+// 401 or 403 means it isn't; other failures, e.g. when offline, are returned
+// as they are.
+func (c *restClient) AuthStatus(ctx context.Context) error {
+	code, _, _, err := c.send(ctx, http.MethodGet, "user", nil, nil)
+	if err != nil && code == http.StatusForbidden {
+		return fmt.Errorf("%w: %w", ErrNotAuthenticated, err)
+	}
+	return err
+}
+
+// CreatePR creates a pull request and returns the PR number.
+func (c *restClient) CreatePR(ctx context.Context, dir, title, body, base, head string) (int, error) {
+	return c.createPR(ctx, dir, title, body, base, head, false)
+}
+
+// CreateDraftPR creates a draft pull request and returns the PR number.
+func (c *restClient) CreateDraftPR(ctx context.Context, dir, title, body, base, head string) (int, error) {
+	return c.createPR(ctx, dir, title, body, base, head, true)
+}
+
+// createPR creates a pull request from head, or the current branch of dir,
+// into base, or the repository's default branch.
+func (c *restClient) createPR(ctx context.Context, dir, title, body, base, head string, draft bool) (int, error) {
+	repo, err := c.repoEndpoint(ctx, dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create PR: %w", err)
+	}
+
+	if head == "" {
+		cmd := exec.CommandContext(ctx, "git", "rev-parse", "--abbrev-ref", "HEAD")
+		cmd.Dir = dir
+		output, err := cmd.Output()
+		if err != nil {
+			return 0, fmt.Errorf("failed to create PR: no current branch: %w", err)
+		}
+		head = strings.TrimSpace(string(output))
+	}
+	if base == "" {
+		var r struct {
+			DefaultBranch string `json:"default_branch"`
+		}
+		if err := c.call(ctx, http.MethodGet, repo, nil, &r); err != nil {
+			return 0, fmt.Errorf("failed to create PR: %w", err)
+		}
+		base = r.DefaultBranch
+	}
+
+	var pr restPR
+	if err := c.call(ctx, http.MethodPost, repo+"/pulls", map[string]any{
+		"title": title,
+		"body":  body,
+		"head":  head,
+		"base":  base,
+		"draft": draft,
+	}, &pr); err != nil {
+		return 0, fmt.Errorf("failed to create PR: %w", err)
+	}
+	return pr.Number, nil
+}
+
+// MarkPRReady marks a draft pull request ready for review, which only the
+// GraphQL API can do.
+func (c *restClient) MarkPRReady(ctx context.Context, dir string, prNumber int) error {
+	pr, err := c.getPR(ctx, dir, prNumber)
+	if err == nil {
+		_, err = c.graphql(ctx, markPRReadyMutation, map[string]any{"id": pr.NodeID})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to mark PR #%d ready: %w", prNumber, err)
+	}
+	return nil
+}
+
+// GetPRStatus gets the status of a pull request, from the cache while it is fresh.
+func (c *restClient) GetPRStatus(ctx context.Context, dir string, prNumber int) (*PRStatus, error) {
+	return c.cache.status(prNumber, func(etag string) (*PRStatus, string, error) {
+		repo, err := c.repoEndpoint(ctx, dir)
+		if err != nil {
+			return nil, "", err
+		}
+
+		header := make(http.Header)
+		if etag != "" {
+			header.Set("If-None-Match", etag)
+		}
+		code, headers, body, err := c.send(ctx, http.MethodGet, fmt.Sprintf("%s/pulls/%d", repo, prNumber), nil, header)
+		if err != nil {
+			return nil, "", err
+		}
+		if code == http.StatusNotModified {
+			return nil, etag, nil
+		}
+
+		status, err := parsePRStatus(body)
+		if err != nil {
+			return nil, "", err
+		}
+		return status, headers.Get("ETag"), nil
+	})
+}
+
+// GetPRChecks returns the state of a pull request's checks: its head
+// commit's check runs and commit statuses.
+func (c *restClient) GetPRChecks(ctx context.Context, dir string, prNumber int) (string, error) {
+	checks, err := c.prChecks(ctx, dir, prNumber)
+	if err != nil {
+		return ChecksNone, fmt.Errorf("failed to get checks of PR #%d: %w", prNumber, err)
+	}
+	return summarizeChecks(checks), nil
+}
+
+// prChecks returns the checks of a pull request's head commit as they are
+// in gh's statusCheckRollup, whose values are upper case.
+func (c *restClient) prChecks(ctx context.Context, dir string, prNumber int) ([]checkRollup, error) {
+	repo, err := c.repoEndpoint(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	pr, err := c.getPR(ctx, dir, prNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	var runs struct {
+		CheckRuns []struct {
+			Status     string `json:"status"`
+			Conclusion string `json:"conclusion"`
+		} `json:"check_runs"`
+	}
+	if err := c.call(ctx, http.MethodGet, fmt.Sprintf("%s/commits/%s/check-runs?per_page=100", repo, pr.Head.SHA), nil, &runs); err != nil {
+		return nil, err
+	}
+	var combined struct {
+		Statuses []struct {
+			State string `json:"state"`
+		} `json:"statuses"`
+	}
+	if err := c.call(ctx, http.MethodGet, fmt.Sprintf("%s/commits/%s/status", repo, pr.Head.SHA), nil, &combined); err != nil {
+		return nil, err
+	}
+
+	var checks []checkRollup
+	for _, run := range runs.CheckRuns {
+		checks = append(checks, checkRollup{
+			Status:     strings.ToUpper(run.Status),
+			Conclusion: strings.ToUpper(run.Conclusion),
+		})
+	}
+	for _, status := range combined.Statuses {
+		checks = append(checks, checkRollup{State: strings.ToUpper(status.State)})
+	}
+	return checks, nil
+}
+
+// IsPRMerged checks if a pull request has been merged.
+func (c *restClient) IsPRMerged(ctx context.Context, dir string, prNumber int) (bool, error) {
+	status, err := c.GetPRStatus(ctx, dir, prNumber)
+	if err != nil {
+		return false, err
+	}
+	return status.Merged, nil
+}
+
+// ViewPRWeb opens the pull request in a web browser.
+func (c *restClient) ViewPRWeb(ctx context.Context, dir string, prNumber int) error {
+	status, err := c.GetPRStatus(ctx, dir, prNumber)
+	if err != nil {
+		return err
+	}
+
+	opener := "xdg-open"
+	if runtime.GOOS == "darwin" {
+		opener = "open"
+	}
+	if err := exec.CommandContext(ctx, opener, status.URL).Run(); err != nil {
+		return fmt.Errorf("failed to open %s: %w", status.URL, err)
+	}
+	return nil
+}
+
+// MergePR merges a pull request on GitHub. With opts.Auto it enables
+// auto-merge, which merges it, or adds it to the merge queue, once its
+// requirements are met.
+func (c *restClient) MergePR(ctx context.Context, dir string, prNumber int, opts MergeOpts) error {
+	if err := c.mergePR(ctx, dir, prNumber, opts); err != nil {
+		return fmt.Errorf("failed to merge PR #%d: %w", prNumber, err)
+	}
+	return nil
+}
+
+func (c *restClient) mergePR(ctx context.Context, dir string, prNumber int, opts MergeOpts) error {
+	if opts.Auto {
+		pr, err := c.getPR(ctx, dir, prNumber)
+		if err != nil {
+			return err
+		}
+		variables := map[string]any{"id": pr.NodeID}
+		if opts.Method != "" {
+			variables["method"] = strings.ToUpper(opts.Method)
+		}
+		_, err = c.graphql(ctx, enableAutoMergeMutation, variables)
+		return err
+	}
+
+	repo, err := c.repoEndpoint(ctx, dir)
+	if err != nil {
+		return err
+	}
+	in := map[string]any{}
+	if opts.Method != "" {
+		in["merge_method"] = opts.Method
+	}
+	return c.call(ctx, http.MethodPut, fmt.Sprintf("%s/pulls/%d/merge", repo, prNumber), in, nil)
+}
+
+// GetPR gets a pull request with its branches.
+func (c *restClient) GetPR(ctx context.Context, dir string, prNumber int) (*PullRequest, error) {
+	pr, err := c.getPR(ctx, dir, prNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PR #%d: %w", prNumber, err)
+	}
+
+	result := &PullRequest{
+		Number:      pr.Number,
+		Title:       pr.Title,
+		URL:         pr.HTMLURL,
+		State:       strings.ToUpper(pr.State),
+		HeadRefName: pr.Head.Ref,
+		HeadRefOid:  pr.Head.SHA,
+		BaseRefName: pr.Base.Ref,
+	}
+	if pr.Merged {
+		result.State = "MERGED"
+	}
+	if head, base := pr.Head.Repo, pr.Base.Repo; head != nil {
+		result.HeadRepositoryOwner.Login = head.Owner.Login
+		result.IsCrossRepository = base == nil || head.FullName != base.FullName
+	}
+	return result, nil
+}
+
+// ListReviewThreads lists the unresolved review threads of a pull request.
+func (c *restClient) ListReviewThreads(ctx context.Context, dir string, prNumber int) ([]ReviewThread, error) {
+	owner, name, err := c.repo(ctx, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list review threads of PR #%d: %w", prNumber, err)
+	}
+
+	output, err := c.graphql(ctx, reviewThreadsQuery, map[string]any{
+		"owner":  owner,
+		"name":   name,
+		"number": prNumber,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list review threads of PR #%d: %w", prNumber, err)
+	}
+	return parseReviewThreads(output, prNumber)
+}
+
+// ListPRComments lists the latest comments of others on a pull request.
+func (c *restClient) ListPRComments(ctx context.Context, dir string, prNumber int) ([]PRComment, error) {
+	owner, name, err := c.repo(ctx, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list comments of PR #%d: %w", prNumber, err)
+	}
+
+	output, err := c.graphql(ctx, prCommentsQuery, map[string]any{
+		"owner":  owner,
+		"name":   name,
+		"number": prNumber,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list comments of PR #%d: %w", prNumber, err)
+	}
+	return parsePRComments(output, prNumber)
+}
+
+// ResolveReviewThread resolves a review thread and replies to it if reply
+// isn't empty, in the same order as the gh client.
+func (c *restClient) ResolveReviewThread(ctx context.Context, dir, threadID, reply string) error {
+	if _, err := c.graphql(ctx, resolveReviewThreadMutation, map[string]any{"id": threadID}); err != nil {
+		return fmt.Errorf("failed to resolve review thread: %w", err)
+	}
+	if reply == "" {
+		return nil
+	}
+	if _, err := c.graphql(ctx, replyReviewThreadMutation, map[string]any{"id": threadID, "body": reply}); err != nil {
+		return fmt.Errorf("failed to reply to review thread: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+// Package github provides an interface for GitHub CLI (gh) operations.
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// PullRequest is a pull request with the branches it merges.
+type PullRequest struct {
+	Number            int    `json:"number"`
+	Title             string `json:"title"`
+	URL               string `json:"url"`
+	State             string `json:"state"` // "OPEN", "CLOSED" or "MERGED"
+	HeadRefName       string `json:"headRefName"`
+	HeadRefOid        string `json:"headRefOid"`
+	BaseRefName       string `json:"baseRefName"`
+	IsCrossRepository bool   `json:"isCrossRepository"` // The head branch is in a fork
+
+	HeadRepositoryOwner struct {
+		Login string `json:"login"`
+	} `json:"headRepositoryOwner"`
+}
+
+// ReviewThread is a thread of review comments on a line of a pull request.
+type ReviewThread struct {
+	ID         string          `json:"id"`
+	Path       string          `json:"path"`
+	Line       int             `json:"line"` // 0 if the thread is on the whole file
+	IsOutdated bool            `json:"isOutdated"`
+	Comments   []ReviewComment `json:"comments"`
+}
+
+// ReviewComment is a comment of a review thread.
+type ReviewComment struct {
+	Author string `json:"author"`
+	Body   string `json:"body"`
+	URL    string `json:"url"`
+}
+
+// reviewThreadsQuery fetches the review threads of a pull request. Threads
+// past the first 100 are left for the next run.
+const reviewThreadsQuery = `query($owner: String!, $name: String!, $number: Int!) {
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) {
+      reviewThreads(first: 100) {
+        nodes {
+          id
+          isResolved
+          isOutdated
+          path
+          line
+          comments(first: 50) {
+            nodes { author { login } body url }
+          }
+        }
+      }
+    }
+  }
+}`
+
+const resolveReviewThreadMutation = `mutation($id: ID!) {
+  resolveReviewThread(input: {threadId: $id}) { thread { id } }
+}`
+
+const replyReviewThreadMutation = `mutation($id: ID!, $body: String!) {
+  addPullRequestReviewThreadReply(input: {pullRequestReviewThreadId: $id, body: $body}) { comment { id } }
+}`
+
+// GetPR gets a pull request with its branches.
+func (c *ghClient) GetPR(ctx context.Context, dir string, prNumber int) (*PullRequest, error) {
+	output, err := c.runOutput(ctx, dir, "pr", "view", fmt.Sprintf("%d", prNumber), "--json",
+		"number,title,url,state,headRefName,headRefOid,baseRefName,isCrossRepository,headRepositoryOwner")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PR #%d: %w", prNumber, err)
+	}
+
+	var pr PullRequest
+	if err := json.Unmarshal([]byte(output), &pr); err != nil {
+		return nil, fmt.Errorf("failed to parse PR #%d: %w", prNumber, err)
+	}
+	return &pr, nil
+}
+
+// ListReviewThreads lists the unresolved review threads of a pull request.
+func (c *ghClient) ListReviewThreads(ctx context.Context, dir string, prNumber int) ([]ReviewThread, error) {
+	// gh fills in {owner} and {repo} from the repository of dir
+	output, err := c.runOutput(ctx, dir, "api", "graphql",
+		"-f", "query="+reviewThreadsQuery,
+		"-F", "owner={owner}",
+		"-F", "name={repo}",
+		"-F", fmt.Sprintf("number=%d", prNumber))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list review threads of PR #%d: %w", prNumber, err)
+	}
+
+	return parseReviewThreads([]byte(output), prNumber)
+}
+
+// parseReviewThreads parses the response to reviewThreadsQuery into the
+// unresolved threads of a pull request.
+func parseReviewThreads(output []byte, prNumber int) ([]ReviewThread, error) {
+	var resp struct {
+		Data struct {
+			Repository struct {
+				PullRequest *struct {
+					ReviewThreads struct {
+						Nodes []struct {
+							ID         string `json:"id"`
+							IsResolved bool   `json:"isResolved"`
+							IsOutdated bool   `json:"isOutdated"`
+							Path       string `json:"path"`
+							Line       int    `json:"line"`
+							Comments   struct {
+								Nodes []struct {
+									Author struct {
+										Login string `json:"login"`
+									} `json:"author"`
+									Body string `json:"body"`
+									URL  string `json:"url"`
+								} `json:"nodes"`
+							} `json:"comments"`
+						} `json:"nodes"`
+					} `json:"reviewThreads"`
+				} `json:"pullRequest"`
+			} `json:"repository"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(output, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse review threads of PR #%d: %w", prNumber, err)
+	}
+	pr := resp.Data.Repository.PullRequest
+	if pr == nil {
+		return nil, fmt.Errorf("PR #%d not found", prNumber)
+	}
+
+	var threads []ReviewThread
+	for _, n := range pr.ReviewThreads.Nodes {
+		if n.IsResolved {
+			continue
+		}
+		thread := ReviewThread{
+			ID:         n.ID,
+			Path:       n.Path,
+			Line:       n.Line,
+			IsOutdated: n.IsOutdated,
+		}
+		for _, comment := range n.Comments.Nodes {
+			thread.Comments = append(thread.Comments, ReviewComment{
+				Author: comment.Author.Login,
+				Body:   comment.Body,
+				URL:    comment.URL,
+			})
+		}
+		threads = append(threads, thread)
+	}
+	return threads, nil
+}
+
+// ResolveReviewThread resolves a review thread and replies to it if reply
+// isn't empty. Resolving a resolved thread succeeds, so it goes first: a
+// retry after a failed reply resolves again instead of replying twice.
+func (c *ghClient) ResolveReviewThread(ctx context.Context, dir, threadID, reply string) error {
+	if err := c.run(ctx, dir, "api", "graphql",
+		"-f", "query="+resolveReviewThreadMutation,
+		"-f", "id="+threadID); err != nil {
+		return fmt.Errorf("failed to resolve review thread: %w", err)
+	}
+	if reply == "" {
+		return nil
+	}
+	if err := c.run(ctx, dir, "api", "graphql",
+		"-f", "query="+replyReviewThreadMutation,
+		"-f", "id="+threadID,
+		"-f", "body="+reply); err != nil {
+		return fmt.Errorf("failed to reply to review thread: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+// Package icon provides the status indicators shown in the status bar and
+// the TUIs, as emoji or, in ASCII mode, plain text for
+// terminals and fonts that render emoji poorly.
+package icon
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/donghojung/taw/internal/constants"
+)
+
+// Icon is a status indicator.
+type Icon int
+
+const (
+	Working   Icon = iota // Agent is working
+	Waiting               // Agent needs the user
+	Stuck                 // Agent may be stuck
+	Done                  // Task finished
+	Warning               // Something needs attention
+	New                   // The new task window
+	Success               // A step succeeded
+	Failure               // A step failed
+	Pending               // A step not run (yet)
+	Running               // A step in progress
+	Cursor                // The selected item of a list
+	Dashboard             // The dashboard window
+	Logs                  // The log window
+	Queue                 // Queued tasks
+	Postponed             // An operation waits for another to finish
+)
+
+// forms holds the emoji and ASCII form of each icon.
+var forms = map[Icon][2]string{
+	Working:   {constants.EmojiWorking, constants.ASCIIWorking},
+	Waiting:   {constants.EmojiWaiting, constants.ASCIIWaiting},
+	Stuck:     {constants.EmojiStuck, constants.ASCIIStuck},
+	Done:      {constants.EmojiDone, constants.ASCIIDone},
+	Warning:   {constants.EmojiWarning, constants.ASCIIWarning},
+	New:       {constants.EmojiNew, constants.ASCIINew},
+	Success:   {"✓", "[OK]"},
+	Failure:   {"✗", "[X]"},
+	Pending:   {"○", "[ ]"},
+	Running:   {"●", "[*]"},
+	Cursor:    {"▸", ">"},
+	Dashboard: {"📊", "[D]"},
+	Logs:      {"📜", "[L]"},
+	Queue:     {"📋", "[Q]"},
+	Postponed: {"⏳", "[..]"},
+}
+
+var ascii atomic.Bool
+
+// SetASCII switches all icons to their ASCII form.
+func SetASCII(enabled bool) {
+	ascii.Store(enabled)
+}
+
+// ASCII returns true if icons are shown in their ASCII form.
+func ASCII() bool {
+	return ascii.Load()
+}
+
+// String returns the icon in the current form.
+func (i Icon) String() string {
+	if ascii.Load() {
+		return forms[i][1]
+	}
+	return forms[i][0]
+}
+
+// Label returns name prefixed with the icon, e.g. a window name.
+func (i Icon) Label(name string) string {
+	return i.String() + name
+}
+
+// Decoration returns an emoji followed by a space to decorate a title, or
+// nothing in ASCII mode.
+func Decoration(emoji string) string {
+	if ascii.Load() {
+		return ""
+	}
+	return emoji + " "
+}
+
+// Key returns the display name of an Alt (Option) key binding.
+func Key(key string) string {
+	if ascii.Load() {
+		return "M-" + key
+	}
+	return "⌥" + key
+}
+
+// Localize replaces the window icons and key names in text, such as the
+// agent prompt's rename-window instructions or the help, with their current
+// form.
+func Localize(text string) string {
+	if !ascii.Load() {
+		return text
+	}
+
+	pairs := []string{"⌥", "M-"}
+	for _, i := range []Icon{Working, Waiting, Stuck, Done, Warning, New} {
+		pairs = append(pairs, forms[i][0], forms[i][1])
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
@@ -88,7 +88,7 @@ func (l *fileLogger) Debug(format string, args ...interface{}) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	msg := fmt.Sprintf(format, args...)
+	msg := Redact(fmt.Sprintf(format, args...))
 	fmt.Fprintf(os.Stderr, "[DEBUG] %s\n", msg)
 }
 
@@ -100,7 +100,7 @@ func (l *fileLogger) Log(format string, args ...interface{}) {
 		return
 	}
 
-	msg := fmt.Sprintf(format, args...)
+	msg := Redact(fmt.Sprintf(format, args...))
 	timestamp := time.Now().Format("2006-01-02 15:04:05")
 	context := l.getContext()
 
@@ -115,7 +115,7 @@ func (l *fileLogger) Warn(format string, args ...interface{}) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	msg := fmt.Sprintf(format, args...)
+	msg := Redact(fmt.Sprintf(format, args...))
 	fmt.Fprintf(os.Stderr, "Warning: %s\n", msg)
 
 	if l.file != nil {
@@ -132,7 +132,7 @@ func (l *fileLogger) Error(format string, args ...interface{}) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	msg := fmt.Sprintf(format, args...)
+	msg := Redact(fmt.Sprintf(format, args...))
 	fmt.Fprintf(os.Stderr, "Error: %s\n", msg)
 
 	if l.file != nil {
@@ -155,35 +155,42 @@ func (l *fileLogger) Close() error {
 	return nil
 }
 
-// Global logger instance
-var globalLogger Logger = NewStdout(os.Getenv("TAW_DEBUG") == "1")
+// Global logger instance, set up on first use unless SetGlobal set one
+var (
+	globalLogger     Logger
+	globalLoggerOnce sync.Once
+)
 
 // SetGlobal sets the global logger instance.
 func SetGlobal(l Logger) {
+	globalLoggerOnce.Do(func() {})
 	globalLogger = l
 }
 
 // Global returns the global logger instance.
 func Global() Logger {
+	globalLoggerOnce.Do(func() {
+		globalLogger = NewStdout(os.Getenv("TAW_DEBUG") == "1")
+	})
 	return globalLogger
 }
 
 // Debug logs debug information using the global logger.
 func Debug(format string, args ...interface{}) {
-	globalLogger.Debug(format, args...)
+	Global().Debug(format, args...)
 }
 
 // Log logs information using the global logger.
 func Log(format string, args ...interface{}) {
-	globalLogger.Log(format, args...)
+	Global().Log(format, args...)
 }
 
 // Warn logs a warning using the global logger.
 func Warn(format string, args ...interface{}) {
-	globalLogger.Warn(format, args...)
+	Global().Warn(format, args...)
 }
 
 // Error logs an error using the global logger.
 func Error(format string, args ...interface{}) {
-	globalLogger.Error(format, args...)
+	Global().Error(format, args...)
 }
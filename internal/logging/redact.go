@@ -0,0 +1,89 @@
+// Package logging provides unified logging functionality for TAW.
+package logging
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// redactedText replaces secrets in logs and saved output.
+const redactedText = "[redacted]"
+
+// minSecretLength skips values too short to redact without mangling
+// unrelated text.
+const minSecretLength = 4
+
+// defaultSecretPatterns returns the patterns of common credential formats,
+// redacted even when nobody configured them. They compile on the first
+// redaction rather than at startup.
+var defaultSecretPatterns = sync.OnceValue(func() []*regexp.Regexp {
+	return []*regexp.Regexp{
+		regexp.MustCompile(`gh[pousr]_[A-Za-z0-9]{36,}`),            // GitHub tokens
+		regexp.MustCompile(`github_pat_[A-Za-z0-9_]{22,}`),          // GitHub fine-grained tokens
+		regexp.MustCompile(`sk-ant-[A-Za-z0-9_-]{20,}`),             // Anthropic API keys
+		regexp.MustCompile(`sk-[A-Za-z0-9_-]{20,}`),                 // OpenAI-style API keys
+		regexp.MustCompile(`AKIA[0-9A-Z]{16}`),                      // AWS access key IDs
+		regexp.MustCompile(`xox[abprs]-[A-Za-z0-9-]{10,}`),          // Slack tokens
+		regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9._~+/-]{20,}=*`), // Authorization headers
+		regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[^-]*-----END [A-Z ]*PRIVATE KEY-----`),
+	}
+})
+
+var (
+	redactMu       sync.RWMutex
+	redactor       *strings.Replacer
+	redactPatterns []*regexp.Regexp
+)
+
+// SetRedactions sets the secret values Redact removes. All loggers redact
+// their messages.
+func SetRedactions(secrets []string) {
+	// Longest first, so a secret containing another is redacted whole
+	secrets = append([]string(nil), secrets...)
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+
+	var pairs []string
+	for _, secret := range secrets {
+		if len(secret) >= minSecretLength {
+			pairs = append(pairs, secret, redactedText)
+		}
+	}
+
+	redactMu.Lock()
+	defer redactMu.Unlock()
+	if len(pairs) == 0 {
+		redactor = nil
+		return
+	}
+	redactor = strings.NewReplacer(pairs...)
+}
+
+// SetRedactPatterns sets secret patterns Redact removes in addition to the
+// built-in ones (GitHub, Anthropic, OpenAI, AWS and Slack keys, bearer tokens
+// and private keys).
+func SetRedactPatterns(patterns []*regexp.Regexp) {
+	redactMu.Lock()
+	defer redactMu.Unlock()
+	redactPatterns = patterns
+}
+
+// Redact masks secrets in s: the values set with SetRedactions, then the
+// built-in and configured patterns. Anything TAW writes to disk that may
+// contain command output goes through here.
+func Redact(s string) string {
+	redactMu.RLock()
+	defer redactMu.RUnlock()
+
+	if redactor != nil {
+		s = redactor.Replace(s)
+	}
+	for _, p := range defaultSecretPatterns() {
+		s = p.ReplaceAllLiteralString(s, redactedText)
+	}
+	for _, p := range redactPatterns {
+		s = p.ReplaceAllLiteralString(s, redactedText)
+	}
+	return s
+}
@@ -0,0 +1,132 @@
+// Package slack provides the Slack side of the slash command bridge:
+// verifying signed requests and posting messages to channels.
+package slack
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/donghojung/taw/internal/constants"
+)
+
+// APIURL is the base URL of the Slack Web API.
+const APIURL = "https://slack.com/api/"
+
+// MaxRequestAge is how old a signed request may be, against replays.
+const MaxRequestAge = 5 * time.Minute
+
+// Recognized Slack failures, matched with errors.Is.
+var (
+	ErrBadSignature = errors.New("invalid Slack signature")
+	ErrStaleRequest = errors.New("stale Slack request")
+)
+
+// Verify checks the signature of a request from Slack: the
+// X-Slack-Signature header must be the HMAC-SHA256 of "v0:<timestamp>:<body>"
+// keyed with the app's signing secret, and the timestamp recent.
+func Verify(secret string, header http.Header, body []byte, now time.Time) error {
+	ts := header.Get("X-Slack-Request-Timestamp")
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: no timestamp", ErrBadSignature)
+	}
+	if age := now.Sub(time.Unix(sec, 0)); age > MaxRequestAge || age < -MaxRequestAge {
+		return ErrStaleRequest
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:", ts)
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(header.Get("X-Slack-Signature"))) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// Response is the reply to a slash command.
+type Response struct {
+	ResponseType string `json:"response_type"` // in_channel, or ephemeral (only the user sees it)
+	Text         string `json:"text"`
+}
+
+// Origin is where a task was requested in Slack, kept with the task to tell
+// the channel how it went.
+type Origin struct {
+	Channel string `json:"channel"` // Channel ID
+	User    string `json:"user"`    // ID of the user who asked
+}
+
+// Client defines the interface for the Slack Web API.
+type Client interface {
+	// PostMessage posts a message to a channel.
+	PostMessage(ctx context.Context, channel, text string) error
+}
+
+// apiClient implements the Client interface with a bot token.
+type apiClient struct {
+	apiURL string
+	token  string
+	client *http.Client
+}
+
+// New creates a Slack client with a bot token (xoxb-...). An empty apiURL
+// uses APIURL; a non-positive timeout uses the default.
+func New(apiURL, token string, timeout time.Duration) Client {
+	if apiURL == "" {
+		apiURL = APIURL
+	}
+	if timeout <= 0 {
+		timeout = constants.DefaultNetworkTimeout
+	}
+	return &apiClient{
+		apiURL: apiURL,
+		token:  token,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// PostMessage posts a message to a channel with chat.postMessage.
+func (c *apiClient) PostMessage(ctx context.Context, channel, text string) error {
+	data, err := json.Marshal(map[string]string{"channel": channel, "text": text})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL+"chat.postMessage", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post to Slack: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to post to Slack: %s", resp.Status)
+	}
+
+	// Slack reports errors in the body, with 200
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to post to Slack: %w", err)
+	}
+	if !result.OK {
+		return fmt.Errorf("failed to post to Slack: %s", result.Error)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/donghojung/taw/internal/constants"
+	"github.com/donghojung/taw/internal/git"
+	"github.com/donghojung/taw/internal/logging"
+)
+
+// ArchiveOutcome describes how a task ended.
+type ArchiveOutcome string
+
+const (
+	OutcomeMerged    ArchiveOutcome = "merged"    // Merged into main (or enqueued)
+	OutcomeCompleted ArchiveOutcome = "completed" // Ended without merging
+	OutcomeDiscarded ArchiveOutcome = "discarded" // Losing competing draft
+)
+
+// ArchiveEntry is the persistent record of a task, kept after cleanup for
+// later reporting.
+type ArchiveEntry struct {
+	TaskName    string         `json:"task_name"`
+	Content     string         `json:"content,omitempty"`
+	Owner       string         `json:"owner,omitempty"`
+	PRNumber    int            `json:"pr_number,omitempty"`
+	PRSummary   *PRSummary     `json:"pr_summary,omitempty"`
+	Outcome     ArchiveOutcome `json:"outcome,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	StartedAt   time.Time      `json:"started_at,omitempty"`
+	CompletedAt time.Time      `json:"completed_at,omitempty"`
+
+	// Time the task spent waiting for the user
+	WaitingTime time.Duration `json:"waiting_time,omitempty"`
+
+	// Diff against main, recorded before merge
+	Files      int `json:"files,omitempty"`
+	Insertions int `json:"insertions,omitempty"`
+	Deletions  int `json:"deletions,omitempty"`
+
+	// Files changed in a project that isn't a git repository
+	Changes *FileChanges `json:"changes,omitempty"`
+
+	// Failures seen along the way
+	VerifyFailures int `json:"verify_failures,omitempty"`
+	PushFailures   int `json:"push_failures,omitempty"`
+
+	Path string `json:"-"`
+}
+
+// Archive stores task records in .taw/archive/, one JSON file per task run.
+type Archive struct {
+	dir string
+}
+
+// NewArchive creates a new archive for the given directory.
+func NewArchive(dir string) *Archive {
+	return &Archive{
+		dir: dir,
+	}
+}
+
+// Archive returns the project's task archive.
+func (m *Manager) Archive() *Archive {
+	return NewArchive(filepath.Join(m.tawDir, constants.ArchiveDirName))
+}
+
+// RecordDiff archives the task branch's diff against main (error is non-fatal).
+// Must run before the merge, which empties the diff.
+func (m *Manager) RecordDiff(ctx context.Context, task *Task) {
+	if !m.isGitRepo {
+		return
+	}
+
+	stat, err := m.gitClient.GetBranchDiffStat(ctx, m.projectDir, m.MainBranch(ctx), task.GetBranch())
+	if err != nil {
+		return
+	}
+
+	files, insertions, deletions := git.ParseShortStat(stat)
+	if err := m.Archive().Record(task.Name, func(e *ArchiveEntry) {
+		e.Files, e.Insertions, e.Deletions = files, insertions, deletions
+	}); err != nil {
+		// Archive is informational only
+	}
+}
+
+// RecordCompletion archives how a task ended (error is non-fatal).
+func (m *Manager) RecordCompletion(task *Task, outcome ArchiveOutcome) {
+	if err := task.RecordCompleted(); err != nil {
+		// The timeline is informational only
+	}
+	timeline := task.LoadTimeline()
+
+	if err := m.Archive().Record(task.Name, func(e *ArchiveEntry) {
+		e.Outcome = outcome
+		e.CompletedAt = time.Now()
+		e.StartedAt = timeline.StartedAt
+		e.WaitingTime = timeline.Waiting
+		if e.PRNumber == 0 {
+			e.PRNumber = task.PRNumber
+		}
+	}); err != nil {
+		// Archive is informational only
+	}
+}
+
+// RecordVerifyFailure counts a failed verification run (error is non-fatal).
+func (m *Manager) RecordVerifyFailure(task *Task) {
+	if err := m.Archive().Record(task.Name, func(e *ArchiveEntry) {
+		e.VerifyFailures++
+	}); err != nil {
+		// Archive is informational only
+	}
+}
+
+// Record updates the open (not yet completed) entry for a task, creating it
+// if needed.
+func (a *Archive) Record(taskName string, update func(*ArchiveEntry)) error {
+	if err := os.MkdirAll(a.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	entry, err := a.openEntry(taskName)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if entry == nil {
+		entry = &ArchiveEntry{
+			TaskName:  taskName,
+			CreatedAt: now,
+			Path:      filepath.Join(a.dir, fmt.Sprintf("%s-%d.json", taskName, now.Unix())),
+		}
+	}
+
+	update(entry)
+	entry.UpdatedAt = now
+
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode archive entry: %w", err)
+	}
+	// Summaries quote command output, which may contain secrets
+	if err := os.WriteFile(entry.Path, []byte(logging.Redact(string(data))), 0644); err != nil {
+		return fmt.Errorf("failed to write archive entry: %w", err)
+	}
+	return nil
+}
+
+// List returns all archived entries, oldest first.
+func (a *Archive) List() ([]ArchiveEntry, error) {
+	entries, err := os.ReadDir(a.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read archive directory: %w", err)
+	}
+
+	var archived []ArchiveEntry
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+
+		path := filepath.Join(a.dir, e.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		var entry ArchiveEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			continue
+		}
+		entry.Path = path
+		archived = append(archived, entry)
+	}
+
+	sort.Slice(archived, func(i, j int) bool {
+		return archived[i].CreatedAt.Before(archived[j].CreatedAt)
+	})
+
+	return archived, nil
+}
+
+// openEntry returns the latest entry for a task that isn't completed yet.
+func (a *Archive) openEntry(taskName string) (*ArchiveEntry, error) {
+	archived, err := a.List()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(archived) - 1; i >= 0; i-- {
+		if archived[i].TaskName == taskName && archived[i].CompletedAt.IsZero() {
+			return &archived[i], nil
+		}
+	}
+	return nil, nil
+}
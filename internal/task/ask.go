@@ -0,0 +1,78 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/donghojung/taw/internal/constants"
+)
+
+// askTag marks a task that only asks a question about the codebase.
+const askTag = "ask"
+
+// AskContent returns the content of a task asking question.
+func AskContent(question string) string {
+	return strings.TrimSpace(question) + "\n\n#" + askTag + "\n"
+}
+
+// IsAsk returns true if the task only asks a question about the codebase
+// (#ask): it reads the project directory without a branch or commits, and
+// its answer is kept in .taw/answers when it ends.
+func (t *Task) IsAsk() bool {
+	for _, tag := range t.Tags() {
+		if strings.EqualFold(tag, askTag) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAnswerPath returns the path of the file the agent of an ask task writes
+// its answer to.
+func (t *Task) GetAnswerPath() string {
+	return filepath.Join(t.AgentDir, constants.AnswerFileName)
+}
+
+// answerPath returns the path an ask task's answer is kept at.
+func (m *Manager) answerPath(name string) string {
+	return filepath.Join(m.tawDir, constants.AnswersDirName, name+".md")
+}
+
+// SaveAnswer keeps the answer of an ask task in .taw/answers/<task>.md with
+// its question: the file its agent wrote, else transcript, the end of its
+// pane. It returns the path of the kept answer.
+func (m *Manager) SaveAnswer(task *Task, transcript string) (string, error) {
+	answer := readTrimmed(task.GetAnswerPath())
+	if answer == "" {
+		answer = strings.TrimSpace(transcript)
+	}
+	if answer == "" {
+		return "", fmt.Errorf("%s left no answer", task.Name)
+	}
+
+	question := strings.TrimSpace(tagPattern.ReplaceAllStringFunc(task.Content, func(tag string) string {
+		if strings.EqualFold(strings.TrimSpace(tag), "#"+askTag) {
+			return ""
+		}
+		return tag
+	}))
+
+	path := m.answerPath(task.Name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create answers directory: %w", err)
+	}
+	content := fmt.Sprintf("# %s\n\n## Question\n\n%s\n\n## Answer\n\n%s\n", task.Name, question, answer)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("failed to save answer: %w", err)
+	}
+	return path, nil
+}
+
+// LoadAnswer returns the kept answer of a task with its question, or "" if
+// it has none.
+func (m *Manager) LoadAnswer(name string) string {
+	return readTrimmed(m.answerPath(name))
+}
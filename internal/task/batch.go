@@ -0,0 +1,396 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/donghojung/taw/internal/config"
+	"github.com/donghojung/taw/internal/constants"
+)
+
+// Batch is a set of tasks queued together by 'taw batch' to run unattended,
+// e.g. overnight. Its tasks are tagged with a "Batch: <id>" line.
+type Batch struct {
+	ID         string            `json:"id"`
+	File       string            `json:"file"`
+	OnComplete config.OnComplete `json:"on_complete"` // Used by its tasks instead of on_complete
+	Tasks      int               `json:"tasks"`
+	CreatedAt  time.Time         `json:"created_at"`
+	FinishedAt time.Time         `json:"finished_at,omitempty"` // No task left queued or running
+}
+
+// BatchResult is how a task of a batch turned out.
+type BatchResult string
+
+const (
+	BatchSucceeded BatchResult = "succeeded"    // Ended, or done with its work pushed
+	BatchFailed    BatchResult = "failed"       // Verification or push failed, or the task is broken
+	BatchReview    BatchResult = "needs review" // Waiting for the user
+	BatchRunning   BatchResult = "running"      // Agent still working
+	BatchPending   BatchResult = "not started"  // Still queued
+)
+
+// batchResults are the results in report order.
+var batchResults = []BatchResult{BatchSucceeded, BatchFailed, BatchReview, BatchRunning, BatchPending}
+
+// BatchItem is a task of a batch in its report.
+type BatchItem struct {
+	Title    string      `json:"title"`
+	TaskName string      `json:"task_name,omitempty"` // Empty while queued
+	Result   BatchResult `json:"result"`
+	Detail   string      `json:"detail,omitempty"`
+	PRNumber int         `json:"pr_number,omitempty"`
+}
+
+// BatchReport is the morning report of a batch: what succeeded, failed or
+// needs review.
+type BatchReport struct {
+	Batch *Batch      `json:"batch"`
+	Items []BatchItem `json:"items"`
+}
+
+// batchLine matches the line of task content naming the batch of the task.
+var batchLine = regexp.MustCompile(`(?m)^Batch: ([0-9]{8}-[0-9]{6})[ \t]*$`)
+
+// BatchFromContent returns the ID of the batch named in task content by a
+// "Batch: <id>" line, or "" if there is none.
+func BatchFromContent(content string) string {
+	if m := batchLine.FindStringSubmatch(content); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// BatchID returns the ID of the batch the task was queued in, or "".
+func (t *Task) BatchID() string {
+	content, err := t.LoadContent()
+	if err != nil {
+		return ""
+	}
+	return BatchFromContent(content)
+}
+
+// ParseBatchFile splits a markdown file into task contents, one per "## "
+// section, whose heading becomes the first line of the task. Text before the
+// first section is ignored.
+func ParseBatchFile(content string) []string {
+	var sections []string
+	var current *strings.Builder
+	flush := func() {
+		if current != nil {
+			if section := strings.TrimSpace(current.String()); section != "" {
+				sections = append(sections, section)
+			}
+		}
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		if heading, ok := strings.CutPrefix(line, "## "); ok {
+			flush()
+			current = &strings.Builder{}
+			current.WriteString(strings.TrimSpace(heading) + "\n")
+			continue
+		}
+		if current != nil {
+			current.WriteString(line + "\n")
+		}
+	}
+	flush()
+	return sections
+}
+
+// NewBatch returns a new batch of tasks, and their contents tagged with it.
+func NewBatch(file string, sections []string, onComplete config.OnComplete) (*Batch, []string) {
+	now := time.Now()
+	b := &Batch{
+		ID:         now.Format(constants.BatchIDFormat),
+		File:       file,
+		OnComplete: onComplete,
+		Tasks:      len(sections),
+		CreatedAt:  now,
+	}
+
+	contents := make([]string, len(sections))
+	for i, section := range sections {
+		contents[i] = fmt.Sprintf("%s\n\nBatch: %s\n", section, b.ID)
+	}
+	return b, contents
+}
+
+// getBatchDir returns the directory of batch records and reports.
+func (m *Manager) getBatchDir() string {
+	return filepath.Join(m.tawDir, constants.BatchDirName)
+}
+
+// GetBatchReportPath returns where the report of a finished batch is saved.
+func (m *Manager) GetBatchReportPath(b *Batch) string {
+	return filepath.Join(m.getBatchDir(), b.ID+".md")
+}
+
+// SaveBatch saves a batch record.
+func (m *Manager) SaveBatch(b *Batch) error {
+	if err := os.MkdirAll(m.getBatchDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create batch directory: %w", err)
+	}
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(m.getBatchDir(), b.ID+".json"), data, 0644); err != nil {
+		return fmt.Errorf("failed to save batch: %w", err)
+	}
+	return nil
+}
+
+// LoadBatch loads a batch record.
+func (m *Manager) LoadBatch(id string) (*Batch, error) {
+	data, err := os.ReadFile(filepath.Join(m.getBatchDir(), id+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("batch %s not found", id)
+		}
+		return nil, err
+	}
+	var b Batch
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("failed to read batch %s: %w", id, err)
+	}
+	return &b, nil
+}
+
+// ListBatches returns the batch records, oldest first.
+func (m *Manager) ListBatches() ([]*Batch, error) {
+	entries, err := os.ReadDir(m.getBatchDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read batch directory: %w", err)
+	}
+
+	var batches []*Batch
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".json")
+		if e.IsDir() || !ok {
+			continue
+		}
+		if b, err := m.LoadBatch(id); err == nil {
+			batches = append(batches, b)
+		}
+	}
+	sort.Slice(batches, func(i, j int) bool {
+		return batches[i].ID < batches[j].ID
+	})
+	return batches, nil
+}
+
+// OnComplete returns what happens when the task completes: the mode of its
+// batch, or on_complete. A batch mode the organization policy doesn't allow
+// (any more) falls back to on_complete. Tasks addressing review comments push
+// to their pull request instead of merging it.
+func (m *Manager) OnComplete(task *Task) config.OnComplete {
+	if id := task.BatchID(); id != "" {
+		if b, err := m.LoadBatch(id); err == nil && b.OnComplete != "" {
+			policy, err := config.LoadPolicy()
+			if err == nil && policy.Check("on_complete", string(b.OnComplete)) == nil {
+				return b.OnComplete
+			}
+		}
+	}
+	if m.config == nil {
+		return config.OnCompleteConfirm
+	}
+	if m.config.OnComplete == config.OnCompleteAutoMerge && task.Review() != nil {
+		return config.OnCompleteAutoPR
+	}
+	return m.config.OnComplete
+}
+
+// BatchReport reports how each task of a batch turned out, from the archive,
+// the open tasks and the queue.
+func (m *Manager) BatchReport(b *Batch, queue []QueuedTask) (*BatchReport, error) {
+	entries, err := m.Archive().List()
+	if err != nil {
+		return nil, err
+	}
+
+	// The last run of each task counts
+	var names []string
+	latest := make(map[string]ArchiveEntry)
+	for _, e := range entries {
+		if BatchFromContent(e.Content) != b.ID || e.Outcome == OutcomeDiscarded {
+			continue
+		}
+		if _, seen := latest[e.TaskName]; !seen {
+			names = append(names, e.TaskName)
+		}
+		latest[e.TaskName] = e
+	}
+
+	r := &BatchReport{Batch: b}
+	for _, name := range names {
+		e := latest[name]
+		if !e.CompletedAt.IsZero() {
+			r.Items = append(r.Items, BatchItem{
+				Title:    contentTitle(e.Content),
+				TaskName: name,
+				Result:   BatchSucceeded,
+				Detail:   string(e.Outcome),
+				PRNumber: e.PRNumber,
+			})
+			continue
+		}
+		// Tasks cleaned up without ending are left out
+		if t, err := m.GetTask(name); err == nil {
+			r.Items = append(r.Items, batchItem(t, contentTitle(e.Content)))
+		}
+	}
+
+	for _, q := range queue {
+		if BatchFromContent(q.Content) == b.ID {
+			r.Items = append(r.Items, BatchItem{Title: contentTitle(q.Content), Result: BatchPending})
+		}
+	}
+	return r, nil
+}
+
+// batchItem returns how an open task of a batch is doing.
+func batchItem(t *Task, title string) BatchItem {
+	item := BatchItem{Title: title, TaskName: t.Name}
+	item.PRNumber, _ = t.LoadPRNumber()
+
+	status := t.LoadStatus()
+	verify := t.LoadVerifyResult()
+	switch {
+	case status == StatusPushFailed:
+		item.Result = BatchFailed
+		item.Detail = "push failed"
+		if hint := t.LoadPushFailure(); hint != "" {
+			item.Detail += ": " + hint
+		}
+	case status == StatusCorrupted:
+		item.Result = BatchFailed
+		item.Detail = "worktree or branch is broken"
+	case verify != nil && !verify.Passed():
+		item.Result = BatchFailed
+		item.Detail = "verification " + verify.Summary()
+	case status == StatusWaiting:
+		item.Result = BatchReview
+		item.Detail = "waiting for input"
+	case status == StatusDone:
+		item.Result = BatchSucceeded
+		item.Detail = "done, not ended yet"
+	default:
+		item.Result = BatchRunning
+	}
+	return item
+}
+
+// Count returns the number of tasks with a result.
+func (r *BatchReport) Count(result BatchResult) int {
+	n := 0
+	for _, item := range r.Items {
+		if item.Result == result {
+			n++
+		}
+	}
+	return n
+}
+
+// Finished returns true if no task of the batch is queued or running.
+func (r *BatchReport) Finished() bool {
+	return r.Count(BatchRunning) == 0 && r.Count(BatchPending) == 0
+}
+
+// Summary counts the tasks by result, e.g. "3 succeeded, 1 failed".
+func (r *BatchReport) Summary() string {
+	var parts []string
+	for _, result := range batchResults {
+		if n := r.Count(result); n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, result))
+		}
+	}
+	if len(parts) == 0 {
+		return "no tasks"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Markdown renders the report.
+func (r *BatchReport) Markdown() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "## TAW batch %s (%s)\n\n", r.Batch.ID, r.Batch.File)
+	fmt.Fprintf(&sb, "- Started: %s\n", r.Batch.CreatedAt.Format("2006-01-02 15:04"))
+	if !r.Batch.FinishedAt.IsZero() {
+		fmt.Fprintf(&sb, "- Finished: %s (%s)\n", r.Batch.FinishedAt.Format("2006-01-02 15:04"),
+			FormatDuration(r.Batch.FinishedAt.Sub(r.Batch.CreatedAt)))
+	}
+	fmt.Fprintf(&sb, "- On complete: %s\n", r.Batch.OnComplete)
+	fmt.Fprintf(&sb, "- Tasks: %d (%s)\n", len(r.Items), r.Summary())
+
+	for _, result := range batchResults {
+		if r.Count(result) == 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, "\n### %s%s\n\n", strings.ToUpper(string(result[:1])), result[1:])
+		for _, item := range r.Items {
+			if item.Result != result {
+				continue
+			}
+			line := "- " + item.Title
+			if item.TaskName != "" {
+				line = fmt.Sprintf("- %s (%s)", item.TaskName, item.Title)
+			}
+			if item.PRNumber > 0 {
+				line += fmt.Sprintf(" #%d", item.PRNumber)
+			}
+			if item.Detail != "" {
+				line += ": " + item.Detail
+			}
+			sb.WriteString(line + "\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// FinishBatches checks the batches that were still running, and saves the
+// report of each batch that has now finished. It returns their reports.
+func (m *Manager) FinishBatches(queue []QueuedTask) ([]*BatchReport, error) {
+	batches, err := m.ListBatches()
+	if err != nil {
+		return nil, err
+	}
+
+	var finished []*BatchReport
+	for _, b := range batches {
+		if !b.FinishedAt.IsZero() {
+			continue
+		}
+		r, err := m.BatchReport(b, queue)
+		if err != nil {
+			return finished, err
+		}
+		if !r.Finished() {
+			continue
+		}
+
+		b.FinishedAt = time.Now()
+		if err := m.SaveBatch(b); err != nil {
+			return finished, err
+		}
+		if err := os.WriteFile(m.GetBatchReportPath(b), []byte(r.Markdown()), 0644); err != nil {
+			return finished, fmt.Errorf("failed to save batch report: %w", err)
+		}
+		finished = append(finished, r)
+	}
+	return finished, nil
+}
@@ -0,0 +1,268 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/donghojung/taw/internal/claude"
+	"github.com/donghojung/taw/internal/config"
+	"github.com/donghojung/taw/internal/constants"
+)
+
+// Spend is the estimated Claude cost of the tasks of a day, kept in
+// .taw/spend/<date>.json so tasks that were cleaned up still count.
+type Spend struct {
+	Date       string             `json:"date"`
+	Tasks      map[string]float64 `json:"tasks"`
+	DailyLimit float64            `json:"daily_limit,omitempty"` // Overrides budget.daily_usd for the day
+	Warned     bool               `json:"warned,omitempty"`      // The user was told the day went over budget
+}
+
+// Total returns the cost of all tasks of the day.
+func (s *Spend) Total() float64 {
+	total := 0.0
+	for _, cost := range s.Tasks {
+		total += cost
+	}
+	return total
+}
+
+// BudgetStatus is the cost of a task and of the day against their budgets.
+// Budgets of 0 are unlimited.
+type BudgetStatus struct {
+	TaskCost   float64
+	TaskBudget float64
+	DayCost    float64
+	DayBudget  float64
+
+	// Set when the task or the day went over budget with this check
+	TaskExceeded bool
+	DayExceeded  bool
+}
+
+// TaskOver returns true if the task is over its budget.
+func (s *BudgetStatus) TaskOver() bool {
+	return s.TaskBudget > 0 && s.TaskCost >= s.TaskBudget
+}
+
+// DayOver returns true if the day is over its budget.
+func (s *BudgetStatus) DayOver() bool {
+	return s.DayBudget > 0 && s.DayCost >= s.DayBudget
+}
+
+// spendPath returns the path to the spending of the day of t.
+func (m *Manager) spendPath(t time.Time) string {
+	return filepath.Join(m.tawDir, constants.SpendDirName, t.Format("2006-01-02")+".json")
+}
+
+// LoadSpend returns the spending of the day of t.
+func (m *Manager) LoadSpend(t time.Time) *Spend {
+	spend := &Spend{Date: t.Format("2006-01-02"), Tasks: make(map[string]float64)}
+	data, err := os.ReadFile(m.spendPath(t))
+	if err != nil {
+		return spend
+	}
+	if err := json.Unmarshal(data, spend); err != nil || spend.Tasks == nil {
+		spend.Tasks = make(map[string]float64)
+	}
+	return spend
+}
+
+// SpendSince returns the cost of each task from the day of since to today.
+func (m *Manager) SpendSince(since time.Time) map[string]float64 {
+	costs := make(map[string]float64)
+	for day := startOfDay(since); !day.After(time.Now()); day = day.AddDate(0, 0, 1) {
+		for name, cost := range m.LoadSpend(day).Tasks {
+			costs[name] += cost
+		}
+	}
+	return costs
+}
+
+// saveSpend writes the spending of today.
+func (m *Manager) saveSpend(spend *Spend) error {
+	path := m.spendPath(time.Now())
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(spend, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// HasBudget returns true if a per-task or daily budget is set.
+func (m *Manager) HasBudget() bool {
+	return m.config != nil && (m.config.Budget.PerTaskUSD > 0 || m.config.Budget.DailyUSD > 0) ||
+		m.LoadSpend(time.Now()).DailyLimit > 0
+}
+
+// BudgetPolicy returns what happens when a budget is exceeded.
+func (m *Manager) BudgetPolicy() config.BudgetPolicy {
+	if m.config == nil || m.config.Budget.OnExceed == "" {
+		return config.BudgetWarn
+	}
+	return m.config.Budget.OnExceed
+}
+
+// TaskBudget returns the budget of a task: its override, or
+// budget.per_task_usd. 0 is unlimited.
+func (m *Manager) TaskBudget(task *Task) float64 {
+	if data, err := os.ReadFile(filepath.Join(task.AgentDir, constants.BudgetFileName)); err == nil {
+		if f, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64); err == nil {
+			return f
+		}
+	}
+	if m.config == nil {
+		return 0
+	}
+	return m.config.Budget.PerTaskUSD
+}
+
+// SetTaskBudget overrides the budget of a task (0 is unlimited), and warns
+// again once the task goes over it.
+func (m *Manager) SetTaskBudget(task *Task, usd float64) error {
+	os.Remove(filepath.Join(task.AgentDir, constants.BudgetWarnedFile))
+	return os.WriteFile(filepath.Join(task.AgentDir, constants.BudgetFileName), []byte(strconv.FormatFloat(usd, 'f', -1, 64)), 0644)
+}
+
+// DailyBudget returns today's budget: its override, or budget.daily_usd.
+// 0 is unlimited.
+func (m *Manager) DailyBudget() float64 {
+	if limit := m.LoadSpend(time.Now()).DailyLimit; limit > 0 {
+		return limit
+	}
+	if m.config == nil {
+		return 0
+	}
+	return m.config.Budget.DailyUSD
+}
+
+// SetDailyBudget overrides today's budget, and warns again once the day
+// goes over it.
+func (m *Manager) SetDailyBudget(usd float64) error {
+	spend := m.LoadSpend(time.Now())
+	spend.DailyLimit = usd
+	spend.Warned = false
+	return m.saveSpend(spend)
+}
+
+// BudgetPaused returns true if new tasks wait because today is over budget
+// and the policy pauses them.
+func (m *Manager) BudgetPaused() bool {
+	if m.BudgetPolicy() == config.BudgetWarn {
+		return false
+	}
+	budget := m.DailyBudget()
+	return budget > 0 && m.LoadSpend(time.Now()).Total() >= budget
+}
+
+// ErrSharedCost is returned for the cost of a task working in the project
+// directory: the transcripts there are shared with the other tasks and the
+// user's own sessions, so no task can be charged for them.
+var ErrSharedCost = errors.New("cost is shared with the project directory")
+
+// sharedSpendKey records the day's cost of the tasks in the project directory.
+const sharedSpendKey = "(project directory)"
+
+// TaskCost returns the estimated cost of a task since it was created, in
+// total and today, from the transcripts of the sessions in its working
+// directory, or ErrSharedCost if that is the project directory.
+func (m *Manager) TaskCost(task *Task) (total, today float64, err error) {
+	workDir := m.GetWorkingDirectory(task)
+	if workDir == m.projectDir {
+		return 0, 0, ErrSharedCost
+	}
+	usage, err := claude.LoadUsage(workDir, task.CreatedAt, time.Time{})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read transcripts: %w", err)
+	}
+
+	midnight := startOfDay(time.Now())
+	if task.CreatedAt.After(midnight) {
+		return usage.CostUSD, usage.CostUSD, nil
+	}
+	todayUsage, err := claude.LoadUsage(workDir, midnight, time.Time{})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read transcripts: %w", err)
+	}
+	return usage.CostUSD, todayUsage.CostUSD, nil
+}
+
+// CheckBudget records today's cost of a task and compares the task and the
+// day with their budgets. Going over a budget is reported once, until the
+// budget is raised. Tasks in the project directory only count towards the
+// day, together.
+func (m *Manager) CheckBudget(task *Task) (*BudgetStatus, error) {
+	total, today, err := m.TaskCost(task)
+	shared := errors.Is(err, ErrSharedCost)
+	if shared {
+		usage, err := claude.LoadUsage(m.projectDir, startOfDay(time.Now()), time.Time{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read transcripts: %w", err)
+		}
+		today = usage.CostUSD
+	} else if err != nil {
+		return nil, err
+	}
+
+	spend := m.LoadSpend(time.Now())
+	status := &BudgetStatus{DayBudget: m.DailyBudget()}
+	if shared {
+		spend.Tasks[sharedSpendKey] = today
+	} else {
+		spend.Tasks[task.Name] = today
+		status.TaskCost, status.TaskBudget = total, m.TaskBudget(task)
+	}
+	status.DayCost = spend.Total()
+
+	warnedPath := filepath.Join(task.AgentDir, constants.BudgetWarnedFile)
+	if status.TaskOver() {
+		if _, err := os.Stat(warnedPath); os.IsNotExist(err) {
+			status.TaskExceeded = true
+			if err := os.WriteFile(warnedPath, []byte(strconv.FormatFloat(total, 'f', 2, 64)), 0644); err != nil {
+				return status, err
+			}
+		}
+	}
+	if status.DayOver() && !spend.Warned {
+		status.DayExceeded = true
+		spend.Warned = true
+	}
+	return status, m.saveSpend(spend)
+}
+
+// startOfDay returns the midnight starting the day of t.
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// StopAgent stops the agent of a task that went over budget, leaving a shell
+// in its pane, and sets the task to waiting. The dashboard can restart it.
+func (m *Manager) StopAgent(task *Task) error {
+	if m.tmuxClient == nil {
+		return fmt.Errorf("no tmux session to stop the agent in")
+	}
+	windowID, err := task.LoadWindowID()
+	if err != nil {
+		return fmt.Errorf("failed to load window ID: %w", err)
+	}
+
+	if err := m.tmuxClient.RespawnPane(windowID+".0", m.GetWorkingDirectory(task)); err != nil {
+		return fmt.Errorf("failed to stop agent: %w", err)
+	}
+	if err := task.SaveStatus(StatusWaiting); err != nil {
+		return fmt.Errorf("failed to save status: %w", err)
+	}
+	task.clearHealth()
+	m.syncWindow(task, windowID)
+	return nil
+}
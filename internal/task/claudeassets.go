@@ -0,0 +1,291 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/donghojung/taw/internal/config"
+	"github.com/donghojung/taw/internal/constants"
+	"github.com/donghojung/taw/internal/embed"
+	"github.com/donghojung/taw/internal/logging"
+)
+
+// assetSyncState maps each file TAW generated in a worktree (relative to the
+// worktree) to the checksum it last wrote, so files changed since (by the
+// project or the agent) are left alone.
+type assetSyncState map[string]string
+
+// SyncClaudeAssets prepares the worktree for Claude before the agent starts:
+//   - slash commands from the embedded assets and the project's .taw/commands/
+//     (which override embedded ones of the same name) go to .claude/commands/
+//   - the shared settings plus the configured tool permissions for the task's
+//     tags go to .claude/settings.local.json
+//   - the configured MCP servers for the task's tags go to .mcp.json
+//
+// Files are only rewritten when their content changed and the copy in the
+// worktree was not edited. Files the project tracks are left alone, and the
+// others are listed in the repository's info/exclude so commits of the task
+// never pick them up.
+func (m *Manager) SyncClaudeAssets(ctx context.Context, task *Task) error {
+	if !m.UsesWorktree(task) {
+		return nil
+	}
+
+	worktreeDir := task.GetWorktreeDir()
+	claudeDir := filepath.Join(worktreeDir, constants.ClaudeLink)
+
+	// Worktrees from older versions link .claude to the shared assets;
+	// replace the link so syncing never writes into them
+	if info, err := os.Lstat(claudeDir); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if err := os.Remove(claudeDir); err != nil {
+			return fmt.Errorf("failed to remove .claude symlink: %w", err)
+		}
+	}
+
+	files, err := m.loadCommands()
+	if err != nil {
+		return err
+	}
+
+	tools := m.config.Tools.Resolve(task.Tags())
+
+	// A .mcp.json the project tracks stays as it is: a generated one would
+	// replace it in the task's commits, and its servers aren't approved up front
+	if len(tools.MCP) > 0 {
+		tracked, err := m.gitClient.TrackedFiles(ctx, worktreeDir, []string{constants.MCPConfigFileName})
+		if err != nil {
+			return fmt.Errorf("failed to list tracked files: %w", err)
+		}
+		if len(tracked) > 0 {
+			logging.Warn("The project tracks %s; MCP servers from the tools settings are not added", constants.MCPConfigFileName)
+			tools.MCP = nil
+		}
+	}
+
+	settings, err := m.buildSettings(tools)
+	if err != nil {
+		return err
+	}
+	files[filepath.Join(constants.ClaudeLink, constants.SettingsFileName)] = settings
+
+	if len(tools.MCP) > 0 {
+		mcp, err := buildMCPConfig(tools.MCP)
+		if err != nil {
+			return err
+		}
+		files[constants.MCPConfigFileName] = mcp
+	}
+
+	statePath := filepath.Join(claudeDir, constants.AssetSyncFileName)
+	state := make(assetSyncState)
+	if data, err := os.ReadFile(statePath); err == nil {
+		if err := json.Unmarshal(data, &state); err != nil {
+			state = make(assetSyncState)
+		}
+	}
+
+	paths := []string{filepath.Join(constants.ClaudeLink, constants.AssetSyncFileName)}
+	for rel := range files {
+		paths = append(paths, rel)
+	}
+	tracked, err := m.gitClient.TrackedFiles(ctx, worktreeDir, paths)
+	if err != nil {
+		return fmt.Errorf("failed to list tracked files: %w", err)
+	}
+	for _, rel := range tracked {
+		delete(files, filepath.FromSlash(rel))
+	}
+
+	excludes := []string{"/" + filepath.ToSlash(paths[0])}
+	for rel, content := range files {
+		if err := syncFile(filepath.Join(worktreeDir, rel), content, rel, state); err != nil {
+			return err
+		}
+		excludes = append(excludes, "/"+filepath.ToSlash(rel))
+	}
+	sort.Strings(excludes)
+	if err := m.gitClient.Exclude(ctx, worktreeDir, excludes); err != nil {
+		return fmt.Errorf("failed to exclude synced files from git: %w", err)
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(statePath, data, 0644)
+}
+
+// syncFile writes content to path unless the file there was changed since TAW
+// last wrote it, and records the written checksum in state under key.
+func syncFile(path string, content []byte, key string, state assetSyncState) error {
+	sum := checksum(content)
+
+	// Links (from older versions) are TAW's own; replace them with the file
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+	}
+
+	if existing, err := os.ReadFile(path); err == nil {
+		current := checksum(existing)
+		if current == sum {
+			state[key] = sum
+			return nil
+		}
+		if current != state[key] {
+			// Edited in the worktree or tracked by the project
+			return nil
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	state[key] = sum
+	return nil
+}
+
+// loadCommands returns the slash commands to sync, keyed by their path
+// relative to the worktree.
+func (m *Manager) loadCommands() (map[string][]byte, error) {
+	commandsDir := filepath.Join(constants.ClaudeLink, constants.CommandsDirName)
+	commands := make(map[string][]byte)
+
+	names, err := embed.ListCommands()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list embedded commands: %w", err)
+	}
+	for _, name := range names {
+		content, err := embed.GetCommand(name)
+		if err != nil {
+			return nil, err
+		}
+		commands[filepath.Join(commandsDir, name+".md")] = []byte(content)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(m.tawDir, constants.CommandsDirName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return commands, nil
+		}
+		return nil, fmt.Errorf("failed to read project commands: %w", err)
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".md") {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(m.tawDir, constants.CommandsDirName, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		commands[filepath.Join(commandsDir, e.Name())] = content
+	}
+
+	return commands, nil
+}
+
+// buildSettings returns the shared Claude settings (the installed copy, or
+// the embedded one) with the task's permission rules added.
+func (m *Manager) buildSettings(tools config.ToolSet) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(m.tawDir, constants.ClaudeLink, constants.SettingsFileName))
+	if err != nil {
+		data, err = embed.GetAsset("assets/claude/" + constants.SettingsFileName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	settings := make(map[string]any)
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", constants.SettingsFileName, err)
+	}
+
+	permissions, _ := settings["permissions"].(map[string]any)
+	if permissions == nil {
+		permissions = make(map[string]any)
+	}
+	permissions["allow"] = appendRules(permissions["allow"], tools.Allow)
+	permissions["deny"] = appendRules(permissions["deny"], tools.Deny)
+	if rules, _ := permissions["deny"].([]any); len(rules) == 0 {
+		delete(permissions, "deny")
+	}
+	settings["permissions"] = permissions
+
+	// Project MCP servers need approval unless enabled up front
+	if len(tools.MCP) > 0 {
+		settings["enableAllProjectMcpServers"] = true
+	}
+
+	data, err = json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// appendRules adds rules missing from a JSON list of permission rules.
+func appendRules(list any, rules []string) []any {
+	existing, _ := list.([]any)
+	seen := make(map[string]bool)
+	for _, rule := range existing {
+		if s, ok := rule.(string); ok {
+			seen[s] = true
+		}
+	}
+
+	for _, rule := range rules {
+		if !seen[rule] {
+			seen[rule] = true
+			existing = append(existing, rule)
+		}
+	}
+	return existing
+}
+
+// buildMCPConfig renders MCP servers in the .mcp.json format.
+func buildMCPConfig(servers []config.MCPServer) ([]byte, error) {
+	type mcpServer struct {
+		Type    string   `json:"type,omitempty"`
+		Command string   `json:"command,omitempty"`
+		Args    []string `json:"args,omitempty"`
+		URL     string   `json:"url,omitempty"`
+	}
+
+	mcpServers := make(map[string]mcpServer)
+	for _, server := range servers {
+		if server.URL != "" {
+			mcpServers[server.Name] = mcpServer{Type: "http", URL: server.URL}
+			continue
+		}
+
+		fields := strings.Fields(server.Command)
+		if len(fields) == 0 {
+			continue
+		}
+		mcpServers[server.Name] = mcpServer{Command: fields[0], Args: fields[1:]}
+	}
+
+	data, err := json.MarshalIndent(map[string]any{"mcpServers": mcpServers}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// checksum returns the hex SHA-256 of data.
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
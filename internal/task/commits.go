@@ -0,0 +1,204 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/donghojung/taw/internal/config"
+	"github.com/donghojung/taw/internal/git"
+)
+
+// conventionalPattern matches a Conventional Commits subject:
+// type(scope)!: description.
+var conventionalPattern = regexp.MustCompile(`^([a-z]+)(\([^()]+\))?(!)?: (\S.*)$`)
+
+// CommitIssue is a commit whose message doesn't follow the convention.
+type CommitIssue struct {
+	Hash    string
+	Subject string
+	Problem string
+}
+
+// String returns the commit and its problem, e.g. "a1b2c3d Fix it: no type".
+func (i CommitIssue) String() string {
+	hash := i.Hash
+	if len(hash) > 7 {
+		hash = hash[:7]
+	}
+	return fmt.Sprintf("%s %s: %s", hash, i.Subject, i.Problem)
+}
+
+// commitsConfig returns the commit checks of the project.
+func (m *Manager) commitsConfig() config.CommitsConfig {
+	if m.config == nil {
+		return config.CommitsConfig{}
+	}
+	return m.config.Commits
+}
+
+// CommitPolicy returns what end-task does with commits that break the convention.
+func (m *Manager) CommitPolicy() config.CommitPolicy {
+	if policy := m.commitsConfig().OnInvalid; policy != "" {
+		return policy
+	}
+	return config.CommitPolicyReject
+}
+
+// taskCommits returns the commits of a task's branch. Only worktree tasks
+// have commits of their own.
+func (m *Manager) taskCommits(ctx context.Context, task *Task) ([]git.Commit, error) {
+	if !m.UsesWorktree(task) {
+		return nil, nil
+	}
+	return m.gitClient.GetCommits(ctx, m.GetWorkingDirectory(task), m.MainBranch(ctx))
+}
+
+// CheckCommits returns the commits of a task whose messages don't follow the
+// project's commit convention.
+func (m *Manager) CheckCommits(ctx context.Context, task *Task) ([]CommitIssue, error) {
+	cfg := m.commitsConfig()
+	if cfg.Convention != config.CommitConventionConventional {
+		return nil, nil
+	}
+
+	commits, err := m.taskCommits(ctx, task)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits: %w", err)
+	}
+
+	var issues []CommitIssue
+	for _, c := range commits {
+		if problem := conventionalProblem(c.Subject, cfg.Types); problem != "" {
+			issues = append(issues, CommitIssue{Hash: c.Hash, Subject: c.Subject, Problem: problem})
+		}
+	}
+	return issues, nil
+}
+
+// conventionalProblem returns what's wrong with a commit subject, or "" if it
+// follows Conventional Commits with one of the types (any type if empty).
+func conventionalProblem(subject string, types []string) string {
+	// Reverts and fixups made by git are left alone
+	if strings.HasPrefix(subject, "Revert \"") || strings.HasPrefix(subject, "fixup! ") || strings.HasPrefix(subject, "squash! ") {
+		return ""
+	}
+
+	match := conventionalPattern.FindStringSubmatch(subject)
+	if match == nil {
+		return "not in the form type(scope): description"
+	}
+	if len(types) > 0 && !hasString(types, match[1]) {
+		return fmt.Sprintf("type %q is not one of %s", match[1], strings.Join(types, ", "))
+	}
+	return ""
+}
+
+// hasString returns true if list contains s.
+func hasString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// changelogEntry returns the changelog line of a commit, or "" if it doesn't
+// belong in the changelog: only features, fixes and breaking changes do.
+func changelogEntry(c git.Commit) string {
+	match := conventionalPattern.FindStringSubmatch(c.Subject)
+	if match == nil {
+		return ""
+	}
+	breaking := match[3] != "" || strings.Contains(c.Body, "BREAKING CHANGE:") || strings.Contains(c.Body, "BREAKING-CHANGE:")
+	if match[1] != "feat" && match[1] != "fix" && !breaking {
+		return ""
+	}
+	return "- " + c.Subject
+}
+
+// UpdateChangelog adds an entry for each feature, fix and breaking change of a
+// task to the Unreleased section of the project's changelog and commits it.
+// Entries already in the changelog aren't added again, so ending a task twice
+// is harmless. It does nothing if no changelog is configured.
+func (m *Manager) UpdateChangelog(ctx context.Context, task *Task) error {
+	name := m.commitsConfig().Changelog
+	if name == "" {
+		return nil
+	}
+
+	commits, err := m.taskCommits(ctx, task)
+	if err != nil {
+		return fmt.Errorf("failed to list commits: %w", err)
+	}
+
+	workDir := m.GetWorkingDirectory(task)
+	path := filepath.Join(workDir, name)
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	existing := string(content)
+
+	var entries []string
+	for _, c := range commits {
+		entry := changelogEntry(c)
+		if entry != "" && !strings.Contains(existing, entry+"\n") && !hasString(entries, entry) {
+			entries = append(entries, entry)
+		}
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	updated := addUnreleased(existing, entries)
+	if err := os.WriteFile(path, []byte(updated), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := m.gitClient.Add(ctx, workDir, name); err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+	if err := m.gitClient.Commit(ctx, workDir, fmt.Sprintf("docs(changelog): add %s", task.Name)); err != nil {
+		return fmt.Errorf("failed to commit %s: %w", name, err)
+	}
+	return nil
+}
+
+// addUnreleased adds entries at the top of the Unreleased section of a
+// changelog, creating the section (and the changelog) if needed.
+func addUnreleased(changelog string, entries []string) string {
+	block := strings.Join(entries, "\n") + "\n"
+	if changelog == "" {
+		return "# Changelog\n\n## Unreleased\n\n" + block
+	}
+
+	lines := strings.SplitAfter(changelog, "\n")
+	for i, line := range lines {
+		heading := strings.ToLower(strings.TrimSpace(line))
+		if heading == "## unreleased" || heading == "## [unreleased]" {
+			// After the heading and its blank line
+			at := i + 1
+			if at < len(lines) && strings.TrimSpace(lines[at]) == "" {
+				at++
+			}
+			return strings.Join(lines[:at], "") + block + strings.Join(lines[at:], "")
+		}
+	}
+
+	// A new section before the first release, or at the end
+	section := "## Unreleased\n\n" + block + "\n"
+	for i, line := range lines {
+		if strings.HasPrefix(line, "## ") {
+			return strings.Join(lines[:i], "") + section + strings.Join(lines[i:], "")
+		}
+	}
+	if !strings.HasSuffix(changelog, "\n") {
+		changelog += "\n"
+	}
+	return changelog + "\n" + strings.TrimSuffix(section, "\n")
+}
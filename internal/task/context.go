@@ -0,0 +1,126 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/donghojung/taw/internal/config"
+	"github.com/donghojung/taw/internal/constants"
+)
+
+// Context is what an agent needs to know about its task, saved to the agent
+// directory as context.json. Fields that don't apply to the task are empty.
+type Context struct {
+	TaskName    string `json:"task_name"`
+	TaskID      string `json:"task_id,omitempty"`
+	TaskBranch  string `json:"task_branch,omitempty"`
+	MainBranch  string `json:"main_branch,omitempty"`
+	TawDir      string `json:"taw_dir"`
+	ProjectDir  string `json:"project_dir"`
+	WorktreeDir string `json:"worktree_dir,omitempty"` // Worktree mode only
+	WorkDir     string `json:"work_dir,omitempty"`     // Non-git projects only
+	WindowID    string `json:"window_id,omitempty"`
+	OnComplete  string `json:"on_complete"`
+	PushRemote  string `json:"push_remote"`
+	SessionName string `json:"session_name,omitempty"` // Empty in terminal mode
+	TawHome     string `json:"taw_home"`
+	TawBin      string `json:"taw_bin"`
+}
+
+// GetContextPath returns the path to the task's context file.
+func (t *Task) GetContextPath() string {
+	return filepath.Join(t.AgentDir, constants.ContextFileName)
+}
+
+// SaveContext writes the task's context file.
+func (t *Task) SaveContext(c Context) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(t.GetContextPath(), append(data, '\n'), 0644)
+}
+
+// TaskContext returns the context of a task running in the session, or in
+// the terminal if sessionName is empty.
+func (m *Manager) TaskContext(ctx context.Context, task *Task, sessionName string) Context {
+	tawBin, err := os.Executable()
+	if err != nil {
+		tawBin = "taw"
+	}
+
+	c := Context{
+		TaskName:    task.Name,
+		TaskID:      task.ID,
+		TawDir:      m.tawDir,
+		ProjectDir:  m.projectDir,
+		OnComplete:  string(m.OnComplete(task)),
+		PushRemote:  m.PushRemote(),
+		SessionName: sessionName,
+		TawHome:     filepath.Dir(filepath.Dir(tawBin)),
+		TawBin:      tawBin,
+	}
+	if m.isGitRepo {
+		c.MainBranch = m.MainBranch(ctx)
+		if m.UsesWorktree(task) {
+			c.TaskBranch = task.GetBranch()
+			c.WorktreeDir = m.GetWorkingDirectory(task)
+		}
+	} else {
+		c.WorkDir = m.GetWorkingDirectory(task)
+	}
+	if sessionName != "" {
+		if windowID, err := task.LoadWindowID(); err == nil {
+			c.WindowID = windowID
+		}
+	}
+	return c
+}
+
+// Vars returns the context as the variables prompts and the agent's shell
+// use, e.g. TASK_NAME, leaving out empty fields.
+func (c Context) Vars() map[string]string {
+	vars := make(map[string]string)
+	for name, value := range map[string]string{
+		"TASK_NAME":    c.TaskName,
+		"TASK_ID":      c.TaskID,
+		"TASK_BRANCH":  c.TaskBranch,
+		"MAIN_BRANCH":  c.MainBranch,
+		"TAW_DIR":      c.TawDir,
+		"PROJECT_DIR":  c.ProjectDir,
+		"WORKTREE_DIR": c.WorktreeDir,
+		"WORK_DIR":     c.WorkDir,
+		"WINDOW_ID":    c.WindowID,
+		"ON_COMPLETE":  c.OnComplete,
+		"PUSH_REMOTE":  c.PushRemote,
+		"SESSION_NAME": c.SessionName,
+		"TAW_HOME":     c.TawHome,
+		"TAW_BIN":      c.TawBin,
+	} {
+		if value != "" {
+			vars[name] = value
+		}
+	}
+	return vars
+}
+
+// EnvVars returns the context as variables for the task's env script, sorted
+// by name.
+func (c Context) EnvVars() []config.EnvVar {
+	vars := c.Vars()
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	env := make([]config.EnvVar, len(names))
+	for i, name := range names {
+		env[i] = config.EnvVar{Name: name, Value: vars[name]}
+	}
+	return env
+}
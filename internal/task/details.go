@@ -0,0 +1,137 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/donghojung/taw/internal/config"
+	"github.com/donghojung/taw/internal/git"
+)
+
+// Details is everything known about a task, for 'taw show'. What can't be
+// found out is left empty.
+type Details struct {
+	ID          string    `json:"id,omitempty"`
+	Name        string    `json:"name"`
+	Status      Status    `json:"status"`
+	Stuck       bool      `json:"stuck,omitempty"`
+	Hibernated  bool      `json:"hibernated,omitempty"`
+	Stale       bool      `json:"stale,omitempty"`     // Flagged for going untouched (stale.days)
+	ReadOnly    bool      `json:"read_only,omitempty"` // isolation: none, without a worktree
+	Owner       string    `json:"owner,omitempty"`
+	Branch      string    `json:"branch,omitempty"`
+	WorkDir     string    `json:"work_dir"`
+	Tags        []string  `json:"tags,omitempty"`
+	Ticket      string    `json:"ticket,omitempty"`
+	Batch       string    `json:"batch,omitempty"`
+	DraftGroup  string    `json:"draft_group,omitempty"`
+	Content     string    `json:"content"`
+	Prompt      string    `json:"prompt,omitempty"`      // The task's extra prompt
+	Instruction string    `json:"instruction,omitempty"` // The task as sent to the agent
+	Answer      string    `json:"answer,omitempty"`      // The kept answer of an ask task
+	Timeline    *Timeline `json:"timeline"`
+
+	// Changes against the main branch, or of the project files without git
+	Files      int `json:"files"`
+	Insertions int `json:"insertions,omitempty"`
+	Deletions  int `json:"deletions,omitempty"`
+
+	PRNumber int    `json:"pr_number,omitempty"`
+	PRURL    string `json:"pr_url,omitempty"`
+	PRState  string `json:"pr_state,omitempty"`
+
+	CostUSD      float64 `json:"cost_usd"`
+	CostTodayUSD float64 `json:"cost_today_usd"`
+	CostShared   bool    `json:"cost_shared,omitempty"` // Working in the project directory, the cost isn't the task's own
+	BudgetUSD    float64 `json:"budget_usd,omitempty"`
+
+	Restarts    int           `json:"restarts,omitempty"`
+	Verify      *VerifyResult `json:"verify,omitempty"`
+	PushFailure string        `json:"push_failure,omitempty"`
+
+	// Recent log lines of the task and the end of its agent's pane
+	Log        []string `json:"log,omitempty"`
+	Transcript string   `json:"transcript,omitempty"`
+}
+
+// Details collects what is known about a task. The pull request status
+// comes from the cache while it's fresh.
+func (m *Manager) Details(ctx context.Context, task *Task) *Details {
+	d := &Details{
+		ID:          task.ID,
+		Name:        task.Name,
+		Status:      task.Status,
+		Stuck:       task.IsStuck(),
+		Hibernated:  task.IsHibernated(),
+		Stale:       task.IsStale(),
+		ReadOnly:    task.Isolation() == config.IsolationNone,
+		Owner:       task.Owner,
+		WorkDir:     m.GetWorkingDirectory(task),
+		Tags:        task.Tags(),
+		Ticket:      task.TicketKey(),
+		Batch:       task.BatchID(),
+		DraftGroup:  task.DraftGroup,
+		Content:     task.Content,
+		Prompt:      readTrimmed(task.GetPromptPath()),
+		Instruction: readTrimmed(task.GetUserPromptPath()),
+		Answer:      m.LoadAnswer(task.Name),
+		Timeline:    task.LoadTimeline(),
+		PRNumber:    task.PRNumber,
+		BudgetUSD:   m.TaskBudget(task),
+		Restarts:    task.LoadRestarts(),
+		Verify:      task.LoadVerifyResult(),
+		PushFailure: task.LoadPushFailure(),
+	}
+
+	if m.isGitRepo {
+		d.Branch = task.GetBranch()
+		if m.gitClient.BranchExists(ctx, m.projectDir, d.Branch) {
+			if stat, err := m.gitClient.GetBranchDiffStat(ctx, m.projectDir, m.MainBranch(ctx), d.Branch); err == nil {
+				d.Files, d.Insertions, d.Deletions = git.ParseShortStat(stat)
+			}
+		}
+	} else if changes, err := m.NoGitChanges(task); err == nil {
+		d.Files = changes.Count()
+	}
+
+	if task.PRNumber > 0 {
+		if status, err := m.ghClient.GetPRStatus(ctx, m.projectDir, task.PRNumber); err == nil {
+			d.PRURL, d.PRState = status.URL, status.State
+		}
+	}
+
+	total, today, err := m.TaskCost(task)
+	switch {
+	case err == nil:
+		d.CostUSD, d.CostTodayUSD = total, today
+	case errors.Is(err, ErrSharedCost):
+		d.CostShared = true
+	}
+	return d
+}
+
+// Diff returns the changes of a task against the main branch: its commits
+// and what isn't committed yet in its working directory.
+func (m *Manager) Diff(ctx context.Context, task *Task) (string, error) {
+	if !m.isGitRepo {
+		return "", fmt.Errorf("diffs need a git repository")
+	}
+	diff, err := m.gitClient.GetDiff(ctx, m.GetWorkingDirectory(task), m.MainBranch(ctx))
+	if err != nil {
+		return "", fmt.Errorf("failed to diff %s: %w", task.Name, err)
+	}
+	return diff, nil
+}
+
+// readTrimmed returns the trimmed content of a file, or "" if it can't be read.
+func readTrimmed(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,112 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// TaskDiskUsage is the disk space used by one task.
+type TaskDiskUsage struct {
+	Task  *Task
+	Bytes int64 // Agent directory plus a worktree outside it
+}
+
+// DiskUsage is the disk space used by the project's .taw directory.
+type DiskUsage struct {
+	Total int64           // The whole .taw directory plus worktrees outside it
+	Tasks []TaskDiskUsage // Largest first
+}
+
+// DiskUsage measures each task and the whole .taw directory. Symlinks are
+// not followed, so shared assets count once.
+func (m *Manager) DiskUsage() (*DiskUsage, error) {
+	tasks, err := m.ListTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &DiskUsage{Total: dirSize(m.tawDir)}
+	for _, t := range tasks {
+		size := dirSize(t.AgentDir)
+		if worktreeDir := t.GetWorktreeDir(); !isWithin(worktreeDir, t.AgentDir) {
+			worktreeSize := dirSize(worktreeDir)
+			size += worktreeSize
+			if !isWithin(worktreeDir, m.tawDir) {
+				usage.Total += worktreeSize
+			}
+		}
+		usage.Tasks = append(usage.Tasks, TaskDiskUsage{Task: t, Bytes: size})
+	}
+
+	sort.SliceStable(usage.Tasks, func(i, j int) bool {
+		return usage.Tasks[i].Bytes > usage.Tasks[j].Bytes
+	})
+	return usage, nil
+}
+
+// Size returns the disk space used by the named task, or 0 if unknown.
+func (u *DiskUsage) Size(taskName string) int64 {
+	for _, t := range u.Tasks {
+		if t.Task.Name == taskName {
+			return t.Bytes
+		}
+	}
+	return 0
+}
+
+// OverQuota returns true if the total exceeds quota. A quota of 0 is unlimited.
+func (u *DiskUsage) OverQuota(quota int64) bool {
+	return quota > 0 && u.Total > quota
+}
+
+// Largest returns up to n of the largest tasks.
+func (u *DiskUsage) Largest(n int) []TaskDiskUsage {
+	if n > len(u.Tasks) {
+		n = len(u.Tasks)
+	}
+	return u.Tasks[:n]
+}
+
+// FormatSize formats a byte count for display, e.g. "1.5G".
+func FormatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%dB", bytes)
+	}
+
+	size, suffix := float64(bytes)/unit, "K"
+	for _, next := range []string{"M", "G"} {
+		if size < unit {
+			break
+		}
+		size, suffix = size/unit, next
+	}
+	return fmt.Sprintf("%.1f%s", size, suffix)
+}
+
+// dirSize returns the total size of the files under path. Unreadable entries
+// are skipped (error is non-fatal - the size is an estimate).
+func dirSize(path string) int64 {
+	var size int64
+	filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
+
+// isWithin returns true if path is dir or inside it.
+func isWithin(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
@@ -0,0 +1,162 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/donghojung/taw/internal/config"
+)
+
+// DraftSummary describes a competing draft for comparison.
+type DraftSummary struct {
+	Task     *Task
+	Done     bool
+	DiffStat string // e.g. "3 files changed, 42 insertions(+), 7 deletions(-)"
+	Tests    string // Test result, "-" when unknown
+	Cost     string // Agent cost, "-" when unknown
+}
+
+// DraftCount returns how many competing drafts to create per task.
+// Drafts require worktree mode; otherwise it is always 1.
+func (m *Manager) DraftCount() int {
+	if !m.isGitRepo || m.config == nil || m.config.WorkMode != config.WorkModeWorktree {
+		return 1
+	}
+	if m.config.Drafts < 1 {
+		return 1
+	}
+	return m.config.Drafts
+}
+
+// CreateDrafts creates n competing tasks for the same content. Each draft
+// gets its own worktree and agent; the group name is the shared base name,
+// name if it makes a valid task name (see CreateTask).
+func (m *Manager) CreateDrafts(ctx context.Context, content, name string, n int) ([]*Task, error) {
+	name = m.taskName(ctx, content, name)
+
+	// Reserve the group name so two groups never share drafts
+	groupDir, err := m.createTaskDirectory(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create task directory: %w", err)
+	}
+	group := New(filepath.Base(groupDir), groupDir)
+
+	drafts := []*Task{group}
+	for i := 2; i <= n; i++ {
+		agentDir, err := m.createTaskDirectory(fmt.Sprintf("%s-d%d", group.Name, i))
+		if err != nil {
+			m.removeDrafts(drafts)
+			return nil, fmt.Errorf("failed to create task directory: %w", err)
+		}
+		drafts = append(drafts, New(filepath.Base(agentDir), agentDir))
+	}
+
+	owner := m.CurrentUser(ctx)
+	for _, draft := range drafts {
+		if err := draft.SaveContent(content); err != nil {
+			m.removeDrafts(drafts)
+			return nil, fmt.Errorf("failed to save task content: %w", err)
+		}
+		if err := draft.SaveDraftGroup(group.Name); err != nil {
+			m.removeDrafts(drafts)
+			return nil, fmt.Errorf("failed to save draft group: %w", err)
+		}
+		if err := draft.SaveOwner(owner); err != nil {
+			// The owner is informational only
+		}
+		if err := draft.RecordCreated(); err != nil {
+			// The timeline is informational only
+		}
+	}
+
+	for _, draft := range drafts {
+		m.recordCreation(draft)
+	}
+
+	return drafts, nil
+}
+
+// ListDrafts returns all drafts in a group, sorted by name.
+func (m *Manager) ListDrafts(group string) ([]*Task, error) {
+	tasks, err := m.ListTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	var drafts []*Task
+	for _, t := range tasks {
+		if t.DraftGroup == group {
+			drafts = append(drafts, t)
+		}
+	}
+
+	sort.Slice(drafts, func(i, j int) bool {
+		return drafts[i].Name < drafts[j].Name
+	})
+
+	return drafts, nil
+}
+
+// AllDraftsDone returns true if every draft in the group has finished.
+func (m *Manager) AllDraftsDone(group string) bool {
+	drafts, err := m.ListDrafts(group)
+	if err != nil || len(drafts) == 0 {
+		return false
+	}
+
+	for _, d := range drafts {
+		if !d.IsDraftDone() {
+			return false
+		}
+	}
+	return true
+}
+
+// SummarizeDrafts collects comparison data for every draft in a group.
+func (m *Manager) SummarizeDrafts(ctx context.Context, group string) ([]DraftSummary, error) {
+	drafts, err := m.ListDrafts(group)
+	if err != nil {
+		return nil, err
+	}
+
+	mainBranch := m.MainBranch(ctx)
+
+	summaries := make([]DraftSummary, 0, len(drafts))
+	for _, d := range drafts {
+		stat, err := m.gitClient.GetBranchDiffStat(ctx, m.projectDir, mainBranch, d.GetBranch())
+		if err != nil || stat == "" {
+			stat = "no changes"
+		}
+
+		tests := "-"
+		if result := d.LoadVerifyResult(); result != nil {
+			tests = result.Summary()
+		}
+
+		cost := "-"
+		if total, _, err := m.TaskCost(d); err == nil {
+			cost = fmt.Sprintf("$%.2f", total)
+		}
+
+		summaries = append(summaries, DraftSummary{
+			Task:     d,
+			Done:     d.IsDraftDone(),
+			DiffStat: strings.TrimSpace(stat),
+			Tests:    tests,
+			Cost:     cost,
+		})
+	}
+
+	return summaries, nil
+}
+
+// removeDrafts removes partially created drafts.
+func (m *Manager) removeDrafts(drafts []*Task) {
+	for _, d := range drafts {
+		d.Remove()
+	}
+}
@@ -0,0 +1,64 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/donghojung/taw/internal/config"
+)
+
+// ErrQueueHeld is returned when queued tasks can't start yet.
+var ErrQueueHeld = errors.New("queue held")
+
+// QueueDrain returns the queue drain policy.
+func (m *Manager) QueueDrain() config.QueueDrain {
+	if m.config == nil || m.config.Queue.Drain == "" {
+		return config.QueueDrainSlot
+	}
+	return m.config.Queue.Drain
+}
+
+// QueueHold returns why the drain policy keeps queued tasks from starting on
+// their own now, or "" if they start as running tasks end. attached tells
+// whether a client is attached to the session.
+func (m *Manager) QueueHold(now time.Time, attached bool) string {
+	switch m.QueueDrain() {
+	case config.QueueDrainManual:
+		return "queue.drain is manual; start them with 'taw queue start'"
+	case config.QueueDrainHours:
+		if !m.config.Queue.InHours(now) {
+			return fmt.Sprintf("outside queue.hours (%s)", m.config.Queue.Hours)
+		}
+	case config.QueueDrainAttached:
+		if !attached {
+			return "no client is attached to the session"
+		}
+	}
+	return ""
+}
+
+// QueueBlocked returns why no queued task can start now, even by hand, or ""
+// if one can: another task works in the shared working tree, max_parallel_tasks
+// are running, agents back off from a rate limit, or today is over budget.
+func (m *Manager) QueueBlocked(ctx context.Context) (string, error) {
+	if busy, err := m.SharedTreeBusy(ctx, nil); err != nil || busy != "" {
+		return busy, err
+	}
+	atLimit, err := m.AtTaskLimit()
+	if err != nil {
+		return "", err
+	}
+	if atLimit {
+		return fmt.Sprintf("%d tasks are running (max_parallel_tasks)", m.config.MaxParallel), nil
+	}
+	if limit := m.RateLimited(); limit != nil {
+		return fmt.Sprintf("%s until %s", limit.Reason, limit.Until.Format("15:04")), nil
+	}
+	if m.BudgetPaused() {
+		return fmt.Sprintf("today's spend is over the $%.2f budget", m.DailyBudget()), nil
+	}
+	return "", nil
+}
@@ -0,0 +1,90 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/donghojung/taw/internal/claude"
+	"github.com/donghojung/taw/internal/config"
+	"github.com/donghojung/taw/internal/constants"
+)
+
+// GetEnvPath returns the path to the task's env script, sourced by the agent
+// and shell panes so values never pass through the terminal.
+func (t *Task) GetEnvPath() string {
+	return filepath.Join(t.AgentDir, constants.AgentEnvFileName)
+}
+
+// SaveEnv writes the variables as a shell script only the user can read.
+func (t *Task) SaveEnv(vars []config.EnvVar) error {
+	var sb strings.Builder
+	for _, v := range vars {
+		fmt.Fprintf(&sb, "export %s='%s'\n", v.Name, strings.ReplaceAll(v.Value, "'", `'\''`))
+	}
+
+	path := t.GetEnvPath()
+	if err := os.WriteFile(path, []byte(sb.String()), 0600); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of an existing file
+	return os.Chmod(path, 0600)
+}
+
+// SourceEnvCommand returns a shell command loading the task's env script,
+// which succeeds without the script.
+func (t *Task) SourceEnvCommand() string {
+	return fmt.Sprintf("if [ -r '%[1]s' ]; then . '%[1]s'; fi", t.GetEnvPath())
+}
+
+// AgentScript returns the sh script that runs command as the task's agent. It
+// sources agent.shell_env and the task's env script first, and stops with a
+// message in the pane if claude isn't on the PATH there, which may differ from
+// TAW's own.
+func (m *Manager) AgentScript(task *Task, command string) string {
+	var sb strings.Builder
+	if path := m.shellEnvPath(); path != "" {
+		fmt.Fprintf(&sb, ". %s\n", shellQuote(path))
+	}
+	sb.WriteString(task.SourceEnvCommand() + "\n")
+	if !m.Sandboxed() {
+		hint := claude.ErrorHint(claude.ErrNotInstalled) + ". If it works in your terminal, set agent.login_shell: true or agent.shell_env in .taw/config"
+		fmt.Fprintf(&sb, "if ! command -v claude >/dev/null 2>&1; then\n")
+		fmt.Fprintf(&sb, "\techo \"taw: claude is not on the PATH of this pane ($PATH)\" >&2\n")
+		fmt.Fprintf(&sb, "\techo %s >&2\n", shellQuote(hint))
+		fmt.Fprintf(&sb, "\texit %d\nfi\n", constants.AgentNotFoundCode)
+	}
+	sb.WriteString(command)
+	return sb.String()
+}
+
+// shellEnvPath returns the path of agent.shell_env, or "" if it isn't set.
+// It may start with ~/ or be relative to the project.
+func (m *Manager) shellEnvPath() string {
+	if m.config == nil || m.config.Agent.ShellEnv == "" {
+		return ""
+	}
+	path := m.config.Agent.ShellEnv
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	if !filepath.IsAbs(path) {
+		return filepath.Join(m.projectDir, path)
+	}
+	return path
+}
+
+// agentEnv returns the agent variables as KEY=VALUE pairs for exec.Cmd.Env.
+// An exposed .taw/env is skipped, like for the agent panes.
+func (m *Manager) agentEnv() []string {
+	if m.config == nil {
+		return nil
+	}
+
+	vars, _ := m.config.AgentEnv(m.tawDir)
+	return config.Environ(vars)
+}
@@ -0,0 +1,41 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"errors"
+
+	"github.com/donghojung/taw/internal/claude"
+	"github.com/donghojung/taw/internal/git"
+	"github.com/donghojung/taw/internal/github"
+)
+
+// Hint returns a user-facing remediation hint for an error returned by the
+// manager or the clients it wraps, or "" if the error isn't recognized.
+// CLI and TUI layers show it instead of the raw command output.
+func Hint(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var preflightErr *PreflightError
+	var pushErr *PushError
+	switch {
+	case errors.As(err, &preflightErr):
+		return preflightErr.Hint
+	case errors.As(err, &pushErr):
+		return pushErr.Hint()
+	case errors.Is(err, ErrProjectLocked):
+		return "Another taw operation is using the project - wait for it to finish and try again"
+	case errors.Is(err, ErrMainCheckedOut):
+		return "Check out another branch in the project directory and end the task again, or merge its branch by hand"
+	case errors.Is(err, ErrSandboxUnavailable):
+		return "Install docker and set sandbox_image in .taw/config, or set sandbox: none"
+	}
+
+	for _, hint := range []func(error) string{git.ErrorHint, github.ErrorHint, claude.ErrorHint} {
+		if h := hint(err); h != "" {
+			return h
+		}
+	}
+	return ""
+}
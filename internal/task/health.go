@@ -0,0 +1,141 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/donghojung/taw/internal/constants"
+	"github.com/donghojung/taw/internal/logging"
+)
+
+// IsStuck returns true if the agent was flagged as possibly stuck: its pane
+// didn't change for agent.stuck_after while it was working.
+func (t *Task) IsStuck() bool {
+	_, err := os.Stat(filepath.Join(t.AgentDir, constants.StuckFileName))
+	return err == nil
+}
+
+// clearHealth forgets the pane content and the stuck flag.
+func (t *Task) clearHealth() {
+	os.Remove(filepath.Join(t.AgentDir, constants.PaneHashFileName))
+	os.Remove(filepath.Join(t.AgentDir, constants.StuckFileName))
+}
+
+// loadPaneHash returns the hash of the pane content last seen and when it
+// was first seen.
+func (t *Task) loadPaneHash() (string, time.Time) {
+	data, err := os.ReadFile(filepath.Join(t.AgentDir, constants.PaneHashFileName))
+	if err != nil {
+		return "", time.Time{}
+	}
+	hash, since, _ := strings.Cut(strings.TrimSpace(string(data)), " ")
+	seen, err := time.Parse(time.RFC3339, since)
+	if err != nil {
+		return "", time.Time{}
+	}
+	return hash, seen
+}
+
+// savePaneHash records the hash of the pane content, first seen now.
+func (t *Task) savePaneHash(hash string) error {
+	data := hash + " " + time.Now().Format(time.RFC3339)
+	return os.WriteFile(filepath.Join(t.AgentDir, constants.PaneHashFileName), []byte(data), 0644)
+}
+
+// CheckHealth compares the agent pane of a working task with the last check
+// and flags the task as possibly stuck once the pane hasn't changed for
+// agent.stuck_after. It returns whether the task is stuck, and whether that
+// changed with this check. Needs the tmux client of the session.
+func (m *Manager) CheckHealth(task *Task) (stuck, changed bool, err error) {
+	wasStuck := task.IsStuck()
+	if task.Status != StatusWorking || m.config == nil || m.config.Agent.StuckAfter <= 0 || m.tmuxClient == nil {
+		task.clearHealth()
+		return false, wasStuck, nil
+	}
+	windowID, err := task.LoadWindowID()
+	if err != nil || windowID == "" {
+		return wasStuck, false, nil
+	}
+
+	content, err := m.tmuxClient.CapturePane(windowID+".0", 0)
+	if err != nil {
+		return wasStuck, false, fmt.Errorf("failed to capture agent pane: %w", err)
+	}
+	sum := sha256.Sum256([]byte(content))
+	hash := hex.EncodeToString(sum[:])
+
+	last, since := task.loadPaneHash()
+	if hash != last {
+		task.clearHealth()
+		return false, wasStuck, task.savePaneHash(hash)
+	}
+	if wasStuck || time.Since(since) < m.config.Agent.StuckAfter {
+		return wasStuck, false, nil
+	}
+	if err := os.WriteFile(filepath.Join(task.AgentDir, constants.StuckFileName), []byte(since.Format(time.RFC3339)), 0644); err != nil {
+		return false, false, err
+	}
+	return true, true, nil
+}
+
+// NudgeAgent types a reminder into the agent's prompt, for an agent that may
+// be stuck.
+func (m *Manager) NudgeAgent(task *Task) error {
+	if m.tmuxClient == nil {
+		return fmt.Errorf("no tmux session to nudge the agent in")
+	}
+	windowID, err := task.LoadWindowID()
+	if err != nil {
+		return fmt.Errorf("failed to load window ID: %w", err)
+	}
+
+	pane := windowID + ".0"
+	if err := m.tmuxClient.SendKeysLiteral(pane, constants.NudgeMessage); err != nil {
+		return fmt.Errorf("failed to send nudge: %w", err)
+	}
+	if err := m.tmuxClient.SendKeys(pane, "Enter"); err != nil {
+		return err
+	}
+	if err := task.RecordInstruction(InstructionNudge, constants.NudgeMessage); err != nil {
+		// The instruction log is informational only
+	}
+	task.clearHealth()
+	m.syncWindow(task, windowID)
+	return nil
+}
+
+// RestartAgent kills the agent in its pane and starts it again, continuing
+// its conversation.
+func (m *Manager) RestartAgent(task *Task) error {
+	if m.tmuxClient == nil {
+		return fmt.Errorf("no tmux session to restart the agent in")
+	}
+	windowID, err := task.LoadWindowID()
+	if err != nil {
+		return fmt.Errorf("failed to load window ID: %w", err)
+	}
+
+	if err := m.tmuxClient.RespawnPane(windowID+".0", m.GetWorkingDirectory(task)); err != nil {
+		return fmt.Errorf("failed to stop agent: %w", err)
+	}
+	if err := sendResumeCommand(m.tmuxClient, task); err != nil {
+		return err
+	}
+	task.clearHealth()
+	m.syncWindow(task, windowID)
+	return nil
+}
+
+// syncWindow updates the status shown for a task's window (error is
+// non-fatal).
+func (m *Manager) syncWindow(task *Task, windowID string) {
+	if err := task.SyncWindow(m.tmuxClient, windowID); err != nil {
+		logging.Debug("Failed to update window: %v", err)
+	}
+}
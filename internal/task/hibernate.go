@@ -0,0 +1,153 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/donghojung/taw/internal/config"
+	"github.com/donghojung/taw/internal/constants"
+)
+
+// getHibernatedPath returns the path to the marker of a hibernated task.
+func (t *Task) getHibernatedPath() string {
+	return filepath.Join(t.AgentDir, constants.HibernatedFileName)
+}
+
+// IsHibernated returns true if the task's worktree was removed to reclaim
+// disk space.
+func (t *Task) IsHibernated() bool {
+	_, err := os.Stat(t.getHibernatedPath())
+	return err == nil
+}
+
+// HibernatedAt returns when the task was hibernated, or the zero time if it
+// isn't.
+func (t *Task) HibernatedAt() time.Time {
+	data, err := os.ReadFile(t.getHibernatedPath())
+	if err != nil {
+		return time.Time{}
+	}
+	at, _ := time.Parse(time.RFC3339, strings.TrimSpace(string(data)))
+	return at
+}
+
+// HibernateCandidates returns the done tasks that still have a worktree.
+func (m *Manager) HibernateCandidates() ([]*Task, error) {
+	if !m.worktreeMode() {
+		return nil, nil
+	}
+
+	tasks, err := m.ListTasks()
+	if err != nil {
+		return nil, err
+	}
+	var candidates []*Task
+	for _, t := range tasks {
+		if t.Status != StatusDone || t.IsHibernated() || t.DraftGroup != "" || !m.UsesWorktree(t) {
+			continue
+		}
+		if _, err := os.Stat(t.GetWorktreeDir()); err != nil {
+			continue
+		}
+		candidates = append(candidates, t)
+	}
+	return candidates, nil
+}
+
+// HibernateTask removes the worktree of a done task kept open, keeping its
+// branch and agent directory, and closes its window. It returns the disk
+// space reclaimed. Opening the task again recreates the worktree (see
+// WakeTask).
+func (m *Manager) HibernateTask(ctx context.Context, task *Task) (int64, error) {
+	if !m.UsesWorktree(task) {
+		return 0, errors.New("only tasks with a worktree can be hibernated")
+	}
+	if task.IsHibernated() {
+		return 0, errors.New("already hibernated")
+	}
+	if task.Status != StatusDone {
+		return 0, fmt.Errorf("task is %s, not done", task.Status)
+	}
+	worktreeDir := task.GetWorktreeDir()
+	if _, err := os.Stat(worktreeDir); err != nil {
+		return 0, errors.New("task has no worktree")
+	}
+	if m.gitClient.HasChanges(ctx, worktreeDir) {
+		return 0, errors.New("worktree has uncommitted changes")
+	}
+	return m.hibernate(ctx, task)
+}
+
+// hibernate closes the task's window and removes its worktree, marking the
+// task hibernated. It returns the disk space reclaimed.
+func (m *Manager) hibernate(ctx context.Context, task *Task) (int64, error) {
+	worktreeDir := task.GetWorktreeDir()
+	unlock, err := m.LockProject(fmt.Sprintf("hibernation of %s", task.Name))
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	// The agent goes with the window, before its directory does
+	if m.tmuxClient != nil {
+		if windows, err := m.tmuxClient.ListWindows(); err == nil {
+			if windowID := task.WindowIn(windows); windowID != "" {
+				if err := m.tmuxClient.KillWindow(windowID); err != nil {
+					return 0, fmt.Errorf("failed to close window: %w", err)
+				}
+			}
+		}
+	}
+	m.StopSandbox(task)
+
+	size := dirSize(worktreeDir)
+	if err := m.gitClient.WorktreeRemove(ctx, m.projectDir, worktreeDir, false); err != nil {
+		return 0, fmt.Errorf("failed to remove worktree: %w", err)
+	}
+	if err := os.WriteFile(task.getHibernatedPath(), []byte(time.Now().Format(time.RFC3339)), 0644); err != nil {
+		return size, fmt.Errorf("failed to mark task hibernated: %w", err)
+	}
+
+	// Without its window the task isn't reopened on attach until woken, and
+	// the window's ID may go to another window
+	if err := task.RemoveTabLock(); err != nil {
+		return size, fmt.Errorf("failed to remove tab-lock: %w", err)
+	}
+	if err := os.Remove(task.GetWindowIDPath()); err != nil && !os.IsNotExist(err) {
+		return size, fmt.Errorf("failed to forget window: %w", err)
+	}
+	task.WindowID = ""
+	return size, nil
+}
+
+// WakeTask recreates the worktree of a hibernated task from its branch, the
+// way a missing worktree is recovered. Tasks that aren't hibernated are left
+// as they are.
+func (m *Manager) WakeTask(ctx context.Context, task *Task) error {
+	if !task.IsHibernated() {
+		return nil
+	}
+
+	recovering := *task
+	recovering.CorruptedReason = CorruptMissingWorktree
+	if err := NewRecoveryManager(m.projectDir).RecoverTask(ctx, &recovering); err != nil {
+		return err
+	}
+	task.WorktreeDir = task.GetWorktreeDir()
+
+	if err := os.Remove(task.getHibernatedPath()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to clear hibernation: %w", err)
+	}
+	return nil
+}
+
+// worktreeMode returns true if tasks get their own worktree.
+func (m *Manager) worktreeMode() bool {
+	return m.isGitRepo && m.config != nil && m.config.WorkMode == config.WorkModeWorktree
+}
@@ -0,0 +1,28 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/donghojung/taw/internal/constants"
+)
+
+// IDECommand returns the shell command that opens the task's working
+// directory in the ide setting. {dir} and {task} are replaced in it; without
+// {dir}, the directory is appended.
+func (m *Manager) IDECommand(task *Task) (string, error) {
+	if task.IsHibernated() {
+		return "", errors.New("the task is hibernated; open it again first (h in the dashboard)")
+	}
+
+	ide := constants.DefaultIDE
+	if m.config != nil && strings.TrimSpace(m.config.IDE) != "" {
+		ide = strings.TrimSpace(m.config.IDE)
+	}
+	if !strings.Contains(ide, "{dir}") {
+		ide += " {dir}"
+	}
+	dir := shellQuote(m.GetWorkingDirectory(task))
+	return strings.NewReplacer("{dir}", dir, "{task}", shellQuote(task.Name)).Replace(ide), nil
+}
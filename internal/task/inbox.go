@@ -0,0 +1,94 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/donghojung/taw/internal/claude"
+	"github.com/donghojung/taw/internal/constants"
+)
+
+// InboxDir returns the directory of queue.inbox, or "" if it's off. It may
+// start with ~/ or be relative to the project.
+func (m *Manager) InboxDir() string {
+	if m.config == nil || m.config.Queue.Inbox == "" {
+		return ""
+	}
+	dir := m.config.Queue.Inbox
+	if rest, ok := strings.CutPrefix(dir, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	if !filepath.IsAbs(dir) {
+		return filepath.Join(m.projectDir, dir)
+	}
+	return dir
+}
+
+// IngestInbox queues the markdown files of the inbox as tasks, oldest first:
+// the file's content is the task, and its name the task's name. Queued files
+// are removed. Hidden files, empty files and files changed in the last
+// constants.InboxSettleDuration, which may still be written or synced, are
+// left for a later call. It returns the names of the files queued.
+func (m *Manager) IngestInbox(queue *QueueManager) ([]string, error) {
+	dir := m.InboxDir()
+	if dir == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read inbox: %w", err)
+	}
+
+	type inboxFile struct {
+		name    string
+		modTime time.Time
+	}
+	var files []inboxFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || !strings.EqualFold(filepath.Ext(name), ".md") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.Size() == 0 || time.Since(info.ModTime()) < constants.InboxSettleDuration {
+			continue
+		}
+		files = append(files, inboxFile{name: name, modTime: info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+
+	var queued []string
+	for _, f := range files {
+		path := filepath.Join(dir, f.name)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return queued, fmt.Errorf("failed to read %s: %w", f.name, err)
+		}
+
+		// Removed first, so a file that can't be removed isn't queued again
+		if err := os.Remove(path); err != nil {
+			return queued, fmt.Errorf("failed to remove %s: %w", f.name, err)
+		}
+		name := claude.SanitizeTaskName(strings.TrimSuffix(f.name, filepath.Ext(f.name)))
+		if err := queue.AddNamed(string(content), name); err != nil {
+			if restoreErr := os.WriteFile(path, content, 0644); restoreErr != nil {
+				return queued, fmt.Errorf("failed to queue %s: %w (and to put it back: %v)", f.name, err, restoreErr)
+			}
+			return queued, fmt.Errorf("failed to queue %s: %w", f.name, err)
+		}
+		queued = append(queued, f.name)
+	}
+	return queued, nil
+}
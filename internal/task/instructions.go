@@ -0,0 +1,172 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/donghojung/taw/internal/constants"
+	"github.com/donghojung/taw/internal/logging"
+	"github.com/donghojung/taw/internal/tmux"
+)
+
+// InstructionKind says why TAW sent an instruction to an agent.
+type InstructionKind string
+
+const (
+	InstructionTask    InstructionKind = "task"    // The task, when the agent starts
+	InstructionNudge   InstructionKind = "nudge"   // A reminder for an agent that may be stuck
+	InstructionVerify  InstructionKind = "verify"  // A failed verification step to fix
+	InstructionCommits InstructionKind = "commits" // Commit messages to reword
+	InstructionComment InstructionKind = "comment" // New comments on the task's pull request
+)
+
+// Instruction is an input TAW typed into a task's agent pane.
+type Instruction struct {
+	Time time.Time       `json:"time"`
+	Kind InstructionKind `json:"kind"`
+	Text string          `json:"text"`
+}
+
+// GetInstructionsPath returns the path to the task's instruction log, one
+// JSON instruction per line, only ever appended to.
+func (t *Task) GetInstructionsPath() string {
+	return filepath.Join(t.AgentDir, constants.InstructionFileName)
+}
+
+// RecordInstruction appends an instruction sent now to the instruction log.
+func (t *Task) RecordInstruction(kind InstructionKind, text string) error {
+	data, err := json.Marshal(Instruction{Time: time.Now(), Kind: kind, Text: logging.Redact(text)})
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(t.GetInstructionsPath(), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// LoadInstructions returns the instructions sent to the task's agent, oldest
+// first. Lines that can't be read are skipped.
+func (t *Task) LoadInstructions() ([]Instruction, error) {
+	return readInstructions(t.GetInstructionsPath())
+}
+
+// readInstructions reads a file of JSON instructions, one per line.
+func readInstructions(path string) ([]Instruction, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var instructions []Instruction
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var in Instruction
+		if err := json.Unmarshal(scanner.Bytes(), &in); err == nil {
+			instructions = append(instructions, in)
+		}
+	}
+	return instructions, scanner.Err()
+}
+
+// SendInstruction types an instruction into the task's agent pane and
+// records it in the instruction log.
+func (m *Manager) SendInstruction(ctx context.Context, tm tmux.Client, task *Task, pane string, kind InstructionKind, text string) error {
+	if err := task.RecordInstruction(kind, text); err != nil {
+		logging.Debug("Failed to record instruction: %v", err)
+	}
+	return m.claudeClient.SendInput(ctx, tm, pane, text)
+}
+
+// GetReplayPath returns the path to the instructions a replay task still
+// has to send after its task instruction.
+func (t *Task) GetReplayPath() string {
+	return filepath.Join(t.AgentDir, constants.ReplayFileName)
+}
+
+// LoadReplay returns the instructions a replay task has to send, or nil if
+// the task isn't a replay.
+func (t *Task) LoadReplay() []Instruction {
+	instructions, err := readInstructions(t.GetReplayPath())
+	if err != nil {
+		return nil
+	}
+	return instructions
+}
+
+// CreateReplay creates a task that runs the same instructions as source on
+// a fresh worktree: the same content and extra prompt, then, after its task
+// instruction, the instructions source's agent got later on.
+func (m *Manager) CreateReplay(ctx context.Context, source *Task) (*Task, error) {
+	instructions, err := source.LoadInstructions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read instructions: %w", err)
+	}
+	if len(instructions) == 0 {
+		return nil, fmt.Errorf("no instructions were recorded for %s", source.Name)
+	}
+
+	agentDir, err := m.createTaskDirectory(source.Name + "-replay")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create task directory: %w", err)
+	}
+	task := New(filepath.Base(agentDir), agentDir)
+
+	if err := task.SaveContent(source.Content); err != nil {
+		task.Remove()
+		return nil, fmt.Errorf("failed to save task content: %w", err)
+	}
+	if prompt, err := os.ReadFile(source.GetPromptPath()); err == nil {
+		if err := os.WriteFile(task.GetPromptPath(), prompt, 0644); err != nil {
+			task.Remove()
+			return nil, fmt.Errorf("failed to copy task prompt: %w", err)
+		}
+	}
+
+	// The task instruction is sent when the agent starts, like any task's
+	var replay []byte
+	for _, in := range instructions {
+		if in.Kind == InstructionTask {
+			continue
+		}
+		data, err := json.Marshal(in)
+		if err != nil {
+			task.Remove()
+			return nil, err
+		}
+		replay = append(append(replay, data...), '\n')
+	}
+	if err := os.WriteFile(task.GetReplayPath(), replay, 0644); err != nil {
+		task.Remove()
+		return nil, fmt.Errorf("failed to save replay: %w", err)
+	}
+
+	if _, err := task.EnsureID(); err != nil {
+		task.Remove()
+		return nil, err
+	}
+	if err := task.SaveOwner(m.CurrentUser(ctx)); err != nil {
+		// The owner is informational only
+	}
+	if err := task.RecordCreated(); err != nil {
+		// The timeline is informational only
+	}
+	m.recordCreation(task)
+
+	return task, nil
+}
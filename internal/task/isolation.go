@@ -0,0 +1,69 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/donghojung/taw/internal/config"
+	"github.com/donghojung/taw/internal/constants"
+)
+
+// isolationTagPrefix starts the tags choosing a task's isolation, e.g.
+// #isolation-none.
+const isolationTagPrefix = "isolation-"
+
+// Isolation returns whether the task gets its own worktree, as chosen when
+// it was created. Tasks from older versions get one.
+func (t *Task) Isolation() config.Isolation {
+	data, err := os.ReadFile(filepath.Join(t.AgentDir, constants.IsolationFileName))
+	if err != nil {
+		return config.IsolationWorktree
+	}
+	if isolation := config.Isolation(strings.TrimSpace(string(data))); isolation == config.IsolationNone {
+		return isolation
+	}
+	return config.IsolationWorktree
+}
+
+// SaveIsolation records whether the task gets its own worktree.
+func (t *Task) SaveIsolation(isolation config.Isolation) error {
+	return os.WriteFile(filepath.Join(t.AgentDir, constants.IsolationFileName), []byte(isolation), 0644)
+}
+
+// InferIsolation returns the isolation of a task with content: none for ask
+// tasks, the one its #isolation-none or #isolation-worktree tag chooses, none
+// if it has one of read_only_tags, else worktree.
+func (m *Manager) InferIsolation(content string) config.Isolation {
+	t := &Task{Content: content}
+	if t.IsAsk() {
+		return config.IsolationNone
+	}
+	tags := t.Tags()
+	for _, tag := range tags {
+		if choice, ok := strings.CutPrefix(strings.ToLower(tag), isolationTagPrefix); ok {
+			for _, isolation := range config.ValidIsolations() {
+				if choice == string(isolation) {
+					return isolation
+				}
+			}
+		}
+	}
+	if m.config != nil {
+		for _, tag := range tags {
+			for _, readOnly := range m.config.ReadOnlyTags {
+				if strings.EqualFold(tag, strings.TrimPrefix(readOnly, "#")) {
+					return config.IsolationNone
+				}
+			}
+		}
+	}
+	return config.IsolationWorktree
+}
+
+// UsesWorktree returns true if the task gets its own worktree and branch:
+// in worktree mode, unless it's a read-only task (isolation: none).
+func (m *Manager) UsesWorktree(task *Task) bool {
+	return m.worktreeMode() && task.Isolation() != config.IsolationNone
+}
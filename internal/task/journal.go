@@ -0,0 +1,209 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/donghojung/taw/internal/constants"
+)
+
+// JournalOp identifies a journaled background operation.
+type JournalOp string
+
+const (
+	JournalMerge   JournalOp = "merge"   // Merge the task into main
+	JournalCleanup JournalOp = "cleanup" // Remove the task's worktree, branch and agent dir
+	JournalPush    JournalOp = "push"    // Push the task branch
+)
+
+// JournalEntry records an operation that has started but not yet finished.
+// An entry whose process is gone was interrupted half-way.
+type JournalEntry struct {
+	Op        JournalOp `json:"op"`
+	TaskName  string    `json:"task_name"`
+	AgentDir  string    `json:"agent_dir"`
+	Dir       string    `json:"dir,omitempty"` // Temporary directory to roll back, if any
+	PID       int       `json:"pid"`
+	StartedAt time.Time `json:"started_at"`
+
+	Path string `json:"-"`
+}
+
+// Interrupted returns true if the process that started the operation is gone.
+func (e *JournalEntry) Interrupted() bool {
+	if e.PID <= 0 {
+		return true
+	}
+	return syscall.Kill(e.PID, 0) == syscall.ESRCH
+}
+
+// Journal persists in-flight operations in .taw/journal/ so a killed
+// process leaves a record that can be resumed or rolled back.
+type Journal struct {
+	dir string
+}
+
+// NewJournal creates a new journal for the given directory.
+func NewJournal(dir string) *Journal {
+	return &Journal{
+		dir: dir,
+	}
+}
+
+// Begin records the start of an operation.
+func (j *Journal) Begin(op JournalOp, task *Task) (*JournalEntry, error) {
+	if err := os.MkdirAll(j.dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create journal directory: %w", err)
+	}
+
+	entry := &JournalEntry{
+		Op:        op,
+		TaskName:  task.Name,
+		AgentDir:  task.AgentDir,
+		PID:       os.Getpid(),
+		StartedAt: time.Now(),
+		Path:      filepath.Join(j.dir, fmt.Sprintf("%s-%s.json", op, task.Name)),
+	}
+	return entry, j.Update(entry)
+}
+
+// Update rewrites an entry, e.g. after it acquired a directory to roll back.
+func (j *Journal) Update(entry *JournalEntry) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal journal entry: %w", err)
+	}
+	if err := os.WriteFile(entry.Path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write journal entry: %w", err)
+	}
+	return nil
+}
+
+// Finish marks an operation as completed by removing its entry.
+func (j *Journal) Finish(entry *JournalEntry) error {
+	if err := os.Remove(entry.Path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove journal entry: %w", err)
+	}
+	return nil
+}
+
+// List returns all recorded entries, oldest first.
+func (j *Journal) List() ([]JournalEntry, error) {
+	entries, err := os.ReadDir(j.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read journal directory: %w", err)
+	}
+
+	var result []JournalEntry
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+
+		path := filepath.Join(j.dir, e.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		var entry JournalEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			continue
+		}
+		entry.Path = path
+		result = append(result, entry)
+	}
+
+	sort.Slice(result, func(i, k int) bool {
+		return result[i].StartedAt.Before(result[k].StartedAt)
+	})
+
+	return result, nil
+}
+
+// Journal returns the manager's operation journal.
+func (m *Manager) Journal() *Journal {
+	return NewJournal(filepath.Join(m.tawDir, constants.JournalDirName))
+}
+
+// beginJournal records the start of an operation (error is non-fatal).
+// The returned entry may be nil; finishJournal accepts it either way.
+func (m *Manager) beginJournal(op JournalOp, task *Task) *JournalEntry {
+	entry, err := m.Journal().Begin(op, task)
+	if err != nil {
+		// Journaling is best-effort - the operation still runs
+		return nil
+	}
+	return entry
+}
+
+// finishJournal marks an operation as completed, whether it succeeded or not.
+// Only a process killed mid-operation leaves its entry behind.
+func (m *Manager) finishJournal(entry *JournalEntry) {
+	if entry == nil {
+		return
+	}
+	if err := m.Journal().Finish(entry); err != nil {
+		// A leftover entry is recovered harmlessly later
+	}
+}
+
+// RecoverJournal resumes or rolls back operations interrupted by a killed
+// process. Interrupted merges and pushes are rolled back and handed to the
+// outbox for retry; interrupted cleanups are run again. Returns the entries
+// that were recovered.
+func (m *Manager) RecoverJournal(ctx context.Context, outbox *Outbox) ([]JournalEntry, error) {
+	journal := m.Journal()
+	entries, err := journal.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var recovered []JournalEntry
+	for i := range entries {
+		entry := &entries[i]
+		if !entry.Interrupted() {
+			// Still in progress
+			continue
+		}
+
+		if entry.Dir != "" {
+			m.removeMergeWorktree(ctx, entry.Dir)
+		}
+
+		switch entry.Op {
+		case JournalMerge:
+			if err := outbox.Add(OutboxMerge, entry.TaskName); err != nil {
+				return recovered, err
+			}
+		case JournalPush:
+			if err := outbox.Add(OutboxPush, entry.TaskName); err != nil {
+				return recovered, err
+			}
+		case JournalCleanup:
+			// Cleanup is idempotent, so finish what was started. The agent
+			// dir may already be gone, so the task is rebuilt from the entry.
+			if err := m.CleanupTask(ctx, New(entry.TaskName, entry.AgentDir)); err != nil {
+				return recovered, fmt.Errorf("failed to resume cleanup of %s: %w", entry.TaskName, err)
+			}
+		}
+
+		if err := journal.Finish(entry); err != nil {
+			return recovered, err
+		}
+		recovered = append(recovered, *entry)
+	}
+
+	return recovered, nil
+}
@@ -0,0 +1,169 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/donghojung/taw/internal/claude"
+	"github.com/donghojung/taw/internal/constants"
+	"github.com/donghojung/taw/internal/logging"
+)
+
+// KnowledgeDoc is a note of the project knowledge base
+// (.taw/knowledge/*.md). A front matter block says which tasks get it:
+//
+//	---
+//	tags: backend, api
+//	keywords: payment, stripe webhook
+//	always: false
+//	---
+//
+// Without keywords, the words of the file name are its keywords.
+type KnowledgeDoc struct {
+	Name     string   `json:"name"`
+	Path     string   `json:"path"`
+	Tags     []string `json:"tags,omitempty"`
+	Keywords []string `json:"keywords,omitempty"`
+	Always   bool     `json:"always,omitempty"`
+	Content  string   `json:"-"` // Without the front matter
+}
+
+// knowledgeNameSeparators splits a file name into keywords.
+var knowledgeNameSeparators = regexp.MustCompile(`[-_. ]+`)
+
+// KnowledgeDir returns the knowledge base directory of the project.
+func (m *Manager) KnowledgeDir() string {
+	return filepath.Join(m.tawDir, constants.KnowledgeDirName)
+}
+
+// Knowledge returns the notes of the knowledge base, by name. It returns
+// nil if the project has no knowledge base.
+func (m *Manager) Knowledge() ([]KnowledgeDoc, error) {
+	paths, err := filepath.Glob(filepath.Join(m.KnowledgeDir(), "*.md"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var docs []KnowledgeDoc
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		docs = append(docs, parseKnowledgeDoc(path, string(data)))
+	}
+	return docs, nil
+}
+
+// parseKnowledgeDoc reads the front matter and content of a note.
+func parseKnowledgeDoc(path, data string) KnowledgeDoc {
+	doc := KnowledgeDoc{
+		Name:    strings.TrimSuffix(filepath.Base(path), ".md"),
+		Path:    path,
+		Content: data,
+	}
+
+	if rest, ok := strings.CutPrefix(data, "---\n"); ok {
+		if header, body, ok := strings.Cut(rest, "\n---\n"); ok {
+			doc.Content = body
+			for _, line := range strings.Split(header, "\n") {
+				key, value, ok := strings.Cut(line, ":")
+				if !ok {
+					continue
+				}
+				value = strings.TrimSpace(value)
+				switch strings.TrimSpace(key) {
+				case "tags":
+					doc.Tags = splitComma(value)
+				case "keywords":
+					doc.Keywords = splitComma(value)
+				case "always":
+					doc.Always = value == "true"
+				}
+			}
+		}
+	}
+
+	if len(doc.Keywords) == 0 {
+		for _, word := range knowledgeNameSeparators.Split(doc.Name, -1) {
+			if len(word) >= 3 {
+				doc.Keywords = append(doc.Keywords, word)
+			}
+		}
+	}
+	doc.Content = strings.TrimSpace(doc.Content)
+	return doc
+}
+
+// splitComma splits a comma-separated list, dropping empty items.
+func splitComma(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimPrefix(strings.TrimSpace(item), "#"); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Match returns true if a task with the tags and content gets the note: it
+// is always injected, shares a tag with the task, or the task mentions one
+// of its keywords as a word.
+func (d KnowledgeDoc) Match(tags []string, content string) bool {
+	if d.Always {
+		return true
+	}
+	for _, tag := range d.Tags {
+		if hasString(tags, tag) {
+			return true
+		}
+	}
+	lower := strings.ToLower(content)
+	for _, keyword := range d.Keywords {
+		pattern := `\b` + regexp.QuoteMeta(strings.ToLower(keyword)) + `\b`
+		if matched, _ := regexp.MatchString(pattern, lower); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchKnowledge returns the notes a task gets, in name order.
+func (m *Manager) MatchKnowledge(task *Task) []KnowledgeDoc {
+	docs, err := m.Knowledge()
+	if err != nil {
+		logging.Warn("Failed to read the knowledge base: %v", err)
+		return nil
+	}
+
+	var matched []KnowledgeDoc
+	tags := task.Tags()
+	for _, doc := range docs {
+		if doc.Content != "" && doc.Match(tags, task.Content) {
+			matched = append(matched, doc)
+		}
+	}
+	return matched
+}
+
+// KnowledgeLayers returns the notes a task gets as prompt layers, up to
+// KnowledgeMaxBytes in total so a broad match doesn't bloat the prompt.
+func (m *Manager) KnowledgeLayers(task *Task) []claude.PromptLayer {
+	var layers []claude.PromptLayer
+	size := 0
+	for _, doc := range m.MatchKnowledge(task) {
+		if size+len(doc.Content) > constants.KnowledgeMaxBytes {
+			logging.Warn("Knowledge %s left out of %s's prompt: over %d bytes of knowledge", doc.Name, task.Name, constants.KnowledgeMaxBytes)
+			continue
+		}
+		size += len(doc.Content)
+		layers = append(layers, claude.PromptLayer{Name: "knowledge", Source: doc.Path, Content: doc.Content})
+	}
+	return layers
+}
@@ -0,0 +1,86 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// ErrLimitsUnsupported is returned when some configured limits can't be
+// applied on this host. The agent still starts with the others.
+var ErrLimitsUnsupported = errors.New("resource limits not supported")
+
+// LimitCommand wraps an agent command line running on the host with the
+// configured limits: a systemd scope for CPU and memory, nice and ionice.
+// Returns ErrLimitsUnsupported, with the best command possible, if a limit
+// needs a tool this host lacks.
+func (m *Manager) LimitCommand(command string) (string, error) {
+	if m.config == nil {
+		return command, nil
+	}
+	limits := m.config.Limits
+
+	var prefix []string
+	var unsupported []string
+
+	if limits.CPUs > 0 || limits.Memory != "" {
+		if _, err := exec.LookPath("systemd-run"); err != nil {
+			unsupported = append(unsupported, "cpus/memory need systemd-run")
+		} else {
+			prefix = append(prefix, "systemd-run", "--user", "--scope", "--quiet")
+			if limits.CPUs > 0 {
+				prefix = append(prefix, "-p", fmt.Sprintf("CPUQuota=%d%%", int(limits.CPUs*100)))
+			}
+			if limits.Memory != "" {
+				prefix = append(prefix, "-p", "MemoryMax="+strings.ToUpper(limits.Memory))
+			}
+			prefix = append(prefix, "--")
+		}
+	}
+
+	prefix = append(prefix, m.niceArgs()...)
+
+	if limits.IONice != "" {
+		if _, err := exec.LookPath("ionice"); err != nil {
+			unsupported = append(unsupported, "ionice is not installed")
+		} else if limits.IONice == "idle" {
+			prefix = append(prefix, "ionice", "-c3")
+		} else {
+			prefix = append(prefix, "ionice", "-c2", "-n"+limits.IONice)
+		}
+	}
+
+	if len(prefix) > 0 {
+		command = strings.Join(prefix, " ") + " " + command
+	}
+	if len(unsupported) > 0 {
+		return command, fmt.Errorf("%w: %s", ErrLimitsUnsupported, strings.Join(unsupported, ", "))
+	}
+	return command, nil
+}
+
+// niceArgs returns the nice prefix for the configured niceness, if any.
+func (m *Manager) niceArgs() []string {
+	if m.config == nil || m.config.Limits.Nice <= 0 {
+		return nil
+	}
+	return []string{"nice", "-n", strconv.Itoa(m.config.Limits.Nice)}
+}
+
+// sandboxLimitArgs returns the docker run arguments for the configured CPU
+// and memory limits.
+func (m *Manager) sandboxLimitArgs() []string {
+	limits := m.config.Limits
+
+	var args []string
+	if limits.CPUs > 0 {
+		args = append(args, "--cpus", strconv.FormatFloat(limits.CPUs, 'f', -1, 64))
+	}
+	if limits.Memory != "" {
+		args = append(args, "--memory", strings.ToLower(limits.Memory))
+	}
+	return args
+}
@@ -0,0 +1,136 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/donghojung/taw/internal/constants"
+)
+
+// ErrProjectLocked is returned when another operation holds the project lock
+// for longer than the wait timeout.
+var ErrProjectLocked = errors.New("another operation in progress")
+
+// ErrTaskLocked is returned when another process is already ending or
+// merging the task.
+var ErrTaskLocked = errors.New("task is already being handled")
+
+// ProjectLock is an advisory flock serializing git mutations of the project
+// directory across TAW processes. The lock file records the current holder
+// so waiters can say what they are waiting for.
+type ProjectLock struct {
+	path string
+	file *os.File
+}
+
+// NewProjectLock creates a lock backed by the given file.
+func NewProjectLock(path string) *ProjectLock {
+	return &ProjectLock{
+		path: path,
+	}
+}
+
+// Lock acquires the lock for the named operation, waiting up to timeout.
+func (l *ProjectLock) Lock(op string, timeout time.Duration) error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create lock directory: %w", err)
+	}
+
+	file, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, syscall.EWOULDBLOCK) {
+			file.Close()
+			return fmt.Errorf("failed to lock %s: %w", l.path, err)
+		}
+		if time.Now().After(deadline) {
+			file.Close()
+			return fmt.Errorf("%w: %s", ErrProjectLocked, l.Holder())
+		}
+		time.Sleep(constants.ProjectLockPollInterval)
+	}
+
+	// Record the holder (error is non-fatal)
+	if err := file.Truncate(0); err == nil {
+		fmt.Fprintf(file, "%s (pid %d)", op, os.Getpid())
+	}
+
+	l.file = file
+	return nil
+}
+
+// Unlock releases the lock.
+func (l *ProjectLock) Unlock() error {
+	if l.file == nil {
+		return nil
+	}
+
+	l.file.Truncate(0)
+	err := syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN)
+	l.file.Close()
+	l.file = nil
+	return err
+}
+
+// Holder describes the operation currently holding the lock.
+func (l *ProjectLock) Holder() string {
+	data, err := os.ReadFile(l.path)
+	if err != nil || len(strings.TrimSpace(string(data))) == 0 {
+		return "unknown operation"
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// LockProject acquires the project lock around a git mutation of the project
+// directory. Call the returned function to release it.
+func (m *Manager) LockProject(op string) (func(), error) {
+	lock := NewProjectLock(filepath.Join(m.tawDir, constants.ProjectLockFileName))
+	timeout := constants.DefaultLockTimeout
+	if m.config != nil && m.config.Timeouts.Lock > 0 {
+		timeout = m.config.Timeouts.Lock
+	}
+
+	if err := lock.Lock(op, timeout); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		if err := lock.Unlock(); err != nil {
+			// The lock is released when the file is closed anyway
+		}
+	}, nil
+}
+
+// LockTask acquires the lock of a task for the named operation (end-task,
+// merge, ...), so two clients can't end or merge the same task at once. It
+// doesn't wait: a task already locked fails with ErrTaskLocked. Call the
+// returned function to release it.
+func (m *Manager) LockTask(task *Task, op string) (func(), error) {
+	lock := NewProjectLock(filepath.Join(task.AgentDir, constants.TaskLockFileName))
+	if err := lock.Lock(op, 0); err != nil {
+		if errors.Is(err, ErrProjectLocked) {
+			return nil, fmt.Errorf("%w by %s", ErrTaskLocked, lock.Holder())
+		}
+		return nil, err
+	}
+
+	return func() {
+		if err := lock.Unlock(); err != nil {
+			// The lock is released when the file is closed anyway
+		}
+	}, nil
+}
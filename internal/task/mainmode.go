@@ -0,0 +1,47 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/donghojung/taw/internal/config"
+)
+
+// SharesWorkingTree returns true if tasks work in the project's working tree
+// on the current branch (work_mode: main). Such tasks run one at a time.
+func (m *Manager) SharesWorkingTree() bool {
+	return m.isGitRepo && m.config != nil && m.config.WorkMode == config.WorkModeMain
+}
+
+// SharedTreeBusy returns why task can't start in the shared working tree
+// now, or "" if it can: another task still works in it, or a new task would
+// start on changes no task made. task is nil for a task yet to be created.
+// Tasks that don't share the working tree can always start.
+func (m *Manager) SharedTreeBusy(ctx context.Context, task *Task) (string, error) {
+	if !m.SharesWorkingTree() {
+		return "", nil
+	}
+
+	tasks, err := m.ListTasks()
+	if err != nil {
+		return "", err
+	}
+	for _, t := range tasks {
+		if task != nil && t.Name == task.Name {
+			continue
+		}
+		if t.Status != StatusDone && t.Status != StatusPushFailed {
+			return fmt.Sprintf("%s is working in the shared working tree (work_mode: main)", t.Name), nil
+		}
+	}
+
+	// A task that started before left its own changes behind
+	if task != nil && task.Status != StatusPending {
+		return "", nil
+	}
+	if m.gitClient.HasChanges(ctx, m.projectDir) {
+		return "the working tree has uncommitted changes; commit or stash them (work_mode: main)", nil
+	}
+	return "", nil
+}
@@ -2,16 +2,21 @@
 package task
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
 
 	"github.com/donghojung/taw/internal/claude"
 	"github.com/donghojung/taw/internal/config"
 	"github.com/donghojung/taw/internal/constants"
 	"github.com/donghojung/taw/internal/git"
 	"github.com/donghojung/taw/internal/github"
+	"github.com/donghojung/taw/internal/logging"
 	"github.com/donghojung/taw/internal/tmux"
 )
 
@@ -30,16 +35,49 @@ type Manager struct {
 
 // NewManager creates a new task manager.
 func NewManager(agentsDir, projectDir, tawDir string, isGitRepo bool, cfg *config.Config) *Manager {
-	return &Manager{
+	// Zero timeouts (no config) fall back to the defaults
+	var timeouts config.TimeoutsConfig
+	backend := config.GitBackendAuto
+	var gitEnv []string
+	if cfg != nil {
+		timeouts = cfg.Timeouts
+		backend = cfg.GitBackend
+		// TAW's commits get the same git settings as the agents'
+		gitEnv = config.Environ(cfg.GitEnv())
+	}
+
+	m := &Manager{
 		agentsDir:   agentsDir,
 		projectDir:  projectDir,
 		tawDir:      tawDir,
 		isGitRepo:   isGitRepo,
 		config:      cfg,
-		gitClient:   git.New(),
-		ghClient:    github.New(),
-		claudeClient: claude.New(),
+		gitClient:   git.NewWithBackend(git.Backend(backend), timeouts.Git, timeouts.Network, gitEnv),
+		claudeClient: claude.NewWithTimeouts(timeouts.ClaudeReady, timeouts.ClaudeName),
+	}
+	m.ghClient = m.newGitHubClient(false)
+	return m
+}
+
+// newGitHubClient creates the gh client with the project's PR status cache.
+func (m *Manager) newGitHubClient(refresh bool) github.Client {
+	cache := github.PRCache{
+		Dir:     filepath.Join(m.tawDir, constants.CacheDirName),
+		TTL:     constants.DefaultPRCacheTTL,
+		Refresh: refresh,
+	}
+	var timeout time.Duration
+	if m.config != nil {
+		cache.TTL = m.config.PRCacheTTL
+		timeout = m.config.Timeouts.GitHub
 	}
+	return github.NewWithCache(timeout, cache)
+}
+
+// SetPRRefresh makes PR status checks revalidate cached statuses with GitHub
+// regardless of their TTL.
+func (m *Manager) SetPRRefresh(refresh bool) {
+	m.ghClient = m.newGitHubClient(refresh)
 }
 
 // SetTmuxClient sets the tmux client for the manager.
@@ -47,15 +85,11 @@ func (m *Manager) SetTmuxClient(client tmux.Client) {
 	m.tmuxClient = client
 }
 
-// CreateTask creates a new task with the given content.
-// It generates a task name using Claude and creates the task directory atomically.
-func (m *Manager) CreateTask(content string) (*Task, error) {
-	// Generate task name using Claude
-	name, err := m.claudeClient.GenerateTaskName(content)
-	if err != nil {
-		// Use fallback name if Claude fails
-		name = fmt.Sprintf("task-%d", os.Getpid())
-	}
+// CreateTask creates a new task with the given content, named name if it
+// makes a valid task name, else with a name generated by Claude. The task
+// directory is created atomically.
+func (m *Manager) CreateTask(ctx context.Context, content, name string) (*Task, error) {
+	name = m.taskName(ctx, content, name)
 
 	// Create task directory atomically
 	agentDir, err := m.createTaskDirectory(name)
@@ -70,10 +104,50 @@ func (m *Manager) CreateTask(content string) (*Task, error) {
 		task.Remove()
 		return nil, fmt.Errorf("failed to save task content: %w", err)
 	}
+	if _, err := task.EnsureID(); err != nil {
+		task.Remove()
+		return nil, err
+	}
+	if err := task.SaveOwner(m.CurrentUser(ctx)); err != nil {
+		// The owner is informational only
+	}
+	if err := task.RecordCreated(); err != nil {
+		// The timeline is informational only
+	}
+	if err := task.SaveIsolation(m.InferIsolation(content)); err != nil {
+		task.Remove()
+		return nil, fmt.Errorf("failed to save isolation: %w", err)
+	}
+
+	m.recordCreation(task)
 
 	return task, nil
 }
 
+// taskName returns hint as a task name if it makes a valid one, or a name
+// generated by Claude from the content.
+func (m *Manager) taskName(ctx context.Context, content, hint string) string {
+	if name := claude.SanitizeTaskName(hint); claude.TaskNamePattern.MatchString(name) {
+		return name
+	}
+	name, err := m.claudeClient.GenerateTaskName(ctx, content)
+	if err != nil {
+		// Use fallback name if Claude fails
+		name = fmt.Sprintf("task-%d", os.Getpid())
+	}
+	return name
+}
+
+// recordCreation opens the task's archive entry (error is non-fatal).
+func (m *Manager) recordCreation(task *Task) {
+	if err := m.Archive().Record(task.Name, func(e *ArchiveEntry) {
+		e.Content = task.Content
+		e.Owner = task.Owner
+	}); err != nil {
+		// Archive is informational only
+	}
+}
+
 // createTaskDirectory creates a task directory atomically.
 // If the name already exists, it appends a number.
 func (m *Manager) createTaskDirectory(baseName string) (string, error) {
@@ -110,20 +184,45 @@ func (m *Manager) GetTask(name string) (*Task, error) {
 		return nil, fmt.Errorf("failed to load task content: %w", err)
 	}
 
+	task.LoadID()
+
+	// The timeline falls back to the task file, written when the task is created
+	if created := task.LoadTimeline().CreatedAt; !created.IsZero() {
+		task.CreatedAt = created
+	}
+
 	// Load window ID if exists (error is non-fatal)
 	if task.HasTabLock() {
+		task.Status = StatusWorking
 		if _, err := task.LoadWindowID(); err != nil {
 			// Window ID file might be corrupted or missing - continue anyway
 		}
 	}
 
+	// The status reported by the agent, if any
+	if status := task.LoadStatus(); status != "" {
+		task.Status = status
+	}
+
+	task.LoadOwner()
+
 	// Load PR number if exists (error is non-fatal)
 	if _, err := task.LoadPRNumber(); err != nil {
 		// PR file might be corrupted - continue anyway
 	}
 
-	// Set worktree directory (with nil check for config)
-	if m.isGitRepo && m.config != nil && m.config.WorkMode == config.WorkModeWorktree {
+	// Load draft group if this is a competing draft
+	if task.LoadDraftGroup() != "" && task.IsDraftDone() {
+		task.Status = StatusDone
+	}
+
+	// Surface a failed push recorded by end-task
+	if task.LoadPushFailure() != "" {
+		task.Status = StatusPushFailed
+	}
+
+	// Set worktree directory of tasks that have one
+	if m.UsesWorktree(task) {
 		task.WorktreeDir = task.GetWorktreeDir()
 	}
 
@@ -157,6 +256,26 @@ func (m *Manager) ListTasks() ([]*Task, error) {
 	return tasks, nil
 }
 
+// AtTaskLimit returns true if max_parallel_tasks tasks are already running.
+// Tasks that are done or failed to push don't count.
+func (m *Manager) AtTaskLimit() (bool, error) {
+	if m.config == nil || m.config.MaxParallel == 0 {
+		return false, nil
+	}
+
+	tasks, err := m.ListTasks()
+	if err != nil {
+		return false, err
+	}
+	running := 0
+	for _, t := range tasks {
+		if t.Status != StatusDone && t.Status != StatusPushFailed {
+			running++
+		}
+	}
+	return running >= m.config.MaxParallel, nil
+}
+
 // FindIncompleteTasks finds tasks that have a tab-lock but no active window.
 func (m *Manager) FindIncompleteTasks(sessionName string) ([]*Task, error) {
 	if m.tmuxClient == nil {
@@ -175,27 +294,14 @@ func (m *Manager) FindIncompleteTasks(sessionName string) ([]*Task, error) {
 		windows = nil
 	}
 
-	activeWindowIDs := make(map[string]bool)
-	for _, w := range windows {
-		activeWindowIDs[w.ID] = true
-	}
-
 	var incomplete []*Task
 	for _, task := range tasks {
 		if !task.HasTabLock() {
 			continue
 		}
 
-		windowID, err := task.LoadWindowID()
-		if err != nil {
-			// Has tab-lock but no window ID file - incomplete
-			task.Status = StatusPending
-			incomplete = append(incomplete, task)
-			continue
-		}
-
 		// Check if window is still active
-		if !activeWindowIDs[windowID] {
+		if task.WindowIn(windows) == "" {
 			task.Status = StatusPending
 			incomplete = append(incomplete, task)
 		}
@@ -204,9 +310,13 @@ func (m *Manager) FindIncompleteTasks(sessionName string) ([]*Task, error) {
 	return incomplete, nil
 }
 
-// FindCorruptedTasks finds tasks with corrupted worktrees.
-func (m *Manager) FindCorruptedTasks() ([]*Task, error) {
-	if !m.isGitRepo || m.config == nil || m.config.WorkMode != config.WorkModeWorktree {
+// FindCorruptedTasks finds tasks with corrupted worktrees, and with the tmux
+// client set, tasks whose window or agent is gone.
+// Tasks are checked concurrently against one snapshot of worktrees, branches
+// and windows.
+func (m *Manager) FindCorruptedTasks(ctx context.Context) ([]*Task, error) {
+	worktreeMode := m.isGitRepo && m.config != nil && m.config.WorkMode == config.WorkModeWorktree
+	if !worktreeMode && m.tmuxClient == nil {
 		return nil, nil
 	}
 
@@ -215,12 +325,49 @@ func (m *Manager) FindCorruptedTasks() ([]*Task, error) {
 		return nil, err
 	}
 
+	scan := &worktreeScan{
+		branches: make(map[string]bool),
+	}
+	if worktreeMode {
+		scan.worktrees, scan.worktreesErr = m.gitClient.WorktreeList(ctx, m.projectDir)
+		if branches, err := m.gitClient.ListBranches(ctx, m.projectDir); err == nil {
+			for _, branch := range branches {
+				scan.branches[branch] = true
+			}
+		}
+	}
+	if m.tmuxClient != nil {
+		// Without a session there are no windows to check against
+		if windows, err := m.tmuxClient.ListWindows(); err == nil {
+			scan.windows = windows
+			scan.windowsListed = true
+		}
+	}
+
+	reasons := make([]CorruptedReason, len(tasks))
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(constants.ScanConcurrency)
+	for i, task := range tasks {
+		i, task := i, task
+		g.Go(func() error {
+			if worktreeMode && m.UsesWorktree(task) {
+				reasons[i] = m.checkWorktreeStatus(task, scan)
+			}
+			if reasons[i] == "" && scan.windowsListed {
+				reasons[i] = m.checkWindowStatus(task, scan)
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
 	var corrupted []*Task
-	for _, task := range tasks {
-		reason := m.checkWorktreeStatus(task)
-		if reason != "" {
+	for i, task := range tasks {
+		if reasons[i] != "" {
 			task.Status = StatusCorrupted
-			task.CorruptedReason = reason
+			task.CorruptedReason = reasons[i]
 			corrupted = append(corrupted, task)
 		}
 	}
@@ -228,15 +375,35 @@ func (m *Manager) FindCorruptedTasks() ([]*Task, error) {
 	return corrupted, nil
 }
 
+// worktreeScan is the repository and session state shared by the checks of
+// one corrupted-task scan.
+type worktreeScan struct {
+	worktrees    []git.Worktree
+	worktreesErr error
+	branches     map[string]bool
+	windows       []tmux.Window // Windows of the session
+	windowsListed bool          // Whether windows was listed
+}
+
+// shellCommands are the pane commands of an idle shell.
+var shellCommands = map[string]bool{
+	"sh": true, "bash": true, "zsh": true, "fish": true,
+	"dash": true, "ksh": true, "tcsh": true, "csh": true, "nu": true,
+}
+
 // checkWorktreeStatus checks the status of a task's worktree.
-func (m *Manager) checkWorktreeStatus(task *Task) CorruptedReason {
+func (m *Manager) checkWorktreeStatus(task *Task, scan *worktreeScan) CorruptedReason {
 	worktreeDir := task.GetWorktreeDir()
 
 	// Check if worktree directory exists
 	info, err := os.Stat(worktreeDir)
 	if os.IsNotExist(err) {
+		// Hibernated tasks get their worktree back when opened again
+		if task.IsHibernated() {
+			return ""
+		}
 		// Check if branch exists
-		if m.gitClient.BranchExists(m.projectDir, task.Name) {
+		if scan.branches[task.GetBranch()] {
 			return CorruptMissingWorktree
 		}
 		return "" // No worktree and no branch - task might be cleaned up
@@ -252,34 +419,85 @@ func (m *Manager) checkWorktreeStatus(task *Task) CorruptedReason {
 		return CorruptInvalidGit
 	}
 
-	// Check if worktree is registered in git
-	worktrees, err := m.gitClient.WorktreeList(m.projectDir)
+	// Check if the git directory it points to exists
+	gitDir, err := worktreeGitDir(worktreeDir)
 	if err != nil {
+		return CorruptInvalidGit
+	}
+	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
+		return CorruptMissingGitDir
+	}
+
+	// Check if worktree is registered in git
+	if scan.worktreesErr != nil {
 		return CorruptNotInGit
 	}
 
 	registered := false
-	for _, wt := range worktrees {
-		if wt.Path == worktreeDir || strings.HasSuffix(wt.Path, "/"+filepath.Base(worktreeDir)) {
+	detached := false
+	for _, wt := range scan.worktrees {
+		if wt.Path == worktreeDir {
 			registered = true
+			detached = wt.Branch == ""
 			break
 		}
+		if strings.HasSuffix(wt.Path, "/"+filepath.Base(worktreeDir)) {
+			registered = true
+		}
 	}
 
 	if !registered {
 		return CorruptNotInGit
 	}
 
+	// Check if HEAD left the branch, other than for a rebase in progress
+	if detached && !rebaseInProgress(gitDir) {
+		return CorruptDetachedHead
+	}
+
 	// Check if branch exists
-	if !m.gitClient.BranchExists(m.projectDir, task.Name) {
+	if !scan.branches[task.GetBranch()] {
 		return CorruptMissingBranch
 	}
 
+	// Check for locks that block git in the worktree
+	if len(staleGitLocks(worktreeDir)) > 0 {
+		return CorruptStaleGitLock
+	}
+
 	return "" // OK
 }
 
+// checkWindowStatus checks the task's window and the agent running in it.
+func (m *Manager) checkWindowStatus(task *Task, scan *worktreeScan) CorruptedReason {
+	// No window ID yet while handle-task is still creating the window
+	if id, err := task.LoadWindowID(); err != nil || id == "" {
+		return ""
+	}
+	// An interrupted setup is resumed by handle-task instead
+	if task.SetupInterrupted() {
+		return ""
+	}
+	windowID := task.WindowIn(scan.windows)
+	if windowID == "" {
+		return CorruptStaleTabLock
+	}
+
+	// An agent that finished may have been exited on purpose
+	if task.Status == StatusDone {
+		return ""
+	}
+	command, err := m.tmuxClient.PaneCommand(windowID + ".0")
+	if err == nil && shellCommands[strings.TrimPrefix(command, "-")] {
+		return CorruptDeadAgent
+	}
+	return ""
+}
+
 // FindMergedTasks finds tasks whose branches have been merged.
-func (m *Manager) FindMergedTasks() ([]*Task, error) {
+// Merged branches are listed once per scan; only tasks with a PR need a
+// per-task gh call, and those run concurrently.
+func (m *Manager) FindMergedTasks(ctx context.Context) ([]*Task, error) {
 	if !m.isGitRepo {
 		return nil, nil
 	}
@@ -289,11 +507,36 @@ func (m *Manager) FindMergedTasks() ([]*Task, error) {
 		return nil, err
 	}
 
-	mainBranch := m.gitClient.GetMainBranch(m.projectDir)
+	mainBranch := m.MainBranch(ctx)
+	mergedBranches := make(map[string]bool)
+	for _, into := range []string{mainBranch, m.UpstreamRemote() + "/" + mainBranch} {
+		branches, err := m.gitClient.MergedBranches(ctx, m.projectDir, into)
+		if err != nil {
+			// Upstream ref may not exist (e.g. never fetched)
+			continue
+		}
+		for _, branch := range branches {
+			mergedBranches[branch] = true
+		}
+	}
+
+	results := make([]bool, len(tasks))
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(constants.ScanConcurrency)
+	for i, task := range tasks {
+		i, task := i, task
+		g.Go(func() error {
+			results[i] = m.isTaskMerged(ctx, task, mergedBranches)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
 
 	var merged []*Task
-	for _, task := range tasks {
-		if m.isTaskMerged(task, mainBranch) {
+	for i, task := range tasks {
+		if results[i] {
 			task.Status = StatusDone
 			merged = append(merged, task)
 		}
@@ -302,35 +545,46 @@ func (m *Manager) FindMergedTasks() ([]*Task, error) {
 	return merged, nil
 }
 
-// isTaskMerged checks if a task has been merged.
-func (m *Manager) isTaskMerged(task *Task, mainBranch string) bool {
+// isTaskMerged checks if a task has been merged, given the branches merged
+// into main (local or upstream).
+func (m *Manager) isTaskMerged(ctx context.Context, task *Task, mergedBranches map[string]bool) bool {
+	if mergedBranches[task.GetBranch()] {
+		return true
+	}
+
 	// Check if PR is merged
 	if task.HasPR() {
 		prNumber, err := task.LoadPRNumber()
 		if err == nil && prNumber > 0 {
-			merged, err := m.ghClient.IsPRMerged(m.projectDir, prNumber)
+			merged, err := m.ghClient.IsPRMerged(ctx, m.projectDir, prNumber)
 			if err == nil && merged {
 				return true
 			}
 		}
 	}
 
-	// Check if branch is merged into main
-	if m.gitClient.BranchMerged(m.projectDir, task.Name, mainBranch) {
-		return true
-	}
-
 	return false
 }
 
 // CleanupTask cleans up a task's resources.
-func (m *Manager) CleanupTask(task *Task) error {
-	if m.isGitRepo && m.config != nil && m.config.WorkMode == config.WorkModeWorktree {
+func (m *Manager) CleanupTask(ctx context.Context, task *Task) error {
+	entry := m.beginJournal(JournalCleanup, task)
+	defer m.finishJournal(entry)
+
+	m.StopSandbox(task)
+
+	if m.UsesWorktree(task) {
+		unlock, err := m.LockProject(fmt.Sprintf("cleanup of %s", task.Name))
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
 		worktreeDir := task.GetWorktreeDir()
 
 		// Remove worktree
 		if _, err := os.Stat(worktreeDir); err == nil {
-			if err := m.gitClient.WorktreeRemove(m.projectDir, worktreeDir, true); err != nil {
+			if err := m.gitClient.WorktreeRemove(ctx, m.projectDir, worktreeDir, true); err != nil {
 				// Try force remove if normal remove fails
 				if removeErr := os.RemoveAll(worktreeDir); removeErr != nil {
 					// Log but continue - cleanup should not fail entirely
@@ -339,45 +593,102 @@ func (m *Manager) CleanupTask(task *Task) error {
 		}
 
 		// Prune worktrees (error is non-fatal)
-		if err := m.gitClient.WorktreePrune(m.projectDir); err != nil {
+		if err := m.gitClient.WorktreePrune(ctx, m.projectDir); err != nil {
 			// Log but continue
 		}
 
-		// Delete branch (error is non-fatal)
-		if m.gitClient.BranchExists(m.projectDir, task.Name) {
-			if err := m.gitClient.BranchDelete(m.projectDir, task.Name, true); err != nil {
+		// Delete branch, keeping it in the trash for 'taw undo' (error is non-fatal)
+		if branch := task.GetBranch(); m.gitClient.BranchExists(ctx, m.projectDir, branch) {
+			if m.TrashDays() > 0 {
+				if err := m.trashBranch(ctx, task); err != nil {
+					logging.Warn("Failed to keep branch of %s in trash: %v", task.Name, err)
+				}
+			}
+			if err := m.gitClient.BranchDelete(ctx, m.projectDir, branch, true); err != nil {
 				// Log but continue
 			}
 		}
 	}
 
-	// Remove agent directory
+	// Drop expired trash while at it
+	defer m.PurgeTrash(ctx)
+
+	// Move the agent directory to the trash, or remove it
+	if m.TrashDays() > 0 {
+		err := m.moveToTrash(task)
+		if err == nil {
+			return nil
+		}
+		logging.Warn("Failed to move %s to trash: %v", task.Name, err)
+	}
 	return task.Remove()
 }
 
 // SetupWorktree creates a git worktree for the task.
-func (m *Manager) SetupWorktree(task *Task) error {
-	if !m.isGitRepo || m.config == nil || m.config.WorkMode != config.WorkModeWorktree {
+func (m *Manager) SetupWorktree(ctx context.Context, task *Task) error {
+	if !m.UsesWorktree(task) {
 		return nil
 	}
 
+	unlock, err := m.LockProject(fmt.Sprintf("worktree setup of %s", task.Name))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	worktreeDir := task.GetWorktreeDir()
 	task.WorktreeDir = worktreeDir
 
+	// A task restored with 'taw undo' already has its worktree
+	if _, err := os.Stat(worktreeDir); err == nil {
+		return nil
+	}
+
+	// Name the branch and place the worktree as configured; tasks addressing
+	// review comments start from the branch of the pull request
+	branch := m.config.BranchName(task.Name)
+	if review := task.Review(); review != nil {
+		if err := m.setupReviewBranch(ctx, task, review, branch); err != nil {
+			return err
+		}
+	}
+	if err := task.SaveBranch(branch); err != nil {
+		return fmt.Errorf("failed to save branch: %w", err)
+	}
+	if path := m.config.WorktreePath(m.projectDir, task.Name); path != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create worktree root: %w", err)
+		}
+		if err := task.SaveWorktreeDir(path); err != nil {
+			return fmt.Errorf("failed to save worktree path: %w", err)
+		}
+		worktreeDir = path
+	}
+
 	// Stash any uncommitted changes (error is non-fatal)
-	stashHash, _ := m.gitClient.StashCreate(m.projectDir)
+	stashHash, _ := m.gitClient.StashCreate(ctx, m.projectDir)
 
 	// Get untracked files (error is non-fatal)
-	untrackedFiles, _ := m.gitClient.GetUntrackedFiles(m.projectDir)
-
-	// Create worktree with new branch
-	if err := m.gitClient.WorktreeAdd(m.projectDir, worktreeDir, task.Name, true); err != nil {
+	untrackedFiles, _ := m.gitClient.GetUntrackedFiles(ctx, m.projectDir)
+
+	// Create worktree with new branch, or with the branch a setup that was
+	// interrupted before the worktree existed already created
+	createBranch := !m.gitClient.BranchExists(ctx, m.projectDir, branch)
+	if createBranch {
+		if start := m.branchStart(ctx); start != "" {
+			if err := m.gitClient.BranchCreate(ctx, m.projectDir, branch, start); err != nil {
+				return fmt.Errorf("failed to create branch: %w", err)
+			}
+			createBranch = false
+		}
+	}
+	if err := m.gitClient.WorktreeAdd(ctx, m.projectDir, worktreeDir, branch, createBranch); err != nil {
 		return fmt.Errorf("failed to create worktree: %w", err)
 	}
 
 	// Apply stash to worktree if there were changes (error is non-fatal)
 	if stashHash != "" {
-		if err := m.gitClient.StashApply(worktreeDir, stashHash); err != nil {
+		if err := m.gitClient.StashApply(ctx, worktreeDir, stashHash); err != nil {
 			// Stash apply can fail if there are conflicts - continue anyway
 		}
 	}
@@ -389,20 +700,64 @@ func (m *Manager) SetupWorktree(task *Task) error {
 		}
 	}
 
-	// Create .claude symlink in worktree (error is non-fatal)
-	claudeLink := filepath.Join(worktreeDir, constants.ClaudeLink)
-	claudeTarget := filepath.Join(m.tawDir, constants.ClaudeLink)
-	if err := os.Symlink(claudeTarget, claudeLink); err != nil {
-		// Symlink might already exist or fail for other reasons - continue anyway
-	}
-
 	return nil
 }
 
 // GetWorkingDirectory returns the working directory for a task.
 func (m *Manager) GetWorkingDirectory(task *Task) string {
-	if m.isGitRepo && m.config != nil && m.config.WorkMode == config.WorkModeWorktree {
+	if m.UsesWorktree(task) {
 		return task.GetWorktreeDir()
 	}
+	if !m.isGitRepo && task.HasSnapshot() {
+		return task.GetSnapshotDir()
+	}
 	return m.projectDir
 }
+
+// PushRemote returns the remote task branches are pushed to.
+func (m *Manager) PushRemote() string {
+	if m.config == nil || m.config.PushRemote == "" {
+		return constants.DefaultRemote
+	}
+	return m.config.PushRemote
+}
+
+// branchStart returns where a new task branch starts if not at the project
+// directory's HEAD: <upstream>/<main> when main is checked out there and
+// behind it, as merges pushed from a temporary worktree leave it.
+func (m *Manager) branchStart(ctx context.Context) string {
+	mainBranch := m.MainBranch(ctx)
+	if current, err := m.gitClient.GetCurrentBranch(ctx, m.projectDir); err != nil || current != mainBranch {
+		return ""
+	}
+	remoteMain := m.UpstreamRemote() + "/" + mainBranch
+	if !m.gitClient.RefExists(ctx, m.projectDir, "refs/remotes/"+remoteMain) {
+		return ""
+	}
+	head, err := m.gitClient.RevParse(ctx, m.projectDir, "HEAD")
+	if err != nil {
+		return ""
+	}
+	remote, err := m.gitClient.RevParse(ctx, m.projectDir, remoteMain)
+	if err != nil || remote == head || m.gitClient.IsAncestor(ctx, m.projectDir, remote, head) {
+		return ""
+	}
+	if !m.gitClient.IsAncestor(ctx, m.projectDir, head, remote) {
+		logging.Warn("Local %s has diverged from %s; new tasks start from the local %s", mainBranch, remoteMain, mainBranch)
+		return ""
+	}
+	return remoteMain
+}
+
+// UpstreamRemote returns the remote that hosts the main branch.
+func (m *Manager) UpstreamRemote() string {
+	if m.config == nil || m.config.UpstreamRemote == "" {
+		return constants.DefaultRemote
+	}
+	return m.config.UpstreamRemote
+}
+
+// MainBranch returns the main branch name of the upstream remote.
+func (m *Manager) MainBranch(ctx context.Context) string {
+	return m.gitClient.GetDefaultBranch(ctx, m.projectDir, m.UpstreamRemote())
+}
@@ -0,0 +1,270 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/donghojung/taw/internal/config"
+	"github.com/donghojung/taw/internal/github"
+	"github.com/donghojung/taw/internal/logging"
+)
+
+// MergeToMain integrates the task branch into the main branch according to
+// the configured merge strategy. It never touches the user's checkout in the
+// project directory.
+func (m *Manager) MergeToMain(ctx context.Context, task *Task) error {
+	unlock, err := m.LockProject(fmt.Sprintf("merge of %s", task.Name))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entry := m.beginJournal(JournalMerge, task)
+	defer m.finishJournal(entry)
+
+	strategy := m.MergeStrategy()
+	if strategy.UsesPullRequest() {
+		return m.mergeViaPullRequest(ctx, task, strategy)
+	}
+	return m.mergeLocally(ctx, task, entry)
+}
+
+// MergeStrategy returns the strategy of the merge step (merge.strategy), or
+// the configured merge strategy, defaulting to merge.
+func (m *Manager) MergeStrategy() config.MergeStrategy {
+	if m.config == nil {
+		return config.MergeStrategyMerge
+	}
+	if strategy := m.config.Pipeline.Option(config.ActionMerge, "strategy"); strategy != "" {
+		return config.MergeStrategy(strategy)
+	}
+	if m.config.MergeStrategy == "" {
+		return config.MergeStrategyMerge
+	}
+	return m.config.MergeStrategy
+}
+
+// ErrMainCheckedOut is returned when a merge without a remote would have to
+// move the main branch checked out in the project directory.
+var ErrMainCheckedOut = errors.New("main branch is checked out")
+
+// mergeLocally merges the task branch with --no-ff and pushes the result.
+// The merge happens in a temporary detached worktree so the user's checkout
+// in the project directory (branch, index, dirty files) is never touched.
+// Without the upstream remote the merge moves the local main branch instead.
+func (m *Manager) mergeLocally(ctx context.Context, task *Task, entry *JournalEntry) error {
+	mainBranch := m.MainBranch(ctx)
+	upstream := m.UpstreamRemote()
+
+	if _, err := m.gitClient.GetRemoteURL(ctx, m.projectDir, upstream); err != nil {
+		return m.mergeWithoutRemote(ctx, task, entry, mainBranch)
+	}
+
+	if err := m.gitClient.Fetch(ctx, m.projectDir, upstream); err != nil {
+		return fmt.Errorf("failed to fetch: %w", err)
+	}
+
+	mergeDir, err := m.mergeInWorktree(ctx, task, entry, upstream+"/"+mainBranch)
+	if mergeDir != "" {
+		defer m.removeMergeWorktree(ctx, mergeDir)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := m.gitClient.Push(ctx, mergeDir, upstream, "HEAD:"+mainBranch, false); err != nil {
+		return fmt.Errorf("failed to push merged %s: %w", mainBranch, err)
+	}
+
+	m.updateLocalMain(ctx, mainBranch)
+	return nil
+}
+
+// mergeWithoutRemote merges the task branch into the local main branch, for
+// repositories without the upstream remote. Main can only move while no
+// worktree has it checked out, the project directory included.
+func (m *Manager) mergeWithoutRemote(ctx context.Context, task *Task, entry *JournalEntry, mainBranch string) error {
+	worktrees, err := m.gitClient.WorktreeList(ctx, m.projectDir)
+	if err != nil {
+		return fmt.Errorf("failed to list worktrees: %w", err)
+	}
+	for _, wt := range worktrees {
+		if wt.Branch == mainBranch {
+			return fmt.Errorf("%w: no remote %s to push to, and %s can't move while checked out in %s",
+				ErrMainCheckedOut, m.UpstreamRemote(), mainBranch, wt.Path)
+		}
+	}
+
+	ref := "refs/heads/" + mainBranch
+	old, err := m.gitClient.RevParse(ctx, m.projectDir, ref)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", mainBranch, err)
+	}
+
+	mergeDir, err := m.mergeInWorktree(ctx, task, entry, old)
+	if mergeDir != "" {
+		defer m.removeMergeWorktree(ctx, mergeDir)
+	}
+	if err != nil {
+		return err
+	}
+
+	merged, err := m.gitClient.RevParse(ctx, mergeDir, "HEAD")
+	if err != nil {
+		return fmt.Errorf("failed to resolve merge: %w", err)
+	}
+	// Refused if main moved since, rather than dropping those commits
+	if err := m.gitClient.UpdateRefFrom(ctx, m.projectDir, ref, merged, old); err != nil {
+		return fmt.Errorf("failed to update %s: %w", mainBranch, err)
+	}
+	return nil
+}
+
+// mergeInWorktree merges the task branch with --no-ff in a temporary detached
+// worktree at base. It returns the worktree, to be removed by the caller even
+// on error, or "" if none was created.
+func (m *Manager) mergeInWorktree(ctx context.Context, task *Task, entry *JournalEntry, base string) (string, error) {
+	mergeDir, err := os.MkdirTemp(m.tawDir, "merge-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create merge directory: %w", err)
+	}
+
+	// Let recovery remove the merge worktree if this process is killed
+	if entry != nil {
+		entry.Dir = mergeDir
+		if err := m.Journal().Update(entry); err != nil {
+			// Journaling is best-effort - continue with the merge
+		}
+	}
+
+	if err := m.gitClient.WorktreeAddDetached(ctx, m.projectDir, mergeDir, base); err != nil {
+		return mergeDir, fmt.Errorf("failed to create merge worktree: %w", err)
+	}
+
+	branch := task.GetBranch()
+	mergeMsg := fmt.Sprintf("Merge branch '%s'", branch)
+	if err := m.gitClient.Merge(ctx, mergeDir, branch, true, mergeMsg); err != nil {
+		m.gitClient.MergeAbort(ctx, mergeDir)
+		return mergeDir, fmt.Errorf("merge failed: %w", err)
+	}
+	return mergeDir, nil
+}
+
+// mergeViaPullRequest merges the task through a GitHub pull request, creating
+// one if the task doesn't have a PR yet. Used for repos with branch protection
+// or linear-history requirements where a pushed local merge would be rejected.
+func (m *Manager) mergeViaPullRequest(ctx context.Context, task *Task, strategy config.MergeStrategy) error {
+	if !m.ghClient.IsInstalled() {
+		return fmt.Errorf("merge_strategy %s: %w", strategy, github.ErrNotInstalled)
+	}
+
+	mainBranch := m.MainBranch(ctx)
+
+	prNumber, err := m.ensurePR(ctx, task, mainBranch)
+	if err != nil {
+		return err
+	}
+
+	opts := github.MergeOpts{}
+	switch strategy {
+	case config.MergeStrategySquash:
+		opts.Method = "squash"
+	case config.MergeStrategyRebase:
+		opts.Method = "rebase"
+	case config.MergeStrategyMergeQueue:
+		opts.Auto = true
+	}
+
+	if err := m.ghClient.MergePR(ctx, m.projectDir, prNumber, opts); err != nil {
+		return err
+	}
+
+	if err := m.gitClient.Fetch(ctx, m.projectDir, m.UpstreamRemote()); err == nil {
+		m.updateLocalMain(ctx, mainBranch)
+	}
+	return nil
+}
+
+// ensurePR returns the task's PR number, creating a pull request if needed.
+func (m *Manager) ensurePR(ctx context.Context, task *Task, base string) (int, error) {
+	if prNumber, err := task.LoadPRNumber(); err == nil && prNumber > 0 {
+		// The draft opened when the task was parked is ready now
+		if task.IsParked() {
+			if err := m.ghClient.MarkPRReady(ctx, m.GetWorkingDirectory(task), prNumber); err != nil {
+				return 0, err
+			}
+			if err := task.ClearParked(); err != nil {
+				// The marker only makes the next end mark it ready again
+			}
+		}
+		return prNumber, nil
+	}
+
+	body := task.Content
+	summary := m.prSummary(ctx, task, base)
+	if summary != nil {
+		body = strings.TrimRight(body, "\n") + "\n\n" + summary.Markdown()
+	}
+
+	prNumber, err := m.ghClient.CreatePR(ctx, m.GetWorkingDirectory(task), task.Name, body, base, m.prHead(ctx, task))
+	if err != nil {
+		return 0, err
+	}
+
+	if err := task.SavePRNumber(prNumber); err != nil {
+		// PR exists on GitHub; failing to record it locally is non-fatal
+	}
+
+	// Keep the summary for later reporting (error is non-fatal)
+	if err := m.Archive().Record(task.Name, func(e *ArchiveEntry) {
+		e.Content = task.Content
+		e.PRNumber = prNumber
+		e.PRSummary = summary
+	}); err != nil {
+		// Archive is informational only
+	}
+
+	return prNumber, nil
+}
+
+// prHead returns the --head value for a task's pull request.
+// For fork workflows this is "owner:branch" of the push remote; otherwise empty.
+func (m *Manager) prHead(ctx context.Context, task *Task) string {
+	if m.PushRemote() == m.UpstreamRemote() {
+		return ""
+	}
+
+	url, err := m.gitClient.GetRemoteURL(ctx, m.projectDir, m.PushRemote())
+	if err != nil {
+		return ""
+	}
+
+	owner := github.OwnerFromURL(url)
+	if owner == "" {
+		return ""
+	}
+	return owner + ":" + task.GetBranch()
+}
+
+// updateLocalMain fast-forwards the local main branch from upstream (error is non-fatal).
+// Git refuses when main is checked out, leaving the user's checkout alone;
+// new tasks then start from the upstream main instead (see branchStart).
+func (m *Manager) updateLocalMain(ctx context.Context, mainBranch string) {
+	if err := m.gitClient.FetchBranch(ctx, m.projectDir, m.UpstreamRemote(), mainBranch); err != nil {
+		logging.Log("Local %s not updated, pull to get the merge: %v", mainBranch, err)
+	}
+}
+
+// removeMergeWorktree removes a temporary merge worktree.
+// It runs even when ctx is cancelled so an aborted merge doesn't leak worktrees.
+func (m *Manager) removeMergeWorktree(ctx context.Context, mergeDir string) {
+	ctx = context.WithoutCancel(ctx)
+	if err := m.gitClient.WorktreeRemove(ctx, m.projectDir, mergeDir, true); err != nil {
+		os.RemoveAll(mergeDir)
+		m.gitClient.WorktreePrune(ctx, m.projectDir)
+	}
+}
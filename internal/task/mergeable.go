@@ -0,0 +1,73 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"context"
+
+	"github.com/donghojung/taw/internal/git"
+	"github.com/donghojung/taw/internal/github"
+	"github.com/donghojung/taw/internal/logging"
+)
+
+// MergeCandidate is a completed task with what to look at before merging it.
+type MergeCandidate struct {
+	Task       *Task
+	Files      int
+	Insertions int
+	Deletions  int
+	Verify     *VerifyResult // Nil if verification didn't run
+	CI         string        // State of the PR's checks (github.Checks*), empty without a PR
+}
+
+// Ready returns true if nothing says the task shouldn't be merged: neither
+// its verification nor its PR's checks failed.
+func (c MergeCandidate) Ready() bool {
+	if c.Verify != nil && !c.Verify.Passed() {
+		return false
+	}
+	return c.CI != github.ChecksFailing
+}
+
+// MergeableTasks returns the tasks whose agent reported done and that have a
+// window. Competing drafts are resolved through pick-draft instead.
+func (m *Manager) MergeableTasks() ([]*Task, error) {
+	tasks, err := m.ListTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	var mergeable []*Task
+	for _, t := range tasks {
+		if t.Status != StatusDone || t.DraftGroup != "" {
+			continue
+		}
+		if _, err := t.LoadWindowID(); err != nil {
+			continue
+		}
+		mergeable = append(mergeable, t)
+	}
+	return mergeable, nil
+}
+
+// MergeCandidates returns tasks with their diff stats against the main
+// branch, last verification and PR checks. What can't be read is left out.
+func (m *Manager) MergeCandidates(ctx context.Context, tasks []*Task) []MergeCandidate {
+	mainBranch := m.MainBranch(ctx)
+
+	candidates := make([]MergeCandidate, 0, len(tasks))
+	for _, t := range tasks {
+		c := MergeCandidate{Task: t, Verify: t.LoadVerifyResult()}
+		if stat, err := m.gitClient.GetBranchDiffStat(ctx, m.projectDir, mainBranch, t.GetBranch()); err == nil {
+			c.Files, c.Insertions, c.Deletions = git.ParseShortStat(stat)
+		}
+		if t.PRNumber > 0 {
+			ci, err := m.ghClient.GetPRChecks(ctx, m.projectDir, t.PRNumber)
+			if err != nil {
+				logging.Debug("Failed to get checks of %s: %v", t.Name, err)
+			}
+			c.CI = ci
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates
+}
@@ -0,0 +1,347 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/donghojung/taw/internal/config"
+	"github.com/donghojung/taw/internal/constants"
+	"github.com/donghojung/taw/internal/logging"
+)
+
+// FileChanges are the files a task changed in a project that isn't a git
+// repository, relative to the project directory.
+type FileChanges struct {
+	Added    []string `json:"added,omitempty"`
+	Modified []string `json:"modified,omitempty"`
+	Deleted  []string `json:"deleted,omitempty"`
+}
+
+// Count returns the number of changed files.
+func (c *FileChanges) Count() int {
+	return len(c.Added) + len(c.Modified) + len(c.Deleted)
+}
+
+// Summary returns e.g. "2 added, 1 modified, 0 deleted".
+func (c *FileChanges) Summary() string {
+	return fmt.Sprintf("%d added, %d modified, %d deleted", len(c.Added), len(c.Modified), len(c.Deleted))
+}
+
+// Lines returns one line per changed file, prefixed with A, M or D.
+func (c *FileChanges) Lines() []string {
+	var lines []string
+	for _, group := range []struct {
+		prefix string
+		paths  []string
+	}{{"A", c.Added}, {"M", c.Modified}, {"D", c.Deleted}} {
+		for _, path := range group.paths {
+			lines = append(lines, group.prefix+" "+path)
+		}
+	}
+	return lines
+}
+
+// SnapshotConflictError is returned when files a task changed in its
+// snapshot were also changed in the project since the task started.
+type SnapshotConflictError struct {
+	Paths []string
+}
+
+func (e *SnapshotConflictError) Error() string {
+	return fmt.Sprintf("changed in the project since the task started: %s", strings.Join(e.Paths, ", "))
+}
+
+// GetSnapshotDir returns the path to the task's copy of the project.
+func (t *Task) GetSnapshotDir() string {
+	return filepath.Join(t.AgentDir, constants.SnapshotDirName)
+}
+
+// GetChecksumsPath returns the path to the checksums of the project files
+// when the task started.
+func (t *Task) GetChecksumsPath() string {
+	return filepath.Join(t.AgentDir, constants.ChecksumsFileName)
+}
+
+// HasSnapshot returns true if the task works in a copy of the project.
+func (t *Task) HasSnapshot() bool {
+	info, err := os.Stat(t.GetSnapshotDir())
+	return err == nil && info.IsDir()
+}
+
+// noGitIgnore returns the names left out of snapshots and change summaries.
+func (m *Manager) noGitIgnore() []string {
+	ignore := []string{constants.TawDirName}
+	if m.config != nil {
+		ignore = append(ignore, m.config.NoGit.Ignore...)
+	}
+	return ignore
+}
+
+// SetupNoGit prepares a task in a project that isn't a git repository: it
+// records the checksums of the project files to summarize the task's changes
+// with, and in snapshot mode copies the project for the agent to work in.
+// A task that was set up before is left as it is.
+func (m *Manager) SetupNoGit(task *Task) error {
+	if m.isGitRepo {
+		return nil
+	}
+	if _, err := os.Stat(task.GetChecksumsPath()); err == nil {
+		return nil
+	}
+
+	ignore := m.noGitIgnore()
+	sums, err := checksumTree(m.projectDir, ignore)
+	if err != nil {
+		return fmt.Errorf("failed to checksum project files: %w", err)
+	}
+
+	if m.config != nil && m.config.NoGit.Mode == config.NoGitModeSnapshot {
+		snapshotDir := task.GetSnapshotDir()
+		if err := copyTree(m.projectDir, snapshotDir, ignore); err != nil {
+			os.RemoveAll(snapshotDir)
+			return fmt.Errorf("failed to copy project to snapshot: %w", err)
+		}
+	}
+
+	data, err := json.MarshalIndent(sums, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(task.GetChecksumsPath(), data, 0644)
+}
+
+// loadChecksums returns the checksums recorded when the task started.
+func (t *Task) loadChecksums() (map[string]string, error) {
+	data, err := os.ReadFile(t.GetChecksumsPath())
+	if err != nil {
+		return nil, err
+	}
+	var sums map[string]string
+	if err := json.Unmarshal(data, &sums); err != nil {
+		return nil, fmt.Errorf("invalid checksums: %w", err)
+	}
+	return sums, nil
+}
+
+// NoGitChanges returns the files the task changed since it started, by
+// comparing checksums of its working directory with the recorded ones.
+func (m *Manager) NoGitChanges(task *Task) (*FileChanges, error) {
+	before, err := task.loadChecksums()
+	if err != nil {
+		return nil, fmt.Errorf("no checksums recorded for %s: %w", task.Name, err)
+	}
+	after, err := checksumTree(m.GetWorkingDirectory(task), m.noGitIgnore())
+	if err != nil {
+		return nil, fmt.Errorf("failed to checksum files: %w", err)
+	}
+
+	changes := &FileChanges{}
+	for path, sum := range after {
+		old, ok := before[path]
+		switch {
+		case !ok:
+			changes.Added = append(changes.Added, path)
+		case old != sum:
+			changes.Modified = append(changes.Modified, path)
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changes.Deleted = append(changes.Deleted, path)
+		}
+	}
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Modified)
+	sort.Strings(changes.Deleted)
+	return changes, nil
+}
+
+// ApplySnapshot copies the changes of a task's snapshot into the project.
+// Nothing is applied if a changed file was also changed in the project since
+// the task started; that returns a *SnapshotConflictError and takes the
+// project's current files as the new baseline, so applying again after
+// merging them into the snapshot goes through.
+func (m *Manager) ApplySnapshot(task *Task, changes *FileChanges) error {
+	if !task.HasSnapshot() {
+		return nil
+	}
+
+	before, err := task.loadChecksums()
+	if err != nil {
+		return err
+	}
+
+	// The project's copy of each changed file must be as the task found it
+	var conflicts []string
+	for _, path := range append(append(append([]string{}, changes.Added...), changes.Modified...), changes.Deleted...) {
+		current, err := checksumPath(filepath.Join(m.projectDir, path))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if current != before[path] {
+			conflicts = append(conflicts, path)
+			if current == "" {
+				delete(before, path)
+			} else {
+				before[path] = current
+			}
+		}
+	}
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		if data, err := json.MarshalIndent(before, "", "  "); err == nil {
+			if err := os.WriteFile(task.GetChecksumsPath(), data, 0644); err != nil {
+				logging.Debug("Failed to update checksums: %v", err)
+			}
+		}
+		return &SnapshotConflictError{Paths: conflicts}
+	}
+
+	snapshotDir := task.GetSnapshotDir()
+	for _, path := range append(append([]string{}, changes.Added...), changes.Modified...) {
+		dst := filepath.Join(m.projectDir, path)
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		if err := copyEntry(filepath.Join(snapshotDir, path), dst); err != nil {
+			return fmt.Errorf("failed to apply %s: %w", path, err)
+		}
+	}
+	for _, path := range changes.Deleted {
+		if err := os.Remove(filepath.Join(m.projectDir, path)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// RecordChanges archives the files a task changed (error is non-fatal).
+func (m *Manager) RecordChanges(task *Task, changes *FileChanges) {
+	if err := m.Archive().Record(task.Name, func(e *ArchiveEntry) {
+		e.Changes = changes
+		e.Files = changes.Count()
+	}); err != nil {
+		logging.Debug("Failed to archive changes: %v", err)
+	}
+}
+
+// walkTree calls fn for the files and symlinks under dir, skipping entries
+// with an ignored name.
+func walkTree(dir string, ignore []string, fn func(rel string, d fs.DirEntry) error) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		if hasName(ignore, d.Name()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		return fn(filepath.ToSlash(rel), d)
+	})
+}
+
+// hasName returns true if names contains name.
+func hasName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// checksumTree returns the checksums of the files under dir by relative path.
+func checksumTree(dir string, ignore []string) (map[string]string, error) {
+	sums := make(map[string]string)
+	err := walkTree(dir, ignore, func(rel string, d fs.DirEntry) error {
+		sum, err := checksumPath(filepath.Join(dir, rel))
+		if err != nil {
+			return err
+		}
+		sums[rel] = sum
+		return nil
+	})
+	return sums, err
+}
+
+// checksumPath returns the SHA-256 of a file, or of a symlink's target path.
+func checksumPath(path string) (string, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return "", err
+		}
+		io.WriteString(h, "symlink:"+target)
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		if _, err := io.Copy(h, f); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// copyTree copies the files and symlinks under src to dst.
+func copyTree(src, dst string, ignore []string) error {
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return err
+	}
+	return walkTree(src, ignore, func(rel string, d fs.DirEntry) error {
+		target := filepath.Join(dst, rel)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		return copyEntry(filepath.Join(src, rel), target)
+	})
+}
+
+// copyEntry copies a file, or recreates a symlink.
+func copyEntry(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		return copyFile(src, dst)
+	}
+
+	target, err := os.Readlink(src)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Symlink(target, dst)
+}
@@ -0,0 +1,363 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/donghojung/taw/internal/config"
+	"github.com/donghojung/taw/internal/constants"
+)
+
+// OutboxActionKind identifies a pending remote action.
+type OutboxActionKind string
+
+const (
+	OutboxPush     OutboxActionKind = "push"      // Push the task branch
+	OutboxCreatePR OutboxActionKind = "create-pr" // Create the task's pull request
+	OutboxMerge    OutboxActionKind = "merge"     // Merge the task into main
+	OutboxWebhook  OutboxActionKind = "webhook"   // Send a task event to a webhook
+	OutboxTicket   OutboxActionKind = "ticket"    // Move the ticket of a task
+	OutboxSlack    OutboxActionKind = "slack"     // Tell a Slack channel how its task went
+	OutboxReview   OutboxActionKind = "review"    // Resolve a review thread a task addressed
+)
+
+// OutboxAction is a remote action persisted until it succeeds.
+type OutboxAction struct {
+	Kind        OutboxActionKind `json:"kind"`
+	TaskName    string           `json:"task_name"`
+	Attempts    int              `json:"attempts"`
+	LastError   string           `json:"last_error,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	NextAttempt time.Time        `json:"next_attempt"`
+
+	// The webhook and the event of a webhook action
+	Webhook string          `json:"webhook,omitempty"`
+	Event   json.RawMessage `json:"event,omitempty"`
+
+	// The ticket change of a ticket action
+	Ticket *TicketSync `json:"ticket,omitempty"`
+
+	// The message of a Slack action
+	Slack *SlackMessage `json:"slack,omitempty"`
+
+	// The review thread of a review action
+	Review *ReviewResolve `json:"review,omitempty"`
+
+	Path string `json:"-"`
+}
+
+// GaveUp returns true if the action exhausted its retries.
+func (a *OutboxAction) GaveUp() bool {
+	return a.Attempts >= constants.OutboxMaxAttempts
+}
+
+// Outbox persists pending remote actions in .taw/outbox/ so transient
+// network failures are retried instead of silently dropped.
+type Outbox struct {
+	dir  string
+	lock *ProjectLock
+}
+
+// NewOutbox creates a new outbox for the given directory.
+func NewOutbox(dir string) *Outbox {
+	return &Outbox{
+		dir: dir,
+	}
+}
+
+// Add enqueues an action for a task. An existing action of the same kind
+// for the same task is kept as is.
+func (o *Outbox) Add(kind OutboxActionKind, taskName string) error {
+	if err := os.MkdirAll(o.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create outbox directory: %w", err)
+	}
+
+	path := filepath.Join(o.dir, fmt.Sprintf("%s-%s.json", kind, taskName))
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	now := time.Now()
+	return o.save(&OutboxAction{
+		Kind:        kind,
+		TaskName:    taskName,
+		CreatedAt:   now,
+		NextAttempt: now,
+		Path:        path,
+	})
+}
+
+// AddWebhook enqueues the delivery of a task event to a webhook.
+func (o *Outbox) AddWebhook(hook string, event *Event) error {
+	if err := os.MkdirAll(o.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create outbox directory: %w", err)
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	now := time.Now()
+	return o.save(&OutboxAction{
+		Kind:        OutboxWebhook,
+		TaskName:    event.Task,
+		CreatedAt:   now,
+		NextAttempt: now,
+		Webhook:     hook,
+		Event:       data,
+		Path:        filepath.Join(o.dir, fmt.Sprintf("%s-%s-%s.json", OutboxWebhook, hook, event.ID)),
+	})
+}
+
+// AddTicket enqueues a change of the ticket of a task.
+func (o *Outbox) AddTicket(taskName string, sync *TicketSync) error {
+	if err := os.MkdirAll(o.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create outbox directory: %w", err)
+	}
+
+	now := time.Now()
+	return o.save(&OutboxAction{
+		Kind:        OutboxTicket,
+		TaskName:    taskName,
+		CreatedAt:   now,
+		NextAttempt: now,
+		Ticket:      sync,
+		Path:        filepath.Join(o.dir, fmt.Sprintf("%s-%s-%s.json", OutboxTicket, taskName, sync.State)),
+	})
+}
+
+// AddSlack enqueues a message about a task to the Slack channel it was
+// requested in.
+func (o *Outbox) AddSlack(taskName string, event config.WebhookEvent, msg *SlackMessage) error {
+	if err := os.MkdirAll(o.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create outbox directory: %w", err)
+	}
+
+	now := time.Now()
+	return o.save(&OutboxAction{
+		Kind:        OutboxSlack,
+		TaskName:    taskName,
+		CreatedAt:   now,
+		NextAttempt: now,
+		Slack:       msg,
+		Path:        filepath.Join(o.dir, fmt.Sprintf("%s-%s-%s.json", OutboxSlack, taskName, event)),
+	})
+}
+
+// AddReview enqueues resolving a review thread a task addressed.
+func (o *Outbox) AddReview(taskName string, r *ReviewResolve) error {
+	if err := os.MkdirAll(o.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create outbox directory: %w", err)
+	}
+
+	now := time.Now()
+	return o.save(&OutboxAction{
+		Kind:        OutboxReview,
+		TaskName:    taskName,
+		CreatedAt:   now,
+		NextAttempt: now,
+		Review:      r,
+		Path:        filepath.Join(o.dir, fmt.Sprintf("%s-%s-%s.json", OutboxReview, taskName, r.Thread)),
+	})
+}
+
+// List returns all pending actions, oldest first.
+func (o *Outbox) List() ([]OutboxAction, error) {
+	entries, err := os.ReadDir(o.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read outbox directory: %w", err)
+	}
+
+	var actions []OutboxAction
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		path := filepath.Join(o.dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		var action OutboxAction
+		if err := json.Unmarshal(data, &action); err != nil {
+			continue
+		}
+		action.Path = path
+		actions = append(actions, action)
+	}
+
+	sort.Slice(actions, func(i, j int) bool {
+		return actions[i].CreatedAt.Before(actions[j].CreatedAt)
+	})
+
+	return actions, nil
+}
+
+// Remove deletes a completed action.
+func (o *Outbox) Remove(action *OutboxAction) error {
+	if err := os.Remove(action.Path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove outbox action: %w", err)
+	}
+	return nil
+}
+
+// RecordFailure increments the attempt count and schedules the next retry.
+func (o *Outbox) RecordFailure(action *OutboxAction, actionErr error) error {
+	action.Attempts++
+	action.LastError = actionErr.Error()
+	action.NextAttempt = time.Now().Add(OutboxBackoff(action.Attempts))
+	return o.save(action)
+}
+
+// Lock acquires the outbox processor lock without waiting. It returns true
+// if acquired, false if another processor holds it. The lock is a flock, so
+// it is released when its processor dies.
+func (o *Outbox) Lock() (bool, error) {
+	lock := NewProjectLock(filepath.Join(o.dir, constants.OutboxLockFileName))
+	if err := lock.Lock("outbox processor", 0); err != nil {
+		if errors.Is(err, ErrProjectLocked) {
+			return false, nil
+		}
+		return false, err
+	}
+	o.lock = lock
+	return true, nil
+}
+
+// Unlock releases the outbox processor lock.
+func (o *Outbox) Unlock() error {
+	if o.lock == nil {
+		return nil
+	}
+	err := o.lock.Unlock()
+	o.lock = nil
+	return err
+}
+
+// save writes an action to its file.
+func (o *Outbox) save(action *OutboxAction) error {
+	data, err := json.MarshalIndent(action, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode outbox action: %w", err)
+	}
+	if err := os.WriteFile(action.Path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write outbox action: %w", err)
+	}
+	return nil
+}
+
+// OutboxBackoff returns the retry delay after the given number of attempts.
+func OutboxBackoff(attempts int) time.Duration {
+	delay := constants.OutboxBaseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= constants.OutboxMaxBackoff {
+			return constants.OutboxMaxBackoff
+		}
+	}
+	return delay
+}
+
+// ProcessOutbox runs every due action once. It returns the number of actions
+// still worth retrying and how long until the earliest of them is due.
+func (m *Manager) ProcessOutbox(ctx context.Context, o *Outbox) (int, time.Duration, error) {
+	actions, err := o.List()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	pending := 0
+	var wait time.Duration
+	schedule := func(a *OutboxAction) {
+		d := time.Until(a.NextAttempt)
+		if pending == 0 || d < wait {
+			wait = d
+		}
+		pending++
+	}
+
+	for i := range actions {
+		action := &actions[i]
+		if action.GaveUp() {
+			// Left in place so it stays visible in 'taw status'
+			continue
+		}
+
+		if time.Now().Before(action.NextAttempt) {
+			schedule(action)
+			continue
+		}
+
+		if err := m.runOutboxAction(ctx, action); err != nil {
+			if recordErr := o.RecordFailure(action, err); recordErr != nil {
+				return pending, wait, recordErr
+			}
+			if !action.GaveUp() {
+				schedule(action)
+			}
+			continue
+		}
+
+		if err := o.Remove(action); err != nil {
+			return pending, wait, err
+		}
+	}
+
+	if wait < 0 {
+		wait = 0
+	}
+	return pending, wait, nil
+}
+
+// runOutboxAction executes a single outbox action.
+func (m *Manager) runOutboxAction(ctx context.Context, action *OutboxAction) error {
+	// Events, ticket changes and review threads outlive their task
+	switch action.Kind {
+	case OutboxWebhook:
+		return m.deliverWebhook(ctx, action)
+	case OutboxTicket:
+		return m.syncTicket(ctx, action.Ticket)
+	case OutboxSlack:
+		return m.postSlack(ctx, action.Slack)
+	case OutboxReview:
+		return m.resolveReview(ctx, action.TaskName, action.Review)
+	}
+
+	task, err := m.GetTask(action.TaskName)
+	if err != nil {
+		// Task was cleaned up in the meantime - nothing left to do
+		return nil
+	}
+
+	// Retried later while a client is ending the task
+	unlock, err := m.LockTask(task, "outbox "+string(action.Kind))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	switch action.Kind {
+	case OutboxPush:
+		return m.PushTask(ctx, task)
+	case OutboxCreatePR:
+		_, err := m.ensurePR(ctx, task, m.MainBranch(ctx))
+		return err
+	case OutboxMerge:
+		return m.MergeToMain(ctx, task)
+	default:
+		return fmt.Errorf("unknown outbox action: %s", action.Kind)
+	}
+}
@@ -0,0 +1,75 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/donghojung/taw/internal/constants"
+)
+
+// CurrentUser returns who is creating tasks: the git user.name of the
+// project, or $USER. It returns "" if neither is set.
+func (m *Manager) CurrentUser(ctx context.Context) string {
+	if name, err := m.gitClient.GetUserName(ctx, m.projectDir); err == nil && name != "" {
+		return name
+	}
+	return os.Getenv("USER")
+}
+
+// SaveOwner records the user who created the task.
+func (t *Task) SaveOwner(owner string) error {
+	t.Owner = owner
+	if owner == "" {
+		return nil
+	}
+	return os.WriteFile(filepath.Join(t.AgentDir, constants.OwnerFileName), []byte(owner), 0644)
+}
+
+// LoadOwner loads the user who created the task, or "" for tasks created
+// before owners were recorded.
+func (t *Task) LoadOwner() string {
+	data, err := os.ReadFile(filepath.Join(t.AgentDir, constants.OwnerFileName))
+	if err != nil {
+		return ""
+	}
+	t.Owner = strings.TrimSpace(string(data))
+	return t.Owner
+}
+
+// OwnedBy returns true if owner matches the owner filter, ignoring case. An
+// empty filter matches everyone.
+func OwnedBy(owner, filter string) bool {
+	return filter == "" || strings.EqualFold(owner, filter)
+}
+
+// FilterOwner returns the tasks created by the given user.
+func FilterOwner(tasks []*Task, owner string) []*Task {
+	if owner == "" {
+		return tasks
+	}
+	var owned []*Task
+	for _, t := range tasks {
+		if OwnedBy(t.Owner, owner) {
+			owned = append(owned, t)
+		}
+	}
+	return owned
+}
+
+// FilterArchiveOwner returns the archive entries of tasks created by the
+// given user.
+func FilterArchiveOwner(entries []ArchiveEntry, owner string) []ArchiveEntry {
+	if owner == "" {
+		return entries
+	}
+	var owned []ArchiveEntry
+	for _, e := range entries {
+		if OwnedBy(e.Owner, owner) {
+			owned = append(owned, e)
+		}
+	}
+	return owned
+}
@@ -0,0 +1,107 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/donghojung/taw/internal/config"
+)
+
+// parkCommitMessage is the message of the commit of a parked task's work;
+// %s is the task name.
+const parkCommitMessage = "chore: park work in progress on %s"
+
+// ParkOnQuit returns what quitting the session does with the tasks in
+// progress (park_on_quit).
+func (m *Manager) ParkOnQuit() config.ParkOnQuit {
+	if m.config == nil || m.config.ParkOnQuit == "" {
+		return config.ParkOnQuitAsk
+	}
+	return m.config.ParkOnQuit
+}
+
+// ParkableTasks returns the tasks still in progress that can be parked:
+// tasks with their own branch that aren't done, parked, broken, competing
+// drafts or read-only.
+func (m *Manager) ParkableTasks() ([]*Task, error) {
+	if !m.isGitRepo || m.config == nil || m.config.WorkMode != config.WorkModeWorktree {
+		return nil, nil
+	}
+
+	tasks, err := m.ListTasks()
+	if err != nil {
+		return nil, err
+	}
+	var parkable []*Task
+	for _, t := range tasks {
+		switch t.Status {
+		case StatusWorking, StatusWaiting, StatusPushFailed:
+		default:
+			continue
+		}
+		if t.DraftGroup != "" || !m.UsesWorktree(t) {
+			continue
+		}
+		parkable = append(parkable, t)
+	}
+	return parkable, nil
+}
+
+// ParkTask backs a task in progress up and shows it to the team: it commits
+// the work in its worktree, pushes its branch and opens a draft pull request
+// unless it has a pull request, then marks it parked. The agent may go on
+// working; ending the task marks the draft ready for review.
+func (m *Manager) ParkTask(ctx context.Context, task *Task) (int, error) {
+	if !m.HasPushRemote(ctx) {
+		return 0, errors.New("no remote to push to")
+	}
+
+	if _, err := m.commitAll(ctx, task, fmt.Sprintf(parkCommitMessage, task.Name)); err != nil {
+		return 0, err
+	}
+	if err := m.PushTask(ctx, task); err != nil {
+		return 0, err
+	}
+
+	prNumber, err := task.LoadPRNumber()
+	if err != nil || prNumber == 0 {
+		prNumber, err = m.openDraftPR(ctx, task)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := task.SaveStatus(StatusParked); err != nil {
+		return prNumber, fmt.Errorf("failed to save status: %w", err)
+	}
+	return prNumber, nil
+}
+
+// openDraftPR opens a draft pull request for a parked task against the main
+// branch.
+func (m *Manager) openDraftPR(ctx context.Context, task *Task) (int, error) {
+	body := strings.TrimRight(task.Content, "\n") + "\n\n_Work in progress, parked by TAW._"
+	prNumber, err := m.ghClient.CreateDraftPR(ctx, m.GetWorkingDirectory(task), task.Name, body, m.MainBranch(ctx), m.prHead(ctx, task))
+	if err != nil {
+		return 0, err
+	}
+
+	if err := task.SavePRNumber(prNumber); err != nil {
+		return prNumber, fmt.Errorf("failed to save PR number: %w", err)
+	}
+	if err := task.MarkParked(); err != nil {
+		return prNumber, fmt.Errorf("failed to mark task parked: %w", err)
+	}
+
+	// Keep the PR for later reporting (error is non-fatal)
+	if err := m.Archive().Record(task.Name, func(e *ArchiveEntry) {
+		e.Content = task.Content
+		e.PRNumber = prNumber
+	}); err != nil {
+		// Archive is informational only
+	}
+	return prNumber, nil
+}
@@ -0,0 +1,81 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/donghojung/taw/internal/config"
+)
+
+// defaultCommitMessage is the message of the commit of the changes an agent
+// left, without the commit.message option.
+const defaultCommitMessage = "chore: auto-commit on task end"
+
+// EndSteps returns the steps that end a task: those of its on_complete mode
+// (see OnComplete), with merge added if merge is set, as by 'taw merge'.
+// Read-only tasks (isolation: none) have nothing to land and only notify.
+func (m *Manager) EndSteps(task *Task, merge bool) []config.PipelineAction {
+	mode := m.OnComplete(task)
+	steps := config.OnCompleteSteps(mode)
+	if m.config != nil {
+		steps = m.config.EndSteps(mode)
+	}
+	if task.Isolation() == config.IsolationNone {
+		if config.HasAction(steps, config.ActionNotify) {
+			return []config.PipelineAction{config.ActionNotify}
+		}
+		return nil
+	}
+	if merge && !config.HasAction(steps, config.ActionMerge) {
+		steps = append(steps[:len(steps):len(steps)], config.ActionMerge)
+	}
+	return steps
+}
+
+// PipelineOption returns an option of a step with {task} replaced by the
+// task name, or "" if it isn't set.
+func (m *Manager) PipelineOption(task *Task, action config.PipelineAction, name string) string {
+	if m.config == nil {
+		return ""
+	}
+	return strings.ReplaceAll(m.config.Pipeline.Option(action, name), "{task}", task.Name)
+}
+
+// CommitChanges commits the changes the agent left in the task's worktree,
+// with the commit.message option and the diff stat. It returns false if
+// there was nothing to commit.
+func (m *Manager) CommitChanges(ctx context.Context, task *Task) (bool, error) {
+	message := m.PipelineOption(task, config.ActionCommit, "message")
+	if message == "" {
+		message = defaultCommitMessage
+	}
+	return m.commitAll(ctx, task, message)
+}
+
+// commitAll commits all changes in the task's worktree with message and the
+// diff stat. It returns false if there was nothing to commit.
+func (m *Manager) commitAll(ctx context.Context, task *Task, message string) (bool, error) {
+	workDir := m.GetWorkingDirectory(task)
+	if !m.gitClient.HasChanges(ctx, workDir) {
+		return false, nil
+	}
+	if err := m.gitClient.AddAll(ctx, workDir); err != nil {
+		return false, fmt.Errorf("failed to add changes: %w", err)
+	}
+
+	if diffStat, _ := m.gitClient.GetDiffStat(ctx, workDir); diffStat != "" {
+		message += "\n\n" + diffStat
+	}
+	if err := m.gitClient.Commit(ctx, workDir, message); err != nil {
+		return false, fmt.Errorf("failed to commit: %w", err)
+	}
+	return true, nil
+}
+
+// OpenPR opens a pull request for the task against the main branch unless it
+// has one, and returns its number.
+func (m *Manager) OpenPR(ctx context.Context, task *Task) (int, error) {
+	return m.ensurePR(ctx, task, m.MainBranch(ctx))
+}
@@ -0,0 +1,108 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/donghojung/taw/internal/constants"
+	"github.com/donghojung/taw/internal/github"
+)
+
+// PRCommentsInterval returns how often the pull requests of open tasks are
+// checked for new comments, or 0 if they aren't.
+func (m *Manager) PRCommentsInterval() time.Duration {
+	if m.config == nil {
+		return 0
+	}
+	return m.config.PRComments.Interval
+}
+
+// ForwardPRComments returns true if new comments are typed into the agent's
+// pane rather than setting the task to waiting.
+func (m *Manager) ForwardPRComments() bool {
+	return m.config != nil && m.config.PRComments.Forward
+}
+
+// GetPRCommentsPath returns the path to the time of the newest comment on
+// the task's pull request that was checked.
+func (t *Task) GetPRCommentsPath() string {
+	return filepath.Join(t.AgentDir, constants.PRCommentsFileName)
+}
+
+// loadPRCommentsSeen returns the time of the newest comment checked, and
+// false if the pull request wasn't checked yet.
+func (t *Task) loadPRCommentsSeen() (time.Time, bool) {
+	data, err := os.ReadFile(t.GetPRCommentsPath())
+	if err != nil {
+		return time.Time{}, false
+	}
+	seen, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(string(data)))
+	return seen, err == nil
+}
+
+// NewPRComments returns the comments of others on the task's pull request
+// since the last check, oldest first. The first check only notes the newest
+// comment: a pull request the task took over, e.g. to address its review,
+// already has comments.
+func (m *Manager) NewPRComments(ctx context.Context, task *Task) ([]github.PRComment, error) {
+	if task.PRNumber <= 0 {
+		return nil, nil
+	}
+	comments, err := m.ghClient.ListPRComments(ctx, m.projectDir, task.PRNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	seen, checked := task.loadPRCommentsSeen()
+	newest := seen
+	var fresh []github.PRComment
+	for _, c := range comments {
+		if !c.CreatedAt.After(seen) {
+			continue
+		}
+		if checked {
+			fresh = append(fresh, c)
+		}
+		if c.CreatedAt.After(newest) {
+			newest = c.CreatedAt
+		}
+	}
+
+	if !checked || newest.After(seen) {
+		if err := os.WriteFile(task.GetPRCommentsPath(), []byte(newest.Format(time.RFC3339Nano)), 0644); err != nil {
+			return nil, fmt.Errorf("failed to save checked comments: %w", err)
+		}
+	}
+	return fresh, nil
+}
+
+// PRCommentsInstruction composes the follow-up instruction forwarding new
+// comments on a pull request to its task's agent.
+func PRCommentsInstruction(prNumber int, comments []github.PRComment) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "New comments on your pull request #%d. Address them, commit and push your changes, or say why not:\n", prNumber)
+	for _, c := range comments {
+		sb.WriteString("\n")
+		switch {
+		case c.Review == "CHANGES_REQUESTED":
+			fmt.Fprintf(&sb, "%s requested changes (%s):\n", c.Author, c.URL)
+		case c.Review == "APPROVED":
+			fmt.Fprintf(&sb, "%s approved (%s):\n", c.Author, c.URL)
+		case c.Path != "":
+			fmt.Fprintf(&sb, "%s wrote on %s (%s):\n", c.Author, c.Location(), c.URL)
+		default:
+			fmt.Fprintf(&sb, "%s wrote (%s):\n", c.Author, c.URL)
+		}
+		if body := strings.TrimSpace(c.Body); body != "" {
+			for _, line := range strings.Split(body, "\n") {
+				sb.WriteString(strings.TrimRight("> "+line, " ") + "\n")
+			}
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,91 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/donghojung/taw/internal/config"
+	"github.com/donghojung/taw/internal/git"
+	"github.com/donghojung/taw/internal/github"
+)
+
+// PreflightError describes a failed remote access check.
+type PreflightError struct {
+	Check string // "git" or "gh"
+	Err   error
+	Hint  string // Actionable message for the user
+}
+
+func (e *PreflightError) Error() string {
+	return fmt.Sprintf("%s preflight failed: %v", e.Check, e.Err)
+}
+
+func (e *PreflightError) Unwrap() error {
+	return e.Err
+}
+
+// Preflight verifies that the push remote accepts pushes and, when the
+// completion flow needs GitHub, that gh is installed and authenticated.
+// Returns nil for repos without a push remote since there is nothing to check.
+func (m *Manager) Preflight(ctx context.Context) error {
+	if !m.isGitRepo || !m.HasPushRemote(ctx) {
+		return nil
+	}
+
+	if err := m.gitClient.CanPush(ctx, m.projectDir, m.PushRemote()); err != nil {
+		hint := git.ErrorHint(err)
+		if hint == "" {
+			hint = git.PushErrorHint(git.ClassifyPushError(err))
+		}
+		return &PreflightError{
+			Check: "git",
+			Err:   err,
+			Hint:  hint,
+		}
+	}
+
+	if !m.needsGitHub() {
+		return nil
+	}
+
+	if !m.ghClient.IsInstalled() {
+		return &PreflightError{
+			Check: "gh",
+			Err:   github.ErrNotInstalled,
+			Hint:  github.ErrorHint(github.ErrNotInstalled),
+		}
+	}
+
+	if err := m.ghClient.AuthStatus(ctx); err != nil {
+		return &PreflightError{
+			Check: "gh",
+			Err:   err,
+			Hint:  github.ErrorHint(err),
+		}
+	}
+
+	return nil
+}
+
+// HasPushRemote returns true if the configured push remote exists.
+func (m *Manager) HasPushRemote(ctx context.Context) bool {
+	_, err := m.gitClient.GetRemoteURL(ctx, m.projectDir, m.PushRemote())
+	return err == nil
+}
+
+// needsGitHub returns true if completing a task involves GitHub pull requests.
+func (m *Manager) needsGitHub() bool {
+	if m.config == nil {
+		return false
+	}
+	// With auto-pr, the agent opens the pull request
+	if m.config.OnComplete == config.OnCompleteAutoPR {
+		return true
+	}
+	steps := m.config.EndSteps(m.config.OnComplete)
+	if config.HasAction(steps, config.ActionPR) {
+		return true
+	}
+	return config.HasAction(steps, config.ActionMerge) && m.MergeStrategy().UsesPullRequest()
+}
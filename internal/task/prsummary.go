@@ -0,0 +1,188 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/donghojung/taw/internal/constants"
+	"github.com/donghojung/taw/internal/git"
+)
+
+// maxSummaryPackages limits the packages listed in a PR summary.
+const maxSummaryPackages = 10
+
+// coveragePattern matches a percentage such as "63.2%".
+var coveragePattern = regexp.MustCompile(`([0-9]+(?:\.[0-9]+)?)%`)
+
+// PRSummary describes a task's changes for its pull request body.
+type PRSummary struct {
+	Base           string   `json:"base"`
+	Files          int      `json:"files"`
+	Insertions     int      `json:"insertions"`
+	Deletions      int      `json:"deletions"`
+	Packages       []string `json:"packages,omitempty"`
+	HasCoverage    bool     `json:"has_coverage,omitempty"`
+	CoverageBefore float64  `json:"coverage_before,omitempty"`
+	CoverageAfter  float64  `json:"coverage_after,omitempty"`
+	Verify         string   `json:"verify,omitempty"`
+}
+
+// Markdown renders the summary as a table for the PR body.
+func (s *PRSummary) Markdown() string {
+	var sb strings.Builder
+
+	sb.WriteString("## Summary\n\n")
+	sb.WriteString("| | |\n|---|---|\n")
+	fmt.Fprintf(&sb, "| Diff | %d files changed, +%d / -%d |\n", s.Files, s.Insertions, s.Deletions)
+
+	if len(s.Packages) > 0 {
+		shown := s.Packages
+		if len(shown) > maxSummaryPackages {
+			shown = shown[:maxSummaryPackages]
+		}
+		pkgs := "`" + strings.Join(shown, "`, `") + "`"
+		if more := len(s.Packages) - len(shown); more > 0 {
+			pkgs += fmt.Sprintf(" and %d more", more)
+		}
+		fmt.Fprintf(&sb, "| Packages | %s |\n", pkgs)
+	}
+
+	if s.HasCoverage {
+		fmt.Fprintf(&sb, "| Coverage | %.1f%% → %.1f%% (%+.1f) |\n",
+			s.CoverageBefore, s.CoverageAfter, s.CoverageAfter-s.CoverageBefore)
+	}
+
+	if s.Verify != "" {
+		fmt.Fprintf(&sb, "| Verification | %s |\n", s.Verify)
+	}
+
+	return sb.String()
+}
+
+// BuildPRSummary computes the diff stat, changed packages and, if a coverage
+// command is configured, the coverage delta of a task against base.
+func (m *Manager) BuildPRSummary(ctx context.Context, task *Task, base string) (*PRSummary, error) {
+	workDir := m.GetWorkingDirectory(task)
+
+	baseRef := m.UpstreamRemote() + "/" + base
+	stat, err := m.gitClient.GetBranchDiffStat(ctx, workDir, baseRef, "HEAD")
+	if err != nil {
+		// No remote-tracking branch - compare against the local one
+		baseRef = base
+		if stat, err = m.gitClient.GetBranchDiffStat(ctx, workDir, baseRef, "HEAD"); err != nil {
+			return nil, fmt.Errorf("failed to get diff stat: %w", err)
+		}
+	}
+
+	summary := &PRSummary{Base: base}
+	summary.Files, summary.Insertions, summary.Deletions = git.ParseShortStat(stat)
+
+	files, err := m.gitClient.GetChangedFiles(ctx, workDir, baseRef, "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changed files: %w", err)
+	}
+	summary.Packages = changedPackages(files)
+
+	if result := task.LoadVerifyResult(); result != nil {
+		summary.Verify = result.Summary()
+	}
+
+	if m.config != nil && m.config.PRSummary.CoverageCommand != "" {
+		before, after, err := m.coverageDelta(ctx, workDir, baseRef)
+		if err == nil {
+			summary.HasCoverage = true
+			summary.CoverageBefore = before
+			summary.CoverageAfter = after
+		}
+	}
+
+	return summary, nil
+}
+
+// prSummary returns the summary for a task's PR, or nil if disabled or unavailable.
+func (m *Manager) prSummary(ctx context.Context, task *Task, base string) *PRSummary {
+	if m.config == nil || !m.config.PRSummary.Enabled || !m.isGitRepo {
+		return nil
+	}
+
+	summary, err := m.BuildPRSummary(ctx, task, base)
+	if err != nil {
+		// The PR is still useful without a summary
+		return nil
+	}
+	return summary
+}
+
+// coverageDelta runs the coverage command on base (in a temporary worktree)
+// and on the task's working directory.
+func (m *Manager) coverageDelta(ctx context.Context, workDir, baseRef string) (float64, float64, error) {
+	after, err := m.runCoverage(ctx, workDir)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	baseDir, err := os.MkdirTemp(m.tawDir, "coverage-")
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to create coverage directory: %w", err)
+	}
+	defer m.removeMergeWorktree(ctx, baseDir)
+
+	if err := m.gitClient.WorktreeAddDetached(ctx, m.projectDir, baseDir, baseRef); err != nil {
+		return 0, 0, fmt.Errorf("failed to create coverage worktree: %w", err)
+	}
+
+	before, err := m.runCoverage(ctx, baseDir)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return before, after, nil
+}
+
+// runCoverage runs the coverage command in dir and parses the last percentage
+// in its output.
+func (m *Manager) runCoverage(ctx context.Context, dir string) (float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, constants.DefaultVerifyTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", m.config.PRSummary.CoverageCommand)
+	cmd.Dir = dir
+
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("coverage command failed: %w", err)
+	}
+
+	matches := coveragePattern.FindAllStringSubmatch(string(out), -1)
+	if len(matches) == 0 {
+		return 0, fmt.Errorf("no coverage percentage in output")
+	}
+
+	return strconv.ParseFloat(matches[len(matches)-1][1], 64)
+}
+
+// changedPackages returns the sorted, unique directories of changed files.
+func changedPackages(files []string) []string {
+	seen := make(map[string]bool)
+	var pkgs []string
+	for _, file := range files {
+		dir := filepath.Dir(file)
+		if dir == "." {
+			dir = "(root)"
+		}
+		if !seen[dir] {
+			seen[dir] = true
+			pkgs = append(pkgs, dir)
+		}
+	}
+	sort.Strings(pkgs)
+	return pkgs
+}
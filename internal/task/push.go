@@ -0,0 +1,80 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/donghojung/taw/internal/git"
+)
+
+// PushError is returned by PushTask when the task branch could not be pushed.
+type PushError struct {
+	Kind git.PushErrorKind
+	Err  error
+}
+
+func (e *PushError) Error() string {
+	return fmt.Sprintf("push failed (%s): %v", e.Kind, e.Err)
+}
+
+func (e *PushError) Unwrap() error {
+	return e.Err
+}
+
+// Hint returns a user-facing remediation hint for the failure.
+func (e *PushError) Hint() string {
+	return git.PushErrorHint(e.Kind)
+}
+
+// PushTask pushes the task branch to the push remote. A non-fast-forward rejection is
+// retried once after rebasing onto the remote branch. On failure the task is
+// marked push-failed so it stays visible instead of being cleaned up.
+func (m *Manager) PushTask(ctx context.Context, task *Task) error {
+	// No remote configured (local-only repo) - nothing to push
+	if !m.HasPushRemote(ctx) {
+		return nil
+	}
+
+	entry := m.beginJournal(JournalPush, task)
+	defer m.finishJournal(entry)
+
+	workDir := m.GetWorkingDirectory(task)
+	remote := m.PushRemote()
+	branch := task.GetBranch()
+
+	// Tasks addressing review comments push to the branch of the pull request
+	refspec := branch
+	target := pushTarget(task, branch)
+	if target != branch {
+		refspec = branch + ":" + target
+	}
+
+	err := m.gitClient.Push(ctx, workDir, remote, refspec, true)
+	kind := git.ClassifyPushError(err)
+
+	if kind == git.PushErrorNonFastForward {
+		if rebaseErr := m.gitClient.PullRebase(ctx, workDir, remote, target); rebaseErr != nil {
+			m.gitClient.RebaseAbort(ctx, workDir)
+		} else {
+			err = m.gitClient.Push(ctx, workDir, remote, refspec, true)
+			kind = git.ClassifyPushError(err)
+		}
+	}
+
+	if err == nil {
+		task.ClearPushFailure()
+		return nil
+	}
+
+	pushErr := &PushError{Kind: kind, Err: err}
+	if saveErr := task.SavePushFailure(fmt.Sprintf("%s: %s", kind, pushErr.Hint())); saveErr != nil {
+		// Marker is best-effort - the error is still returned
+	}
+	if archiveErr := m.Archive().Record(task.Name, func(e *ArchiveEntry) {
+		e.PushFailures++
+	}); archiveErr != nil {
+		// Archive is informational only
+	}
+	return pushErr
+}
@@ -2,12 +2,16 @@
 package task
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
+
+	"github.com/donghojung/taw/internal/constants"
+	"github.com/donghojung/taw/internal/slack"
 )
 
 // QueueManager handles the quick task queue.
@@ -22,15 +26,54 @@ func NewQueueManager(queueDir string) *QueueManager {
 	}
 }
 
+// queueFilePattern matches a queue file: its number, then the name the task
+// gets, if any, e.g. 004.task or 004-fix-login.task.
+var queueFilePattern = regexp.MustCompile(`^(\d+)(?:-([a-z0-9-]+))?\.task$`)
+
 // QueuedTask represents a task in the queue.
 type QueuedTask struct {
 	Number  int
+	Name    string // Name for the task; empty to generate one
 	Path    string
 	Content string
+	Slack   *slack.Origin // Where the task was requested in Slack, if it was
+}
+
+// Title returns the first line of the task's content, cut to
+// constants.QueueTitleMaxLen for display.
+func (t *QueuedTask) Title() string {
+	title := contentTitle(t.Content)
+	if runes := []rune(title); len(runes) > constants.QueueTitleMaxLen {
+		title = string(runes[:constants.QueueTitleMaxLen-1]) + "…"
+	}
+	return title
+}
+
+// contentTitle returns the first non-empty line of task content.
+func contentTitle(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
 }
 
 // Add adds a new task to the queue.
 func (q *QueueManager) Add(content string) error {
+	return q.AddNamed(content, "")
+}
+
+// AddNamed adds a new task to the queue that gets the given name, if it's a
+// valid task name once started, instead of a generated one.
+func (q *QueueManager) AddNamed(content, name string) error {
+	return q.AddWithOrigin(content, name, nil)
+}
+
+// AddWithOrigin adds a task named as with AddNamed, and where in Slack it was
+// requested unless origin is nil. The origin is kept next to its queue file
+// until the task starts.
+func (q *QueueManager) AddWithOrigin(content, name string, origin *slack.Origin) error {
 	// Ensure queue directory exists
 	if err := os.MkdirAll(q.queueDir, 0755); err != nil {
 		return fmt.Errorf("failed to create queue directory: %w", err)
@@ -43,9 +86,17 @@ func (q *QueueManager) Add(content string) error {
 	}
 
 	// Create task file
-	filename := fmt.Sprintf("%03d.task", nextNum)
-	path := filepath.Join(q.queueDir, filename)
+	path := q.taskPath(nextNum, name)
 
+	if origin != nil {
+		data, err := json.Marshal(origin)
+		if err != nil {
+			return fmt.Errorf("failed to encode Slack origin: %w", err)
+		}
+		if err := os.WriteFile(slackOriginPath(path), data, 0644); err != nil {
+			return fmt.Errorf("failed to write Slack origin: %w", err)
+		}
+	}
 	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
 		return fmt.Errorf("failed to write queue task: %w", err)
 	}
@@ -64,14 +115,12 @@ func (q *QueueManager) List() ([]QueuedTask, error) {
 	}
 
 	var tasks []QueuedTask
-	pattern := regexp.MustCompile(`^(\d+)\.task$`)
-
 	for _, entry := range entries {
 		if entry.IsDir() {
 			continue
 		}
 
-		matches := pattern.FindStringSubmatch(entry.Name())
+		matches := queueFilePattern.FindStringSubmatch(entry.Name())
 		if matches == nil {
 			continue
 		}
@@ -87,8 +136,10 @@ func (q *QueueManager) List() ([]QueuedTask, error) {
 
 		tasks = append(tasks, QueuedTask{
 			Number:  num,
+			Name:    matches[2],
 			Path:    path,
 			Content: string(content),
+			Slack:   loadSlackOrigin(slackOriginPath(path)),
 		})
 	}
 
@@ -114,8 +165,8 @@ func (q *QueueManager) Pop() (*QueuedTask, error) {
 	first := &tasks[0]
 
 	// Remove the task file
-	if err := os.Remove(first.Path); err != nil {
-		return nil, fmt.Errorf("failed to remove queue task: %w", err)
+	if err := removeQueueFile(first.Path); err != nil {
+		return nil, err
 	}
 
 	return first, nil
@@ -129,14 +180,125 @@ func (q *QueueManager) Clear() error {
 	}
 
 	for _, task := range tasks {
-		if err := os.Remove(task.Path); err != nil {
-			return fmt.Errorf("failed to remove queue task: %w", err)
+		if err := removeQueueFile(task.Path); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
+// At returns the task at position n of the queue, 1 being the next to
+// start.
+func (q *QueueManager) At(n int) (*QueuedTask, error) {
+	tasks, err := q.List()
+	if err != nil {
+		return nil, err
+	}
+	if n < 1 || n > len(tasks) {
+		return nil, fmt.Errorf("no task at position %d of the queue (%d queued)", n, len(tasks))
+	}
+	return &tasks[n-1], nil
+}
+
+// Remove removes the task at position n from the queue and returns it.
+func (q *QueueManager) Remove(n int) (*QueuedTask, error) {
+	t, err := q.At(n)
+	if err != nil {
+		return nil, err
+	}
+	if err := removeQueueFile(t.Path); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Promote moves the task at position n to the front of the queue, so it
+// starts next, and returns it.
+func (q *QueueManager) Promote(n int) (*QueuedTask, error) {
+	tasks, err := q.List()
+	if err != nil {
+		return nil, err
+	}
+	if n < 1 || n > len(tasks) {
+		return nil, fmt.Errorf("no task at position %d of the queue (%d queued)", n, len(tasks))
+	}
+	promoted := tasks[n-1]
+	if n == 1 {
+		return &promoted, nil
+	}
+
+	// Set the task aside, move the ones before it back by one, last first,
+	// then give it the number of the first
+	aside := filepath.Join(q.queueDir, constants.QueuePromoteFile)
+	if err := moveQueueFile(promoted.Path, aside); err != nil {
+		return nil, err
+	}
+	for i := n - 2; i >= 0; i-- {
+		if err := moveQueueFile(tasks[i].Path, q.taskPath(tasks[i].Number+1, tasks[i].Name)); err != nil {
+			return nil, err
+		}
+	}
+	promoted.Number = tasks[0].Number
+	promoted.Path = q.taskPath(promoted.Number, promoted.Name)
+	if err := moveQueueFile(aside, promoted.Path); err != nil {
+		return nil, err
+	}
+	return &promoted, nil
+}
+
+// taskPath returns the path of the queue file with the given number and
+// task name. Names that can't be part of a queue file name are left out.
+func (q *QueueManager) taskPath(number int, name string) string {
+	filename := fmt.Sprintf("%03d.task", number)
+	if name != "" {
+		if withName := fmt.Sprintf("%03d-%s.task", number, name); queueFilePattern.MatchString(withName) {
+			filename = withName
+		}
+	}
+	return filepath.Join(q.queueDir, filename)
+}
+
+// slackOriginPath returns the path of the Slack origin of a queue file.
+func slackOriginPath(path string) string {
+	return path + constants.SlackOriginFileName
+}
+
+// loadSlackOrigin reads a Slack origin, or returns nil if there is none.
+func loadSlackOrigin(path string) *slack.Origin {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var origin slack.Origin
+	if err := json.Unmarshal(data, &origin); err != nil || origin.Channel == "" {
+		return nil
+	}
+	return &origin
+}
+
+// removeQueueFile removes a queue file and its Slack origin.
+func removeQueueFile(path string) error {
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove queue task: %w", err)
+	}
+	if err := os.Remove(slackOriginPath(path)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove Slack origin: %w", err)
+	}
+	return nil
+}
+
+// moveQueueFile renames a queue file with its Slack origin.
+func moveQueueFile(from, to string) error {
+	if err := os.Rename(slackOriginPath(from), slackOriginPath(to)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to move Slack origin: %w", err)
+	}
+	if err := os.Rename(from, to); err != nil {
+		return fmt.Errorf("failed to move queue task: %w", err)
+	}
+	return nil
+}
+
 // Count returns the number of tasks in the queue.
 func (q *QueueManager) Count() (int, error) {
 	tasks, err := q.List()
@@ -0,0 +1,140 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/donghojung/taw/internal/constants"
+)
+
+// rateLimitPatterns match the errors Claude Code prints when the API rate
+// limits or is overloaded, with the reason shown for them.
+var rateLimitPatterns = []struct {
+	re     *regexp.Regexp
+	reason string
+}{
+	{regexp.MustCompile(`(?i)API Error: 529|overloaded_error`), "Claude API overloaded"},
+	{regexp.MustCompile(`(?i)API Error: 429|rate_limit_error|usage limit reached`), "Claude rate limit"},
+}
+
+// DetectRateLimit returns the reason if the last lines of an agent pane
+// show a rate limit or overload error.
+func DetectRateLimit(content string) (string, bool) {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	if len(lines) > constants.RateLimitScanLines {
+		lines = lines[len(lines)-constants.RateLimitScanLines:]
+	}
+	tail := strings.Join(lines, "\n")
+	for _, p := range rateLimitPatterns {
+		if p.re.MatchString(tail) {
+			return p.reason, true
+		}
+	}
+	return "", false
+}
+
+// RateLimit is the session-wide backoff after an agent hit a rate limit or
+// overload: new tasks are queued and the queue waits until it ends.
+type RateLimit struct {
+	Reason string    `json:"reason"`
+	Task   string    `json:"task"`
+	Hits   int       `json:"hits"` // Hits in a row, doubling the backoff
+	Until  time.Time `json:"until"`
+}
+
+// Active returns true if the backoff hasn't ended yet.
+func (r *RateLimit) Active() bool {
+	return r != nil && time.Now().Before(r.Until)
+}
+
+// rateLimitPath returns the path to the session's backoff state.
+func (m *Manager) rateLimitPath() string {
+	return filepath.Join(m.tawDir, constants.RateLimitFileName)
+}
+
+// RateLimit returns the last backoff, or nil if there was none. Check
+// Active to see if it still holds.
+func (m *Manager) RateLimit() *RateLimit {
+	data, err := os.ReadFile(m.rateLimitPath())
+	if err != nil {
+		return nil
+	}
+	var limit RateLimit
+	if err := json.Unmarshal(data, &limit); err != nil {
+		return nil
+	}
+	return &limit
+}
+
+// RateLimited returns the backoff in effect, or nil if new tasks can start.
+func (m *Manager) RateLimited() *RateLimit {
+	if limit := m.RateLimit(); limit.Active() {
+		return limit
+	}
+	return nil
+}
+
+// RecordRateLimit starts or extends the backoff after an agent hit a rate
+// limit. The backoff doubles with each hit, up to
+// constants.RateLimitMaxBackoff, and starts over once the limit has been
+// clear for that long.
+func (m *Manager) RecordRateLimit(task *Task, reason string) (*RateLimit, error) {
+	hits := 0
+	if last := m.RateLimit(); last != nil && time.Since(last.Until) < constants.RateLimitMaxBackoff {
+		hits = last.Hits
+	}
+
+	backoff := constants.RateLimitBaseBackoff << hits
+	if backoff > constants.RateLimitMaxBackoff || backoff <= 0 {
+		backoff = constants.RateLimitMaxBackoff
+	}
+	limit := &RateLimit{
+		Reason: reason,
+		Task:   task.Name,
+		Hits:   hits + 1,
+		Until:  time.Now().Add(backoff),
+	}
+
+	data, err := json.MarshalIndent(limit, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return limit, os.WriteFile(m.rateLimitPath(), data, 0644)
+}
+
+// CheckRateLimit looks for a rate limit error in the agent pane of a working
+// task. An error is only reported once: the pane must have changed since it
+// was last reported. Needs the tmux client of the session.
+func (m *Manager) CheckRateLimit(task *Task) (string, bool, error) {
+	if task.Status != StatusWorking || m.tmuxClient == nil {
+		return "", false, nil
+	}
+	windowID, err := task.LoadWindowID()
+	if err != nil || windowID == "" {
+		return "", false, nil
+	}
+
+	content, err := m.tmuxClient.CapturePane(windowID+".0", 0)
+	if err != nil {
+		return "", false, err
+	}
+	reason, hit := DetectRateLimit(content)
+	if !hit {
+		return "", false, nil
+	}
+
+	sum := sha256.Sum256([]byte(content))
+	hash := hex.EncodeToString(sum[:])
+	seenPath := filepath.Join(task.AgentDir, constants.RateLimitSeenFile)
+	if seen, err := os.ReadFile(seenPath); err == nil && string(seen) == hash {
+		return "", false, nil
+	}
+	return reason, true, os.WriteFile(seenPath, []byte(hash), 0644)
+}
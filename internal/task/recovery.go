@@ -2,19 +2,24 @@
 package task
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/donghojung/taw/internal/constants"
 	"github.com/donghojung/taw/internal/git"
+	"github.com/donghojung/taw/internal/tmux"
 )
 
 // RecoveryManager handles recovery of corrupted tasks.
 type RecoveryManager struct {
 	projectDir string
 	gitClient  git.Client
+	tmuxClient tmux.Client
 }
 
 // NewRecoveryManager creates a new recovery manager.
@@ -25,6 +30,12 @@ func NewRecoveryManager(projectDir string) *RecoveryManager {
 	}
 }
 
+// SetTmuxClient sets the tmux client of the task windows, needed to restart
+// dead agents.
+func (r *RecoveryManager) SetTmuxClient(client tmux.Client) {
+	r.tmuxClient = client
+}
+
 // RecoveryAction represents what action to take for a corrupted task.
 type RecoveryAction string
 
@@ -32,30 +43,39 @@ const (
 	RecoveryRecover RecoveryAction = "recover" // Try to recover the task
 	RecoveryCleanup RecoveryAction = "cleanup" // Clean up the task
 	RecoveryCancel  RecoveryAction = "cancel"  // Do nothing
+	RecoverySkip    RecoveryAction = "skip"    // Leave the task as is for now
 )
 
 // RecoverTask attempts to recover a corrupted task.
-func (r *RecoveryManager) RecoverTask(task *Task) error {
+func (r *RecoveryManager) RecoverTask(ctx context.Context, task *Task) error {
 	switch task.CorruptedReason {
 	case CorruptMissingWorktree:
-		return r.recoverMissingWorktree(task)
+		return r.recoverMissingWorktree(ctx, task)
 	case CorruptNotInGit:
-		return r.recoverNotInGit(task)
-	case CorruptInvalidGit:
-		return r.recoverInvalidGit(task)
+		return r.recoverNotInGit(ctx, task)
+	case CorruptInvalidGit, CorruptMissingGitDir:
+		return r.recoverInvalidGit(ctx, task)
 	case CorruptMissingBranch:
-		return r.recoverMissingBranch(task)
+		return r.recoverMissingBranch(ctx, task)
+	case CorruptDetachedHead:
+		return r.recoverDetachedHead(ctx, task)
+	case CorruptStaleGitLock:
+		return r.recoverStaleGitLock(task)
+	case CorruptStaleTabLock:
+		return r.recoverStaleTabLock(task)
+	case CorruptDeadAgent:
+		return r.recoverDeadAgent(task)
 	default:
 		return fmt.Errorf("unknown corruption reason: %s", task.CorruptedReason)
 	}
 }
 
 // recoverMissingWorktree recreates a worktree from an existing branch.
-func (r *RecoveryManager) recoverMissingWorktree(task *Task) error {
+func (r *RecoveryManager) recoverMissingWorktree(ctx context.Context, task *Task) error {
 	worktreeDir := task.GetWorktreeDir()
 
 	// Branch exists, just recreate the worktree
-	if err := r.gitClient.WorktreeAdd(r.projectDir, worktreeDir, task.Name, false); err != nil {
+	if err := r.gitClient.WorktreeAdd(ctx, r.projectDir, worktreeDir, task.GetBranch(), false); err != nil {
 		return fmt.Errorf("failed to recreate worktree: %w", err)
 	}
 
@@ -63,8 +83,9 @@ func (r *RecoveryManager) recoverMissingWorktree(task *Task) error {
 }
 
 // recoverNotInGit removes the directory and recreates the worktree.
-func (r *RecoveryManager) recoverNotInGit(task *Task) error {
+func (r *RecoveryManager) recoverNotInGit(ctx context.Context, task *Task) error {
 	worktreeDir := task.GetWorktreeDir()
+	branch := task.GetBranch()
 
 	// Remove the unregistered directory
 	if err := os.RemoveAll(worktreeDir); err != nil {
@@ -72,11 +93,11 @@ func (r *RecoveryManager) recoverNotInGit(task *Task) error {
 	}
 
 	// Prune worktrees
-	r.gitClient.WorktreePrune(r.projectDir)
+	r.gitClient.WorktreePrune(ctx, r.projectDir)
 
 	// Recreate worktree
-	createBranch := !r.gitClient.BranchExists(r.projectDir, task.Name)
-	if err := r.gitClient.WorktreeAdd(r.projectDir, worktreeDir, task.Name, createBranch); err != nil {
+	createBranch := !r.gitClient.BranchExists(ctx, r.projectDir, branch)
+	if err := r.gitClient.WorktreeAdd(ctx, r.projectDir, worktreeDir, branch, createBranch); err != nil {
 		return fmt.Errorf("failed to recreate worktree: %w", err)
 	}
 
@@ -84,12 +105,13 @@ func (r *RecoveryManager) recoverNotInGit(task *Task) error {
 }
 
 // recoverInvalidGit backs up files, removes directory, and recreates worktree.
-func (r *RecoveryManager) recoverInvalidGit(task *Task) error {
+func (r *RecoveryManager) recoverInvalidGit(ctx context.Context, task *Task) error {
 	worktreeDir := task.GetWorktreeDir()
+	branch := task.GetBranch()
 	backupDir := worktreeDir + ".backup"
 
 	// Check if branch exists
-	branchExists := r.gitClient.BranchExists(r.projectDir, task.Name)
+	branchExists := r.gitClient.BranchExists(ctx, r.projectDir, branch)
 
 	// Create backup
 	if err := os.Rename(worktreeDir, backupDir); err != nil {
@@ -97,10 +119,10 @@ func (r *RecoveryManager) recoverInvalidGit(task *Task) error {
 	}
 
 	// Prune worktrees
-	r.gitClient.WorktreePrune(r.projectDir)
+	r.gitClient.WorktreePrune(ctx, r.projectDir)
 
 	// Recreate worktree
-	if err := r.gitClient.WorktreeAdd(r.projectDir, worktreeDir, task.Name, !branchExists); err != nil {
+	if err := r.gitClient.WorktreeAdd(ctx, r.projectDir, worktreeDir, branch, !branchExists); err != nil {
 		// Restore backup on failure
 		os.Rename(backupDir, worktreeDir)
 		return fmt.Errorf("failed to recreate worktree: %w", err)
@@ -118,25 +140,130 @@ func (r *RecoveryManager) recoverInvalidGit(task *Task) error {
 }
 
 // recoverMissingBranch creates a branch from the worktree HEAD.
-func (r *RecoveryManager) recoverMissingBranch(task *Task) error {
+func (r *RecoveryManager) recoverMissingBranch(ctx context.Context, task *Task) error {
 	worktreeDir := task.GetWorktreeDir()
 
 	// Get HEAD commit from worktree
-	headCommit, err := r.getWorktreeHead(worktreeDir)
+	headCommit, err := r.worktreeHead(ctx, worktreeDir)
 	if err != nil {
 		return fmt.Errorf("failed to get worktree HEAD: %w", err)
 	}
 
 	// Create branch at HEAD
-	if err := r.gitClient.BranchCreate(r.projectDir, task.Name, headCommit); err != nil {
+	if err := r.gitClient.BranchCreate(ctx, r.projectDir, task.GetBranch(), headCommit); err != nil {
 		return fmt.Errorf("failed to create branch: %w", err)
 	}
 
 	return nil
 }
 
-// getWorktreeHead gets the HEAD commit of a worktree.
-func (r *RecoveryManager) getWorktreeHead(worktreeDir string) (string, error) {
+// recoverDetachedHead puts the worktree back on the task branch at its
+// current commit, as long as that doesn't drop commits of the branch.
+func (r *RecoveryManager) recoverDetachedHead(ctx context.Context, task *Task) error {
+	worktreeDir := task.GetWorktreeDir()
+	branch := task.GetBranch()
+
+	head, err := r.gitClient.RevParse(ctx, worktreeDir, "HEAD")
+	if err != nil {
+		return fmt.Errorf("failed to get worktree HEAD: %w", err)
+	}
+	if r.gitClient.BranchExists(ctx, r.projectDir, branch) && !r.gitClient.IsAncestor(ctx, r.projectDir, branch, head) {
+		return fmt.Errorf("HEAD has diverged from branch %s; check out the branch in the worktree and cherry-pick what you need", branch)
+	}
+
+	// Create or fast-forward the branch to HEAD, then attach HEAD to it;
+	// uncommitted changes stay as they are
+	if err := r.gitClient.UpdateRef(ctx, r.projectDir, "refs/heads/"+branch, head); err != nil {
+		return fmt.Errorf("failed to move branch: %w", err)
+	}
+	if err := r.gitClient.Checkout(ctx, worktreeDir, branch); err != nil {
+		return fmt.Errorf("failed to check out branch: %w", err)
+	}
+
+	return nil
+}
+
+// recoverStaleGitLock removes the lock files left by killed git processes.
+func (r *RecoveryManager) recoverStaleGitLock(task *Task) error {
+	for _, lock := range staleGitLocks(task.GetWorktreeDir()) {
+		if err := os.Remove(lock); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", filepath.Base(lock), err)
+		}
+	}
+	return nil
+}
+
+// recoverStaleTabLock removes the tab-lock of a closed window, so the task
+// gets a new window when it's opened again.
+func (r *RecoveryManager) recoverStaleTabLock(task *Task) error {
+	if err := task.RemoveTabLock(); err != nil {
+		return fmt.Errorf("failed to remove tab-lock: %w", err)
+	}
+	return nil
+}
+
+// recoverDeadAgent restarts the agent in its pane, continuing its conversation.
+func (r *RecoveryManager) recoverDeadAgent(task *Task) error {
+	return sendResumeCommand(r.tmuxClient, task)
+}
+
+// sendResumeCommand starts the agent in its pane with the recorded resume
+// command, continuing its conversation.
+func sendResumeCommand(tm tmux.Client, task *Task) error {
+	if tm == nil {
+		return fmt.Errorf("no tmux session to restart the agent in")
+	}
+	command := task.LoadResumeCommand()
+	if command == "" {
+		return fmt.Errorf("no resume command recorded; end the task and start it again")
+	}
+	windowID, err := task.LoadWindowID()
+	if err != nil {
+		return fmt.Errorf("failed to load window ID: %w", err)
+	}
+
+	pane := windowID + ".0"
+	if err := tm.SendKeysLiteral(pane, command); err != nil {
+		return fmt.Errorf("failed to send resume command: %w", err)
+	}
+	if err := tm.SendKeys(pane, "Enter"); err != nil {
+		return err
+	}
+	if err := task.SaveAgentStart(); err != nil {
+		// Only the crash detection of the watchdog uses it
+	}
+	return nil
+}
+
+// worktreeHead returns the commit checked out in a worktree. When HEAD no
+// longer resolves, e.g. because its branch was deleted, the last commit of the
+// worktree's HEAD reflog is used.
+func (r *RecoveryManager) worktreeHead(ctx context.Context, worktreeDir string) (string, error) {
+	if head, err := r.gitClient.RevParse(ctx, worktreeDir, "HEAD"); err == nil {
+		return head, nil
+	}
+
+	gitdir, err := worktreeGitDir(worktreeDir)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(filepath.Join(gitdir, "logs", "HEAD"))
+	if err != nil {
+		return "", fmt.Errorf("HEAD doesn't resolve and has no reflog: %w", err)
+	}
+
+	// Reflog lines are "<old> <new> <committer> <time>\t<message>"
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(fields) < 2 {
+		return "", fmt.Errorf("invalid HEAD reflog")
+	}
+	return r.gitClient.RevParse(ctx, r.projectDir, fields[1])
+}
+
+// worktreeGitDir returns the git directory of a linked worktree, read from
+// its .git file.
+func worktreeGitDir(worktreeDir string) (string, error) {
 	gitFile := filepath.Join(worktreeDir, ".git")
 
 	// Read .git file to get gitdir
@@ -157,28 +284,50 @@ func (r *RecoveryManager) getWorktreeHead(worktreeDir string) (string, error) {
 		return "", fmt.Errorf("invalid .git file format: empty gitdir path")
 	}
 
-	// Read HEAD file from gitdir
-	headFile := filepath.Join(gitdir, "HEAD")
-	headData, err := os.ReadFile(headFile)
+	// Relative with worktree.useRelativePaths or when moved by hand
+	if !filepath.IsAbs(gitdir) {
+		gitdir = filepath.Join(worktreeDir, gitdir)
+	}
+	return gitdir, nil
+}
+
+// staleGitLocks returns the lock files in a worktree's git directory older
+// than constants.StaleGitLockAge, which block git commands in the worktree.
+func staleGitLocks(worktreeDir string) []string {
+	gitdir, err := worktreeGitDir(worktreeDir)
 	if err != nil {
-		return "", fmt.Errorf("failed to read HEAD file: %w", err)
+		return nil
 	}
 
-	// HEAD could be a ref or a commit hash
-	head := strings.TrimSpace(string(headData))
-	if strings.HasPrefix(head, "ref: ") {
-		// It's a reference, resolve it
-		refName := strings.TrimPrefix(head, "ref: ")
-		refName = strings.TrimSpace(refName)
-		refPath := filepath.Join(gitdir, "..", refName)
-		refData, err := os.ReadFile(refPath)
-		if err != nil {
-			return "", fmt.Errorf("failed to resolve ref %s: %w", refName, err)
+	var locks []string
+	for _, name := range []string{"index.lock", "HEAD.lock", "locked"} {
+		path := filepath.Join(gitdir, name)
+		info, err := os.Stat(path)
+		if err != nil || time.Since(info.ModTime()) < constants.StaleGitLockAge {
+			continue
+		}
+		// git worktree add writes "initializing" there until it's done; a
+		// worktree locked on purpose with 'git worktree lock' is left alone
+		if name == "locked" {
+			data, _ := os.ReadFile(path)
+			if !strings.HasPrefix(string(data), "initializing") {
+				continue
+			}
 		}
-		return strings.TrimSpace(string(refData)), nil
+		locks = append(locks, path)
 	}
+	return locks
+}
 
-	return head, nil
+// rebaseInProgress returns true if a rebase, which detaches HEAD until it's
+// done, is in progress in the given git directory.
+func rebaseInProgress(gitDir string) bool {
+	for _, name := range []string{"rebase-merge", "rebase-apply"} {
+		if _, err := os.Stat(filepath.Join(gitDir, name)); err == nil {
+			return true
+		}
+	}
+	return false
 }
 
 // GetRecoveryDescription returns a human-readable description of the corruption.
@@ -192,6 +341,16 @@ func GetRecoveryDescription(reason CorruptedReason) string {
 		return "Worktree .git file is corrupted or invalid"
 	case CorruptMissingBranch:
 		return "Worktree exists but the branch is missing"
+	case CorruptMissingGitDir:
+		return "Worktree .git file points to a git directory that no longer exists"
+	case CorruptDetachedHead:
+		return "Worktree HEAD is detached from the task branch"
+	case CorruptStaleGitLock:
+		return "A killed git process left a lock file in the worktree"
+	case CorruptStaleTabLock:
+		return "Task is marked as open but its window no longer exists"
+	case CorruptDeadAgent:
+		return "Agent exited; its pane is back at the shell prompt"
 	default:
 		return "Unknown corruption"
 	}
@@ -208,6 +367,16 @@ func GetRecoveryAction(reason CorruptedReason) string {
 		return "Backup files, recreate worktree, restore files"
 	case CorruptMissingBranch:
 		return "Create branch from worktree HEAD"
+	case CorruptMissingGitDir:
+		return "Backup files, recreate worktree, restore files"
+	case CorruptDetachedHead:
+		return "Move the branch to HEAD and check it out"
+	case CorruptStaleGitLock:
+		return "Remove the stale lock file"
+	case CorruptStaleTabLock:
+		return "Remove the tab-lock and open a new window"
+	case CorruptDeadAgent:
+		return "Restart the agent, continuing its conversation"
 	default:
 		return "Unknown action"
 	}
@@ -0,0 +1,218 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Report aggregates archived tasks over a period, e.g. for standups.
+type Report struct {
+	Since time.Time `json:"since"`
+	Until time.Time `json:"until"`
+	Owner string    `json:"owner,omitempty"` // Only tasks created by this user
+
+	Created   int `json:"created"`
+	Completed int `json:"completed"`
+	Merged    int `json:"merged"`
+	Discarded int `json:"discarded"`
+	MergedPRs int `json:"merged_prs"`
+
+	Files      int `json:"files"`
+	Insertions int `json:"insertions"`
+	Deletions  int `json:"deletions"`
+
+	AvgTimeToMerge    time.Duration `json:"avg_time_to_merge"`
+	AvgTimeToStart    time.Duration `json:"avg_time_to_start"`    // Creation to the agent getting the task
+	AvgTimeToComplete time.Duration `json:"avg_time_to_complete"` // Creation to the task ending
+	AvgTimeWaiting    time.Duration `json:"avg_time_waiting"`     // Time spent waiting for the user
+
+	VerifyFailures int `json:"verify_failures"`
+	PushFailures   int `json:"push_failures"`
+
+	// Estimated Claude cost of the period, from .taw/spend
+	Cost  float64            `json:"cost_usd"`
+	costs map[string]float64 // Of each task
+
+	// Tasks completed per user who created them
+	CompletedByOwner map[string]int `json:"completed_by_owner,omitempty"`
+
+	Tasks []ArchiveEntry `json:"tasks"`
+}
+
+// BuildReport aggregates archive entries touched since the given time.
+func BuildReport(entries []ArchiveEntry, since time.Time) *Report {
+	r := &Report{
+		Since: since,
+		Until: time.Now(),
+	}
+
+	var mergeTime, startTime, completeTime, waitingTime time.Duration
+	var started, finished int
+	for _, e := range entries {
+		if e.UpdatedAt.Before(since) {
+			continue
+		}
+		r.Tasks = append(r.Tasks, e)
+
+		if !e.CreatedAt.Before(since) {
+			r.Created++
+		}
+		r.VerifyFailures += e.VerifyFailures
+		r.PushFailures += e.PushFailures
+
+		if e.CompletedAt.IsZero() || e.CompletedAt.Before(since) {
+			continue
+		}
+
+		r.Completed++
+		switch e.Outcome {
+		case OutcomeMerged:
+			r.Merged++
+			if e.PRNumber > 0 {
+				r.MergedPRs++
+			}
+			mergeTime += e.CompletedAt.Sub(e.CreatedAt)
+		case OutcomeDiscarded:
+			// Losing drafts don't count towards the diff
+			r.Discarded++
+			continue
+		}
+		finished++
+		completeTime += e.CompletedAt.Sub(e.CreatedAt)
+		waitingTime += e.WaitingTime
+		if !e.StartedAt.IsZero() {
+			started++
+			startTime += e.StartedAt.Sub(e.CreatedAt)
+		}
+
+		if e.Owner != "" {
+			if r.CompletedByOwner == nil {
+				r.CompletedByOwner = make(map[string]int)
+			}
+			r.CompletedByOwner[e.Owner]++
+		}
+
+		r.Files += e.Files
+		r.Insertions += e.Insertions
+		r.Deletions += e.Deletions
+	}
+
+	if r.Merged > 0 {
+		r.AvgTimeToMerge = mergeTime / time.Duration(r.Merged)
+	}
+	if finished > 0 {
+		r.AvgTimeToComplete = completeTime / time.Duration(finished)
+		r.AvgTimeWaiting = waitingTime / time.Duration(finished)
+	}
+	if started > 0 {
+		r.AvgTimeToStart = startTime / time.Duration(started)
+	}
+
+	return r
+}
+
+// AddCost sets the cost of the period from the cost of each task in it. With
+// an owner, only the owner's tasks in the report count.
+func (r *Report) AddCost(costs map[string]float64) {
+	r.costs = costs
+	r.Cost = 0
+	if r.Owner == "" {
+		for _, cost := range costs {
+			r.Cost += cost
+		}
+		return
+	}
+	for _, e := range r.Tasks {
+		r.Cost += costs[e.TaskName]
+	}
+}
+
+// Markdown renders the report for pasting into a standup or chat.
+func (r *Report) Markdown() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "## TAW report (%s – %s", r.Since.Format("2006-01-02"), r.Until.Format("2006-01-02"))
+	if r.Owner != "" {
+		fmt.Fprintf(&sb, ", %s", r.Owner)
+	}
+	sb.WriteString(")\n\n")
+
+	fmt.Fprintf(&sb, "- Tasks created: %d\n", r.Created)
+	fmt.Fprintf(&sb, "- Tasks completed: %d (merged: %d, discarded drafts: %d)\n", r.Completed, r.Merged, r.Discarded)
+	fmt.Fprintf(&sb, "- Merged PRs: %d\n", r.MergedPRs)
+	fmt.Fprintf(&sb, "- Diff size: %d files, +%d / -%d\n", r.Files, r.Insertions, r.Deletions)
+	if r.Merged > 0 {
+		fmt.Fprintf(&sb, "- Average time to merge: %s\n", r.AvgTimeToMerge.Round(time.Minute))
+	}
+	if r.AvgTimeToStart > 0 {
+		fmt.Fprintf(&sb, "- Average time to start: %s\n", FormatDuration(r.AvgTimeToStart))
+	}
+	if r.AvgTimeToComplete > 0 {
+		fmt.Fprintf(&sb, "- Average time to complete: %s (waiting for input: %s)\n",
+			FormatDuration(r.AvgTimeToComplete), FormatDuration(r.AvgTimeWaiting))
+	}
+	fmt.Fprintf(&sb, "- Failures: %d verification, %d push\n", r.VerifyFailures, r.PushFailures)
+	fmt.Fprintf(&sb, "- Cost: $%.2f\n", r.Cost)
+	if len(r.CompletedByOwner) > 1 {
+		owners := make([]string, 0, len(r.CompletedByOwner))
+		for owner := range r.CompletedByOwner {
+			owners = append(owners, fmt.Sprintf("%s %d", owner, r.CompletedByOwner[owner]))
+		}
+		sort.Strings(owners)
+		fmt.Fprintf(&sb, "- Completed by: %s\n", strings.Join(owners, ", "))
+	}
+
+	var done []ArchiveEntry
+	for _, e := range r.Tasks {
+		if !e.CompletedAt.IsZero() && e.Outcome != OutcomeDiscarded {
+			done = append(done, e)
+		}
+	}
+	if len(done) > 0 {
+		sb.WriteString("\n### Completed\n\n")
+		for _, e := range done {
+			line := fmt.Sprintf("- %s (%s, +%d / -%d, %s)", e.TaskName, e.Outcome, e.Insertions, e.Deletions,
+				FormatDuration(e.CompletedAt.Sub(e.CreatedAt)))
+			if e.PRNumber > 0 {
+				line += fmt.Sprintf(" #%d", e.PRNumber)
+			}
+			if cost := r.costs[e.TaskName]; cost > 0 {
+				line += fmt.Sprintf(" $%.2f", cost)
+			}
+			if e.Owner != "" && r.Owner == "" {
+				line += " by " + e.Owner
+			}
+			sb.WriteString(line + "\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// ParseSince parses a look-back period such as "7d", "2w" or "36h".
+func ParseSince(value string) (time.Duration, error) {
+	if n, ok := strings.CutSuffix(value, "d"); ok {
+		days, err := strconv.Atoi(n)
+		if err != nil {
+			return 0, fmt.Errorf("invalid period: %s", value)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	if n, ok := strings.CutSuffix(value, "w"); ok {
+		weeks, err := strconv.Atoi(n)
+		if err != nil {
+			return 0, fmt.Errorf("invalid period: %s", value)
+		}
+		return time.Duration(weeks) * 7 * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid period: %s", value)
+	}
+	return d, nil
+}
@@ -0,0 +1,217 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/donghojung/taw/internal/config"
+	"github.com/donghojung/taw/internal/constants"
+	"github.com/donghojung/taw/internal/github"
+)
+
+// Review is the pull request a task addresses review comments of, named in
+// its content by a "Review: #<n> on <branch> at <head>" line and a
+// "Review-Thread: <id>" line per thread.
+type Review struct {
+	PR      int
+	Branch  string   // The head branch the task pushes to
+	Head    string   // The head commit when the task was created
+	Threads []string // Resolved once follow-up commits land
+}
+
+// ReviewResolve resolves a review thread addressed by a task.
+type ReviewResolve struct {
+	PR     int    `json:"pr"`
+	Head   string `json:"head"` // Nothing landed while the PR is still at this commit
+	Thread string `json:"thread"`
+}
+
+var (
+	reviewLine       = regexp.MustCompile(`(?m)^Review: #([0-9]+) on (\S+) at ([0-9a-f]{7,40})[ \t]*$`)
+	reviewThreadLine = regexp.MustCompile(`(?m)^Review-Thread: (\S+)[ \t]*$`)
+)
+
+// ReviewFromContent returns the pull request review named in task content,
+// or nil if there is none.
+func ReviewFromContent(content string) *Review {
+	m := reviewLine.FindStringSubmatch(content)
+	if m == nil {
+		return nil
+	}
+	pr, _ := strconv.Atoi(m[1])
+	r := &Review{PR: pr, Branch: m[2], Head: m[3]}
+	for _, t := range reviewThreadLine.FindAllStringSubmatch(content, -1) {
+		r.Threads = append(r.Threads, t[1])
+	}
+	return r
+}
+
+// Review returns the pull request review the task addresses, or nil.
+func (t *Task) Review() *Review {
+	content, err := t.LoadContent()
+	if err != nil {
+		return nil
+	}
+	return ReviewFromContent(content)
+}
+
+// ReviewPR gets an open pull request and its unresolved review threads, and
+// checks that tasks can push to its branch: they need worktrees, and the
+// branch on the push remote.
+func (m *Manager) ReviewPR(ctx context.Context, prNumber int) (*github.PullRequest, []github.ReviewThread, error) {
+	if !m.isGitRepo || m.config == nil || m.config.WorkMode != config.WorkModeWorktree {
+		return nil, nil, fmt.Errorf("addressing reviews needs work_mode: worktree")
+	}
+
+	pr, err := m.ghClient.GetPR(ctx, m.projectDir, prNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+	if pr.State != "OPEN" {
+		return nil, nil, fmt.Errorf("PR #%d is %s", prNumber, strings.ToLower(pr.State))
+	}
+
+	remote := m.PushRemote()
+	if pr.IsCrossRepository {
+		url, _ := m.gitClient.GetRemoteURL(ctx, m.projectDir, remote)
+		if !strings.EqualFold(github.OwnerFromURL(url), pr.HeadRepositoryOwner.Login) {
+			return nil, nil, fmt.Errorf("PR #%d is from %s's fork, not %s, so its branch can't be pushed", prNumber, pr.HeadRepositoryOwner.Login, remote)
+		}
+	} else if remote != m.UpstreamRemote() {
+		return nil, nil, fmt.Errorf("PR #%d's branch is on %s, but tasks push to %s", prNumber, m.UpstreamRemote(), remote)
+	}
+
+	threads, err := m.ghClient.ListReviewThreads(ctx, m.projectDir, prNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pr, threads, nil
+}
+
+// ReviewTaskContents composes the contents of tasks addressing review
+// threads of a pull request: one per thread, or one for all of them if
+// combined.
+func ReviewTaskContents(pr *github.PullRequest, threads []github.ReviewThread, combined bool) []string {
+	footer := func(threads []github.ReviewThread) string {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "Review: #%d on %s at %s\n", pr.Number, pr.HeadRefName, pr.HeadRefOid)
+		for _, t := range threads {
+			fmt.Fprintf(&sb, "Review-Thread: %s\n", t.ID)
+		}
+		return sb.String()
+	}
+
+	if combined {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "Address the review comments on PR #%d: %s\n\n", pr.Number, pr.Title)
+		fmt.Fprintf(&sb, "%s\n", pr.URL)
+		for _, t := range threads {
+			fmt.Fprintf(&sb, "\n### %s\n\n%s", reviewLocation(t), reviewComments(t))
+		}
+		return []string{fmt.Sprintf("%s\n%s", sb.String(), footer(threads))}
+	}
+
+	contents := make([]string, len(threads))
+	for i, t := range threads {
+		contents[i] = fmt.Sprintf("Address the review comment on %s in PR #%d\n\n%s\n%s",
+			reviewLocation(t), pr.Number, reviewComments(t), footer(threads[i:i+1]))
+	}
+	return contents
+}
+
+// reviewLocation returns where a review thread is, e.g. "main.go:42".
+func reviewLocation(t github.ReviewThread) string {
+	if t.Line > 0 {
+		return fmt.Sprintf("%s:%d", t.Path, t.Line)
+	}
+	return t.Path
+}
+
+// reviewComments quotes the comments of a review thread.
+func reviewComments(t github.ReviewThread) string {
+	var blocks []string
+	if t.IsOutdated {
+		blocks = append(blocks, "(The code changed since this comment.)\n")
+	}
+	for _, c := range t.Comments {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "%s wrote (%s):\n", c.Author, c.URL)
+		for _, line := range strings.Split(strings.TrimSpace(c.Body), "\n") {
+			sb.WriteString(strings.TrimRight("> "+line, " ") + "\n")
+		}
+		blocks = append(blocks, sb.String())
+	}
+	return strings.Join(blocks, "\n")
+}
+
+// setupReviewBranch creates the branch of a task from the head branch of a
+// pull request, and records the pull request as the task's. Each task has a
+// branch of its own, so tasks on threads of the same pull request run side
+// by side; PushTask pushes it to the head branch.
+func (m *Manager) setupReviewBranch(ctx context.Context, task *Task, review *Review, branch string) error {
+	if err := task.SavePRNumber(review.PR); err != nil {
+		return fmt.Errorf("failed to save PR number: %w", err)
+	}
+	// An interrupted setup already created it
+	if m.gitClient.BranchExists(ctx, m.projectDir, branch) {
+		return nil
+	}
+
+	remote := m.PushRemote()
+	if err := m.gitClient.Fetch(ctx, m.projectDir, remote); err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", remote, err)
+	}
+	if err := m.gitClient.BranchCreate(ctx, m.projectDir, branch, remote+"/"+review.Branch); err != nil {
+		return fmt.Errorf("failed to create branch from %s/%s: %w", remote, review.Branch, err)
+	}
+	return nil
+}
+
+// pushTarget returns the remote branch a task pushes to: the head branch of
+// the pull request it addresses review comments of, or its own branch.
+func pushTarget(task *Task, branch string) string {
+	if review := task.Review(); review != nil {
+		return review.Branch
+	}
+	return branch
+}
+
+// queueReviewResolves queues resolving the review threads a task addressed,
+// once it ended. It returns how many were queued.
+func (m *Manager) queueReviewResolves(task *Task) (int, error) {
+	review := task.Review()
+	if review == nil {
+		return 0, nil
+	}
+
+	outbox := NewOutbox(filepath.Join(m.tawDir, constants.OutboxDirName))
+	for i, thread := range review.Threads {
+		if err := outbox.AddReview(task.Name, &ReviewResolve{PR: review.PR, Head: review.Head, Thread: thread}); err != nil {
+			return i, err
+		}
+	}
+	return len(review.Threads), nil
+}
+
+// resolveReview resolves a review thread addressed by a task once follow-up
+// commits landed on its pull request. Without any, the thread is left open.
+func (m *Manager) resolveReview(ctx context.Context, taskName string, r *ReviewResolve) error {
+	if r == nil {
+		return nil
+	}
+	pr, err := m.ghClient.GetPR(ctx, m.projectDir, r.PR)
+	if err != nil {
+		return err
+	}
+	if pr.HeadRefOid == r.Head {
+		return nil
+	}
+
+	reply := fmt.Sprintf("Addressed in %s (taw task `%s`)", pr.HeadRefOid, taskName)
+	return m.ghClient.ResolveReviewThread(ctx, m.projectDir, r.Thread, reply)
+}
@@ -0,0 +1,62 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/donghojung/taw/internal/config"
+	"github.com/donghojung/taw/internal/logging"
+)
+
+// PromptRoutes returns the routes of a task by its tags and content, before
+// it has changed anything.
+func (m *Manager) PromptRoutes(task *Task) []config.Route {
+	if m.config == nil {
+		return nil
+	}
+	return m.config.MatchRoutes(task.Tags(), task.Content, nil)
+}
+
+// verifyRoutes returns the routes of a task by its tags, content and the
+// files it changed.
+func (m *Manager) verifyRoutes(ctx context.Context, task *Task) []config.Route {
+	if m.config == nil || len(m.config.Routes) == 0 {
+		return nil
+	}
+
+	var files []string
+	if m.UsesWorktree(task) {
+		var err error
+		files, err = m.gitClient.GetWorkingChangedFiles(ctx, m.GetWorkingDirectory(task), m.MainBranch(ctx))
+		if err != nil {
+			logging.Debug("Failed to list changed files of %s: %v", task.Name, err)
+		}
+	}
+	return m.config.MatchRoutes(task.Tags(), task.Content, files)
+}
+
+// RoutePrompt returns the prompt files of a task's routes as a section of
+// its user prompt, or "" if it has none. Missing files are skipped.
+func (m *Manager) RoutePrompt(task *Task) string {
+	var sb strings.Builder
+	for _, r := range m.PromptRoutes(task) {
+		if r.Prompt == "" {
+			continue
+		}
+		path := r.Prompt
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(m.projectDir, path)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			logging.Warn("Failed to read prompt of route %s: %v", r.Name, err)
+			continue
+		}
+		fmt.Fprintf(&sb, "\n\n## %s (%s)\n\n%s", r.Name, r.Prompt, strings.TrimSpace(string(content)))
+	}
+	return sb.String()
+}
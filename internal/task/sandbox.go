@@ -0,0 +1,152 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/donghojung/taw/internal/config"
+	"github.com/donghojung/taw/internal/constants"
+)
+
+// ErrSandboxUnavailable is returned when the configured sandbox can't be used.
+var ErrSandboxUnavailable = errors.New("sandbox unavailable")
+
+// devcontainerImagePattern finds the image of a devcontainer.json, which may
+// contain comments and so isn't parsed as JSON.
+var devcontainerImagePattern = regexp.MustCompile(`"image"\s*:\s*"([^"]+)"`)
+
+// Sandboxed returns true if agents run in a sandbox.
+func (m *Manager) Sandboxed() bool {
+	return m.config != nil && m.config.Sandbox == config.SandboxDocker
+}
+
+// SandboxCommand wraps an agent command line so it runs in the task's
+// container. Only the worktree (or project, in main mode), the repository's
+// .git directory (with its config and hooks read-only), the task's agent
+// directory (read-only) and the configured mounts are visible inside. The named environment variables are passed
+// through by name, so their values never appear on the command line.
+func (m *Manager) SandboxCommand(task *Task, command string, envNames []string) (string, error) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return "", fmt.Errorf("%w: docker is not installed", ErrSandboxUnavailable)
+	}
+
+	image, err := m.sandboxImage()
+	if err != nil {
+		return "", err
+	}
+
+	workDir := m.GetWorkingDirectory(task)
+	args := []string{
+		"docker", "run", "--rm", "-it", "--init",
+		"--name", shellQuote(m.SandboxName(task)),
+		// The agent directory holds the worktree, so it is mounted first
+		"-v", shellQuote(task.AgentDir + ":" + task.AgentDir + ":ro"),
+		"-v", shellQuote(workDir + ":" + workDir),
+		"-w", shellQuote(workDir),
+	}
+
+	// Commits in a worktree write to the main repository's .git. Its config
+	// (core.hooksPath, core.fsmonitor) and hooks run code on the host's next
+	// git command, so they stay read-only, in main mode too.
+	gitDir := filepath.Join(m.projectDir, ".git")
+	if info, err := os.Stat(gitDir); err == nil && info.IsDir() {
+		if !strings.HasPrefix(gitDir, workDir+string(filepath.Separator)) {
+			args = append(args, "-v", shellQuote(gitDir+":"+gitDir))
+		}
+		hooksDir := filepath.Join(gitDir, "hooks")
+		if err := os.MkdirAll(hooksDir, 0755); err != nil {
+			return "", fmt.Errorf("failed to create %s: %w", hooksDir, err)
+		}
+		configPath := filepath.Join(gitDir, "config")
+		args = append(args,
+			"-v", shellQuote(configPath+":"+configPath+":ro"),
+			"-v", shellQuote(hooksDir+":"+hooksDir+":ro"))
+	}
+
+	home, _ := os.UserHomeDir()
+	for _, mount := range m.config.SandboxMounts {
+		if rest, ok := strings.CutPrefix(mount, "~/"); ok && home != "" {
+			mount = filepath.Join(home, rest)
+		}
+		args = append(args, "-v", shellQuote(mount))
+	}
+
+	for _, name := range envNames {
+		args = append(args, "-e", name)
+	}
+	args = append(args, "-e", "TAW_SANDBOX="+string(config.SandboxDocker))
+
+	// CPU and memory caps apply to the container; niceness to the agent in it
+	args = append(args, m.sandboxLimitArgs()...)
+	if nice := m.niceArgs(); len(nice) > 0 {
+		command = strings.Join(nice, " ") + " " + command
+	}
+
+	if m.config.SandboxArgs != "" {
+		args = append(args, m.config.SandboxArgs)
+	}
+	args = append(args, shellQuote(image), command)
+
+	return strings.Join(args, " "), nil
+}
+
+// SandboxName returns the container name of a task.
+func (m *Manager) SandboxName(task *Task) string {
+	return fmt.Sprintf("taw-%s-%s", sanitizeContainerName(filepath.Base(m.projectDir)), task.Name)
+}
+
+// StopSandbox removes the task's container if it is still running
+// (error is non-fatal - containers are started with --rm).
+func (m *Manager) StopSandbox(task *Task) {
+	if !m.Sandboxed() {
+		return
+	}
+	if err := exec.Command("docker", "rm", "-f", m.SandboxName(task)).Run(); err != nil {
+		// Already gone
+	}
+}
+
+// sandboxImage returns the configured image, or the one in the project's
+// devcontainer.json.
+func (m *Manager) sandboxImage() (string, error) {
+	if m.config.SandboxImage != "" {
+		return m.config.SandboxImage, nil
+	}
+
+	for _, path := range []string{
+		filepath.Join(m.projectDir, constants.DevcontainerDir, constants.DevcontainerJSON),
+		filepath.Join(m.projectDir, "."+constants.DevcontainerJSON),
+	} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if match := devcontainerImagePattern.FindSubmatch(data); match != nil {
+			return string(match[1]), nil
+		}
+		return "", fmt.Errorf("%w: %s has no image (set sandbox_image)", ErrSandboxUnavailable, path)
+	}
+
+	return "", fmt.Errorf("%w: set sandbox_image or add .devcontainer/devcontainer.json", ErrSandboxUnavailable)
+}
+
+// sanitizeContainerName replaces characters docker doesn't allow in names.
+func sanitizeContainerName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '.' || r == '-' {
+			return r
+		}
+		return '-'
+	}, name)
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
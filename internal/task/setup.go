@@ -0,0 +1,102 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/donghojung/taw/internal/constants"
+)
+
+// SetupStep is a step of handle-task setting up a task. Each step is recorded
+// in the tab-lock once done, so a handle-task that died halfway is resumed
+// from the first missing step instead of leaving the task half-initialized.
+type SetupStep string
+
+const (
+	SetupWorkspace SetupStep = "workspace" // Worktree, or the files of a non-git project
+	SetupWindow    SetupStep = "window"    // Task window created
+	SetupShell     SetupStep = "shell"     // User pane split off the window
+	SetupAgent     SetupStep = "agent"     // Agent command sent to its pane
+	SetupStarted   SetupStep = "started"   // Task instruction sent to the agent
+)
+
+// getSetupPath returns the path to the record of done setup steps.
+func (t *Task) getSetupPath() string {
+	return filepath.Join(t.GetTabLockDir(), constants.SetupFileName)
+}
+
+// BeginSetup starts recording the setup of a task whose tab-lock was just
+// created.
+func (t *Task) BeginSetup() error {
+	return os.WriteFile(t.getSetupPath(), nil, 0644)
+}
+
+// SetupInterrupted returns true if the setup of the task was started but not
+// finished. Tasks set up before steps were recorded count as finished.
+func (t *Task) SetupInterrupted() bool {
+	if _, err := os.Stat(t.getSetupPath()); err != nil {
+		return false
+	}
+	return !t.SetupDone(SetupStarted)
+}
+
+// SetupDone returns true if a setup step was recorded as done.
+func (t *Task) SetupDone(step SetupStep) bool {
+	for _, done := range t.loadSetup() {
+		if done == step {
+			return true
+		}
+	}
+	return false
+}
+
+// MarkSetupDone records a setup step as done.
+func (t *Task) MarkSetupDone(step SetupStep) error {
+	if t.SetupDone(step) {
+		return nil
+	}
+	f, err := os.OpenFile(t.getSetupPath(), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to record setup step: %w", err)
+	}
+	defer f.Close()
+	_, err = fmt.Fprintln(f, step)
+	return err
+}
+
+// ForgetSetup drops recorded steps, e.g. the steps inside a window that no
+// longer exists, so they run again.
+func (t *Task) ForgetSetup(steps ...SetupStep) error {
+	var kept []string
+	for _, done := range t.loadSetup() {
+		forget := false
+		for _, step := range steps {
+			if done == step {
+				forget = true
+				break
+			}
+		}
+		if !forget {
+			kept = append(kept, string(done)+"\n")
+		}
+	}
+	return os.WriteFile(t.getSetupPath(), []byte(strings.Join(kept, "")), 0644)
+}
+
+// loadSetup returns the setup steps recorded as done.
+func (t *Task) loadSetup() []SetupStep {
+	data, err := os.ReadFile(t.getSetupPath())
+	if err != nil {
+		return nil
+	}
+	var steps []SetupStep
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			steps = append(steps, SetupStep(line))
+		}
+	}
+	return steps
+}
@@ -0,0 +1,84 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/donghojung/taw/internal/config"
+	"github.com/donghojung/taw/internal/constants"
+	"github.com/donghojung/taw/internal/slack"
+)
+
+// SlackMessage is a message about a task to the Slack channel it was
+// requested in. The link of the task's pull request is added when posting.
+type SlackMessage struct {
+	Channel string `json:"channel"`
+	Text    string `json:"text"`
+	PR      int    `json:"pr,omitempty"`
+}
+
+// SaveSlackOrigin records the Slack channel and user that asked for the task.
+func (t *Task) SaveSlackOrigin(origin *slack.Origin) error {
+	data, err := json.Marshal(origin)
+	if err != nil {
+		return fmt.Errorf("failed to encode Slack origin: %w", err)
+	}
+	return os.WriteFile(filepath.Join(t.AgentDir, constants.SlackOriginFileName), data, 0644)
+}
+
+// LoadSlackOrigin returns where in Slack the task was requested, or nil.
+func (t *Task) LoadSlackOrigin() *slack.Origin {
+	return loadSlackOrigin(filepath.Join(t.AgentDir, constants.SlackOriginFileName))
+}
+
+// queueSlack queues telling the Slack channel a task was requested in that
+// it finished or failed. It returns true if a message was queued.
+func (m *Manager) queueSlack(kind config.WebhookEvent, task *Task, reason string) (bool, error) {
+	origin := task.LoadSlackOrigin()
+	if origin == nil {
+		return false, nil
+	}
+
+	msg := &SlackMessage{Channel: origin.Channel}
+	switch kind {
+	case config.EventTaskCompleted:
+		msg.Text = fmt.Sprintf(":white_check_mark: Task `%s` finished", task.Name)
+		msg.PR, _ = task.LoadPRNumber()
+	case config.EventTaskFailed:
+		msg.Text = fmt.Sprintf(":warning: Task `%s` needs attention: %s", task.Name, reason)
+	default:
+		return false, nil
+	}
+
+	outbox := NewOutbox(filepath.Join(m.tawDir, constants.OutboxDirName))
+	if err := outbox.AddSlack(task.Name, kind, msg); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// postSlack posts a message with the bot token from .taw/env.
+func (m *Manager) postSlack(ctx context.Context, msg *SlackMessage) error {
+	if msg == nil || m.config == nil {
+		return nil
+	}
+	name := m.config.Slack.BotTokenEnvName()
+	token := m.Secret(name)
+	if token == "" {
+		return fmt.Errorf("%s is not set", name)
+	}
+
+	text := msg.Text
+	if msg.PR > 0 {
+		pr, err := m.ghClient.GetPRStatus(ctx, m.projectDir, msg.PR)
+		if err != nil {
+			return err
+		}
+		text += fmt.Sprintf(" - <%s|PR #%d>", pr.URL, msg.PR)
+	}
+	return slack.New("", token, m.config.Timeouts.Network).PostMessage(ctx, msg.Channel, text)
+}
@@ -0,0 +1,162 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/donghojung/taw/internal/constants"
+)
+
+// StaleDays returns how many days a task in progress may go untouched
+// before it is flagged stale, or 0 if tasks aren't.
+func (m *Manager) StaleDays() int {
+	if m.config == nil {
+		return 0
+	}
+	return m.config.Stale.Days
+}
+
+// staleGrace returns how long a stale task stays flagged before it is parked.
+func (m *Manager) staleGrace() time.Duration {
+	if m.config == nil {
+		return 0
+	}
+	return time.Duration(m.config.Stale.GraceDays) * 24 * time.Hour
+}
+
+// getStalePath returns the path to the marker of a stale task.
+func (t *Task) getStalePath() string {
+	return filepath.Join(t.AgentDir, constants.StaleFileName)
+}
+
+// StaleSince returns when the task was flagged stale, or the zero time if it
+// isn't.
+func (t *Task) StaleSince() time.Time {
+	data, err := os.ReadFile(t.getStalePath())
+	if err != nil {
+		return time.Time{}
+	}
+	at, _ := time.Parse(time.RFC3339, strings.TrimSpace(string(data)))
+	return at
+}
+
+// IsStale returns true if the task was flagged for going untouched.
+func (t *Task) IsStale() bool {
+	_, err := os.Stat(t.getStalePath())
+	return err == nil
+}
+
+// clearStale removes the stale flag.
+func (t *Task) clearStale() {
+	if err := os.Remove(t.getStalePath()); err != nil && !os.IsNotExist(err) {
+		// The flag is set again or cleared on the next check
+	}
+}
+
+// LastActivity returns when the task was last touched: the newest of when
+// its agent started, the last commit on its branch and the last output in
+// its window.
+func (m *Manager) LastActivity(ctx context.Context, task *Task) time.Time {
+	tl := task.LoadTimeline()
+	last := tl.StartedAt
+	if last.IsZero() {
+		last = tl.CreatedAt
+	}
+
+	if at, err := m.gitClient.LastCommitTime(ctx, m.projectDir, task.GetBranch()); err == nil && at.After(last) {
+		last = at
+	}
+
+	if m.tmuxClient != nil {
+		if windowID, err := task.LoadWindowID(); err == nil && windowID != "" {
+			output, err := m.tmuxClient.RunWithOutput("display-message", "-p", "-t", windowID, "#{window_activity}")
+			if seconds, perr := strconv.ParseInt(strings.TrimSpace(output), 10, 64); err == nil && perr == nil && seconds > 0 {
+				if at := time.Unix(seconds, 0); at.After(last) {
+					last = at
+				}
+			}
+		}
+	}
+	return last
+}
+
+// CheckStale flags a task in progress that went untouched for stale.days,
+// and clears the flag once it is touched again. It returns whether the task
+// was just flagged, and whether it stayed flagged for stale.grace_days and
+// is due to be parked.
+func (m *Manager) CheckStale(ctx context.Context, task *Task) (flagged, due bool, err error) {
+	days := m.StaleDays()
+	if days <= 0 {
+		task.clearStale()
+		return false, false, nil
+	}
+
+	last := m.LastActivity(ctx, task)
+	since := task.StaleSince()
+	if task.IsStale() {
+		if last.After(since) {
+			task.clearStale()
+			return false, false, nil
+		}
+		return false, time.Since(since) >= m.staleGrace(), nil
+	}
+
+	if time.Since(last) < time.Duration(days)*24*time.Hour {
+		return false, false, nil
+	}
+	if err := os.WriteFile(task.getStalePath(), []byte(time.Now().Format(time.RFC3339)), 0644); err != nil {
+		return false, false, fmt.Errorf("failed to flag task stale: %w", err)
+	}
+	return true, false, nil
+}
+
+// ParkStaleTask parks a task that stayed stale: it backs its work up as a
+// draft pull request like ParkTask or, without a remote or GitHub, as a patch
+// in its task directory, then removes its worktree and closes its window.
+// It returns where the work went.
+func (m *Manager) ParkStaleTask(ctx context.Context, task *Task) (string, error) {
+	var saved string
+	prNumber, err := m.ParkTask(ctx, task)
+	if err == nil {
+		saved = fmt.Sprintf("draft PR #%d", prNumber)
+	} else {
+		path, patchErr := m.saveParkedPatch(ctx, task)
+		if patchErr != nil {
+			return "", fmt.Errorf("%v, and saving a patch failed: %w", err, patchErr)
+		}
+		if err := task.SaveStatus(StatusParked); err != nil {
+			return path, fmt.Errorf("failed to save status: %w", err)
+		}
+		saved = path
+	}
+
+	if _, err := m.hibernate(ctx, task); err != nil {
+		return saved, fmt.Errorf("failed to remove worktree: %w", err)
+	}
+	task.clearStale()
+	return saved, nil
+}
+
+// saveParkedPatch commits the work in the task's worktree and saves the
+// commits of its branch as a patch in its task directory.
+func (m *Manager) saveParkedPatch(ctx context.Context, task *Task) (string, error) {
+	if _, err := m.commitAll(ctx, task, fmt.Sprintf(parkCommitMessage, task.Name)); err != nil {
+		return "", err
+	}
+	patch, err := m.gitClient.FormatPatch(ctx, m.projectDir, m.MainBranch(ctx), task.GetBranch())
+	if err != nil {
+		return "", fmt.Errorf("failed to create patch: %w", err)
+	}
+
+	path := filepath.Join(task.AgentDir, constants.ParkedPatchFileName)
+	if err := os.WriteFile(path, []byte(patch), 0644); err != nil {
+		return "", fmt.Errorf("failed to save patch: %w", err)
+	}
+	return path, nil
+}
@@ -2,24 +2,31 @@
 package task
 
 import (
+	"crypto/rand"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/donghojung/taw/internal/constants"
+	"github.com/donghojung/taw/internal/icon"
+	"github.com/donghojung/taw/internal/tmux"
 )
 
 // Status represents the status of a task.
 type Status string
 
 const (
-	StatusPending   Status = "pending"   // Task created, not yet started
-	StatusWorking   Status = "working"   // Agent is working on the task
-	StatusWaiting   Status = "waiting"   // Waiting for user input (merge conflict, etc.)
-	StatusDone      Status = "done"      // Task completed and merged
-	StatusCorrupted Status = "corrupted" // Task has issues that need recovery
+	StatusPending    Status = "pending"     // Task created, not yet started
+	StatusWorking    Status = "working"     // Agent is working on the task
+	StatusWaiting    Status = "waiting"     // Waiting for user input (merge conflict, etc.)
+	StatusDone       Status = "done"        // Task completed and merged
+	StatusCorrupted  Status = "corrupted"   // Task has issues that need recovery
+	StatusPushFailed Status = "push-failed" // Pushing the task branch failed
+	StatusParked     Status = "parked"      // Pushed with a draft PR when the session was quit
 )
 
 // CorruptedReason represents why a task is corrupted.
@@ -30,10 +37,26 @@ const (
 	CorruptNotInGit        CorruptedReason = "not_in_git"       // Worktree exists but not registered in git
 	CorruptInvalidGit      CorruptedReason = "invalid_git"      // .git file is corrupted
 	CorruptMissingBranch   CorruptedReason = "missing_branch"   // Branch doesn't exist
+	CorruptMissingGitDir   CorruptedReason = "missing_gitdir"   // .git file points to a git directory that's gone
+	CorruptDetachedHead    CorruptedReason = "detached_head"    // Worktree HEAD is detached from the task branch
+	CorruptStaleGitLock    CorruptedReason = "stale_git_lock"   // Lock file left in the worktree by a killed git process
+	CorruptStaleTabLock    CorruptedReason = "stale_tab_lock"   // Tab-lock left for a window that no longer exists
+	CorruptDeadAgent       CorruptedReason = "dead_agent"       // Window exists but the agent exited to the shell
 )
 
+// tagPattern matches a #tag in task content. Markdown headings ("# Title")
+// aren't tags because of the space.
+var tagPattern = regexp.MustCompile(`(?:^|\s)#([A-Za-z0-9][A-Za-z0-9_-]*)`)
+
+// priorityPattern matches a priority tag, #p1 (highest) to #p9.
+var priorityPattern = regexp.MustCompile(`^p([1-9])$`)
+
+// noPriority ranks tasks without a priority tag after all others.
+const noPriority = 10
+
 // Task represents a TAW task.
 type Task struct {
+	ID          string // Stable across window IDs, which tmux reuses
 	Name        string
 	AgentDir    string
 	WorktreeDir string
@@ -41,10 +64,14 @@ type Task struct {
 	Content     string
 	Status      Status
 	PRNumber    int
+	Owner       string
 	CreatedAt   time.Time
 
 	// For corrupted tasks
 	CorruptedReason CorruptedReason
+
+	// For competing drafts (best-of-N)
+	DraftGroup string
 }
 
 // New creates a new Task with the given name and agent directory.
@@ -62,6 +89,50 @@ func (t *Task) GetTaskFilePath() string {
 	return filepath.Join(t.AgentDir, constants.TaskFileName)
 }
 
+// GetIDPath returns the path to the file of the task's stable ID.
+func (t *Task) GetIDPath() string {
+	return filepath.Join(t.AgentDir, constants.TaskIDFileName)
+}
+
+// LoadID loads the task's stable ID, or "" if it has none yet.
+func (t *Task) LoadID() string {
+	data, err := os.ReadFile(t.GetIDPath())
+	if err != nil {
+		return ""
+	}
+	t.ID = strings.TrimSpace(string(data))
+	return t.ID
+}
+
+// EnsureID returns the task's stable ID, generating one for a task created
+// without it.
+func (t *Task) EnsureID() (string, error) {
+	if id := t.LoadID(); id != "" {
+		return id, nil
+	}
+
+	id, err := newTaskID()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate task ID: %w", err)
+	}
+	if err := os.WriteFile(t.GetIDPath(), []byte(id), 0644); err != nil {
+		return "", fmt.Errorf("failed to save task ID: %w", err)
+	}
+	t.ID = id
+	return id, nil
+}
+
+// newTaskID returns a random (version 4) UUID.
+func newTaskID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
 // GetTabLockDir returns the path to the tab-lock directory.
 func (t *Task) GetTabLockDir() string {
 	return filepath.Join(t.AgentDir, constants.TabLockDirName)
@@ -72,12 +143,49 @@ func (t *Task) GetWindowIDPath() string {
 	return filepath.Join(t.GetTabLockDir(), constants.WindowIDFileName)
 }
 
-// GetWorktreeDir returns the path to the worktree directory.
+// GetWorktreeDir returns the path to the worktree directory: the one
+// recorded when the worktree was created under the worktree root, or the
+// agent directory's worktree.
 func (t *Task) GetWorktreeDir() string {
 	if t.WorktreeDir != "" {
 		return t.WorktreeDir
 	}
-	return filepath.Join(t.AgentDir, "worktree")
+	if data, err := os.ReadFile(filepath.Join(t.AgentDir, constants.WorktreePathFile)); err == nil {
+		if path := strings.TrimSpace(string(data)); path != "" {
+			return path
+		}
+	}
+	return filepath.Join(t.AgentDir, constants.WorktreeDirName)
+}
+
+// SaveWorktreeDir records a worktree created outside the agent directory.
+func (t *Task) SaveWorktreeDir(path string) error {
+	t.WorktreeDir = path
+	return os.WriteFile(filepath.Join(t.AgentDir, constants.WorktreePathFile), []byte(path), 0644)
+}
+
+// GetBranch returns the task's branch, which is the task name unless the
+// branch template named it otherwise.
+func (t *Task) GetBranch() string {
+	if branch := t.loadBranch(); branch != "" {
+		return branch
+	}
+	return t.Name
+}
+
+// loadBranch returns the recorded branch, or "" for tasks without a branch
+// of their own.
+func (t *Task) loadBranch() string {
+	data, err := os.ReadFile(filepath.Join(t.AgentDir, constants.BranchFileName))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// SaveBranch records the task's branch.
+func (t *Task) SaveBranch(branch string) error {
+	return os.WriteFile(filepath.Join(t.AgentDir, constants.BranchFileName), []byte(branch), 0644)
 }
 
 // GetPRFilePath returns the path to the PR number file.
@@ -95,6 +203,12 @@ func (t *Task) GetUserPromptPath() string {
 	return filepath.Join(t.AgentDir, ".user-prompt")
 }
 
+// GetPromptPath returns the path to the task's extra prompt, the last layer
+// of the system prompt.
+func (t *Task) GetPromptPath() string {
+	return filepath.Join(t.AgentDir, constants.PromptFileName)
+}
+
 // GetOriginPath returns the path to the origin symlink.
 func (t *Task) GetOriginPath() string {
 	return filepath.Join(t.AgentDir, "origin")
@@ -181,30 +295,282 @@ func (t *Task) LoadPRNumber() (int, error) {
 	return prNumber, nil
 }
 
+// GetStatusPath returns the path to the status file, the source of truth for
+// the status the agent reported. Window names only display it.
+func (t *Task) GetStatusPath() string {
+	return filepath.Join(t.AgentDir, constants.StatusFileName)
+}
+
+// SaveStatus records the status reported for the task.
+func (t *Task) SaveStatus(status Status) error {
+	t.Status = status
+	if err := t.recordStatusTime(status); err != nil {
+		// The timeline is informational only
+	}
+	return os.WriteFile(t.GetStatusPath(), []byte(status), 0644)
+}
+
+// LoadStatus returns the recorded status, or "" if none was reported.
+func (t *Task) LoadStatus() Status {
+	data, err := os.ReadFile(t.GetStatusPath())
+	if err != nil {
+		return ""
+	}
+	return Status(strings.TrimSpace(string(data)))
+}
+
+// GetPushFailedPath returns the path to the push failure marker file.
+func (t *Task) GetPushFailedPath() string {
+	return filepath.Join(t.AgentDir, constants.PushFailedFileName)
+}
+
+// SavePushFailure records a failed push with the given reason.
+func (t *Task) SavePushFailure(reason string) error {
+	t.Status = StatusPushFailed
+	return os.WriteFile(t.GetPushFailedPath(), []byte(reason), 0644)
+}
+
+// LoadPushFailure returns the recorded push failure reason, or "" if the last push succeeded.
+func (t *Task) LoadPushFailure() string {
+	data, err := os.ReadFile(t.GetPushFailedPath())
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// ClearPushFailure removes the push failure marker.
+func (t *Task) ClearPushFailure() error {
+	if err := os.Remove(t.GetPushFailedPath()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// GetResumeCommandPath returns the path to the agent resume command file.
+func (t *Task) GetResumeCommandPath() string {
+	return filepath.Join(t.AgentDir, constants.ResumeCmdFileName)
+}
+
+// SaveResumeCommand records the shell command that restarts the agent in its
+// pane, continuing its conversation.
+func (t *Task) SaveResumeCommand(command string) error {
+	return os.WriteFile(t.GetResumeCommandPath(), []byte(command), 0644)
+}
+
+// LoadResumeCommand returns the recorded resume command, or "" if there is none.
+func (t *Task) LoadResumeCommand() string {
+	data, err := os.ReadFile(t.GetResumeCommandPath())
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// GetAgentScriptPath returns the path to the sh script that runs the agent,
+// or continues its conversation if resume is set.
+func (t *Task) GetAgentScriptPath(resume bool) string {
+	if resume {
+		return filepath.Join(t.AgentDir, constants.ResumeScriptFile)
+	}
+	return filepath.Join(t.AgentDir, constants.AgentScriptFile)
+}
+
+// SaveAgentScript writes the sh script that runs the agent, or continues its
+// conversation if resume is set.
+func (t *Task) SaveAgentScript(resume bool, script string) error {
+	return os.WriteFile(t.GetAgentScriptPath(resume), []byte(script+"\n"), 0644)
+}
+
+// SaveAgentStart records that the agent was started in its pane just now.
+func (t *Task) SaveAgentStart() error {
+	path := filepath.Join(t.AgentDir, constants.AgentStartFileName)
+	return os.WriteFile(path, []byte(time.Now().Format(time.RFC3339)), 0644)
+}
+
+// AgentUptime returns how long ago the agent was last started, and false if
+// no start was recorded.
+func (t *Task) AgentUptime() (time.Duration, bool) {
+	data, err := os.ReadFile(filepath.Join(t.AgentDir, constants.AgentStartFileName))
+	if err != nil {
+		return 0, false
+	}
+	started, err := time.Parse(time.RFC3339, strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, false
+	}
+	return time.Since(started), true
+}
+
+// LoadRestarts returns how many times in a row the agent was restarted after
+// crashing.
+func (t *Task) LoadRestarts() int {
+	data, err := os.ReadFile(filepath.Join(t.AgentDir, constants.RestartsFileName))
+	if err != nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+	return n
+}
+
+// SaveRestarts records how many times in a row the agent was restarted.
+func (t *Task) SaveRestarts(n int) error {
+	return os.WriteFile(filepath.Join(t.AgentDir, constants.RestartsFileName), []byte(strconv.Itoa(n)), 0644)
+}
+
+// GetDraftGroupPath returns the path to the draft group file.
+func (t *Task) GetDraftGroupPath() string {
+	return filepath.Join(t.AgentDir, constants.DraftGroupFileName)
+}
+
+// SaveDraftGroup marks the task as a draft in the given group.
+func (t *Task) SaveDraftGroup(group string) error {
+	t.DraftGroup = group
+	return os.WriteFile(t.GetDraftGroupPath(), []byte(group), 0644)
+}
+
+// LoadDraftGroup loads the draft group, or "" if the task is not a draft.
+func (t *Task) LoadDraftGroup() string {
+	data, err := os.ReadFile(t.GetDraftGroupPath())
+	if err != nil {
+		return ""
+	}
+	t.DraftGroup = strings.TrimSpace(string(data))
+	return t.DraftGroup
+}
+
+// ClearDraftGroup turns a draft back into a regular task.
+func (t *Task) ClearDraftGroup() error {
+	t.DraftGroup = ""
+	os.Remove(filepath.Join(t.AgentDir, constants.DraftDoneFileName))
+	if err := os.Remove(t.GetDraftGroupPath()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// MarkDraftDone records that the draft's agent has finished.
+func (t *Task) MarkDraftDone() error {
+	t.Status = StatusDone
+	return os.WriteFile(filepath.Join(t.AgentDir, constants.DraftDoneFileName), []byte{}, 0644)
+}
+
+// IsDraftDone returns true if the draft's agent has finished.
+func (t *Task) IsDraftDone() bool {
+	_, err := os.Stat(filepath.Join(t.AgentDir, constants.DraftDoneFileName))
+	return err == nil
+}
+
+// MarkParked records that the task's pull request is a draft opened when it
+// was parked.
+func (t *Task) MarkParked() error {
+	return os.WriteFile(filepath.Join(t.AgentDir, constants.ParkedFileName), []byte{}, 0644)
+}
+
+// IsParked returns true if the task's pull request is a draft opened when it
+// was parked.
+func (t *Task) IsParked() bool {
+	_, err := os.Stat(filepath.Join(t.AgentDir, constants.ParkedFileName))
+	return err == nil
+}
+
+// ClearParked forgets that the task's pull request is a draft.
+func (t *Task) ClearParked() error {
+	if err := os.Remove(filepath.Join(t.AgentDir, constants.ParkedFileName)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Tags returns the #tags in the task content, without the #.
+func (t *Task) Tags() []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, m := range tagPattern.FindAllStringSubmatch(t.Content, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			tags = append(tags, m[1])
+		}
+	}
+	return tags
+}
+
+// Priority returns the task's priority from its #p1 (highest) to #p9 tag,
+// or a lower one than all of them if it has none.
+func (t *Task) Priority() int {
+	for _, tag := range t.Tags() {
+		if match := priorityPattern.FindStringSubmatch(tag); match != nil {
+			return int(match[1][0] - '0')
+		}
+	}
+	return noPriority
+}
+
 // HasPR returns true if the task has a PR number.
 func (t *Task) HasPR() bool {
 	_, err := os.Stat(t.GetPRFilePath())
 	return err == nil
 }
 
-// GetWindowName returns the window name with status emoji.
-func (t *Task) GetWindowName() string {
-	emoji := constants.EmojiWorking
+// StatusIcon returns the icon showing the task's status.
+func (t *Task) StatusIcon() icon.Icon {
 	switch t.Status {
-	case StatusWaiting:
-		emoji = constants.EmojiWaiting
+	case StatusWaiting, StatusPushFailed, StatusParked:
+		return icon.Waiting
 	case StatusDone:
-		emoji = constants.EmojiDone
+		return icon.Done
 	case StatusCorrupted:
-		emoji = constants.EmojiWarning
+		return icon.Warning
+	}
+	if t.IsStuck() {
+		return icon.Stuck
+	}
+	return icon.Working
+}
+
+// WindowStatus returns the status shown for the task's window: its status,
+// or stuck while the agent's pane stopped changing.
+func (t *Task) WindowStatus() string {
+	if t.StatusIcon() == icon.Stuck {
+		return "stuck"
+	}
+	if t.Status == "" {
+		return string(StatusWorking)
 	}
+	return string(t.Status)
+}
 
-	name := t.Name
-	if len(name) > 12 {
-		name = name[:12]
+// SyncWindow records the task's ID, name, status and branch in the options
+// of its window, which the status bar shows and internal commands find the
+// window by.
+func (t *Task) SyncWindow(tm tmux.Client, windowID string) error {
+	b := tmux.NewBatch()
+	if t.ID != "" {
+		b.SetWindowOption(windowID, constants.TaskIDOption, t.ID)
 	}
+	b.SetWindowOption(windowID, constants.TaskOption, t.Name)
+	b.SetWindowOption(windowID, constants.TaskStatusOption, t.WindowStatus())
+	b.SetWindowOption(windowID, constants.TaskBranchOption, t.loadBranch())
+	return tm.RunBatch(b)
+}
 
-	return emoji + name
+// WindowIn returns the ID of the task's window among windows: the one tagged
+// with the task, or the untagged one with the recorded ID for windows opened
+// before tasks tagged theirs. It returns "" if the window is gone.
+func (t *Task) WindowIn(windows []tmux.Window) string {
+	for _, w := range windows {
+		if (t.ID != "" && w.TaskID == t.ID) || w.Task == t.Name {
+			return w.ID
+		}
+	}
+	if id, err := t.LoadWindowID(); err == nil {
+		for _, w := range windows {
+			if w.ID == id && w.Task == "" {
+				return id
+			}
+		}
+	}
+	return ""
 }
 
 // SetupSymlinks creates the origin symlink.
@@ -0,0 +1,231 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/donghojung/taw/internal/constants"
+	"github.com/donghojung/taw/internal/logging"
+)
+
+// TasksFileItem is a checklist item of the tasks file ("- [ ] title"), with
+// the lines indented under it as its body.
+type TasksFileItem struct {
+	Line    int // Index of its line in the file
+	Title   string
+	Body    string
+	Checked bool
+}
+
+// TasksFileSync is what syncing the tasks file did, by item title.
+type TasksFileSync struct {
+	Queued  []string // Unchecked items queued as tasks
+	Checked []string // Items whose task was merged, now checked off
+	Running []string // Items whose task is queued or running
+	Ended   []string // Items whose task ended without merging, left unchecked
+}
+
+// Changed returns true if the sync queued tasks or checked off items.
+func (s *TasksFileSync) Changed() bool {
+	return len(s.Queued) > 0 || len(s.Checked) > 0
+}
+
+// checklistItem matches a checklist item: its indent, box and title.
+var checklistItem = regexp.MustCompile(`^(\s*)[-*+] \[([ xX])\] (.+)$`)
+
+// tasksFileLine matches the line of task content naming the tasks file the
+// task was queued from.
+var tasksFileLine = regexp.MustCompile(`(?m)^Tasks file: (.+?)[ \t]*$`)
+
+// ParseTasksFile returns the checklist items of a markdown file. Lines
+// indented deeper than an item, up to the next item, are its body.
+func ParseTasksFile(content string) []TasksFileItem {
+	var items []TasksFileItem
+	var current *TasksFileItem
+	var indent int
+	var body []string
+	flush := func() {
+		if current != nil {
+			current.Body = strings.TrimSpace(strings.Join(body, "\n"))
+			items = append(items, *current)
+		}
+		current, body = nil, nil
+	}
+
+	for i, line := range strings.Split(content, "\n") {
+		if m := checklistItem.FindStringSubmatch(line); m != nil {
+			flush()
+			current = &TasksFileItem{Line: i, Title: strings.TrimSpace(m[3]), Checked: m[2] != " "}
+			indent = len(m[1])
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed != "" && len(line)-len(trimmed) <= indent {
+			flush()
+			continue
+		}
+		body = append(body, strings.TrimSpace(line))
+	}
+	flush()
+	return items
+}
+
+// TasksFilePath returns the path of the tasks file (tasks_file), relative to
+// the project.
+func (m *Manager) TasksFilePath() string {
+	name := constants.DefaultTasksFile
+	if m.config != nil && m.config.TasksFile != "" {
+		name = m.config.TasksFile
+	}
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(m.projectDir, name)
+}
+
+// tasksFileContent returns the content of the task of an item, tagged with
+// the tasks file so that the item can be checked off once it's merged.
+func (m *Manager) tasksFileContent(item TasksFileItem) string {
+	content := item.Title
+	if item.Body != "" {
+		content += "\n\n" + item.Body
+	}
+	return fmt.Sprintf("%s\n\nTasks file: %s\n", content, m.tasksFileName())
+}
+
+// tasksFileName returns the name of the tasks file in task contents.
+func (m *Manager) tasksFileName() string {
+	if rel, err := filepath.Rel(m.projectDir, m.TasksFilePath()); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return m.TasksFilePath()
+}
+
+// tasksFileTitle returns the title of the item task content was queued
+// from, or "" if it wasn't queued from the tasks file.
+func (m *Manager) tasksFileTitle(content string) string {
+	match := tasksFileLine.FindStringSubmatch(content)
+	if match == nil || match[1] != m.tasksFileName() {
+		return ""
+	}
+	title, _, _ := strings.Cut(content, "\n")
+	return strings.TrimSpace(title)
+}
+
+// SyncTasksFile makes the tasks file the backlog: its unchecked items that
+// no task was queued for are queued, and items whose task was merged are
+// checked off with a link to the pull request. Items whose task ended
+// without merging are left alone. With dryRun, nothing is queued or written.
+func (m *Manager) SyncTasksFile(ctx context.Context, queue *QueueManager, dryRun bool) (*TasksFileSync, error) {
+	path := m.TasksFilePath()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tasks file: %w", err)
+	}
+	items := ParseTasksFile(string(data))
+
+	// Items with a task queued or running
+	running := make(map[string]bool)
+	queued, err := queue.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, q := range queued {
+		if title := m.tasksFileTitle(q.Content); title != "" {
+			running[title] = true
+		}
+	}
+	tasks, err := m.ListTasks()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tasks {
+		if content, err := t.LoadContent(); err == nil {
+			if title := m.tasksFileTitle(content); title != "" {
+				running[title] = true
+			}
+		}
+	}
+
+	// Items whose task ended, and the last merge of each
+	archived, err := m.Archive().List()
+	if err != nil {
+		return nil, err
+	}
+	ended := make(map[string]bool)
+	merged := make(map[string]*ArchiveEntry)
+	for i, e := range archived {
+		title := m.tasksFileTitle(e.Content)
+		if title == "" || e.Outcome == "" {
+			continue
+		}
+		ended[title] = true
+		// Without git, a completed task's changes are in the project
+		if e.Outcome == OutcomeMerged || (e.Outcome == OutcomeCompleted && !m.isGitRepo) {
+			merged[title] = &archived[i]
+		}
+	}
+
+	sync := &TasksFileSync{}
+	lines := strings.Split(string(data), "\n")
+	for _, item := range items {
+		if item.Checked {
+			continue
+		}
+		switch {
+		case merged[item.Title] != nil:
+			lines[item.Line] = checkOff(lines[item.Line], m.prLink(ctx, merged[item.Title].PRNumber))
+			sync.Checked = append(sync.Checked, item.Title)
+		case running[item.Title]:
+			sync.Running = append(sync.Running, item.Title)
+		case ended[item.Title]:
+			sync.Ended = append(sync.Ended, item.Title)
+		default:
+			if !dryRun {
+				if err := queue.Add(m.tasksFileContent(item)); err != nil {
+					return sync, fmt.Errorf("failed to queue task: %w", err)
+				}
+			}
+			// Items with the same title are one task
+			running[item.Title] = true
+			sync.Queued = append(sync.Queued, item.Title)
+		}
+	}
+
+	if len(sync.Checked) > 0 && !dryRun {
+		if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+			return sync, fmt.Errorf("failed to write tasks file: %w", err)
+		}
+	}
+	return sync, nil
+}
+
+// prLink returns a markdown link to a pull request, "" without one.
+func (m *Manager) prLink(ctx context.Context, number int) string {
+	if number == 0 {
+		return ""
+	}
+	pr, err := m.ghClient.GetPRStatus(ctx, m.projectDir, number)
+	if err != nil || pr.URL == "" {
+		logging.Debug("Failed to get the URL of PR #%d: %v", number, err)
+		return fmt.Sprintf("#%d", number)
+	}
+	return fmt.Sprintf("[#%d](%s)", number, pr.URL)
+}
+
+// checkOff checks the box of a checklist item line and appends link to it.
+func checkOff(line, link string) string {
+	line = strings.Replace(line, "[ ]", "[x]", 1)
+	if link != "" {
+		line = strings.TrimRight(line, " \t") + " (" + link + ")"
+	}
+	return line
+}
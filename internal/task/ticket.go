@@ -0,0 +1,178 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/donghojung/taw/internal/config"
+	"github.com/donghojung/taw/internal/constants"
+	"github.com/donghojung/taw/internal/ticket"
+)
+
+// TicketState is where a task stands for its ticket.
+type TicketState string
+
+const (
+	TicketInProgress TicketState = "in_progress" // The task started
+	TicketInReview   TicketState = "in_review"   // The task has a pull request
+	TicketDone       TicketState = "done"        // The task finished
+)
+
+// TicketSync moves a ticket to the status of a state, and links the pull
+// request of its task.
+type TicketSync struct {
+	Key   string      `json:"key"`
+	State TicketState `json:"state"`
+	PR    int         `json:"pr,omitempty"`
+}
+
+// HasTicketProvider returns true if an issue tracker is configured.
+func (m *Manager) HasTicketProvider() bool {
+	return m.config != nil && m.config.Ticket.Provider != "" && m.config.Ticket.Provider != config.TicketNone
+}
+
+// TicketProvider returns the issue tracker of the project, with its token
+// from .taw/env.
+func (m *Manager) TicketProvider() (ticket.Provider, error) {
+	if !m.HasTicketProvider() {
+		return nil, ticket.ErrNotConfigured
+	}
+
+	cfg := m.config.Ticket
+	token := m.Secret(cfg.TokenEnvName())
+	if token == "" {
+		return nil, fmt.Errorf("%w: %s is not set", ticket.ErrNoToken, cfg.TokenEnvName())
+	}
+
+	switch cfg.Provider {
+	case config.TicketJira:
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("ticket.url must be set to the Jira site")
+		}
+		return ticket.NewJira(cfg.URL, cfg.Email, token, m.config.Timeouts.Network), nil
+	case config.TicketLinear:
+		return ticket.NewLinear(cfg.URL, token, m.config.Timeouts.Network), nil
+	default:
+		return nil, fmt.Errorf("unknown ticket provider: %s", cfg.Provider)
+	}
+}
+
+// TicketKey returns the key of the ticket a task was created from, or "".
+func (t *Task) TicketKey() string {
+	content, err := t.LoadContent()
+	if err != nil {
+		return ""
+	}
+	return ticket.KeyFromContent(content)
+}
+
+// ticketStatus returns the tracker status of a state, "" to leave tickets.
+func (m *Manager) ticketStatus(state TicketState) string {
+	switch state {
+	case TicketInProgress:
+		return m.config.Ticket.InProgress
+	case TicketInReview:
+		return m.config.Ticket.InReview
+	case TicketDone:
+		return m.config.Ticket.Done
+	}
+	return ""
+}
+
+// QueueTicketSync queues moving the ticket of a task to a state in the
+// outbox, unless it's already there or the task has no ticket. It returns
+// true if it was queued.
+func (m *Manager) QueueTicketSync(task *Task, state TicketState) (bool, error) {
+	if !m.HasTicketProvider() {
+		return false, nil
+	}
+	key := task.TicketKey()
+	if key == "" {
+		return false, nil
+	}
+
+	if loadTicketState(task) == state {
+		return false, nil
+	}
+
+	pr, _ := task.LoadPRNumber()
+	outbox := NewOutbox(filepath.Join(m.tawDir, constants.OutboxDirName))
+	if err := outbox.AddTicket(task.Name, &TicketSync{Key: key, State: state, PR: pr}); err != nil {
+		return false, err
+	}
+	return true, os.WriteFile(filepath.Join(task.AgentDir, constants.TicketStateFile), []byte(state), 0644)
+}
+
+// loadTicketState returns the state the ticket of a task was last queued
+// for, or "".
+func loadTicketState(task *Task) TicketState {
+	data, err := os.ReadFile(filepath.Join(task.AgentDir, constants.TicketStateFile))
+	if err != nil {
+		return ""
+	}
+	return TicketState(strings.TrimSpace(string(data)))
+}
+
+// QueueTicketReviews queues moving the tickets of tasks that got a pull
+// request to in review, and returns how many were queued.
+func (m *Manager) QueueTicketReviews() (int, error) {
+	if !m.HasTicketProvider() {
+		return 0, nil
+	}
+	tasks, err := m.ListTasks()
+	if err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	for _, task := range tasks {
+		if pr, _ := task.LoadPRNumber(); pr == 0 {
+			continue
+		}
+		// A finished task stays done
+		if loadTicketState(task) == TicketDone {
+			continue
+		}
+		ok, err := m.QueueTicketSync(task, TicketInReview)
+		if err != nil {
+			return queued, err
+		}
+		if ok {
+			queued++
+		}
+	}
+	return queued, nil
+}
+
+// syncTicket moves a ticket to the status of its state and links the pull
+// request of its task. Tickets are left alone once no tracker is configured.
+func (m *Manager) syncTicket(ctx context.Context, sync *TicketSync) error {
+	if sync == nil || !m.HasTicketProvider() {
+		return nil
+	}
+	provider, err := m.TicketProvider()
+	if err != nil {
+		return err
+	}
+
+	if status := m.ticketStatus(sync.State); status != "" {
+		if err := provider.Transition(ctx, sync.Key, status); err != nil {
+			return err
+		}
+	}
+
+	if sync.PR > 0 {
+		pr, err := m.ghClient.GetPRStatus(ctx, m.projectDir, sync.PR)
+		if err != nil {
+			return err
+		}
+		if err := provider.Link(ctx, sync.Key, pr.URL, fmt.Sprintf("Pull request #%d", sync.PR)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
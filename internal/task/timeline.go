@@ -0,0 +1,157 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/donghojung/taw/internal/constants"
+)
+
+// Timeline records when a task reached each stage of its life, and how long
+// it spent waiting for the user. It is kept in the agent directory.
+type Timeline struct {
+	CreatedAt   time.Time `json:"created_at"`
+	StartedAt   time.Time `json:"started_at,omitempty"`   // Task instruction first sent to the agent
+	CompletedAt time.Time `json:"completed_at,omitempty"` // Task ended
+
+	// Time spent waiting before the current wait, if any
+	Waiting      time.Duration `json:"waiting,omitempty"`
+	WaitingSince time.Time     `json:"waiting_since,omitempty"`
+}
+
+// TimeToStart returns how long the task waited for its agent to start, and
+// false if it hasn't started.
+func (tl *Timeline) TimeToStart() (time.Duration, bool) {
+	if tl.CreatedAt.IsZero() || tl.StartedAt.IsZero() {
+		return 0, false
+	}
+	return tl.StartedAt.Sub(tl.CreatedAt), true
+}
+
+// TimeToComplete returns how long the task took from creation to its end, and
+// false if it hasn't ended.
+func (tl *Timeline) TimeToComplete() (time.Duration, bool) {
+	if tl.CreatedAt.IsZero() || tl.CompletedAt.IsZero() {
+		return 0, false
+	}
+	return tl.CompletedAt.Sub(tl.CreatedAt), true
+}
+
+// TimeWaiting returns how long the task has spent waiting for the user,
+// including the current wait.
+func (tl *Timeline) TimeWaiting(now time.Time) time.Duration {
+	waiting := tl.Waiting
+	if !tl.WaitingSince.IsZero() {
+		waiting += now.Sub(tl.WaitingSince)
+	}
+	return waiting
+}
+
+// getTimelinePath returns the path to the timeline file.
+func (t *Task) getTimelinePath() string {
+	return filepath.Join(t.AgentDir, constants.TimelineFileName)
+}
+
+// LoadTimeline returns the timeline of the task. Tasks created before
+// timelines were recorded get the creation time of their task file.
+func (t *Task) LoadTimeline() *Timeline {
+	tl := &Timeline{}
+	if data, err := os.ReadFile(t.getTimelinePath()); err == nil {
+		if err := json.Unmarshal(data, tl); err != nil {
+			tl = &Timeline{}
+		}
+	}
+	if tl.CreatedAt.IsZero() {
+		if info, err := os.Stat(t.GetTaskFilePath()); err == nil {
+			tl.CreatedAt = info.ModTime()
+		}
+	}
+	return tl
+}
+
+// updateTimeline applies update to the timeline of the task and saves it.
+func (t *Task) updateTimeline(update func(*Timeline)) error {
+	tl := t.LoadTimeline()
+	update(tl)
+	data, err := json.MarshalIndent(tl, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(t.getTimelinePath(), data, 0644); err != nil {
+		return fmt.Errorf("failed to save timeline: %w", err)
+	}
+	return nil
+}
+
+// RecordCreated records when the task was created.
+func (t *Task) RecordCreated() error {
+	return t.updateTimeline(func(tl *Timeline) {
+		tl.CreatedAt = t.CreatedAt
+	})
+}
+
+// RecordStarted records when the agent was first given the task. Restarts of
+// the agent keep the first start.
+func (t *Task) RecordStarted() error {
+	return t.updateTimeline(func(tl *Timeline) {
+		if tl.StartedAt.IsZero() {
+			tl.StartedAt = time.Now()
+		}
+	})
+}
+
+// RecordCompleted records when the task ended, closing a wait in progress.
+func (t *Task) RecordCompleted() error {
+	return t.updateTimeline(func(tl *Timeline) {
+		now := time.Now()
+		tl.Waiting = tl.TimeWaiting(now)
+		tl.WaitingSince = time.Time{}
+		tl.CompletedAt = now
+	})
+}
+
+// recordStatusTime starts or ends a wait as the status changes.
+func (t *Task) recordStatusTime(status Status) error {
+	tl := t.LoadTimeline()
+	waiting := !tl.WaitingSince.IsZero()
+	if waiting == (status == StatusWaiting) {
+		return nil
+	}
+
+	return t.updateTimeline(func(tl *Timeline) {
+		now := time.Now()
+		if status == StatusWaiting {
+			tl.WaitingSince = now
+			return
+		}
+		tl.Waiting = tl.TimeWaiting(now)
+		tl.WaitingSince = time.Time{}
+	})
+}
+
+// FormatDuration formats a duration for display with its two largest units,
+// e.g. "2d4h", "1h20m" or "45m".
+func FormatDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "<1m"
+	case d >= 24*time.Hour:
+		days := int(d.Hours()) / 24
+		if hours := int(d.Hours()) % 24; hours > 0 {
+			return fmt.Sprintf("%dd%dh", days, hours)
+		}
+		return fmt.Sprintf("%dd", days)
+	case d >= time.Hour:
+		hours := int(d.Hours())
+		if minutes := int(d.Minutes()) % 60; minutes > 0 {
+			return fmt.Sprintf("%dh%dm", hours, minutes)
+		}
+		return fmt.Sprintf("%dh", hours)
+	default:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+}
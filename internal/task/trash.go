@@ -0,0 +1,211 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/donghojung/taw/internal/config"
+	"github.com/donghojung/taw/internal/constants"
+	"github.com/donghojung/taw/internal/logging"
+)
+
+// TrashEntry is a cleaned-up task kept in .taw/trash/ until it expires, so
+// it can be restored with 'taw undo'.
+type TrashEntry struct {
+	Name      string
+	Dir       string
+	TrashedAt time.Time
+	HasBranch bool // The task branch is kept in a refs/taw/trash/ ref
+}
+
+// ExpiresAt returns when the entry is purged for the given retention.
+func (e *TrashEntry) ExpiresAt(days int) time.Time {
+	return e.TrashedAt.AddDate(0, 0, days)
+}
+
+// TrashDays returns how many days cleaned-up tasks are kept; 0 disables the
+// trash.
+func (m *Manager) TrashDays() int {
+	if m.config == nil {
+		return constants.DefaultTrashDays
+	}
+	return m.config.TrashDays
+}
+
+// trashDir returns the directory holding trashed agent directories.
+func (m *Manager) trashDir() string {
+	return filepath.Join(m.tawDir, constants.TrashDirName)
+}
+
+// trashRef returns the ref keeping the branch of a trashed task.
+func trashRef(name string) string {
+	return constants.TrashRefPrefix + name
+}
+
+// trashBranch points the task's trash ref at its branch, so the branch can
+// be restored after it's deleted.
+func (m *Manager) trashBranch(ctx context.Context, task *Task) error {
+	return m.gitClient.UpdateRef(ctx, m.projectDir, trashRef(task.Name), "refs/heads/"+task.GetBranch())
+}
+
+// moveToTrash moves the task's agent directory (without its worktree, which
+// is removed beforehand) into the trash, replacing an older entry of the
+// same name.
+func (m *Manager) moveToTrash(task *Task) error {
+	// Already trashed by an interrupted cleanup being resumed
+	if _, err := os.Stat(task.AgentDir); os.IsNotExist(err) {
+		return nil
+	}
+
+	if err := os.MkdirAll(m.trashDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create trash directory: %w", err)
+	}
+
+	dest := filepath.Join(m.trashDir(), task.Name)
+	if err := os.RemoveAll(dest); err != nil {
+		return fmt.Errorf("failed to replace trashed task: %w", err)
+	}
+	if err := os.Rename(task.AgentDir, dest); err != nil {
+		return fmt.Errorf("failed to move task to trash: %w", err)
+	}
+
+	// Stale once the task is gone; restoring opens a new window
+	os.RemoveAll(filepath.Join(dest, constants.TabLockDirName))
+
+	stamp := []byte(time.Now().Format(time.RFC3339))
+	return os.WriteFile(filepath.Join(dest, constants.TrashedAtFileName), stamp, 0644)
+}
+
+// ListTrash returns the trashed tasks, most recently trashed first.
+func (m *Manager) ListTrash(ctx context.Context) ([]*TrashEntry, error) {
+	dirEntries, err := os.ReadDir(m.trashDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read trash directory: %w", err)
+	}
+
+	var entries []*TrashEntry
+	for _, de := range dirEntries {
+		if !de.IsDir() {
+			continue
+		}
+		entry := &TrashEntry{
+			Name: de.Name(),
+			Dir:  filepath.Join(m.trashDir(), de.Name()),
+		}
+		if data, err := os.ReadFile(filepath.Join(entry.Dir, constants.TrashedAtFileName)); err == nil {
+			entry.TrashedAt, _ = time.Parse(time.RFC3339, strings.TrimSpace(string(data)))
+		}
+		if entry.TrashedAt.IsZero() {
+			if info, err := de.Info(); err == nil {
+				entry.TrashedAt = info.ModTime()
+			}
+		}
+		if m.isGitRepo {
+			entry.HasBranch = m.gitClient.RefExists(ctx, m.projectDir, trashRef(entry.Name))
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].TrashedAt.After(entries[j].TrashedAt)
+	})
+	return entries, nil
+}
+
+// PurgeTrash deletes trashed tasks older than the retention, with their
+// branch refs (errors are non-fatal).
+func (m *Manager) PurgeTrash(ctx context.Context) {
+	m.purgeTrash(ctx, m.TrashDays())
+}
+
+// EmptyTrash deletes every trashed task with its branch ref (errors are
+// non-fatal).
+func (m *Manager) EmptyTrash(ctx context.Context) {
+	m.purgeTrash(ctx, 0)
+}
+
+// purgeTrash deletes trashed tasks older than days, or all of them if days
+// is 0.
+func (m *Manager) purgeTrash(ctx context.Context, days int) {
+	entries, err := m.ListTrash(ctx)
+	if err != nil {
+		logging.Debug("Failed to list trash: %v", err)
+		return
+	}
+
+	for _, entry := range entries {
+		if days > 0 && time.Now().Before(entry.ExpiresAt(days)) {
+			continue
+		}
+		if entry.HasBranch {
+			if err := m.gitClient.DeleteRef(ctx, m.projectDir, trashRef(entry.Name)); err != nil {
+				logging.Debug("Failed to delete trash ref of %s: %v", entry.Name, err)
+				continue
+			}
+		}
+		if err := os.RemoveAll(entry.Dir); err != nil {
+			logging.Debug("Failed to purge %s from trash: %v", entry.Name, err)
+			continue
+		}
+		logging.Debug("Purged %s from trash", entry.Name)
+	}
+}
+
+// RestoreTask restores a trashed task: its agent directory, its branch and
+// its worktree. Commits already merged into main are not reverted.
+func (m *Manager) RestoreTask(ctx context.Context, name string) (*Task, error) {
+	src := filepath.Join(m.trashDir(), name)
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s is not in the trash", name)
+	}
+	agentDir := filepath.Join(m.agentsDir, name)
+	if _, err := os.Stat(agentDir); err == nil {
+		return nil, fmt.Errorf("a task named %s already exists", name)
+	}
+
+	// Without a kept branch, opening the task creates a fresh worktree
+	branch := New(name, src).GetBranch()
+	ref := trashRef(name)
+	hasBranch := m.isGitRepo && m.config != nil && m.config.WorkMode == config.WorkModeWorktree &&
+		m.gitClient.RefExists(ctx, m.projectDir, ref)
+	if hasBranch {
+		unlock, err := m.LockProject(fmt.Sprintf("restore of %s", name))
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+
+		if m.gitClient.BranchExists(ctx, m.projectDir, branch) {
+			return nil, fmt.Errorf("a branch named %s already exists", branch)
+		}
+		if err := m.gitClient.BranchCreate(ctx, m.projectDir, branch, ref); err != nil {
+			return nil, fmt.Errorf("failed to restore branch: %w", err)
+		}
+	}
+
+	if err := os.Rename(src, agentDir); err != nil {
+		return nil, fmt.Errorf("failed to restore task: %w", err)
+	}
+	os.Remove(filepath.Join(agentDir, constants.TrashedAtFileName))
+
+	if hasBranch {
+		worktreeDir := New(name, agentDir).GetWorktreeDir()
+		if err := m.gitClient.WorktreeAdd(ctx, m.projectDir, worktreeDir, branch, false); err != nil {
+			return nil, fmt.Errorf("failed to restore worktree: %w", err)
+		}
+		if err := m.gitClient.DeleteRef(ctx, m.projectDir, ref); err != nil {
+			logging.Debug("Failed to delete trash ref of %s: %v", name, err)
+		}
+	}
+
+	return m.GetTask(name)
+}
@@ -0,0 +1,235 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/donghojung/taw/internal/config"
+	"github.com/donghojung/taw/internal/constants"
+	"github.com/donghojung/taw/internal/logging"
+)
+
+// VerifyStepResult records the outcome of a single verification step.
+type VerifyStepResult struct {
+	Name         string        `json:"name"`
+	Command      string        `json:"command"`
+	Passed       bool          `json:"passed"`
+	AllowFailure bool          `json:"allow_failure,omitempty"`
+	TimedOut     bool          `json:"timed_out,omitempty"`
+	Output       string        `json:"output,omitempty"`
+	Duration     time.Duration `json:"duration"`
+	RanAt        time.Time     `json:"ran_at"`
+}
+
+// Blocking returns true if the step failed and its failure is not allowed.
+func (r *VerifyStepResult) Blocking() bool {
+	return !r.Passed && !r.AllowFailure
+}
+
+// Summary returns a short human-readable result, e.g. "passed (12s)".
+func (r *VerifyStepResult) Summary() string {
+	state := "passed"
+	switch {
+	case r.TimedOut:
+		state = "timed out"
+	case !r.Passed && r.AllowFailure:
+		state = "failed (allowed)"
+	case !r.Passed:
+		state = "failed"
+	}
+	return fmt.Sprintf("%s (%s)", state, r.Duration.Round(time.Second))
+}
+
+// VerifyResult records the outcome of a task's verification pipeline.
+type VerifyResult struct {
+	Steps []VerifyStepResult `json:"steps"`
+}
+
+// Passed returns true if no step blocks completion.
+func (r *VerifyResult) Passed() bool {
+	for i := range r.Steps {
+		if r.Steps[i].Blocking() {
+			return false
+		}
+	}
+	return true
+}
+
+// FirstFailure returns the first blocking step, or nil.
+func (r *VerifyResult) FirstFailure() *VerifyStepResult {
+	for i := range r.Steps {
+		if r.Steps[i].Blocking() {
+			return &r.Steps[i]
+		}
+	}
+	return nil
+}
+
+// Summary returns a short human-readable result, e.g. "failed: lint (12s)".
+func (r *VerifyResult) Summary() string {
+	var total time.Duration
+	for _, step := range r.Steps {
+		total += step.Duration
+	}
+
+	if failed := r.FirstFailure(); failed != nil {
+		return fmt.Sprintf("failed: %s (%s)", failed.Name, total.Round(time.Second))
+	}
+	return fmt.Sprintf("passed (%s)", total.Round(time.Second))
+}
+
+// set records a step result, replacing an earlier result for the same step.
+func (r *VerifyResult) set(step VerifyStepResult) {
+	for i := range r.Steps {
+		if r.Steps[i].Name == step.Name {
+			r.Steps[i] = step
+			return
+		}
+	}
+	r.Steps = append(r.Steps, step)
+}
+
+// GetVerifyLogPath returns the path to the full output of a verification step.
+func (t *Task) GetVerifyLogPath(step string) string {
+	return filepath.Join(t.AgentDir, fmt.Sprintf("%s%s.log", constants.VerifyLogPrefix, step))
+}
+
+// SaveVerifyResult saves the verification result.
+func (t *Task) SaveVerifyResult(result *VerifyResult) error {
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode verify result: %w", err)
+	}
+	return os.WriteFile(filepath.Join(t.AgentDir, constants.VerifyFileName), data, 0644)
+}
+
+// LoadVerifyResult loads the last verification result, or nil if none.
+func (t *Task) LoadVerifyResult() *VerifyResult {
+	data, err := os.ReadFile(filepath.Join(t.AgentDir, constants.VerifyFileName))
+	if err != nil {
+		return nil
+	}
+
+	var result VerifyResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil
+	}
+	return &result
+}
+
+// ClearVerifyResult removes the recorded verification result.
+func (t *Task) ClearVerifyResult() {
+	os.Remove(filepath.Join(t.AgentDir, constants.VerifyFileName))
+}
+
+// VerifyPipeline returns the verification steps of a task: the configured
+// ones, then the verify commands of its routes.
+func (m *Manager) VerifyPipeline(ctx context.Context, task *Task) []config.VerifyStep {
+	if m.config == nil {
+		return nil
+	}
+	pipeline := m.config.Verify.Pipeline()
+	for _, r := range m.verifyRoutes(ctx, task) {
+		if r.Verify != "" {
+			pipeline = append(pipeline, config.VerifyStep{Name: r.Name, Command: r.Verify, Timeout: m.config.Verify.Timeout})
+		}
+	}
+	return pipeline
+}
+
+// Verify runs the verification pipeline in the task's working directory and
+// records the result in the task state. It stops at the first blocking
+// failure. It returns nil if no pipeline is configured.
+func (m *Manager) Verify(ctx context.Context, task *Task) (*VerifyResult, error) {
+	pipeline := m.VerifyPipeline(ctx, task)
+	if len(pipeline) == 0 {
+		return nil, nil
+	}
+
+	task.ClearVerifyResult()
+
+	result := &VerifyResult{}
+	for _, step := range pipeline {
+		stepResult, err := m.VerifyStep(ctx, task, step)
+		if err != nil {
+			return result, err
+		}
+		result.set(*stepResult)
+		if stepResult.Blocking() {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// VerifyStep runs a single verification step and merges its result into the
+// task's recorded verification result, so failed steps can be retried alone.
+func (m *Manager) VerifyStep(ctx context.Context, task *Task, step config.VerifyStep) (*VerifyStepResult, error) {
+	timeout := step.Timeout
+	if timeout <= 0 {
+		timeout = constants.DefaultVerifyTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", step.Command)
+	cmd.Dir = m.GetWorkingDirectory(task)
+	cmd.Env = append(os.Environ(), m.agentEnv()...)
+
+	start := time.Now()
+	out, err := cmd.CombinedOutput()
+	output := logging.Redact(string(out))
+
+	// A cancelled run says nothing about the task, so don't record it
+	if ctx.Err() == context.Canceled {
+		return nil, ctx.Err()
+	}
+
+	timedOut := ctx.Err() == context.DeadlineExceeded
+	if timedOut {
+		output += fmt.Sprintf("\n[taw] %s timed out after %s\n", step.Name, timeout)
+	}
+
+	stepResult := &VerifyStepResult{
+		Name:         step.Name,
+		Command:      step.Command,
+		Passed:       err == nil,
+		AllowFailure: step.AllowFailure,
+		TimedOut:     timedOut,
+		Output:       tail(output, constants.VerifyOutputMaxBytes),
+		Duration:     time.Since(start),
+		RanAt:        start,
+	}
+
+	if err := os.WriteFile(task.GetVerifyLogPath(step.Name), []byte(output), 0644); err != nil {
+		return stepResult, fmt.Errorf("failed to write verify log: %w", err)
+	}
+
+	result := task.LoadVerifyResult()
+	if result == nil {
+		result = &VerifyResult{}
+	}
+	result.set(*stepResult)
+	if err := task.SaveVerifyResult(result); err != nil {
+		return stepResult, err
+	}
+
+	return stepResult, nil
+}
+
+// tail returns at most the last n bytes of s.
+func tail(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.TrimLeft(s[len(s)-n:], "\n")
+}
@@ -0,0 +1,71 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"fmt"
+
+	"github.com/donghojung/taw/internal/claude"
+	"github.com/donghojung/taw/internal/constants"
+)
+
+// WatchdogAction is what the watchdog did about an agent that exited.
+type WatchdogAction string
+
+const (
+	WatchdogNone      WatchdogAction = "none"      // The agent finished or was quit by the user
+	WatchdogRestarted WatchdogAction = "restarted" // The agent crashed and was restarted
+	WatchdogStopped   WatchdogAction = "stopped"   // The agent crashed and the task waits for the user
+)
+
+// AgentExited handles the exit of the agent in a task window. An exit with a
+// non-zero code, or before the agent ran for constants.AgentMinUptime, is a
+// crash: with agent.auto_restart the agent is restarted, continuing its
+// conversation, up to constants.MaxAgentRestarts times in a row; otherwise
+// the task is set to waiting. Needs the tmux client of the session.
+func (m *Manager) AgentExited(task *Task, code int) (WatchdogAction, error) {
+	// An agent that finished may have been exited on purpose
+	if task.Status == StatusDone {
+		return WatchdogNone, nil
+	}
+
+	uptime, known := task.AgentUptime()
+	early := known && uptime < constants.AgentMinUptime
+	if code == 0 && !early {
+		return WatchdogNone, nil
+	}
+
+	// Restarting won't put claude on the PATH
+	if code == constants.AgentNotFoundCode {
+		if err := task.SaveStatus(StatusWaiting); err != nil {
+			return WatchdogStopped, fmt.Errorf("failed to save status: %w", err)
+		}
+		return WatchdogStopped, claude.ErrNotInstalled
+	}
+
+	// Only crashes soon after the last (re)start count toward the limit
+	restarts := task.LoadRestarts()
+	if !early {
+		restarts = 0
+	}
+
+	if m.config != nil && m.config.Agent.AutoRestart && restarts < constants.MaxAgentRestarts {
+		if err := task.SaveRestarts(restarts + 1); err != nil {
+			return WatchdogStopped, err
+		}
+		if err := sendResumeCommand(m.tmuxClient, task); err != nil {
+			if saveErr := task.SaveStatus(StatusWaiting); saveErr != nil {
+				// The restart error is the one worth reporting
+			}
+			return WatchdogStopped, fmt.Errorf("failed to restart agent: %w", err)
+		}
+		return WatchdogRestarted, nil
+	}
+
+	if err := task.SaveRestarts(0); err != nil {
+		// The counter only limits restarts
+	}
+	if err := task.SaveStatus(StatusWaiting); err != nil {
+		return WatchdogStopped, fmt.Errorf("failed to save status: %w", err)
+	}
+	return WatchdogStopped, nil
+}
@@ -0,0 +1,197 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/donghojung/taw/internal/config"
+	"github.com/donghojung/taw/internal/constants"
+)
+
+// Event is the JSON body posted to webhooks.
+type Event struct {
+	ID      string              `json:"id"` // Same on every retry of a delivery
+	Type    config.WebhookEvent `json:"type"`
+	Time    time.Time           `json:"time"`
+	Project string              `json:"project"`
+	Task    string              `json:"task"`
+	Owner   string              `json:"owner,omitempty"`   // Who created the task
+	Summary string              `json:"summary,omitempty"` // First line of the task content
+	Branch  string              `json:"branch,omitempty"`
+	PR      int                 `json:"pr,omitempty"`
+	Reason  string              `json:"reason,omitempty"` // Why the task failed
+}
+
+// newEvent describes an event of a task.
+func (m *Manager) newEvent(kind config.WebhookEvent, task *Task, reason string) (*Event, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	event := &Event{
+		ID:      hex.EncodeToString(id),
+		Type:    kind,
+		Time:    time.Now().UTC(),
+		Project: filepath.Base(m.projectDir),
+		Task:    task.Name,
+		Owner:   task.LoadOwner(),
+		Reason:  reason,
+	}
+	if content, err := task.LoadContent(); err == nil {
+		event.Summary, _, _ = strings.Cut(strings.TrimSpace(content), "\n")
+	}
+	if m.isGitRepo {
+		event.Branch = task.GetBranch()
+	}
+	if pr, err := task.LoadPRNumber(); err == nil {
+		event.PR = pr
+	}
+	return event, nil
+}
+
+// EmitEvent queues an event of a task in the outbox for each webhook that
+// wants it, the matching change of the task's ticket, for tasks requested
+// in Slack the message to their channel and, for tasks addressing review
+// comments, resolving the threads. It returns how many actions were queued.
+// reason says why a task failed.
+func (m *Manager) EmitEvent(kind config.WebhookEvent, task *Task, reason string) (int, error) {
+	if m.config == nil {
+		return 0, nil
+	}
+
+	queued := 0
+	var state TicketState
+	switch kind {
+	case config.EventTaskStarted:
+		state = TicketInProgress
+	case config.EventTaskCompleted:
+		state = TicketDone
+	}
+	if state != "" {
+		ok, err := m.QueueTicketSync(task, state)
+		if err != nil {
+			return queued, err
+		}
+		if ok {
+			queued++
+		}
+	}
+
+	// Review threads are resolved once the follow-up commits are pushed
+	if kind == config.EventTaskCompleted {
+		n, err := m.queueReviewResolves(task)
+		queued += n
+		if err != nil {
+			return queued, err
+		}
+	}
+
+	ok, err := m.queueSlack(kind, task, reason)
+	if err != nil {
+		return queued, err
+	}
+	if ok {
+		queued++
+	}
+
+	var event *Event
+	outbox := NewOutbox(filepath.Join(m.tawDir, constants.OutboxDirName))
+	for _, hook := range m.config.Webhooks {
+		if hook.URL == "" || !hook.Wants(kind) {
+			continue
+		}
+		if event == nil {
+			if event, err = m.newEvent(kind, task, reason); err != nil {
+				return queued, err
+			}
+		}
+		if err := outbox.AddWebhook(hook.Name, event); err != nil {
+			return queued, err
+		}
+		queued++
+	}
+	return queued, nil
+}
+
+// deliverWebhook posts the event of an outbox action to its webhook. Events
+// of a webhook that was removed from the config are dropped.
+func (m *Manager) deliverWebhook(ctx context.Context, action *OutboxAction) error {
+	var hook *config.Webhook
+	if m.config != nil {
+		for i := range m.config.Webhooks {
+			if m.config.Webhooks[i].Name == action.Webhook {
+				hook = &m.config.Webhooks[i]
+			}
+		}
+	}
+	if hook == nil || hook.URL == "" {
+		return nil
+	}
+
+	timeout := constants.DefaultNetworkTimeout
+	if m.config.Timeouts.Network > 0 {
+		timeout = m.config.Timeouts.Network
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// The outbox file indents the event; the body is compact
+	var body bytes.Buffer
+	if err := json.Compact(&body, action.Event); err != nil {
+		return fmt.Errorf("webhook %s: invalid event: %w", hook.Name, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body.Bytes()))
+	if err != nil {
+		return err
+	}
+	var event Event
+	if err := json.Unmarshal(action.Event, &event); err == nil {
+		req.Header.Set("X-TAW-Event", string(event.Type))
+		req.Header.Set("X-TAW-Delivery", event.ID)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if hook.SecretEnv != "" {
+		secret := m.Secret(hook.SecretEnv)
+		if secret == "" {
+			return fmt.Errorf("webhook %s: %s is not set", hook.Name, hook.SecretEnv)
+		}
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body.Bytes())
+		req.Header.Set("X-TAW-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook %s: %w", hook.Name, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s: %s", hook.Name, resp.Status)
+	}
+	return nil
+}
+
+// Secret returns a variable of the agent env (.taw/env or the env section),
+// or of TAW's environment.
+func (m *Manager) Secret(name string) string {
+	vars, _ := m.config.AgentEnv(m.tawDir)
+	for _, v := range vars {
+		if v.Name == name {
+			return v.Value
+		}
+	}
+	return os.Getenv(name)
+}
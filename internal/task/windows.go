@@ -0,0 +1,117 @@
+// Package task provides task management functionality for TAW.
+package task
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/donghojung/taw/internal/config"
+)
+
+// ArrangeWindows reorders the task windows per window_order and
+// window_group_done. Task windows only trade places among themselves, so the
+// new task window and other windows keep theirs.
+func (m *Manager) ArrangeWindows() error {
+	if m.tmuxClient == nil || m.config == nil {
+		return nil
+	}
+	order := m.config.WindowOrder
+	if (order == "" || order == config.WindowOrderCreated) && !m.config.GroupDone {
+		// Windows are opened in creation order
+		return nil
+	}
+
+	tasks, err := m.ListTasks()
+	if err != nil {
+		return err
+	}
+	windows, err := m.tmuxClient.ListWindows()
+	if err != nil {
+		return fmt.Errorf("failed to list windows: %w", err)
+	}
+
+	byWindow := make(map[string]*Task)
+	for _, t := range tasks {
+		if windowID := t.WindowIn(windows); windowID != "" {
+			t.WindowID = windowID
+			byWindow[windowID] = t
+		}
+	}
+
+	// Task windows in their current order, and in the wanted one
+	var current []string
+	var wanted []*Task
+	for _, w := range windows {
+		if t, ok := byWindow[w.ID]; ok {
+			current = append(current, w.ID)
+			wanted = append(wanted, t)
+		}
+	}
+	sort.SliceStable(wanted, func(i, j int) bool {
+		return m.windowBefore(wanted[i], wanted[j])
+	})
+
+	for i, t := range wanted {
+		if current[i] == t.WindowID {
+			continue
+		}
+		for j := i + 1; j < len(current); j++ {
+			if current[j] != t.WindowID {
+				continue
+			}
+			if err := m.tmuxClient.SwapWindow(current[j], current[i]); err != nil {
+				return fmt.Errorf("failed to move window of %s: %w", t.Name, err)
+			}
+			current[i], current[j] = current[j], current[i]
+			break
+		}
+	}
+
+	return nil
+}
+
+// windowBefore returns true if a's window goes before b's.
+func (m *Manager) windowBefore(a, b *Task) bool {
+	if m.config.GroupDone {
+		if sa, sb := a.settled(), b.settled(); sa != sb {
+			return sb
+		}
+	}
+
+	switch m.config.WindowOrder {
+	case config.WindowOrderNewest:
+		return a.CreatedAt.After(b.CreatedAt)
+	case config.WindowOrderStatus:
+		if ra, rb := a.statusRank(), b.statusRank(); ra != rb {
+			return ra < rb
+		}
+	case config.WindowOrderPriority:
+		if pa, pb := a.Priority(), b.Priority(); pa != pb {
+			return pa < pb
+		}
+	}
+	return a.CreatedAt.Before(b.CreatedAt)
+}
+
+// settled returns true if the agent stopped working on the task, waiting for
+// the user or done.
+func (t *Task) settled() bool {
+	switch t.Status {
+	case StatusWaiting, StatusPushFailed, StatusParked, StatusDone:
+		return true
+	}
+	return false
+}
+
+// statusRank orders statuses for window_order: status, active work first.
+func (t *Task) statusRank() int {
+	switch t.Status {
+	case StatusCorrupted:
+		return 1
+	case StatusWaiting, StatusPushFailed, StatusParked:
+		return 2
+	case StatusDone:
+		return 3
+	}
+	return 0
+}
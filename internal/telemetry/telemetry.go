@@ -0,0 +1,172 @@
+// Package telemetry counts how TAW is used and, only once the user opts in,
+// reports the counts anonymously. Nothing is recorded or sent by default.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/donghojung/taw/internal/constants"
+)
+
+// Events counted once opted in.
+const (
+	EventTaskCreated = "task_created"
+	EventComplete    = "complete." // Followed by the completion mode, e.g. complete.auto-pr
+)
+
+// State is the user's telemetry choice and the counts not reported yet,
+// kept in the user config directory.
+type State struct {
+	Enabled  bool           `json:"enabled"`
+	ID       string         `json:"id,omitempty"` // Random install ID, made on opt-in
+	Counts   map[string]int `json:"counts,omitempty"`
+	LastSent time.Time      `json:"last_sent"`
+}
+
+// Report is what is sent: counts and the environment, never names, paths,
+// task content or anything else from projects.
+type Report struct {
+	ID      string         `json:"id"`
+	Version string         `json:"version"`
+	OS      string         `json:"os"`
+	Arch    string         `json:"arch"`
+	Tmux    string         `json:"tmux"`
+	Counts  map[string]int `json:"counts"`
+}
+
+// Disabled returns true if the environment forbids telemetry
+// (DO_NOT_TRACK=1), whatever the user chose.
+func Disabled() bool {
+	return os.Getenv("DO_NOT_TRACK") == "1"
+}
+
+// path returns the path of the state file in dir.
+func path(dir string) string {
+	return filepath.Join(dir, constants.TelemetryFileName)
+}
+
+// Load reads the state from the user config directory. Without a state file
+// telemetry is off.
+func Load(dir string) (*State, error) {
+	state := &State{}
+	data, err := os.ReadFile(path(dir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("invalid telemetry state: %w", err)
+	}
+	return state, nil
+}
+
+// Save writes the state to the user config directory.
+func (s *State) Save(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path(dir), data, 0644)
+}
+
+// Enable opts in with a new random ID.
+func Enable(dir string) (*State, error) {
+	state, err := Load(dir)
+	if err != nil {
+		state = &State{}
+	}
+	if !state.Enabled || state.ID == "" {
+		id := make([]byte, 16)
+		if _, err := rand.Read(id); err != nil {
+			return nil, err
+		}
+		state.ID = hex.EncodeToString(id)
+	}
+	state.Enabled = true
+	return state, state.Save(dir)
+}
+
+// Disable opts out and forgets the ID and the counts not reported yet.
+func Disable(dir string) error {
+	return (&State{}).Save(dir)
+}
+
+// Count adds an event to the counts if telemetry is on.
+func Count(dir, event string) error {
+	if Disabled() {
+		return nil
+	}
+	state, err := Load(dir)
+	if err != nil || !state.Enabled {
+		return err
+	}
+	if state.Counts == nil {
+		state.Counts = make(map[string]int)
+	}
+	state.Counts[event]++
+	return state.Save(dir)
+}
+
+// Report returns the report of the counts not sent yet.
+func (s *State) Report(version, tmuxVersion string) Report {
+	counts := s.Counts
+	if counts == nil {
+		counts = make(map[string]int)
+	}
+	return Report{
+		ID:      s.ID,
+		Version: version,
+		OS:      runtime.GOOS,
+		Arch:    runtime.GOARCH,
+		Tmux:    tmuxVersion,
+		Counts:  counts,
+	}
+}
+
+// Due returns true if the user opted in and the counts are due to be sent.
+func (s *State) Due() bool {
+	return s.Enabled && !Disabled() && len(s.Counts) > 0 && time.Since(s.LastSent) >= constants.TelemetryInterval
+}
+
+// Send posts the report of the counts to url, then resets the counts.
+func (s *State) Send(ctx context.Context, dir, url, version, tmuxVersion string) error {
+	data, err := json.Marshal(s.Report(version, tmuxVersion))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, constants.TelemetryTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send telemetry: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to send telemetry: %s", resp.Status)
+	}
+
+	s.Counts = nil
+	s.LastSent = time.Now()
+	return s.Save(dir)
+}
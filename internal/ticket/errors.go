@@ -0,0 +1,63 @@
+// Package ticket provides an interface for issue trackers (Jira, Linear):
+// reading tickets, moving them between statuses and linking pull requests.
+package ticket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Recognized tracker failures, matched with errors.Is.
+var (
+	ErrNotFound      = errors.New("ticket not found")
+	ErrUnauthorized  = errors.New("not authorized")
+	ErrNoToken       = errors.New("no API token")
+	ErrNoTransition  = errors.New("no transition to status")
+	ErrNotConfigured = errors.New("no issue tracker configured")
+)
+
+// statusError returns the error of a failed request, with its response body.
+func statusError(code int, body []byte) error {
+	err := fmt.Errorf("%d %s", code, http.StatusText(code))
+	if msg := strings.TrimSpace(string(body)); msg != "" {
+		if len(msg) > 200 {
+			msg = msg[:200] + "..."
+		}
+		err = fmt.Errorf("%w: %s", err, msg)
+	}
+
+	switch code {
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("%w: %w", ErrUnauthorized, err)
+	default:
+		return err
+	}
+}
+
+// ErrorHint returns a user-facing remediation hint for a recognized tracker
+// failure, or "" if the error isn't one.
+func ErrorHint(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrNotConfigured):
+		return "Set ticket.provider (jira or linear) with 'taw config set'"
+	case errors.Is(err, ErrNoToken):
+		return "Add the API token to .taw/env (chmod 600), named by ticket.token_env"
+	case errors.Is(err, ErrUnauthorized):
+		return "Check the API token in .taw/env (and ticket.email for Jira)"
+	case errors.Is(err, ErrNotFound):
+		return "Check the ticket key, and that the token's account can see it"
+	case errors.Is(err, ErrNoTransition):
+		return "Set ticket.in_progress, ticket.in_review and ticket.done to statuses of your workflow"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "The tracker timed out - retry, or raise timeouts.network in .taw/config"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,121 @@
+// Package ticket provides an interface for issue trackers (Jira, Linear):
+// reading tickets, moving them between statuses and linking pull requests.
+package ticket
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// jiraProvider implements the Provider interface with the Jira REST API.
+type jiraProvider struct {
+	baseURL string
+	auth    string
+	client  *http.Client
+}
+
+// NewJira creates a Jira provider for a site, e.g. https://acme.atlassian.net.
+// With an email the token is an Atlassian API token (Jira Cloud); without,
+// it's a personal access token (Jira Data Center). A non-positive timeout
+// uses the default.
+func NewJira(baseURL, email, token string, timeout time.Duration) Provider {
+	auth := "Bearer " + token
+	if email != "" {
+		auth = "Basic " + base64.StdEncoding.EncodeToString([]byte(email+":"+token))
+	}
+	return &jiraProvider{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		auth:    auth,
+		client:  newHTTPClient(timeout),
+	}
+}
+
+// Name returns the name of the tracker.
+func (p *jiraProvider) Name() string {
+	return "Jira"
+}
+
+func (p *jiraProvider) do(ctx context.Context, method, path string, body, out any) error {
+	header := http.Header{"Authorization": {p.auth}}
+	return doJSON(ctx, p.client, method, p.baseURL+"/rest/api/2/"+path, header, body, out)
+}
+
+// Get reads a ticket by its key.
+func (p *jiraProvider) Get(ctx context.Context, key string) (*Ticket, error) {
+	var issue struct {
+		Key    string `json:"key"`
+		Fields struct {
+			Summary     string `json:"summary"`
+			Description string `json:"description"`
+			Status      struct {
+				Name string `json:"name"`
+			} `json:"status"`
+		} `json:"fields"`
+	}
+	if err := p.do(ctx, http.MethodGet, "issue/"+url.PathEscape(key)+"?fields=summary,description,status", nil, &issue); err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", key, err)
+	}
+
+	return &Ticket{
+		Key:         issue.Key,
+		Title:       issue.Fields.Summary,
+		Description: issue.Fields.Description,
+		Status:      issue.Fields.Status.Name,
+		URL:         p.baseURL + "/browse/" + issue.Key,
+	}, nil
+}
+
+// Transition moves a ticket to the named status through the workflow
+// transition that leads there (or is named like it).
+func (p *jiraProvider) Transition(ctx context.Context, key, status string) error {
+	t, err := p.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(t.Status, status) {
+		return nil
+	}
+
+	var result struct {
+		Transitions []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+			To   struct {
+				Name string `json:"name"`
+			} `json:"to"`
+		} `json:"transitions"`
+	}
+	path := "issue/" + url.PathEscape(key) + "/transitions"
+	if err := p.do(ctx, http.MethodGet, path, nil, &result); err != nil {
+		return fmt.Errorf("failed to list transitions of %s: %w", key, err)
+	}
+
+	for _, tr := range result.Transitions {
+		if strings.EqualFold(tr.To.Name, status) || strings.EqualFold(tr.Name, status) {
+			body := map[string]any{"transition": map[string]string{"id": tr.ID}}
+			if err := p.do(ctx, http.MethodPost, path, body, nil); err != nil {
+				return fmt.Errorf("failed to move %s to %s: %w", key, status, err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("%w %q from %q in %s", ErrNoTransition, status, t.Status, key)
+}
+
+// Link adds a remote link to a ticket. The URL identifies the link, so
+// linking it again updates it.
+func (p *jiraProvider) Link(ctx context.Context, key, linkURL, title string) error {
+	body := map[string]any{
+		"globalId": linkURL,
+		"object":   map[string]string{"url": linkURL, "title": title},
+	}
+	if err := p.do(ctx, http.MethodPost, "issue/"+url.PathEscape(key)+"/remotelink", body, nil); err != nil {
+		return fmt.Errorf("failed to link %s: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+// Package ticket provides an interface for issue trackers (Jira, Linear):
+// reading tickets, moving them between statuses and linking pull requests.
+package ticket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// LinearAPIURL is the endpoint of the Linear GraphQL API.
+const LinearAPIURL = "https://api.linear.app/graphql"
+
+// linearProvider implements the Provider interface with the Linear GraphQL API.
+type linearProvider struct {
+	apiURL string
+	token  string
+	client *http.Client
+}
+
+// NewLinear creates a Linear provider with a personal API key. An empty
+// apiURL uses LinearAPIURL; a non-positive timeout uses the default.
+func NewLinear(apiURL, token string, timeout time.Duration) Provider {
+	if apiURL == "" {
+		apiURL = LinearAPIURL
+	}
+	return &linearProvider{
+		apiURL: apiURL,
+		token:  token,
+		client: newHTTPClient(timeout),
+	}
+}
+
+// Name returns the name of the tracker.
+func (p *linearProvider) Name() string {
+	return "Linear"
+}
+
+// query runs a GraphQL query and decodes its data into out.
+func (p *linearProvider) query(ctx context.Context, query string, vars map[string]any, out any) error {
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	header := http.Header{"Authorization": {p.token}}
+	body := map[string]any{"query": query, "variables": vars}
+	if err := doJSON(ctx, p.client, http.MethodPost, p.apiURL, header, body, &resp); err != nil {
+		return err
+	}
+
+	if len(resp.Errors) > 0 {
+		msgs := make([]string, len(resp.Errors))
+		for i, e := range resp.Errors {
+			msgs[i] = e.Message
+		}
+		err := errors.New(strings.Join(msgs, "; "))
+		if strings.Contains(strings.ToLower(err.Error()), "not found") {
+			return fmt.Errorf("%w: %w", ErrNotFound, err)
+		}
+		return err
+	}
+	return json.Unmarshal(resp.Data, out)
+}
+
+// linearIssue is an issue with the workflow states of its team.
+type linearIssue struct {
+	ID          string `json:"id"`
+	Identifier  string `json:"identifier"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	URL         string `json:"url"`
+	State       struct {
+		Name string `json:"name"`
+	} `json:"state"`
+	Team struct {
+		States struct {
+			Nodes []struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"nodes"`
+		} `json:"states"`
+	} `json:"team"`
+}
+
+// issue reads an issue by its identifier, e.g. ENG-123.
+func (p *linearProvider) issue(ctx context.Context, key string) (*linearIssue, error) {
+	const q = `query($id: String!) {
+  issue(id: $id) {
+    id identifier title description url
+    state { name }
+    team { states { nodes { id name } } }
+  }
+}`
+	var data struct {
+		Issue *linearIssue `json:"issue"`
+	}
+	if err := p.query(ctx, q, map[string]any{"id": key}, &data); err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", key, err)
+	}
+	if data.Issue == nil {
+		return nil, fmt.Errorf("failed to get %s: %w", key, ErrNotFound)
+	}
+	return data.Issue, nil
+}
+
+// Get reads a ticket by its key.
+func (p *linearProvider) Get(ctx context.Context, key string) (*Ticket, error) {
+	issue, err := p.issue(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &Ticket{
+		Key:         issue.Identifier,
+		Title:       issue.Title,
+		Description: issue.Description,
+		Status:      issue.State.Name,
+		URL:         issue.URL,
+	}, nil
+}
+
+// Transition moves a ticket to the workflow state of its team with the
+// given name.
+func (p *linearProvider) Transition(ctx context.Context, key, status string) error {
+	issue, err := p.issue(ctx, key)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(issue.State.Name, status) {
+		return nil
+	}
+
+	for _, state := range issue.Team.States.Nodes {
+		if !strings.EqualFold(state.Name, status) {
+			continue
+		}
+		const q = `mutation($id: String!, $state: String!) {
+  issueUpdate(id: $id, input: { stateId: $state }) { success }
+}`
+		var data struct {
+			IssueUpdate struct {
+				Success bool `json:"success"`
+			} `json:"issueUpdate"`
+		}
+		if err := p.query(ctx, q, map[string]any{"id": issue.ID, "state": state.ID}, &data); err != nil {
+			return fmt.Errorf("failed to move %s to %s: %w", key, status, err)
+		}
+		if !data.IssueUpdate.Success {
+			return fmt.Errorf("failed to move %s to %s", key, status)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w %q from %q in %s", ErrNoTransition, status, issue.State.Name, key)
+}
+
+// Link attaches a URL to a ticket. Attaching the same URL again updates it.
+func (p *linearProvider) Link(ctx context.Context, key, url, title string) error {
+	const q = `mutation($id: String!, $url: String!, $title: String) {
+  attachmentLinkURL(issueId: $id, url: $url, title: $title) { success }
+}`
+	var data struct {
+		AttachmentLinkURL struct {
+			Success bool `json:"success"`
+		} `json:"attachmentLinkURL"`
+	}
+	if err := p.query(ctx, q, map[string]any{"id": key, "url": url, "title": title}, &data); err != nil {
+		return fmt.Errorf("failed to link %s: %w", key, err)
+	}
+	if !data.AttachmentLinkURL.Success {
+		return fmt.Errorf("failed to link %s", key)
+	}
+	return nil
+}
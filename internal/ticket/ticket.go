@@ -0,0 +1,134 @@
+// Package ticket provides an interface for issue trackers (Jira, Linear):
+// reading tickets, moving them between statuses and linking pull requests.
+package ticket
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/donghojung/taw/internal/constants"
+)
+
+// Provider defines the interface for issue tracker operations.
+type Provider interface {
+	// Name returns the name of the tracker, e.g. Jira.
+	Name() string
+
+	// Get reads a ticket by its key, e.g. PROJ-123.
+	Get(ctx context.Context, key string) (*Ticket, error)
+
+	// Transition moves a ticket to the named status. A ticket already in
+	// that status is left alone.
+	Transition(ctx context.Context, key, status string) error
+
+	// Link adds a link to a ticket, e.g. to a pull request. Linking the same
+	// URL again updates the link.
+	Link(ctx context.Context, key, url, title string) error
+}
+
+// Ticket is an issue in a tracker.
+type Ticket struct {
+	Key         string
+	Title       string
+	Description string
+	Status      string
+	URL         string
+}
+
+// keyLine matches the line of task content naming the ticket of the task.
+var keyLine = regexp.MustCompile(`(?m)^Ticket: ([A-Z][A-Z0-9_]*-[0-9]+)\b`)
+
+// IsKey returns true if s looks like a ticket key, e.g. PROJ-123.
+func IsKey(s string) bool {
+	return keyLine.MatchString("Ticket: " + s)
+}
+
+// KeyFromContent returns the key of the ticket named in task content by a
+// "Ticket: KEY" line, or "" if there is none.
+func KeyFromContent(content string) string {
+	if m := keyLine.FindStringSubmatch(content); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// Content returns the task content for a ticket: its title, its description
+// and the line that links the task to it.
+func (t *Ticket) Content() string {
+	var sb strings.Builder
+	sb.WriteString(t.Title)
+	sb.WriteString("\n\n")
+	if desc := strings.TrimSpace(t.Description); desc != "" {
+		sb.WriteString(desc)
+		sb.WriteString("\n\n")
+	}
+	fmt.Fprintf(&sb, "Ticket: %s", t.Key)
+	if t.URL != "" {
+		fmt.Fprintf(&sb, " (%s)", t.URL)
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// newHTTPClient returns an HTTP client with a timeout. A non-positive
+// timeout uses the default.
+func newHTTPClient(timeout time.Duration) *http.Client {
+	if timeout <= 0 {
+		timeout = constants.DefaultNetworkTimeout
+	}
+	return &http.Client{Timeout: timeout}
+}
+
+// doJSON sends a request with an optional JSON body and decodes the JSON
+// response into out (unless it's nil). Error statuses are wrapped with the
+// matching sentinel.
+func doJSON(ctx context.Context, client *http.Client, method, url string, header http.Header, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return statusError(resp.StatusCode, data)
+	}
+	if out == nil || len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("unexpected response: %w", err)
+	}
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 	"time"
 
@@ -29,20 +30,33 @@ type Client interface {
 	ListWindows() ([]Window, error)
 	SelectWindow(target string) error
 	MoveWindow(source, target string) error
+	SwapWindow(source, target string) error
 
 	// Pane operations
 	SplitWindow(target string, horizontal bool, command string) error
 	SelectPane(target string) error
+	RespawnPane(target, startDir string) error
 	SendKeys(target string, keys ...string) error
 	SendKeysLiteral(target, text string) error
 	CapturePane(target string, lines int) (string, error)
+	PaneCommand(target string) (string, error)
 
-	// Display popup
+	// Display popup (split pane or temporary window on tmux < 3.2)
 	DisplayPopup(opts PopupOpts, command string) error
+	ClosePopup() error
+
+	// Batch
+	RunBatch(b *Batch) error
+
+	// Signaling
+	WaitFor(channel string, timeout time.Duration) error
+	Signal(channel string) error
+	WatchOutput(target, pattern string) (*OutputWatch, error)
 
 	// Options
 	SetOption(key, value string, global bool) error
 	GetOption(key string) (string, error)
+	SetWindowOption(target, key, value string) error
 	SetEnv(key, value string) error
 
 	// Keybindings
@@ -53,6 +67,7 @@ type Client interface {
 	Run(args ...string) error
 	RunWithOutput(args ...string) (string, error)
 	Display(format string) (string, error)
+	DisplayMessage(message string) error
 }
 
 // SessionOpts contains options for creating a new session.
@@ -101,6 +116,12 @@ type Window struct {
 	Index  int
 	Name   string
 	Active bool
+
+	// Task metadata from the window options of task windows
+	TaskID string // @taw_task_id
+	Task   string // @taw_task
+	Status string // @taw_status
+	Branch string // @taw_branch
 }
 
 // tmuxClient implements the Client interface.
@@ -157,6 +178,17 @@ func (c *tmuxClient) RunWithOutput(args ...string) (string, error) {
 	return strings.TrimSpace(stdout.String()), nil
 }
 
+// shellArgs returns the arguments that make tmux run a shell command with sh.
+// TAW's commands use sh syntax, but tmux runs a single command string with
+// default-shell, which may be fish or nushell. tmux before 3.0 only takes
+// the string.
+func shellArgs(command string) []string {
+	if !SupportsArgv() {
+		return []string{command}
+	}
+	return []string{"sh", "-c", command}
+}
+
 // Session management
 
 func (c *tmuxClient) HasSession(name string) bool {
@@ -184,7 +216,7 @@ func (c *tmuxClient) NewSession(opts SessionOpts) error {
 	}
 	// Command must be the last argument
 	if opts.Command != "" {
-		args = append(args, opts.Command)
+		args = append(args, shellArgs(opts.Command)...)
 	}
 
 	return c.Run(args...)
@@ -228,7 +260,7 @@ func (c *tmuxClient) NewWindow(opts WindowOpts) (string, error) {
 		args = append(args, "-a", "-t", fmt.Sprintf(":%d", opts.AfterIndex))
 	}
 	if opts.Command != "" {
-		args = append(args, opts.Command)
+		args = append(args, shellArgs(opts.Command)...)
 	}
 
 	return c.RunWithOutput(args...)
@@ -243,7 +275,12 @@ func (c *tmuxClient) RenameWindow(target, name string) error {
 }
 
 func (c *tmuxClient) ListWindows() ([]Window, error) {
-	output, err := c.RunWithOutput("list-windows", "-F", "#{window_id}|#{window_index}|#{window_name}|#{window_active}")
+	format := strings.Join([]string{
+		"#{window_id}", "#{window_index}", "#{window_name}", "#{window_active}",
+		"#{" + constants.TaskIDOption + "}", "#{" + constants.TaskOption + "}",
+		"#{" + constants.TaskStatusOption + "}", "#{" + constants.TaskBranchOption + "}",
+	}, "|")
+	output, err := c.RunWithOutput("list-windows", "-F", format)
 	if err != nil {
 		return nil, err
 	}
@@ -262,12 +299,16 @@ func (c *tmuxClient) ListWindows() ([]Window, error) {
 		var index int
 		fmt.Sscanf(parts[1], "%d", &index)
 
-		windows = append(windows, Window{
+		w := Window{
 			ID:     parts[0],
 			Index:  index,
 			Name:   parts[2],
 			Active: parts[3] == "1",
-		})
+		}
+		if len(parts) >= 8 {
+			w.TaskID, w.Task, w.Status, w.Branch = parts[4], parts[5], parts[6], parts[7]
+		}
+		windows = append(windows, w)
 	}
 
 	return windows, nil
@@ -281,6 +322,10 @@ func (c *tmuxClient) MoveWindow(source, target string) error {
 	return c.Run("move-window", "-s", source, "-t", target)
 }
 
+func (c *tmuxClient) SwapWindow(source, target string) error {
+	return c.Run("swap-window", "-d", "-s", source, "-t", target)
+}
+
 // Pane operations
 
 func (c *tmuxClient) SplitWindow(target string, horizontal bool, command string) error {
@@ -293,7 +338,7 @@ func (c *tmuxClient) SplitWindow(target string, horizontal bool, command string)
 	}
 
 	if command != "" {
-		args = append(args, command)
+		args = append(args, shellArgs(command)...)
 	}
 
 	return c.Run(args...)
@@ -303,6 +348,15 @@ func (c *tmuxClient) SelectPane(target string) error {
 	return c.Run("select-pane", "-t", target)
 }
 
+// RespawnPane kills whatever runs in a pane and starts a new shell in it.
+func (c *tmuxClient) RespawnPane(target, startDir string) error {
+	args := []string{"respawn-pane", "-k", "-t", target}
+	if startDir != "" {
+		args = append(args, "-c", startDir)
+	}
+	return c.Run(args...)
+}
+
 func (c *tmuxClient) SendKeys(target string, keys ...string) error {
 	args := []string{"send-keys", "-t", target}
 	args = append(args, keys...)
@@ -321,9 +375,19 @@ func (c *tmuxClient) CapturePane(target string, lines int) (string, error) {
 	return c.RunWithOutput(args...)
 }
 
+// PaneCommand returns the command running in the foreground of a pane,
+// e.g. "claude", or the shell's name once it has exited.
+func (c *tmuxClient) PaneCommand(target string) (string, error) {
+	return c.RunWithOutput("display-message", "-p", "-t", target, "#{pane_current_command}")
+}
+
 // Display popup
 
 func (c *tmuxClient) DisplayPopup(opts PopupOpts, command string) error {
+	if !SupportsPopup() {
+		return c.displayPopupFallback(opts, command)
+	}
+
 	args := []string{"display-popup"}
 
 	if opts.Close {
@@ -348,27 +412,95 @@ func (c *tmuxClient) DisplayPopup(opts PopupOpts, command string) error {
 		args = append(args, "-s", opts.Style)
 	}
 	if command != "" {
-		args = append(args, command)
+		args = append(args, shellArgs(command)...)
 	}
 
 	return c.Run(args...)
 }
 
+// displayPopupFallback shows a popup's command in a split pane, or in a
+// temporary window if the popup is tall or the pane doesn't fit. The pane
+// closes when the command exits, like a popup with Close.
+func (c *tmuxClient) displayPopupFallback(opts PopupOpts, command string) error {
+	height := strings.TrimSuffix(opts.Height, "%")
+	percent := strings.HasSuffix(opts.Height, "%")
+
+	var paneID string
+	var err error
+	if n, _ := strconv.Atoi(height); !percent || n < constants.PopupWindowHeight {
+		args := []string{"split-window", "-v", "-P", "-F", "#{pane_id}"}
+		if height != "" {
+			if percent {
+				args = append(args, "-p", height)
+			} else {
+				args = append(args, "-l", height)
+			}
+		}
+		if opts.Directory != "" {
+			args = append(args, "-c", opts.Directory)
+		}
+		if command != "" {
+			args = append(args, shellArgs(command)...)
+		}
+		paneID, err = c.RunWithOutput(args...)
+	}
+
+	if paneID == "" || err != nil {
+		args := []string{"new-window", "-P", "-F", "#{pane_id}"}
+		if title := strings.TrimSpace(opts.Title); title != "" {
+			args = append(args, "-n", title)
+		}
+		if opts.Directory != "" {
+			args = append(args, "-c", opts.Directory)
+		}
+		if command != "" {
+			args = append(args, shellArgs(command)...)
+		}
+		if paneID, err = c.RunWithOutput(args...); err != nil {
+			return err
+		}
+	}
+
+	return c.SetOption(constants.PopupPaneOption, paneID, true)
+}
+
+// ClosePopup closes the open popup, or the pane standing in for it.
+func (c *tmuxClient) ClosePopup() error {
+	if SupportsPopup() {
+		return c.Run("display-popup", "-C")
+	}
+
+	paneID, _ := c.GetOption(constants.PopupPaneOption)
+	if paneID == "" {
+		return nil
+	}
+	c.SetOption(constants.PopupPaneOption, "", true)
+	// Killing the only pane of a temporary window closes the window
+	return c.Run("kill-pane", "-t", paneID)
+}
+
 // Options
 
 func (c *tmuxClient) SetOption(key, value string, global bool) error {
+	return c.Run(setOptionArgs(key, value, global)...)
+}
+
+func setOptionArgs(key, value string, global bool) []string {
 	args := []string{"set-option"}
 	if global {
 		args = append(args, "-g")
 	}
-	args = append(args, key, value)
-	return c.Run(args...)
+	return append(args, key, value)
 }
 
 func (c *tmuxClient) GetOption(key string) (string, error) {
 	return c.RunWithOutput("show-option", "-gv", key)
 }
 
+func (c *tmuxClient) SetWindowOption(target, key, value string) error {
+	return c.Run("set-option", "-w", "-t", target, key, value)
+}
+
 func (c *tmuxClient) SetEnv(key, value string) error {
 	return c.Run("set-environment", key, value)
 }
@@ -376,6 +508,10 @@ func (c *tmuxClient) SetEnv(key, value string) error {
 // Keybindings
 
 func (c *tmuxClient) Bind(opts BindOpts) error {
+	return c.Run(bindArgs(opts)...)
+}
+
+func bindArgs(opts BindOpts) []string {
 	args := []string{"bind"}
 
 	if opts.NoPrefix {
@@ -385,36 +521,136 @@ func (c *tmuxClient) Bind(opts BindOpts) error {
 		args = append(args, "-T", opts.Table)
 	}
 
-	args = append(args, opts.Key, opts.Command)
-	return c.Run(args...)
+	return append(args, opts.Key, opts.Command)
 }
 
 func (c *tmuxClient) Unbind(key string) error {
 	return c.Run("unbind", key)
 }
 
+// Batch
+
+// Batch collects tmux commands to run as a single command list, saving one
+// tmux subprocess per command.
+type Batch struct {
+	commands [][]string
+}
+
+// NewBatch creates an empty batch.
+func NewBatch() *Batch {
+	return &Batch{}
+}
+
+// SetOption adds a set-option command.
+func (b *Batch) SetOption(key, value string, global bool) {
+	b.commands = append(b.commands, setOptionArgs(key, value, global))
+}
+
+// SetWindowOption adds a set-option command for a window.
+func (b *Batch) SetWindowOption(target, key, value string) {
+	b.commands = append(b.commands, []string{"set-option", "-w", "-t", target, key, value})
+}
+
+// Bind adds a bind command.
+func (b *Batch) Bind(opts BindOpts) {
+	b.commands = append(b.commands, bindArgs(opts))
+}
+
+// SourceFile adds a source-file command, ignoring a missing file.
+func (b *Batch) SourceFile(path string) {
+	b.commands = append(b.commands, []string{"source-file", "-q", path})
+}
+
+// Commands returns the collected commands.
+func (b *Batch) Commands() [][]string {
+	return b.commands
+}
+
+func (c *tmuxClient) RunBatch(b *Batch) error {
+	if len(b.commands) == 0 {
+		return nil
+	}
+
+	var args []string
+	for i, command := range b.commands {
+		if i > 0 {
+			args = append(args, ";")
+		}
+		args = append(args, command...)
+	}
+	return c.Run(args...)
+}
+
+// Signaling
+
+func (c *tmuxClient) WaitFor(channel string, timeout time.Duration) error {
+	return c.waitFor(context.Background(), channel, timeout)
+}
+
+// waitFor blocks until channel is signaled, the timeout expires or ctx is
+// done.
+func (c *tmuxClient) waitFor(ctx context.Context, channel string, timeout time.Duration) error {
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := c.cmdContext(waitCtx, "wait-for", channel).Run(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if waitCtx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("timeout waiting for %s", channel)
+		}
+		return err
+	}
+	return nil
+}
+
+// Signal wakes up clients waiting on channel. tmux remembers a signal sent
+// before anyone waits, so there is no race with WaitFor.
+func (c *tmuxClient) Signal(channel string) error {
+	return c.Run("wait-for", "-S", channel)
+}
+
+// OutputWatch streams the output of a pane through pipe-pane, instead of
+// polling capture-pane, to wait for a pattern.
+type OutputWatch struct {
+	client  *tmuxClient
+	target  string
+	channel string
+}
+
+// WatchOutput starts watching the pane for output matching pattern (an
+// extended regex for grep). Only output printed after the call is seen, so
+// check what the pane already shows after starting the watch, then Wait.
+// Stop the watch when done.
+func (c *tmuxClient) WatchOutput(target, pattern string) (*OutputWatch, error) {
+	channel := fmt.Sprintf("taw-output-%d", time.Now().UnixNano())
+	watcher := fmt.Sprintf("grep -q -m1 -E '%s' && tmux -L '%s' wait-for -S '%s'",
+		strings.ReplaceAll(pattern, "'", `'\''`), c.socket, channel)
+
+	if err := c.Run("pipe-pane", "-O", "-t", target, watcher); err != nil {
+		return nil, fmt.Errorf("failed to watch pane: %w", err)
+	}
+	return &OutputWatch{client: c, target: target, channel: channel}, nil
+}
+
+// Wait blocks until the pane printed matching output, the timeout expires or
+// ctx is done.
+func (w *OutputWatch) Wait(ctx context.Context, timeout time.Duration) error {
+	return w.client.waitFor(ctx, w.channel, timeout)
+}
+
+// Stop ends the watch.
+func (w *OutputWatch) Stop() {
+	w.client.Run("pipe-pane", "-t", w.target)
+}
+
 // Display
 
 func (c *tmuxClient) Display(format string) (string, error) {
 	return c.RunWithOutput("display-message", "-p", format)
 }
 
-// WaitForWindow waits for a window to be created with the given ID file.
-func WaitForWindow(ctx context.Context, checkFn func() (string, bool)) (string, error) {
-	timeout := time.After(constants.WindowCreationTimeout)
-	ticker := time.NewTicker(100 * time.Millisecond)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return "", ctx.Err()
-		case <-timeout:
-			return "", fmt.Errorf("timeout waiting for window creation")
-		case <-ticker.C:
-			if id, ok := checkFn(); ok {
-				return id, nil
-			}
-		}
-	}
+func (c *tmuxClient) DisplayMessage(message string) error {
+	return c.Run("display-message", message)
 }
@@ -0,0 +1,98 @@
+// Package tmux provides an interface for interacting with tmux.
+package tmux
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/donghojung/taw/internal/constants"
+)
+
+// Conflict is a TAW key binding that shadows one of the user's.
+type Conflict struct {
+	Key         string
+	UserCommand string
+	TawCommand  string
+}
+
+// listKeysPattern matches a line of list-keys, e.g.
+// "bind-key -r -T root M-h   select-pane -L".
+var listKeysPattern = regexp.MustCompile(`^bind-key\s+(?:-r\s+)?-T\s+(\S+)\s+(\S+)\s+(.*)$`)
+
+// UserConfigPath returns the user's tmux config file, or an empty string if
+// there is none.
+func UserConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	candidates := []string{filepath.Join(home, ".tmux.conf")}
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		candidates = append(candidates, filepath.Join(xdg, "tmux", "tmux.conf"))
+	}
+	candidates = append(candidates, filepath.Join(home, ".config", "tmux", "tmux.conf"))
+
+	for _, path := range candidates {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// UserRootBindings returns the no-prefix (root table) key bindings of the
+// tmux config at path, by key. The config is loaded by a throwaway tmux
+// server, so sourced files and conditionals are handled like tmux does.
+func UserRootBindings(path string) (map[string]string, error) {
+	c := NewWithSocket(fmt.Sprintf("%skeys-%d", constants.TmuxSocketPrefix, os.Getpid()))
+	out, err := c.RunWithOutput("-f", path, "new-session", "-d", ";", "list-keys", "-T", "root", ";", "kill-server")
+	if err != nil {
+		c.KillServer()
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+
+	bindings := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		if m := listKeysPattern.FindStringSubmatch(strings.TrimSpace(line)); m != nil && m[1] == "root" {
+			bindings[m[2]] = strings.TrimSpace(m[3])
+		}
+	}
+	return bindings, nil
+}
+
+// FindConflicts returns the no-prefix TAW bindings that replace a user
+// binding of the same key, in the order of taw.
+func FindConflicts(taw []BindOpts, user map[string]string) []Conflict {
+	var conflicts []Conflict
+	for _, b := range taw {
+		if !b.NoPrefix {
+			continue
+		}
+		if command, ok := user[b.Key]; ok {
+			conflicts = append(conflicts, Conflict{Key: b.Key, UserCommand: command, TawCommand: b.Command})
+		}
+	}
+	return conflicts
+}
+
+// FreeAltKeys returns the Alt+letter and Alt+digit keys bound neither by TAW
+// nor by the user, to move shadowed user bindings to.
+func FreeAltKeys(taw []BindOpts, user map[string]string) []string {
+	used := make(map[string]bool)
+	for _, b := range taw {
+		used[b.Key] = true
+	}
+
+	var free []string
+	for _, r := range "abcdefghijklmnopqrstuvwxyz0123456789" {
+		key := "M-" + string(r)
+		if _, ok := user[key]; !ok && !used[key] {
+			free = append(free, key)
+		}
+	}
+	return free
+}
@@ -0,0 +1,81 @@
+// Package tmux provides an interface for interacting with tmux.
+package tmux
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// display-popup was added in tmux 3.2, and commands given as arguments
+// instead of a shell command string in tmux 3.0.
+const (
+	popupMajor = 3
+	popupMinor = 2
+	argvMajor  = 3
+)
+
+// versionPattern matches the version in "tmux 3.3a" or "tmux next-3.4".
+var versionPattern = regexp.MustCompile(`(\d+)\.(\d+)`)
+
+var (
+	versionOnce  sync.Once
+	versionText  string
+	versionMajor int
+	versionMinor int
+	versionKnown bool
+)
+
+// Version returns the installed tmux version as reported by tmux -V, e.g.
+// "tmux 3.3a", or an empty string if tmux couldn't be run.
+func Version() string {
+	detectVersion()
+	return versionText
+}
+
+// SupportsPopup returns true if the installed tmux has display-popup. An
+// unrecognized version (e.g. a build from master) is assumed to have it.
+func SupportsPopup() bool {
+	detectVersion()
+	if !versionKnown {
+		return true
+	}
+	return versionMajor > popupMajor || (versionMajor == popupMajor && versionMinor >= popupMinor)
+}
+
+// SupportsArgv returns true if the installed tmux runs a command given as
+// several arguments directly, without passing it to default-shell. An
+// unrecognized version is assumed to.
+func SupportsArgv() bool {
+	detectVersion()
+	return !versionKnown || versionMajor >= argvMajor
+}
+
+// PopupWarning returns a warning if popups fall back to panes and windows,
+// or an empty string if the installed tmux has display-popup.
+func PopupWarning() string {
+	if SupportsPopup() {
+		return ""
+	}
+	return fmt.Sprintf("%s has no popups (needs tmux %d.%d+): help, log, shell and quick task open in split panes or windows instead",
+		versionText, popupMajor, popupMinor)
+}
+
+// detectVersion runs tmux -V once per process.
+func detectVersion() {
+	versionOnce.Do(func() {
+		out, err := exec.Command("tmux", "-V").Output()
+		if err != nil {
+			return
+		}
+		versionText = strings.TrimSpace(string(out))
+		if m := versionPattern.FindStringSubmatch(versionText); m != nil {
+			versionMajor, _ = strconv.Atoi(m[1])
+			versionMinor, _ = strconv.Atoi(m[2])
+			versionKnown = true
+		}
+	})
+}
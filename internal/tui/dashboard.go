@@ -0,0 +1,282 @@
+// Package tui provides terminal user interface components for TAW.
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/donghojung/taw/internal/constants"
+	"github.com/donghojung/taw/internal/icon"
+	"github.com/donghojung/taw/internal/task"
+)
+
+// Dashboard shows the tasks, the queue and pending remote actions of a
+// project, refreshed periodically. It runs in the dashboard window, where the
+// agent of the selected task can be nudged or restarted, and a done task
+// hibernated or opened again.
+type Dashboard struct {
+	mgr     *task.Manager
+	reopen  func(*task.Task) error
+	queue   *task.QueueManager
+	outbox  *task.Outbox
+	tasks   []*task.Task
+	queued  []task.QueuedTask
+	actions []task.OutboxAction
+	updated time.Time
+	err     error
+	cursor  int
+	message string
+	owner   string // Only tasks of this user are shown, "" for all
+}
+
+// dashboardMsg carries freshly loaded dashboard data.
+type dashboardMsg struct {
+	tasks   []*task.Task
+	queued  []task.QueuedTask
+	actions []task.OutboxAction
+	err     error
+}
+
+// dashboardTickMsg is sent when the dashboard should reload.
+type dashboardTickMsg time.Time
+
+// dashboardActionMsg reports the outcome of nudging or restarting an agent.
+type dashboardActionMsg string
+
+// NewDashboard creates a new dashboard. reopen opens a hibernated task again.
+func NewDashboard(mgr *task.Manager, queue *task.QueueManager, outbox *task.Outbox, reopen func(*task.Task) error) *Dashboard {
+	return &Dashboard{
+		mgr:    mgr,
+		reopen: reopen,
+		queue:  queue,
+		outbox: outbox,
+	}
+}
+
+// Init initializes the dashboard.
+func (m *Dashboard) Init() tea.Cmd {
+	return m.load()
+}
+
+// Update handles messages and updates the model.
+func (m *Dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q":
+			return m, tea.Quit
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.tasks)-1 {
+				m.cursor++
+			}
+		case "n":
+			if t := m.selected(); t != nil {
+				return m, m.act(t, "Nudged", m.mgr.NudgeAgent)
+			}
+		case "r":
+			if t := m.selected(); t != nil {
+				return m, m.act(t, "Restarted", m.mgr.RestartAgent)
+			}
+		case "h":
+			if t := m.selected(); t != nil {
+				return m, m.hibernate(t)
+			}
+		case "o":
+			if m.owner == "" {
+				m.owner = m.mgr.CurrentUser(context.Background())
+			} else {
+				m.owner = ""
+			}
+			return m, m.load()
+		}
+
+	case dashboardMsg:
+		m.tasks = task.FilterOwner(msg.tasks, m.owner)
+		m.queued = msg.queued
+		m.actions = msg.actions
+		m.err = msg.err
+		m.updated = time.Now()
+		if m.cursor >= len(m.tasks) {
+			m.cursor = max(len(m.tasks)-1, 0)
+		}
+		return m, m.tick()
+
+	case dashboardActionMsg:
+		m.message = string(msg)
+
+	case dashboardTickMsg:
+		return m, m.load()
+	}
+
+	return m, nil
+}
+
+// View renders the dashboard.
+func (m *Dashboard) View() string {
+	var sb strings.Builder
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("39"))
+
+	headerStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("252"))
+
+	descStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("240"))
+
+	errStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("196"))
+
+	sb.WriteString("\n")
+	sb.WriteString(titleStyle.Render(icon.Decoration("📊") + "Dashboard"))
+	sb.WriteString("\n\n")
+
+	if m.err != nil {
+		sb.WriteString(errStyle.Render(fmt.Sprintf("Failed to load tasks: %v", m.err)))
+		sb.WriteString("\n\n")
+	}
+
+	title := fmt.Sprintf("Tasks (%d)", len(m.tasks))
+	if m.owner != "" {
+		title = fmt.Sprintf("Tasks of %s (%d)", m.owner, len(m.tasks))
+	}
+	sb.WriteString(headerStyle.Render(title))
+	sb.WriteString("\n")
+	if len(m.tasks) == 0 {
+		sb.WriteString(descStyle.Render("  (none)") + "\n")
+	}
+	for i, t := range m.tasks {
+		cursor := "  "
+		if i == m.cursor {
+			cursor = icon.Cursor.String() + " "
+		}
+		status := string(t.Status)
+		if t.IsStuck() {
+			status = "stuck?"
+		} else if t.IsHibernated() {
+			status = "hibernated"
+		}
+		owner := t.Owner
+		if owner == "" {
+			owner = "-"
+		}
+		line := fmt.Sprintf("%s%-4s %-32s %-11s %-16s %6s", cursor, t.StatusIcon(), t.Name, status, owner,
+			task.FormatDuration(time.Since(t.CreatedAt)))
+		if waiting := t.LoadTimeline().TimeWaiting(time.Now()); waiting >= time.Minute {
+			line += "  waited " + task.FormatDuration(waiting)
+		}
+		if t.PRNumber > 0 {
+			line += fmt.Sprintf("  PR #%d", t.PRNumber)
+		}
+		if result := t.LoadVerifyResult(); result != nil {
+			line += "  verify: " + result.Summary()
+		}
+		sb.WriteString(line + "\n")
+	}
+
+	sb.WriteString("\n")
+	sb.WriteString(headerStyle.Render(fmt.Sprintf("Queue: %d task(s) waiting", len(m.queued))))
+	sb.WriteString("\n")
+	for i := 0; i < len(m.queued) && i < constants.QueuePreviewCount; i++ {
+		sb.WriteString(fmt.Sprintf("  %d. %s\n", i+1, m.queued[i].Title()))
+	}
+	if len(m.queued) > constants.QueuePreviewCount {
+		sb.WriteString(descStyle.Render(fmt.Sprintf("  ... and %d more", len(m.queued)-constants.QueuePreviewCount)) + "\n")
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString(headerStyle.Render(fmt.Sprintf("Outbox (%d)", len(m.actions))))
+	sb.WriteString("\n")
+	for _, a := range m.actions {
+		state := "due now"
+		if a.GaveUp() {
+			state = "gave up"
+		} else if wait := time.Until(a.NextAttempt); wait > 0 {
+			state = fmt.Sprintf("retry in %s", wait.Round(time.Second))
+		}
+		sb.WriteString(fmt.Sprintf("  %-10s %-32s %s\n", a.Kind, a.TaskName, state))
+	}
+
+	if m.message != "" {
+		sb.WriteString("\n")
+		sb.WriteString(m.message)
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("\n")
+	sb.WriteString(descStyle.Render(fmt.Sprintf("Updated %s  ↑/↓: Select  n: Nudge agent  r: Restart agent  h: Hibernate/open  o: My tasks/all  q: Quit", m.updated.Format("15:04:05"))))
+
+	return sb.String()
+}
+
+// load reads the tasks, queue and outbox.
+func (m *Dashboard) load() tea.Cmd {
+	return func() tea.Msg {
+		tasks, err := m.mgr.ListTasks()
+		queued, _ := m.queue.List()
+		actions, _ := m.outbox.List()
+		return dashboardMsg{tasks: tasks, queued: queued, actions: actions, err: err}
+	}
+}
+
+// selected returns the selected task, or nil if there are none.
+func (m *Dashboard) selected() *task.Task {
+	if m.cursor < 0 || m.cursor >= len(m.tasks) {
+		return nil
+	}
+	return m.tasks[m.cursor]
+}
+
+// act runs fn on the agent of a task and reports the outcome.
+func (m *Dashboard) act(t *task.Task, done string, fn func(*task.Task) error) tea.Cmd {
+	return func() tea.Msg {
+		if err := fn(t); err != nil {
+			return dashboardActionMsg(fmt.Sprintf("%s %s: %v", icon.Failure, t.Name, err))
+		}
+		return dashboardActionMsg(fmt.Sprintf("%s %s the agent of %s", icon.Success, done, t.Name))
+	}
+}
+
+// hibernate hibernates a done task to reclaim its worktree's disk space, or
+// opens a hibernated one again.
+func (m *Dashboard) hibernate(t *task.Task) tea.Cmd {
+	return func() tea.Msg {
+		if t.IsHibernated() {
+			if err := m.reopen(t); err != nil {
+				return dashboardActionMsg(fmt.Sprintf("%s %s: %v", icon.Failure, t.Name, err))
+			}
+			return dashboardActionMsg(fmt.Sprintf("%s Opening %s", icon.Success, t.Name))
+		}
+		size, err := m.mgr.HibernateTask(context.Background(), t)
+		if err != nil {
+			return dashboardActionMsg(fmt.Sprintf("%s %s: %v", icon.Failure, t.Name, err))
+		}
+		return dashboardActionMsg(fmt.Sprintf("%s Hibernated %s, reclaimed %s", icon.Success, t.Name, task.FormatSize(size)))
+	}
+}
+
+// tick returns a command that asks for a reload after the refresh interval.
+func (m *Dashboard) tick() tea.Cmd {
+	return tea.Tick(constants.DashboardRefreshInterval, func(t time.Time) tea.Msg {
+		return dashboardTickMsg(t)
+	})
+}
+
+// RunDashboard runs the dashboard until the user quits.
+func RunDashboard(mgr *task.Manager, queue *task.QueueManager, outbox *task.Outbox, reopen func(*task.Task) error) error {
+	m := NewDashboard(mgr, queue, outbox, reopen)
+	p := tea.NewProgram(m, tea.WithAltScreen())
+	_, err := p.Run()
+	return err
+}
@@ -0,0 +1,371 @@
+// Package tui provides terminal user interface components for TAW.
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// DiffViewer shows a diff, unified or side by side, with the lines colored
+// by what they are.
+type DiffViewer struct {
+	load       func() (string, error)
+	lines      []string  // The unified diff
+	rows       []diffRow // The side-by-side diff
+	sideBySide bool
+	scrollPos  int
+	width      int
+	height     int
+	err        error
+}
+
+// diffRow is a row of a side-by-side diff: a line of the old and of the new
+// version, or a header across both.
+type diffRow struct {
+	header      string
+	left, right string
+	lkind       byte // '-' removed, ' ' unchanged, 0 nothing
+	rkind       byte // '+' added, ' ' unchanged, 0 nothing
+}
+
+// diffLoadedMsg carries a loaded diff.
+type diffLoadedMsg struct {
+	diff string
+}
+
+var (
+	diffAddStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	diffDelStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	diffHunkStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+	diffFileStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("3"))
+	diffMetaStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("244"))
+	diffStatusStyle = lipgloss.NewStyle().Background(lipgloss.Color("240")).Foreground(lipgloss.Color("252"))
+)
+
+// NewDiffViewer creates a diff viewer showing the diff load returns.
+func NewDiffViewer(load func() (string, error)) *DiffViewer {
+	return &DiffViewer{load: load}
+}
+
+// Init loads the diff.
+func (m *DiffViewer) Init() tea.Cmd {
+	return m.loadDiff()
+}
+
+// Update handles messages and updates the model.
+func (m *DiffViewer) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.scrollDown(0)
+		return m, nil
+
+	case diffLoadedMsg:
+		m.err = nil
+		m.lines = nil
+		if msg.diff != "" {
+			m.lines = strings.Split(strings.ReplaceAll(msg.diff, "\t", "    "), "\n")
+		}
+		m.rows = sideBySideRows(m.lines)
+		m.scrollDown(0)
+		return m, nil
+
+	case error:
+		m.err = msg
+		return m, nil
+	}
+
+	return m, nil
+}
+
+// handleKey handles keyboard input.
+func (m *DiffViewer) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "esc", "alt+g":
+		return m, tea.Quit
+
+	case "down", "j":
+		m.scrollDown(1)
+
+	case "up", "k":
+		m.scrollUp(1)
+
+	case "pgdown", " ":
+		m.scrollDown(m.contentHeight())
+
+	case "pgup":
+		m.scrollUp(m.contentHeight())
+
+	case "g":
+		m.scrollPos = 0
+
+	case "G":
+		m.scrollDown(m.rowCount())
+
+	case "n":
+		m.jumpFile(1)
+
+	case "N":
+		m.jumpFile(-1)
+
+	case "s":
+		// Stay on the same file
+		file := m.currentFile()
+		m.sideBySide = !m.sideBySide
+		m.scrollPos = 0
+		for i := 0; i < file; i++ {
+			m.jumpFile(1)
+		}
+
+	case "r":
+		return m, m.loadDiff()
+	}
+
+	return m, nil
+}
+
+// loadDiff loads the diff in the background.
+func (m *DiffViewer) loadDiff() tea.Cmd {
+	return func() tea.Msg {
+		diff, err := m.load()
+		if err != nil {
+			return err
+		}
+		return diffLoadedMsg{diff: diff}
+	}
+}
+
+// rowCount returns the number of rows of the current layout.
+func (m *DiffViewer) rowCount() int {
+	if m.sideBySide {
+		return len(m.rows)
+	}
+	return len(m.lines)
+}
+
+// isFileStart returns true if row i of the current layout starts a file.
+func (m *DiffViewer) isFileStart(i int) bool {
+	if m.sideBySide {
+		return strings.HasPrefix(m.rows[i].header, "diff --git ")
+	}
+	return strings.HasPrefix(m.lines[i], "diff --git ")
+}
+
+// currentFile returns how many files start above the top row.
+func (m *DiffViewer) currentFile() int {
+	n := 0
+	for i := 1; i <= m.scrollPos && i < m.rowCount(); i++ {
+		if m.isFileStart(i) {
+			n++
+		}
+	}
+	return n
+}
+
+// jumpFile scrolls to the start of the next (dir 1) or previous (dir -1) file.
+func (m *DiffViewer) jumpFile(dir int) {
+	for i := m.scrollPos + dir; i >= 0 && i < m.rowCount(); i += dir {
+		if m.isFileStart(i) {
+			m.scrollPos = 0
+			m.scrollDown(i)
+			return
+		}
+	}
+}
+
+// scrollUp scrolls up by n rows.
+func (m *DiffViewer) scrollUp(n int) {
+	m.scrollPos -= n
+	if m.scrollPos < 0 {
+		m.scrollPos = 0
+	}
+}
+
+// scrollDown scrolls down by n rows, keeping the last page full.
+func (m *DiffViewer) scrollDown(n int) {
+	max := m.rowCount() - m.contentHeight()
+	if max < 0 {
+		max = 0
+	}
+	m.scrollPos += n
+	if m.scrollPos > max {
+		m.scrollPos = max
+	}
+}
+
+// contentHeight returns the height available for the diff.
+func (m *DiffViewer) contentHeight() int {
+	// Reserve 1 line for status bar
+	h := m.height - 1
+	if h < 1 {
+		h = 1
+	}
+	return h
+}
+
+// View renders the diff viewer.
+func (m *DiffViewer) View() string {
+	if m.err != nil {
+		return fmt.Sprintf("Error: %v\n\nPress r to retry, q or Esc to close.", m.err)
+	}
+	if m.width == 0 || m.height == 0 {
+		return "Loading..."
+	}
+
+	var sb strings.Builder
+	end := m.scrollPos + m.contentHeight()
+	if end > m.rowCount() {
+		end = m.rowCount()
+	}
+	for i := m.scrollPos; i < end; i++ {
+		if m.sideBySide {
+			sb.WriteString(m.renderRow(m.rows[i]))
+		} else {
+			sb.WriteString(renderDiffLine(m.lines[i], m.width))
+		}
+		sb.WriteString("\n")
+	}
+	for i := end - m.scrollPos; i < m.contentHeight(); i++ {
+		sb.WriteString("\n")
+	}
+
+	status := " "
+	if m.sideBySide {
+		status = " [SPLIT] "
+	}
+	if m.rowCount() > 0 {
+		status += fmt.Sprintf("Lines %d-%d of %d ", m.scrollPos+1, end, m.rowCount())
+	} else {
+		status += "No changes "
+	}
+	hint := "↑↓:scroll n/N:file s:split r:refresh q:close"
+	padding := m.width - len([]rune(status)) - len([]rune(hint))
+	if padding < 0 {
+		hint = "q:close"
+		padding = m.width - len([]rune(status)) - len(hint)
+		if padding < 0 {
+			padding = 0
+		}
+	}
+	sb.WriteString(diffStatusStyle.Render(status + strings.Repeat(" ", padding) + hint))
+	return sb.String()
+}
+
+// renderDiffLine colors a line of a unified diff by its kind.
+func renderDiffLine(line string, width int) string {
+	text := truncate(line, width)
+	switch {
+	case strings.HasPrefix(line, "diff --git "):
+		return diffFileStyle.Render(text)
+	case strings.HasPrefix(line, "+++ "), strings.HasPrefix(line, "--- "),
+		strings.HasPrefix(line, "index "), strings.HasPrefix(line, "new file"),
+		strings.HasPrefix(line, "deleted file"), strings.HasPrefix(line, "rename "),
+		strings.HasPrefix(line, "similarity "), strings.HasPrefix(line, "Binary "):
+		return diffMetaStyle.Render(text)
+	case strings.HasPrefix(line, "@@"):
+		return diffHunkStyle.Render(text)
+	case strings.HasPrefix(line, "+"):
+		return diffAddStyle.Render(text)
+	case strings.HasPrefix(line, "-"):
+		return diffDelStyle.Render(text)
+	}
+	return text
+}
+
+// renderRow renders a row of the side-by-side diff.
+func (m *DiffViewer) renderRow(r diffRow) string {
+	if r.header != "" || r.lkind == 0 && r.rkind == 0 {
+		return renderDiffLine(r.header, m.width)
+	}
+	half := (m.width - 3) / 2
+	if half < 1 {
+		half = 1
+	}
+	side := func(text string, kind byte) string {
+		text = truncate(text, half)
+		text += strings.Repeat(" ", half-len([]rune(text)))
+		switch kind {
+		case '-':
+			return diffDelStyle.Render(text)
+		case '+':
+			return diffAddStyle.Render(text)
+		}
+		return text
+	}
+	return side(r.left, r.lkind) + diffMetaStyle.Render(" │ ") + side(r.right, r.rkind)
+}
+
+// sideBySideRows lays out a unified diff side by side: removed lines on the
+// left next to the lines added in their place on the right.
+func sideBySideRows(lines []string) []diffRow {
+	var rows []diffRow
+	var removed, added []string
+	flush := func() {
+		for i := 0; i < len(removed) || i < len(added); i++ {
+			var r diffRow
+			if i < len(removed) {
+				r.left, r.lkind = removed[i], '-'
+			}
+			if i < len(added) {
+				r.right, r.rkind = added[i], '+'
+			}
+			rows = append(rows, r)
+		}
+		removed, added = nil, nil
+	}
+
+	inHunk := false
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			inHunk = false
+			rows = append(rows, diffRow{header: line})
+		case strings.HasPrefix(line, "@@"):
+			flush()
+			inHunk = true
+			rows = append(rows, diffRow{header: line})
+		case !inHunk:
+			// File metadata such as index and ---/+++ lines
+			rows = append(rows, diffRow{header: line})
+		case strings.HasPrefix(line, "\\"):
+			// "\ No newline at end of file"
+			continue
+		case strings.HasPrefix(line, "-"):
+			removed = append(removed, line[1:])
+		case strings.HasPrefix(line, "+"):
+			added = append(added, line[1:])
+		default:
+			flush()
+			text := strings.TrimPrefix(line, " ")
+			rows = append(rows, diffRow{left: text, right: text, lkind: ' ', rkind: ' '})
+		}
+	}
+	flush()
+	return rows
+}
+
+// truncate cuts s to at most n runes.
+func truncate(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
+
+// RunDiffViewer runs the diff viewer for the diff load returns.
+func RunDiffViewer(load func() (string, error)) error {
+	p := tea.NewProgram(NewDiffViewer(load), tea.WithAltScreen())
+	_, err := p.Run()
+	return err
+}
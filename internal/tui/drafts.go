@@ -0,0 +1,133 @@
+// Package tui provides terminal user interface components for TAW.
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/donghojung/taw/internal/icon"
+	"github.com/donghojung/taw/internal/task"
+)
+
+// DraftPicker lets the user compare competing drafts and pick a winner.
+type DraftPicker struct {
+	group     string
+	drafts    []task.DraftSummary
+	cursor    int
+	done      bool
+	cancelled bool
+}
+
+// NewDraftPicker creates a new draft picker.
+func NewDraftPicker(group string, drafts []task.DraftSummary) *DraftPicker {
+	return &DraftPicker{
+		group:  group,
+		drafts: drafts,
+	}
+}
+
+// Init initializes the draft picker.
+func (m *DraftPicker) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages and updates the model.
+func (m *DraftPicker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q", "esc":
+			m.cancelled = true
+			m.done = true
+			return m, tea.Quit
+
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+
+		case "down", "j":
+			if m.cursor < len(m.drafts)-1 {
+				m.cursor++
+			}
+
+		case "enter", " ":
+			m.done = true
+			return m, tea.Quit
+		}
+	}
+
+	return m, nil
+}
+
+// View renders the draft picker.
+func (m *DraftPicker) View() string {
+	var sb strings.Builder
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("220"))
+
+	descStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("240"))
+
+	selectedStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("39")).
+		Bold(true)
+
+	normalStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("252"))
+
+	sb.WriteString("\n")
+	sb.WriteString(titleStyle.Render(fmt.Sprintf("%sCompeting Drafts: %s", icon.Decoration("🏁"), m.group)))
+	sb.WriteString("\n\n")
+	sb.WriteString("Pick the draft to keep. The others will be discarded.\n\n")
+
+	for i, d := range m.drafts {
+		cursor := "  "
+		style := normalStyle
+		if i == m.cursor {
+			cursor = icon.Cursor.String() + " "
+			style = selectedStyle
+		}
+
+		state := icon.Done.String()
+		if !d.Done {
+			state = icon.Working.String()
+		}
+
+		sb.WriteString(cursor + state + " " + style.Render(d.Task.Name) + "\n")
+		sb.WriteString("    " + descStyle.Render(d.DiffStat) + "\n")
+		sb.WriteString("    " + descStyle.Render(fmt.Sprintf("tests: %s  cost: %s", d.Tests, d.Cost)) + "\n")
+	}
+
+	sb.WriteString("\n")
+	sb.WriteString(descStyle.Render("↑/↓: Navigate  Enter: Pick  q: Cancel"))
+
+	return sb.String()
+}
+
+// Result returns the chosen draft, or nil if cancelled.
+func (m *DraftPicker) Result() *task.Task {
+	if m.cancelled || len(m.drafts) == 0 {
+		return nil
+	}
+	return m.drafts[m.cursor].Task
+}
+
+// RunDraftPicker runs the draft picker and returns the chosen draft.
+func RunDraftPicker(group string, drafts []task.DraftSummary) (*task.Task, error) {
+	m := NewDraftPicker(group, drafts)
+	p := tea.NewProgram(m)
+
+	finalModel, err := p.Run()
+	if err != nil {
+		return nil, err
+	}
+
+	picker := finalModel.(*DraftPicker)
+	return picker.Result(), nil
+}
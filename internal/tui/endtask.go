@@ -2,12 +2,15 @@
 package tui
 
 import (
+	"context"
 	"fmt"
 	"strings"
-	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/donghojung/taw/internal/icon"
+	"github.com/donghojung/taw/internal/task"
 )
 
 // StepStatus represents the status of a step.
@@ -23,13 +26,17 @@ const (
 
 // Step represents a step in the end task process.
 type Step struct {
-	Name    string
-	Status  StepStatus
-	Message string
+	Name         string
+	Status       StepStatus
+	Message      string
+	AllowFailure bool                                           // A failure is shown but doesn't stop the process
+	Run          func(ctx context.Context) (StepStatus, string) // Executes the step; nil steps succeed immediately
 }
 
 // EndTaskUI provides UI for the end task process.
 type EndTaskUI struct {
+	ctx         context.Context
+	cancel      context.CancelFunc
 	taskName    string
 	steps       []Step
 	currentStep int
@@ -46,25 +53,17 @@ type stepCompleteMsg struct {
 	message string
 }
 
-// NewEndTaskUI creates a new end task UI.
-func NewEndTaskUI(taskName string, isGitRepo bool) *EndTaskUI {
-	steps := []Step{}
-
-	if isGitRepo {
-		steps = append(steps,
-			Step{Name: "Check uncommitted changes", Status: StepPending},
-			Step{Name: "Commit changes", Status: StepPending},
-			Step{Name: "Push to remote", Status: StepPending},
-			Step{Name: "Check merge status", Status: StepPending},
-		)
+// NewEndTaskUI creates a new end task UI that runs the given steps in order.
+// Quitting cancels the running step through ctx.
+func NewEndTaskUI(ctx context.Context, taskName string, steps []Step) *EndTaskUI {
+	for i := range steps {
+		steps[i].Status = StepPending
 	}
 
-	steps = append(steps,
-		Step{Name: "Cleanup task", Status: StepPending},
-		Step{Name: "Close window", Status: StepPending},
-	)
-
+	ctx, cancel := context.WithCancel(ctx)
 	return &EndTaskUI{
+		ctx:      ctx,
+		cancel:   cancel,
 		taskName: taskName,
 		steps:    steps,
 	}
@@ -81,7 +80,15 @@ func (m *EndTaskUI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "ctrl+c", "q":
+			m.cancel()
 			return m, tea.Quit
+
+		case "r":
+			// Retry the failed step
+			if m.done && m.currentStep < len(m.steps) && m.steps[m.currentStep].Status == StepFail {
+				m.done = false
+				return m, m.runNextStep()
+			}
 		}
 
 	case tea.WindowSizeMsg:
@@ -92,7 +99,7 @@ func (m *EndTaskUI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.steps[msg.index].Status = msg.status
 		m.steps[msg.index].Message = msg.message
 
-		if msg.status == StepFail {
+		if msg.status == StepFail && !m.steps[msg.index].AllowFailure {
 			m.done = true
 			return m, nil
 		}
@@ -141,54 +148,54 @@ func (m *EndTaskUI) View() string {
 	sb.WriteString(titleStyle.Render(fmt.Sprintf("Ending task: %s", m.taskName)))
 	sb.WriteString("\n\n")
 
-	for i, step := range m.steps {
-		var icon string
+	for _, step := range m.steps {
+		var marker icon.Icon
 		var style lipgloss.Style
 
 		switch step.Status {
 		case StepOK:
-			icon = "✓"
+			marker = icon.Success
 			style = okStyle
 		case StepSkip:
-			icon = "○"
+			marker = icon.Pending
 			style = skipStyle
 		case StepFail:
-			icon = "✗"
+			marker = icon.Failure
 			style = failStyle
 		case StepRunning:
-			icon = "●"
+			marker = icon.Running
 			style = runningStyle
 		default:
-			icon = "○"
+			marker = icon.Pending
 			style = pendingStyle
 		}
 
-		line := fmt.Sprintf(" %s %s", icon, step.Name)
+		line := fmt.Sprintf(" %s %s", marker, step.Name)
 		sb.WriteString(style.Render(line))
 
 		if step.Message != "" {
 			sb.WriteString(skipStyle.Render(fmt.Sprintf(" (%s)", step.Message)))
 		}
+		if step.Status == StepFail && step.AllowFailure {
+			sb.WriteString(skipStyle.Render(" [allowed]"))
+		}
 
 		sb.WriteString("\n")
-
-		_ = i
 	}
 
 	if m.done {
 		sb.WriteString("\n")
 		if m.err != nil {
 			sb.WriteString(failStyle.Render(fmt.Sprintf("Error: %v", m.err)))
-		} else {
-			allOK := true
-			for _, step := range m.steps {
-				if step.Status == StepFail {
-					allOK = false
-					break
-				}
+			if hint := task.Hint(m.err); hint != "" {
+				sb.WriteString("\n")
+				sb.WriteString(skipStyle.Render(hint))
 			}
-			if allOK {
+		} else {
+			if m.Passed() {
 				sb.WriteString(okStyle.Render("Done!"))
+			} else {
+				sb.WriteString(skipStyle.Render("r: Retry failed step  q: Quit"))
 			}
 		}
 		sb.WriteString("\n")
@@ -197,31 +204,62 @@ func (m *EndTaskUI) View() string {
 	return sb.String()
 }
 
+// Passed returns true if every step finished without a blocking failure.
+func (m *EndTaskUI) Passed() bool {
+	if m.err != nil {
+		return false
+	}
+	for _, step := range m.steps {
+		switch step.Status {
+		case StepOK, StepSkip:
+		case StepFail:
+			if !step.AllowFailure {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 // runNextStep runs the next step.
 func (m *EndTaskUI) runNextStep() tea.Cmd {
 	if m.currentStep >= len(m.steps) {
 		return nil
 	}
 
-	m.steps[m.currentStep].Status = StepRunning
+	index := m.currentStep
+	step := m.steps[index]
+	m.steps[index].Status = StepRunning
+	m.steps[index].Message = ""
 
+	ctx := m.ctx
 	return func() tea.Msg {
-		// Simulate step execution
-		// In real implementation, this would execute actual tasks
-		time.Sleep(500 * time.Millisecond)
+		if step.Run == nil {
+			return stepCompleteMsg{index: index, status: StepOK}
+		}
 
+		status, message := step.Run(ctx)
 		return stepCompleteMsg{
-			index:   m.currentStep,
-			status:  StepOK,
-			message: "",
+			index:   index,
+			status:  status,
+			message: message,
 		}
 	}
 }
 
-// RunEndTaskUI runs the end task UI.
-func RunEndTaskUI(taskName string, isGitRepo bool) error {
-	m := NewEndTaskUI(taskName, isGitRepo)
+// RunEndTaskUI runs the end task UI and reports whether all steps passed.
+func RunEndTaskUI(ctx context.Context, taskName string, steps []Step) (bool, error) {
+	m := NewEndTaskUI(ctx, taskName, steps)
+	defer m.cancel()
 	p := tea.NewProgram(m)
-	_, err := p.Run()
-	return err
+
+	finalModel, err := p.Run()
+	if err != nil {
+		return false, err
+	}
+
+	ui := finalModel.(*EndTaskUI)
+	return ui.Passed(), nil
 }
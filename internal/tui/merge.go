@@ -0,0 +1,320 @@
+// Package tui provides terminal user interface components for TAW.
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/donghojung/taw/internal/github"
+	"github.com/donghojung/taw/internal/icon"
+	"github.com/donghojung/taw/internal/task"
+)
+
+// MergeFunc merges a task into the main branch and ends it.
+type MergeFunc func(ctx context.Context, t *task.Task) error
+
+// mergeItem is a completed task, whether it's selected and its progress.
+type mergeItem struct {
+	candidate task.MergeCandidate
+	selected  bool
+	status    StepStatus
+	message   string
+}
+
+// MergeUI lists completed tasks, lets the user select the ones to merge,
+// then merges them one by one and sums up what happened.
+type MergeUI struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	items   []mergeItem
+	merge   MergeFunc
+	cursor  int
+	merging bool
+	current int
+	done    bool
+}
+
+// mergeDoneMsg is sent when a task has been merged, or failed to.
+type mergeDoneMsg struct {
+	index int
+	err   error
+}
+
+// NewMergeUI creates a new merge UI for the given candidates. Tasks whose
+// verification or checks failed start unselected. Quitting while merging
+// cancels the running merge through ctx.
+func NewMergeUI(ctx context.Context, candidates []task.MergeCandidate, merge MergeFunc) *MergeUI {
+	items := make([]mergeItem, len(candidates))
+	for i, c := range candidates {
+		items[i] = mergeItem{candidate: c, selected: c.Ready(), status: StepPending}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	return &MergeUI{
+		ctx:    ctx,
+		cancel: cancel,
+		items:  items,
+		merge:  merge,
+	}
+}
+
+// Init initializes the merge UI.
+func (m *MergeUI) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages and updates the model.
+func (m *MergeUI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" || m.done {
+			m.cancel()
+			return m, tea.Quit
+		}
+		if m.merging {
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "q", "esc":
+			return m, tea.Quit
+
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+
+		case "down", "j":
+			if m.cursor < len(m.items)-1 {
+				m.cursor++
+			}
+
+		case " ", "x":
+			m.items[m.cursor].selected = !m.items[m.cursor].selected
+
+		case "a":
+			// Select all, or none if all are selected
+			all := m.Selected() == len(m.items)
+			for i := range m.items {
+				m.items[i].selected = !all
+			}
+
+		case "enter":
+			if m.Selected() == 0 {
+				return m, nil
+			}
+			m.merging = true
+			return m, m.mergeNext()
+		}
+
+	case mergeDoneMsg:
+		item := &m.items[msg.index]
+		if msg.err != nil {
+			item.status = StepFail
+			item.message = msg.err.Error()
+		} else {
+			item.status = StepOK
+		}
+
+		m.current++
+		return m, m.mergeNext()
+	}
+
+	return m, nil
+}
+
+// mergeNext merges the next selected task. When all are done, the summary
+// stays on screen until a key is pressed.
+func (m *MergeUI) mergeNext() tea.Cmd {
+	for m.current < len(m.items) && !m.items[m.current].selected {
+		m.items[m.current].status = StepSkip
+		m.current++
+	}
+	if m.current >= len(m.items) {
+		m.done = true
+		return nil
+	}
+
+	index := m.current
+	t := m.items[index].candidate.Task
+	m.items[index].status = StepRunning
+
+	ctx := m.ctx
+	merge := m.merge
+	return func() tea.Msg {
+		return mergeDoneMsg{index: index, err: merge(ctx, t)}
+	}
+}
+
+// View renders the merge UI.
+func (m *MergeUI) View() string {
+	var sb strings.Builder
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("220"))
+
+	warningStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("196"))
+
+	descStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("240"))
+
+	selectedStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("39")).
+		Bold(true)
+
+	normalStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("252"))
+
+	okStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("40"))
+
+	runningStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("220"))
+
+	sb.WriteString("\n")
+	sb.WriteString(titleStyle.Render(fmt.Sprintf("%s  Merge Completed Tasks: %d done", icon.Done, len(m.items))))
+	sb.WriteString("\n\n")
+
+	for i, item := range m.items {
+		c := item.candidate
+		if m.merging {
+			var marker icon.Icon
+			style := descStyle
+			switch item.status {
+			case StepOK:
+				marker, style = icon.Success, okStyle
+			case StepFail:
+				marker, style = icon.Failure, warningStyle
+			case StepRunning:
+				marker, style = icon.Running, runningStyle
+			default:
+				marker = icon.Pending
+			}
+			line := fmt.Sprintf(" %s %s", marker, c.Task.Name)
+			if item.status == StepSkip {
+				line += ": skipped"
+			}
+			sb.WriteString(style.Render(line))
+			if item.message != "" {
+				sb.WriteString(descStyle.Render(fmt.Sprintf(" (%s)", item.message)))
+			}
+			sb.WriteString("\n")
+			continue
+		}
+
+		cursor := "  "
+		style := normalStyle
+		if i == m.cursor {
+			cursor = icon.Cursor.String() + " "
+			style = selectedStyle
+		}
+		check := "[ ]"
+		if item.selected {
+			check = "[x]"
+		}
+		sb.WriteString(cursor + check + " " + style.Render(c.Task.Name) + "\n")
+
+		stat := fmt.Sprintf("%d files, +%d -%d", c.Files, c.Insertions, c.Deletions)
+		if c.Files == 0 {
+			stat = "no changes"
+		}
+		sb.WriteString("      " + descStyle.Render(stat) + "  " + checkLabel("verify", verifyState(c.Verify), okStyle, warningStyle, descStyle))
+		if c.Task.PRNumber > 0 {
+			sb.WriteString("  " + checkLabel(fmt.Sprintf("PR #%d CI", c.Task.PRNumber), c.CI, okStyle, warningStyle, descStyle))
+		}
+		sb.WriteString("\n")
+	}
+
+	switch {
+	case m.done:
+		merged, failed, skipped := m.Summary()
+		sb.WriteString("\n")
+		summary := fmt.Sprintf("Merged %d, failed %d, skipped %d", merged, failed, skipped)
+		if failed > 0 {
+			sb.WriteString(warningStyle.Render(fmt.Sprintf("%s %s: failed tasks stay open, see .taw/log", icon.Failure, summary)))
+		} else {
+			sb.WriteString(okStyle.Render(fmt.Sprintf("%s %s", icon.Success, summary)))
+		}
+		sb.WriteString("\n")
+		sb.WriteString(descStyle.Render("Press any key to close"))
+	case !m.merging:
+		sb.WriteString("\n")
+		sb.WriteString(descStyle.Render(fmt.Sprintf("%d selected  ↑/↓: Task  Space: Select  a: All/None  Enter: Merge  q: Cancel", m.Selected())))
+	}
+
+	return sb.String()
+}
+
+// verifyState returns the state of a verification as a check state.
+func verifyState(result *task.VerifyResult) string {
+	switch {
+	case result == nil:
+		return github.ChecksNone
+	case result.Passed():
+		return github.ChecksPassing
+	default:
+		return github.ChecksFailing
+	}
+}
+
+// checkLabel renders a check with its state, colored by how it went.
+func checkLabel(name, state string, okStyle, failStyle, descStyle lipgloss.Style) string {
+	switch state {
+	case github.ChecksPassing:
+		return okStyle.Render(name + " " + icon.Success.String())
+	case github.ChecksFailing:
+		return failStyle.Render(name + " " + icon.Failure.String())
+	case github.ChecksPending:
+		return descStyle.Render(name + " " + icon.Running.String())
+	default:
+		return descStyle.Render(name + " -")
+	}
+}
+
+// Selected returns the number of selected tasks.
+func (m *MergeUI) Selected() int {
+	var selected int
+	for _, item := range m.items {
+		if item.selected {
+			selected++
+		}
+	}
+	return selected
+}
+
+// Summary returns the number of tasks merged, failed and skipped.
+func (m *MergeUI) Summary() (merged, failed, skipped int) {
+	for _, item := range m.items {
+		switch item.status {
+		case StepOK:
+			merged++
+		case StepFail:
+			failed++
+		case StepSkip:
+			skipped++
+		}
+	}
+	return merged, failed, skipped
+}
+
+// RunMergeUI runs the merge UI for the given candidates and merges the
+// selected ones. It returns the number of tasks that failed to merge.
+func RunMergeUI(ctx context.Context, candidates []task.MergeCandidate, merge MergeFunc) (int, error) {
+	m := NewMergeUI(ctx, candidates, merge)
+	defer m.cancel()
+	p := tea.NewProgram(m)
+
+	finalModel, err := p.Run()
+	if err != nil {
+		return 0, err
+	}
+
+	_, failed, _ := finalModel.(*MergeUI).Summary()
+	return failed, nil
+}
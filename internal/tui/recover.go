@@ -2,28 +2,64 @@
 package tui
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/donghojung/taw/internal/icon"
 	"github.com/donghojung/taw/internal/task"
 )
 
-// RecoverUI provides UI for recovering corrupted tasks.
+// RecoverFunc applies the chosen recovery action to a corrupted task.
+type RecoverFunc func(ctx context.Context, t *task.Task, action task.RecoveryAction) error
+
+// recoverActions are the per-task choices, in the order they're shown.
+var recoverActions = []task.RecoveryAction{task.RecoveryRecover, task.RecoveryCleanup, task.RecoverySkip}
+
+// recoverItem is a corrupted task with its chosen action and its progress.
+type recoverItem struct {
+	task    *task.Task
+	action  int // Index into recoverActions
+	status  StepStatus
+	message string
+}
+
+// RecoverUI lists corrupted tasks, lets the user choose to recover, clean up
+// or skip each one, then applies the choices one by one.
 type RecoverUI struct {
-	task       *task.Task
-	cursor     int
-	done       bool
-	cancelled  bool
-	action     task.RecoveryAction
+	ctx      context.Context
+	cancel   context.CancelFunc
+	items    []recoverItem
+	apply    RecoverFunc
+	cursor   int
+	applying bool
+	current  int
+	done     bool
+}
+
+// recoverDoneMsg is sent when the action of a task has been applied.
+type recoverDoneMsg struct {
+	index int
+	err   error
 }
 
-// NewRecoverUI creates a new recovery UI.
-func NewRecoverUI(t *task.Task) *RecoverUI {
+// NewRecoverUI creates a new recovery UI for the given corrupted tasks.
+// Quitting while applying cancels the running action through ctx.
+func NewRecoverUI(ctx context.Context, tasks []*task.Task, apply RecoverFunc) *RecoverUI {
+	items := make([]recoverItem, len(tasks))
+	for i, t := range tasks {
+		items[i] = recoverItem{task: t, status: StepPending}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
 	return &RecoverUI{
-		task: t,
+		ctx:    ctx,
+		cancel: cancel,
+		items:  items,
+		apply:  apply,
 	}
 }
 
@@ -36,10 +72,16 @@ func (m *RecoverUI) Init() tea.Cmd {
 func (m *RecoverUI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" || m.done {
+			m.cancel()
+			return m, tea.Quit
+		}
+		if m.applying {
+			return m, nil
+		}
+
 		switch msg.String() {
-		case "ctrl+c", "q":
-			m.cancelled = true
-			m.done = true
+		case "q", "esc":
 			return m, tea.Quit
 
 		case "up", "k":
@@ -48,28 +90,76 @@ func (m *RecoverUI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 		case "down", "j":
-			if m.cursor < 2 {
+			if m.cursor < len(m.items)-1 {
 				m.cursor++
 			}
 
-		case "enter", " ":
-			m.done = true
-			switch m.cursor {
-			case 0:
-				m.action = task.RecoveryRecover
-			case 1:
-				m.action = task.RecoveryCleanup
-			case 2:
-				m.action = task.RecoveryCancel
-				m.cancelled = true
-			}
-			return m, tea.Quit
+		case "left", "h":
+			item := &m.items[m.cursor]
+			item.action = (item.action + len(recoverActions) - 1) % len(recoverActions)
+
+		case "right", "l", "tab":
+			item := &m.items[m.cursor]
+			item.action = (item.action + 1) % len(recoverActions)
+
+		case "r":
+			m.items[m.cursor].action = 0
+		case "c":
+			m.items[m.cursor].action = 1
+		case "s":
+			m.items[m.cursor].action = 2
+
+		case "enter":
+			m.applying = true
+			return m, m.applyNext()
 		}
+
+	case recoverDoneMsg:
+		item := &m.items[msg.index]
+		if msg.err != nil {
+			item.status = StepFail
+			item.message = msg.err.Error()
+		} else {
+			item.status = StepOK
+		}
+
+		m.current++
+		return m, m.applyNext()
 	}
 
 	return m, nil
 }
 
+// applyNext applies the action of the next task, skipping the tasks left as
+// they are.
+func (m *RecoverUI) applyNext() tea.Cmd {
+	for m.current < len(m.items) && recoverActions[m.items[m.current].action] == task.RecoverySkip {
+		m.items[m.current].status = StepSkip
+		m.current++
+	}
+	if m.current >= len(m.items) {
+		m.done = true
+		// Keep failures on screen until a key is pressed
+		if m.Failed() > 0 {
+			return nil
+		}
+		return tea.Quit
+	}
+
+	index := m.current
+	item := m.items[index]
+	m.items[index].status = StepRunning
+
+	ctx := m.ctx
+	apply := m.apply
+	return func() tea.Msg {
+		return recoverDoneMsg{
+			index: index,
+			err:   apply(ctx, item.task, recoverActions[item.action]),
+		}
+	}
+}
+
 // View renders the recovery UI.
 func (m *RecoverUI) View() string {
 	var sb strings.Builder
@@ -91,66 +181,99 @@ func (m *RecoverUI) View() string {
 	normalStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("252"))
 
-	sb.WriteString("\n")
-	sb.WriteString(titleStyle.Render(fmt.Sprintf("⚠️  Task Recovery: %s", m.task.Name)))
-	sb.WriteString("\n\n")
+	okStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("40"))
 
-	// Show corruption details
-	sb.WriteString(warningStyle.Render("Problem: "))
-	sb.WriteString(task.GetRecoveryDescription(m.task.CorruptedReason))
-	sb.WriteString("\n\n")
+	runningStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("220"))
 
-	sb.WriteString(descStyle.Render("Recommended action: "))
-	sb.WriteString(task.GetRecoveryAction(m.task.CorruptedReason))
+	sb.WriteString("\n")
+	sb.WriteString(titleStyle.Render(fmt.Sprintf("%s  Task Recovery: %d corrupted task(s)", icon.Warning, len(m.items))))
 	sb.WriteString("\n\n")
 
-	// Options
-	sb.WriteString("Choose an action:\n\n")
+	for i, item := range m.items {
+		cursor := "  "
+		if !m.applying && i == m.cursor {
+			cursor = icon.Cursor.String() + " "
+		}
 
-	options := []struct {
-		name string
-		desc string
-	}{
-		{"Recover", "Attempt to fix the issue and continue the task"},
-		{"Cleanup", "Remove the corrupted task completely"},
-		{"Cancel", "Do nothing and exit"},
-	}
+		if m.applying {
+			var marker icon.Icon
+			style := descStyle
+			switch item.status {
+			case StepOK:
+				marker, style = icon.Success, okStyle
+			case StepFail:
+				marker, style = icon.Failure, warningStyle
+			case StepRunning:
+				marker, style = icon.Running, runningStyle
+			default:
+				marker = icon.Pending
+			}
+			sb.WriteString(style.Render(fmt.Sprintf(" %s %s: %s", marker, item.task.Name, recoverActions[item.action])))
+			if item.message != "" {
+				sb.WriteString(descStyle.Render(fmt.Sprintf(" (%s)", item.message)))
+			}
+			sb.WriteString("\n")
+			continue
+		}
 
-	for i, opt := range options {
-		cursor := "  "
-		style := normalStyle
-		if i == m.cursor {
-			cursor = "▸ "
-			style = selectedStyle
+		sb.WriteString(cursor + normalStyle.Bold(true).Render(item.task.Name) + "\n")
+		sb.WriteString("    " + warningStyle.Render("Problem: ") + task.GetRecoveryDescription(item.task.CorruptedReason) + "\n")
+		sb.WriteString("    " + descStyle.Render("Recommended: "+task.GetRecoveryAction(item.task.CorruptedReason)) + "\n")
+
+		var choices []string
+		for j, action := range recoverActions {
+			label := strings.ToUpper(string(action[:1])) + string(action[1:])
+			if j == item.action {
+				choices = append(choices, selectedStyle.Render("["+label+"]"))
+			} else {
+				choices = append(choices, descStyle.Render(" "+label+" "))
+			}
 		}
-		sb.WriteString(cursor + style.Render(opt.name) + "\n")
-		sb.WriteString("    " + descStyle.Render(opt.desc) + "\n")
+		sb.WriteString("    " + strings.Join(choices, " ") + "\n\n")
 	}
 
-	sb.WriteString("\n")
-	sb.WriteString(descStyle.Render("↑/↓: Navigate  Enter: Select  q: Cancel"))
+	switch {
+	case m.done && m.Failed() > 0:
+		sb.WriteString("\n")
+		sb.WriteString(warningStyle.Render(fmt.Sprintf("%s %d task(s) failed", icon.Failure, m.Failed())))
+		sb.WriteString("\n")
+		sb.WriteString(descStyle.Render("Press any key to continue"))
+	case m.done:
+		sb.WriteString("\n")
+		sb.WriteString(okStyle.Render("Done!"))
+		sb.WriteString("\n")
+	case !m.applying:
+		sb.WriteString(descStyle.Render("↑/↓: Task  ←/→ or r/c/s: Recover/Cleanup/Skip  Enter: Apply  q: Skip all"))
+	}
 
 	return sb.String()
 }
 
-// Result returns the chosen action.
-func (m *RecoverUI) Result() task.RecoveryAction {
-	if m.cancelled {
-		return task.RecoveryCancel
+// Failed returns the number of tasks whose action failed.
+func (m *RecoverUI) Failed() int {
+	var failed int
+	for _, item := range m.items {
+		if item.status == StepFail {
+			failed++
+		}
 	}
-	return m.action
+	return failed
 }
 
-// RunRecoverUI runs the recovery UI and returns the chosen action.
-func RunRecoverUI(t *task.Task) (task.RecoveryAction, error) {
-	m := NewRecoverUI(t)
+// RunRecoverUI runs the recovery UI for the given corrupted tasks and applies
+// the chosen actions. It returns the number of tasks whose action failed.
+func RunRecoverUI(ctx context.Context, tasks []*task.Task, apply RecoverFunc) (int, error) {
+	m := NewRecoverUI(ctx, tasks, apply)
+	defer m.cancel()
 	p := tea.NewProgram(m)
 
 	finalModel, err := p.Run()
 	if err != nil {
-		return task.RecoveryCancel, err
+		return 0, err
 	}
 
 	ui := finalModel.(*RecoverUI)
-	return ui.Result(), nil
+	return ui.Failed(), nil
 }
@@ -2,41 +2,238 @@
 package tui
 
 import (
+	"fmt"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
 	"github.com/donghojung/taw/internal/config"
+	"github.com/donghojung/taw/internal/icon"
 )
 
+// setupOption is a choice of a setup step.
+type setupOption struct {
+	value string
+	name  string
+	desc  string
+}
+
+// setupStep is a page of the setup wizard that sets one config setting,
+// either with a list of choices or, without options, a text input.
+type setupStep struct {
+	key          string
+	title        string
+	desc         string
+	options      []setupOption
+	gitOnly      bool
+	worktreeOnly bool
+}
+
+// presetKey is the key of the preset step, which sets several settings.
+const presetKey = "preset"
+
+// setupSteps are the pages of the setup wizard, before the review.
+var setupSteps = []setupStep{
+	{
+		key:     presetKey,
+		title:   "Preset",
+		desc:    "Start from a set of settings; each can be changed next",
+		options: presetOptions(),
+	},
+	{
+		key:     "work_mode",
+		title:   "Work Mode",
+		desc:    "Choose how tasks work with git",
+		gitOnly: true,
+		options: []setupOption{
+			{string(config.WorkModeWorktree), "worktree (Recommended)", "Each task gets its own git worktree"},
+			{string(config.WorkModeMain), "main", "All tasks work on the current branch"},
+		},
+	},
+	{
+		key:   "on_complete",
+		title: "On Complete Action",
+		desc:  "What happens when a task is completed",
+		options: []setupOption{
+			{string(config.OnCompleteConfirm), "confirm (Recommended)", "Ask before each action"},
+			{string(config.OnCompleteAutoCommit), "auto-commit", "Automatically commit changes"},
+			{string(config.OnCompleteAutoMerge), "auto-merge", "Auto commit + merge + cleanup"},
+			{string(config.OnCompleteAutoPR), "auto-pr", "Auto commit + create pull request"},
+		},
+	},
+	{
+		key:     "merge_strategy",
+		title:   "Merge Strategy",
+		desc:    "How a task branch is merged into main",
+		gitOnly: true,
+		options: []setupOption{
+			{string(config.MergeStrategyMerge), "merge (Recommended)", "Local --no-ff merge and push"},
+			{string(config.MergeStrategySquash), "squash", "Squash-merge the task's PR with gh"},
+			{string(config.MergeStrategyRebase), "rebase", "Rebase-merge the task's PR with gh"},
+			{string(config.MergeStrategyMergeQueue), "gh-merge-queue", "Add the task's PR to the merge queue"},
+		},
+	},
+	{
+		key:   "max_parallel_tasks",
+		title: "Max Parallel Tasks",
+		desc:  "How many agents run at once; more tasks wait in the queue",
+		options: []setupOption{
+			{"0", "unlimited (Recommended)", "Start every task right away"},
+			{"2", "2", "Two agents at a time"},
+			{"4", "4", "Four agents at a time"},
+			{"8", "8", "Eight agents at a time"},
+		},
+	},
+	{
+		key:   "model",
+		title: "Model",
+		desc:  "Which Claude model the agents use",
+		options: []setupOption{
+			{"", "default (Recommended)", "Claude's default model"},
+			{"opus", "opus", "Most capable, slower"},
+			{"sonnet", "sonnet", "Balanced"},
+			{"haiku", "haiku", "Fastest, for small tasks"},
+		},
+	},
+	{
+		key:          "branch_template",
+		title:        "Branch Template",
+		desc:         "Task branch name: {task} is the task name, {user} is $USER",
+		worktreeOnly: true,
+	},
+	{
+		key:          "worktree_root",
+		title:        "Worktree Root",
+		desc:         "Where task worktrees are created, e.g. ~/worktrees\nLeave empty to keep them in .taw/agents",
+		worktreeOnly: true,
+	},
+	{
+		key:   "notifications",
+		title: "Notifications",
+		desc:  "How you're told a task needs you or is done",
+		options: []setupOption{
+			{string(config.NotificationsNone), "none", "Only the window name shows the status"},
+			{string(config.NotificationsTmux), "tmux", "A message in the tmux status line"},
+			{string(config.NotificationsDesktop), "desktop", "A desktop notification and the tmux message"},
+		},
+	},
+}
+
+// presetOptions returns the options of the preset step.
+func presetOptions() []setupOption {
+	options := []setupOption{{"", "custom", "Keep the current settings"}}
+	for _, p := range config.Presets() {
+		options = append(options, setupOption{p.Name, p.Name, p.Description})
+	}
+	return options
+}
+
 // SetupWizard provides an interactive setup wizard.
 type SetupWizard struct {
-	step       int
-	workMode   config.WorkMode
-	onComplete config.OnComplete
+	base       config.Config // The config before a preset
+	cfg        *config.Config
+	preset     string
+	steps      []setupStep
+	step       int // len(steps) is the review
+	projectDir string
 	isGitRepo  bool
 	cursor     int
+	input      string
+	err        error
 	done       bool
 	cancelled  bool
 }
 
 // SetupResult contains the result of the setup wizard.
 type SetupResult struct {
-	WorkMode   config.WorkMode
-	OnComplete config.OnComplete
-	Cancelled  bool
+	Config    *config.Config // The chosen settings, on a copy of the given config
+	Preset    string         // The chosen preset, if any
+	Cancelled bool
 }
 
-// NewSetupWizard creates a new setup wizard.
-func NewSetupWizard(isGitRepo bool) *SetupWizard {
-	return &SetupWizard{
-		step:       0,
-		workMode:   config.WorkModeWorktree,
-		onComplete: config.OnCompleteConfirm,
+// NewSetupWizard creates a new setup wizard starting from the given config.
+// Presets detect the test command of the project in projectDir.
+func NewSetupWizard(cfg *config.Config, projectDir string, isGitRepo bool) *SetupWizard {
+	// Work on a copy, so cancelling leaves cfg as it was
+	m := &SetupWizard{
+		base:       *cfg,
+		projectDir: projectDir,
 		isGitRepo:  isGitRepo,
-		cursor:     0,
 	}
+	c := m.base
+	m.cfg = &c
+
+	// Keep a current value that isn't one of the usual choices
+	for _, s := range setupSteps {
+		if s.options != nil {
+			value := m.value(s.key)
+			if s.optionIndex(value) < 0 {
+				s.options = append(s.options, setupOption{value, value + " (current)", "Keep the current setting"})
+			}
+		}
+		m.steps = append(m.steps, s)
+	}
+
+	m.enterStep(m.nextShown(0, 1))
+	return m
+}
+
+// optionIndex returns the index of the option with the value, or -1.
+func (s setupStep) optionIndex(value string) int {
+	for i, opt := range s.options {
+		if opt.value == value {
+			return i
+		}
+	}
+	return -1
+}
+
+// shown returns true if the step applies to the project and the choices so far.
+func (m *SetupWizard) shown(i int) bool {
+	s := m.steps[i]
+	if (s.gitOnly || s.worktreeOnly) && !m.isGitRepo {
+		return false
+	}
+	return !s.worktreeOnly || m.cfg.WorkMode == config.WorkModeWorktree
+}
+
+// nextShown returns the first shown step from i in direction dir, the review
+// past the last step, or -1 before the first.
+func (m *SetupWizard) nextShown(i, dir int) int {
+	for ; i >= 0 && i < len(m.steps); i += dir {
+		if m.shown(i) {
+			return i
+		}
+	}
+	return i
+}
+
+// enterStep moves to a step with its cursor on the current value.
+func (m *SetupWizard) enterStep(i int) {
+	m.step = i
+	m.cursor = 0
+	m.err = nil
+	if i >= len(m.steps) {
+		return
+	}
+
+	s := m.steps[i]
+	value := m.value(s.key)
+	if s.options == nil {
+		m.input = value
+	} else if idx := s.optionIndex(value); idx >= 0 {
+		m.cursor = idx
+	}
+}
+
+// value returns the current value of a step's setting.
+func (m *SetupWizard) value(key string) string {
+	if key == presetKey {
+		return m.preset
+	}
+	value, _ := m.cfg.Get(key)
+	return value
 }
 
 // Init initializes the setup wizard.
@@ -46,33 +243,108 @@ func (m *SetupWizard) Init() tea.Cmd {
 
 // Update handles messages and updates the model.
 func (m *SetupWizard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
-			m.cancelled = true
-			m.done = true
-			return m, tea.Quit
-
-		case "up", "k":
-			if m.cursor > 0 {
-				m.cursor--
-			}
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	textInput := m.step < len(m.steps) && m.steps[m.step].options == nil
 
-		case "down", "j":
-			max := m.maxCursor()
-			if m.cursor < max {
-				m.cursor++
+	switch key.String() {
+	case "ctrl+c":
+		return m.cancel()
+	case "esc":
+		m.back()
+		return m, nil
+	case "enter":
+		return m.selectOption()
+	}
+
+	if textInput {
+		switch key.Type {
+		case tea.KeyBackspace:
+			if len(m.input) > 0 {
+				runes := []rune(m.input)
+				m.input = string(runes[:len(runes)-1])
 			}
+		case tea.KeyRunes, tea.KeySpace:
+			m.input += string(key.Runes)
+		}
+		m.err = nil
+		return m, nil
+	}
+
+	switch key.String() {
+	case "q":
+		return m.cancel()
+
+	case "left", "h":
+		m.back()
+
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
 
-		case "enter", " ":
-			return m.selectOption()
+	case "down", "j":
+		if m.step < len(m.steps) && m.cursor < len(m.steps[m.step].options)-1 {
+			m.cursor++
 		}
+
+	case " ", "right", "l":
+		return m.selectOption()
 	}
 
 	return m, nil
 }
 
+// cancel quits without a result.
+func (m *SetupWizard) cancel() (tea.Model, tea.Cmd) {
+	m.cancelled = true
+	m.done = true
+	return m, tea.Quit
+}
+
+// back returns to the previous shown step.
+func (m *SetupWizard) back() {
+	if prev := m.nextShown(m.step-1, -1); prev >= 0 {
+		m.enterStep(prev)
+	}
+}
+
+// selectOption sets the current step's setting and moves on, or finishes on
+// the review.
+func (m *SetupWizard) selectOption() (tea.Model, tea.Cmd) {
+	if m.step >= len(m.steps) {
+		m.done = true
+		return m, tea.Quit
+	}
+
+	s := m.steps[m.step]
+	value := strings.TrimSpace(m.input)
+	if s.options != nil {
+		value = s.options[m.cursor].value
+	}
+	if s.key == presetKey {
+		// Start over from the config before any preset
+		c := m.base
+		if value != "" {
+			if err := c.ApplyPreset(value, m.projectDir); err != nil {
+				m.err = err
+				return m, nil
+			}
+		}
+		m.cfg = &c
+		m.preset = value
+	} else if err := m.cfg.Set(s.key, value); err != nil {
+		m.err = err
+		return m, nil
+	}
+
+	m.enterStep(m.nextShown(m.step+1, 1))
+	return m, nil
+}
+
 // View renders the setup wizard.
 func (m *SetupWizard) View() string {
 	var sb strings.Builder
@@ -91,128 +363,133 @@ func (m *SetupWizard) View() string {
 	descStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("240"))
 
+	errorStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("196"))
+
 	sb.WriteString("\n")
-	sb.WriteString(titleStyle.Render("🚀 TAW Setup Wizard"))
+	sb.WriteString(titleStyle.Render(icon.Decoration("🚀") + "TAW Setup Wizard"))
+	sb.WriteString(descStyle.Render(fmt.Sprintf("  %d/%d", m.position(), m.shownCount()+1)))
 	sb.WriteString("\n\n")
 
-	switch m.step {
-	case 0:
-		if m.isGitRepo {
-			sb.WriteString("Work Mode:\n")
-			sb.WriteString(descStyle.Render("Choose how tasks work with git\n\n"))
-
-			options := []struct {
-				name string
-				desc string
-			}{
-				{"worktree (Recommended)", "Each task gets its own git worktree"},
-				{"main", "All tasks work on the current branch"},
-			}
-
-			for i, opt := range options {
-				cursor := "  "
-				style := normalStyle
-				if i == m.cursor {
-					cursor = "▸ "
-					style = selectedStyle
-				}
-				sb.WriteString(cursor + style.Render(opt.name) + "\n")
-				sb.WriteString("    " + descStyle.Render(opt.desc) + "\n")
+	if m.step >= len(m.steps) {
+		sb.WriteString("Review:\n")
+		sb.WriteString(descStyle.Render("Save these settings to .taw/config") + "\n\n")
+		for i, s := range m.steps {
+			if !m.shown(i) {
+				continue
 			}
-		} else {
-			// Skip work mode for non-git repos
-			m.step = 1
-			return m.View()
+			sb.WriteString(fmt.Sprintf("  %-20s %s\n", s.title+":", normalStyle.Render(m.display(s))))
+		}
+		for _, setting := range m.presetExtras() {
+			sb.WriteString(fmt.Sprintf("  %-20s %s\n", setting.Key+":", normalStyle.Render(setting.Value)))
 		}
+		sb.WriteString("\n")
+		sb.WriteString(descStyle.Render("Enter: Save  Esc/←: Back  q: Cancel"))
+		return sb.String()
+	}
 
-	case 1:
-		sb.WriteString("On Complete Action:\n")
-		sb.WriteString(descStyle.Render("What happens when a task is completed\n\n"))
-
-		options := []struct {
-			name string
-			desc string
-		}{
-			{"confirm (Recommended)", "Ask before each action"},
-			{"auto-commit", "Automatically commit changes"},
-			{"auto-merge", "Auto commit + merge + cleanup"},
-			{"auto-pr", "Auto commit + create pull request"},
+	s := m.steps[m.step]
+	sb.WriteString(s.title + ":\n")
+	sb.WriteString(descStyle.Render(s.desc) + "\n\n")
+
+	if s.options == nil {
+		sb.WriteString(icon.Cursor.String() + " " + selectedStyle.Render(m.input) + "█\n")
+		if m.err != nil {
+			sb.WriteString("\n" + errorStyle.Render(m.err.Error()) + "\n")
 		}
+		sb.WriteString("\n")
+		sb.WriteString(descStyle.Render("Enter: Next  Esc: Back  Ctrl+C: Cancel"))
+		return sb.String()
+	}
 
-		for i, opt := range options {
-			cursor := "  "
-			style := normalStyle
-			if i == m.cursor {
-				cursor = "▸ "
-				style = selectedStyle
-			}
-			sb.WriteString(cursor + style.Render(opt.name) + "\n")
-			sb.WriteString("    " + descStyle.Render(opt.desc) + "\n")
+	for i, opt := range s.options {
+		cursor := "  "
+		style := normalStyle
+		if i == m.cursor {
+			cursor = icon.Cursor.String() + " "
+			style = selectedStyle
 		}
+		sb.WriteString(cursor + style.Render(opt.name) + "\n")
+		sb.WriteString("    " + descStyle.Render(opt.desc) + "\n")
+	}
+	if m.err != nil {
+		sb.WriteString("\n" + errorStyle.Render(m.err.Error()) + "\n")
 	}
 
 	sb.WriteString("\n")
-	sb.WriteString(descStyle.Render("↑/↓: Navigate  Enter: Select  q: Cancel"))
+	sb.WriteString(descStyle.Render("↑/↓: Navigate  Enter: Select  Esc/←: Back  q: Cancel"))
 
 	return sb.String()
 }
 
-// maxCursor returns the maximum cursor position for the current step.
-func (m *SetupWizard) maxCursor() int {
-	switch m.step {
-	case 0:
-		return 1 // worktree, main
-	case 1:
-		return 3 // confirm, auto-commit, auto-merge, auto-pr
+// display returns a step's setting as shown on the review.
+func (m *SetupWizard) display(s setupStep) string {
+	value := m.value(s.key)
+	if idx := s.optionIndex(value); idx >= 0 {
+		return strings.TrimSuffix(s.options[idx].name, " (Recommended)")
+	}
+	if value == "" {
+		return "(none)"
 	}
-	return 0
+	return value
 }
 
-// selectOption handles option selection.
-func (m *SetupWizard) selectOption() (tea.Model, tea.Cmd) {
-	switch m.step {
-	case 0:
-		// Work mode
-		switch m.cursor {
-		case 0:
-			m.workMode = config.WorkModeWorktree
-		case 1:
-			m.workMode = config.WorkModeMain
+// presetExtras returns the settings the chosen preset changed that have no
+// step of their own.
+func (m *SetupWizard) presetExtras() []config.Setting {
+	if m.preset == "" {
+		return nil
+	}
+
+	steps := make(map[string]bool)
+	for _, s := range m.steps {
+		steps[s.key] = true
+	}
+	var extras []config.Setting
+	for _, setting := range m.cfg.Settings() {
+		before, _ := m.base.Get(setting.Key)
+		if !steps[setting.Key] && setting.Value != before {
+			extras = append(extras, setting)
 		}
-		m.step = 1
-		m.cursor = 0
-
-	case 1:
-		// On complete
-		switch m.cursor {
-		case 0:
-			m.onComplete = config.OnCompleteConfirm
-		case 1:
-			m.onComplete = config.OnCompleteAutoCommit
-		case 2:
-			m.onComplete = config.OnCompleteAutoMerge
-		case 3:
-			m.onComplete = config.OnCompleteAutoPR
+	}
+	return extras
+}
+
+// shownCount returns the number of shown steps, without the review.
+func (m *SetupWizard) shownCount() int {
+	count := 0
+	for i := range m.steps {
+		if m.shown(i) {
+			count++
 		}
-		m.done = true
-		return m, tea.Quit
 	}
+	return count
+}
 
-	return m, nil
+// position returns the 1-based position of the current page.
+func (m *SetupWizard) position() int {
+	pos := 1
+	for i := 0; i < m.step && i < len(m.steps); i++ {
+		if m.shown(i) {
+			pos++
+		}
+	}
+	return pos
 }
 
 // Result returns the setup result.
 func (m *SetupWizard) Result() SetupResult {
 	return SetupResult{
-		WorkMode:   m.workMode,
-		OnComplete: m.onComplete,
-		Cancelled:  m.cancelled,
+		Config:    m.cfg,
+		Preset:    m.preset,
+		Cancelled: m.cancelled,
 	}
 }
 
-// RunSetupWizard runs the setup wizard and returns the result.
-func RunSetupWizard(isGitRepo bool) (*SetupResult, error) {
-	m := NewSetupWizard(isGitRepo)
+// RunSetupWizard runs the setup wizard, prefilled from cfg, and returns the
+// result.
+func RunSetupWizard(cfg *config.Config, projectDir string, isGitRepo bool) (*SetupResult, error) {
+	m := NewSetupWizard(cfg, projectDir, isGitRepo)
 	p := tea.NewProgram(m)
 
 	finalModel, err := p.Run()
@@ -7,6 +7,9 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/donghojung/taw/internal/icon"
+	"github.com/donghojung/taw/internal/task"
 )
 
 // Spinner provides a loading spinner with message.
@@ -70,13 +73,17 @@ func (m *Spinner) View() string {
 	if m.done {
 		if m.err != nil {
 			style := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
-			return style.Render(fmt.Sprintf("✗ %s: %v\n", m.message, m.err))
+			if hint := task.Hint(m.err); hint != "" {
+				hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+				return style.Render(fmt.Sprintf("%s %s: %v\n", icon.Failure, m.message, m.err)) + hintStyle.Render(hint+"\n")
+			}
+			return style.Render(fmt.Sprintf("%s %s: %v\n", icon.Failure, m.message, m.err))
 		}
 		style := lipgloss.NewStyle().Foreground(lipgloss.Color("40"))
 		if m.result != "" {
-			return style.Render(fmt.Sprintf("✓ %s: %s\n", m.message, m.result))
+			return style.Render(fmt.Sprintf("%s %s: %s\n", icon.Success, m.message, m.result))
 		}
-		return style.Render(fmt.Sprintf("✓ %s\n", m.message))
+		return style.Render(fmt.Sprintf("%s %s\n", icon.Success, m.message))
 	}
 
 	spinnerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("39"))
@@ -178,12 +185,12 @@ func (s *SimpleSpinner) Stop(success bool, result string) {
 	close(s.done)
 
 	if success {
-		fmt.Printf("\r✓ %s", s.message)
+		fmt.Printf("\r%s %s", icon.Success, s.message)
 		if result != "" {
 			fmt.Printf(": %s", result)
 		}
 	} else {
-		fmt.Printf("\r✗ %s", s.message)
+		fmt.Printf("\r%s %s", icon.Failure, s.message)
 		if result != "" {
 			fmt.Printf(": %s", result)
 		}
@@ -0,0 +1,71 @@
+package taw
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/donghojung/taw/internal/task"
+)
+
+// QueuedTask is a task waiting in the queue.
+type QueuedTask struct {
+	Position int    // 1 starts next
+	Name     string // Empty if Claude names it when it starts
+	Content  string
+}
+
+// Queue is the queue of tasks waiting to start. Positions start at 1, the
+// next task to start, as in 'taw queue list'.
+type Queue struct {
+	q *task.QueueManager
+}
+
+// Queue returns the project's task queue.
+func (p *Project) Queue() *Queue {
+	return &Queue{q: task.NewQueueManager(p.app.QueueDir)}
+}
+
+// List returns the queued tasks in the order they start.
+func (q *Queue) List() ([]QueuedTask, error) {
+	tasks, err := q.q.List()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]QueuedTask, len(tasks))
+	for i, t := range tasks {
+		list[i] = QueuedTask{Position: i + 1, Name: t.Name, Content: t.Content}
+	}
+	return list, nil
+}
+
+// Add queues a task last, named name if it's a valid task name. Unlike
+// Project.CreateTask, it doesn't ask the session to start the queue.
+func (q *Queue) Add(content, name string) error {
+	if strings.TrimSpace(content) == "" {
+		return errors.New("task content is empty")
+	}
+	return q.q.AddNamed(content, name)
+}
+
+// Remove removes the task at a position and returns it.
+func (q *Queue) Remove(position int) (*QueuedTask, error) {
+	t, err := q.q.Remove(position)
+	if err != nil {
+		return nil, err
+	}
+	return &QueuedTask{Position: position, Name: t.Name, Content: t.Content}, nil
+}
+
+// Promote moves the task at a position to the front and returns it.
+func (q *Queue) Promote(position int) (*QueuedTask, error) {
+	t, err := q.q.Promote(position)
+	if err != nil {
+		return nil, err
+	}
+	return &QueuedTask{Position: 1, Name: t.Name, Content: t.Content}, nil
+}
+
+// Clear removes all queued tasks.
+func (q *Queue) Clear() error {
+	return q.q.Clear()
+}
@@ -0,0 +1,122 @@
+package taw
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/donghojung/taw/internal/task"
+)
+
+// Status is where a task stands.
+type Status string
+
+const (
+	StatusPending    Status = "pending"     // Created, its agent not started yet
+	StatusWorking    Status = "working"     // The agent is working
+	StatusWaiting    Status = "waiting"     // The agent waits for the user
+	StatusDone       Status = "done"        // The agent is done, the task can be ended
+	StatusCorrupted  Status = "corrupted"   // Its worktree or window needs recovery
+	StatusPushFailed Status = "push-failed" // Ending it failed to push its branch
+	StatusParked     Status = "parked"      // Pushed with a draft pull request on quit
+)
+
+// Task is a task of the project that was started: it has an agent directory,
+// and usually a window and a worktree.
+type Task struct {
+	Name      string
+	Content   string
+	Status    Status
+	Branch    string // Empty outside git projects
+	WorkDir   string // Where its agent works
+	PRNumber  int    // 0 without a pull request
+	Owner     string
+	CreatedAt time.Time
+}
+
+// newTask returns the API form of a task.
+func (p *Project) newTask(t *task.Task) Task {
+	workDir := p.app.ProjectDir
+	if t.WorktreeDir != "" {
+		workDir = t.WorktreeDir
+	}
+	var branch string
+	if p.app.IsGitRepo {
+		branch = t.GetBranch()
+	}
+	return Task{
+		Name:      t.Name,
+		Content:   t.Content,
+		Status:    Status(t.Status),
+		Branch:    branch,
+		WorkDir:   workDir,
+		PRNumber:  t.PRNumber,
+		Owner:     t.Owner,
+		CreatedAt: t.CreatedAt,
+	}
+}
+
+// CreateTask queues a task and, if the session runs, asks it to start the
+// queue. The task starts as the queue allows (max_parallel_tasks, rate
+// limits, budget, queue.drain). name is its name if valid, else Claude names
+// it from content like any queued task. ctx bounds the request to the
+// session; the task stays queued if it's cancelled.
+func (p *Project) CreateTask(ctx context.Context, content, name string) error {
+	if err := p.Queue().Add(content, name); err != nil {
+		return err
+	}
+	if err := p.send(ctx, "process-queue"); err != nil && !errors.Is(err, ErrNoSession) {
+		return err
+	}
+	return nil
+}
+
+// ListTasks returns the started tasks of the project. Queued tasks are
+// listed by Queue().List.
+func (p *Project) ListTasks() ([]Task, error) {
+	tasks, err := p.mgr.ListTasks()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Task, len(tasks))
+	for i, t := range tasks {
+		list[i] = p.newTask(t)
+	}
+	return list, nil
+}
+
+// Task returns a started task by name, or ErrTaskNotFound.
+func (p *Project) Task(name string) (*Task, error) {
+	t, err := p.mgr.GetTask(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrTaskNotFound, name)
+	}
+	api := p.newTask(t)
+	return &api, nil
+}
+
+// EndTask asks the session to end a task like 'taw end' does: verify,
+// commit, push, merge per on_complete (or always with merge), clean up and
+// close its window. It returns once the session accepted it; a task that
+// needs attention is kept open, with its status telling why. ctx bounds the
+// request to the session.
+func (p *Project) EndTask(ctx context.Context, name string, merge bool) error {
+	t, err := p.mgr.GetTask(name)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrTaskNotFound, name)
+	}
+	if merge && !p.app.IsGitRepo {
+		return fmt.Errorf("merge only works in git repositories")
+	}
+	taskID, err := t.EnsureID()
+	if err != nil {
+		return err
+	}
+
+	args := []string{taskID}
+	if merge {
+		args = append([]string{"--merge"}, args...)
+	}
+	return p.send(ctx, "end-task", args...)
+}
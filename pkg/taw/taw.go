@@ -0,0 +1,111 @@
+// Package taw is the Go API of TAW, for tools that run tasks in a TAW project
+// (bots, IDE servers) without shelling out to the taw command.
+//
+// A Project queues tasks, lists them and ends them. Tasks run in the
+// project's taw session, like tasks created from the command line: while no
+// session runs, created tasks wait in the queue until 'taw' starts one, and
+// tasks can't be ended.
+//
+//	project, err := taw.Open(ctx, "/path/to/project")
+//	if err != nil {
+//		return err
+//	}
+//	if err := project.CreateTask(ctx, "Fix the flaky login test", ""); err != nil {
+//		return err
+//	}
+package taw
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/donghojung/taw/internal/app"
+	"github.com/donghojung/taw/internal/config"
+	"github.com/donghojung/taw/internal/constants"
+	"github.com/donghojung/taw/internal/daemon"
+	"github.com/donghojung/taw/internal/git"
+	"github.com/donghojung/taw/internal/task"
+)
+
+var (
+	// ErrNoProject is returned by Open for a directory outside TAW projects.
+	ErrNoProject = errors.New("no TAW project found")
+
+	// ErrNoSession is returned for what needs the project's taw session
+	// while it isn't running.
+	ErrNoSession = errors.New("taw session not running")
+
+	// ErrTaskNotFound is returned for a task that doesn't exist.
+	ErrTaskNotFound = errors.New("task not found")
+)
+
+// Project is a TAW project: a directory with a .taw directory, created by
+// running taw in it once.
+type Project struct {
+	app *app.App
+	mgr *task.Manager
+}
+
+// Open returns the TAW project of dir or its closest parent with a .taw
+// directory, with its configuration.
+func Open(ctx context.Context, dir string) (*Project, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if info, err := os.Stat(filepath.Join(dir, constants.TawDirName)); err == nil && info.IsDir() {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, ErrNoProject
+		}
+		dir = parent
+	}
+
+	application, err := app.New(dir)
+	if err != nil {
+		return nil, err
+	}
+	application.SetGitRepo(git.New().IsGitRepo(ctx, dir))
+	if err := application.LoadConfig(); err != nil {
+		application.Config = config.DefaultConfig()
+	}
+
+	mgr := task.NewManager(application.AgentsDir, application.ProjectDir, application.TawDir, application.IsGitRepo, application.Config)
+	return &Project{app: application, mgr: mgr}, nil
+}
+
+// Dir returns the project directory.
+func (p *Project) Dir() string {
+	return p.app.ProjectDir
+}
+
+// SessionName returns the name of the project's tmux session.
+func (p *Project) SessionName() string {
+	return p.app.SessionName
+}
+
+// SessionRunning returns true if the project's taw session is running.
+func (p *Project) SessionRunning() bool {
+	return daemon.Ping(daemon.SocketPath(p.app.SessionName)) == nil
+}
+
+// send asks the session's daemon to run an internal job, giving up when ctx
+// is done.
+func (p *Project) send(ctx context.Context, job string, args ...string) error {
+	if err := daemon.SendContext(ctx, daemon.SocketPath(p.app.SessionName), job, args...); err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		if !p.SessionRunning() {
+			return ErrNoSession
+		}
+		return fmt.Errorf("failed to run %s: %w", job, err)
+	}
+	return nil
+}